@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"censys_alert_system/config"
+	"censys_alert_system/external"
+	"censys_alert_system/internal/archive"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+)
+
+func newArchiveService(cfg *config.Config, alertStorage service.AlertStorageInterface) (*service.ArchiveService, error) {
+	sink, err := archive.NewSink(cfg.ArchiveTarget, external.S3Config{
+		Endpoint:  cfg.ArchiveS3Endpoint,
+		Region:    cfg.ArchiveS3Region,
+		AccessKey: cfg.ArchiveS3AccessKey,
+		SecretKey: cfg.ArchiveS3SecretKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	store, err := archive.NewStore(sink, cfg.ArchiveFormat, cfg.ArchiveFileRows)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewArchiveService(alertStorage, store, 0), nil
+}
+
+// runArchiveCommand archives a partition or a time range on demand:
+//
+//	alert-service archive -partition alerts_p20250101
+//	alert-service archive -from 2025-01-01 -to 2025-02-01 [-severity low,medium] [-name jan-low]
+func runArchiveCommand(ctx context.Context, args []string, archiver *service.ArchiveService, partitions service.PartitionStorageInterface) error {
+	flags := flag.NewFlagSet("archive", flag.ContinueOnError)
+	partitionName := flags.String("partition", "", "archive every alert in this partition")
+	from := flags.String("from", "", "archive alerts created at or after this time (RFC 3339 or YYYY-MM-DD)")
+	to := flags.String("to", "", "archive alerts created before this time (RFC 3339 or YYYY-MM-DD)")
+	severities := flags.String("severity", "", "comma-separated severities to archive (default all)")
+	name := flags.String("name", "", "archive name (default the partition name, or derived from the range)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var filter models.AlertFilter
+	if *partitionName != "" {
+		all, err := partitions.ListAlertPartitions(ctx)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, p := range all {
+			if p.Name == *partitionName {
+				filter.From, filter.To, found = p.From, p.To, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("partition %q not found", *partitionName)
+		}
+		if *name == "" {
+			*name = *partitionName
+		}
+	} else {
+		var err error
+		if filter.From, err = parseCommandTime(*from); err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+		if filter.To, err = parseCommandTime(*to); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+		if filter.To.IsZero() {
+			return errors.New("-to or -partition is required")
+		}
+		if !filter.From.IsZero() && !filter.From.Before(filter.To) {
+			return errors.New("-from must be before -to")
+		}
+	}
+	if *severities != "" {
+		for _, severity := range strings.Split(*severities, ",") {
+			severity = strings.TrimSpace(severity)
+			if !service.IsValidSeverity(severity) {
+				return fmt.Errorf("invalid severity %q", severity)
+			}
+			filter.Severities = append(filter.Severities, severity)
+		}
+	}
+	if *name == "" {
+		*name = "range-" + filter.To.UTC().Format("20060102T150405Z")
+		if !filter.From.IsZero() {
+			*name = "range-" + filter.From.UTC().Format("20060102T150405Z") + "-" + filter.To.UTC().Format("20060102T150405Z")
+		}
+		if len(filter.Severities) > 0 {
+			*name += "-" + strings.Join(filter.Severities, "-")
+		}
+	}
+
+	manifest, err := archiver.ArchiveRange(ctx, *name, filter)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		log.Printf("No alerts to archive")
+		return nil
+	}
+	log.Printf("Archived %d alert(s) as %s", manifest.Rows, manifest.Name)
+	return nil
+}
+
+// runRestoreCommand loads an archive back into the database:
+//
+//	alert-service restore alerts_p20250101
+func runRestoreCommand(ctx context.Context, args []string, archiver *service.ArchiveService) error {
+	if len(args) != 1 {
+		return errors.New("usage: alert-service restore <archive name>")
+	}
+	restored, skipped, err := archiver.Restore(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	log.Printf("Restored %d alert(s) from %s, skipped %d already stored", restored, args[0], skipped)
+	return nil
+}
+
+// parseCommandTime parses an RFC 3339 time or a UTC date; empty is the zero time
+func parseCommandTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"censys_alert_system/internal/importer"
+	"censys_alert_system/internal/service"
+)
+
+// runImportCommand imports historical alerts from a CSV or NDJSON file, or
+// stdin, and prints the import report as JSON:
+//
+//	alert-service import [-dry-run] [-enrich] [-source ids] [-format csv] siem-export.csv
+//	alert-service import -format ndjson - < siem-export.ndjson
+func runImportCommand(ctx context.Context, args []string, imports *service.ImportService) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "csv or ndjson (default from the file extension)")
+	source := flags.String("source", "", "source of rows without one")
+	enrich := flags.Bool("enrich", false, "run imported alerts through enrichment")
+	dryRun := flags.Bool("dry-run", false, "validate and deduplicate without storing anything")
+	report := flags.String("report", "", "write the report to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: alert-service import [flags] <file, or - for stdin>")
+	}
+
+	name := flags.Arg(0)
+	var r io.Reader = os.Stdin
+	fileFormat, compressed, _ := importer.FileFormat(name)
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if *format == "" {
+		*format = fileFormat
+	}
+	if *format == "" {
+		return fmt.Errorf("cannot tell the format of %s: use -format csv or -format ndjson", name)
+	}
+
+	result, importErr := imports.Import(ctx, r, service.ImportOptions{
+		Format:        *format,
+		DefaultSource: *source,
+		Enrich:        *enrich,
+		DryRun:        *dryRun,
+	})
+
+	out := os.Stdout
+	if *report != "" {
+		f, err := os.Create(*report)
+		if err != nil {
+			return errors.Join(importErr, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return errors.Join(importErr, err)
+	}
+	if importErr != nil {
+		return importErr
+	}
+
+	verb := "Imported"
+	if *dryRun {
+		verb = "Would import"
+	}
+	log.Printf("%s %d alert(s) from %s: %d row(s), %d duplicate, %d rejected",
+		verb, result.Inserted, name, result.Rows, result.Duplicates, result.Rejected)
+	return nil
+}
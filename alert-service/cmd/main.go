@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -11,36 +14,285 @@ import (
 
 	"censys_alert_system/config"
 	"censys_alert_system/external"
+	"censys_alert_system/internal/connectors"
+	"censys_alert_system/internal/events"
 	"censys_alert_system/internal/handlers"
 	"censys_alert_system/internal/service"
+	"censys_alert_system/internal/spool"
 	"censys_alert_system/internal/storage"
+	"censys_alert_system/internal/syslog"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
 	cfg := config.LoadConfig()
 
 	log.Printf("Alert Service Configuration:")
-	log.Printf("  Database: %s@%s:%s/%s", cfg.DBUser, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	log.Printf("  Storage Backend: %s", cfg.StorageBackend)
+	switch cfg.StorageBackend {
+	case config.StorageBackendPostgres:
+		log.Printf("  Database: %s@%s:%s/%s", cfg.DBUser, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	case config.StorageBackendSQLite:
+		log.Printf("  SQLite Path: %s", cfg.SQLitePath)
+	}
 	log.Printf("  Mock API URL: %s", cfg.MockAPIURL)
 	log.Printf("  Sync Interval: %s", cfg.SyncInterval)
+	log.Printf("  Sync Look-back: %s", cfg.SyncLookback)
+	log.Printf("  Connectors File: %s", cfg.ConnectorsFile)
+	log.Printf("  Circuit Breaker: %d failures, %s cool-down", cfg.CircuitFailureThreshold, cfg.CircuitCoolDown)
+	log.Printf("  Dead Letters: %d attempts, %s-%s backoff", cfg.DeadLetterMaxAttempts, cfg.DeadLetterBaseDelay, cfg.DeadLetterMaxDelay)
+	log.Printf("  Paging Enabled: %t", cfg.PagerDutyRoutingKey != "")
+	log.Printf("  Syslog Enabled: %t", cfg.SyslogEnabled())
+	log.Printf("  Spool Directory: %s", cfg.SpoolDir)
+	log.Printf("  Migrate On Start: %t", cfg.MigrateOnStart)
+	log.Printf("  Archive Target: %s", cfg.ArchiveTarget)
+	log.Printf("  Export Directory: %s (jobs above %d rows)", cfg.ExportDir, cfg.ExportSyncMaxRows)
+
+	// db is nil unless alerts are stored in Postgres; features that keep
+	// their own tables are only available then
+	var db *sql.DB
+	var alertStorage service.AlertStorageInterface
+	var connectorStorage service.ConnectorStorageInterface
+	if cfg.StorageBackend == config.StorageBackendPostgres {
+		var err error
+		db, err = config.NewDB(cfg.GetDBConnectionString())
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		defer db.Close()
+
+		log.Println("Successfully connected to database")
+
+		migrator, err := newMigrator(db)
+		if err != nil {
+			log.Fatalf("Failed to load migrations: %v", err)
+		}
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			if err := migrator.RunCommand(context.Background(), os.Args[2:]); err != nil {
+				log.Fatalf("migrate failed: %v", err)
+			}
+			return
+		}
+		if err := migrator.Start(context.Background(), cfg.MigrateOnStart, cfg.SeedSampleData); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		alertStorage = storage.NewAlertStorage(db)
+		connectorStorage = storage.NewConnectorStorage(db)
+	} else {
+		if len(os.Args) > 1 && os.Args[1] != "import" {
+			log.Fatalf("%s needs STORAGE_BACKEND=%s", os.Args[1], config.StorageBackendPostgres)
+		}
+		local, localConnectors, closeStorage, err := newLocalAlertStorage(cfg)
+		if err != nil {
+			log.Fatalf("Failed to open alert storage: %v", err)
+		}
+		defer closeStorage()
+		alertStorage = local
+		connectorStorage = localConnectors
+		log.Printf("Storing alerts in %s; dead letters, backfill, re-enrichment, partitions, escalation and archival need postgres", cfg.StorageBackend)
+	}
 
-	db, err := config.NewDB(cfg.GetDBConnectionString())
+	connectorConfigs, err := cfg.Connectors()
+	if err != nil {
+		log.Fatalf("Failed to load connectors: %v", err)
+	}
+	upstreams, err := connectors.NewRegistry().Build(connectorConfigs)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Fatalf("Failed to build connectors: %v", err)
+	}
+	for _, c := range upstreams {
+		log.Printf("  Connector: %s (every %s)", c.Name, c.PollInterval)
+	}
+	mappers, err := connectors.LoadMappings(connectorConfigs)
+	if err != nil {
+		log.Fatalf("Failed to load connector mappings: %v", err)
+	}
+
+	alertService := service.NewAlertService(alertStorage, nil)
+	alertService.SetConnectors(upstreams)
+	alertService.SetConnectorStorage(connectorStorage)
+	alertService.SetSyncParallelism(cfg.SyncParallelism)
+	alertService.SetSyncBatchSize(cfg.SyncBatchSize)
+
+	var (
+		deadLetterService   *service.DeadLetterService
+		backfillService     *service.BackfillService
+		reenrichmentService *service.ReenrichmentService
+		partitionStorage    *storage.PartitionStorage
+		partitionService    *service.PartitionService
+		archiveService      *service.ArchiveService
+	)
+	if db != nil {
+		deadLetterService = service.NewDeadLetterService(
+			storage.NewDeadLetterStorage(db),
+			alertService,
+			service.DeadLetterPolicy{
+				MaxAttempts: cfg.DeadLetterMaxAttempts,
+				BaseDelay:   cfg.DeadLetterBaseDelay,
+				MaxDelay:    cfg.DeadLetterMaxDelay,
+			},
+		)
+		alertService.SetDeadLetters(deadLetterService)
+
+		backfillService = service.NewBackfillService(storage.NewBackfillStorage(db), alertService)
+		reenrichmentService = service.NewReenrichmentService(
+			storage.NewReenrichmentStorage(db),
+			alertService,
+			service.ReenrichmentPolicy{
+				Rate:      float64(cfg.ReenrichRate),
+				BatchSize: cfg.ReenrichBatchSize,
+			},
+		)
+
+		retention, err := service.ParseRetention(cfg.AlertRetention)
+		if err != nil {
+			log.Fatalf("Invalid ALERT_RETENTION: %v", err)
+		}
+		partitionStorage = storage.NewPartitionStorage(db)
+		partitionService, err = service.NewPartitionService(
+			partitionStorage,
+			service.PartitionPolicy{
+				Interval:  cfg.AlertPartitionInterval,
+				Premake:   cfg.AlertPartitionPremake,
+				Retention: retention,
+				Action:    cfg.AlertRetentionAction,
+			},
+		)
+		if err != nil {
+			log.Fatalf("Failed to configure alert partitions: %v", err)
+		}
+		backfillService.SetPartitions(partitionService)
+
+		if cfg.ArchiveTarget != "" {
+			archiveService, err = newArchiveService(cfg, alertStorage)
+			if err != nil {
+				log.Fatalf("Failed to configure archival: %v", err)
+			}
+			archiveService.SetPartitions(partitionService)
+			partitionService.SetArchiver(archiveService)
+		}
+	}
+
+	importService := service.NewImportService(alertStorage)
+	if partitionService != nil {
+		importService.SetPartitions(partitionService)
+	}
+
+	// One-off commands: import a file, archive a partition or range, or
+	// restore an archive. migrate is handled above, before the schema is
+	// brought up to date.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			if cfg.StorageBackend == config.StorageBackendMemory {
+				log.Printf("Warning: the memory backend keeps nothing once import exits; use -dry-run, or POST /alerts/import to a running service")
+			}
+			err = runImportCommand(context.Background(), os.Args[2:], importService)
+		case "archive", "restore":
+			if archiveService == nil {
+				log.Fatalf("%s needs ARCHIVE_TARGET to be set", os.Args[1])
+			}
+			if os.Args[1] == "archive" {
+				err = runArchiveCommand(context.Background(), os.Args[2:], archiveService, partitionStorage)
+			} else {
+				err = runRestoreCommand(context.Background(), os.Args[2:], archiveService)
+			}
+		default:
+			log.Fatalf("Unknown command %q: use migrate, import, archive or restore", os.Args[1])
+		}
+		if err != nil {
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	broker := events.NewBroker(cfg.StreamReplayBuffer, cfg.StreamClientQueue)
+	alertService.SetPublisher(broker)
+
+	var escalationService *service.EscalationService
+	if cfg.PagerDutyRoutingKey != "" {
+		pager := external.NewPagerDutyClient(cfg.PagerDutyEventsURL, cfg.PagerDutyRoutingKey)
+		alertService.SetPager(pager, service.PagerPolicy{
+			QueueSize: cfg.PagerQueueSize,
+			Timeout:   cfg.PagerTimeout,
+		})
+
+		// Escalation tracks pages in its own table
+		if db != nil {
+			tiers := []service.PagerInterface{pager}
+			for _, routingKey := range cfg.EscalationRoutingKeys {
+				tiers = append(tiers, external.NewPagerDutyClient(cfg.PagerDutyEventsURL, routingKey))
+			}
+			escalationService = service.NewEscalationService(
+				storage.NewEscalationStorage(db),
+				alertStorage,
+				tiers,
+				service.EscalationPolicy{
+					Timeout:          cfg.EscalationTimeout,
+					MaxNotifications: cfg.EscalationMaxNotifications,
+				},
+			)
+			alertService.SetEscalation(escalationService)
+		}
 	}
-	defer db.Close()
 
-	log.Println("Successfully connected to database")
+	// Exports keep their jobs on disk, so they work with every backend
+	exportService, err := service.NewExportService(alertStorage, service.ExportPolicy{
+		Dir:         cfg.ExportDir,
+		SyncMaxRows: cfg.ExportSyncMaxRows,
+		TTL:         cfg.ExportTTL,
+		Workers:     cfg.ExportWorkers,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure exports: %v", err)
+	}
 
-	alertStorage := storage.NewAlertStorage(db)
-	mockAPIClient := external.NewMockAPIClient(cfg.MockAPIURL)
-	alertService := service.NewAlertService(alertStorage, mockAPIClient)
 	alertHandler := handlers.NewAlertHandler(alertService)
+	streamHandler := handlers.NewStreamHandler(broker, cfg.StreamHeartbeat)
+	ingestHandler := handlers.NewIngestHandler(alertService, cfg.IngestSecrets)
+	mappingHandler := handlers.NewMappingHandler(mappers)
+	exportHandler := handlers.NewExportHandler(exportService)
+	importHandler := handlers.NewImportHandler(importService)
+	wsHandler := handlers.NewWebSocketHandler(broker, alertService, events.ParseSlowConsumerPolicy(cfg.WSSlowClientPolicy), cfg.WSAllowedOrigins)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/alerts", alertHandler.GetAlerts)
+	mux.HandleFunc("/alerts/stream", streamHandler.StreamAlerts)
+	mux.HandleFunc("GET /alerts/export", exportHandler.ExportAlerts)
+	mux.HandleFunc("POST /alerts/import", importHandler.ImportAlerts)
+	mux.HandleFunc("POST /alerts/{id}/acknowledge", alertHandler.AcknowledgeAlert)
+	mux.HandleFunc("POST /alerts/{id}/resolve", alertHandler.ResolveAlert)
 	mux.HandleFunc("/sync", alertHandler.TriggerSync)
-	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("/connectors", alertHandler.GetConnectors)
+	mux.HandleFunc("POST /ingest/{source}", ingestHandler.IngestAlerts)
+	mux.HandleFunc("POST /mappings/dry-run", mappingHandler.DryRun)
+	mux.HandleFunc("GET /exports", exportHandler.ListExports)
+	mux.HandleFunc("GET /exports/{id}", exportHandler.GetExport)
+	mux.HandleFunc("GET /exports/{id}/download", exportHandler.DownloadExport)
+	if db != nil {
+		deadLetterHandler := handlers.NewDeadLetterHandler(deadLetterService)
+		backfillHandler := handlers.NewBackfillHandler(backfillService)
+		reenrichmentHandler := handlers.NewReenrichmentHandler(reenrichmentService)
+		mux.HandleFunc("GET /dead-letters", deadLetterHandler.ListDeadLetters)
+		mux.HandleFunc("POST /dead-letters/retry", deadLetterHandler.RetryDeadLetters)
+		mux.HandleFunc("POST /dead-letters/{id}/retry", deadLetterHandler.RetryDeadLetter)
+		mux.HandleFunc("POST /backfill", backfillHandler.CreateBackfill)
+		mux.HandleFunc("GET /backfill", backfillHandler.ListBackfills)
+		mux.HandleFunc("GET /backfill/{id}", backfillHandler.GetBackfill)
+		mux.HandleFunc("POST /backfill/{id}/pause", backfillHandler.PauseBackfill)
+		mux.HandleFunc("POST /backfill/{id}/resume", backfillHandler.ResumeBackfill)
+		mux.HandleFunc("POST /backfill/{id}/cancel", backfillHandler.CancelBackfill)
+		mux.HandleFunc("POST /reenrichment", reenrichmentHandler.CreateReenrichment)
+		mux.HandleFunc("GET /reenrichment", reenrichmentHandler.ListReenrichments)
+		mux.HandleFunc("GET /reenrichment/{id}", reenrichmentHandler.GetReenrichment)
+		mux.HandleFunc("POST /reenrichment/{id}/pause", reenrichmentHandler.PauseReenrichment)
+		mux.HandleFunc("POST /reenrichment/{id}/resume", reenrichmentHandler.ResumeReenrichment)
+		mux.HandleFunc("POST /reenrichment/{id}/cancel", reenrichmentHandler.CancelReenrichment)
+	}
+	mux.HandleFunc("/ws", wsHandler.ServeWS)
+	mux.HandleFunc("/health", alertHandler.HealthHandler)
+	mux.Handle("/metrics", promhttp.Handler())
 
 	server := &http.Server{
 		Addr:         ":8080",
@@ -53,18 +305,97 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Partition maintenance and retention
+	if partitionService != nil {
+		go partitionService.Run(ctx, cfg.PartitionCheckInterval)
+	}
+
+	// Paging queue
+	go alertService.RunPager(ctx)
+
 	// Initial sync
 	go runSync(ctx, alertService, "STARTUP")
 
-	// Periodic sync
-	go startPeriodicSync(ctx, alertService, cfg.SyncInterval)
+	// Periodic sync; each connector runs on its own poll interval
+	go startPeriodicSync(ctx, alertService)
+
+	// Export workers and expiry
+	go exportService.Run(ctx, cfg.ExportCheckInterval)
+
+	// Escalation scheduler
+	if escalationService != nil {
+		go escalationService.Run(ctx, cfg.EscalationCheckInterval)
+	}
+
+	if db != nil {
+		// Dead-letter reprocessor
+		go deadLetterService.Run(ctx, cfg.DeadLetterCheckInterval)
+
+		// Backfill worker
+		go backfillService.Run(ctx, cfg.BackfillCheckInterval)
+
+		// Re-enrichment worker
+		go reenrichmentService.Run(ctx, cfg.ReenrichCheckInterval)
+	}
+
+	// Syslog receiver
+	if cfg.SyslogEnabled() {
+		syslogServer, err := newSyslogServer(cfg, alertService)
+		if err != nil {
+			log.Fatalf("Failed to configure syslog receiver: %v", err)
+		}
+		go func() {
+			if err := syslogServer.Run(ctx); err != nil {
+				log.Fatalf("Syslog receiver failed: %v", err)
+			}
+		}()
+	}
+
+	// File-drop ingestion
+	if cfg.SpoolDir != "" {
+		if cfg.SpoolDefaultSource != "" && !service.IsValidSource(cfg.SpoolDefaultSource) {
+			log.Fatalf("Invalid spool default source %q", cfg.SpoolDefaultSource)
+		}
+		watcher, err := spool.NewWatcher(spool.Config{
+			Dir:           cfg.SpoolDir,
+			PollInterval:  cfg.SpoolPollInterval,
+			SettleTime:    cfg.SpoolSettleTime,
+			BatchSize:     cfg.SpoolBatchSize,
+			DefaultSource: cfg.SpoolDefaultSource,
+		}, alertService)
+		if err != nil {
+			log.Fatalf("Failed to configure spool directory: %v", err)
+		}
+		go watcher.Run(ctx)
+	}
 
 	go func() {
 		log.Printf("Alert Service starting on http://localhost%s", server.Addr)
 		log.Printf("Endpoints:")
-		log.Printf("  GET  /alerts  - List alerts (optional: ?id=<uuid> or ?days=<int>)")
+		log.Printf("  GET  /alerts  - List alerts (optional: ?id=<uuid> or ?days=<int>, ?from=, ?to=, ?source=, ?severity=, ?status=, ?format=)")
+		log.Printf("  GET  /alerts/stream - Server-Sent Events stream of new alerts")
+		log.Printf("  GET  /alerts/export - Export alerts as CSV, NDJSON or XLSX (?format=, ?columns=, filters)")
+		log.Printf("  POST /alerts/import - Import historical alerts from CSV or NDJSON (?dry_run=, ?enrich=, ?source=)")
+		log.Printf("  POST /alerts/{id}/acknowledge - Acknowledge an alert")
+		log.Printf("  POST /alerts/{id}/resolve     - Resolve an alert")
 		log.Printf("  POST /sync    - Trigger manual sync")
+		log.Printf("  GET  /connectors - Upstream connector health")
+		log.Printf("  POST /ingest/{source} - Push alerts (JSON or NDJSON)")
+		log.Printf("  POST /mappings/dry-run - Preview a field mapping on a sample payload")
+		log.Printf("  GET  /exports - Export jobs and their progress (optional: ?status=)")
+		log.Printf("  GET  /exports/{id}/download - Download a completed export")
+		log.Printf("  GET  /dead-letters - Alerts that failed enrichment or storage")
+		log.Printf("  POST /dead-letters/{id}/retry - Retry one dead letter now")
+		log.Printf("  POST /dead-letters/retry      - Requeue matching dead letters")
+		log.Printf("  POST /backfill - Queue a backfill of a historical time range")
+		log.Printf("  GET  /backfill - Backfill jobs and their progress (optional: ?status=)")
+		log.Printf("  POST /backfill/{id}/pause|resume|cancel - Control a backfill job")
+		log.Printf("  POST /reenrichment - Queue re-enrichment of stored alerts")
+		log.Printf("  GET  /reenrichment - Re-enrichment jobs and their progress (optional: ?status=)")
+		log.Printf("  POST /reenrichment/{id}/pause|resume|cancel - Control a re-enrichment job")
+		log.Printf("  GET  /ws      - WebSocket subscription API")
 		log.Printf("  GET  /health  - Health check")
+		log.Printf("  GET  /metrics - Prometheus metrics")
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
@@ -88,9 +419,42 @@ func main() {
 	log.Println("Server exited gracefully")
 }
 
-func startPeriodicSync(ctx context.Context, alertService *service.AlertService, interval time.Duration) {
-	log.Printf("[SCHEDULER] Starting periodic sync every %s", interval)
-	ticker := time.NewTicker(interval)
+func newSyslogServer(cfg *config.Config, alertService *service.AlertService) (*syslog.Server, error) {
+	rules, err := syslog.ParseRules(cfg.SyslogSeverityRules, service.IsValidSeverity)
+	if err != nil {
+		return nil, err
+	}
+	if !service.IsValidSource(cfg.SyslogDefaultSource) {
+		return nil, fmt.Errorf("invalid syslog default source %q", cfg.SyslogDefaultSource)
+	}
+
+	syslogCfg := syslog.Config{
+		UDPAddr:       cfg.SyslogUDPAddr,
+		TCPAddr:       cfg.SyslogTCPAddr,
+		TLSAddr:       cfg.SyslogTLSAddr,
+		DefaultSource: cfg.SyslogDefaultSource,
+		Rules:         rules,
+	}
+	if cfg.SyslogTLSAddr != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SyslogTLSCertFile, cfg.SyslogTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading syslog TLS certificate: %w", err)
+		}
+		syslogCfg.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	return syslog.NewServer(syslogCfg, alertService), nil
+}
+
+// schedulerTick is how often the scheduler looks for connectors that are due
+const schedulerTick = time.Second
+
+func startPeriodicSync(ctx context.Context, alertService *service.AlertService) {
+	log.Printf("[SCHEDULER] Starting periodic sync")
+	ticker := time.NewTicker(schedulerTick)
 	defer ticker.Stop()
 
 	for {
@@ -99,7 +463,19 @@ func startPeriodicSync(ctx context.Context, alertService *service.AlertService,
 			log.Println("[SCHEDULER] Stopping periodic sync")
 			return
 		case <-ticker.C:
-			runSync(ctx, alertService, "SCHEDULED")
+			// Runs in the background so a slow connector does not delay the others;
+			// connectors that are still syncing are not due again
+			go func() {
+				syncCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+				defer cancel()
+
+				ran, err := alertService.SyncDue(syncCtx)
+				if err != nil {
+					log.Printf("[SCHEDULED] Sync failed: %v", err)
+				} else if ran > 0 {
+					log.Printf("[SCHEDULED] Synced %d connector(s)", ran)
+				}
+			}()
 		}
 	}
 }
@@ -116,14 +492,3 @@ func runSync(ctx context.Context, alertService *service.AlertService, source str
 		log.Printf("[%s] Sync completed successfully", source)
 	}
 }
-
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"ok"}`))
-}
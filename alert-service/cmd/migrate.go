@@ -0,0 +1,16 @@
+package main
+
+import (
+	"database/sql"
+
+	"censys_alert_system/migrations"
+	"migrate"
+)
+
+// migrationsTable tracks the alert service's migrations; the mock API,
+// which shares the database, keeps its own
+const migrationsTable = "schema_migrations"
+
+func newMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrationsTable, migrations.FS)
+}
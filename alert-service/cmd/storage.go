@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+
+	"censys_alert_system/config"
+	"censys_alert_system/internal/service"
+	"censys_alert_system/internal/storage"
+)
+
+// newLocalAlertStorage opens the alert storage of a backend that needs no
+// database server, and the connector state storage kept alongside it. The
+// returned function closes them.
+func newLocalAlertStorage(cfg *config.Config) (service.AlertStorageInterface, service.ConnectorStorageInterface, func(), error) {
+	switch cfg.StorageBackend {
+	case config.StorageBackendMemory:
+		return storage.NewMemoryAlertStorage(), storage.NewMemoryConnectorStorage(), func() {}, nil
+	case config.StorageBackendSQLite:
+		s, err := storage.NewSQLiteAlertStorage(cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return s, s.ConnectorStorage(), func() { s.Close() }, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown storage backend %q: use postgres, memory or sqlite", cfg.StorageBackend)
+	}
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultConnectorName is the connector built from MOCK_API_URL when no
+// connectors file is configured
+const DefaultConnectorName = "mock-api"
+
+// Duration is a time.Duration that reads from JSON as "30s", "5m", ...
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Credentials authenticate a connector against its upstream. At most one of
+// APIKey, BearerToken or Username/Password is expected.
+type Credentials struct {
+	APIKey       string `json:"api_key,omitempty"`
+	APIKeyHeader string `json:"api_key_header,omitempty"`
+	BearerToken  string `json:"bearer_token,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+}
+
+// RetryConfig controls retries of a connector's upstream calls
+type RetryConfig struct {
+	MaxRetries int      `json:"max_retries"`
+	WaitMin    Duration `json:"wait_min"`
+	WaitMax    Duration `json:"wait_max"`
+}
+
+// BreakerConfig controls the circuit breaker around a connector's upstream.
+// Zero values fall back to CIRCUIT_FAILURE_THRESHOLD and CIRCUIT_COOL_DOWN.
+type BreakerConfig struct {
+	FailureThreshold int      `json:"failure_threshold"`
+	CoolDown         Duration `json:"cool_down"`
+}
+
+// RateLimitConfig caps the request rate to a connector's upstream. Zero
+// values use the client defaults.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+}
+
+// ConnectorConfig describes one upstream source
+type ConnectorConfig struct {
+	Name         string      `json:"name"`
+	Type         string      `json:"type"`
+	URL          string      `json:"url"`
+	Credentials  Credentials `json:"credentials"`
+	PollInterval Duration    `json:"poll_interval"`
+	Retry        RetryConfig `json:"retry"`
+
+	CircuitBreaker BreakerConfig   `json:"circuit_breaker"`
+	RateLimit      RateLimitConfig `json:"rate_limit"`
+
+	// PageSize is the number of alerts requested per page; the upstream decides when zero
+	PageSize int `json:"page_size,omitempty"`
+
+	// Lookback is how far behind the watermark incremental syncs re-fetch to
+	// catch late arrivals. Zero falls back to SYNC_LOOKBACK.
+	Lookback Duration `json:"lookback,omitempty"`
+
+	// FieldMapping renames upstream fields to alert fields (alert field -> upstream field).
+	// It is shorthand for a mapping spec with plain paths.
+	FieldMapping map[string]string `json:"field_mapping,omitempty"`
+
+	// Mapping is an inline mapping spec; MappingFile points to a YAML or JSON
+	// spec, relative to the connectors file. At most one mapping may be set.
+	Mapping     json.RawMessage `json:"mapping,omitempty"`
+	MappingFile string          `json:"mapping_file,omitempty"`
+}
+
+// defaultRetry matches the retry policy of the original single upstream client
+var defaultRetry = RetryConfig{
+	MaxRetries: 3,
+	WaitMin:    Duration(1 * time.Second),
+	WaitMax:    Duration(30 * time.Second),
+}
+
+// Connectors returns the configured upstream connectors. Without a
+// CONNECTORS_FILE, a single mock API connector is built from MOCK_API_URL.
+func (c *Config) Connectors() ([]ConnectorConfig, error) {
+	var connectors []ConnectorConfig
+	if c.ConnectorsFile == "" {
+		connectors = []ConnectorConfig{{
+			Name:         DefaultConnectorName,
+			Type:         "mock-api",
+			URL:          c.MockAPIURL,
+			PollInterval: Duration(c.SyncInterval),
+			Retry:        defaultRetry,
+		}}
+	} else {
+		var err error
+		connectors, err = LoadConnectors(c.ConnectorsFile, c.SyncInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range connectors {
+		breaker := &connectors[i].CircuitBreaker
+		if breaker.FailureThreshold == 0 {
+			breaker.FailureThreshold = c.CircuitFailureThreshold
+		}
+		if breaker.CoolDown == 0 {
+			breaker.CoolDown = Duration(c.CircuitCoolDown)
+		}
+		if connectors[i].Lookback == 0 {
+			connectors[i].Lookback = Duration(c.SyncLookback)
+		}
+	}
+	return connectors, nil
+}
+
+// LoadConnectors reads a JSON list of connectors, filling in defaults for the
+// poll interval and retry policy
+func LoadConnectors(path string, defaultInterval time.Duration) ([]ConnectorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading connectors file: %w", err)
+	}
+
+	var file struct {
+		Connectors []ConnectorConfig `json:"connectors"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing connectors file: %w", err)
+	}
+
+	for i := range file.Connectors {
+		if mappingFile := file.Connectors[i].MappingFile; mappingFile != "" && !filepath.IsAbs(mappingFile) {
+			file.Connectors[i].MappingFile = filepath.Join(filepath.Dir(path), mappingFile)
+		}
+	}
+
+	return normalizeConnectors(file.Connectors, defaultInterval)
+}
+
+func normalizeConnectors(connectors []ConnectorConfig, defaultInterval time.Duration) ([]ConnectorConfig, error) {
+	if len(connectors) == 0 {
+		return nil, fmt.Errorf("no connectors configured")
+	}
+
+	seen := make(map[string]bool)
+	for i := range connectors {
+		conn := &connectors[i]
+		if conn.Name == "" {
+			return nil, fmt.Errorf("connector %d: name is required", i)
+		}
+		if seen[conn.Name] {
+			return nil, fmt.Errorf("connector %q: duplicate name", conn.Name)
+		}
+		seen[conn.Name] = true
+
+		if conn.Type == "" {
+			return nil, fmt.Errorf("connector %q: type is required", conn.Name)
+		}
+		if conn.URL == "" {
+			return nil, fmt.Errorf("connector %q: url is required", conn.Name)
+		}
+		mappings := 0
+		for _, set := range []bool{len(conn.FieldMapping) > 0, len(conn.Mapping) > 0, conn.MappingFile != ""} {
+			if set {
+				mappings++
+			}
+		}
+		if mappings > 1 {
+			return nil, fmt.Errorf("connector %q: set only one of field_mapping, mapping and mapping_file", conn.Name)
+		}
+		if conn.PollInterval <= 0 {
+			conn.PollInterval = Duration(defaultInterval)
+		}
+		if conn.Retry == (RetryConfig{}) {
+			conn.Retry = defaultRetry
+		}
+		if conn.Retry.MaxRetries < 0 || conn.Retry.WaitMin < 0 || conn.Retry.WaitMax < conn.Retry.WaitMin {
+			return nil, fmt.Errorf("connector %q: invalid retry policy", conn.Name)
+		}
+		if conn.CircuitBreaker.FailureThreshold < 0 || conn.CircuitBreaker.CoolDown < 0 {
+			return nil, fmt.Errorf("connector %q: invalid circuit breaker", conn.Name)
+		}
+		if conn.RateLimit.RequestsPerSecond < 0 || conn.RateLimit.Burst < 0 {
+			return nil, fmt.Errorf("connector %q: invalid rate limit", conn.Name)
+		}
+		if conn.Lookback < 0 {
+			return nil, fmt.Errorf("connector %q: lookback must not be negative", conn.Name)
+		}
+		if conn.PageSize < 0 {
+			return nil, fmt.Errorf("connector %q: invalid page size", conn.Name)
+		}
+	}
+
+	return connectors, nil
+}
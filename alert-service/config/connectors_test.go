@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConnectorsFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "connectors.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConnectors(t *testing.T) {
+	t.Run("fills defaults", func(t *testing.T) {
+		path := writeConnectorsFile(t, `{"connectors": [
+			{"name": "primary", "type": "mock-api", "url": "http://mock-api:8081"},
+			{"name": "siem", "type": "mock-api", "url": "http://siem", "poll_interval": "5m",
+			 "credentials": {"bearer_token": "t"}, "retry": {"max_retries": 5, "wait_min": "2s", "wait_max": "1m"},
+			 "rate_limit": {"requests_per_second": 2.5, "burst": 5},
+			 "field_mapping": {"severity": "sev"}}
+		]}`)
+
+		connectors, err := LoadConnectors(path, time.Minute)
+
+		require.NoError(t, err)
+		require.Len(t, connectors, 2)
+		assert.Equal(t, Duration(time.Minute), connectors[0].PollInterval)
+		assert.Equal(t, defaultRetry, connectors[0].Retry)
+		assert.Equal(t, Duration(5*time.Minute), connectors[1].PollInterval)
+		assert.Equal(t, RetryConfig{MaxRetries: 5, WaitMin: Duration(2 * time.Second), WaitMax: Duration(time.Minute)}, connectors[1].Retry)
+		assert.Equal(t, "t", connectors[1].Credentials.BearerToken)
+		assert.Equal(t, map[string]string{"severity": "sev"}, connectors[1].FieldMapping)
+		assert.Equal(t, RateLimitConfig{RequestsPerSecond: 2.5, Burst: 5}, connectors[1].RateLimit)
+	})
+
+	t.Run("resolves mapping files next to the connectors file", func(t *testing.T) {
+		path := writeConnectorsFile(t, `{"connectors": [
+			{"name": "a", "type": "mock-api", "url": "http://x", "mapping_file": "mappings/a.yaml"},
+			{"name": "b", "type": "mock-api", "url": "http://y", "mapping_file": "/etc/b.yaml"}
+		]}`)
+
+		connectors, err := LoadConnectors(path, time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(filepath.Dir(path), "mappings", "a.yaml"), connectors[0].MappingFile)
+		assert.Equal(t, "/etc/b.yaml", connectors[1].MappingFile)
+	})
+
+	t.Run("rejects invalid files", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"empty":          `{"connectors": []}`,
+			"missing name":   `{"connectors": [{"type": "mock-api", "url": "http://x"}]}`,
+			"duplicate name": `{"connectors": [{"name": "a", "type": "mock-api", "url": "http://x"}, {"name": "a", "type": "mock-api", "url": "http://y"}]}`,
+			"missing url":    `{"connectors": [{"name": "a", "type": "mock-api"}]}`,
+			"bad duration":   `{"connectors": [{"name": "a", "type": "mock-api", "url": "http://x", "poll_interval": "soon"}]}`,
+			"bad retry":      `{"connectors": [{"name": "a", "type": "mock-api", "url": "http://x", "retry": {"wait_min": "1m", "wait_max": "1s"}}]}`,
+			"two mappings":   `{"connectors": [{"name": "a", "type": "mock-api", "url": "http://x", "field_mapping": {"severity": "sev"}, "mapping_file": "a.yaml"}]}`,
+			"bad page size":  `{"connectors": [{"name": "a", "type": "mock-api", "url": "http://x", "page_size": -5}]}`,
+			"bad rate limit": `{"connectors": [{"name": "a", "type": "mock-api", "url": "http://x", "rate_limit": {"requests_per_second": -1}}]}`,
+			"bad breaker":    `{"connectors": [{"name": "a", "type": "mock-api", "url": "http://x", "circuit_breaker": {"failure_threshold": -1}}]}`,
+		} {
+			_, err := LoadConnectors(writeConnectorsFile(t, content), time.Minute)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestConfig_ConnectorsDefaultsToMockAPI(t *testing.T) {
+	cfg := &Config{MockAPIURL: "http://mock-api:8081", SyncInterval: 30 * time.Second}
+
+	connectors, err := cfg.Connectors()
+
+	require.NoError(t, err)
+	require.Len(t, connectors, 1)
+	assert.Equal(t, DefaultConnectorName, connectors[0].Name)
+	assert.Equal(t, "http://mock-api:8081", connectors[0].URL)
+	assert.Equal(t, Duration(30*time.Second), connectors[0].PollInterval)
+}
+
+func TestConfig_ConnectorsCircuitBreakerDefaults(t *testing.T) {
+	path := writeConnectorsFile(t, `{"connectors": [
+		{"name": "a", "type": "mock-api", "url": "http://x"},
+		{"name": "b", "type": "mock-api", "url": "http://y", "circuit_breaker": {"failure_threshold": 10, "cool_down": "30s"}}
+	]}`)
+	cfg := &Config{ConnectorsFile: path, SyncInterval: time.Minute, CircuitFailureThreshold: 3, CircuitCoolDown: 2 * time.Minute}
+
+	connectors, err := cfg.Connectors()
+
+	require.NoError(t, err)
+	assert.Equal(t, BreakerConfig{FailureThreshold: 3, CoolDown: Duration(2 * time.Minute)}, connectors[0].CircuitBreaker)
+	assert.Equal(t, BreakerConfig{FailureThreshold: 10, CoolDown: Duration(30 * time.Second)}, connectors[1].CircuitBreaker)
+}
+
+func TestConfig_ConnectorsLookback(t *testing.T) {
+	path := writeConnectorsFile(t, `{"connectors": [
+		{"name": "a", "type": "mock-api", "url": "http://x"},
+		{"name": "b", "type": "mock-api", "url": "http://y", "lookback": "15m"}
+	]}`)
+	cfg := &Config{ConnectorsFile: path, SyncInterval: time.Minute, SyncLookback: 5 * time.Minute}
+
+	connectors, err := cfg.Connectors()
+
+	require.NoError(t, err)
+	assert.Equal(t, Duration(5*time.Minute), connectors[0].Lookback)
+	assert.Equal(t, Duration(15*time.Minute), connectors[1].Lookback)
+
+	_, err = LoadConnectors(writeConnectorsFile(t, `{"connectors": [{"name": "a", "type": "mock-api", "url": "http://x", "lookback": "-1m"}]}`), time.Minute)
+	assert.ErrorContains(t, err, "lookback must not be negative")
+}
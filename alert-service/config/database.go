@@ -4,11 +4,20 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
+// Storage backends selected by STORAGE_BACKEND
+const (
+	StorageBackendPostgres = "postgres"
+	StorageBackendMemory   = "memory"
+	StorageBackendSQLite   = "sqlite"
+)
+
 type Config struct {
 	DBHost       string
 	DBPort       string
@@ -17,8 +26,117 @@ type Config struct {
 	DBName       string
 	MockAPIURL   string
 	SyncInterval time.Duration
+
+	// StorageBackend is where alerts are stored: postgres, memory or sqlite.
+	// Features that keep their own tables need postgres.
+	StorageBackend string
+	// SQLitePath is the database file of the sqlite backend
+	SQLitePath string
+
+	// MigrateOnStart applies pending schema migrations at startup
+	MigrateOnStart bool
+	// SeedSampleData loads the sample alerts into an empty database at startup
+	SeedSampleData bool
+
+	// ConnectorsFile lists upstream connectors; MockAPIURL is used when empty
+	ConnectorsFile  string
+	SyncParallelism int
+	// SyncBatchSize is how many synced alerts are inserted per transaction
+	SyncBatchSize int
+	// SyncLookback is the default look-back overlap for connectors that do not set their own
+	SyncLookback time.Duration
+
+	// Circuit breaker defaults for connectors that do not set their own
+	CircuitFailureThreshold int
+	CircuitCoolDown         time.Duration
+
+	// PagerDutyRoutingKey enables paging for critical alerts when set
+	PagerDutyRoutingKey string
+	PagerDutyEventsURL  string
+	// PagerQueueSize and PagerTimeout bound the background paging queue
+	PagerQueueSize int
+	PagerTimeout   time.Duration
+
+	// EscalationRoutingKeys are the routing keys for escalation tiers after the initial page
+	EscalationRoutingKeys      []string
+	EscalationTimeout          time.Duration
+	EscalationMaxNotifications int
+	EscalationCheckInterval    time.Duration
+
+	// Dead-letter retries of synced alerts that failed enrichment or storage
+	DeadLetterMaxAttempts   int
+	DeadLetterBaseDelay     time.Duration
+	DeadLetterMaxDelay      time.Duration
+	DeadLetterCheckInterval time.Duration
+
+	// BackfillCheckInterval is how often the backfill worker looks for queued jobs
+	BackfillCheckInterval time.Duration
+
+	// Re-enrichment throttling: alerts per second and alerts per checkpoint
+	ReenrichRate          int
+	ReenrichBatchSize     int
+	ReenrichCheckInterval time.Duration
+
+	// Alert partitions are "day" or "month"; AlertPartitionPremake are kept ready ahead
+	AlertPartitionInterval string
+	AlertPartitionPremake  int
+	// AlertRetention maps severities to days kept; unlisted severities are kept forever
+	AlertRetention map[string]string
+	// AlertRetentionAction is "drop" or "detach" for partitions past retention
+	AlertRetentionAction   string
+	PartitionCheckInterval time.Duration
+
+	// ArchiveTarget is a directory or s3://bucket/prefix alerts are archived
+	// to before retention removes them; empty disables archival
+	ArchiveTarget      string
+	ArchiveFormat      string
+	ArchiveFileRows    int
+	ArchiveS3Endpoint  string
+	ArchiveS3Region    string
+	ArchiveS3AccessKey string
+	ArchiveS3SecretKey string
+
+	// ExportDir holds export job files; exports larger than
+	// ExportSyncMaxRows run as jobs
+	ExportDir           string
+	ExportSyncMaxRows   int
+	ExportTTL           time.Duration
+	ExportWorkers       int
+	ExportCheckInterval time.Duration
+
+	StreamReplayBuffer int
+	StreamClientQueue  int
+	StreamHeartbeat    time.Duration
+
+	// WSSlowClientPolicy is "drop" (skip events for a full queue) or "disconnect"
+	WSSlowClientPolicy string
+	WSAllowedOrigins   []string
+
+	// IngestSecrets maps each push source to its shared secret
+	IngestSecrets map[string]string
+
+	// Syslog listeners are disabled when their address is empty
+	SyslogUDPAddr       string
+	SyslogTCPAddr       string
+	SyslogTLSAddr       string
+	SyslogTLSCertFile   string
+	SyslogTLSKeyFile    string
+	SyslogDefaultSource string
+	// SyslogSeverityRules maps facility/severity onto alert severities, first match wins
+	SyslogSeverityRules string
+
+	// SpoolDir is watched for dropped alert files; empty disables file ingestion
+	SpoolDir           string
+	SpoolPollInterval  time.Duration
+	SpoolSettleTime    time.Duration
+	SpoolBatchSize     int
+	SpoolDefaultSource string
 }
 
+// syslogDefaultRules maps emerg..crit to critical, err to high, warning to
+// medium and everything else to low, regardless of facility
+const syslogDefaultRules = "*:emerg-crit=critical,*:err=high,*:warning=medium,*:notice-debug=low"
+
 func LoadConfig() *Config {
 	return &Config{
 		DBHost:       getEnv("DB_HOST", "localhost"),
@@ -28,6 +146,84 @@ func LoadConfig() *Config {
 		DBName:       getEnv("DB_NAME", "alerts_db"),
 		MockAPIURL:   getEnv("MOCK_API_URL", "http://localhost:8081"),
 		SyncInterval: parseDuration(getEnv("SYNC_INTERVAL", "60s"), 60*time.Second),
+
+		StorageBackend: getEnv("STORAGE_BACKEND", StorageBackendPostgres),
+		SQLitePath:     getEnv("SQLITE_PATH", "alerts.db"),
+
+		MigrateOnStart: parseBool(getEnv("MIGRATE_ON_START", "true"), true),
+		SeedSampleData: parseBool(getEnv("SEED_SAMPLE_DATA", "false"), false),
+
+		ConnectorsFile:  getEnv("CONNECTORS_FILE", ""),
+		SyncParallelism: parseInt(getEnv("SYNC_PARALLELISM", "4"), 4),
+		SyncBatchSize:   parseInt(getEnv("SYNC_BATCH_SIZE", "500"), 500),
+		SyncLookback:    parseDuration(getEnv("SYNC_LOOKBACK", "5m"), 5*time.Minute),
+
+		CircuitFailureThreshold: parseInt(getEnv("CIRCUIT_FAILURE_THRESHOLD", "3"), 3),
+		CircuitCoolDown:         parseDuration(getEnv("CIRCUIT_COOL_DOWN", "2m"), 2*time.Minute),
+
+		PagerDutyRoutingKey: getEnv("PAGERDUTY_ROUTING_KEY", ""),
+		PagerDutyEventsURL:  getEnv("PAGERDUTY_EVENTS_URL", "https://events.pagerduty.com/v2/enqueue"),
+		PagerQueueSize:      parseInt(getEnv("PAGER_QUEUE_SIZE", "1000"), 1000),
+		PagerTimeout:        parseDuration(getEnv("PAGER_TIMEOUT", "30s"), 30*time.Second),
+
+		EscalationRoutingKeys:      parseList(getEnv("ESCALATION_ROUTING_KEYS", "")),
+		EscalationTimeout:          parseDuration(getEnv("ESCALATION_TIMEOUT", "15m"), 15*time.Minute),
+		EscalationMaxNotifications: parseInt(getEnv("ESCALATION_MAX_NOTIFICATIONS", "3"), 3),
+		EscalationCheckInterval:    parseDuration(getEnv("ESCALATION_CHECK_INTERVAL", "30s"), 30*time.Second),
+
+		DeadLetterMaxAttempts:   parseInt(getEnv("DEAD_LETTER_MAX_ATTEMPTS", "5"), 5),
+		DeadLetterBaseDelay:     parseDuration(getEnv("DEAD_LETTER_BASE_DELAY", "1m"), time.Minute),
+		DeadLetterMaxDelay:      parseDuration(getEnv("DEAD_LETTER_MAX_DELAY", "1h"), time.Hour),
+		DeadLetterCheckInterval: parseDuration(getEnv("DEAD_LETTER_CHECK_INTERVAL", "30s"), 30*time.Second),
+
+		BackfillCheckInterval: parseDuration(getEnv("BACKFILL_CHECK_INTERVAL", "10s"), 10*time.Second),
+
+		ReenrichRate:          parseInt(getEnv("REENRICH_RATE", "50"), 50),
+		ReenrichBatchSize:     parseInt(getEnv("REENRICH_BATCH_SIZE", "100"), 100),
+		ReenrichCheckInterval: parseDuration(getEnv("REENRICH_CHECK_INTERVAL", "10s"), 10*time.Second),
+
+		AlertPartitionInterval: getEnv("ALERT_PARTITION_INTERVAL", "month"),
+		AlertPartitionPremake:  parseInt(getEnv("ALERT_PARTITION_PREMAKE", "3"), 3),
+		AlertRetention:         parseMap(getEnv("ALERT_RETENTION", "")),
+		AlertRetentionAction:   getEnv("ALERT_RETENTION_ACTION", "drop"),
+		PartitionCheckInterval: parseDuration(getEnv("PARTITION_CHECK_INTERVAL", "1h"), time.Hour),
+
+		ArchiveTarget:      getEnv("ARCHIVE_TARGET", ""),
+		ArchiveFormat:      getEnv("ARCHIVE_FORMAT", "ndjson"),
+		ArchiveFileRows:    parseInt(getEnv("ARCHIVE_FILE_ROWS", "100000"), 100000),
+		ArchiveS3Endpoint:  getEnv("ARCHIVE_S3_ENDPOINT", ""),
+		ArchiveS3Region:    getEnv("ARCHIVE_S3_REGION", "us-east-1"),
+		ArchiveS3AccessKey: getEnv("ARCHIVE_S3_ACCESS_KEY", ""),
+		ArchiveS3SecretKey: getEnv("ARCHIVE_S3_SECRET_KEY", ""),
+
+		ExportDir:           getEnv("EXPORT_DIR", "exports"),
+		ExportSyncMaxRows:   parseInt(getEnv("EXPORT_SYNC_MAX_ROWS", "100000"), 100000),
+		ExportTTL:           parseDuration(getEnv("EXPORT_TTL", "24h"), 24*time.Hour),
+		ExportWorkers:       parseInt(getEnv("EXPORT_WORKERS", "2"), 2),
+		ExportCheckInterval: parseDuration(getEnv("EXPORT_CHECK_INTERVAL", "1m"), time.Minute),
+
+		StreamReplayBuffer: parseInt(getEnv("STREAM_REPLAY_BUFFER", "1000"), 1000),
+		StreamClientQueue:  parseInt(getEnv("STREAM_CLIENT_QUEUE", "256"), 256),
+		StreamHeartbeat:    parseDuration(getEnv("STREAM_HEARTBEAT", "15s"), 15*time.Second),
+
+		WSSlowClientPolicy: getEnv("WS_SLOW_CLIENT_POLICY", "drop"),
+		WSAllowedOrigins:   parseList(getEnv("WS_ALLOWED_ORIGINS", "")),
+
+		IngestSecrets: parseMap(getEnv("INGEST_SECRETS", "")),
+
+		SyslogUDPAddr:       getEnv("SYSLOG_UDP_ADDR", ""),
+		SyslogTCPAddr:       getEnv("SYSLOG_TCP_ADDR", ""),
+		SyslogTLSAddr:       getEnv("SYSLOG_TLS_ADDR", ""),
+		SyslogTLSCertFile:   getEnv("SYSLOG_TLS_CERT", ""),
+		SyslogTLSKeyFile:    getEnv("SYSLOG_TLS_KEY", ""),
+		SyslogDefaultSource: getEnv("SYSLOG_DEFAULT_SOURCE", "network-monitor"),
+		SyslogSeverityRules: getEnv("SYSLOG_SEVERITY_RULES", syslogDefaultRules),
+
+		SpoolDir:           getEnv("SPOOL_DIR", ""),
+		SpoolPollInterval:  parseDuration(getEnv("SPOOL_POLL_INTERVAL", "5s"), 5*time.Second),
+		SpoolSettleTime:    parseDuration(getEnv("SPOOL_SETTLE_TIME", "2s"), 2*time.Second),
+		SpoolBatchSize:     parseInt(getEnv("SPOOL_BATCH_SIZE", "500"), 500),
+		SpoolDefaultSource: getEnv("SPOOL_DEFAULT_SOURCE", ""),
 	}
 }
 
@@ -46,6 +242,50 @@ func parseDuration(value string, defaultValue time.Duration) time.Duration {
 	return d
 }
 
+func parseInt(value string, defaultValue int) int {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+func parseBool(value string, defaultValue bool) bool {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return b
+}
+
+// parseList splits a comma-separated value, dropping empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseMap parses comma-separated key=value pairs, dropping malformed entries
+func parseMap(value string) map[string]string {
+	items := make(map[string]string)
+	for _, item := range parseList(value) {
+		key, val, ok := strings.Cut(item, "=")
+		if ok && strings.TrimSpace(key) != "" {
+			items[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+	}
+	return items
+}
+
+// SyslogEnabled reports whether any syslog listener is configured
+func (c *Config) SyslogEnabled() bool {
+	return c.SyslogUDPAddr != "" || c.SyslogTCPAddr != "" || c.SyslogTLSAddr != ""
+}
+
 func (c *Config) GetDBConnectionString() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
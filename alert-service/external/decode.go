@@ -0,0 +1,157 @@
+package external
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+)
+
+// DefaultDecodeBatch is how many decoded alerts are handed over at a time
+const DefaultDecodeBatch = 500
+
+// NDJSON media types accepted from upstreams
+var ndjsonMediaTypes = map[string]bool{
+	"application/x-ndjson": true,
+	"application/ndjson":   true,
+	"application/jsonl":    true,
+}
+
+// acceptHeader asks for NDJSON, which streams without an enclosing document,
+// and falls back to JSON
+const acceptHeader = "application/x-ndjson, application/json;q=0.9"
+
+// Pagination headers of NDJSON responses, which have no envelope for next_cursor and has_more
+const (
+	headerNextCursor = "X-Next-Cursor"
+	headerHasMore    = "X-Has-More"
+)
+
+// errDecode marks malformed response bodies, as opposed to errors returned by the page handler
+var errDecode = errors.New("error decoding response")
+
+func decodeError(err error) error {
+	return fmt.Errorf("%w: %v", errDecode, err)
+}
+
+// isNDJSON reports whether a Content-Type header names an NDJSON body
+func isNDJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && ndjsonMediaTypes[mediaType]
+}
+
+// decodeJSONAlerts stream-decodes {"alerts": [...], "next_cursor": ..., "has_more": ...}
+// or a bare array of alerts without holding the whole body. Every full batch
+// of alerts is passed to emit as soon as it is decoded; the remainder is
+// returned with the pagination fields, which may follow the alerts.
+func decodeJSONAlerts(r io.Reader, batchSize int, emit func([]ExternalAlert) error) ([]ExternalAlert, pageInfo, error) {
+	var info pageInfo
+	decoder := json.NewDecoder(r)
+
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, info, decodeError(err)
+	}
+	switch tok {
+	case json.Delim('['):
+		// A bare array has no further pages
+		rest, err := decodeAlertArray(decoder, batchSize, emit)
+		return rest, info, err
+	case json.Delim('{'):
+	default:
+		return nil, info, decodeError(fmt.Errorf("expected an object or an array, got %v", tok))
+	}
+
+	var rest []ExternalAlert
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, info, decodeError(err)
+		}
+
+		switch key, _ := tok.(string); key {
+		case "alerts":
+			tok, err := decoder.Token()
+			if err != nil {
+				return nil, info, decodeError(err)
+			}
+			if tok == nil {
+				continue
+			}
+			if tok != json.Delim('[') {
+				return nil, info, decodeError(fmt.Errorf("alerts must be an array"))
+			}
+			if rest, err = decodeAlertArray(decoder, batchSize, emit); err != nil {
+				return nil, info, err
+			}
+		case "next_cursor":
+			err = decoder.Decode(&info.NextCursor)
+		case "has_more":
+			err = decoder.Decode(&info.HasMore)
+		default:
+			var skip json.RawMessage
+			err = decoder.Decode(&skip)
+		}
+		if err != nil {
+			return nil, info, decodeError(err)
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, info, decodeError(err)
+	}
+	return rest, info, nil
+}
+
+// decodeAlertArray decodes the elements of an array whose opening bracket
+// was already read, emitting full batches and returning the remainder
+func decodeAlertArray(decoder *json.Decoder, batchSize int, emit func([]ExternalAlert) error) ([]ExternalAlert, error) {
+	var batch []ExternalAlert
+	for decoder.More() {
+		var alert ExternalAlert
+		if err := decoder.Decode(&alert); err != nil {
+			return nil, decodeError(err)
+		}
+		batch = append(batch, alert)
+
+		if len(batch) == batchSize {
+			if err := emit(batch); err != nil {
+				return nil, err
+			}
+			// The handler may keep the slice
+			batch = nil
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, decodeError(err)
+	}
+	return batch, nil
+}
+
+// decodeNDJSONAlerts stream-decodes one alert per line, emitting full batches
+// and returning the remainder
+func decodeNDJSONAlerts(r io.Reader, batchSize int, emit func([]ExternalAlert) error) ([]ExternalAlert, error) {
+	decoder := json.NewDecoder(r)
+
+	var batch []ExternalAlert
+	for {
+		var alert ExternalAlert
+		err := decoder.Decode(&alert)
+		if err == io.EOF {
+			return batch, nil
+		}
+		if err != nil {
+			return nil, decodeError(err)
+		}
+		batch = append(batch, alert)
+
+		if len(batch) == batchSize {
+			if err := emit(batch); err != nil {
+				return nil, err
+			}
+			batch = nil
+		}
+	}
+}
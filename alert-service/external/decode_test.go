@@ -0,0 +1,124 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSONAlerts(t *testing.T) {
+	t.Run("emits full batches and returns the rest with the page info", func(t *testing.T) {
+		body := `{"has_more":true,"alerts":[{"description":"a"},{"description":"b"},{"description":"c"}],"meta":{"x":[1,2]},"next_cursor":"c1"}`
+
+		var batches [][]ExternalAlert
+		rest, info, err := decodeJSONAlerts(strings.NewReader(body), 2, func(batch []ExternalAlert) error {
+			batches = append(batches, batch)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		assert.Equal(t, "b", batches[0][1].Description)
+		require.Len(t, rest, 1)
+		assert.Equal(t, "c", rest[0].Description)
+		assert.Equal(t, pageInfo{NextCursor: "c1", HasMore: true}, info)
+	})
+
+	t.Run("accepts a bare array and null alerts", func(t *testing.T) {
+		rest, info, err := decodeJSONAlerts(strings.NewReader(`[{"description":"a"}]`), 10, nil)
+		require.NoError(t, err)
+		assert.Len(t, rest, 1)
+		assert.False(t, info.HasMore)
+
+		rest, _, err = decodeJSONAlerts(strings.NewReader(`{"alerts":null}`), 10, nil)
+		require.NoError(t, err)
+		assert.Empty(t, rest)
+	})
+
+	t.Run("returns handler errors as they are", func(t *testing.T) {
+		stop := errors.New("stop")
+		_, _, err := decodeJSONAlerts(strings.NewReader(`{"alerts":[{},{}]}`), 1, func([]ExternalAlert) error {
+			return stop
+		})
+		assert.Equal(t, stop, err)
+	})
+
+	t.Run("rejects malformed bodies", func(t *testing.T) {
+		for _, body := range []string{``, `"alerts"`, `{"alerts":{}}`, `{"alerts":[{"description":1}]}`, `{"alerts":[{}`} {
+			_, _, err := decodeJSONAlerts(strings.NewReader(body), 10, nil)
+			assert.ErrorIs(t, err, errDecode, body)
+		}
+	})
+}
+
+func TestDecodeNDJSONAlerts(t *testing.T) {
+	body := "{\"description\":\"a\"}\n{\"description\":\"b\"}\n\n{\"description\":\"c\"}\n"
+
+	var batches int
+	rest, err := decodeNDJSONAlerts(strings.NewReader(body), 2, func(batch []ExternalAlert) error {
+		batches++
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, batches)
+	require.Len(t, rest, 1)
+	assert.Equal(t, "c", rest[0].Description)
+
+	_, err = decodeNDJSONAlerts(strings.NewReader("{\"description\":\"a\"}\nnot json\n"), 2, nil)
+	assert.ErrorIs(t, err, errDecode)
+}
+
+func TestMockAPIClient_StreamedPages(t *testing.T) {
+	t.Run("hands pages over in partial chunks", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"alerts":[{"description":"a"},{"description":"b"},{"description":"c"}],"has_more":false}`))
+		}))
+		defer server.Close()
+
+		client := NewMockAPIClientWithOptions(server.URL, ClientOptions{DecodeBatch: 2})
+		var pages []AlertPage
+		err := client.FetchAlertPages(context.Background(), time.Time{}, "", func(page AlertPage) error {
+			pages = append(pages, page)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, pages, 2)
+		assert.True(t, pages[0].Partial)
+		assert.Len(t, pages[0].Alerts, 2)
+		assert.False(t, pages[1].Partial)
+		assert.Len(t, pages[1].Alerts, 1)
+	})
+
+	t.Run("reads NDJSON with pagination headers", func(t *testing.T) {
+		var accept []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accept = append(accept, r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+			if r.URL.Query().Get("cursor") == "" {
+				w.Header().Set("X-Next-Cursor", "c1")
+				w.Header().Set("X-Has-More", "true")
+				w.Write([]byte("{\"description\":\"a\"}\n"))
+				return
+			}
+			w.Header().Set("X-Has-More", "false")
+			w.Write([]byte("{\"description\":\"b\"}\n"))
+		}))
+		defer server.Close()
+
+		alerts, err := NewMockAPIClient(server.URL).FetchAllAlerts(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, alerts, 2)
+		assert.Equal(t, "b", alerts[1].Description)
+		assert.Equal(t, []string{acceptHeader, acceptHeader}, accept)
+	})
+}
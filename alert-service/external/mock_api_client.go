@@ -3,9 +3,12 @@ package external
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
@@ -17,44 +20,142 @@ type ExternalAlert struct {
 	Severity    string    `json:"severity"`
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	// Raw is the original upstream record for pushed and mapped alerts, kept in whole_event
+	Raw json.RawMessage `json:"-"`
+
+	// Indicators are observables promoted from the payload (e.g. src_ip, dst_ip)
+	Indicators map[string]string `json:"-"`
 }
 
 // ExternalAlertsResponse is the response from the mock API
 type ExternalAlertsResponse struct {
 	Alerts []ExternalAlert `json:"alerts"`
+	pageInfo
+}
+
+// pageInfo is the pagination envelope of an upstream response. Mapped
+// upstreams use the same top-level fields.
+type pageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// AlertPage is one page of alerts from an upstream. NextCursor continues
+// after the page and is only meaningful when HasMore is true.
+//
+// Large pages are handed over in chunks as they are decoded. Every chunk but
+// the last is Partial: more alerts of the same page follow, and NextCursor
+// and HasMore are not known yet.
+type AlertPage struct {
+	Alerts     []ExternalAlert
+	NextCursor string
+	HasMore    bool
+	Partial    bool
 }
 
-// MockAPIClient handles communication with the mock alerts API
+// PageHandler receives each page before the next one is requested.
+// Returning an error stops paging.
+type PageHandler func(page AlertPage) error
+
+// MockAPIClient handles communication with the mock alerts API and with
+// upstreams that speak the same protocol
 type MockAPIClient struct {
 	baseURL string
 	client  *retryablehttp.Client
+	limiter *RateLimiter
+	opts    ClientOptions
+}
+
+// ClientOptions configures retries, rate limiting, credentials and response mapping of an upstream client
+type ClientOptions struct {
+	RetryMax     int
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// RateLimit is the most requests per second sent to the upstream
+	// (DefaultRateLimit when zero); the limiter slows down when throttled
+	RateLimit float64
+	RateBurst int
+
+	// PageSize is sent as ?limit=; the upstream default is used when zero
+	PageSize int
+
+	// DecodeBatch is how many alerts are decoded before they are handed to
+	// the page handler (DefaultDecodeBatch when zero)
+	DecodeBatch int
+
+	// APIKey is sent in APIKeyHeader (X-API-Key by default)
+	APIKey       string
+	APIKeyHeader string
+	BearerToken  string
+	Username     string
+	Password     string
+
+	// Mapper decodes responses in an upstream's own schema; nil expects the mock API schema
+	Mapper AlertMapper
+}
+
+// AlertMapper turns a raw upstream response into alerts.
+// Implemented by mapping.Mapper
+type AlertMapper interface {
+	MapAlerts(payload []byte) ([]ExternalAlert, error)
+}
+
+// DefaultClientOptions returns the retry policy used for the mock API
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		RetryMax:     3,
+		RetryWaitMin: 1 * time.Second,
+		RetryWaitMax: 30 * time.Second,
+	}
 }
 
 // NewMockAPIClient creates a new mock API client with retry support
 func NewMockAPIClient(baseURL string) *MockAPIClient {
+	return NewMockAPIClientWithOptions(baseURL, DefaultClientOptions())
+}
+
+// NewMockAPIClientWithOptions creates a client with its own retry policy,
+// rate limiter, credentials and mapping
+func NewMockAPIClientWithOptions(baseURL string, opts ClientOptions) *MockAPIClient {
 	retryClient := retryablehttp.NewClient()
-	retryClient.RetryMax = 3
-	retryClient.RetryWaitMin = 1 * time.Second
-	retryClient.RetryWaitMax = 30 * time.Second
+	retryClient.RetryMax = opts.RetryMax
+	retryClient.RetryWaitMin = opts.RetryWaitMin
+	retryClient.RetryWaitMax = opts.RetryWaitMax
 	retryClient.Logger = &RetryLogger{}
+	retryClient.Backoff = retryBackoff
+
+	limiter := NewRateLimiter(opts.RateLimit, opts.RateBurst)
+	retryClient.HTTPClient.Transport = &rateLimitedTransport{base: retryClient.HTTPClient.Transport, limiter: limiter}
 
 	retryClient.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
 		if ctx.Err() != nil {
 			return false, ctx.Err()
 		}
 
+		if errors.Is(err, ErrRateLimited) {
+			return false, err
+		}
+
 		if err != nil {
 			fmt.Printf("[RETRY] Connection error, will retry: %v\n", err)
 			return true, nil
 		}
 
-		if resp.StatusCode >= 500 {
-			fmt.Printf("[RETRY] Server error %d, will retry\n", resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			// Waiting longer than the policy allows would hold up the sync;
+			// the limiter keeps later requests back until the upstream is ready
+			if wait, ok := retryAfter(resp, time.Now()); ok && wait > opts.RetryWaitMax {
+				fmt.Printf("[RETRY] Rate limited, Retry-After %s exceeds the maximum wait, giving up\n", wait)
+				return false, nil
+			}
+			fmt.Printf("[RETRY] Rate limited (status %d), will retry\n", resp.StatusCode)
 			return true, nil
 		}
 
-		if resp.StatusCode == 429 {
-			fmt.Printf("[RETRY] Rate limited, will retry\n")
+		if resp.StatusCode >= 500 {
+			fmt.Printf("[RETRY] Server error %d, will retry\n", resp.StatusCode)
 			return true, nil
 		}
 
@@ -64,7 +165,54 @@ func NewMockAPIClient(baseURL string) *MockAPIClient {
 	return &MockAPIClient{
 		baseURL: baseURL,
 		client:  retryClient,
+		limiter: limiter,
+		opts:    opts,
+	}
+}
+
+// retryBackoff waits as long as the upstream asked through Retry-After or an
+// exhausted X-RateLimit window, and backs off exponentially otherwise
+func retryBackoff(min, max time.Duration, attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		now := time.Now()
+		if wait, ok := retryAfter(resp, now); ok {
+			return wait
+		}
+		if remaining, ok := headerInt(resp.Header, "X-RateLimit-Remaining"); ok && remaining <= 0 {
+			if reset, ok := rateLimitReset(resp.Header, now); ok && reset.After(now) {
+				return reset.Sub(now)
+			}
+		}
+	}
+	return retryablehttp.DefaultBackoff(min, max, attempt, resp)
+}
+
+// RateLimiter returns the client's upstream rate limiter
+func (c *MockAPIClient) RateLimiter() *RateLimiter {
+	return c.limiter
+}
+
+// newRequest builds a GET request carrying the configured credentials
+func (c *MockAPIClient) newRequest(ctx context.Context, url string) (*retryablehttp.Request, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+
+	switch {
+	case c.opts.APIKey != "":
+		header := c.opts.APIKeyHeader
+		if header == "" {
+			header = "X-API-Key"
+		}
+		req.Header.Set(header, c.opts.APIKey)
+	case c.opts.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.opts.BearerToken)
+	case c.opts.Username != "":
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+
+	return req, nil
 }
 
 // RetryLogger logs retry attempts
@@ -74,16 +222,18 @@ func (l *RetryLogger) Printf(format string, args ...interface{}) {
 	fmt.Printf("[RETRY] "+format+"\n", args...)
 }
 
-// CheckHealth verifies the mock API is available
+// CheckHealth verifies the mock API is available. It makes a single attempt
+// without retries so a down upstream is detected quickly; the caller's
+// circuit breaker decides when to try again.
 func (c *MockAPIClient) CheckHealth(ctx context.Context) error {
 	url := fmt.Sprintf("%s/health", c.baseURL)
 
-	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := c.newRequest(ctx, url)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.client.HTTPClient.Do(req.Request)
 	if err != nil {
 		return fmt.Errorf("health check failed: %w", err)
 	}
@@ -96,40 +246,177 @@ func (c *MockAPIClient) CheckHealth(ctx context.Context) error {
 	return nil
 }
 
-// FetchAlertsSince fetches alerts from the mock API since the given time
+// FetchAlertsSince fetches every alert created after since, following pages
 func (c *MockAPIClient) FetchAlertsSince(ctx context.Context, since time.Time) ([]ExternalAlert, error) {
-	url := fmt.Sprintf("%s/alerts?since=%s", c.baseURL, since.Format(time.RFC3339))
-	return c.fetchAlerts(ctx, url)
+	return c.collectPages(ctx, since)
 }
 
-// FetchAllAlerts fetches all alerts from the mock API
+// FetchAllAlerts fetches every alert, following pages
 func (c *MockAPIClient) FetchAllAlerts(ctx context.Context) ([]ExternalAlert, error) {
-	url := fmt.Sprintf("%s/alerts", c.baseURL)
-	return c.fetchAlerts(ctx, url)
+	return c.collectPages(ctx, time.Time{})
 }
 
-// fetchAlerts is a helper that performs the actual HTTP request
-func (c *MockAPIClient) fetchAlerts(ctx context.Context, url string) ([]ExternalAlert, error) {
-	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (c *MockAPIClient) collectPages(ctx context.Context, since time.Time) ([]ExternalAlert, error) {
+	var alerts []ExternalAlert
+	err := c.FetchAlertPages(ctx, since, "", func(page AlertPage) error {
+		alerts = append(alerts, page.Alerts...)
+		return nil
+	})
+	return alerts, err
+}
+
+// FetchAlertPages fetches alerts a page at a time, oldest first, and hands
+// each page to handle before requesting the next. It starts after cursor when
+// one is given (to resume an interrupted run), otherwise with the alerts
+// created after since, which is exclusive as upstream ?since= is; a zero since
+// fetches everything. Pages are stream-decoded and may arrive in
+// Partial chunks.
+func (c *MockAPIClient) FetchAlertPages(ctx context.Context, since time.Time, cursor string, handle PageHandler) error {
+	for {
+		info, err := c.fetchPage(ctx, c.pageURL(since, time.Time{}, cursor), cursor, handle)
+		if err != nil {
+			return err
+		}
+		if !info.HasMore {
+			return nil
+		}
+		cursor = info.NextCursor
+	}
+}
+
+// FetchAlertRange fetches the alerts created from from up to and including
+// to, a page at a time like FetchAlertPages. Both bounds are inclusive. Alerts
+// outside the range are dropped, and paging stops at the first alert past to,
+// so upstreams that ignore ?until= are not read to the end.
+func (c *MockAPIClient) FetchAlertRange(ctx context.Context, from, to time.Time, cursor string, handle PageHandler) error {
+	// ?since= is exclusive at whatever precision the upstream stores
+	// created_at, so ask from a second earlier and drop what precedes from
+	since := from
+	if !since.IsZero() {
+		since = since.Add(-time.Second)
+	}
+
+	past := false
+	inRange := func(page AlertPage) error {
+		kept := make([]ExternalAlert, 0, len(page.Alerts))
+		for _, alert := range page.Alerts {
+			switch {
+			case alert.CreatedAt.After(to):
+				past = true
+			case !alert.CreatedAt.Before(from):
+				kept = append(kept, alert)
+			}
+		}
+		page.Alerts = kept
+		if past && !page.Partial {
+			page.HasMore, page.NextCursor = false, ""
+		}
+		return handle(page)
+	}
+
+	for {
+		info, err := c.fetchPage(ctx, c.pageURL(since, to, cursor), cursor, inRange)
+		if err != nil {
+			return err
+		}
+		if !info.HasMore || past {
+			return nil
+		}
+		cursor = info.NextCursor
+	}
+}
+
+// pageURL builds the URL of one page. The cursor encodes the position on its
+// own, so since is only sent for the first page; until, when set, bounds
+// every page.
+func (c *MockAPIClient) pageURL(since, until time.Time, cursor string) string {
+	query := url.Values{}
+	switch {
+	case cursor != "":
+		query.Set("cursor", cursor)
+	case !since.IsZero():
+		query.Set("since", since.Format(time.RFC3339Nano))
+	}
+	if !until.IsZero() {
+		query.Set("until", until.Format(time.RFC3339Nano))
+	}
+	if c.opts.PageSize > 0 {
+		query.Set("limit", strconv.Itoa(c.opts.PageSize))
+	}
+
+	if len(query) == 0 {
+		return c.baseURL + "/alerts"
+	}
+	return c.baseURL + "/alerts?" + query.Encode()
+}
+
+// fetchPage performs the request for the page after cursor and hands its
+// alerts to handle as they are decoded. JSON and NDJSON responses are
+// streamed; mapped responses are read whole since a mapping sees the full
+// document.
+func (c *MockAPIClient) fetchPage(ctx context.Context, url, cursor string, handle PageHandler) (pageInfo, error) {
+	req, err := c.newRequest(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return pageInfo{}, err
+	}
+	if c.opts.Mapper == nil {
+		req.Header.Set("Accept", acceptHeader)
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error calling mock API after retries: %w", err)
+		return pageInfo{}, fmt.Errorf("error calling mock API after retries: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("mock API returned status %d: %s", resp.StatusCode, string(body))
+		return pageInfo{}, fmt.Errorf("mock API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var (
+		alerts []ExternalAlert
+		info   pageInfo
+	)
+	emit := func(chunk []ExternalAlert) error {
+		return handle(AlertPage{Alerts: chunk, Partial: true})
 	}
 
-	var response ExternalAlertsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	switch {
+	case c.opts.Mapper != nil:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return pageInfo{}, fmt.Errorf("error reading response: %w", err)
+		}
+		if alerts, err = c.opts.Mapper.MapAlerts(body); err != nil {
+			return pageInfo{}, fmt.Errorf("error mapping response: %w", err)
+		}
+		// Responses that are not objects (e.g. a bare array) have no further pages
+		_ = json.Unmarshal(body, &info)
+	case isNDJSON(resp.Header.Get("Content-Type")):
+		if alerts, err = decodeNDJSONAlerts(resp.Body, c.decodeBatch(), emit); err != nil {
+			return pageInfo{}, err
+		}
+		info.NextCursor = resp.Header.Get(headerNextCursor)
+		info.HasMore, _ = strconv.ParseBool(resp.Header.Get(headerHasMore))
+	default:
+		if alerts, info, err = decodeJSONAlerts(resp.Body, c.decodeBatch(), emit); err != nil {
+			return pageInfo{}, err
+		}
 	}
 
-	return response.Alerts, nil
+	if info.HasMore && (info.NextCursor == "" || info.NextCursor == cursor) {
+		return pageInfo{}, fmt.Errorf("mock API reported more alerts without a new cursor")
+	}
+	if err := handle(AlertPage{Alerts: alerts, NextCursor: info.NextCursor, HasMore: info.HasMore}); err != nil {
+		return pageInfo{}, err
+	}
+	return info, nil
+}
+
+func (c *MockAPIClient) decodeBatch() int {
+	if c.opts.DecodeBatch > 0 {
+		return c.opts.DecodeBatch
+	}
+	return DefaultDecodeBatch
 }
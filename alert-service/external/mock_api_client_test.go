@@ -0,0 +1,176 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockAPIClient_Credentials(t *testing.T) {
+	cases := []struct {
+		name   string
+		opts   ClientOptions
+		header string
+		want   string
+	}{
+		{"api key", ClientOptions{APIKey: "k1"}, "X-API-Key", "k1"},
+		{"custom api key header", ClientOptions{APIKey: "k2", APIKeyHeader: "X-Token"}, "X-Token", "k2"},
+		{"bearer token", ClientOptions{BearerToken: "t1"}, "Authorization", "Bearer t1"},
+		{"basic auth", ClientOptions{Username: "user", Password: "pass"}, "Authorization", "Basic dXNlcjpwYXNz"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get(tc.header)
+				w.Write([]byte(`{"alerts":[]}`))
+			}))
+			defer server.Close()
+
+			client := NewMockAPIClientWithOptions(server.URL, tc.opts)
+			_, err := client.FetchAllAlerts(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+// stubMapper records the payload it was given and returns fixed alerts
+type stubMapper struct {
+	payload []byte
+	alerts  []ExternalAlert
+}
+
+func (m *stubMapper) MapAlerts(payload []byte) ([]ExternalAlert, error) {
+	m.payload = payload
+	return m.alerts, nil
+}
+
+func TestMockAPIClient_Mapper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"sev":"P1"}]}`))
+	}))
+	defer server.Close()
+
+	mapper := &stubMapper{alerts: []ExternalAlert{{Source: "ids", Severity: "critical", Description: "mapped", CreatedAt: time.Now()}}}
+	client := NewMockAPIClientWithOptions(server.URL, ClientOptions{Mapper: mapper})
+
+	alerts, err := client.FetchAllAlerts(context.Background())
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data":[{"sev":"P1"}]}`, string(mapper.payload))
+	assert.Equal(t, mapper.alerts, alerts)
+}
+
+func TestMockAPIClient_CheckHealthDoesNotRetry(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "k1", r.Header.Get("X-API-Key"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewMockAPIClientWithOptions(server.URL, ClientOptions{RetryMax: 3, RetryWaitMin: time.Millisecond, RetryWaitMax: time.Millisecond, APIKey: "k1"})
+	err := client.CheckHealth(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 503")
+	assert.Equal(t, 1, calls)
+}
+
+func TestMockAPIClient_Pagination(t *testing.T) {
+	since := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	t.Run("follows cursors until has_more is false", func(t *testing.T) {
+		var queries []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.RawQuery)
+			switch r.URL.Query().Get("cursor") {
+			case "":
+				w.Write([]byte(`{"alerts":[{"source":"ids","severity":"low","description":"a"}],"next_cursor":"c1","has_more":true}`))
+			case "c1":
+				w.Write([]byte(`{"alerts":[{"source":"ids","severity":"low","description":"b"}],"next_cursor":"c2","has_more":true}`))
+			default:
+				w.Write([]byte(`{"alerts":[{"source":"ids","severity":"low","description":"c"}],"has_more":false}`))
+			}
+		}))
+		defer server.Close()
+
+		client := NewMockAPIClientWithOptions(server.URL, ClientOptions{PageSize: 1})
+		alerts, err := client.FetchAlertsSince(context.Background(), since)
+
+		require.NoError(t, err)
+		require.Len(t, alerts, 3)
+		assert.Equal(t, "c", alerts[2].Description)
+		assert.Equal(t, []string{"limit=1&since=2024-01-15T10%3A00%3A00Z", "cursor=c1&limit=1", "cursor=c2&limit=1"}, queries)
+	})
+
+	t.Run("resumes from a cursor and stops when the handler fails", func(t *testing.T) {
+		var queries []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.RawQuery)
+			w.Write([]byte(`{"alerts":[],"next_cursor":"next","has_more":true}`))
+		}))
+		defer server.Close()
+
+		client := NewMockAPIClientWithOptions(server.URL, ClientOptions{})
+		stop := errors.New("stop")
+		err := client.FetchAlertPages(context.Background(), since, "saved", func(page AlertPage) error {
+			return stop
+		})
+
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, []string{"cursor=saved"}, queries)
+	})
+
+	t.Run("rejects has_more without a new cursor", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"alerts":[],"has_more":true}`))
+		}))
+		defer server.Close()
+
+		_, err := NewMockAPIClientWithOptions(server.URL, ClientOptions{}).FetchAllAlerts(context.Background())
+
+		assert.ErrorContains(t, err, "without a new cursor")
+	})
+
+	t.Run("range includes from, sends until with every page and stops past it", func(t *testing.T) {
+		to := since.Add(time.Hour)
+		var queries []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			queries = append(queries, r.URL.RawQuery)
+			// An upstream that ignores until
+			if r.URL.Query().Get("cursor") == "" {
+				w.Write([]byte(`{"alerts":[{"source":"ids","severity":"low","description":"early","created_at":"2024-01-15T09:59:59.5Z"},{"source":"ids","severity":"low","description":"a","created_at":"2024-01-15T10:00:00Z"}],"next_cursor":"c1","has_more":true}`))
+				return
+			}
+			w.Write([]byte(`{"alerts":[{"source":"ids","severity":"low","description":"b","created_at":"2024-01-15T11:00:00Z"},{"source":"ids","severity":"low","description":"c","created_at":"2024-01-15T11:00:01Z"}],"next_cursor":"c2","has_more":true}`))
+		}))
+		defer server.Close()
+
+		var alerts []ExternalAlert
+		var last AlertPage
+		err := NewMockAPIClientWithOptions(server.URL, ClientOptions{}).FetchAlertRange(context.Background(), since, to, "", func(page AlertPage) error {
+			alerts = append(alerts, page.Alerts...)
+			last = page
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, alerts, 2)
+		assert.Equal(t, "a", alerts[0].Description, "an alert created at from is in the range")
+		assert.Equal(t, "b", alerts[1].Description, "so is one created at to")
+		assert.False(t, last.HasMore)
+		// ?since= is exclusive, so the request starts a second before from
+		assert.Equal(t, []string{"since=2024-01-15T09%3A59%3A59Z&until=2024-01-15T11%3A00%3A00Z", "cursor=c1&until=2024-01-15T11%3A00%3A00Z"}, queries)
+	})
+}
@@ -0,0 +1,147 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+
+	"censys_alert_system/internal/models"
+)
+
+// PagerDuty Events API v2 event actions
+const (
+	EventActionTrigger     = "trigger"
+	EventActionAcknowledge = "acknowledge"
+	EventActionResolve     = "resolve"
+)
+
+// PagerDutyPayload is the payload block of a trigger event
+type PagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// PagerDutyEvent is a PagerDuty Events API v2 request body.
+// Payload is only sent with trigger events.
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"`
+}
+
+// PagerDutyClient sends paging events to a PagerDuty Events API v2 compatible endpoint
+type PagerDutyClient struct {
+	eventsURL  string
+	routingKey string
+	client     *retryablehttp.Client
+}
+
+// NewPagerDutyClient creates a new paging client with retry support.
+// eventsURL may point at any Events API v2 compatible endpoint.
+func NewPagerDutyClient(eventsURL, routingKey string) *PagerDutyClient {
+	retryClient := retryablehttp.NewClient()
+	retryClient.RetryMax = 3
+	retryClient.RetryWaitMin = 1 * time.Second
+	retryClient.RetryWaitMax = 10 * time.Second
+	retryClient.Logger = &RetryLogger{}
+
+	return &PagerDutyClient{
+		eventsURL:  eventsURL,
+		routingKey: routingKey,
+		client:     retryClient,
+	}
+}
+
+// Trigger opens (or re-triggers) an incident for the alert, keyed by its fingerprint
+func (c *PagerDutyClient) Trigger(ctx context.Context, alert models.Alert) error {
+	details := map[string]interface{}{
+		"alert_id":    alert.ID,
+		"description": alert.Description,
+	}
+	if alert.EnrichmentType != nil {
+		details["enrichment_type"] = *alert.EnrichmentType
+	}
+	if alert.IPAddress != nil {
+		details["ip_address"] = *alert.IPAddress
+	}
+
+	return c.SendEvent(ctx, PagerDutyEvent{
+		EventAction: EventActionTrigger,
+		DedupKey:    alert.Fingerprint,
+		Payload: &PagerDutyPayload{
+			Summary:       fmt.Sprintf("[%s] %s", alert.Severity, alert.Description),
+			Source:        alert.Source,
+			Severity:      pagerDutySeverity(alert.Severity),
+			Timestamp:     alert.CreatedAt.UTC().Format(time.RFC3339),
+			Component:     "alert-service",
+			CustomDetails: details,
+		},
+	})
+}
+
+// Acknowledge acknowledges the incident with the given dedup key
+func (c *PagerDutyClient) Acknowledge(ctx context.Context, dedupKey string) error {
+	return c.SendEvent(ctx, PagerDutyEvent{EventAction: EventActionAcknowledge, DedupKey: dedupKey})
+}
+
+// Resolve resolves the incident with the given dedup key
+func (c *PagerDutyClient) Resolve(ctx context.Context, dedupKey string) error {
+	return c.SendEvent(ctx, PagerDutyEvent{EventAction: EventActionResolve, DedupKey: dedupKey})
+}
+
+// SendEvent posts a single event to the events endpoint
+func (c *PagerDutyClient) SendEvent(ctx context.Context, event PagerDutyEvent) error {
+	if event.DedupKey == "" {
+		return fmt.Errorf("pagerduty event requires a dedup key")
+	}
+	event.RoutingKey = c.routingKey
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode pagerduty event: %w", err)
+	}
+
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, c.eventsURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending pagerduty %s event: %w", event.EventAction, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("pagerduty returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// pagerDutySeverity maps our severities onto PagerDuty's critical/error/warning/info scale
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case "critical":
+		return "critical"
+	case "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "info"
+	}
+}
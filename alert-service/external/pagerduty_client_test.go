@@ -0,0 +1,95 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPagerDutyStandIn starts a local Events API v2 stand-in that records received events
+func newPagerDutyStandIn(t *testing.T, status int) (*httptest.Server, *[]PagerDutyEvent) {
+	var events []PagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event PagerDutyEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+
+		w.WriteHeader(status)
+		w.Write([]byte(`{"status":"success","message":"Event processed"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &events
+}
+
+func TestPagerDutyClient_Trigger(t *testing.T) {
+	server, events := newPagerDutyStandIn(t, http.StatusAccepted)
+	client := NewPagerDutyClient(server.URL, "routing-key")
+
+	createdAt := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	alert := models.Alert{
+		ID:          "some-uuid",
+		Source:      "firewall",
+		Severity:    "critical",
+		Description: "Multiple blocked intrusion attempts",
+		Fingerprint: "abc123",
+		CreatedAt:   createdAt,
+	}
+
+	err := client.Trigger(context.Background(), alert)
+
+	require.NoError(t, err)
+	require.Len(t, *events, 1)
+	event := (*events)[0]
+	assert.Equal(t, "routing-key", event.RoutingKey)
+	assert.Equal(t, EventActionTrigger, event.EventAction)
+	assert.Equal(t, "abc123", event.DedupKey)
+	require.NotNil(t, event.Payload)
+	assert.Equal(t, "critical", event.Payload.Severity)
+	assert.Equal(t, "firewall", event.Payload.Source)
+	assert.Equal(t, "2025-01-10T12:00:00Z", event.Payload.Timestamp)
+}
+
+func TestPagerDutyClient_AcknowledgeAndResolve(t *testing.T) {
+	server, events := newPagerDutyStandIn(t, http.StatusAccepted)
+	client := NewPagerDutyClient(server.URL, "routing-key")
+	ctx := context.Background()
+
+	require.NoError(t, client.Acknowledge(ctx, "abc123"))
+	require.NoError(t, client.Resolve(ctx, "abc123"))
+
+	require.Len(t, *events, 2)
+	assert.Equal(t, EventActionAcknowledge, (*events)[0].EventAction)
+	assert.Equal(t, EventActionResolve, (*events)[1].EventAction)
+	assert.Equal(t, "abc123", (*events)[1].DedupKey)
+	assert.Nil(t, (*events)[1].Payload)
+}
+
+func TestPagerDutyClient_Errors(t *testing.T) {
+	t.Run("missing dedup key", func(t *testing.T) {
+		client := NewPagerDutyClient("http://127.0.0.1:0", "routing-key")
+
+		err := client.Resolve(context.Background(), "")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "dedup key")
+	})
+
+	t.Run("rejected event", func(t *testing.T) {
+		server, _ := newPagerDutyStandIn(t, http.StatusBadRequest)
+		client := NewPagerDutyClient(server.URL, "routing-key")
+
+		err := client.Acknowledge(context.Background(), "abc123")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "status 400")
+	})
+}
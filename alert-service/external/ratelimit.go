@@ -0,0 +1,212 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit is the request rate allowed per upstream when none is configured
+const DefaultRateLimit = 10.0
+
+// Adaptive rate bounds, as fractions of the configured rate
+const (
+	minRateFraction      = 0.05
+	recoveryRateFraction = 0.1
+)
+
+// ErrRateLimited is returned when the upstream asked us to wait past the request deadline
+var ErrRateLimited = errors.New("rate limited by upstream")
+
+// RateLimiter is a token bucket shared by every request to one upstream. Its
+// rate adapts to the upstream: a 429 or 503 halves it and pauses requests for
+// Retry-After, X-RateLimit-Remaining/Reset cap it to the remaining budget, and
+// each unthrottled response recovers it towards the configured rate.
+type RateLimiter struct {
+	maxRate float64
+	burst   float64
+	now     func() time.Time
+
+	mu          sync.Mutex
+	rate        float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rate requests per second with bursts up to burst
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if rate <= 0 {
+		rate = DefaultRateLimit
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+
+	l := &RateLimiter{maxRate: rate, burst: float64(burst), now: time.Now, rate: rate, tokens: float64(burst)}
+	l.last = l.now()
+	return l
+}
+
+// Rate returns the current request rate per second
+func (l *RateLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// Wait blocks until a request may be sent. It fails fast when the wait would
+// outlast ctx's deadline.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && l.now().Add(delay).After(deadline) {
+			return fmt.Errorf("%w: next request allowed in %s", ErrRateLimited, delay.Round(time.Millisecond))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before trying again
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.pausedUntil) {
+		return l.pausedUntil.Sub(now)
+	}
+
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Observe adapts the rate to the throttling signals of an upstream response
+func (l *RateLimiter) Observe(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	throttled := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+	if throttled {
+		l.setRate(l.rate/2, "upstream returned "+strconv.Itoa(resp.StatusCode))
+		if wait, ok := retryAfter(resp, now); ok {
+			l.pause(now.Add(wait))
+		}
+	}
+
+	remaining, hasRemaining := headerInt(resp.Header, "X-RateLimit-Remaining")
+	reset, hasReset := rateLimitReset(resp.Header, now)
+	switch {
+	case hasRemaining && hasReset && remaining <= 0:
+		l.pause(reset)
+	case hasRemaining && hasReset && reset.After(now):
+		// Spread the remaining budget over the rest of the window
+		if budget := float64(remaining) / reset.Sub(now).Seconds(); budget < l.rate {
+			l.setRate(budget, fmt.Sprintf("%d request(s) left until %s", remaining, reset.Format(time.RFC3339)))
+			return
+		}
+	}
+
+	if !throttled && l.rate < l.maxRate {
+		l.setRate(l.rate+l.maxRate*recoveryRateFraction, "")
+	}
+}
+
+// setRate clamps and applies a new rate. Callers hold l.mu.
+func (l *RateLimiter) setRate(rate float64, reason string) {
+	rate = math.Max(l.maxRate*minRateFraction, math.Min(rate, l.maxRate))
+	if rate == l.rate {
+		return
+	}
+	if reason != "" {
+		fmt.Printf("[RATELIMIT] Rate %.2f/s -> %.2f/s: %s\n", l.rate, rate, reason)
+	}
+	l.rate = rate
+}
+
+// pause holds every request until until. Callers hold l.mu.
+func (l *RateLimiter) pause(until time.Time) {
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+		l.tokens = 0
+		l.last = until
+	}
+}
+
+// retryAfter reads the Retry-After header, given in seconds or as an HTTP-date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// rateLimitReset reads X-RateLimit-Reset, given as a Unix timestamp or as
+// seconds until the window resets
+func rateLimitReset(header http.Header, now time.Time) (time.Time, bool) {
+	value, ok := headerInt(header, "X-RateLimit-Reset")
+	if !ok {
+		return time.Time{}, false
+	}
+	// Values this large are timestamps rather than delays
+	if value > 1_000_000_000 {
+		return time.Unix(int64(value), 0), true
+	}
+	return now.Add(time.Duration(value) * time.Second), true
+}
+
+func headerInt(header http.Header, name string) (int, bool) {
+	value, err := strconv.Atoi(strings.TrimSpace(header.Get(name)))
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// rateLimitedTransport waits for the limiter before each request and feeds it every response
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *RateLimiter
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	t.limiter.Observe(resp)
+	return resp, err
+}
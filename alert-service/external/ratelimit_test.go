@@ -0,0 +1,167 @@
+package external
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(rate float64, burst int) (*RateLimiter, *time.Time) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(rate, burst)
+	l.now = func() time.Time { return now }
+	l.last = now
+	return l, &now
+}
+
+func response(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"seconds", "120", 2 * time.Minute, true},
+		{"http date", now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{"date in the past", now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"missing", "", 0, false},
+		{"invalid", "soon", 0, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := retryAfter(response(http.StatusTooManyRequests, map[string]string{"Retry-After": tc.header}), now)
+
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("token bucket", func(t *testing.T) {
+		l, now := newTestLimiter(2, 2)
+
+		assert.Zero(t, l.reserve())
+		assert.Zero(t, l.reserve())
+		assert.Equal(t, 500*time.Millisecond, l.reserve())
+
+		*now = now.Add(500 * time.Millisecond)
+		assert.Zero(t, l.reserve())
+	})
+
+	t.Run("429 halves the rate and pauses for Retry-After", func(t *testing.T) {
+		l, _ := newTestLimiter(10, 10)
+
+		l.Observe(response(http.StatusTooManyRequests, map[string]string{"Retry-After": "30"}))
+
+		assert.Equal(t, 5.0, l.Rate())
+		assert.Equal(t, 30*time.Second, l.reserve())
+	})
+
+	t.Run("rate never drops below the floor", func(t *testing.T) {
+		l, _ := newTestLimiter(10, 10)
+
+		for range 10 {
+			l.Observe(response(http.StatusTooManyRequests, nil))
+		}
+
+		assert.Equal(t, 10*minRateFraction, l.Rate())
+	})
+
+	t.Run("remaining budget caps the rate", func(t *testing.T) {
+		l, now := newTestLimiter(10, 10)
+
+		l.Observe(response(http.StatusOK, map[string]string{
+			"X-RateLimit-Remaining": "20",
+			"X-RateLimit-Reset":     strconv.FormatInt(now.Add(10*time.Second).Unix(), 10),
+		}))
+
+		assert.Equal(t, 2.0, l.Rate())
+	})
+
+	t.Run("exhausted window pauses until reset", func(t *testing.T) {
+		l, _ := newTestLimiter(10, 10)
+
+		l.Observe(response(http.StatusOK, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "15"}))
+
+		assert.Equal(t, 15*time.Second, l.reserve())
+	})
+
+	t.Run("unthrottled responses recover the rate", func(t *testing.T) {
+		l, _ := newTestLimiter(10, 10)
+		l.Observe(response(http.StatusTooManyRequests, nil))
+
+		for range 5 {
+			l.Observe(response(http.StatusOK, nil))
+		}
+
+		assert.Equal(t, 10.0, l.Rate())
+	})
+
+	t.Run("wait fails fast past the deadline", func(t *testing.T) {
+		l := NewRateLimiter(10, 10)
+		l.Observe(response(http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		assert.ErrorIs(t, l.Wait(ctx), ErrRateLimited)
+	})
+}
+
+func TestMockAPIClient_RetryAfter(t *testing.T) {
+	t.Run("waits for Retry-After before retrying", func(t *testing.T) {
+		var calls []time.Time
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, time.Now())
+			if len(calls) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"alerts":[]}`))
+		}))
+		defer server.Close()
+
+		client := NewMockAPIClientWithOptions(server.URL, ClientOptions{RetryMax: 2, RetryWaitMin: time.Millisecond, RetryWaitMax: 5 * time.Second})
+		_, err := client.FetchAllAlerts(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, calls, 2)
+		assert.GreaterOrEqual(t, calls[1].Sub(calls[0]), time.Second)
+		assert.Less(t, client.RateLimiter().Rate(), DefaultRateLimit)
+	})
+
+	t.Run("gives up when Retry-After exceeds the maximum wait", func(t *testing.T) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		client := NewMockAPIClientWithOptions(server.URL, ClientOptions{RetryMax: 3, RetryWaitMin: time.Millisecond, RetryWaitMax: time.Second})
+		_, err := client.FetchAllAlerts(context.Background())
+
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,309 @@
+// Package archive writes alerts to compressed, checksummed archives in a
+// local directory or an S3-compatible bucket and reads them back for restore.
+//
+// An archive is a directory of data files (gzip NDJSON or Parquet) named
+// part-0000, part-0001, ... and a manifest.json, written last, that lists
+// every file with its row count, size and SHA-256. An archive without a
+// manifest is incomplete and is never restored.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+// ManifestVersion is the version of the manifest layout written by this package
+const ManifestVersion = 1
+
+// DefaultFileRows caps the alerts in one data file when no limit is set
+const DefaultFileRows = 100000
+
+// manifestKey is the name of the manifest within an archive
+const manifestKey = "manifest.json"
+
+// Manifest describes a complete archive
+type Manifest struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Format  string `json:"format"`
+	// From and To bound the created_at of the archived alerts, [From, To);
+	// From is zero when the range was open-ended
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to"`
+	// Severities lists the severities archived, or is empty for all of them
+	Severities []string `json:"severities,omitempty"`
+	// Oldest and Newest are the earliest and latest created_at archived
+	Oldest    time.Time `json:"oldest,omitzero"`
+	Newest    time.Time `json:"newest,omitzero"`
+	Rows      int       `json:"rows"`
+	Files     []File    `json:"files"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// File is one data file of an archive
+type File struct {
+	Key    string `json:"key"`
+	Rows   int    `json:"rows"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// Store writes archives to and reads them from a sink
+type Store struct {
+	sink     Sink
+	format   string
+	fileRows int
+	now      func() time.Time
+}
+
+// NewStore creates a store writing archives in format, at most fileRows
+// alerts per data file
+func NewStore(sink Sink, format string, fileRows int) (*Store, error) {
+	if format == "" {
+		format = FormatNDJSON
+	}
+	if format != FormatNDJSON && format != FormatParquet {
+		return nil, fmt.Errorf("archive: invalid format %q: use %s or %s", format, FormatNDJSON, FormatParquet)
+	}
+	if fileRows <= 0 {
+		fileRows = DefaultFileRows
+	}
+	return &Store{sink: sink, format: format, fileRows: fileRows, now: time.Now}, nil
+}
+
+// Create starts a new archive. name is the archive's directory within the
+// sink; from, to and severities are recorded in the manifest.
+func (s *Store) Create(name string, from, to time.Time, severities []string) *Writer {
+	return &Writer{
+		store: s,
+		manifest: Manifest{
+			Version:    ManifestVersion,
+			Name:       name,
+			Format:     s.format,
+			From:       from,
+			To:         to,
+			Severities: severities,
+		},
+	}
+}
+
+// Writer streams alerts into an archive. Each data file is staged in a
+// temporary file and uploaded once it is full.
+type Writer struct {
+	store    *Store
+	manifest Manifest
+	part     *partFile
+}
+
+// partFile is the data file being written
+type partFile struct {
+	file *os.File
+	enc  encoder
+	hash hash.Hash
+	rows int
+}
+
+// Write adds alerts to the archive
+func (w *Writer) Write(ctx context.Context, alerts ...models.Alert) error {
+	for _, alert := range alerts {
+		if w.part == nil {
+			if err := w.openPart(); err != nil {
+				return err
+			}
+		}
+		if err := w.part.enc.Encode(alert); err != nil {
+			return fmt.Errorf("archive: encoding alert %s: %w", alert.ID, err)
+		}
+		w.part.rows++
+		if w.manifest.Oldest.IsZero() || alert.CreatedAt.Before(w.manifest.Oldest) {
+			w.manifest.Oldest = alert.CreatedAt
+		}
+		if alert.CreatedAt.After(w.manifest.Newest) {
+			w.manifest.Newest = alert.CreatedAt
+		}
+		if w.part.rows >= w.store.fileRows {
+			if err := w.flushPart(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rows returns the number of alerts written so far
+func (w *Writer) Rows() int {
+	rows := w.manifest.Rows
+	if w.part != nil {
+		rows += w.part.rows
+	}
+	return rows
+}
+
+// Close uploads the last data file and then the manifest, completing the archive
+func (w *Writer) Close(ctx context.Context) (*Manifest, error) {
+	if w.part != nil {
+		if err := w.flushPart(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	w.manifest.CreatedAt = w.store.now().UTC()
+	data, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("archive: encoding manifest: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if err := w.store.sink.Put(ctx, w.manifest.Name+"/"+manifestKey, bytes.NewReader(data), int64(len(data)), hex.EncodeToString(sum[:])); err != nil {
+		return nil, err
+	}
+
+	manifest := w.manifest
+	return &manifest, nil
+}
+
+// Abort discards the data file being written. Files already uploaded stay
+// in the sink but, with no manifest, are not a restorable archive.
+func (w *Writer) Abort() {
+	if w.part != nil {
+		w.part.file.Close()
+		os.Remove(w.part.file.Name())
+		w.part = nil
+	}
+}
+
+func (w *Writer) openPart() error {
+	f, err := os.CreateTemp("", "alert-archive-*")
+	if err != nil {
+		return fmt.Errorf("archive: staging data file: %w", err)
+	}
+	h := sha256.New()
+	w.part = &partFile{file: f, hash: h, enc: newEncoder(w.store.format, io.MultiWriter(f, h))}
+	return nil
+}
+
+// flushPart finishes the current data file, uploads it and records it in the manifest
+func (w *Writer) flushPart(ctx context.Context) error {
+	part := w.part
+	defer w.Abort()
+
+	if err := part.enc.Close(); err != nil {
+		return fmt.Errorf("archive: finishing data file: %w", err)
+	}
+	size, err := part.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("archive: staging data file: %w", err)
+	}
+	if _, err := part.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("archive: staging data file: %w", err)
+	}
+
+	file := File{
+		Key:    fmt.Sprintf("part-%04d%s", len(w.manifest.Files), fileExtension(w.store.format)),
+		Rows:   part.rows,
+		Bytes:  size,
+		SHA256: hex.EncodeToString(part.hash.Sum(nil)),
+	}
+	if err := w.store.sink.Put(ctx, w.manifest.Name+"/"+file.Key, part.file, file.Bytes, file.SHA256); err != nil {
+		return err
+	}
+
+	w.manifest.Files = append(w.manifest.Files, file)
+	w.manifest.Rows += file.Rows
+	return nil
+}
+
+// Manifest reads the manifest of a complete archive
+func (s *Store) Manifest(ctx context.Context, name string) (*Manifest, error) {
+	r, err := s.sink.Get(ctx, name+"/"+manifestKey)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("archive: invalid manifest for %s: %w", name, err)
+	}
+	if manifest.Version > ManifestVersion {
+		return nil, fmt.Errorf("archive: manifest version %d of %s is newer than supported version %d", manifest.Version, name, ManifestVersion)
+	}
+	return &manifest, nil
+}
+
+// Read calls handle with the alerts of every data file in manifest, at most
+// batchSize at a time. Each file is downloaded and checked against its
+// checksum before any of its alerts are handed over.
+func (s *Store) Read(ctx context.Context, manifest *Manifest, batchSize int, handle func([]models.Alert) error) error {
+	for _, file := range manifest.Files {
+		if err := s.readFile(ctx, manifest, file, batchSize, handle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) readFile(ctx context.Context, manifest *Manifest, file File, batchSize int, handle func([]models.Alert) error) error {
+	key := manifest.Name + "/" + file.Key
+	r, err := s.sink.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	staged, err := os.CreateTemp("", "alert-restore-*")
+	if err != nil {
+		return fmt.Errorf("archive: staging %s: %w", key, err)
+	}
+	defer os.Remove(staged.Name())
+	defer staged.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(staged, h), r)
+	if err != nil {
+		return fmt.Errorf("archive: downloading %s: %w", key, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != file.SHA256 || size != file.Bytes {
+		return fmt.Errorf("archive: %s is corrupt: got %d bytes with sha256 %s, manifest lists %d bytes with sha256 %s",
+			key, size, sum, file.Bytes, file.SHA256)
+	}
+	if _, err := staged.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("archive: staging %s: %w", key, err)
+	}
+
+	rows := 0
+	batch := make([]models.Alert, 0, batchSize)
+	var handleErr error
+	err = decodeFile(manifest.Format, staged, func(alert models.Alert) error {
+		rows++
+		batch = append(batch, alert)
+		if len(batch) < batchSize {
+			return nil
+		}
+		handleErr = handle(batch)
+		batch = batch[:0]
+		return handleErr
+	})
+	if handleErr != nil {
+		return handleErr
+	}
+	if err != nil {
+		return fmt.Errorf("archive: decoding %s: %w", key, err)
+	}
+	if rows != file.Rows {
+		return fmt.Errorf("archive: %s has %d rows, manifest lists %d", key, rows, file.Rows)
+	}
+	if len(batch) > 0 {
+		return handle(batch)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAlerts(n int) []models.Alert {
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	acknowledgedAt := createdAt.Add(time.Hour)
+	ip := "10.0.0.1"
+	alerts := make([]models.Alert, n)
+	for i := range alerts {
+		alerts[i] = models.Alert{
+			ID:                 fmt.Sprintf("00000000-0000-0000-0000-%012d", i),
+			Source:             "firewall",
+			Severity:           "low",
+			Description:        fmt.Sprintf("alert %d", i),
+			WholeEvent:         []byte(`{"indicators":{"src_ip":"10.0.0.1"}}`),
+			IPAddress:          &ip,
+			EnrichmentVersions: map[string]int{"ip_address": 1},
+			Fingerprint:        fmt.Sprintf("fp-%d", i),
+			Status:             models.StatusOpen,
+			CreatedAt:          createdAt.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	alerts[0].Status = models.StatusAcknowledged
+	alerts[0].AcknowledgedAt = &acknowledgedAt
+	return alerts
+}
+
+func writeArchive(t *testing.T, store *Store, name string, alerts []models.Alert) *Manifest {
+	ctx := context.Background()
+	w := store.Create(name, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), []string{"low"})
+	require.NoError(t, w.Write(ctx, alerts...))
+	manifest, err := w.Close(ctx)
+	require.NoError(t, err)
+	return manifest
+}
+
+func readArchive(t *testing.T, store *Store, name string) []models.Alert {
+	ctx := context.Background()
+	manifest, err := store.Manifest(ctx, name)
+	require.NoError(t, err)
+
+	var alerts []models.Alert
+	require.NoError(t, store.Read(ctx, manifest, 2, func(batch []models.Alert) error {
+		assert.LessOrEqual(t, len(batch), 2)
+		alerts = append(alerts, batch...)
+		return nil
+	}))
+	return alerts
+}
+
+func TestStore_RoundTrip(t *testing.T) {
+	for _, format := range []string{FormatNDJSON, FormatParquet} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			sink, err := NewDirSink(dir)
+			require.NoError(t, err)
+			store, err := NewStore(sink, format, 2)
+			require.NoError(t, err)
+
+			alerts := testAlerts(5)
+			manifest := writeArchive(t, store, "alerts_p20250101", alerts)
+
+			assert.Equal(t, 5, manifest.Rows)
+			require.Len(t, manifest.Files, 3)
+			assert.Equal(t, "part-0000"+fileExtension(format), manifest.Files[0].Key)
+			assert.Equal(t, 1, manifest.Files[2].Rows)
+			assert.FileExists(t, filepath.Join(dir, "alerts_p20250101", "manifest.json"))
+
+			restored := readArchive(t, store, "alerts_p20250101")
+			require.Len(t, restored, 5)
+			for i := range alerts {
+				assert.Equal(t, alerts[i].ID, restored[i].ID)
+				assert.Equal(t, alerts[i].WholeEvent, restored[i].WholeEvent)
+				assert.Equal(t, alerts[i].EnrichmentVersions, restored[i].EnrichmentVersions)
+				assert.True(t, alerts[i].CreatedAt.Equal(restored[i].CreatedAt))
+				assert.Nil(t, restored[i].EnrichmentType)
+			}
+			assert.Equal(t, "10.0.0.1", *restored[3].IPAddress)
+			assert.True(t, alerts[0].AcknowledgedAt.Equal(*restored[0].AcknowledgedAt))
+		})
+	}
+}
+
+func TestStore_DetectsCorruption(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewDirSink(dir)
+	require.NoError(t, err)
+	store, err := NewStore(sink, FormatNDJSON, 0)
+	require.NoError(t, err)
+
+	manifest := writeArchive(t, store, "corrupt", testAlerts(3))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "corrupt", manifest.Files[0].Key), []byte("tampered"), 0o644))
+
+	err = store.Read(context.Background(), manifest, 10, func([]models.Alert) error {
+		t.Fatal("alerts from a corrupt file were handed over")
+		return nil
+	})
+
+	assert.ErrorContains(t, err, "is corrupt")
+}
+
+func TestStore_MissingManifest(t *testing.T) {
+	sink, err := NewDirSink(t.TempDir())
+	require.NoError(t, err)
+	store, err := NewStore(sink, FormatNDJSON, 0)
+	require.NoError(t, err)
+
+	_, err = store.Manifest(context.Background(), "never-written")
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestNewStore_InvalidFormat(t *testing.T) {
+	_, err := NewStore(nil, "csv", 0)
+	assert.ErrorContains(t, err, "invalid format")
+}
+
+// TestStore_S3 runs against a real S3-compatible store when
+// ARCHIVE_TEST_S3_ENDPOINT is set, e.g. a local MinIO:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	ARCHIVE_TEST_S3_ENDPOINT=http://localhost:9000 go test ./internal/archive/
+//
+// The bucket (ARCHIVE_TEST_S3_BUCKET, default alert-archive) must exist.
+func TestStore_S3(t *testing.T) {
+	endpoint := os.Getenv("ARCHIVE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("ARCHIVE_TEST_S3_ENDPOINT not set")
+	}
+	bucket := os.Getenv("ARCHIVE_TEST_S3_BUCKET")
+	if bucket == "" {
+		bucket = "alert-archive"
+	}
+	accessKey, secretKey := os.Getenv("ARCHIVE_TEST_S3_ACCESS_KEY"), os.Getenv("ARCHIVE_TEST_S3_SECRET_KEY")
+	if accessKey == "" {
+		accessKey, secretKey = "minioadmin", "minioadmin"
+	}
+
+	sink, err := NewSink("s3://"+bucket+"/test-"+fmt.Sprint(time.Now().UnixNano()), external.S3Config{
+		Endpoint:  endpoint,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+	})
+	require.NoError(t, err)
+	store, err := NewStore(sink, FormatParquet, 2)
+	require.NoError(t, err)
+
+	writeArchive(t, store, "alerts_p20250101", testAlerts(3))
+	assert.Len(t, readArchive(t, store, "alerts_p20250101"), 3)
+}
@@ -0,0 +1,182 @@
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Archive formats
+const (
+	// FormatNDJSON is gzip-compressed newline-delimited JSON, one alert per line
+	FormatNDJSON = "ndjson"
+	// FormatParquet is Apache Parquet with zstd-compressed columns
+	FormatParquet = "parquet"
+)
+
+// parquetReadBatch is the number of rows decoded from a Parquet file at a time
+const parquetReadBatch = 256
+
+// fileExtension returns the extension of a data file in format
+func fileExtension(format string) string {
+	if format == FormatParquet {
+		return ".parquet"
+	}
+	return ".ndjson.gz"
+}
+
+// encoder writes alerts to one data file
+type encoder interface {
+	Encode(alert models.Alert) error
+	// Close flushes the file's trailing data; it does not close the underlying writer
+	Close() error
+}
+
+func newEncoder(format string, w io.Writer) encoder {
+	if format == FormatParquet {
+		return &parquetEncoder{w: parquet.NewGenericWriter[parquetAlert](w, parquet.Compression(&parquet.Zstd))}
+	}
+	gz := gzip.NewWriter(w)
+	return &ndjsonEncoder{gz: gz, enc: json.NewEncoder(gz)}
+}
+
+// decodeFile calls handle with every alert in a data file
+func decodeFile(format string, f *os.File, handle func(models.Alert) error) error {
+	if format == FormatParquet {
+		return decodeParquet(f, handle)
+	}
+	return decodeNDJSON(f, handle)
+}
+
+type ndjsonEncoder struct {
+	gz  *gzip.Writer
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(alert models.Alert) error {
+	return e.enc.Encode(alert)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return e.gz.Close()
+}
+
+func decodeNDJSON(r io.Reader, handle func(models.Alert) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	dec := json.NewDecoder(gz)
+	for line := 1; ; line++ {
+		var alert models.Alert
+		err := dec.Decode(&alert)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := handle(alert); err != nil {
+			return err
+		}
+	}
+}
+
+// parquetAlert is the Parquet row of an archived alert. Enrichment versions
+// are kept as a JSON object so new enrichment fields need no schema change.
+type parquetAlert struct {
+	ID                 string     `parquet:"id"`
+	Source             string     `parquet:"source,dict"`
+	Severity           string     `parquet:"severity,dict"`
+	Description        string     `parquet:"description"`
+	WholeEvent         []byte     `parquet:"whole_event"`
+	EnrichmentType     *string    `parquet:"enrichment_type,optional,dict"`
+	IPAddress          *string    `parquet:"ip_address,optional"`
+	EnrichmentVersions string     `parquet:"enrichment_versions"`
+	Fingerprint        string     `parquet:"fingerprint"`
+	Status             string     `parquet:"status,dict"`
+	AcknowledgedAt     *time.Time `parquet:"acknowledged_at,optional,timestamp(microsecond)"`
+	ResolvedAt         *time.Time `parquet:"resolved_at,optional,timestamp(microsecond)"`
+	CreatedAt          time.Time  `parquet:"created_at,timestamp(microsecond)"`
+}
+
+type parquetEncoder struct {
+	w *parquet.GenericWriter[parquetAlert]
+}
+
+func (e *parquetEncoder) Encode(alert models.Alert) error {
+	versions, err := json.Marshal(alert.EnrichmentVersions)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write([]parquetAlert{{
+		ID:                 alert.ID,
+		Source:             alert.Source,
+		Severity:           alert.Severity,
+		Description:        alert.Description,
+		WholeEvent:         alert.WholeEvent,
+		EnrichmentType:     alert.EnrichmentType,
+		IPAddress:          alert.IPAddress,
+		EnrichmentVersions: string(versions),
+		Fingerprint:        alert.Fingerprint,
+		Status:             alert.Status,
+		AcknowledgedAt:     alert.AcknowledgedAt,
+		ResolvedAt:         alert.ResolvedAt,
+		CreatedAt:          alert.CreatedAt,
+	}})
+	return err
+}
+
+func (e *parquetEncoder) Close() error {
+	return e.w.Close()
+}
+
+func decodeParquet(f *os.File, handle func(models.Alert) error) error {
+	reader := parquet.NewGenericReader[parquetAlert](f)
+	defer reader.Close()
+
+	rows := make([]parquetAlert, parquetReadBatch)
+	for {
+		n, err := reader.Read(rows)
+		for _, row := range rows[:n] {
+			alert := models.Alert{
+				ID:             row.ID,
+				Source:         row.Source,
+				Severity:       row.Severity,
+				Description:    row.Description,
+				WholeEvent:     row.WholeEvent,
+				EnrichmentType: row.EnrichmentType,
+				IPAddress:      row.IPAddress,
+				Fingerprint:    row.Fingerprint,
+				Status:         row.Status,
+				AcknowledgedAt: row.AcknowledgedAt,
+				ResolvedAt:     row.ResolvedAt,
+				CreatedAt:      row.CreatedAt,
+			}
+			if row.EnrichmentVersions != "" && row.EnrichmentVersions != "null" {
+				if err := json.Unmarshal([]byte(row.EnrichmentVersions), &alert.EnrichmentVersions); err != nil {
+					return fmt.Errorf("alert %s: invalid enrichment_versions: %w", row.ID, err)
+				}
+			}
+			if err := handle(alert); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"censys_alert_system/external"
+)
+
+// ErrNotFound is returned when an archive object does not exist
+var ErrNotFound = errors.New("archive: not found")
+
+// Sink stores archive objects under slash-separated keys
+type Sink interface {
+	// Put stores size bytes from body under key; checksum is the hex SHA-256 of the body
+	Put(ctx context.Context, key string, body io.ReadSeeker, size int64, checksum string) error
+	// Get opens the object stored under key. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// NewSink opens the sink named by target: s3://bucket/prefix for an
+// S3-compatible store reached through s3, or a local directory otherwise
+func NewSink(target string, s3 external.S3Config) (Sink, error) {
+	if rest, ok := strings.CutPrefix(target, "s3://"); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		s3.Bucket = bucket
+		client, err := external.NewS3Client(s3)
+		if err != nil {
+			return nil, fmt.Errorf("archive: %w", err)
+		}
+		return &S3Sink{client: client, prefix: strings.Trim(prefix, "/")}, nil
+	}
+	dir := strings.TrimPrefix(target, "file://")
+	if dir == "" {
+		return nil, errors.New("archive: no target configured")
+	}
+	return NewDirSink(dir)
+}
+
+// DirSink stores archive objects as files under a local directory
+type DirSink struct {
+	dir string
+}
+
+// NewDirSink creates dir if needed and stores objects under it
+func NewDirSink(dir string) (*DirSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("archive: creating %s: %w", dir, err)
+	}
+	return &DirSink{dir: dir}, nil
+}
+
+// Put writes the object to a temporary file and renames it into place, so a
+// reader never sees a partial object
+func (s *DirSink) Put(_ context.Context, key string, body io.ReadSeeker, _ int64, _ string) error {
+	dest := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("archive: creating directory for %s: %w", key, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("archive: writing %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("archive: writing %s: %w", key, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("archive: writing %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("archive: writing %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return fmt.Errorf("archive: writing %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get opens the file stored under key
+func (s *DirSink) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("archive: reading %s: %w", key, err)
+	}
+	return f, nil
+}
+
+func (s *DirSink) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+// S3Sink stores archive objects in an S3-compatible bucket under a key prefix
+type S3Sink struct {
+	client *external.S3Client
+	prefix string
+}
+
+// Put uploads the object
+func (s *S3Sink) Put(ctx context.Context, key string, body io.ReadSeeker, size int64, checksum string) error {
+	if err := s.client.PutObject(ctx, s.key(key), body, size, checksum); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	return nil
+}
+
+// Get downloads the object
+func (s *S3Sink) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	body, err := s.client.GetObject(ctx, s.key(key))
+	if errors.Is(err, external.ErrObjectNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+	return body, nil
+}
+
+func (s *S3Sink) key(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
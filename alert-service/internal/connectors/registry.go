@@ -0,0 +1,136 @@
+// Package connectors builds upstream connectors from configuration.
+package connectors
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"censys_alert_system/config"
+	"censys_alert_system/external"
+	"censys_alert_system/internal/mapping"
+	"censys_alert_system/internal/service"
+)
+
+// Factory creates the client for a connector of one type
+type Factory func(cfg config.ConnectorConfig) (service.APIClientInterface, error)
+
+// Registry maps connector types to the factories that build them
+type Registry struct {
+	factories map[string]Factory
+}
+
+// NewRegistry creates a registry with the built-in connector types
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]Factory)}
+	r.Register("mock-api", newMockAPIClient)
+	return r
+}
+
+// Register adds or replaces the factory for a connector type
+func (r *Registry) Register(connectorType string, factory Factory) {
+	r.factories[connectorType] = factory
+}
+
+// Types returns the registered connector types
+func (r *Registry) Types() []string {
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Build creates one connector per configuration entry
+func (r *Registry) Build(configs []config.ConnectorConfig) ([]*service.Connector, error) {
+	connectors := make([]*service.Connector, 0, len(configs))
+	for _, cfg := range configs {
+		factory, ok := r.factories[cfg.Type]
+		if !ok {
+			return nil, fmt.Errorf("connector %q: unknown type %q (known: %v)", cfg.Name, cfg.Type, r.Types())
+		}
+
+		client, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("connector %q: %w", cfg.Name, err)
+		}
+
+		connectors = append(connectors, &service.Connector{
+			Name:         cfg.Name,
+			Client:       client,
+			PollInterval: time.Duration(cfg.PollInterval),
+			// The default connector continues from data synced before connectors existed
+			InheritWatermark: cfg.Name == config.DefaultConnectorName,
+			Lookback:         time.Duration(cfg.Lookback),
+			Breaker: service.NewCircuitBreaker(cfg.Name, service.BreakerConfig{
+				FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+				CoolDown:         time.Duration(cfg.CircuitBreaker.CoolDown),
+			}),
+		})
+	}
+	return connectors, nil
+}
+
+// LoadMapping builds the connector's response mapper from its mapping file,
+// inline mapping or field_mapping shorthand. Returns nil when none is configured.
+func LoadMapping(cfg config.ConnectorConfig) (*mapping.Mapper, error) {
+	switch {
+	case cfg.MappingFile != "":
+		return mapping.Load(cfg.MappingFile)
+	case len(cfg.Mapping) > 0:
+		return mapping.Parse(cfg.Mapping)
+	case len(cfg.FieldMapping) > 0:
+		spec := mapping.Spec{Fields: make(map[string]mapping.FieldSpec)}
+		for field, upstream := range cfg.FieldMapping {
+			spec.Fields[field] = mapping.FieldSpec{Path: upstream}
+		}
+		return mapping.New(spec)
+	default:
+		return nil, nil
+	}
+}
+
+// LoadMappings returns the mapper of every connector that configures one, by name
+func LoadMappings(configs []config.ConnectorConfig) (map[string]*mapping.Mapper, error) {
+	mappers := make(map[string]*mapping.Mapper)
+	for _, cfg := range configs {
+		mapper, err := LoadMapping(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("connector %q: %w", cfg.Name, err)
+		}
+		if mapper != nil {
+			mappers[cfg.Name] = mapper
+		}
+	}
+	return mappers, nil
+}
+
+// newMockAPIClient builds a client for the mock API protocol
+// (GET /alerts, GET /alerts?since=, GET /health)
+func newMockAPIClient(cfg config.ConnectorConfig) (service.APIClientInterface, error) {
+	mapper, err := LoadMapping(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := external.ClientOptions{
+		RetryMax:     cfg.Retry.MaxRetries,
+		RetryWaitMin: time.Duration(cfg.Retry.WaitMin),
+		RetryWaitMax: time.Duration(cfg.Retry.WaitMax),
+		RateLimit:    cfg.RateLimit.RequestsPerSecond,
+		RateBurst:    cfg.RateLimit.Burst,
+		PageSize:     cfg.PageSize,
+		APIKey:       cfg.Credentials.APIKey,
+		APIKeyHeader: cfg.Credentials.APIKeyHeader,
+		BearerToken:  cfg.Credentials.BearerToken,
+		Username:     cfg.Credentials.Username,
+		Password:     cfg.Credentials.Password,
+	}
+	// Only set a non-nil mapper so the client keeps its native decoding otherwise
+	if mapper != nil {
+		opts.Mapper = mapper
+	}
+
+	return external.NewMockAPIClientWithOptions(cfg.URL, opts), nil
+}
@@ -0,0 +1,98 @@
+package connectors
+
+import (
+	"testing"
+	"time"
+
+	"censys_alert_system/config"
+	"censys_alert_system/external"
+	"censys_alert_system/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Build(t *testing.T) {
+	registry := NewRegistry()
+
+	connectors, err := registry.Build([]config.ConnectorConfig{
+		{Name: config.DefaultConnectorName, Type: "mock-api", URL: "http://mock-api:8081", PollInterval: config.Duration(time.Minute)},
+		{Name: "siem", Type: "mock-api", URL: "http://siem", PollInterval: config.Duration(5 * time.Minute),
+			CircuitBreaker: config.BreakerConfig{FailureThreshold: 5, CoolDown: config.Duration(time.Minute)}},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, connectors, 2)
+	assert.IsType(t, &external.MockAPIClient{}, connectors[0].Client)
+	assert.True(t, connectors[0].InheritWatermark)
+	assert.False(t, connectors[1].InheritWatermark)
+	assert.Equal(t, 5*time.Minute, connectors[1].PollInterval)
+	assert.Equal(t, service.CircuitClosed, connectors[1].Breaker.State())
+	assert.Equal(t, 5, connectors[1].Breaker.Status().FailureThreshold)
+	assert.Equal(t, "1m0s", connectors[1].Breaker.Status().CoolDown)
+}
+
+func TestRegistry_UnknownType(t *testing.T) {
+	_, err := NewRegistry().Build([]config.ConnectorConfig{{Name: "x", Type: "carrier-pigeon", URL: "http://x"}})
+
+	assert.ErrorContains(t, err, `unknown type "carrier-pigeon"`)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("custom", func(cfg config.ConnectorConfig) (service.APIClientInterface, error) {
+		return external.NewMockAPIClient(cfg.URL), nil
+	})
+
+	assert.Equal(t, []string{"custom", "mock-api"}, registry.Types())
+}
+
+func TestLoadMapping(t *testing.T) {
+	payload := []byte(`{"alerts": [{"src": "firewall", "sev": "high", "msg": "boom", "ts": "2024-06-01T12:00:00Z"}]}`)
+
+	t.Run("field_mapping shorthand", func(t *testing.T) {
+		mapper, err := LoadMapping(config.ConnectorConfig{FieldMapping: map[string]string{
+			"source": "src", "severity": "sev", "description": "msg", "created_at": "ts",
+		}})
+		require.NoError(t, err)
+
+		alerts, err := mapper.MapAlerts(payload)
+
+		require.NoError(t, err)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, "firewall", alerts[0].Source)
+		assert.Equal(t, "boom", alerts[0].Description)
+	})
+
+	t.Run("inline mapping", func(t *testing.T) {
+		mapper, err := LoadMapping(config.ConnectorConfig{Mapping: []byte(`{"fields": {
+			"source": {"path": "$.src"}, "severity": {"path": "$.sev"},
+			"description": {"path": "$.msg"}, "created_at": {"path": "$.ts"}}}`)})
+		require.NoError(t, err)
+
+		alerts, err := mapper.MapAlerts(payload)
+
+		require.NoError(t, err)
+		assert.Len(t, alerts, 1)
+	})
+
+	t.Run("example mapping file", func(t *testing.T) {
+		mapper, err := LoadMapping(config.ConnectorConfig{MappingFile: "../../mappings/example.yaml"})
+
+		require.NoError(t, err)
+		assert.NotNil(t, mapper)
+	})
+
+	t.Run("none configured", func(t *testing.T) {
+		mapper, err := LoadMapping(config.ConnectorConfig{})
+
+		require.NoError(t, err)
+		assert.Nil(t, mapper)
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		_, err := LoadMappings([]config.ConnectorConfig{{Name: "x", Mapping: []byte(`{"fields": {"title": {"path": "t"}}}`)}})
+
+		assert.ErrorContains(t, err, `connector "x"`)
+	})
+}
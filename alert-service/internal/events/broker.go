@@ -0,0 +1,199 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+)
+
+// SlowConsumerPolicy decides what happens when a subscriber's queue is full
+type SlowConsumerPolicy int
+
+const (
+	// DisconnectSlow closes the subscription; the client resumes from its last event ID
+	DisconnectSlow SlowConsumerPolicy = iota
+	// DropEvents discards the event for that subscriber and keeps it connected
+	DropEvents
+)
+
+// ParseSlowConsumerPolicy maps "drop" or "disconnect" onto a policy
+func ParseSlowConsumerPolicy(value string) SlowConsumerPolicy {
+	if value == "drop" {
+		return DropEvents
+	}
+	return DisconnectSlow
+}
+
+// Subscription receives live alert events from a Broker.
+// The events channel is closed when the subscriber is unsubscribed or
+// disconnected for falling behind.
+type Subscription struct {
+	events        chan models.AlertEvent
+	policy        SlowConsumerPolicy
+	disconnected  bool
+	droppedEvents int
+}
+
+// Events returns the channel of live events
+func (s *Subscription) Events() <-chan models.AlertEvent {
+	return s.events
+}
+
+// Broker fans out alert events to subscribers and keeps a bounded replay buffer.
+// Publish never blocks: a subscriber whose queue is full is handled by its policy.
+//
+// Event IDs continue from the broker's epoch, the time it was created in
+// microseconds, so IDs from an earlier process are always lower than any this
+// one issues and cannot be mistaken for its own.
+type Broker struct {
+	mu          sync.Mutex
+	epoch       uint64
+	nextID      uint64
+	buffer      []models.AlertEvent
+	bufferSize  int
+	queueSize   int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBroker creates a broker that retains the last bufferSize events for replay
+// and allows each subscriber to queue up to queueSize events
+func NewBroker(bufferSize, queueSize int) *Broker {
+	epoch := uint64(time.Now().UnixMicro())
+	return &Broker{
+		epoch:       epoch,
+		nextID:      epoch,
+		bufferSize:  bufferSize,
+		queueSize:   queueSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the next event ID, records the event for replay and delivers it
+// to every subscriber without blocking
+func (b *Broker) Publish(eventType string, alert models.Alert) models.AlertEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := models.AlertEvent{
+		ID:    b.nextID,
+		Type:  eventType,
+		Alert: alert,
+		Time:  time.Now(),
+	}
+	metrics.EventsPublished.Inc()
+
+	if b.bufferSize > 0 {
+		if len(b.buffer) >= b.bufferSize {
+			b.buffer = b.buffer[1:]
+		}
+		b.buffer = append(b.buffer, event)
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			metrics.EventsDropped.Inc()
+			if sub.policy == DropEvents {
+				sub.droppedEvents++
+				continue
+			}
+			b.disconnect(sub)
+		}
+	}
+
+	return event
+}
+
+// Subscribe registers a new subscriber and returns the buffered events after
+// lastEventID (pass 0 for none). Replay and registration happen atomically,
+// so no event is missed or duplicated between the two.
+//
+// reset reports that lastEventID cannot be honoured because it was issued by
+// an earlier process or has already left the replay buffer. The subscriber may
+// have missed events, and the whole buffer is replayed.
+func (b *Broker) Subscribe(lastEventID uint64, policy SlowConsumerPolicy) (sub *Subscription, replay []models.AlertEvent, reset bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastEventID > 0 {
+		reset = !b.resumable(lastEventID)
+		for _, event := range b.buffer {
+			if reset || event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	sub = &Subscription{
+		events: make(chan models.AlertEvent, b.queueSize),
+		policy: policy,
+	}
+	b.subscribers[sub] = struct{}{}
+	metrics.EventSubscribers.Inc()
+
+	return sub, replay, reset
+}
+
+// resumable reports whether every event after lastEventID can still be
+// replayed; callers must hold b.mu
+func (b *Broker) resumable(lastEventID uint64) bool {
+	if lastEventID < b.epoch || lastEventID > b.nextID {
+		return false
+	}
+	if len(b.buffer) == 0 {
+		return lastEventID == b.nextID
+	}
+	return lastEventID >= b.buffer[0].ID-1
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		b.remove(sub)
+	}
+}
+
+// Disconnected reports whether the subscription was closed for falling behind
+func (b *Broker) Disconnected(sub *Subscription) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return sub.disconnected
+}
+
+// DroppedEvents returns how many events were discarded for a DropEvents subscriber
+func (b *Broker) DroppedEvents(sub *Subscription) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return sub.droppedEvents
+}
+
+// SubscriberCount returns the number of active subscribers
+func (b *Broker) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers)
+}
+
+// disconnect closes a slow subscriber; callers must hold b.mu
+func (b *Broker) disconnect(sub *Subscription) {
+	sub.disconnected = true
+	metrics.SlowSubscribersDisconnected.Inc()
+	b.remove(sub)
+}
+
+// remove unregisters a subscriber and closes its channel; callers must hold b.mu
+func (b *Broker) remove(sub *Subscription) {
+	delete(b.subscribers, sub)
+	close(sub.events)
+	metrics.EventSubscribers.Dec()
+}
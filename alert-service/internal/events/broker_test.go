@@ -0,0 +1,157 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_PublishDeliversToSubscribers(t *testing.T) {
+	broker := NewBroker(10, 10)
+	sub, replay, reset := broker.Subscribe(0, DisconnectSlow)
+	defer broker.Unsubscribe(sub)
+
+	event := broker.Publish(models.EventAlertCreated, models.Alert{ID: "1"})
+
+	assert.Empty(t, replay)
+	assert.False(t, reset)
+	assert.Equal(t, broker.epoch+1, event.ID)
+	received := <-sub.Events()
+	assert.Equal(t, "1", received.Alert.ID)
+	assert.Equal(t, models.EventAlertCreated, received.Type)
+}
+
+func TestBroker_ReplayAfterLastEventID(t *testing.T) {
+	broker := NewBroker(3, 10)
+	var ids []uint64
+	for _, id := range []string{"1", "2", "3", "4"} {
+		ids = append(ids, broker.Publish(models.EventAlertCreated, models.Alert{ID: id}).ID)
+	}
+
+	t.Run("resumes after last event", func(t *testing.T) {
+		sub, replay, reset := broker.Subscribe(ids[1], DisconnectSlow)
+		defer broker.Unsubscribe(sub)
+
+		assert.False(t, reset)
+		require.Len(t, replay, 2)
+		assert.Equal(t, ids[2], replay[0].ID)
+		assert.Equal(t, ids[3], replay[1].ID)
+	})
+
+	t.Run("resumes after the last event before eviction", func(t *testing.T) {
+		sub, replay, reset := broker.Subscribe(ids[0], DisconnectSlow)
+		defer broker.Unsubscribe(sub)
+
+		assert.False(t, reset)
+		require.Len(t, replay, 3)
+	})
+
+	t.Run("up to date client", func(t *testing.T) {
+		sub, replay, reset := broker.Subscribe(ids[3], DisconnectSlow)
+		defer broker.Unsubscribe(sub)
+
+		assert.False(t, reset)
+		assert.Empty(t, replay)
+	})
+
+	t.Run("resets when the last event was evicted", func(t *testing.T) {
+		sub, replay, reset := broker.Subscribe(ids[0]-1, DisconnectSlow)
+		defer broker.Unsubscribe(sub)
+
+		assert.True(t, reset)
+		require.Len(t, replay, 3)
+		assert.Equal(t, ids[1], replay[0].ID)
+	})
+
+	t.Run("resets on an ID from an earlier process", func(t *testing.T) {
+		sub, replay, reset := broker.Subscribe(42, DisconnectSlow)
+		defer broker.Unsubscribe(sub)
+
+		assert.True(t, reset)
+		assert.Len(t, replay, 3)
+	})
+
+	t.Run("resets on an ID this process has not issued", func(t *testing.T) {
+		sub, replay, reset := broker.Subscribe(ids[3]+1, DisconnectSlow)
+		defer broker.Unsubscribe(sub)
+
+		assert.True(t, reset)
+		assert.Len(t, replay, 3)
+	})
+}
+
+func TestBroker_IDsContinueAcrossRestarts(t *testing.T) {
+	before := NewBroker(10, 10)
+	last := before.Publish(models.EventAlertCreated, models.Alert{ID: "1"}).ID
+	before.Publish(models.EventAlertCreated, models.Alert{ID: "2"})
+
+	// Epochs are in microseconds; a real restart is never this quick
+	time.Sleep(time.Millisecond)
+	after := NewBroker(10, 10)
+	first := after.Publish(models.EventAlertCreated, models.Alert{ID: "3"}).ID
+
+	assert.Greater(t, first, last+1, "the new process issues higher IDs")
+	sub, replay, reset := after.Subscribe(last, DisconnectSlow)
+	defer after.Unsubscribe(sub)
+	assert.True(t, reset)
+	require.Len(t, replay, 1)
+	assert.Equal(t, first, replay[0].ID)
+}
+
+func TestBroker_ResetWithoutReplayBuffer(t *testing.T) {
+	broker := NewBroker(0, 10)
+	first := broker.Publish(models.EventAlertCreated, models.Alert{ID: "1"}).ID
+	last := broker.Publish(models.EventAlertCreated, models.Alert{ID: "2"}).ID
+
+	_, _, reset := broker.Subscribe(last, DisconnectSlow)
+	assert.False(t, reset)
+	_, replay, reset := broker.Subscribe(first, DisconnectSlow)
+	assert.True(t, reset, "the event after first cannot be replayed")
+	assert.Empty(t, replay)
+}
+
+func TestBroker_SlowSubscriberIsDisconnected(t *testing.T) {
+	broker := NewBroker(10, 1)
+	slow, _, _ := broker.Subscribe(0, DisconnectSlow)
+	fast, _, _ := broker.Subscribe(0, DisconnectSlow)
+
+	broker.Publish(models.EventAlertCreated, models.Alert{ID: "1"})
+	<-fast.Events()
+	broker.Publish(models.EventAlertCreated, models.Alert{ID: "2"})
+
+	assert.True(t, broker.Disconnected(slow))
+	assert.False(t, broker.Disconnected(fast))
+	assert.Equal(t, 1, broker.SubscriberCount())
+
+	// The queued event is still delivered before the channel closes
+	event, ok := <-slow.Events()
+	assert.True(t, ok)
+	assert.Equal(t, "1", event.Alert.ID)
+	_, ok = <-slow.Events()
+	assert.False(t, ok)
+
+	broker.Unsubscribe(fast)
+	broker.Unsubscribe(slow)
+	assert.Equal(t, 0, broker.SubscriberCount())
+}
+
+func TestBroker_DropEventsPolicyKeepsSubscriber(t *testing.T) {
+	broker := NewBroker(10, 1)
+	sub, _, _ := broker.Subscribe(0, DropEvents)
+	defer broker.Unsubscribe(sub)
+
+	broker.Publish(models.EventAlertCreated, models.Alert{ID: "1"})
+	broker.Publish(models.EventAlertCreated, models.Alert{ID: "2"})
+	broker.Publish(models.EventAlertCreated, models.Alert{ID: "3"})
+
+	assert.False(t, broker.Disconnected(sub))
+	assert.Equal(t, 2, broker.DroppedEvents(sub))
+	assert.Equal(t, "1", (<-sub.Events()).Alert.ID)
+
+	broker.Publish(models.EventAlertCreated, models.Alert{ID: "4"})
+	assert.Equal(t, "4", (<-sub.Events()).Alert.ID)
+}
@@ -0,0 +1,245 @@
+// Package export writes alerts as CSV, NDJSON or XLSX for download.
+//
+// Writers stream: each alert is encoded as it is written, so an export of
+// any size holds one alert at a time. Every format has a header (CSV and
+// XLSX) or keys (NDJSON) naming the selected columns, in the order given.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+// Export formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// ColumnWholeEvent is the upstream event, decoded from the stored JSON. It is
+// left out unless asked for, since it is by far the largest column.
+const ColumnWholeEvent = "whole_event"
+
+// Columns lists every exportable column
+var Columns = []string{
+	"id", "source", "severity", "description", "status", "fingerprint",
+	"enrichment_type", "ip_address", "created_at", "acknowledged_at", "resolved_at",
+	ColumnWholeEvent,
+}
+
+// DefaultColumns are exported when no columns are selected
+var DefaultColumns = Columns[:len(Columns)-1]
+
+var (
+	// ErrUnsupportedFormat is returned for a format other than csv, ndjson or xlsx
+	ErrUnsupportedFormat = errors.New("export: unsupported format")
+	// ErrUnknownColumn is returned for a column not in Columns
+	ErrUnknownColumn = errors.New("export: unknown column")
+)
+
+// ValidateFormat checks that format is one of the export formats
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatCSV, FormatNDJSON, FormatXLSX:
+		return nil
+	}
+	return fmt.Errorf("%w %q: use csv, ndjson or xlsx", ErrUnsupportedFormat, format)
+}
+
+// ContentType is the media type of an export format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+}
+
+// ParseColumns validates a column selection, dropping repeats. An empty
+// selection is DefaultColumns.
+func ParseColumns(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return slices.Clone(DefaultColumns), nil
+	}
+	columns := make([]string, 0, len(names))
+	for _, name := range names {
+		if !slices.Contains(Columns, name) {
+			return nil, fmt.Errorf("%w %q", ErrUnknownColumn, name)
+		}
+		if !slices.Contains(columns, name) {
+			columns = append(columns, name)
+		}
+	}
+	return columns, nil
+}
+
+// Writer encodes alerts in an export format
+type Writer interface {
+	// Write encodes one alert
+	Write(alert models.Alert) error
+	// Flush writes buffered rows through to the underlying writer
+	Flush() error
+	// Close finishes the export; it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter starts an export of the given columns to w
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w, columns: columns}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	}
+	return nil, ValidateFormat(format)
+}
+
+// formatTime renders optional times as RFC 3339 in UTC
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// text renders a column as a cell: empty for missing values, and the stored
+// JSON for whole_event
+func text(alert models.Alert, column string) string {
+	switch column {
+	case "id":
+		return alert.ID
+	case "source":
+		return alert.Source
+	case "severity":
+		return alert.Severity
+	case "description":
+		return alert.Description
+	case "status":
+		return alert.Status
+	case "fingerprint":
+		return alert.Fingerprint
+	case "enrichment_type":
+		return deref(alert.EnrichmentType)
+	case "ip_address":
+		return deref(alert.IPAddress)
+	case "created_at":
+		return formatTime(&alert.CreatedAt)
+	case "acknowledged_at":
+		return formatTime(alert.AcknowledgedAt)
+	case "resolved_at":
+		return formatTime(alert.ResolvedAt)
+	case ColumnWholeEvent:
+		return string(alert.WholeEvent)
+	}
+	return ""
+}
+
+// value renders a column as a JSON value: null for missing values, and the
+// decoded event for whole_event
+func value(alert models.Alert, column string) interface{} {
+	switch column {
+	case "enrichment_type", "ip_address", "acknowledged_at", "resolved_at":
+		if s := text(alert, column); s != "" {
+			return s
+		}
+		return nil
+	case ColumnWholeEvent:
+		if len(alert.WholeEvent) == 0 {
+			return nil
+		}
+		if json.Valid(alert.WholeEvent) {
+			return json.RawMessage(alert.WholeEvent)
+		}
+		return string(alert.WholeEvent)
+	}
+	return text(alert, column)
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	row     []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	c := &csvWriter{w: csv.NewWriter(w), columns: columns, row: make([]string, len(columns))}
+	if err := c.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *csvWriter) Write(alert models.Alert) error {
+	for i, column := range c.columns {
+		c.row[i] = neutralizeFormula(text(alert, column))
+	}
+	return c.w.Write(c.row)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// neutralizeFormula prefixes a quote to cells a spreadsheet would evaluate
+// as a formula, since descriptions and events come from upstream systems
+func neutralizeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+type ndjsonWriter struct {
+	w       io.Writer
+	columns []string
+	line    []byte
+}
+
+// Write encodes the alert as one JSON object with the columns as keys, in order
+func (n *ndjsonWriter) Write(alert models.Alert) error {
+	n.line = append(n.line[:0], '{')
+	for i, column := range n.columns {
+		if i > 0 {
+			n.line = append(n.line, ',')
+		}
+		key, _ := json.Marshal(column)
+		val, err := json.Marshal(value(alert, column))
+		if err != nil {
+			return fmt.Errorf("export: encoding %s of alert %s: %w", column, alert.ID, err)
+		}
+		n.line = append(append(append(n.line, key...), ':'), val...)
+	}
+	n.line = append(n.line, '}', '\n')
+	_, err := n.w.Write(n.line)
+	return err
+}
+
+func (n *ndjsonWriter) Flush() error { return nil }
+
+func (n *ndjsonWriter) Close() error { return nil }
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAlert() models.Alert {
+	ip := "10.0.0.1"
+	resolvedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	return models.Alert{
+		ID:          "5b3c1d2e-0000-4000-8000-000000000001",
+		Source:      "firewall",
+		Severity:    "high",
+		Description: "=HYPERLINK(\"http://evil\")",
+		WholeEvent:  []byte(`{"indicators": {"src_ip": "10.0.0.1"}}`),
+		IPAddress:   &ip,
+		Fingerprint: "fp-1",
+		Status:      models.StatusResolved,
+		ResolvedAt:  &resolvedAt,
+		CreatedAt:   time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+func writeAll(t *testing.T, format string, columns []string, alerts ...models.Alert) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, columns)
+	require.NoError(t, err)
+	for _, alert := range alerts {
+		require.NoError(t, w.Write(alert))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns(nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultColumns, columns)
+	assert.NotContains(t, columns, ColumnWholeEvent)
+
+	columns, err = ParseColumns([]string{"severity", "id", "severity", "whole_event"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"severity", "id", "whole_event"}, columns)
+
+	_, err = ParseColumns([]string{"id", "password"})
+	assert.ErrorIs(t, err, ErrUnknownColumn)
+}
+
+func TestNewWriter_UnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("pdf", io.Discard, DefaultColumns)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestCSVWriter(t *testing.T) {
+	data := writeAll(t, FormatCSV, []string{"id", "description", "ip_address", "enrichment_type", "created_at", "whole_event"}, testAlert())
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"id", "description", "ip_address", "enrichment_type", "created_at", "whole_event"}, records[0])
+	assert.Equal(t, []string{
+		"5b3c1d2e-0000-4000-8000-000000000001",
+		`'=HYPERLINK("http://evil")`,
+		"10.0.0.1",
+		"",
+		"2025-01-01T10:30:00Z",
+		`{"indicators": {"src_ip": "10.0.0.1"}}`,
+	}, records[1])
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	alert := testAlert()
+	other := testAlert()
+	other.WholeEvent = []byte("not json")
+	data := writeAll(t, FormatNDJSON, []string{"severity", "id", "enrichment_type", "resolved_at", "whole_event"}, alert, other)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t,
+		`{"severity":"high","id":"5b3c1d2e-0000-4000-8000-000000000001","enrichment_type":null,"resolved_at":"2025-01-01T12:00:00Z","whole_event":{"indicators":{"src_ip":"10.0.0.1"}}}`,
+		lines[0], "keys in column order, whole_event decoded")
+
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, "not json", row["whole_event"])
+}
+
+// readSheet returns the cells of the only worksheet of an XLSX file
+func readSheet(t *testing.T, data []byte) [][]string {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	names := make(map[string]*zip.File)
+	for _, f := range z.File {
+		names[f.Name] = f
+	}
+	for _, part := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		assert.Contains(t, names, part)
+	}
+	require.Contains(t, names, "xl/worksheets/sheet1.xml")
+	f, err := names["xl/worksheets/sheet1.xml"].Open()
+	require.NoError(t, err)
+	defer f.Close()
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Type string `xml:"t,attr"`
+				Text string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.NewDecoder(f).Decode(&sheet))
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		var cells []string
+		for _, cell := range row.Cells {
+			assert.Equal(t, "inlineStr", cell.Type)
+			cells = append(cells, cell.Text)
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
+
+func TestXLSXWriter(t *testing.T) {
+	alert := testAlert()
+	alert.Description = "  <script> & \"quotes\"  "
+	data := writeAll(t, FormatXLSX, []string{"id", "description", "status", "acknowledged_at"}, alert, testAlert())
+
+	rows := readSheet(t, data)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"id", "description", "status", "acknowledged_at"}, rows[0])
+	assert.Equal(t, []string{alert.ID, "  <script> & \"quotes\"  ", "resolved", ""}, rows[1])
+	assert.Equal(t, `=HYPERLINK("http://evil")`, rows[2][1], "inline strings are never formulas, so nothing is escaped")
+}
+
+func TestXLSXWriter_Limits(t *testing.T) {
+	alert := testAlert()
+	alert.WholeEvent = []byte(`"` + strings.Repeat("é", maxXLSXCell+10) + `"`)
+	rows := readSheet(t, writeAll(t, FormatXLSX, []string{ColumnWholeEvent}, alert))
+	assert.Equal(t, maxXLSXCell, len([]rune(rows[1][0])))
+
+	w, err := newXLSXWriter(io.Discard, DefaultColumns)
+	require.NoError(t, err)
+	w.rows = MaxXLSXRows
+	assert.ErrorIs(t, w.Write(alert), ErrTooManyRows)
+}
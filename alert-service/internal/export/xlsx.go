@@ -0,0 +1,134 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"censys_alert_system/internal/models"
+)
+
+// MaxXLSXRows is the number of alerts that fit on one worksheet, below the
+// header row
+const MaxXLSXRows = 1<<20 - 1
+
+// maxXLSXCell is the most characters a spreadsheet cell holds; longer
+// values, in practice only whole_event, are cut short
+const maxXLSXCell = 32767
+
+// ErrTooManyRows is returned when an XLSX export outgrows one worksheet
+var ErrTooManyRows = fmt.Errorf("export: xlsx holds at most %d alerts; use csv or ndjson, or narrow the filter", MaxXLSXRows)
+
+// xlsxParts are the fixed parts of a workbook with a single worksheet
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Alerts" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxWriter writes a workbook whose only worksheet is streamed row by row.
+// Cells are inline strings, so no shared string table has to be held in
+// memory, and a spreadsheet never evaluates them as formulas.
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	columns []string
+	rows    int
+}
+
+func newXLSXWriter(w io.Writer, columns []string) (*xlsxWriter, error) {
+	z := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxWriter{zip: z, sheet: bufio.NewWriter(f), columns: columns}
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	x.writeRow(columns)
+	return x, nil
+}
+
+func (x *xlsxWriter) Write(alert models.Alert) error {
+	if x.rows >= MaxXLSXRows {
+		return ErrTooManyRows
+	}
+	x.rows++
+	row := make([]string, len(x.columns))
+	for i, column := range x.columns {
+		row[i] = text(alert, column)
+	}
+	return x.writeRow(row)
+}
+
+func (x *xlsxWriter) writeRow(cells []string) error {
+	x.sheet.WriteString("<row>")
+	for _, cell := range cells {
+		x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(x.sheet, []byte(truncateCell(cell))); err != nil {
+			return err
+		}
+		x.sheet.WriteString("</t></is></c>")
+	}
+	_, err := x.sheet.WriteString("</row>")
+	return err
+}
+
+// truncateCell cuts a value to the cell limit without splitting a character
+func truncateCell(cell string) string {
+	if len(cell) <= maxXLSXCell || utf8.RuneCountInString(cell) <= maxXLSXCell {
+		return cell
+	}
+	n := 0
+	for i := range cell {
+		if n == maxXLSXCell {
+			return cell[:i]
+		}
+		n++
+	}
+	return cell
+}
+
+// Flush writes the buffered rows into the zip stream. The zip writer
+// compresses as it goes, so some bytes may still wait for more rows.
+func (x *xlsxWriter) Flush() error {
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Flush()
+}
+
+func (x *xlsxWriter) Close() error {
+	x.sheet.WriteString("</sheetData></worksheet>")
+	return errors.Join(x.sheet.Flush(), x.zip.Close())
+}
@@ -0,0 +1,125 @@
+package formats
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cefHeaderFields counts Version|Vendor|Product|DeviceVersion|SignatureID|Name|Severity|Extension
+const cefHeaderFields = 8
+
+// ParseCEF parses an ArcSight CEF record:
+//
+//	CEF:Version|Device Vendor|Device Product|Device Version|Signature ID|Name|Severity|Extension
+//
+// Header fields may escape "|" and "\" with a backslash. The extension is a
+// list of key=value pairs separated by spaces, where values may contain spaces
+// and escape "=", "\", newlines (\n) and carriage returns (\r).
+func ParseCEF(text string) (*Event, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(text), "CEF:")
+	if !ok {
+		return nil, fmt.Errorf("formats: missing CEF prefix")
+	}
+
+	fields, err := splitHeader(rest, cefHeaderFields)
+	if err != nil {
+		return nil, err
+	}
+	if fields[0] == "" {
+		return nil, fmt.Errorf("formats: missing CEF version")
+	}
+
+	extensions, err := parseCEFExtension(fields[7])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		Format:        FormatCEF,
+		Version:       fields[0],
+		DeviceVendor:  fields[1],
+		DeviceProduct: fields[2],
+		DeviceVersion: fields[3],
+		SignatureID:   fields[4],
+		Name:          fields[5],
+		Severity:      strings.TrimSpace(fields[6]),
+		Extensions:    extensions,
+	}, nil
+}
+
+// parseCEFExtension splits the extension into key/value pairs. A key is a run
+// of key characters directly before an unescaped "=" and preceded by a space
+// (or the start of the extension); everything up to the next key is its value.
+func parseCEFExtension(ext string) (map[string]string, error) {
+	extensions := make(map[string]string)
+
+	type pair struct{ keyStart, eq int }
+	var pairs []pair
+	for i := 0; i < len(ext); i++ {
+		switch ext[i] {
+		case '\\':
+			i++
+		case '=':
+			start := i
+			for start > 0 && isCEFKeyChar(ext[start-1]) {
+				start--
+			}
+			if start == i || (start > 0 && ext[start-1] != ' ') {
+				// An unescaped "=" inside a value; tolerate it
+				continue
+			}
+			pairs = append(pairs, pair{keyStart: start, eq: i})
+		}
+	}
+
+	if len(pairs) == 0 {
+		if strings.TrimSpace(ext) != "" {
+			return nil, fmt.Errorf("formats: invalid CEF extension")
+		}
+		return extensions, nil
+	}
+	if strings.TrimSpace(ext[:pairs[0].keyStart]) != "" {
+		return nil, fmt.Errorf("formats: invalid CEF extension")
+	}
+
+	for i, p := range pairs {
+		end := len(ext)
+		if i+1 < len(pairs) {
+			end = pairs[i+1].keyStart
+		}
+		key := ext[p.keyStart:p.eq]
+		extensions[key] = unescapeCEFValue(strings.TrimRight(ext[p.eq+1:end], " "))
+	}
+	return extensions, nil
+}
+
+func isCEFKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == '-' || c == '[' || c == ']'
+}
+
+func unescapeCEFValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '\\' || i+1 == len(value) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			// \= \\ and, leniently, \| or any other escaped character
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
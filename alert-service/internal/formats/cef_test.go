@@ -0,0 +1,147 @@
+package formats
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCEF(t *testing.T) {
+	t.Run("header and extension", func(t *testing.T) {
+		event, err := ParseCEF(`CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232 msg=Detected a threat. No action needed. rt=1705314600000`)
+		require.NoError(t, err)
+
+		assert.Equal(t, FormatCEF, event.Format)
+		assert.Equal(t, "0", event.Version)
+		assert.Equal(t, "Security", event.DeviceVendor)
+		assert.Equal(t, "threatmanager", event.DeviceProduct)
+		assert.Equal(t, "1.0", event.DeviceVersion)
+		assert.Equal(t, "100", event.SignatureID)
+		assert.Equal(t, "worm successfully stopped", event.Name)
+		assert.Equal(t, "10", event.Severity)
+		assert.Equal(t, map[string]string{
+			"src": "10.0.0.1",
+			"dst": "2.1.2.2",
+			"spt": "1232",
+			"msg": "Detected a threat. No action needed.",
+			"rt":  "1705314600000",
+		}, event.Extensions)
+		assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), event.Timestamp())
+	})
+
+	t.Run("escaping", func(t *testing.T) {
+		event, err := ParseCEF(`CEF:0|security|threat\|manager|1.0|100|detected a \\ in packet|10|act=blocked a \= sign fname=C:\\temp\\x.exe msg=line1\nline2`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "threat|manager", event.DeviceProduct)
+		assert.Equal(t, `detected a \ in packet`, event.Name)
+		assert.Equal(t, "blocked a = sign", event.Extensions["act"])
+		assert.Equal(t, `C:\temp\x.exe`, event.Extensions["fname"])
+		assert.Equal(t, "line1\nline2", event.Extensions["msg"])
+	})
+
+	t.Run("empty extension", func(t *testing.T) {
+		event, err := ParseCEF("CEF:1|Vendor|Product|2|sig|Name|Low|")
+		require.NoError(t, err)
+
+		assert.Empty(t, event.Extensions)
+		assert.Equal(t, "Low", event.Severity)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, text := range []string{
+			"",
+			"LEEF:1.0|a|b|c|d|",
+			"CEF:0|Vendor|Product|1.0|100|Name",
+			"CEF:|Vendor|Product|1.0|100|Name|5|",
+			"CEF:0|Vendor|Product|1.0|100|Name|5|just some text",
+		} {
+			_, err := ParseCEF(text)
+			assert.Error(t, err, text)
+		}
+	})
+}
+
+func TestMapSeverity(t *testing.T) {
+	cases := map[string]string{
+		"0": "low", "3": "low", "4": "medium", "6": "medium", "7": "high", "8": "high",
+		"9": "critical", "10": "critical", "Low": "low", "Medium": "medium",
+		"High": "high", "Very-High": "critical",
+	}
+	for input, want := range cases {
+		got, ok := MapSeverity(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "11", "-1", "Unknown", "urgent"} {
+		_, ok := MapSeverity(input)
+		assert.False(t, ok, input)
+	}
+}
+
+func TestEvent_ToExternalAlert(t *testing.T) {
+	event, err := ParseCEF(`CEF:0|Vendor|IDS|1.0|42|Port scan|8|src=192.0.2.10 dst=not-an-ip dpt=22 suser=alice custom=value`)
+	require.NoError(t, err)
+
+	alert, err := event.ToExternalAlert("ids", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "ids", alert.Source)
+	assert.Equal(t, "high", alert.Severity)
+	assert.Equal(t, "Port scan", alert.Description)
+	assert.True(t, alert.CreatedAt.IsZero())
+	assert.Equal(t, map[string]string{
+		"src_ip":   "192.0.2.10",
+		"dst_port": "22",
+		"src_user": "alice",
+	}, alert.Indicators)
+
+	var raw Event
+	require.NoError(t, json.Unmarshal(alert.Raw, &raw))
+	assert.Equal(t, "value", raw.Extensions["custom"])
+	assert.Equal(t, "not-an-ip", raw.Extensions["dst"])
+}
+
+func TestEvent_ToExternalAlertFallbackSeverity(t *testing.T) {
+	event, err := ParseCEF("CEF:0|Vendor|Product|1|sig|Name|Unknown|")
+	require.NoError(t, err)
+
+	_, err = event.ToExternalAlert("ids", "")
+	assert.Error(t, err)
+
+	alert, err := event.ToExternalAlert("ids", "medium")
+	require.NoError(t, err)
+	assert.Equal(t, "medium", alert.Severity)
+}
+
+func FuzzParseCEF(f *testing.F) {
+	f.Add(`CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232`)
+	f.Add(`CEF:0|security|threat\|manager|1.0|100|detected a \\ in packet|10|act=blocked a \= sign`)
+	f.Add(`CEF:0|a|b|c|d|e|f|k=v\`)
+	f.Add(`CEF:0|a|b|c|d|e|f|=v k= x==y`)
+	f.Add("CEF:|||||||")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		event, err := ParseCEF(text)
+		if err != nil {
+			return
+		}
+
+		if event.Format != FormatCEF || event.Version == "" {
+			t.Fatalf("unexpected header %+v", event)
+		}
+		for key := range event.Extensions {
+			if key == "" || strings.ContainsAny(key, " =") {
+				t.Fatalf("invalid extension key %q", key)
+			}
+		}
+		if _, err := event.ToExternalAlert("ids", "low"); err != nil {
+			t.Fatalf("ToExternalAlert: %v", err)
+		}
+	})
+}
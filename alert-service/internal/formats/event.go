@@ -0,0 +1,253 @@
+// Package formats parses vendor security event formats (ArcSight CEF and IBM
+// LEEF) that arrive over syslog, webhooks or files.
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"censys_alert_system/external"
+)
+
+// Event formats
+const (
+	FormatCEF  = "cef"
+	FormatLEEF = "leef"
+)
+
+// Event is a parsed CEF or LEEF record. LEEF has no name or severity in its
+// header; those come from the "sev" attribute and are left empty otherwise.
+type Event struct {
+	Format        string            `json:"format"`
+	Version       string            `json:"version"`
+	DeviceVendor  string            `json:"device_vendor"`
+	DeviceProduct string            `json:"device_product"`
+	DeviceVersion string            `json:"device_version"`
+	SignatureID   string            `json:"signature_id"`
+	Name          string            `json:"name,omitempty"`
+	Severity      string            `json:"severity,omitempty"`
+	Extensions    map[string]string `json:"extensions"`
+}
+
+// IsSecurityEvent reports whether text looks like a CEF or LEEF record
+func IsSecurityEvent(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "CEF:") || strings.HasPrefix(text, "LEEF:")
+}
+
+// Parse parses a single CEF or LEEF record, choosing the parser by prefix
+func Parse(text string) (*Event, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "CEF:"):
+		return ParseCEF(text)
+	case strings.HasPrefix(text, "LEEF:"):
+		return ParseLEEF(text)
+	default:
+		return nil, fmt.Errorf("formats: not a CEF or LEEF record")
+	}
+}
+
+// indicatorKeys promotes well-known CEF and LEEF fields to indicator names
+var indicatorKeys = map[string]string{
+	"src":           "src_ip",
+	"dst":           "dst_ip",
+	"c6a2":          "src_ip",
+	"c6a3":          "dst_ip",
+	"spt":           "src_port",
+	"srcPort":       "src_port",
+	"dpt":           "dst_port",
+	"dstPort":       "dst_port",
+	"suser":         "src_user",
+	"usrName":       "src_user",
+	"duser":         "dst_user",
+	"shost":         "src_host",
+	"srcHostName":   "src_host",
+	"identHostName": "src_host",
+	"dhost":         "dst_host",
+	"dstHostName":   "dst_host",
+	"smac":          "src_mac",
+	"srcMAC":        "src_mac",
+	"dmac":          "dst_mac",
+	"dstMAC":        "dst_mac",
+	"proto":         "protocol",
+	"request":       "url",
+	"url":           "url",
+	"fname":         "file_name",
+	"fileHash":      "file_hash",
+}
+
+// Indicators returns the standard observables of the event under stable
+// names. Addresses that are not valid IPs are left out.
+func (e *Event) Indicators() map[string]string {
+	indicators := make(map[string]string)
+	for key, value := range e.Extensions {
+		name, ok := indicatorKeys[key]
+		if !ok || value == "" {
+			continue
+		}
+		if (name == "src_ip" || name == "dst_ip") && net.ParseIP(value) == nil {
+			continue
+		}
+		// IPv4 fields win over the IPv6 custom address fields
+		if _, exists := indicators[name]; exists && (key == "c6a2" || key == "c6a3") {
+			continue
+		}
+		indicators[name] = value
+	}
+	return indicators
+}
+
+// AlertSeverity maps the event severity onto low/medium/high/critical.
+// CEF uses 0-10 or Low/Medium/High/Very-High in the header; LEEF uses the
+// 0-10 "sev" attribute. It returns false when the event has no usable severity.
+func (e *Event) AlertSeverity() (string, bool) {
+	severity := e.Severity
+	if e.Format == FormatLEEF {
+		severity = e.Extensions["sev"]
+	}
+	return MapSeverity(severity)
+}
+
+// MapSeverity maps a 0-10 vendor severity (or its CEF name) onto our scale
+func MapSeverity(severity string) (string, bool) {
+	severity = strings.TrimSpace(severity)
+	switch strings.ToLower(severity) {
+	case "low":
+		return "low", true
+	case "medium":
+		return "medium", true
+	case "high":
+		return "high", true
+	case "very-high":
+		return "critical", true
+	}
+
+	level, err := strconv.Atoi(severity)
+	if err != nil || level < 0 || level > 10 {
+		return "", false
+	}
+	switch {
+	case level <= 3:
+		return "low", true
+	case level <= 6:
+		return "medium", true
+	case level <= 8:
+		return "high", true
+	default:
+		return "critical", true
+	}
+}
+
+// Description returns the human-readable summary of the event
+func (e *Event) Description() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	if msg := e.Extensions["msg"]; msg != "" {
+		return msg
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s event %s", e.DeviceVendor, e.DeviceProduct, e.SignatureID))
+}
+
+// timeLayouts are the timestamp formats CEF and LEEF devices commonly send
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"Jan 02 2006 15:04:05.000 MST",
+	"Jan 02 2006 15:04:05 MST",
+	"Jan 02 2006 15:04:05.000",
+	"Jan 02 2006 15:04:05",
+	"Jan 2 2006 15:04:05",
+}
+
+// Timestamp returns the event time from rt/devTime (falling back to end and
+// start), or the zero time when none is present or parseable
+func (e *Event) Timestamp() time.Time {
+	for _, key := range []string{"rt", "devTime", "end", "start"} {
+		if ts, ok := parseEventTime(e.Extensions[key]); ok {
+			return ts
+		}
+	}
+	return time.Time{}
+}
+
+func parseEventTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	// Milliseconds since the epoch
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms).UTC(), true
+	}
+
+	for _, layout := range timeLayouts {
+		if ts, err := time.Parse(layout, value); err == nil {
+			return ts.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ToExternalAlert converts the event into an alert for the ingestion pipeline.
+// fallbackSeverity is used when the event carries no usable severity; the
+// parsed event, including the full extension map, becomes the raw payload.
+func (e *Event) ToExternalAlert(source, fallbackSeverity string) (external.ExternalAlert, error) {
+	severity, ok := e.AlertSeverity()
+	if !ok {
+		if fallbackSeverity == "" {
+			return external.ExternalAlert{}, fmt.Errorf("formats: %s event has no usable severity", e.Format)
+		}
+		severity = fallbackSeverity
+	}
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return external.ExternalAlert{}, fmt.Errorf("formats: marshal event: %w", err)
+	}
+
+	return external.ExternalAlert{
+		Source:      source,
+		Severity:    severity,
+		Description: e.Description(),
+		CreatedAt:   e.Timestamp(),
+		Raw:         raw,
+		Indicators:  e.Indicators(),
+	}, nil
+}
+
+// splitHeader splits s on unescaped '|' into at most n fields, unescaping
+// "\|" and "\\" in all but the last field, which is returned verbatim
+func splitHeader(s string, n int) ([]string, error) {
+	fields := make([]string, 0, n)
+	var field strings.Builder
+	for i := 0; i < len(s); i++ {
+		if len(fields) == n-1 {
+			fields = append(fields, s[i:])
+			return fields, nil
+		}
+
+		c := s[i]
+		if c == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\') {
+			field.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		if c == '|' {
+			fields = append(fields, field.String())
+			field.Reset()
+			continue
+		}
+		field.WriteByte(c)
+	}
+
+	if len(fields) == n-1 {
+		return append(fields, field.String()), nil
+	}
+	return nil, fmt.Errorf("formats: expected %d header fields, got %d", n, len(fields)+1)
+}
@@ -0,0 +1,121 @@
+package formats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseLEEF parses an IBM LEEF 1.0 or 2.0 record:
+//
+//	LEEF:1.0|Vendor|Product|Version|EventID|key=value<TAB>key=value
+//	LEEF:2.0|Vendor|Product|Version|EventID|DelimiterCharacter|key=value...
+//
+// LEEF 1.0 attributes are tab-separated. LEEF 2.0 names the delimiter as a
+// single character or a hex code (x5E or 0x5E), defaulting to tab when empty.
+// A backslash escapes the delimiter, "=" or "\" inside a value.
+func ParseLEEF(text string) (*Event, error) {
+	rest, ok := strings.CutPrefix(strings.TrimLeft(text, " \r\n"), "LEEF:")
+	if !ok {
+		return nil, fmt.Errorf("formats: missing LEEF prefix")
+	}
+	rest = strings.TrimRight(rest, "\r\n")
+
+	version, _, _ := strings.Cut(rest, "|")
+	var fields []string
+	var err error
+	delimiter := byte('\t')
+	switch version {
+	case "1.0", "1":
+		fields, err = splitHeader(rest, 6)
+		if err != nil {
+			return nil, err
+		}
+	case "2.0", "2":
+		fields, err = splitHeader(rest, 7)
+		if err != nil {
+			return nil, err
+		}
+		delimiter, err = parseLEEFDelimiter(fields[5])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields[:5], fields[6])
+	default:
+		return nil, fmt.Errorf("formats: unsupported LEEF version %q", version)
+	}
+
+	return &Event{
+		Format:        FormatLEEF,
+		Version:       fields[0],
+		DeviceVendor:  fields[1],
+		DeviceProduct: fields[2],
+		DeviceVersion: fields[3],
+		SignatureID:   fields[4],
+		Extensions:    parseLEEFAttributes(fields[5], delimiter),
+	}, nil
+}
+
+// parseLEEFDelimiter reads the LEEF 2.0 delimiter field
+func parseLEEFDelimiter(field string) (byte, error) {
+	if field == "" {
+		return '\t', nil
+	}
+	if len(field) == 1 {
+		return field[0], nil
+	}
+
+	lower := strings.ToLower(field)
+	hex, ok := strings.CutPrefix(lower, "0x")
+	if !ok {
+		hex, ok = strings.CutPrefix(lower, "x")
+	}
+	if !ok {
+		return 0, fmt.Errorf("formats: invalid LEEF delimiter %q", field)
+	}
+	value, err := strconv.ParseUint(hex, 16, 8)
+	if err != nil || value == 0 {
+		return 0, fmt.Errorf("formats: invalid LEEF delimiter %q", field)
+	}
+	return byte(value), nil
+}
+
+// parseLEEFAttributes splits attributes on unescaped delimiters; each
+// attribute is split at its first unescaped "="
+func parseLEEFAttributes(attrs string, delimiter byte) map[string]string {
+	attributes := make(map[string]string)
+
+	var key, value strings.Builder
+	inValue := false
+	flush := func() {
+		if k := strings.TrimSpace(key.String()); k != "" && inValue {
+			attributes[k] = value.String()
+		}
+		key.Reset()
+		value.Reset()
+		inValue = false
+	}
+
+	for i := 0; i < len(attrs); i++ {
+		c := attrs[i]
+		current := &key
+		if inValue {
+			current = &value
+		}
+
+		switch {
+		case c == '\\' && i+1 < len(attrs) && (attrs[i+1] == delimiter || attrs[i+1] == '=' || attrs[i+1] == '\\'):
+			current.WriteByte(attrs[i+1])
+			i++
+		case c == delimiter:
+			flush()
+		case c == '=' && !inValue:
+			inValue = true
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return attributes
+}
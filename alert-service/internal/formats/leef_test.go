@@ -0,0 +1,103 @@
+package formats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLEEF(t *testing.T) {
+	t.Run("LEEF 1.0 with tab delimiter", func(t *testing.T) {
+		event, err := ParseLEEF("LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5\tcat=anomaly\tsrcPort=81\tdevTime=Jan 15 2024 10:30:00")
+		require.NoError(t, err)
+
+		assert.Equal(t, FormatLEEF, event.Format)
+		assert.Equal(t, "1.0", event.Version)
+		assert.Equal(t, "Microsoft", event.DeviceVendor)
+		assert.Equal(t, "MSExchange", event.DeviceProduct)
+		assert.Equal(t, "4.0 SP1", event.DeviceVersion)
+		assert.Equal(t, "15345", event.SignatureID)
+		assert.Equal(t, "192.0.2.0", event.Extensions["src"])
+		assert.Equal(t, "anomaly", event.Extensions["cat"])
+		assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), event.Timestamp())
+
+		severity, ok := event.AlertSeverity()
+		assert.True(t, ok)
+		assert.Equal(t, "medium", severity)
+		assert.Equal(t, "Microsoft MSExchange event 15345", event.Description())
+		assert.Equal(t, map[string]string{"src_ip": "192.0.2.0", "dst_ip": "172.50.123.1", "src_port": "81"}, event.Indicators())
+	})
+
+	t.Run("LEEF 2.0 with custom delimiters", func(t *testing.T) {
+		for _, delimiter := range []string{"^", "x5E", "0x5e"} {
+			event, err := ParseLEEF("LEEF:2.0|Lancope|StealthWatch|1.0|41|" + delimiter + "|src=10.0.1.8^dst=10.0.0.5^sev=9^msg=a\\^b = c")
+			require.NoError(t, err, delimiter)
+
+			assert.Equal(t, "10.0.0.5", event.Extensions["dst"], delimiter)
+			assert.Equal(t, "a^b = c", event.Extensions["msg"], delimiter)
+			assert.Equal(t, "a^b = c", event.Description(), delimiter)
+		}
+	})
+
+	t.Run("LEEF 2.0 empty delimiter defaults to tab", func(t *testing.T) {
+		event, err := ParseLEEF("LEEF:2.0|V|P|1|E||a=1\tb=2")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"a": "1", "b": "2"}, event.Extensions)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, text := range []string{
+			"",
+			"CEF:0|a|b|c|d|e|f|",
+			"LEEF:3.0|V|P|1|E|a=1",
+			"LEEF:1.0|V|P|1",
+			"LEEF:2.0|V|P|1|E|zz|a=1",
+		} {
+			_, err := ParseLEEF(text)
+			assert.Error(t, err, text)
+		}
+	})
+}
+
+func TestParse_DetectsFormat(t *testing.T) {
+	event, err := Parse("  CEF:0|V|P|1|S|N|5|")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCEF, event.Format)
+
+	event, err = Parse("LEEF:1.0|V|P|1|E|sev=2")
+	require.NoError(t, err)
+	assert.Equal(t, FormatLEEF, event.Format)
+
+	_, err = Parse(`{"severity":"high"}`)
+	assert.Error(t, err)
+}
+
+func FuzzParseLEEF(f *testing.F) {
+	f.Add("LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5")
+	f.Add("LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^sev=9")
+	f.Add("LEEF:2.0|V|P|1|E|0x7C|a=1|b=\\|2")
+	f.Add("LEEF:2.0|V|P|1|E|x|=\\")
+	f.Add("LEEF:1|||||")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		event, err := ParseLEEF(text)
+		if err != nil {
+			return
+		}
+
+		if event.Format != FormatLEEF {
+			t.Fatalf("unexpected format %q", event.Format)
+		}
+		for key := range event.Extensions {
+			if strings.TrimSpace(key) == "" {
+				t.Fatalf("empty attribute key in %q", text)
+			}
+		}
+		if _, err := event.ToExternalAlert("ids", "low"); err != nil {
+			t.Fatalf("ToExternalAlert: %v", err)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+)
+
+// maxBackfillBodyBytes caps backfill request bodies
+const maxBackfillBodyBytes = 64 << 10
+
+// BackfillHandler serves backfill jobs
+type BackfillHandler struct {
+	backfills *service.BackfillService
+}
+
+// BackfillRequest describes the range to backfill. ChunkSize is a Go
+// duration such as "1h"; it defaults to an hour.
+type BackfillRequest struct {
+	Connector string    `json:"connector,omitempty"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Sources   []string  `json:"sources,omitempty"`
+	ChunkSize string    `json:"chunk_size,omitempty"`
+}
+
+// BackfillJobView is a backfill job with its chunk size and progress
+type BackfillJobView struct {
+	models.BackfillJob
+	ChunkSize string  `json:"chunk_size"`
+	Progress  float64 `json:"progress"`
+}
+
+// BackfillJobsResponse lists backfill jobs
+type BackfillJobsResponse struct {
+	Jobs []BackfillJobView `json:"jobs"`
+}
+
+// BackfillJobResponse carries a single backfill job
+type BackfillJobResponse struct {
+	Job BackfillJobView `json:"job"`
+}
+
+func NewBackfillHandler(backfills *service.BackfillService) *BackfillHandler {
+	return &BackfillHandler{backfills: backfills}
+}
+
+func newBackfillJobView(job models.BackfillJob) BackfillJobView {
+	return BackfillJobView{BackfillJob: job, ChunkSize: job.ChunkSize.String(), Progress: job.Progress()}
+}
+
+// CreateBackfill handles POST /backfill. The job is queued and runs in the
+// background; poll GET /backfill/{id} for its progress.
+func (h *BackfillHandler) CreateBackfill(w http.ResponseWriter, r *http.Request) {
+	var body BackfillRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBackfillBodyBytes)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body. Times must be RFC3339.")
+		return
+	}
+
+	req := service.BackfillRequest{
+		Connector: body.Connector,
+		From:      body.From,
+		To:        body.To,
+		Sources:   body.Sources,
+	}
+	if body.ChunkSize != "" {
+		chunk, err := time.ParseDuration(body.ChunkSize)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid 'chunk_size'. Use a duration such as 1h or 15m")
+			return
+		}
+		req.ChunkSize = chunk
+	}
+	for _, source := range req.Sources {
+		if !service.IsValidSource(source) {
+			writeError(w, http.StatusBadRequest, "Invalid source: "+source)
+			return
+		}
+	}
+
+	job, err := h.backfills.Create(r.Context(), req)
+	switch {
+	case errors.Is(err, service.ErrInvalidBackfill):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		log.Printf("[HANDLER] Error creating backfill: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create backfill")
+	default:
+		writeJSON(w, http.StatusAccepted, BackfillJobResponse{Job: newBackfillJobView(*job)})
+	}
+}
+
+// ListBackfills handles GET /backfill
+// Query params:
+//   - status: Comma-separated statuses (pending, running, paused, cancelled, failed, completed)
+func (h *BackfillHandler) ListBackfills(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.backfills.List(r.Context(), splitParam(r.URL.Query().Get("status")))
+	if err != nil {
+		log.Printf("[HANDLER] Error listing backfills: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list backfills")
+		return
+	}
+
+	views := make([]BackfillJobView, len(jobs))
+	for i, job := range jobs {
+		views[i] = newBackfillJobView(job)
+	}
+	writeJSON(w, http.StatusOK, BackfillJobsResponse{Jobs: views})
+}
+
+// GetBackfill handles GET /backfill/{id}
+func (h *BackfillHandler) GetBackfill(w http.ResponseWriter, r *http.Request) {
+	job, err := h.backfills.Get(r.Context(), r.PathValue("id"))
+	h.writeJob(w, job, err, "get")
+}
+
+// PauseBackfill handles POST /backfill/{id}/pause. A running job stops at its
+// next checkpoint.
+func (h *BackfillHandler) PauseBackfill(w http.ResponseWriter, r *http.Request) {
+	job, err := h.backfills.Pause(r.Context(), r.PathValue("id"))
+	h.writeJob(w, job, err, "pause")
+}
+
+// ResumeBackfill handles POST /backfill/{id}/resume. A paused or failed job
+// continues from its last checkpoint.
+func (h *BackfillHandler) ResumeBackfill(w http.ResponseWriter, r *http.Request) {
+	job, err := h.backfills.Resume(r.Context(), r.PathValue("id"))
+	h.writeJob(w, job, err, "resume")
+}
+
+// CancelBackfill handles POST /backfill/{id}/cancel
+func (h *BackfillHandler) CancelBackfill(w http.ResponseWriter, r *http.Request) {
+	job, err := h.backfills.Cancel(r.Context(), r.PathValue("id"))
+	h.writeJob(w, job, err, "cancel")
+}
+
+func (h *BackfillHandler) writeJob(w http.ResponseWriter, job *models.BackfillJob, err error, action string) {
+	switch {
+	case errors.Is(err, service.ErrBackfillNotFound):
+		writeError(w, http.StatusNotFound, "Backfill job not found")
+	case errors.Is(err, service.ErrBackfillTransition):
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		log.Printf("[HANDLER] Error trying to %s backfill: %v", action, err)
+		writeError(w, http.StatusInternalServerError, "Failed to "+action+" backfill")
+	default:
+		writeJSON(w, http.StatusOK, BackfillJobResponse{Job: newBackfillJobView(*job)})
+	}
+}
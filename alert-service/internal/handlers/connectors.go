@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"censys_alert_system/internal/service"
+)
+
+// ConnectorsResponse lists the health of every upstream connector
+type ConnectorsResponse struct {
+	Connectors []service.ConnectorStatus `json:"connectors"`
+}
+
+// GetConnectors handles GET /connectors
+func (h *AlertHandler) GetConnectors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use GET.")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ConnectorsResponse{Connectors: h.alertService.ConnectorStatuses()})
+}
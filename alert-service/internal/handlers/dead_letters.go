@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+)
+
+// DeadLetterHandler serves the dead-letter queue
+type DeadLetterHandler struct {
+	deadLetters *service.DeadLetterService
+}
+
+// DeadLettersResponse lists dead letters
+type DeadLettersResponse struct {
+	DeadLetters []models.DeadLetter `json:"dead_letters"`
+}
+
+// DeadLetterResponse carries a single dead letter
+type DeadLetterResponse struct {
+	DeadLetter *models.DeadLetter `json:"dead_letter"`
+}
+
+// RequeueResponse reports how many dead letters a bulk retry requeued
+type RequeueResponse struct {
+	Requeued int `json:"requeued"`
+}
+
+func NewDeadLetterHandler(deadLetters *service.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{deadLetters: deadLetters}
+}
+
+// ListDeadLetters handles GET /dead-letters
+// Query params:
+//   - status: Comma-separated statuses (pending, resolved, exhausted)
+//   - connector, stage: Values to match
+//   - limit: Maximum results (default 100)
+func (h *DeadLetterHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDeadLetterFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deadLetters, err := h.deadLetters.List(r.Context(), filter)
+	if err != nil {
+		log.Printf("[HANDLER] Error listing dead letters: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list dead letters")
+		return
+	}
+	if deadLetters == nil {
+		deadLetters = []models.DeadLetter{}
+	}
+
+	writeJSON(w, http.StatusOK, DeadLettersResponse{DeadLetters: deadLetters})
+}
+
+// RetryDeadLetter handles POST /dead-letters/{id}/retry. The alert is
+// reprocessed straight away and the dead letter's new state is returned.
+func (h *DeadLetterHandler) RetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	dl, err := h.deadLetters.Retry(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, service.ErrDeadLetterNotFound):
+		writeError(w, http.StatusNotFound, "Dead letter not found")
+	case errors.Is(err, service.ErrDeadLetterResolved):
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		log.Printf("[HANDLER] Error retrying dead letter: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to retry dead letter")
+	default:
+		writeJSON(w, http.StatusOK, DeadLetterResponse{DeadLetter: dl})
+	}
+}
+
+// RetryDeadLetters handles POST /dead-letters/retry. Every unresolved dead
+// letter matching the status, connector and stage parameters is requeued for
+// the reprocessor.
+func (h *DeadLetterHandler) RetryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDeadLetterFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	n, err := h.deadLetters.RetryAll(r.Context(), filter)
+	if err != nil {
+		log.Printf("[HANDLER] Error requeueing dead letters: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to requeue dead letters")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, RequeueResponse{Requeued: n})
+}
+
+func parseDeadLetterFilter(query url.Values) (models.DeadLetterFilter, error) {
+	filter := models.DeadLetterFilter{
+		Statuses:  splitParam(query.Get("status")),
+		Connector: query.Get("connector"),
+		Stage:     query.Get("stage"),
+	}
+
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			return filter, errors.New("Invalid 'limit' parameter. Must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
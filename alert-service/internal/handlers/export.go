@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"censys_alert_system/internal/export"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+)
+
+// ExportHandler serves alert exports and export jobs
+type ExportHandler struct {
+	exports *service.ExportService
+}
+
+// ExportJobView is an export job with its progress and, once completed, the
+// URL to download it from
+type ExportJobView struct {
+	models.ExportJob
+	Progress    float64 `json:"progress"`
+	DownloadURL string  `json:"download_url,omitempty"`
+}
+
+// ExportJobsResponse lists export jobs
+type ExportJobsResponse struct {
+	Jobs []ExportJobView `json:"jobs"`
+}
+
+// ExportJobResponse carries a single export job
+type ExportJobResponse struct {
+	Job ExportJobView `json:"job"`
+}
+
+func NewExportHandler(exports *service.ExportService) *ExportHandler {
+	return &ExportHandler{exports: exports}
+}
+
+func newExportJobView(job models.ExportJob) ExportJobView {
+	view := ExportJobView{ExportJob: job, Progress: job.Progress()}
+	if job.Status == models.JobCompleted {
+		view.DownloadURL = "/exports/" + job.ID + "/download"
+	}
+	return view
+}
+
+// exportFileName names a download after when the export was requested
+func exportFileName(format string, createdAt time.Time) string {
+	return "alerts-" + createdAt.UTC().Format("20060102T150405Z") + "." + format
+}
+
+// ExportAlerts handles GET /alerts/export
+// Query params:
+//   - format: csv (default), ndjson or xlsx
+//   - columns: Comma-separated columns to export, in order
+//   - include_event: true adds the decoded whole_event column
+//   - async: true runs the export as a job even when it is small
+//   - source, severity, status, days, from, to: The filters of GET /alerts
+//
+// Exports up to EXPORT_SYNC_MAX_ROWS alerts are streamed in the response.
+// Larger ones, or async ones, are queued as a job and answered with 202 and
+// the job; download it from GET /exports/{id}/download once it completes.
+func (h *ExportHandler) ExportAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := parseAlertFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req := service.ExportRequest{
+		Format:  query.Get("format"),
+		Columns: splitParam(query.Get("columns")),
+		Filter:  filter,
+	}
+	if req.Format == "" {
+		req.Format = export.FormatCSV
+	}
+	forceAsync, includeEvent := false, false
+	for _, flag := range []struct {
+		name string
+		dst  *bool
+	}{{"async", &forceAsync}, {"include_event", &includeEvent}} {
+		if value := query.Get(flag.name); value != "" {
+			if *flag.dst, err = strconv.ParseBool(value); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid '%s' parameter. Must be true or false", flag.name))
+				return
+			}
+		}
+	}
+	if includeEvent {
+		if len(req.Columns) == 0 {
+			req.Columns = append(req.Columns, export.DefaultColumns...)
+		}
+		req.Columns = append(req.Columns, export.ColumnWholeEvent)
+	}
+
+	ctx := r.Context()
+	total, async, err := h.exports.Prepare(ctx, &req)
+	switch {
+	case errors.Is(err, service.ErrInvalidExport):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		log.Printf("[HANDLER] Error preparing export: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to export alerts")
+		return
+	}
+
+	if async || forceAsync {
+		job, err := h.exports.CreateJob(req, total)
+		switch {
+		case errors.Is(err, service.ErrExportQueueFull):
+			writeError(w, http.StatusServiceUnavailable, "Too many exports are waiting. Try again later.")
+		case err != nil:
+			log.Printf("[HANDLER] Error creating export job: %v", err)
+			writeError(w, http.StatusInternalServerError, "Failed to export alerts")
+		default:
+			w.Header().Set("Location", "/exports/"+job.ID)
+			writeJSON(w, http.StatusAccepted, ExportJobResponse{Job: newExportJobView(*job)})
+		}
+		return
+	}
+
+	// A large export can outlast the server's WriteTimeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("[HANDLER] Could not clear write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(req.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFileName(req.Format, time.Now())))
+	w.Header().Set("X-Export-Total", strconv.Itoa(total))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := h.exports.Export(ctx, w, req, rc.Flush); err != nil {
+		// The status is already sent, so abort the response to show the
+		// client the file is incomplete rather than end it cleanly
+		log.Printf("[HANDLER] Error streaming export: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// ListExports handles GET /exports
+// Query params:
+//   - status: Comma-separated statuses (pending, running, failed, completed)
+func (h *ExportHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	jobs := h.exports.List(splitParam(r.URL.Query().Get("status")))
+
+	views := make([]ExportJobView, len(jobs))
+	for i, job := range jobs {
+		views[i] = newExportJobView(job)
+	}
+	writeJSON(w, http.StatusOK, ExportJobsResponse{Jobs: views})
+}
+
+// GetExport handles GET /exports/{id}
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.exports.Get(r.PathValue("id"))
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		writeError(w, http.StatusNotFound, "Export job not found")
+	case err != nil:
+		log.Printf("[HANDLER] Error getting export: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to get export")
+	default:
+		writeJSON(w, http.StatusOK, ExportJobResponse{Job: newExportJobView(*job)})
+	}
+}
+
+// DownloadExport handles GET /exports/{id}/download for a completed job.
+// Range requests are supported, so an interrupted download can resume.
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	job, f, err := h.exports.Open(r.PathValue("id"))
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		writeError(w, http.StatusNotFound, "Export job not found")
+		return
+	case errors.Is(err, service.ErrExportNotReady):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		log.Printf("[HANDLER] Error opening export: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to download export")
+		return
+	}
+	defer f.Close()
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("[HANDLER] Could not clear write deadline: %v", err)
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(job.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFileName(job.Format, job.CreatedAt)))
+	modified := job.CreatedAt
+	if job.CompletedAt != nil {
+		modified = *job.CompletedAt
+	}
+	http.ServeContent(w, r, "", modified, f)
+}
@@ -3,12 +3,17 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/normalize"
 	"censys_alert_system/internal/service"
 )
 
@@ -25,6 +30,29 @@ type SingleAlertResponse struct {
 	Alert *models.Alert `json:"alert"`
 }
 
+// NormalizedAlertsResponse carries alerts rendered in a standard schema
+type NormalizedAlertsResponse struct {
+	Alerts []interface{} `json:"alerts"`
+}
+
+// NormalizedAlertResponse carries one alert rendered in a standard schema
+type NormalizedAlertResponse struct {
+	Alert interface{} `json:"alert"`
+}
+
+// HealthResponse reports the service status and the circuit of every upstream
+type HealthResponse struct {
+	Status    string           `json:"status"`
+	Upstreams []UpstreamHealth `json:"upstreams"`
+}
+
+// UpstreamHealth is the sync health and circuit breaker state of one connector
+type UpstreamHealth struct {
+	Name    string                `json:"name"`
+	Status  string                `json:"status"`
+	Circuit service.BreakerStatus `json:"circuit"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
@@ -42,8 +70,13 @@ func NewAlertHandler(alertService *service.AlertService) *AlertHandler {
 }
 
 // writeJSON writes a JSON response with the given status code
-func (h *AlertHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSONAs(w, status, "application/json", data)
+}
+
+// writeJSONAs writes a JSON response with an explicit media type
+func writeJSONAs(w http.ResponseWriter, status int, contentType string, data interface{}) {
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		log.Printf("[HANDLER] Error encoding JSON response: %v", err)
@@ -51,18 +84,33 @@ func (h *AlertHandler) writeJSON(w http.ResponseWriter, status int, data interfa
 }
 
 // writeError writes a JSON error response
-func (h *AlertHandler) writeError(w http.ResponseWriter, status int, message string) {
-	h.writeJSON(w, status, ErrorResponse{Error: message})
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message})
 }
 
 // GetAlerts handles GET /alerts with optional query parameters
 // Query params:
 //   - id: Get a specific alert by ID
 //   - days: Get alerts from the last N days
+//   - source, severity, status: Comma-separated values to match
+//   - from, to: RFC3339 bounds on created_at, as [from, to)
+//   - format: native (default), ocsf, ocsf-security-finding or ecs; otherwise
+//     negotiated from the Accept header
 //   - (none): Get all alerts
 func (h *AlertHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use GET.")
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use GET.")
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	format, err := normalize.Negotiate(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if errors.Is(err, normalize.ErrNotAcceptable) {
+		writeError(w, http.StatusNotAcceptable, "Not acceptable. Supported formats: native, ocsf, ocsf-security-finding, ecs")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid 'format' parameter. Must be native, ocsf, ocsf-security-finding or ecs")
 		return
 	}
 
@@ -71,65 +119,126 @@ func (h *AlertHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
 	daysParam := r.URL.Query().Get("days")
 
 	if idParam != "" && daysParam != "" {
-		h.writeError(w, http.StatusBadRequest, "Cannot specify both 'id' and 'days' parameters at the same time")
+		writeError(w, http.StatusBadRequest, "Cannot specify both 'id' and 'days' parameters at the same time")
 		return
 	}
 
-	switch {
-	case idParam != "":
-		h.getAlertByID(ctx, w, idParam)
-	case daysParam != "":
-		h.getAlertsByDays(ctx, w, daysParam)
-	default:
-		h.getAllAlerts(ctx, w)
+	if idParam != "" {
+		h.getAlertByID(ctx, w, format, idParam)
+		return
+	}
+
+	filter, err := parseAlertFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
+
+	h.listAlerts(ctx, w, format, filter)
 }
 
 // getAlertByID retrieves a single alert by its ID
-func (h *AlertHandler) getAlertByID(ctx context.Context, w http.ResponseWriter, id string) {
+func (h *AlertHandler) getAlertByID(ctx context.Context, w http.ResponseWriter, format normalize.Format, id string) {
 	alert, err := h.alertService.GetAlertByID(ctx, id)
+	if errors.Is(err, service.ErrAlertNotFound) {
+		writeError(w, http.StatusNotFound, "Alert not found")
+		return
+	}
 	if err != nil {
-		h.writeError(w, http.StatusNotFound, "Alert not found")
+		log.Printf("[HANDLER] Error getting alert %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Failed to get alert")
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, SingleAlertResponse{Alert: alert})
-}
+	if format == normalize.FormatNative {
+		writeJSON(w, http.StatusOK, SingleAlertResponse{Alert: alert})
+		return
+	}
 
-// getAlertsByDays retrieves alerts from the last N days
-func (h *AlertHandler) getAlertsByDays(ctx context.Context, w http.ResponseWriter, daysParam string) {
-	days, err := strconv.Atoi(daysParam)
-	if err != nil || days <= 0 {
-		h.writeError(w, http.StatusBadRequest, "Invalid 'days' parameter. Must be a positive integer")
+	doc, err := normalize.Render(format, *alert)
+	if err != nil {
+		log.Printf("[HANDLER] Error normalizing alert %s: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "Failed to render alert")
 		return
 	}
+	writeJSONAs(w, http.StatusOK, format.MediaType(), NormalizedAlertResponse{Alert: doc})
+}
 
-	alerts, err := h.alertService.GetAlertsByDays(ctx, days)
+// listAlerts retrieves alerts matching the filter
+func (h *AlertHandler) listAlerts(ctx context.Context, w http.ResponseWriter, format normalize.Format, filter models.AlertFilter) {
+	alerts, err := h.alertService.ListAlerts(ctx, filter)
 	if err != nil {
-		log.Printf("[HANDLER] Error getting alerts by days: %v", err)
-		h.writeError(w, http.StatusInternalServerError, "Failed to retrieve alerts")
+		log.Printf("[HANDLER] Error listing alerts: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to retrieve alerts")
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, AlertsResponse{Alerts: alerts})
-}
+	if format == normalize.FormatNative {
+		writeJSON(w, http.StatusOK, AlertsResponse{Alerts: alerts})
+		return
+	}
 
-// getAllAlerts retrieves all alerts
-func (h *AlertHandler) getAllAlerts(ctx context.Context, w http.ResponseWriter) {
-	alerts, err := h.alertService.GetAlerts(ctx)
+	docs, err := normalize.RenderAll(format, alerts)
 	if err != nil {
-		log.Printf("[HANDLER] Error getting all alerts: %v", err)
-		h.writeError(w, http.StatusInternalServerError, "Failed to retrieve alerts")
+		log.Printf("[HANDLER] Error normalizing alerts: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to render alerts")
 		return
 	}
+	writeJSONAs(w, http.StatusOK, format.MediaType(), NormalizedAlertsResponse{Alerts: docs})
+}
+
+// parseAlertFilter reads the listing filters shared by /alerts and /alerts/stream
+func parseAlertFilter(query url.Values) (models.AlertFilter, error) {
+	filter := models.AlertFilter{
+		Sources:    splitParam(query.Get("source")),
+		Severities: splitParam(query.Get("severity")),
+		Statuses:   splitParam(query.Get("status")),
+	}
+
+	if daysParam := query.Get("days"); daysParam != "" {
+		days, err := strconv.Atoi(daysParam)
+		if err != nil || days <= 0 {
+			return filter, errors.New("Invalid 'days' parameter. Must be a positive integer")
+		}
+		filter.Days = days
+	}
+
+	for _, bound := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := query.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid '%s' parameter. Must be an RFC3339 time", bound.name)
+		}
+		*bound.dst = t
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, errors.New("Invalid time range. 'from' must be before 'to'")
+	}
 
-	h.writeJSON(w, http.StatusOK, AlertsResponse{Alerts: alerts})
+	return filter, nil
+}
+
+// splitParam splits a comma-separated query parameter, dropping empty values
+func splitParam(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
 }
 
 // TriggerSync handles POST /sync to manually trigger a sync
 func (h *AlertHandler) TriggerSync(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use POST.")
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use POST.")
 		return
 	}
 
@@ -144,19 +253,63 @@ func (h *AlertHandler) TriggerSync(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	h.writeJSON(w, http.StatusAccepted, SyncResponse{
+	writeJSON(w, http.StatusAccepted, SyncResponse{
 		Message: "Sync triggered successfully",
 		Status:  "pending",
 	})
 }
 
+// AcknowledgeAlert handles POST /alerts/{id}/acknowledge
+func (h *AlertHandler) AcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	h.transitionAlert(w, r, h.alertService.AcknowledgeAlert)
+}
+
+// ResolveAlert handles POST /alerts/{id}/resolve
+func (h *AlertHandler) ResolveAlert(w http.ResponseWriter, r *http.Request) {
+	h.transitionAlert(w, r, h.alertService.ResolveAlert)
+}
+
+// transitionAlert applies a lifecycle change to the alert named in the path
+func (h *AlertHandler) transitionAlert(w http.ResponseWriter, r *http.Request, transition func(context.Context, string) (*models.Alert, error)) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use POST.")
+		return
+	}
+
+	alert, err := transition(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, service.ErrAlertNotFound):
+		writeError(w, http.StatusNotFound, "Alert not found")
+	case errors.Is(err, service.ErrInvalidStatusTransition):
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		log.Printf("[HANDLER] Error updating alert status: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to update alert")
+	default:
+		writeJSON(w, http.StatusOK, SingleAlertResponse{Alert: alert})
+	}
+}
+
+// HealthHandler handles GET /health. The service is "degraded" while any
+// upstream circuit is not closed; it still answers 200 because it keeps
+// serving alerts and accepting pushed ones.
 func (h *AlertHandler) HealthHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"ok"}`))
+	response := HealthResponse{Status: "ok", Upstreams: []UpstreamHealth{}}
+	for _, status := range h.alertService.ConnectorStatuses() {
+		if status.Circuit.State != service.CircuitClosed {
+			response.Status = "degraded"
+		}
+		response.Upstreams = append(response.Upstreams, UpstreamHealth{
+			Name:    status.Name,
+			Status:  status.Status,
+			Circuit: status.Circuit,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
 }
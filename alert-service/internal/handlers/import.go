@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"censys_alert_system/internal/importer"
+	"censys_alert_system/internal/service"
+)
+
+// maxImportBodyBytes caps the size of an uploaded import file
+const maxImportBodyBytes = 1 << 30
+
+// ImportHandler serves bulk imports of historical alerts
+type ImportHandler struct {
+	imports *service.ImportService
+}
+
+// ImportFailedResponse reports an import that stopped part way, with what
+// it did before it stopped
+type ImportFailedResponse struct {
+	Error  string                `json:"error"`
+	Result *service.ImportResult `json:"result"`
+}
+
+func NewImportHandler(imports *service.ImportService) *ImportHandler {
+	return &ImportHandler{imports: imports}
+}
+
+// ImportAlerts handles POST /alerts/import
+// The body is the file itself, or a multipart/form-data upload with the file
+// in a "file" field, and may be gzip-compressed (Content-Encoding: gzip, or
+// a .gz file name).
+// Query params:
+//   - format: csv or ndjson; otherwise taken from the file name or Content-Type
+//   - source: Source of rows without one
+//   - enrich: true runs imported alerts through enrichment
+//   - dry_run: true validates and deduplicates without storing anything, and
+//     lists the first alerts that would be inserted
+func (h *ImportHandler) ImportAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := service.ImportOptions{
+		Format:        query.Get("format"),
+		DefaultSource: query.Get("source"),
+	}
+	for _, flag := range []struct {
+		name string
+		dst  *bool
+	}{{"enrich", &opts.Enrich}, {"dry_run", &opts.DryRun}} {
+		if value := query.Get(flag.name); value != "" {
+			var err error
+			if *flag.dst, err = strconv.ParseBool(value); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid '%s' parameter. Must be true or false", flag.name))
+				return
+			}
+		}
+	}
+
+	// Large files take longer to upload and import than the server timeouts
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		log.Printf("[HANDLER] Could not clear read deadline: %v", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("[HANDLER] Could not clear write deadline: %v", err)
+	}
+
+	body, format, compressed, err := importBody(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if opts.Format == "" {
+		opts.Format = format
+	}
+	if opts.Format == "" {
+		writeError(w, http.StatusBadRequest, "Missing 'format' parameter. Must be csv or ndjson")
+		return
+	}
+	if compressed || r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid gzip body")
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	result, err := h.imports.Import(r.Context(), body, opts)
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		writeError(w, http.StatusRequestEntityTooLarge, "Import file too large")
+	case errors.Is(err, service.ErrInvalidImport):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		log.Printf("[HANDLER] Error importing alerts: %v", err)
+		writeJSON(w, http.StatusInternalServerError, ImportFailedResponse{Error: "Import stopped part way", Result: result})
+	default:
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// importBody returns the uploaded file and the format and compression its
+// name or media type implies, if any
+func importBody(w http.ResponseWriter, r *http.Request) (body io.Reader, format string, compressed bool, err error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+	body = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "multipart/form-data":
+		reader, err := r.MultipartReader()
+		if err != nil {
+			return nil, "", false, errors.New("Invalid multipart body")
+		}
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil, "", false, errors.New("Missing 'file' field in the multipart body")
+			}
+			if err != nil {
+				return nil, "", false, errors.New("Invalid multipart body")
+			}
+			if part.FormName() == "file" {
+				format, compressed, _ = importer.FileFormat(part.FileName())
+				return part, format, compressed, nil
+			}
+		}
+	case "text/csv":
+		return body, importer.FormatCSV, false, nil
+	case "application/x-ndjson", "application/jsonl":
+		return body, importer.FormatNDJSON, false, nil
+	}
+	return body, "", false, nil
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/formats"
+	"censys_alert_system/internal/service"
+)
+
+// maxIngestBodyBytes caps the size of a single pushed batch
+const maxIngestBodyBytes = 10 << 20
+
+const (
+	// signatureHeader carries an optional HMAC-SHA256, as "sha256=<hex>", of
+	// the signature timestamp, a ".", and the request body
+	signatureHeader = "X-Signature-256"
+
+	// signatureTimestampHeader carries the Unix time in seconds at which a
+	// signed request was sent
+	signatureTimestampHeader = "X-Signature-Timestamp"
+
+	// signatureTolerance is how far a signature timestamp may be from now; a
+	// captured request cannot be replayed once it has passed
+	signatureTolerance = 5 * time.Minute
+)
+
+// IngestHandler accepts alerts pushed by vendors that cannot be polled
+type IngestHandler struct {
+	alertService *service.AlertService
+	secrets      map[string]string
+}
+
+// NewIngestHandler creates an ingest handler. secrets maps each push source
+// to the shared secret used for bearer auth and HMAC signatures.
+func NewIngestHandler(alertService *service.AlertService, secrets map[string]string) *IngestHandler {
+	return &IngestHandler{
+		alertService: alertService,
+		secrets:      secrets,
+	}
+}
+
+// ingestItem is one decoded entry of a pushed batch
+type ingestItem struct {
+	alert external.ExternalAlert
+	err   error
+}
+
+// IngestAlerts handles POST /ingest/{source}
+// Accepts a single alert object, a JSON array, {"alerts": [...]}, NDJSON
+// (Content-Type: application/x-ndjson) or one CEF/LEEF record per line.
+// Requests are authenticated with either "Authorization: Bearer <secret>" or
+// an X-Signature-256 HMAC of the X-Signature-Timestamp and the body.
+func (h *IngestHandler) IngestAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use POST.")
+		return
+	}
+
+	source := r.PathValue("source")
+	if !service.IsValidSource(source) {
+		writeError(w, http.StatusNotFound, "Unknown source")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestBodyBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if !h.authenticate(r, source, body) {
+		writeError(w, http.StatusUnauthorized, "Invalid or missing credentials")
+		return
+	}
+
+	items, err := decodeIngestBody(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Decode failures are rejected here; the rest go through the service and
+	// their batch indices are mapped back to positions in the request
+	var result service.IngestResult
+	var alerts []external.ExternalAlert
+	var positions []int
+	for i, item := range items {
+		if item.err == nil && item.alert.Source == "" {
+			item.alert.Source = source
+		}
+		if item.err == nil && item.alert.Source != source {
+			item.err = fmt.Errorf("source %q does not match endpoint source %q", item.alert.Source, source)
+		}
+		if item.err != nil {
+			result.Reject(i, item.err.Error())
+			continue
+		}
+		alerts = append(alerts, item.alert)
+		positions = append(positions, i)
+	}
+
+	// Stored alerts are deduplicated by fingerprint, so the sender can safely
+	// retry the whole request after a storage failure
+	stored, err := h.alertService.IngestAlerts(r.Context(), "webhook:"+source, alerts)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "Failed to store alerts. Try again later.")
+		return
+	}
+	result.Accepted = stored.Accepted
+	for _, e := range stored.Errors {
+		result.Reject(positions[e.Index], e.Error)
+	}
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Index < result.Errors[j].Index })
+
+	writeJSON(w, http.StatusAccepted, result)
+}
+
+// authenticate checks the bearer secret or the HMAC signature for a source.
+// A signature is only accepted within signatureTolerance of its timestamp.
+func (h *IngestHandler) authenticate(r *http.Request, source string, body []byte) bool {
+	secret, ok := h.secrets[source]
+	if !ok || secret == "" {
+		return false
+	}
+
+	if signature := r.Header.Get(signatureHeader); signature != "" {
+		timestamp := r.Header.Get(signatureTimestampHeader)
+		sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return false
+		}
+		if age := time.Since(time.Unix(sentAt, 0)); age > signatureTolerance || age < -signatureTolerance {
+			return false
+		}
+		given, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		return hmac.Equal(given, mac.Sum(nil))
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// decodeIngestBody splits a pushed payload into individual alerts
+func decodeIngestBody(contentType string, body []byte) ([]ingestItem, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-ndjson" || mediaType == "application/ndjson" {
+		return decodeNDJSON(body)
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, errors.New("request body is empty")
+	}
+
+	if formats.IsSecurityEvent(string(trimmed)) {
+		return decodeSecurityEvents(trimmed)
+	}
+
+	var raws []json.RawMessage
+	switch trimmed[0] {
+	case '[':
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %v", err)
+		}
+	case '{':
+		var batch struct {
+			Alerts []json.RawMessage `json:"alerts"`
+		}
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return nil, fmt.Errorf("invalid JSON object: %v", err)
+		}
+		if batch.Alerts != nil {
+			raws = batch.Alerts
+		} else {
+			raws = []json.RawMessage{trimmed}
+		}
+	default:
+		return nil, errors.New("request body must be a JSON object, a JSON array, NDJSON, CEF or LEEF")
+	}
+
+	items := make([]ingestItem, len(raws))
+	for i, raw := range raws {
+		items[i] = decodeIngestItem(raw)
+	}
+	return items, nil
+}
+
+// decodeNDJSON decodes one alert per non-empty line
+func decodeNDJSON(body []byte) ([]ingestItem, error) {
+	var items []ingestItem
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), maxIngestBodyBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		items = append(items, decodeIngestItem(append([]byte(nil), line...)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid NDJSON: %v", err)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("request body is empty")
+	}
+	return items, nil
+}
+
+// decodeSecurityEvents decodes one CEF or LEEF record per non-empty line
+func decodeSecurityEvents(body []byte) ([]ingestItem, error) {
+	var items []ingestItem
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), maxIngestBodyBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		event, err := formats.Parse(line)
+		if err != nil {
+			items = append(items, ingestItem{err: fmt.Errorf("invalid event: %v", err)})
+			continue
+		}
+		alert, err := event.ToExternalAlert("", "")
+		items = append(items, ingestItem{alert: alert, err: err})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid CEF/LEEF body: %v", err)
+	}
+	return items, nil
+}
+
+func decodeIngestItem(raw json.RawMessage) ingestItem {
+	var alert external.ExternalAlert
+	if err := json.Unmarshal(raw, &alert); err != nil {
+		return ingestItem{err: fmt.Errorf("invalid alert: %v", err)}
+	}
+	alert.Raw = raw
+	return ingestItem{alert: alert}
+}
@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testIngestSecret = "s3cret"
+
+// sign returns the X-Signature-256 value for a timestamp and body
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestIngestHandler_Authenticate(t *testing.T) {
+	h := NewIngestHandler(nil, map[string]string{"firewall": testIngestSecret, "ids": ""})
+	body := []byte(`{"severity":"high","description":"blocked"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	at := func(offset time.Duration) string {
+		return strconv.FormatInt(time.Now().Add(offset).Unix(), 10)
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		headers map[string]string
+		want    bool
+	}{
+		{
+			name:    "bearer secret",
+			source:  "firewall",
+			headers: map[string]string{"Authorization": "Bearer " + testIngestSecret},
+			want:    true,
+		},
+		{
+			name:    "wrong bearer secret",
+			source:  "firewall",
+			headers: map[string]string{"Authorization": "Bearer nope"},
+		},
+		{
+			name:    "bearer without scheme",
+			source:  "firewall",
+			headers: map[string]string{"Authorization": testIngestSecret},
+		},
+		{
+			name:   "no credentials",
+			source: "firewall",
+		},
+		{
+			name:    "source without a secret",
+			source:  "ids",
+			headers: map[string]string{"Authorization": "Bearer "},
+		},
+		{
+			name:    "source not configured",
+			source:  "endpoint",
+			headers: map[string]string{"Authorization": "Bearer " + testIngestSecret},
+		},
+		{
+			name:   "signature",
+			source: "firewall",
+			headers: map[string]string{
+				signatureTimestampHeader: now,
+				signatureHeader:          sign(testIngestSecret, now, body),
+			},
+			want: true,
+		},
+		{
+			name:   "signature without sha256 prefix",
+			source: "firewall",
+			headers: map[string]string{
+				signatureTimestampHeader: now,
+				signatureHeader:          strings.TrimPrefix(sign(testIngestSecret, now, body), "sha256="),
+			},
+			want: true,
+		},
+		{
+			name:   "signature inside the window",
+			source: "firewall",
+			headers: map[string]string{
+				signatureTimestampHeader: at(-4 * time.Minute),
+				signatureHeader:          sign(testIngestSecret, at(-4*time.Minute), body),
+			},
+			want: true,
+		},
+		{
+			name:   "stale signature",
+			source: "firewall",
+			headers: map[string]string{
+				signatureTimestampHeader: at(-6 * time.Minute),
+				signatureHeader:          sign(testIngestSecret, at(-6*time.Minute), body),
+			},
+		},
+		{
+			name:   "signature from the future",
+			source: "firewall",
+			headers: map[string]string{
+				signatureTimestampHeader: at(6 * time.Minute),
+				signatureHeader:          sign(testIngestSecret, at(6*time.Minute), body),
+			},
+		},
+		{
+			name:   "signature with the wrong secret",
+			source: "firewall",
+			headers: map[string]string{
+				signatureTimestampHeader: now,
+				signatureHeader:          sign("other", now, body),
+			},
+		},
+		{
+			name:   "signature over a different timestamp",
+			source: "firewall",
+			headers: map[string]string{
+				signatureTimestampHeader: now,
+				signatureHeader:          sign(testIngestSecret, at(-time.Minute), body),
+			},
+		},
+		{
+			name:   "signature that is not hex",
+			source: "firewall",
+			headers: map[string]string{
+				signatureTimestampHeader: now,
+				signatureHeader:          "sha256=zz",
+			},
+		},
+		{
+			name:    "signature without timestamp",
+			source:  "firewall",
+			headers: map[string]string{signatureHeader: sign(testIngestSecret, now, body)},
+		},
+		{
+			name:   "bad signature does not fall back to bearer",
+			source: "firewall",
+			headers: map[string]string{
+				"Authorization":          "Bearer " + testIngestSecret,
+				signatureTimestampHeader: now,
+				signatureHeader:          sign("other", now, body),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/ingest/"+tt.source, bytes.NewReader(body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tt.want, h.authenticate(req, tt.source, body))
+		})
+	}
+}
+
+func TestDecodeIngestBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		descriptions []string
+		itemErrors   []int
+		err          string
+	}{
+		{
+			name:         "single object",
+			contentType:  "application/json",
+			body:         `{"severity":"high","description":"one"}`,
+			descriptions: []string{"one"},
+		},
+		{
+			name:         "array",
+			contentType:  "application/json",
+			body:         `[{"severity":"high","description":"one"}, {"severity":"low","description":"two"}]`,
+			descriptions: []string{"one", "two"},
+		},
+		{
+			name:         "alerts envelope",
+			body:         `{"alerts": [{"severity":"high","description":"one"}, "not an alert"]}`,
+			descriptions: []string{"one", ""},
+			itemErrors:   []int{1},
+		},
+		{
+			name: "empty alerts envelope",
+			body: `{"alerts": []}`,
+		},
+		{
+			name:         "ndjson skips blank lines",
+			contentType:  "application/x-ndjson",
+			body:         "{\"severity\":\"high\",\"description\":\"one\"}\n\n{not json}\n{\"severity\":\"low\",\"description\":\"two\"}\n",
+			descriptions: []string{"one", "", "two"},
+			itemErrors:   []int{1},
+		},
+		{
+			name:         "ndjson with charset",
+			contentType:  "application/ndjson; charset=utf-8",
+			body:         `{"severity":"high","description":"one"}`,
+			descriptions: []string{"one"},
+		},
+		{
+			name:         "cef and leef lines",
+			contentType:  "text/plain",
+			body:         "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1\nLEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|sev=5\tcat=anomaly\nCEF:0|Vendor|Product|1.0|100|Name",
+			descriptions: []string{"worm successfully stopped", "", ""},
+			itemErrors:   []int{2},
+		},
+		{
+			name: "empty body",
+			body: "  \n",
+			err:  "request body is empty",
+		},
+		{
+			name:        "empty ndjson",
+			contentType: "application/x-ndjson",
+			body:        "\n\n",
+			err:         "request body is empty",
+		},
+		{
+			name: "invalid array",
+			body: `[{"severity":`,
+			err:  "invalid JSON array",
+		},
+		{
+			name: "invalid object",
+			body: `{"severity":`,
+			err:  "invalid JSON object",
+		},
+		{
+			name: "plain text",
+			body: "hello",
+			err:  "must be a JSON object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := decodeIngestBody(tt.contentType, []byte(tt.body))
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, items, len(tt.descriptions))
+
+			var itemErrors []int
+			for i, item := range items {
+				if item.err != nil {
+					itemErrors = append(itemErrors, i)
+					continue
+				}
+				if tt.descriptions[i] != "" {
+					assert.Equal(t, tt.descriptions[i], item.alert.Description)
+				}
+				assert.NotEmpty(t, item.alert.Raw, "the original record is kept")
+			}
+			assert.Equal(t, tt.itemErrors, itemErrors)
+		})
+	}
+}
+
+func TestIngestHandler_IngestAlerts(t *testing.T) {
+	newHandler := func(t *testing.T) (*IngestHandler, *mocks.AlertStorageInterface) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		alertService := service.NewAlertService(mockStorage, nil)
+		return NewIngestHandler(alertService, map[string]string{"firewall": testIngestSecret}), mockStorage
+	}
+	post := func(h *IngestHandler, source string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ingest/"+source, bytes.NewReader(body))
+		req.SetPathValue("source", source)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.IngestAlerts(rec, req)
+		return rec
+	}
+	bearer := map[string]string{"Authorization": "Bearer " + testIngestSecret}
+
+	t.Run("stores alerts and reports rejects by position", func(t *testing.T) {
+		h, mockStorage := newHandler(t)
+		mockStorage.On("CreateAlert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Alert).ID = "stored"
+		}).Return(nil).Twice()
+
+		body := []byte(`[
+			{"severity":"high","description":"one","created_at":"2024-06-01T12:00:00Z"},
+			{"source":"ids","severity":"high","description":"wrong source","created_at":"2024-06-01T12:00:00Z"},
+			{"severity":"urgent","description":"bad severity","created_at":"2024-06-01T12:00:00Z"},
+			{"source":"firewall","severity":"low","description":"two","created_at":"2024-06-01T12:01:00Z"}
+		]`)
+		rec := post(h, "firewall", body, bearer)
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		var result service.IngestResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.Equal(t, 2, result.Accepted)
+		assert.Equal(t, 2, result.Rejected)
+		require.Len(t, result.Errors, 2)
+		assert.Equal(t, 1, result.Errors[0].Index)
+		assert.Contains(t, result.Errors[0].Error, "does not match endpoint source")
+		assert.Equal(t, 2, result.Errors[1].Index)
+		assert.Contains(t, result.Errors[1].Error, "invalid severity")
+	})
+
+	t.Run("signed request", func(t *testing.T) {
+		h, mockStorage := newHandler(t)
+		mockStorage.On("CreateAlert", mock.Anything, mock.Anything).Return(nil).Once()
+
+		body := []byte(`{"severity":"high","description":"one","created_at":"2024-06-01T12:00:00Z"}`)
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		rec := post(h, "firewall", body, map[string]string{
+			signatureTimestampHeader: timestamp,
+			signatureHeader:          sign(testIngestSecret, timestamp, body),
+		})
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	})
+
+	t.Run("body over 10MB", func(t *testing.T) {
+		h, _ := newHandler(t)
+
+		body := bytes.Repeat([]byte(" "), maxIngestBodyBytes+1)
+		rec := post(h, "firewall", body, bearer)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	t.Run("body at 10MB is read", func(t *testing.T) {
+		h, _ := newHandler(t)
+
+		body := bytes.Repeat([]byte(" "), maxIngestBodyBytes)
+		rec := post(h, "firewall", body, bearer)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "the cap is not hit; the blank body is rejected")
+	})
+
+	t.Run("rejected requests", func(t *testing.T) {
+		h, _ := newHandler(t)
+		body := []byte(`{"severity":"high","description":"one"}`)
+
+		assert.Equal(t, http.StatusNotFound, post(h, "toaster", body, bearer).Code)
+		assert.Equal(t, http.StatusUnauthorized, post(h, "firewall", body, nil).Code)
+		assert.Equal(t, http.StatusUnauthorized, post(h, "ids", body, bearer).Code)
+	})
+}
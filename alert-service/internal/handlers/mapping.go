@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"censys_alert_system/internal/mapping"
+	"censys_alert_system/internal/service"
+)
+
+// MappingHandler previews connector mappings against sample payloads
+type MappingHandler struct {
+	mappers map[string]*mapping.Mapper
+}
+
+// NewMappingHandler creates a mapping handler. mappers holds the configured
+// mapping of each connector by name.
+func NewMappingHandler(mappers map[string]*mapping.Mapper) *MappingHandler {
+	return &MappingHandler{mappers: mappers}
+}
+
+// DryRunRequest selects a mapping and carries the sample payload. The mapping
+// is a configured connector's, an inline spec, or a YAML spec.
+type DryRunRequest struct {
+	Connector   string          `json:"connector,omitempty"`
+	Mapping     json.RawMessage `json:"mapping,omitempty"`
+	MappingYAML string          `json:"mapping_yaml,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// DryRunResponse reports what a mapping would do with each record
+type DryRunResponse struct {
+	Records  int                    `json:"records"`
+	Accepted int                    `json:"accepted"`
+	Dropped  int                    `json:"dropped"`
+	Rejected int                    `json:"rejected"`
+	Results  []mapping.RecordResult `json:"results"`
+}
+
+// DryRun handles POST /mappings/dry-run. Nothing is stored.
+func (h *MappingHandler) DryRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use POST.")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestBodyBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	var req DryRunRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if len(req.Payload) == 0 {
+		writeError(w, http.StatusBadRequest, "payload is required")
+		return
+	}
+
+	mapper, status, err := h.selectMapper(req)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+
+	results, err := mapper.Apply(req.Payload)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response := DryRunResponse{Records: len(results), Results: results}
+	for i, result := range results {
+		switch result.Status {
+		case mapping.StatusMapped:
+			// Mapped alerts must still pass the same checks as ingested ones
+			if err := service.ValidateExternalAlert(*result.Alert); err != nil {
+				response.Results[i].Status = mapping.StatusFailed
+				response.Results[i].Reason = err.Error()
+				response.Rejected++
+				continue
+			}
+			response.Accepted++
+		case mapping.StatusDropped:
+			response.Dropped++
+		default:
+			response.Rejected++
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// selectMapper resolves the mapping a dry run uses, with the status to
+// report when it cannot
+func (h *MappingHandler) selectMapper(req DryRunRequest) (*mapping.Mapper, int, error) {
+	selected := 0
+	for _, set := range []bool{req.Connector != "", len(req.Mapping) > 0, req.MappingYAML != ""} {
+		if set {
+			selected++
+		}
+	}
+	if selected != 1 {
+		return nil, http.StatusBadRequest, errors.New("set exactly one of connector, mapping and mapping_yaml")
+	}
+
+	switch {
+	case req.Connector != "":
+		mapper, ok := h.mappers[req.Connector]
+		if !ok {
+			return nil, http.StatusNotFound, errors.New("connector has no mapping configured")
+		}
+		return mapper, 0, nil
+	case len(req.Mapping) > 0:
+		mapper, err := mapping.Parse(req.Mapping)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return mapper, 0, nil
+	default:
+		mapper, err := mapping.Parse([]byte(req.MappingYAML))
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		return mapper, 0, nil
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+)
+
+// maxReenrichmentBodyBytes caps re-enrichment request bodies
+const maxReenrichmentBodyBytes = 64 << 10
+
+// ReenrichmentHandler serves re-enrichment jobs
+type ReenrichmentHandler struct {
+	reenrichments *service.ReenrichmentService
+}
+
+// ReenrichmentRequest selects the stored alerts to re-enrich. An empty
+// fields list runs every enricher; force also rewrites fields that are
+// already at the current pipeline version.
+type ReenrichmentRequest struct {
+	Sources    []string  `json:"sources,omitempty"`
+	Severities []string  `json:"severities,omitempty"`
+	Statuses   []string  `json:"statuses,omitempty"`
+	Days       int       `json:"days,omitempty"`
+	From       time.Time `json:"from,omitzero"`
+	To         time.Time `json:"to,omitzero"`
+	Fields     []string  `json:"fields,omitempty"`
+	Force      bool      `json:"force,omitempty"`
+}
+
+// ReenrichmentJobView is a re-enrichment job with its progress
+type ReenrichmentJobView struct {
+	models.ReenrichmentJob
+	Progress float64 `json:"progress"`
+}
+
+// ReenrichmentJobsResponse lists re-enrichment jobs
+type ReenrichmentJobsResponse struct {
+	Jobs []ReenrichmentJobView `json:"jobs"`
+}
+
+// ReenrichmentJobResponse carries a single re-enrichment job
+type ReenrichmentJobResponse struct {
+	Job ReenrichmentJobView `json:"job"`
+}
+
+func NewReenrichmentHandler(reenrichments *service.ReenrichmentService) *ReenrichmentHandler {
+	return &ReenrichmentHandler{reenrichments: reenrichments}
+}
+
+func newReenrichmentJobView(job models.ReenrichmentJob) ReenrichmentJobView {
+	return ReenrichmentJobView{ReenrichmentJob: job, Progress: job.Progress()}
+}
+
+// CreateReenrichment handles POST /reenrichment. The job is queued and runs
+// in the background; poll GET /reenrichment/{id} for its progress.
+func (h *ReenrichmentHandler) CreateReenrichment(w http.ResponseWriter, r *http.Request) {
+	var body ReenrichmentRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReenrichmentBodyBytes)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body. Times must be RFC3339.")
+		return
+	}
+
+	for _, source := range body.Sources {
+		if !service.IsValidSource(source) {
+			writeError(w, http.StatusBadRequest, "Invalid source: "+source)
+			return
+		}
+	}
+	for _, severity := range body.Severities {
+		if !service.IsValidSeverity(severity) {
+			writeError(w, http.StatusBadRequest, "Invalid severity: "+severity)
+			return
+		}
+	}
+
+	req := service.ReenrichmentRequest{
+		Filter: models.AlertFilter{
+			Sources:    body.Sources,
+			Severities: body.Severities,
+			Statuses:   body.Statuses,
+			Days:       body.Days,
+			From:       body.From,
+			To:         body.To,
+		},
+		Fields: body.Fields,
+		Force:  body.Force,
+	}
+
+	job, err := h.reenrichments.Create(r.Context(), req)
+	switch {
+	case errors.Is(err, service.ErrInvalidReenrichment):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		log.Printf("[HANDLER] Error creating re-enrichment: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to create re-enrichment")
+	default:
+		writeJSON(w, http.StatusAccepted, ReenrichmentJobResponse{Job: newReenrichmentJobView(*job)})
+	}
+}
+
+// ListReenrichments handles GET /reenrichment
+// Query params:
+//   - status: Comma-separated statuses (pending, running, paused, cancelled, failed, completed)
+func (h *ReenrichmentHandler) ListReenrichments(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.reenrichments.List(r.Context(), splitParam(r.URL.Query().Get("status")))
+	if err != nil {
+		log.Printf("[HANDLER] Error listing re-enrichments: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list re-enrichments")
+		return
+	}
+
+	views := make([]ReenrichmentJobView, len(jobs))
+	for i, job := range jobs {
+		views[i] = newReenrichmentJobView(job)
+	}
+	writeJSON(w, http.StatusOK, ReenrichmentJobsResponse{Jobs: views})
+}
+
+// GetReenrichment handles GET /reenrichment/{id}
+func (h *ReenrichmentHandler) GetReenrichment(w http.ResponseWriter, r *http.Request) {
+	job, err := h.reenrichments.Get(r.Context(), r.PathValue("id"))
+	h.writeJob(w, job, err, "get")
+}
+
+// PauseReenrichment handles POST /reenrichment/{id}/pause. A running job
+// stops at its next checkpoint.
+func (h *ReenrichmentHandler) PauseReenrichment(w http.ResponseWriter, r *http.Request) {
+	job, err := h.reenrichments.Pause(r.Context(), r.PathValue("id"))
+	h.writeJob(w, job, err, "pause")
+}
+
+// ResumeReenrichment handles POST /reenrichment/{id}/resume. A paused or
+// failed job continues after the last alert it handled.
+func (h *ReenrichmentHandler) ResumeReenrichment(w http.ResponseWriter, r *http.Request) {
+	job, err := h.reenrichments.Resume(r.Context(), r.PathValue("id"))
+	h.writeJob(w, job, err, "resume")
+}
+
+// CancelReenrichment handles POST /reenrichment/{id}/cancel
+func (h *ReenrichmentHandler) CancelReenrichment(w http.ResponseWriter, r *http.Request) {
+	job, err := h.reenrichments.Cancel(r.Context(), r.PathValue("id"))
+	h.writeJob(w, job, err, "cancel")
+}
+
+func (h *ReenrichmentHandler) writeJob(w http.ResponseWriter, job *models.ReenrichmentJob, err error, action string) {
+	switch {
+	case errors.Is(err, service.ErrReenrichmentNotFound):
+		writeError(w, http.StatusNotFound, "Re-enrichment job not found")
+	case errors.Is(err, service.ErrReenrichmentTransition):
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		log.Printf("[HANDLER] Error trying to %s re-enrichment: %v", action, err)
+		writeError(w, http.StatusInternalServerError, "Failed to "+action+" re-enrichment")
+	default:
+		writeJSON(w, http.StatusOK, ReenrichmentJobResponse{Job: newReenrichmentJobView(*job)})
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"censys_alert_system/internal/events"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/normalize"
+)
+
+// StreamHandler serves live alert events as Server-Sent Events
+type StreamHandler struct {
+	broker    *events.Broker
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(broker *events.Broker, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		broker:    broker,
+		heartbeat: heartbeat,
+	}
+}
+
+// StreamAlerts handles GET /alerts/stream
+// Accepts the same filters and ?format= as GET /alerts and resumes after the
+// Last-Event-ID header (or ?last_event_id=) from the replay buffer. A stream.reset
+// event is sent first when that ID is from before a restart or no longer buffered.
+func (h *StreamHandler) StreamAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use GET.")
+		return
+	}
+
+	filter, err := parseAlertFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The Accept header is text/event-stream, so only ?format= selects the schema
+	format, err := normalize.Negotiate(r.URL.Query().Get("format"), "")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid 'format' parameter. Must be native, ocsf, ocsf-security-finding or ecs")
+		return
+	}
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid Last-Event-ID. Must be a non-negative integer")
+		return
+	}
+
+	// Streams outlive the server's WriteTimeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("[STREAM] Could not clear write deadline: %v", err)
+	}
+
+	sub, replay, reset := h.broker.Subscribe(lastEventID, events.DisconnectSlow)
+	defer h.broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", 3000)
+
+	if reset {
+		if err := writeReset(w, replay); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := writeEvent(w, filter, format, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind; the client reconnects with Last-Event-ID
+				log.Printf("[STREAM] Disconnecting slow client %s", r.RemoteAddr)
+				return
+			}
+			if err := writeEvent(w, filter, format, event); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes a single SSE frame when the event's alert matches the filter
+func writeEvent(w http.ResponseWriter, filter models.AlertFilter, format normalize.Format, event models.AlertEvent) error {
+	if !filter.Matches(event.Alert) {
+		return nil
+	}
+
+	doc, err := normalize.Render(format, event.Alert)
+	if err != nil {
+		log.Printf("[STREAM] Error normalizing event %d: %v", event.ID, err)
+		return nil
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		log.Printf("[STREAM] Error encoding event %d: %v", event.ID, err)
+		return nil
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// writeReset tells a resuming client that its Last-Event-ID could not be
+// honoured. The frame carries the ID just before the replayed events, so the
+// client does not present the stale ID again when it next reconnects.
+func writeReset(w http.ResponseWriter, replay []models.AlertEvent) error {
+	if len(replay) > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", replay[0].ID-1); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: {}\n\n", models.EventStreamReset)
+	return err
+}
+
+// parseLastEventID reads the resume position from the header or query string
+func parseLastEventID(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
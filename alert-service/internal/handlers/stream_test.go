@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/events"
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamRecorder is a flushable ResponseWriter that is safe to read while the
+// handler writes. When hold is set, the first write of an event frame blocks
+// until hold is closed, simulating a client that stops reading.
+type streamRecorder struct {
+	header http.Header
+
+	mu     sync.Mutex
+	code   int
+	body   bytes.Buffer
+	hold   chan struct{}
+	held   chan struct{}
+	holdOn sync.Once
+}
+
+func newStreamRecorder() *streamRecorder {
+	return &streamRecorder{header: make(http.Header), held: make(chan struct{})}
+}
+
+func (r *streamRecorder) Header() http.Header { return r.header }
+
+func (r *streamRecorder) WriteHeader(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.code = code
+}
+
+func (r *streamRecorder) Write(p []byte) (int, error) {
+	if r.hold != nil && bytes.Contains(p, []byte("event: alert.")) {
+		r.holdOn.Do(func() {
+			close(r.held)
+			<-r.hold
+		})
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.body.Write(p)
+}
+
+func (r *streamRecorder) Flush() {}
+
+func (r *streamRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body.String()
+}
+
+// serveStream runs the handler until the returned cancel func is called or
+// the handler returns; done is closed when it has returned
+func serveStream(h *StreamHandler, rec *streamRecorder, target string, header http.Header) (cancel context.CancelFunc, done chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, target, nil).WithContext(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		h.StreamAlerts(rec, req)
+	}()
+	return cancel, done
+}
+
+func TestStreamHandler_StreamAlerts(t *testing.T) {
+	critical := models.Alert{ID: "a-1", Source: "firewall", Severity: "critical", Status: models.StatusOpen}
+	low := models.Alert{ID: "a-2", Source: "ids", Severity: "low", Status: models.StatusOpen}
+
+	t.Run("sends retry and live events matching the filter", func(t *testing.T) {
+		broker := events.NewBroker(10, 10)
+		h := NewStreamHandler(broker, time.Hour)
+		rec := newStreamRecorder()
+
+		cancel, done := serveStream(h, rec, "/alerts/stream?severity=critical", nil)
+		require.Eventually(t, func() bool { return broker.SubscriberCount() == 1 }, time.Second, time.Millisecond)
+		broker.Publish(models.EventAlertCreated, low)
+		event := broker.Publish(models.EventAlertCreated, critical)
+
+		require.Eventually(t, func() bool { return strings.Contains(rec.String(), `"id":"a-1"`) }, time.Second, time.Millisecond)
+		cancel()
+		<-done
+
+		out := rec.String()
+		assert.True(t, strings.HasPrefix(out, "retry: 3000\n\n"))
+		assert.Contains(t, out, fmt.Sprintf("id: %d\nevent: alert.created\n", event.ID))
+		assert.NotContains(t, out, `"id":"a-2"`)
+		assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		assert.Equal(t, 0, broker.SubscriberCount(), "the subscription ends with the request")
+	})
+
+	t.Run("sends heartbeats", func(t *testing.T) {
+		broker := events.NewBroker(10, 10)
+		h := NewStreamHandler(broker, 5*time.Millisecond)
+		rec := newStreamRecorder()
+
+		cancel, done := serveStream(h, rec, "/alerts/stream", nil)
+		require.Eventually(t, func() bool { return strings.Count(rec.String(), ": heartbeat\n\n") >= 2 }, time.Second, time.Millisecond)
+		cancel()
+		<-done
+	})
+
+	t.Run("resumes after Last-Event-ID", func(t *testing.T) {
+		for _, tc := range []struct {
+			name   string
+			target string
+			header bool
+		}{
+			{name: "header", target: "/alerts/stream", header: true},
+			{name: "query", target: "/alerts/stream?last_event_id=%d"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				broker := events.NewBroker(10, 10)
+				first := broker.Publish(models.EventAlertCreated, critical)
+				broker.Publish(models.EventAlertCreated, low)
+				h := NewStreamHandler(broker, time.Hour)
+				rec := newStreamRecorder()
+
+				target := tc.target
+				header := http.Header{}
+				if tc.header {
+					header.Set("Last-Event-ID", fmt.Sprint(first.ID))
+				} else {
+					target = fmt.Sprintf(target, first.ID)
+				}
+				cancel, done := serveStream(h, rec, target, header)
+				require.Eventually(t, func() bool { return strings.Contains(rec.String(), `"id":"a-2"`) }, time.Second, time.Millisecond)
+				cancel()
+				<-done
+
+				assert.NotContains(t, rec.String(), `"id":"a-1"`)
+				assert.NotContains(t, rec.String(), models.EventStreamReset)
+			})
+		}
+	})
+
+	t.Run("resets when Last-Event-ID cannot be honoured", func(t *testing.T) {
+		broker := events.NewBroker(1, 10)
+		broker.Publish(models.EventAlertCreated, critical)
+		kept := broker.Publish(models.EventAlertCreated, low)
+		h := NewStreamHandler(broker, time.Hour)
+		rec := newStreamRecorder()
+
+		cancel, done := serveStream(h, rec, "/alerts/stream?last_event_id=42", nil)
+		require.Eventually(t, func() bool { return strings.Contains(rec.String(), `"id":"a-2"`) }, time.Second, time.Millisecond)
+		cancel()
+		<-done
+
+		out := rec.String()
+		reset := fmt.Sprintf("id: %d\nevent: %s\ndata: {}\n\n", kept.ID-1, models.EventStreamReset)
+		require.Contains(t, out, reset)
+		assert.Less(t, strings.Index(out, reset), strings.Index(out, `"id":"a-2"`), "the reset comes before the replay")
+	})
+
+	t.Run("invalid Last-Event-ID", func(t *testing.T) {
+		h := NewStreamHandler(events.NewBroker(10, 10), time.Hour)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/alerts/stream", nil)
+		req.Header.Set("Last-Event-ID", "abc")
+
+		h.StreamAlerts(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("disconnects a slow client", func(t *testing.T) {
+		broker := events.NewBroker(10, 1)
+		h := NewStreamHandler(broker, time.Hour)
+		rec := newStreamRecorder()
+		rec.hold = make(chan struct{})
+
+		cancel, done := serveStream(h, rec, "/alerts/stream", nil)
+		defer cancel()
+		require.Eventually(t, func() bool { return broker.SubscriberCount() == 1 }, time.Second, time.Millisecond)
+
+		// The handler stalls writing the first event, one more fits in its
+		// queue and the third overflows it
+		broker.Publish(models.EventAlertCreated, models.Alert{ID: "e-1"})
+		<-rec.held
+		broker.Publish(models.EventAlertCreated, models.Alert{ID: "e-2"})
+		broker.Publish(models.EventAlertCreated, models.Alert{ID: "e-3"})
+		assert.Equal(t, 0, broker.SubscriberCount())
+		close(rec.hold)
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("slow client was not disconnected")
+		}
+		assert.Contains(t, rec.String(), `"id":"e-2"`, "queued events are delivered before disconnecting")
+		assert.NotContains(t, rec.String(), `"id":"e-3"`)
+	})
+}
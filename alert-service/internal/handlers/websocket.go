@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"censys_alert_system/internal/events"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+)
+
+const (
+	wsWriteWait        = 10 * time.Second
+	wsPongWait         = 60 * time.Second
+	wsPingInterval     = (wsPongWait * 9) / 10
+	wsMaxMessageSize   = 64 * 1024
+	wsMaxSubscriptions = 32
+	wsReplyQueueSize   = 16
+)
+
+// WebSocket message types
+const (
+	wsSubscribe    = "subscribe"
+	wsUnsubscribe  = "unsubscribe"
+	wsAcknowledge  = "acknowledge"
+	wsEvent        = "event"
+	wsSubscribed   = "subscribed"
+	wsUnsubscribed = "unsubscribed"
+	wsAcknowledged = "acknowledged"
+	wsDropped      = "dropped"
+	wsError        = "error"
+)
+
+// wsClientMessage is a command sent by a WebSocket client.
+// Filter uses the same query syntax as GET /alerts, e.g. "severity=high,critical&source=firewall".
+type wsClientMessage struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	Filter  string `json:"filter,omitempty"`
+	AlertID string `json:"alert_id,omitempty"`
+}
+
+// wsServerMessage is a message sent to a WebSocket client
+type wsServerMessage struct {
+	Type          string             `json:"type"`
+	ID            string             `json:"id,omitempty"`
+	Subscriptions []string           `json:"subscriptions,omitempty"`
+	Event         *models.AlertEvent `json:"event,omitempty"`
+	Alert         *models.Alert      `json:"alert,omitempty"`
+	Count         int                `json:"count,omitempty"`
+	Error         string             `json:"error,omitempty"`
+}
+
+// WebSocketHandler serves the bidirectional alert subscription API
+type WebSocketHandler struct {
+	broker       *events.Broker
+	alertService *service.AlertService
+	policy       events.SlowConsumerPolicy
+	upgrader     websocket.Upgrader
+}
+
+// NewWebSocketHandler creates a WebSocket handler. allowedOrigins lists the
+// browser origins allowed to connect ("*" for any); when empty only
+// same-origin requests are accepted.
+func NewWebSocketHandler(broker *events.Broker, alertService *service.AlertService, policy events.SlowConsumerPolicy, allowedOrigins []string) *WebSocketHandler {
+	h := &WebSocketHandler{
+		broker:       broker,
+		alertService: alertService,
+		policy:       policy,
+	}
+	if len(allowedOrigins) > 0 {
+		h.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			for _, allowed := range allowedOrigins {
+				if allowed == "*" || allowed == origin {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return h
+}
+
+// wsConnection holds the per-connection subscription state
+type wsConnection struct {
+	conn    *websocket.Conn
+	sub     *events.Subscription
+	replies chan wsServerMessage
+	// done is closed when the write loop exits
+	done chan struct{}
+
+	mu      sync.Mutex
+	filters map[string]models.AlertFilter
+}
+
+// ServeWS handles GET /ws
+func (h *WebSocketHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		log.Printf("[WS] Upgrade failed for %s: %v", r.RemoteAddr, err)
+		return
+	}
+
+	metrics.WebSocketConnectionsTotal.Inc()
+	metrics.WebSocketConnectionsActive.Inc()
+	defer metrics.WebSocketConnectionsActive.Dec()
+
+	sub, _, _ := h.broker.Subscribe(0, h.policy)
+	c := &wsConnection{
+		conn:    conn,
+		sub:     sub,
+		replies: make(chan wsServerMessage, wsReplyQueueSize),
+		done:    make(chan struct{}),
+		filters: make(map[string]models.AlertFilter),
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	go func() {
+		defer close(c.done)
+		// Closing the connection also unblocks the read loop
+		defer conn.Close()
+		h.writeLoop(ctx, c)
+	}()
+
+	h.readLoop(ctx, c)
+
+	cancel()
+	h.broker.Unsubscribe(sub)
+	<-c.done
+	log.Printf("[WS] Connection from %s closed", r.RemoteAddr)
+}
+
+// readLoop handles client commands until the connection fails or is closed
+func (h *WebSocketHandler) readLoop(ctx context.Context, c *wsConnection) {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var msg wsClientMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("[WS] Read error: %v", err)
+			}
+			return
+		}
+		metrics.WebSocketMessagesReceived.WithLabelValues(msg.Type).Inc()
+
+		reply := h.handleMessage(ctx, c, msg)
+		select {
+		case c.replies <- reply:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// handleMessage applies a single client command and returns the reply
+func (h *WebSocketHandler) handleMessage(ctx context.Context, c *wsConnection, msg wsClientMessage) wsServerMessage {
+	switch msg.Type {
+	case wsSubscribe:
+		if msg.ID == "" {
+			return wsServerMessage{Type: wsError, Error: "subscribe requires an id"}
+		}
+		query, err := url.ParseQuery(msg.Filter)
+		if err != nil {
+			return wsServerMessage{Type: wsError, ID: msg.ID, Error: fmt.Sprintf("invalid filter: %v", err)}
+		}
+		filter, err := parseAlertFilter(query)
+		if err != nil {
+			return wsServerMessage{Type: wsError, ID: msg.ID, Error: err.Error()}
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, exists := c.filters[msg.ID]; !exists && len(c.filters) >= wsMaxSubscriptions {
+			return wsServerMessage{Type: wsError, ID: msg.ID, Error: fmt.Sprintf("at most %d subscriptions per connection", wsMaxSubscriptions)}
+		}
+		c.filters[msg.ID] = filter
+		return wsServerMessage{Type: wsSubscribed, ID: msg.ID}
+
+	case wsUnsubscribe:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, exists := c.filters[msg.ID]; !exists {
+			return wsServerMessage{Type: wsError, ID: msg.ID, Error: "unknown subscription"}
+		}
+		delete(c.filters, msg.ID)
+		return wsServerMessage{Type: wsUnsubscribed, ID: msg.ID}
+
+	case wsAcknowledge:
+		alert, err := h.alertService.AcknowledgeAlert(ctx, msg.AlertID)
+		switch {
+		case errors.Is(err, service.ErrAlertNotFound):
+			return wsServerMessage{Type: wsError, Error: "Alert not found"}
+		case errors.Is(err, service.ErrInvalidStatusTransition):
+			return wsServerMessage{Type: wsError, Error: err.Error()}
+		case err != nil:
+			log.Printf("[WS] Error acknowledging alert %s: %v", msg.AlertID, err)
+			return wsServerMessage{Type: wsError, Error: "Failed to acknowledge alert"}
+		}
+		return wsServerMessage{Type: wsAcknowledged, Alert: alert}
+
+	default:
+		return wsServerMessage{Type: wsError, Error: fmt.Sprintf("unknown message type %q", msg.Type)}
+	}
+}
+
+// writeLoop is the only writer on the connection: it delivers matching events,
+// command replies and keepalive pings
+func (h *WebSocketHandler) writeLoop(ctx context.Context, c *wsConnection) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	dropped := 0
+	for {
+		select {
+		case <-ctx.Done():
+			c.write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+
+		case <-ping.C:
+			if err := c.write(websocket.PingMessage, nil); err != nil {
+				return
+			}
+
+		case reply := <-c.replies:
+			if err := c.writeJSON(reply); err != nil {
+				return
+			}
+
+		case event, ok := <-c.sub.Events():
+			if !ok {
+				log.Printf("[WS] Disconnecting slow client %s", c.conn.RemoteAddr())
+				c.write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow"))
+				return
+			}
+
+			if total := h.broker.DroppedEvents(c.sub); total > dropped {
+				if err := c.writeJSON(wsServerMessage{Type: wsDropped, Count: total - dropped}); err != nil {
+					return
+				}
+				dropped = total
+			}
+
+			matched := c.matching(event.Alert)
+			if len(matched) == 0 {
+				continue
+			}
+			if err := c.writeJSON(wsServerMessage{Type: wsEvent, Subscriptions: matched, Event: &event}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// matching returns the IDs of the subscriptions whose filter matches the alert
+func (c *wsConnection) matching(alert models.Alert) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var ids []string
+	for id, filter := range c.filters {
+		if filter.Matches(alert) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (c *wsConnection) writeJSON(msg wsServerMessage) error {
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		return err
+	}
+	metrics.WebSocketMessagesSent.WithLabelValues(msg.Type).Inc()
+	return nil
+}
+
+func (c *wsConnection) write(messageType int, data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return c.conn.WriteMessage(messageType, data)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"censys_alert_system/internal/events"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+	"censys_alert_system/internal/service/mocks"
+)
+
+// newWSServer serves a WebSocket handler backed by mocked alert storage
+func newWSServer(t *testing.T, broker *events.Broker, policy events.SlowConsumerPolicy, allowedOrigins []string) (*httptest.Server, *mocks.AlertStorageInterface) {
+	t.Helper()
+	mockStorage := mocks.NewAlertStorageInterface(t)
+	alertService := service.NewAlertService(mockStorage, nil)
+	alertService.SetPublisher(broker)
+
+	srv := httptest.NewServer(http.HandlerFunc(NewWebSocketHandler(broker, alertService, policy, allowedOrigins).ServeWS))
+	t.Cleanup(srv.Close)
+	return srv, mockStorage
+}
+
+func dialWS(t *testing.T, srv *httptest.Server, origin string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+// roundTrip sends a client message and reads the next server message
+func roundTrip(t *testing.T, conn *websocket.Conn, msg wsClientMessage) wsServerMessage {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(msg))
+	return readWS(t, conn)
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) wsServerMessage {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var reply wsServerMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	return reply
+}
+
+func TestWebSocketHandler_Messages(t *testing.T) {
+	broker := events.NewBroker(10, 10)
+	srv, mockStorage := newWSServer(t, broker, events.DisconnectSlow, nil)
+	conn, _, err := dialWS(t, srv, "")
+	require.NoError(t, err)
+
+	t.Run("subscribe", func(t *testing.T) {
+		reply := roundTrip(t, conn, wsClientMessage{Type: wsSubscribe, ID: "crit", Filter: "severity=critical"})
+		assert.Equal(t, wsServerMessage{Type: wsSubscribed, ID: "crit"}, reply)
+
+		reply = roundTrip(t, conn, wsClientMessage{Type: wsSubscribe, ID: "acked", Filter: "status=acknowledged"})
+		assert.Equal(t, wsSubscribed, reply.Type)
+	})
+
+	t.Run("subscribe errors", func(t *testing.T) {
+		assert.Equal(t, "subscribe requires an id", roundTrip(t, conn, wsClientMessage{Type: wsSubscribe}).Error)
+		assert.Contains(t, roundTrip(t, conn, wsClientMessage{Type: wsSubscribe, ID: "x", Filter: "%zz"}).Error, "invalid filter")
+		assert.Contains(t, roundTrip(t, conn, wsClientMessage{Type: wsSubscribe, ID: "x", Filter: "days=-1"}).Error, "days")
+		assert.Contains(t, roundTrip(t, conn, wsClientMessage{Type: "bogus"}).Error, `unknown message type "bogus"`)
+	})
+
+	t.Run("events name the matching subscriptions", func(t *testing.T) {
+		broker.Publish(models.EventAlertCreated, models.Alert{ID: "low", Severity: "low", Status: models.StatusOpen})
+		broker.Publish(models.EventAlertCreated, models.Alert{ID: "crit", Severity: "critical", Status: models.StatusOpen})
+
+		reply := readWS(t, conn)
+		assert.Equal(t, wsEvent, reply.Type)
+		assert.Equal(t, []string{"crit"}, reply.Subscriptions)
+		require.NotNil(t, reply.Event)
+		assert.Equal(t, "crit", reply.Event.Alert.ID)
+	})
+
+	t.Run("acknowledge", func(t *testing.T) {
+		acked := &models.Alert{ID: "crit", Severity: "critical", Status: models.StatusAcknowledged}
+		mockStorage.On("UpdateAlertStatus", mock.Anything, "crit", []string{models.StatusOpen}, models.StatusAcknowledged).Return(acked, nil).Once()
+
+		require.NoError(t, conn.WriteJSON(wsClientMessage{Type: wsAcknowledge, AlertID: "crit"}))
+
+		// The reply and the published event may arrive in either order
+		var types []string
+		for range 2 {
+			reply := readWS(t, conn)
+			types = append(types, reply.Type)
+			switch reply.Type {
+			case wsAcknowledged:
+				assert.Equal(t, acked, reply.Alert)
+			case wsEvent:
+				assert.ElementsMatch(t, []string{"crit", "acked"}, reply.Subscriptions)
+				assert.Equal(t, models.EventAlertAcknowledged, reply.Event.Type)
+			}
+		}
+		assert.ElementsMatch(t, []string{wsAcknowledged, wsEvent}, types)
+	})
+
+	t.Run("acknowledge errors", func(t *testing.T) {
+		mockStorage.On("UpdateAlertStatus", mock.Anything, "missing", mock.Anything, mock.Anything).Return(nil, nil).Once()
+		mockStorage.On("GetAlertByID", mock.Anything, "missing").Return(nil, nil).Once()
+		assert.Equal(t, wsServerMessage{Type: wsError, Error: "Alert not found"}, roundTrip(t, conn, wsClientMessage{Type: wsAcknowledge, AlertID: "missing"}))
+
+		mockStorage.On("UpdateAlertStatus", mock.Anything, "done", mock.Anything, mock.Anything).Return(nil, nil).Once()
+		mockStorage.On("GetAlertByID", mock.Anything, "done").Return(&models.Alert{ID: "done", Status: models.StatusResolved}, nil).Once()
+		assert.Contains(t, roundTrip(t, conn, wsClientMessage{Type: wsAcknowledge, AlertID: "done"}).Error, "invalid status transition")
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		assert.Equal(t, wsServerMessage{Type: wsUnsubscribed, ID: "crit"}, roundTrip(t, conn, wsClientMessage{Type: wsUnsubscribe, ID: "crit"}))
+		assert.Equal(t, "unknown subscription", roundTrip(t, conn, wsClientMessage{Type: wsUnsubscribe, ID: "crit"}).Error)
+
+		// With no matching subscription left the next event is skipped
+		broker.Publish(models.EventAlertCreated, models.Alert{ID: "crit-2", Severity: "critical", Status: models.StatusOpen})
+		broker.Publish(models.EventAlertCreated, models.Alert{ID: "acked-2", Status: models.StatusAcknowledged})
+		reply := readWS(t, conn)
+		assert.Equal(t, "acked-2", reply.Event.Alert.ID)
+	})
+}
+
+func TestWebSocketHandler_SubscriptionLimit(t *testing.T) {
+	srv, _ := newWSServer(t, events.NewBroker(10, 10), events.DisconnectSlow, nil)
+	conn, _, err := dialWS(t, srv, "")
+	require.NoError(t, err)
+
+	for i := range wsMaxSubscriptions {
+		reply := roundTrip(t, conn, wsClientMessage{Type: wsSubscribe, ID: fmt.Sprint(i)})
+		require.Equal(t, wsSubscribed, reply.Type)
+	}
+
+	reply := roundTrip(t, conn, wsClientMessage{Type: wsSubscribe, ID: "one-too-many"})
+	assert.Equal(t, wsError, reply.Type)
+	assert.Equal(t, "at most 32 subscriptions per connection", reply.Error)
+
+	// Replacing an existing subscription's filter is still allowed
+	reply = roundTrip(t, conn, wsClientMessage{Type: wsSubscribe, ID: "0", Filter: "severity=high"})
+	assert.Equal(t, wsSubscribed, reply.Type)
+}
+
+func TestWebSocketHandler_CheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{name: "no origin header", want: true},
+		{name: "cross origin without allowlist", origin: "https://evil.example"},
+		{name: "listed origin", allowed: []string{"https://soc.example"}, origin: "https://soc.example", want: true},
+		{name: "unlisted origin", allowed: []string{"https://soc.example"}, origin: "https://evil.example"},
+		{name: "wildcard", allowed: []string{"*"}, origin: "https://evil.example", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newWSServer(t, events.NewBroker(10, 10), events.DisconnectSlow, tt.allowed)
+
+			_, resp, err := dialWS(t, srv, tt.origin)
+			if tt.want {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, websocket.ErrBadHandshake)
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+	}
+}
+
+func TestWebSocketHandler_SlowConsumer(t *testing.T) {
+	t.Run("disconnect policy closes the connection", func(t *testing.T) {
+		broker := events.NewBroker(10, 1)
+		srv, _ := newWSServer(t, broker, events.DisconnectSlow, nil)
+		conn, _, err := dialWS(t, srv, "")
+		require.NoError(t, err)
+		require.Eventually(t, func() bool { return broker.SubscriberCount() == 1 }, time.Second, time.Millisecond)
+
+		// Publishing from under the subscription's feet overflows its queue of one
+		// faster than the write loop can drain it
+		for i := range 1000 {
+			broker.Publish(models.EventAlertCreated, models.Alert{ID: fmt.Sprint(i)})
+			if broker.SubscriberCount() == 0 {
+				break
+			}
+		}
+		require.Equal(t, 0, broker.SubscriberCount(), "the subscriber was not disconnected")
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				break
+			}
+		}
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), "got %v", err)
+	})
+
+	t.Run("drop policy reports dropped events", func(t *testing.T) {
+		broker := events.NewBroker(10, 1)
+		srv, _ := newWSServer(t, broker, events.DropEvents, nil)
+		conn, _, err := dialWS(t, srv, "")
+		require.NoError(t, err)
+		require.Equal(t, wsSubscribed, roundTrip(t, conn, wsClientMessage{Type: wsSubscribe, ID: "all"}).Type)
+
+		for i := range 1000 {
+			broker.Publish(models.EventAlertCreated, models.Alert{ID: fmt.Sprint(i)})
+		}
+		require.Positive(t, broker.SubscriberCount())
+
+		// The drop count is reported ahead of the next delivered event
+		var reply wsServerMessage
+		for reply.Type != wsDropped {
+			reply = readWS(t, conn)
+		}
+		assert.Positive(t, reply.Count)
+		assert.Equal(t, wsEvent, readWS(t, conn).Type)
+		assert.Equal(t, 1, broker.SubscriberCount(), "the client stays connected")
+	})
+}
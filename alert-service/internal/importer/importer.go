@@ -0,0 +1,399 @@
+// Package importer reads historical alerts from CSV and NDJSON files, such as
+// exports from another SIEM or from GET /alerts/export.
+//
+// Readers stream: each row is decoded as it is read, so a file of any size
+// holds one row at a time. Column names (CSV) and keys (NDJSON) are matched
+// without regard to case, and common names used by other tools are accepted
+// for each alert field, e.g. timestamp for created_at or message for
+// description. A row that cannot be decoded is returned as a *RowError with
+// its line number, and reading carries on with the next row.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"censys_alert_system/internal/export"
+	"censys_alert_system/internal/formats"
+	"censys_alert_system/internal/models"
+)
+
+// Import formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// maxLineBytes caps a single NDJSON line
+const maxLineBytes = 1 << 20
+
+// ErrUnsupportedFormat is returned for a format other than csv or ndjson
+var ErrUnsupportedFormat = errors.New("import: unsupported format")
+
+// fieldNames lists, for each alert field, the columns or keys it is read
+// from, first match wins
+var fieldNames = map[string][]string{
+	"source":          {"source", "log_source"},
+	"severity":        {"severity", "priority", "level"},
+	"description":     {"description", "message", "msg", "title", "summary"},
+	"status":          {"status", "state"},
+	"created_at":      {"created_at", "timestamp", "@timestamp", "time", "event_time"},
+	"acknowledged_at": {"acknowledged_at"},
+	"resolved_at":     {"resolved_at", "closed_at"},
+}
+
+// indicatorNames are columns or keys kept as indicators; ip_address, the
+// enriched address of an exported alert, becomes its src_ip
+var indicatorNames = map[string]string{
+	"src_ip":     "src_ip",
+	"dst_ip":     "dst_ip",
+	"ip_address": "src_ip",
+}
+
+// statusNames maps the lifecycle statuses of other tools onto ours
+var statusNames = map[string]string{
+	"new":    models.StatusOpen,
+	"closed": models.StatusResolved,
+}
+
+// ValidateFormat checks that format is one of the import formats
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatCSV, FormatNDJSON:
+		return nil
+	}
+	return fmt.Errorf("%w %q: use csv or ndjson", ErrUnsupportedFormat, format)
+}
+
+// FileFormat returns the format of a file name (.csv, .ndjson or .jsonl,
+// optionally followed by .gz) and whether it is gzip-compressed
+func FileFormat(name string) (format string, compressed bool, ok bool) {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".gz") {
+		compressed = true
+		lower = strings.TrimSuffix(lower, ".gz")
+	}
+
+	switch filepath.Ext(lower) {
+	case ".csv":
+		return FormatCSV, compressed, true
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, compressed, true
+	default:
+		return "", false, false
+	}
+}
+
+// Record is one decoded row. Severity and status are normalised to ours
+// where they can be, but not validated.
+type Record struct {
+	Line           int
+	Source         string
+	Severity       string
+	Description    string
+	Status         string
+	CreatedAt      time.Time
+	AcknowledgedAt *time.Time
+	ResolvedAt     *time.Time
+	Indicators     map[string]string
+	// Raw is the row as read, as a JSON object
+	Raw json.RawMessage
+}
+
+// RowError is a row that could not be decoded
+type RowError struct {
+	Line  int
+	Field string
+	Err   error
+}
+
+func (e *RowError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("line %d: %s: %v", e.Line, e.Field, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// Reader reads the records of an import file one at a time
+type Reader struct {
+	// next returns the fields of the next row, its line and the row as JSON
+	next func() (fields map[string]interface{}, line int, raw json.RawMessage, err error)
+}
+
+// NewReader starts reading an import file in the given format. A CSV file
+// must start with a header row.
+func NewReader(format string, r io.Reader) (*Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON:
+		return newNDJSONReader(r), nil
+	}
+	return nil, ValidateFormat(format)
+}
+
+// Read returns the next record, or io.EOF after the last one. A *RowError
+// means only that row is unusable; any other error ends the file.
+func (r *Reader) Read() (Record, error) {
+	fields, line, raw, err := r.next()
+	if err != nil {
+		return Record{Line: line}, err
+	}
+	return decodeRecord(fields, line, raw)
+}
+
+func newCSVReader(r io.Reader) (*Reader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("import: empty CSV file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("import: reading CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+	}
+
+	next := func() (map[string]interface{}, int, json.RawMessage, error) {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil, 0, nil, io.EOF
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.StartLine, nil, &RowError{Line: parseErr.StartLine, Err: fmt.Errorf("invalid CSV: %v", parseErr.Err)}
+		}
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("import: reading CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(row) != len(columns) {
+			return nil, line, nil, &RowError{Line: line, Err: fmt.Errorf("row has %d columns, header has %d", len(row), len(columns))}
+		}
+
+		fields := make(map[string]interface{}, len(columns))
+		values := make(map[string]string, len(columns))
+		for i, column := range columns {
+			value := unquoteFormula(row[i])
+			fields[column] = value
+			values[column] = value
+		}
+		raw, err := json.Marshal(values)
+		if err != nil {
+			return nil, line, nil, &RowError{Line: line, Err: err}
+		}
+		return fields, line, raw, nil
+	}
+	return &Reader{next: next}, nil
+}
+
+func newNDJSONReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	line := 0
+
+	next := func() (map[string]interface{}, int, json.RawMessage, error) {
+		for scanner.Scan() {
+			line++
+			text := bytes.TrimSpace(scanner.Bytes())
+			if len(text) == 0 {
+				continue
+			}
+
+			decoder := json.NewDecoder(bytes.NewReader(text))
+			decoder.UseNumber()
+			var object map[string]interface{}
+			if err := decoder.Decode(&object); err != nil || object == nil {
+				return nil, line, nil, &RowError{Line: line, Err: errors.New("not a JSON object")}
+			}
+			fields := make(map[string]interface{}, len(object))
+			for key, value := range object {
+				fields[strings.ToLower(key)] = value
+			}
+			return fields, line, append(json.RawMessage(nil), text...), nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, line + 1, nil, fmt.Errorf("import: reading NDJSON after line %d: %w", line, err)
+		}
+		return nil, line, nil, io.EOF
+	}
+	return &Reader{next: next}
+}
+
+// unquoteFormula drops the quote a CSV export puts before cells a
+// spreadsheet would evaluate, so exported alerts import as they were
+func unquoteFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+// decodeRecord picks the alert fields out of a row
+func decodeRecord(fields map[string]interface{}, line int, raw json.RawMessage) (Record, error) {
+	record := Record{Line: line, Raw: raw}
+	text := func(field string) (string, error) {
+		for _, name := range fieldNames[field] {
+			if value, ok := fields[name]; ok && value != nil {
+				s, ok := scalarString(value)
+				if !ok {
+					return "", &RowError{Line: line, Field: field, Err: errors.New("must be a string")}
+				}
+				if s = strings.TrimSpace(s); s != "" {
+					return s, nil
+				}
+			}
+		}
+		return "", nil
+	}
+	optionalTime := func(field string) (*time.Time, error) {
+		value, err := text(field)
+		if err != nil || value == "" {
+			return nil, err
+		}
+		t, err := parseTime(value)
+		if err != nil {
+			return nil, &RowError{Line: line, Field: field, Err: err}
+		}
+		return &t, nil
+	}
+
+	var err error
+	if record.Source, err = text("source"); err != nil {
+		return record, err
+	}
+	record.Source = strings.ToLower(record.Source)
+	if record.Severity, err = text("severity"); err != nil {
+		return record, err
+	}
+	record.Severity = normalizeSeverity(record.Severity)
+	if record.Description, err = text("description"); err != nil {
+		return record, err
+	}
+	if record.Status, err = text("status"); err != nil {
+		return record, err
+	}
+	record.Status = strings.ToLower(record.Status)
+	if status, ok := statusNames[record.Status]; ok {
+		record.Status = status
+	}
+
+	createdAt, err := optionalTime("created_at")
+	if err != nil {
+		return record, err
+	}
+	if createdAt != nil {
+		record.CreatedAt = *createdAt
+	}
+	if record.AcknowledgedAt, err = optionalTime("acknowledged_at"); err != nil {
+		return record, err
+	}
+	if record.ResolvedAt, err = optionalTime("resolved_at"); err != nil {
+		return record, err
+	}
+
+	record.Indicators = indicators(fields)
+	return record, nil
+}
+
+// indicators collects the indicators of a row: an indicators object, the
+// indicators of an exported whole_event, and the columns in indicatorNames
+func indicators(fields map[string]interface{}) map[string]string {
+	found := make(map[string]string)
+	collect := func(object interface{}) {
+		values, _ := object.(map[string]interface{})
+		for key, value := range values {
+			if s, ok := scalarString(value); ok && s != "" {
+				found[key] = s
+			}
+		}
+	}
+	if event, ok := fields[export.ColumnWholeEvent]; ok {
+		if s, isString := event.(string); isString {
+			// CSV exports keep whole_event as JSON text
+			var decoded interface{}
+			if json.Unmarshal([]byte(s), &decoded) == nil {
+				event = decoded
+			}
+		}
+		if object, ok := event.(map[string]interface{}); ok {
+			collect(object["indicators"])
+		}
+	}
+	collect(fields["indicators"])
+	for name, indicator := range indicatorNames {
+		if s, ok := scalarString(fields[name]); ok && s != "" && found[indicator] == "" {
+			found[indicator] = s
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return found
+}
+
+// normalizeSeverity maps 0-10 and CEF severities onto ours and lowercases
+// the rest, so unknown values are reported as they were given
+func normalizeSeverity(severity string) string {
+	lower := strings.ToLower(severity)
+	if lower == "critical" {
+		return lower
+	}
+	if mapped, ok := formats.MapSeverity(lower); ok {
+		return mapped
+	}
+	return lower
+}
+
+// Time layouts tried after RFC 3339; times without a zone are UTC
+var timeLayouts = []string{time.RFC3339Nano, time.DateTime, "2006-01-02T15:04:05", "2006-01-02T15:04:05.999999999"}
+
+// parseTime reads an RFC 3339 time, a date and time without a zone, or Unix
+// seconds or milliseconds
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil && n > 0 && !math.IsInf(n, 0) {
+		if n >= 1e12 {
+			n /= 1000
+		}
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC().Truncate(time.Microsecond), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or Unix seconds", value)
+}
+
+// scalarString renders strings, numbers and booleans as strings
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case nil:
+		return "", true
+	}
+	return "", false
+}
@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/export"
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll returns the records of a file and the row errors met on the way
+func readAll(t *testing.T, format, data string) ([]Record, []*RowError) {
+	r, err := NewReader(format, strings.NewReader(data))
+	require.NoError(t, err)
+
+	var records []Record
+	var rowErrors []*RowError
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, rowErrors
+		}
+		var rowErr *RowError
+		require.True(t, errors.As(err, &rowErr) || err == nil, "unexpected error: %v", err)
+		if rowErr != nil {
+			rowErrors = append(rowErrors, rowErr)
+			continue
+		}
+		records = append(records, record)
+	}
+}
+
+func TestFileFormat(t *testing.T) {
+	for name, want := range map[string]struct {
+		format     string
+		compressed bool
+		ok         bool
+	}{
+		"siem.csv":         {FormatCSV, false, true},
+		"SIEM.NDJSON":      {FormatNDJSON, false, true},
+		"alerts.jsonl.gz":  {FormatNDJSON, true, true},
+		"alerts.csv.gz":    {FormatCSV, true, true},
+		"alerts.json":      {"", false, false},
+		"alerts.xlsx":      {"", false, false},
+		"no-extension.gz2": {"", false, false},
+	} {
+		format, compressed, ok := FileFormat(name)
+		assert.Equal(t, want.format, format, name)
+		assert.Equal(t, want.compressed, compressed, name)
+		assert.Equal(t, want.ok, ok, name)
+	}
+}
+
+func TestNewReader_UnsupportedFormat(t *testing.T) {
+	_, err := NewReader("xlsx", strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = NewReader(FormatCSV, strings.NewReader(""))
+	assert.Error(t, err, "a CSV file needs a header")
+}
+
+func TestCSVReader(t *testing.T) {
+	data := "\ufeffTimestamp,Source,Priority,Message,State,Closed_At,src_ip\n" +
+		"2024-03-01T10:00:00Z,Firewall,7,'=cmd|' /C calc'!A0,closed,2024-03-02 08:00:00,10.1.1.1\n" +
+		"2024-03-01T11:00:00Z,ids,low,too few columns\n" +
+		"1709290800,ids,Critical,Port scan,new,,\n" +
+		"yesterday,ids,low,Bad time,new,,\n"
+
+	records, rowErrors := readAll(t, FormatCSV, data)
+
+	require.Len(t, records, 2)
+	resolvedAt := time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, Record{
+		Line:        2,
+		Source:      "firewall",
+		Severity:    "high",
+		Description: "=cmd|' /C calc'!A0",
+		Status:      models.StatusResolved,
+		CreatedAt:   time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+		ResolvedAt:  &resolvedAt,
+		Indicators:  map[string]string{"src_ip": "10.1.1.1"},
+		Raw:         records[0].Raw,
+	}, records[0])
+	assert.JSONEq(t, `{"timestamp":"2024-03-01T10:00:00Z","source":"Firewall","priority":"7","message":"=cmd|' /C calc'!A0","state":"closed","closed_at":"2024-03-02 08:00:00","src_ip":"10.1.1.1"}`, string(records[0].Raw))
+
+	assert.Equal(t, 4, records[1].Line)
+	assert.Equal(t, "critical", records[1].Severity)
+	assert.Equal(t, models.StatusOpen, records[1].Status)
+	assert.Equal(t, time.Unix(1709290800, 0).UTC(), records[1].CreatedAt)
+	assert.Nil(t, records[1].Indicators)
+
+	require.Len(t, rowErrors, 2)
+	assert.Equal(t, 3, rowErrors[0].Line)
+	assert.Contains(t, rowErrors[0].Error(), "row has 4 columns, header has 7")
+	assert.Equal(t, 5, rowErrors[1].Line)
+	assert.Equal(t, "created_at", rowErrors[1].Field)
+}
+
+func TestNDJSONReader(t *testing.T) {
+	data := `{"source":"ids","severity":"medium","description":"Port scan","created_at":"2024-03-01T10:00:00Z","indicators":{"src_ip":"10.0.0.5","port":22}}
+
+not json
+{"source":"ids","severity":"high","description":{"nested":true},"created_at":1709290800000}
+{"source":"ids","level":"very-high","msg":"Beacon","@timestamp":1709290800.5,"dst_ip":"8.8.8.8"}
+`
+	records, rowErrors := readAll(t, FormatNDJSON, data)
+
+	require.Len(t, records, 2)
+	assert.Equal(t, 1, records[0].Line)
+	assert.Equal(t, map[string]string{"src_ip": "10.0.0.5", "port": "22"}, records[0].Indicators)
+	assert.Equal(t, `{"source":"ids","severity":"medium","description":"Port scan","created_at":"2024-03-01T10:00:00Z","indicators":{"src_ip":"10.0.0.5","port":22}}`, string(records[0].Raw))
+
+	assert.Equal(t, 5, records[1].Line)
+	assert.Equal(t, "critical", records[1].Severity)
+	assert.Equal(t, "Beacon", records[1].Description)
+	assert.Equal(t, time.Unix(1709290800, 5e8).UTC(), records[1].CreatedAt)
+	assert.Equal(t, map[string]string{"dst_ip": "8.8.8.8"}, records[1].Indicators)
+
+	require.Len(t, rowErrors, 2)
+	assert.Equal(t, 3, rowErrors[0].Line)
+	assert.Equal(t, 4, rowErrors[1].Line)
+	assert.Equal(t, "description", rowErrors[1].Field)
+}
+
+// Alerts exported by GET /alerts/export import as they were exported
+func TestReader_RoundTripsExports(t *testing.T) {
+	ip := "10.0.0.1"
+	acknowledgedAt := time.Date(2025, 1, 1, 11, 0, 0, 0, time.UTC)
+	alert := models.Alert{
+		ID:             "5b3c1d2e-0000-4000-8000-000000000001",
+		Source:         "firewall",
+		Severity:       "high",
+		Description:    "-1 failed logins",
+		WholeEvent:     []byte(`{"indicators":{"src_ip":"192.168.1.9"}}`),
+		IPAddress:      &ip,
+		Fingerprint:    "fp-1",
+		Status:         models.StatusAcknowledged,
+		AcknowledgedAt: &acknowledgedAt,
+		CreatedAt:      time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC),
+	}
+
+	for _, format := range []string{FormatCSV, FormatNDJSON} {
+		var buf bytes.Buffer
+		w, err := export.NewWriter(format, &buf, export.Columns)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(alert))
+		require.NoError(t, w.Close())
+
+		records, rowErrors := readAll(t, format, buf.String())
+		require.Empty(t, rowErrors, format)
+		require.Len(t, records, 1, format)
+		record := records[0]
+		assert.Equal(t, alert.Source, record.Source, format)
+		assert.Equal(t, alert.Severity, record.Severity, format)
+		assert.Equal(t, alert.Description, record.Description, format)
+		assert.Equal(t, alert.Status, record.Status, format)
+		assert.Equal(t, alert.CreatedAt, record.CreatedAt, format)
+		assert.Equal(t, alert.AcknowledgedAt, record.AcknowledgedAt, format)
+		assert.Nil(t, record.ResolvedAt, format)
+		assert.Equal(t, map[string]string{"src_ip": "192.168.1.9"}, record.Indicators, "the event's indicators win over the enriched address")
+	}
+}
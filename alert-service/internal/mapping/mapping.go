@@ -0,0 +1,462 @@
+// Package mapping turns arbitrary upstream JSON into alerts through a
+// declarative spec loaded from YAML or JSON.
+package mapping
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"censys_alert_system/external"
+)
+
+// Alert fields a spec can map
+const (
+	FieldSource      = "source"
+	FieldSeverity    = "severity"
+	FieldDescription = "description"
+	FieldCreatedAt   = "created_at"
+)
+
+var alertFields = []string{FieldSource, FieldSeverity, FieldDescription, FieldCreatedAt}
+
+// Timestamp formats besides Go layouts
+const (
+	FormatRFC3339 = "rfc3339"
+	FormatRFC1123 = "rfc1123"
+	FormatUnix    = "unix"
+	FormatUnixMs  = "unix_ms"
+)
+
+// nowValue as a created_at default stamps the time of mapping
+const nowValue = "now"
+
+// Spec declares how upstream records become alerts
+type Spec struct {
+	// Records is the path to the record array in a response. When empty, a
+	// top-level array, an "alerts" array or a single object is used.
+	Records string               `yaml:"records" json:"records,omitempty"`
+	Fields  map[string]FieldSpec `yaml:"fields" json:"fields"`
+	// Drop lists conditions; a record matching any of them is skipped
+	Drop []Condition `yaml:"drop" json:"drop,omitempty"`
+}
+
+// FieldSpec produces one alert field. The value is the constant Value, or
+// the first of Path/Paths present in the record, translated through Lookup.
+// Default applies when nothing is found or the lookup has no entry.
+type FieldSpec struct {
+	Path    string            `yaml:"path" json:"path,omitempty"`
+	Paths   []string          `yaml:"paths" json:"paths,omitempty"`
+	Value   string            `yaml:"value" json:"value,omitempty"`
+	Lookup  map[string]string `yaml:"lookup" json:"lookup,omitempty"`
+	Default string            `yaml:"default" json:"default,omitempty"`
+
+	// Format and Timezone apply to created_at: rfc3339 (default), rfc1123,
+	// unix, unix_ms or a Go layout; Timezone is used for layouts without a zone
+	Format   string `yaml:"format" json:"format,omitempty"`
+	Timezone string `yaml:"timezone" json:"timezone,omitempty"`
+}
+
+// Condition matches records by the value at Path. All given predicates must hold.
+type Condition struct {
+	Path    string   `yaml:"path" json:"path"`
+	Equals  *string  `yaml:"equals" json:"equals,omitempty"`
+	In      []string `yaml:"in" json:"in,omitempty"`
+	Exists  *bool    `yaml:"exists" json:"exists,omitempty"`
+	Matches string   `yaml:"matches" json:"matches,omitempty"`
+}
+
+type compiledField struct {
+	spec     FieldSpec
+	paths    []Path
+	location *time.Location
+}
+
+type compiledCondition struct {
+	spec    Condition
+	path    Path
+	matches *regexp.Regexp
+}
+
+// Mapper applies a compiled Spec
+type Mapper struct {
+	records    *Path
+	fields     map[string]compiledField
+	conditions []compiledCondition
+	now        func() time.Time
+}
+
+// New compiles and validates a spec
+func New(spec Spec) (*Mapper, error) {
+	m := &Mapper{fields: make(map[string]compiledField), now: time.Now}
+
+	if spec.Records != "" {
+		records, err := ParsePath(spec.Records)
+		if err != nil {
+			return nil, err
+		}
+		m.records = &records
+	}
+
+	for name := range spec.Fields {
+		if !isAlertField(name) {
+			return nil, fmt.Errorf("mapping: unknown field %q (known: %s)", name, strings.Join(alertFields, ", "))
+		}
+	}
+
+	for _, name := range alertFields {
+		fieldSpec := spec.Fields[name]
+		field := compiledField{spec: fieldSpec}
+
+		rawPaths := fieldSpec.Paths
+		if fieldSpec.Path != "" {
+			rawPaths = append([]string{fieldSpec.Path}, rawPaths...)
+		}
+		if len(rawPaths) == 0 && fieldSpec.Value == "" {
+			// Unmapped fields keep their own name
+			rawPaths = []string{name}
+		}
+		for _, raw := range rawPaths {
+			path, err := ParsePath(raw)
+			if err != nil {
+				return nil, err
+			}
+			field.paths = append(field.paths, path)
+		}
+
+		if name == FieldCreatedAt {
+			field.location = time.UTC
+			if fieldSpec.Timezone != "" {
+				location, err := time.LoadLocation(fieldSpec.Timezone)
+				if err != nil {
+					return nil, fmt.Errorf("mapping: created_at: invalid timezone %q", fieldSpec.Timezone)
+				}
+				field.location = location
+			}
+		} else if fieldSpec.Format != "" || fieldSpec.Timezone != "" {
+			return nil, fmt.Errorf("mapping: %s: format and timezone only apply to created_at", name)
+		}
+
+		m.fields[name] = field
+	}
+
+	for i, cond := range spec.Drop {
+		path, err := ParsePath(cond.Path)
+		if err != nil || cond.Path == "" {
+			return nil, fmt.Errorf("mapping: drop[%d]: invalid path %q", i, cond.Path)
+		}
+		if cond.Equals == nil && cond.In == nil && cond.Exists == nil && cond.Matches == "" {
+			return nil, fmt.Errorf("mapping: drop[%d]: needs equals, in, exists or matches", i)
+		}
+		compiled := compiledCondition{spec: cond, path: path}
+		if cond.Matches != "" {
+			re, err := regexp.Compile(cond.Matches)
+			if err != nil {
+				return nil, fmt.Errorf("mapping: drop[%d]: invalid pattern: %w", i, err)
+			}
+			compiled.matches = re
+		}
+		m.conditions = append(m.conditions, compiled)
+	}
+
+	return m, nil
+}
+
+// Parse decodes a spec from YAML or JSON (JSON is valid YAML) and compiles it
+func Parse(data []byte) (*Mapper, error) {
+	var spec Spec
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("mapping: invalid spec: %w", err)
+	}
+	return New(spec)
+}
+
+// Load reads a spec file (.yaml, .yml or .json)
+func Load(path string) (*Mapper, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil, fmt.Errorf("mapping: %s: expected a .yaml, .yml or .json file", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("mapping: %w", err)
+	}
+	return Parse(data)
+}
+
+func isAlertField(name string) bool {
+	for _, field := range alertFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Record outcomes
+const (
+	StatusMapped  = "mapped"
+	StatusDropped = "dropped"
+	StatusFailed  = "failed"
+)
+
+// RecordResult is the outcome of mapping one upstream record
+type RecordResult struct {
+	Index  int                     `json:"index"`
+	Status string                  `json:"status"`
+	Alert  *external.ExternalAlert `json:"alert,omitempty"`
+	Reason string                  `json:"reason,omitempty"`
+}
+
+// Apply maps every record of a payload and reports each outcome
+func (m *Mapper) Apply(payload []byte) ([]RecordResult, error) {
+	records, err := m.extractRecords(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RecordResult, len(records))
+	for i, record := range records {
+		results[i] = m.applyRecord(i, record)
+	}
+	return results, nil
+}
+
+// MapAlerts implements external.AlertMapper. Dropped records are skipped and
+// records that fail to map are logged and skipped.
+func (m *Mapper) MapAlerts(payload []byte) ([]external.ExternalAlert, error) {
+	results, err := m.Apply(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]external.ExternalAlert, 0, len(results))
+	for _, result := range results {
+		switch result.Status {
+		case StatusMapped:
+			alerts = append(alerts, *result.Alert)
+		case StatusFailed:
+			log.Printf("[MAPPING] Skipping record %d: %s", result.Index, result.Reason)
+		}
+	}
+	return alerts, nil
+}
+
+func (m *Mapper) extractRecords(payload []byte) ([]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("mapping: invalid JSON payload: %w", err)
+	}
+
+	if m.records != nil {
+		value, ok := m.records.Lookup(doc)
+		if !ok {
+			return nil, fmt.Errorf("mapping: records path %s not found in payload", m.records)
+		}
+		doc = value
+	} else if object, ok := doc.(map[string]interface{}); ok {
+		if alerts, ok := object["alerts"].([]interface{}); ok {
+			doc = alerts
+		}
+	}
+
+	switch value := doc.(type) {
+	case []interface{}:
+		return value, nil
+	case map[string]interface{}:
+		return []interface{}{value}, nil
+	default:
+		return nil, fmt.Errorf("mapping: records must be objects")
+	}
+}
+
+func (m *Mapper) applyRecord(index int, record interface{}) RecordResult {
+	result := RecordResult{Index: index}
+
+	if _, ok := record.(map[string]interface{}); !ok {
+		result.Status = StatusFailed
+		result.Reason = "record is not an object"
+		return result
+	}
+
+	for i, cond := range m.conditions {
+		if cond.match(record) {
+			result.Status = StatusDropped
+			result.Reason = fmt.Sprintf("drop[%d] matched %s", i, cond.path)
+			return result
+		}
+	}
+
+	values := make(map[string]string, len(alertFields))
+	for _, name := range alertFields {
+		value, err := m.fields[name].resolve(record)
+		if err != nil {
+			result.Status = StatusFailed
+			result.Reason = fmt.Sprintf("%s: %v", name, err)
+			return result
+		}
+		values[name] = value
+	}
+
+	createdAt, err := m.fields[FieldCreatedAt].parseTime(values[FieldCreatedAt], m.now)
+	if err != nil {
+		result.Status = StatusFailed
+		result.Reason = fmt.Sprintf("%s: %v", FieldCreatedAt, err)
+		return result
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		result.Status = StatusFailed
+		result.Reason = err.Error()
+		return result
+	}
+
+	result.Status = StatusMapped
+	result.Alert = &external.ExternalAlert{
+		Source:      values[FieldSource],
+		Severity:    values[FieldSeverity],
+		Description: values[FieldDescription],
+		CreatedAt:   createdAt,
+		Raw:         raw,
+	}
+	return result
+}
+
+// resolve produces the field's string value from a record
+func (f compiledField) resolve(record interface{}) (string, error) {
+	if f.spec.Value != "" {
+		return f.spec.Value, nil
+	}
+
+	for _, path := range f.paths {
+		raw, ok := path.Lookup(record)
+		if !ok || raw == nil {
+			continue
+		}
+		value, ok := scalarString(raw)
+		if !ok {
+			return "", fmt.Errorf("value at %s is not a scalar", path)
+		}
+		if value == "" {
+			continue
+		}
+
+		if f.spec.Lookup == nil {
+			return value, nil
+		}
+		if mapped, ok := f.spec.Lookup[value]; ok {
+			return mapped, nil
+		}
+		if f.spec.Default != "" {
+			return f.spec.Default, nil
+		}
+		return "", fmt.Errorf("no lookup entry for %q", value)
+	}
+
+	if f.spec.Default != "" {
+		return f.spec.Default, nil
+	}
+	return "", fmt.Errorf("no value found")
+}
+
+// parseTime converts the created_at value using the field's format
+func (f compiledField) parseTime(value string, now func() time.Time) (time.Time, error) {
+	if value == nowValue {
+		return now().UTC(), nil
+	}
+
+	switch f.spec.Format {
+	case "", FormatRFC3339:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid RFC 3339 timestamp %q", value)
+		}
+		return t.UTC(), nil
+	case FormatRFC1123:
+		t, err := time.Parse(time.RFC1123Z, value)
+		if err != nil {
+			t, err = time.Parse(time.RFC1123, value)
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid RFC 1123 timestamp %q", value)
+		}
+		return t.UTC(), nil
+	case FormatUnix, FormatUnixMs:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch timestamp %q", value)
+		}
+		if f.spec.Format == FormatUnixMs {
+			return time.UnixMilli(int64(n)).UTC(), nil
+		}
+		sec, frac := int64(n), n-float64(int64(n))
+		return time.Unix(sec, int64(frac*1e9)).UTC(), nil
+	default:
+		t, err := time.ParseInLocation(f.spec.Format, value, f.location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("timestamp %q does not match layout %q", value, f.spec.Format)
+		}
+		return t.UTC(), nil
+	}
+}
+
+func (c compiledCondition) match(record interface{}) bool {
+	raw, found := c.path.Lookup(record)
+	value, scalar := "", false
+	if found && raw != nil {
+		value, scalar = scalarString(raw)
+	}
+
+	if c.spec.Exists != nil && *c.spec.Exists != (found && raw != nil) {
+		return false
+	}
+	if c.spec.Equals != nil && (!scalar || value != *c.spec.Equals) {
+		return false
+	}
+	if c.spec.In != nil && (!scalar || !containsString(c.spec.In, value)) {
+		return false
+	}
+	if c.matches != nil && (!scalar || !c.matches.MatchString(value)) {
+		return false
+	}
+	return true
+}
+
+// scalarString renders strings, numbers and booleans as strings
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package mapping
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	doc := map[string]interface{}{
+		"event": map[string]interface{}{
+			"items": []interface{}{map[string]interface{}{"ts": "a"}},
+		},
+		"@timestamp": "b",
+	}
+
+	for raw, want := range map[string]interface{}{
+		"$.event.items[0].ts": "a",
+		"event.items[0].ts":   "a",
+		"$['@timestamp']":     "b",
+	} {
+		path, err := ParsePath(raw)
+		require.NoError(t, err, raw)
+		value, ok := path.Lookup(doc)
+		assert.True(t, ok, raw)
+		assert.Equal(t, want, value, raw)
+	}
+
+	missing, err := ParsePath("$.event.items[3].ts")
+	require.NoError(t, err)
+	_, ok := missing.Lookup(doc)
+	assert.False(t, ok)
+
+	for _, raw := range []string{"$.a..b", "$.a[", "$.a[x]", "$['a"} {
+		_, err := ParsePath(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestMapper_Apply(t *testing.T) {
+	mapper, err := Parse([]byte(`
+records: $.data.events
+fields:
+  source:
+    path: $.vendor
+    lookup: {acme-ids: ids}
+    default: siem-1
+  severity:
+    path: $.priority
+    lookup: {P1: critical, P2: high}
+  description:
+    paths: [$.title, $.summary]
+  created_at:
+    path: $.ts
+    format: unix
+drop:
+  - path: $.state
+    equals: test
+`))
+	require.NoError(t, err)
+
+	results, err := mapper.Apply([]byte(`{"data": {"events": [
+		{"vendor": "acme-ids", "priority": "P1", "title": "Port scan", "ts": 1718000000},
+		{"vendor": "other", "priority": "P2", "summary": "Fallback path", "ts": 1718000000.5},
+		{"vendor": "acme-ids", "priority": "P1", "title": "x", "ts": 1, "state": "test"},
+		{"vendor": "acme-ids", "priority": "P9", "title": "Unknown priority", "ts": 1},
+		"not an object"
+	]}}`))
+
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	assert.Equal(t, StatusMapped, results[0].Status)
+	assert.Equal(t, "ids", results[0].Alert.Source)
+	assert.Equal(t, "critical", results[0].Alert.Severity)
+	assert.Equal(t, "Port scan", results[0].Alert.Description)
+	assert.Equal(t, time.Unix(1718000000, 0).UTC(), results[0].Alert.CreatedAt)
+	assert.JSONEq(t, `{"vendor": "acme-ids", "priority": "P1", "title": "Port scan", "ts": 1718000000}`, string(results[0].Alert.Raw))
+
+	assert.Equal(t, StatusMapped, results[1].Status)
+	assert.Equal(t, "siem-1", results[1].Alert.Source, "lookup miss uses default")
+	assert.Equal(t, "Fallback path", results[1].Alert.Description)
+	assert.Equal(t, time.Unix(1718000000, 5e8).UTC(), results[1].Alert.CreatedAt)
+
+	assert.Equal(t, StatusDropped, results[2].Status)
+
+	assert.Equal(t, StatusFailed, results[3].Status)
+	assert.Contains(t, results[3].Reason, `no lookup entry for "P9"`)
+
+	assert.Equal(t, StatusFailed, results[4].Status)
+}
+
+func TestMapper_TimestampFormats(t *testing.T) {
+	want := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		field FieldSpec
+		value string
+	}{
+		"rfc3339 default": {FieldSpec{}, `"2024-06-01T14:00:00+02:00"`},
+		"rfc1123":         {FieldSpec{Format: FormatRFC1123}, `"Sat, 01 Jun 2024 12:00:00 GMT"`},
+		"unix":            {FieldSpec{Format: FormatUnix}, `1717243200`},
+		"unix string":     {FieldSpec{Format: FormatUnix}, `"1717243200"`},
+		"unix_ms":         {FieldSpec{Format: FormatUnixMs}, `1717243200000`},
+		"layout":          {FieldSpec{Format: "2006-01-02 15:04:05"}, `"2024-06-01 12:00:00"`},
+		"layout with tz":  {FieldSpec{Format: "2006-01-02 15:04:05", Timezone: "Europe/Berlin"}, `"2024-06-01 14:00:00"`},
+	} {
+		field := tc.field
+		field.Path = "$.ts"
+		mapper, err := New(Spec{Fields: map[string]FieldSpec{
+			FieldSource:      {Value: "firewall"},
+			FieldSeverity:    {Value: "low"},
+			FieldDescription: {Value: "d"},
+			FieldCreatedAt:   field,
+		}})
+		require.NoError(t, err, name)
+
+		alerts, err := mapper.MapAlerts([]byte(`{"ts": ` + tc.value + `}`))
+
+		require.NoError(t, err, name)
+		require.Len(t, alerts, 1, name)
+		assert.Equal(t, want, alerts[0].CreatedAt, name)
+	}
+}
+
+func TestMapper_Defaults(t *testing.T) {
+	mapper, err := New(Spec{Fields: map[string]FieldSpec{
+		FieldSource:    {Default: "firewall"},
+		FieldSeverity:  {Path: "$.level", Default: "medium"},
+		FieldCreatedAt: {Default: "now"},
+	}})
+	require.NoError(t, err)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	mapper.now = func() time.Time { return now }
+
+	results, err := mapper.Apply([]byte(`[{"description": "unmapped fields keep their name"}, {}]`))
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, StatusMapped, results[0].Status)
+	assert.Equal(t, "firewall", results[0].Alert.Source)
+	assert.Equal(t, "medium", results[0].Alert.Severity)
+	assert.Equal(t, "unmapped fields keep their name", results[0].Alert.Description)
+	assert.Equal(t, now, results[0].Alert.CreatedAt)
+
+	assert.Equal(t, StatusFailed, results[1].Status)
+	assert.Equal(t, "description: no value found", results[1].Reason)
+}
+
+func TestMapper_DropConditions(t *testing.T) {
+	mapper, err := Parse([]byte(`{
+		"fields": {"source": {"value": "firewall"}, "severity": {"value": "low"},
+		           "description": {"value": "d"}, "created_at": {"value": "now"}},
+		"drop": [
+			{"path": "$.env", "in": ["dev", "staging"]},
+			{"path": "$.muted", "exists": true},
+			{"path": "$.rule", "matches": "^noise-"},
+			{"path": "$.count", "equals": "0"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	results, err := mapper.Apply([]byte(`{"alerts": [
+		{"env": "dev"}, {"muted": false}, {"rule": "noise-1"}, {"count": 0},
+		{"env": "prod", "rule": "real", "count": 2}
+	]}`))
+
+	require.NoError(t, err)
+	statuses := make([]string, len(results))
+	for i, result := range results {
+		statuses[i] = result.Status
+	}
+	assert.Equal(t, []string{StatusDropped, StatusDropped, StatusDropped, StatusDropped, StatusMapped}, statuses)
+}
+
+func TestParse_RejectsInvalidSpecs(t *testing.T) {
+	for name, spec := range map[string]string{
+		"unknown key":        `fields: {source: {paht: $.x}}`,
+		"unknown field":      `fields: {title: {path: $.x}}`,
+		"bad path":           `fields: {source: {path: "$.a[" }}`,
+		"format on non-time": `fields: {severity: {path: $.x, format: unix}}`,
+		"bad timezone":       `fields: {created_at: {path: $.x, timezone: Mars/Base}}`,
+		"empty drop":         `drop: [{path: $.x}]`,
+		"bad drop pattern":   `drop: [{path: $.x, matches: "("}]`,
+		"not a mapping":      `[1, 2]`,
+		"bad records path":   `records: "$.a..b"`,
+	} {
+		_, err := Parse([]byte(spec))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMapper_InvalidPayloads(t *testing.T) {
+	mapper, err := Parse([]byte(`records: $.data`))
+	require.NoError(t, err)
+
+	_, err = mapper.Apply([]byte(`not json`))
+	assert.ErrorContains(t, err, "invalid JSON payload")
+
+	_, err = mapper.Apply([]byte(`{"other": []}`))
+	assert.ErrorContains(t, err, "records path $.data not found")
+
+	_, err = mapper.Apply([]byte(`{"data": 5}`))
+	assert.ErrorContains(t, err, "records must be objects")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "spec.yml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("fields:\n  severity:\n    path: $.sev\n"), 0o600))
+
+	_, err := Load(yamlPath)
+	assert.NoError(t, err)
+
+	_, err = Load(filepath.Join(dir, "spec.txt"))
+	assert.ErrorContains(t, err, "expected a .yaml, .yml or .json file")
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package mapping
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathStep is one object key or array index of a compiled JSON path
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Path is a compiled JSON path such as $.event.severity, $.items[0].ts or
+// $['@timestamp']. The leading "$." is optional.
+type Path struct {
+	raw   string
+	steps []pathStep
+}
+
+// ParsePath compiles a JSON path
+func ParsePath(raw string) (Path, error) {
+	s := strings.TrimSpace(raw)
+	s = strings.TrimPrefix(s, "$")
+	path := Path{raw: raw}
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			if s == "" || s[0] == '.' || s[0] == '[' {
+				return Path{}, fmt.Errorf("mapping: path %q: empty key", raw)
+			}
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return Path{}, fmt.Errorf("mapping: path %q: unterminated [", raw)
+			}
+			inner := s[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path.steps = append(path.steps, pathStep{key: inner[1 : len(inner)-1]})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return Path{}, fmt.Errorf("mapping: path %q: invalid index %q", raw, inner)
+				}
+				path.steps = append(path.steps, pathStep{index: index, isIndex: true})
+			}
+			s = s[end+1:]
+			continue
+		}
+
+		end := strings.IndexAny(s, ".[")
+		if end < 0 {
+			end = len(s)
+		}
+		if end > 0 {
+			path.steps = append(path.steps, pathStep{key: s[:end]})
+		}
+		s = s[end:]
+	}
+
+	return path, nil
+}
+
+// String returns the path as written
+func (p Path) String() string {
+	return p.raw
+}
+
+// Lookup returns the value at the path, or false when any step is missing
+func (p Path) Lookup(doc interface{}) (interface{}, bool) {
+	current := doc
+	for _, step := range p.steps {
+		if step.isIndex {
+			items, ok := current.([]interface{})
+			if !ok || step.index >= len(items) {
+				return nil, false
+			}
+			current = items[step.index]
+			continue
+		}
+
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[step.key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Live event fan-out
+var (
+	EventsPublished = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alert_events_published_total",
+		Help: "Alert events published to live subscribers.",
+	})
+
+	EventSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "alert_event_subscribers",
+		Help: "Active live event subscribers (SSE streams and WebSocket connections).",
+	})
+
+	EventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alert_events_dropped_total",
+		Help: "Events dropped because a subscriber queue was full.",
+	})
+
+	SlowSubscribersDisconnected = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alert_event_slow_subscribers_disconnected_total",
+		Help: "Subscribers disconnected because their queue was full.",
+	})
+)
+
+// WebSocket connections
+var (
+	WebSocketConnectionsActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "websocket_connections_active",
+		Help: "Open WebSocket connections.",
+	})
+
+	WebSocketConnectionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "websocket_connections_total",
+		Help: "WebSocket connections accepted.",
+	})
+
+	WebSocketMessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocket_messages_received_total",
+		Help: "Messages received from WebSocket clients by type.",
+	}, []string{"type"})
+
+	WebSocketMessagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocket_messages_sent_total",
+		Help: "Messages sent to WebSocket clients by type.",
+	}, []string{"type"})
+)
+
+// Syslog receiver
+var (
+	SyslogMessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "syslog_messages_received_total",
+		Help: "Syslog messages received by transport.",
+	}, []string{"transport"})
+
+	SyslogMessagesInvalid = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "syslog_messages_invalid_total",
+		Help: "Syslog messages that could not be parsed or mapped, by transport.",
+	}, []string{"transport"})
+
+	SyslogMessagesDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "syslog_messages_dropped_total",
+		Help: "Syslog messages dropped because the ingestion queue was full.",
+	})
+)
+
+// Spool directory ingestion
+var (
+	SpoolFilesProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "spool_files_processed_total",
+		Help: "Spool files finished, by result (done or failed).",
+	}, []string{"result"})
+
+	SpoolRecords = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "spool_records_total",
+		Help: "Spool file records settled, by result (accepted or rejected).",
+	}, []string{"result"})
+)
+
+// Upstream circuit breakers
+var (
+	UpstreamCircuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "upstream_circuit_state",
+		Help: "Circuit breaker state per upstream connector (0 closed, 1 half-open, 2 open).",
+	}, []string{"connector"})
+
+	UpstreamCircuitTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_circuit_transitions_total",
+		Help: "Circuit breaker state changes per upstream connector, by new state.",
+	}, []string{"connector", "state"})
+
+	UpstreamSyncsSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_syncs_skipped_total",
+		Help: "Syncs skipped because the upstream connector's circuit was open.",
+	}, []string{"connector"})
+)
+
+// Paging queue
+var (
+	PagerQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pager_queue_depth",
+		Help: "Paging tasks waiting to be sent.",
+	})
+
+	PagerTasksDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pager_tasks_dropped_total",
+		Help: "Paging tasks dropped because the queue was full.",
+	})
+)
+
+// Dead-letter queue
+var (
+	DeadLettersRecorded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dead_letters_recorded_total",
+		Help: "Synced alerts dead-lettered, by the stage that failed.",
+	}, []string{"stage"})
+
+	DeadLetterRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dead_letter_retries_total",
+		Help: "Dead-letter retries, by result (stored or failed).",
+	}, []string{"result"})
+)
+
+// Incremental sync overlap
+var (
+	SyncLateArrivals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sync_late_arrivals_total",
+		Help: "Synced alerts stored with a created_at at or before the connector's watermark, found by the look-back overlap.",
+	}, []string{"connector"})
+
+	SyncDuplicatesSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sync_duplicates_skipped_total",
+		Help: "Synced alerts skipped because an alert with the same fingerprint was already stored.",
+	}, []string{"connector"})
+)
+
+// Re-enrichment
+var (
+	AlertsReenriched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "alerts_reenriched_total",
+		Help: "Stored alerts handled by re-enrichment jobs, by result (updated, current or failed).",
+	}, []string{"result"})
+)
+
+// Partition maintenance and retention
+var (
+	AlertPartitionsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alert_partitions_created_total",
+		Help: "Partitions of the alerts table created ahead of time.",
+	})
+
+	AlertPartitionsRemoved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "alert_partitions_removed_total",
+		Help: "Partitions of the alerts table past retention, by action (drop or detach).",
+	}, []string{"action"})
+
+	AlertsExpired = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "alerts_expired_total",
+		Help: "Alerts deleted for being past their severity's retention, not counting dropped or detached partitions.",
+	}, []string{"severity"})
+)
+
+// Cold archival
+var (
+	AlertsArchived = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerts_archived_total",
+		Help: "Alerts written to cold-storage archives.",
+	})
+
+	AlertsRestored = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "alerts_restored_total",
+		Help: "Alerts restored from cold-storage archives, not counting ones already stored.",
+	})
+)
+
+// Exports
+var (
+	AlertsExported = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "alerts_exported_total",
+		Help: "Alerts written to exports, by format.",
+	}, []string{"format"})
+)
+
+// Imports
+var (
+	AlertsImported = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "alerts_imported_total",
+		Help: "Rows of import files, by result (inserted, duplicate or rejected).",
+	}, []string{"result"})
+)
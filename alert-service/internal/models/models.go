@@ -1,14 +1,320 @@
 package models
 
-import "time"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Alert lifecycle statuses
+const (
+	StatusOpen         = "open"
+	StatusAcknowledged = "acknowledged"
+	StatusResolved     = "resolved"
+)
 
 type Alert struct {
-	ID             string    `json:"id"`
-	Source         string    `json:"source"`
-	Severity       string    `json:"severity"`
-	Description    string    `json:"description"`
-	WholeEvent     []byte    `json:"whole_event"`
-	EnrichmentType *string   `json:"enrichment_type"`
-	IPAddress      *string   `json:"ip_address"`
-	CreatedAt      time.Time `json:"created_at"`
+	ID             string  `json:"id"`
+	Source         string  `json:"source"`
+	Severity       string  `json:"severity"`
+	Description    string  `json:"description"`
+	WholeEvent     []byte  `json:"whole_event"`
+	EnrichmentType *string `json:"enrichment_type"`
+	IPAddress      *string `json:"ip_address"`
+	// EnrichmentVersions maps each enrichment field to the pipeline version that produced it
+	EnrichmentVersions map[string]int `json:"enrichment_versions,omitempty"`
+	Fingerprint        string         `json:"fingerprint"`
+	Status             string         `json:"status"`
+	AcknowledgedAt     *time.Time     `json:"acknowledged_at"`
+	ResolvedAt         *time.Time     `json:"resolved_at"`
+	CreatedAt          time.Time      `json:"created_at"`
+}
+
+// Fingerprint returns a stable identifier for an upstream alert, used to
+// correlate the same alert across syncs and external systems
+func Fingerprint(source, severity, description string, createdAt time.Time) string {
+	h := sha256.New()
+	h.Write([]byte(source + "\x00" + severity + "\x00" + description + "\x00" + createdAt.UTC().Format(time.RFC3339Nano)))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewAlertID returns a random (version 4) UUID, valid as an alert ID in
+// every storage backend
+func NewAlertID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Escalation statuses
+const (
+	EscalationActive    = "active"
+	EscalationStopped   = "stopped"
+	EscalationExhausted = "exhausted"
+)
+
+// Escalation tracks timed re-notification of a paged alert until it is acknowledged
+type Escalation struct {
+	ID            string    `json:"id"`
+	AlertID       string    `json:"alert_id"`
+	Tier          int       `json:"tier"`
+	Notifications int       `json:"notifications"`
+	Status        string    `json:"status"`
+	NextNotifyAt  time.Time `json:"next_notify_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Dead-letter statuses
+const (
+	DeadLetterPending   = "pending"
+	DeadLetterResolved  = "resolved"
+	DeadLetterExhausted = "exhausted"
+)
+
+// Stages at which an upstream alert can fail and be dead-lettered
+const (
+	StageEnrichment = "enrichment"
+	StageStorage    = "storage"
+)
+
+// DeadLetter is an upstream alert that could not be enriched or stored,
+// kept with its payload so it can be retried
+type DeadLetter struct {
+	ID            string          `json:"id"`
+	Connector     string          `json:"connector"`
+	Stage         string          `json:"stage"`
+	Payload       json.RawMessage `json:"payload"`
+	Error         string          `json:"error"`
+	Attempts      int             `json:"attempts"`
+	Status        string          `json:"status"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	// AlertID is the stored alert once a retry succeeds
+	AlertID   *string   `json:"alert_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DeadLetterFilter narrows dead-letter listings and bulk retries. Empty fields match everything.
+type DeadLetterFilter struct {
+	Statuses  []string
+	Connector string
+	Stage     string
+	// Limit caps listings; the storage default applies when zero
+	Limit int
+}
+
+// Background job statuses, shared by backfill and re-enrichment jobs
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobPaused    = "paused"
+	JobCancelled = "cancelled"
+	JobFailed    = "failed"
+	JobCompleted = "completed"
+)
+
+// BackfillJob re-fetches the alerts created in (From, To] from one connector,
+// ChunkSize at a time, without touching the connector's watermark
+type BackfillJob struct {
+	ID        string    `json:"id"`
+	Connector string    `json:"connector"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	// Sources keeps only alerts from these sources; empty keeps every alert
+	Sources   []string      `json:"sources,omitempty"`
+	ChunkSize time.Duration `json:"-"`
+	Status    string        `json:"status"`
+	// Position is the end of the last completed chunk; Cursor continues the
+	// chunk after it when a run stopped mid-chunk
+	Position    time.Time  `json:"position"`
+	Cursor      string     `json:"-"`
+	Fetched     int        `json:"fetched"`
+	Stored      int        `json:"stored"`
+	Duplicates  int        `json:"duplicates"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Progress is the fraction of the range walked so far, from 0 to 1
+func (j BackfillJob) Progress() float64 {
+	total := j.To.Sub(j.From)
+	if total <= 0 || !j.Position.Before(j.To) {
+		return 1
+	}
+	return float64(j.Position.Sub(j.From)) / float64(total)
+}
+
+// ReenrichmentJob runs the stored alerts matching Filter through the current
+// enrichment pipeline, oldest first
+type ReenrichmentJob struct {
+	ID     string      `json:"id"`
+	Filter AlertFilter `json:"filter"`
+	// Fields limits the job to these enrichment fields; empty runs every enricher
+	Fields []string `json:"fields,omitempty"`
+	// Force re-enriches fields that are already current
+	Force bool `json:"force"`
+	// Version is the pipeline version the job applies
+	Version int    `json:"version"`
+	Status  string `json:"status"`
+	// Total is the number of matching alerts when the job was created
+	Total int `json:"total"`
+	// Position and AfterID are the created_at and ID of the last alert processed
+	Position    time.Time  `json:"position"`
+	AfterID     string     `json:"-"`
+	Scanned     int        `json:"scanned"`
+	Updated     int        `json:"updated"`
+	Skipped     int        `json:"skipped"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Progress is the fraction of matching alerts processed so far, from 0 to 1
+func (j ReenrichmentJob) Progress() float64 {
+	if j.Status == JobCompleted || j.Total <= 0 {
+		return 1
+	}
+	return min(float64(j.Scanned)/float64(j.Total), 1)
+}
+
+// ExportJob writes the alerts matching Filter to a file, oldest first, that
+// can be downloaded until ExpiresAt, set when the job finishes
+type ExportJob struct {
+	ID      string      `json:"id"`
+	Format  string      `json:"format"`
+	Columns []string    `json:"columns"`
+	Filter  AlertFilter `json:"filter"`
+	Status  string      `json:"status"`
+	// Total is the number of matching alerts when the job was created
+	Total int `json:"total"`
+	Rows  int `json:"rows"`
+	// Size is the length of the finished file in bytes
+	Size        int64      `json:"size"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at,omitzero"`
+}
+
+// Progress is the fraction of matching alerts written so far, from 0 to 1
+func (j ExportJob) Progress() float64 {
+	if j.Status == JobCompleted || j.Total <= 0 {
+		return 1
+	}
+	return min(float64(j.Rows)/float64(j.Total), 1)
+}
+
+// AlertPartition is one range partition of the alerts table, holding the
+// alerts created in [From, To)
+type AlertPartition struct {
+	Name string    `json:"name"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// AlertFilter narrows alert listings and streams. Empty fields match everything.
+type AlertFilter struct {
+	Sources    []string `json:"sources,omitempty"`
+	Severities []string `json:"severities,omitempty"`
+	Statuses   []string `json:"statuses,omitempty"`
+	// Days limits results to alerts created in the last N days when > 0
+	Days int `json:"days,omitempty"`
+	// From and To limit results to alerts created in [From, To) when set
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+}
+
+// Matches reports whether an alert satisfies the filter
+func (f AlertFilter) Matches(alert Alert) bool {
+	if len(f.Sources) > 0 && !contains(f.Sources, alert.Source) {
+		return false
+	}
+	if len(f.Severities) > 0 && !contains(f.Severities, alert.Severity) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !contains(f.Statuses, alert.Status) {
+		return false
+	}
+	if f.Days > 0 && alert.CreatedAt.Before(time.Now().AddDate(0, 0, -f.Days)) {
+		return false
+	}
+	if !f.From.IsZero() && alert.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !alert.CreatedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Alert event types published as alerts are stored and change status
+const (
+	EventAlertCreated      = "alert.created"
+	EventAlertAcknowledged = "alert.acknowledged"
+	EventAlertResolved     = "alert.resolved"
+)
+
+// EventStreamReset tells a resuming stream client that events after its last
+// event ID are no longer available and it should reload the alerts it shows
+const EventStreamReset = "stream.reset"
+
+// AlertEvent is a single entry in the live alert event stream.
+// IDs increase monotonically, and across restarts of the service.
+type AlertEvent struct {
+	ID    uint64    `json:"id"`
+	Type  string    `json:"type"`
+	Alert Alert     `json:"alert"`
+	Time  time.Time `json:"time"`
+}
+
+// Connector health statuses
+const (
+	ConnectorUnknown = "unknown"
+	ConnectorHealthy = "healthy"
+	ConnectorFailing = "failing"
+)
+
+// ConnectorState is the persisted sync progress and outcome of one upstream connector.
+// Watermark is the newest created_at stored from the connector. Cursor is the
+// upstream's next page while a paged sync is in progress, so an interrupted
+// run resumes mid-stream; it is empty between runs.
+type ConnectorState struct {
+	Name                string     `json:"name"`
+	Watermark           *time.Time `json:"watermark,omitempty"`
+	Cursor              string     `json:"cursor,omitempty"`
+	LastAttemptAt       *time.Time `json:"last_attempt_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// Status summarises the connector's health from its last attempts
+func (s ConnectorState) Status() string {
+	switch {
+	case s.ConsecutiveFailures > 0:
+		return ConnectorFailing
+	case s.LastSuccessAt != nil:
+		return ConnectorHealthy
+	default:
+		return ConnectorUnknown
+	}
 }
@@ -0,0 +1,137 @@
+package normalize
+
+import (
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+// ECSVersion is the Elastic Common Schema version rendered
+const ECSVersion = "8.11.0"
+
+// ecsDataset names the dataset alerts are published under
+const ecsDataset = "alert_service.alerts"
+
+// ecsSeverities maps alert severities to event.severity, using the same
+// scores as Elastic detection rules
+var ecsSeverities = map[string]int{
+	"low":      21,
+	"medium":   47,
+	"high":     73,
+	"critical": 99,
+}
+
+// ecsCategories maps alert sources to event.category values
+var ecsCategories = map[string][]string{
+	"siem-1":                {"threat"},
+	"siem-2":                {"threat"},
+	"firewall":              {"network"},
+	"ids":                   {"intrusion_detection", "network"},
+	"antivirus":             {"malware"},
+	"endpoint":              {"host"},
+	"cloud-security":        {"configuration"},
+	"email-gateway":         {"email"},
+	"network-monitor":       {"network"},
+	"vulnerability-scanner": {"vulnerability"},
+}
+
+// ecsObserverTypes maps alert sources to observer.type where ECS has a match
+var ecsObserverTypes = map[string]string{
+	"firewall": "firewall",
+	"ids":      "ids",
+}
+
+// ECSDocument is an alert in Elastic Common Schema
+type ECSDocument struct {
+	Timestamp time.Time         `json:"@timestamp"`
+	ECS       ECSInfo           `json:"ecs"`
+	Message   string            `json:"message"`
+	Event     ECSEvent          `json:"event"`
+	Log       ECSLog            `json:"log"`
+	Observer  ECSObserver       `json:"observer"`
+	Source    *ECSSource        `json:"source,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// ECSInfo is the ecs field set
+type ECSInfo struct {
+	Version string `json:"version"`
+}
+
+// ECSEvent is the event field set
+type ECSEvent struct {
+	ID       string     `json:"id"`
+	Kind     string     `json:"kind"`
+	Category []string   `json:"category"`
+	Type     []string   `json:"type"`
+	Severity int        `json:"severity"`
+	Created  time.Time  `json:"created"`
+	End      *time.Time `json:"end,omitempty"`
+	Dataset  string     `json:"dataset"`
+	Provider string     `json:"provider"`
+	Hash     string     `json:"hash,omitempty"`
+	Original string     `json:"original,omitempty"`
+}
+
+// ECSLog is the log field set
+type ECSLog struct {
+	Level string `json:"level"`
+}
+
+// ECSObserver is the observer field set, the upstream that raised the alert
+type ECSObserver struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// ECSSource is the source field set
+type ECSSource struct {
+	IP string `json:"ip"`
+}
+
+// ToECS renders an alert as an ECS document
+func ToECS(alert models.Alert) ECSDocument {
+	category, ok := ecsCategories[alert.Source]
+	if !ok {
+		category = []string{"threat"}
+	}
+
+	eventType := []string{"info"}
+	if alert.ResolvedAt != nil {
+		eventType = append(eventType, "end")
+	}
+
+	doc := ECSDocument{
+		Timestamp: alert.CreatedAt.UTC(),
+		ECS:       ECSInfo{Version: ECSVersion},
+		Message:   alert.Description,
+		Event: ECSEvent{
+			ID:       alert.ID,
+			Kind:     "alert",
+			Category: category,
+			Type:     eventType,
+			Severity: ecsSeverities[alert.Severity],
+			Created:  alert.CreatedAt.UTC(),
+			Dataset:  ecsDataset,
+			Provider: alert.Source,
+			Hash:     alert.Fingerprint,
+			Original: rawEvent(alert),
+		},
+		Log:      ECSLog{Level: alert.Severity},
+		Observer: ECSObserver{Name: alert.Source, Type: ecsObserverTypes[alert.Source]},
+		Labels:   map[string]string{"status": alert.Status},
+	}
+
+	if alert.ResolvedAt != nil {
+		end := alert.ResolvedAt.UTC()
+		doc.Event.End = &end
+	}
+	if alert.IPAddress != nil && *alert.IPAddress != "" {
+		doc.Source = &ECSSource{IP: *alert.IPAddress}
+	}
+	if alert.EnrichmentType != nil && *alert.EnrichmentType != "" {
+		doc.Labels["enrichment_type"] = *alert.EnrichmentType
+	}
+
+	return doc
+}
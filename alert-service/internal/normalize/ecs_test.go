@@ -0,0 +1,60 @@
+package normalize
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToECS(t *testing.T) {
+	alert := testAlert()
+
+	doc := ToECS(alert)
+
+	assert.Equal(t, alert.CreatedAt, doc.Timestamp)
+	assert.Equal(t, ECSVersion, doc.ECS.Version)
+	assert.Equal(t, "Port scan detected", doc.Message)
+	assert.Equal(t, "alert", doc.Event.Kind)
+	assert.Equal(t, []string{"intrusion_detection", "network"}, doc.Event.Category)
+	assert.Equal(t, []string{"info"}, doc.Event.Type)
+	assert.Equal(t, 73, doc.Event.Severity)
+	assert.Equal(t, "ids", doc.Event.Provider)
+	assert.Equal(t, "abc123", doc.Event.Hash)
+	assert.Nil(t, doc.Event.End)
+	assert.Equal(t, "high", doc.Log.Level)
+	assert.Equal(t, ECSObserver{Name: "ids", Type: "ids"}, doc.Observer)
+	assert.Equal(t, &ECSSource{IP: "10.0.0.5"}, doc.Source)
+	assert.Equal(t, map[string]string{"status": "open", "enrichment_type": "threat_intel"}, doc.Labels)
+}
+
+func TestToECS_Resolved(t *testing.T) {
+	alert := testAlert()
+	resolvedAt := alert.CreatedAt.Add(time.Hour)
+	alert.Status, alert.ResolvedAt = models.StatusResolved, &resolvedAt
+
+	doc := ToECS(alert)
+
+	assert.Equal(t, []string{"info", "end"}, doc.Event.Type)
+	require.NotNil(t, doc.Event.End)
+	assert.Equal(t, resolvedAt, *doc.Event.End)
+	assert.Equal(t, "resolved", doc.Labels["status"])
+}
+
+func TestToECS_JSONFieldNames(t *testing.T) {
+	alert := testAlert()
+	alert.IPAddress = nil
+
+	data, err := json.Marshal(ToECS(alert))
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "2024-06-01T12:00:00Z", doc["@timestamp"])
+	assert.NotContains(t, doc, "source")
+	assert.Equal(t, map[string]interface{}{"version": "8.11.0"}, doc["ecs"])
+}
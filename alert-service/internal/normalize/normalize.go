@@ -0,0 +1,141 @@
+// Package normalize renders stored alerts in standard schemas for downstream
+// consumers: OCSF Detection Finding and Security Finding, and Elastic Common Schema.
+package normalize
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"censys_alert_system/internal/models"
+)
+
+// Format selects the schema alerts are rendered in
+type Format string
+
+// Supported formats. FormatNative is the service's own alert JSON.
+const (
+	FormatNative              Format = "native"
+	FormatOCSF                Format = "ocsf"
+	FormatOCSFSecurityFinding Format = "ocsf-security-finding"
+	FormatECS                 Format = "ecs"
+)
+
+// Formats lists every supported format
+var Formats = []Format{FormatNative, FormatOCSF, FormatOCSFSecurityFinding, FormatECS}
+
+// ErrNotAcceptable means no supported format satisfies an Accept header
+var ErrNotAcceptable = errors.New("normalize: no acceptable format")
+
+// mediaTypes is the Content-Type served for each format
+var mediaTypes = map[Format]string{
+	FormatNative:              "application/json",
+	FormatOCSF:                "application/vnd.ocsf.detection-finding+json",
+	FormatOCSFSecurityFinding: "application/vnd.ocsf.security-finding+json",
+	FormatECS:                 "application/vnd.elastic.ecs+json",
+}
+
+// acceptTypes maps Accept media types, including short aliases, to formats
+var acceptTypes = map[string]Format{
+	"application/json": FormatNative,
+	"application/*":    FormatNative,
+	"*/*":              FormatNative,
+	"application/vnd.ocsf.detection-finding+json": FormatOCSF,
+	"application/vnd.ocsf.security-finding+json":  FormatOCSFSecurityFinding,
+	"application/ocsf+json":                       FormatOCSF,
+	"application/vnd.elastic.ecs+json":            FormatECS,
+	"application/ecs+json":                        FormatECS,
+}
+
+// ParseFormat reads a ?format= value. "json" is accepted for native.
+func ParseFormat(value string) (Format, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "json" {
+		return FormatNative, nil
+	}
+	for _, format := range Formats {
+		if Format(value) == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("normalize: unknown format %q", value)
+}
+
+// MediaType returns the Content-Type for a format
+func (f Format) MediaType() string {
+	return mediaTypes[f]
+}
+
+// Negotiate picks the format from ?format= when set, otherwise from the
+// Accept header by quality. An empty Accept header selects the native format.
+func Negotiate(formatParam, accept string) (Format, error) {
+	if formatParam != "" {
+		return ParseFormat(formatParam)
+	}
+	if strings.TrimSpace(accept) == "" {
+		return FormatNative, nil
+	}
+
+	type candidate struct {
+		format Format
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := acceptTypes[mediaType]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{format: format, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return "", ErrNotAcceptable
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].format, nil
+}
+
+// Render converts an alert to the document for a format
+func Render(format Format, alert models.Alert) (interface{}, error) {
+	switch format {
+	case FormatNative:
+		return alert, nil
+	case FormatOCSF:
+		return ToDetectionFinding(alert), nil
+	case FormatOCSFSecurityFinding:
+		return ToSecurityFinding(alert), nil
+	case FormatECS:
+		return ToECS(alert), nil
+	default:
+		return nil, fmt.Errorf("normalize: unknown format %q", format)
+	}
+}
+
+// RenderAll converts a batch of alerts
+func RenderAll(format Format, alerts []models.Alert) ([]interface{}, error) {
+	docs := make([]interface{}, 0, len(alerts))
+	for _, alert := range alerts {
+		doc, err := Render(format, alert)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
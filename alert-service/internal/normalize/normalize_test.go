@@ -0,0 +1,126 @@
+package normalize
+
+import (
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stringPtr(s string) *string { return &s }
+
+// testAlert returns a stored alert with every optional field set
+func testAlert() models.Alert {
+	createdAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	return models.Alert{
+		ID:             "3f1c0e9a-5b7d-4c2e-9a61-0d8f2b4e6a10",
+		Source:         "ids",
+		Severity:       "high",
+		Description:    "Port scan detected",
+		WholeEvent:     []byte(`{"source":"ids","severity":"high"}`),
+		EnrichmentType: stringPtr("threat_intel"),
+		IPAddress:      stringPtr("10.0.0.5"),
+		Fingerprint:    "abc123",
+		Status:         models.StatusOpen,
+		CreatedAt:      createdAt,
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		param, accept string
+		want          Format
+	}{
+		"default":             {"", "", FormatNative},
+		"query wins":          {"ecs", "application/vnd.ocsf.detection-finding+json", FormatECS},
+		"query json alias":    {"JSON", "", FormatNative},
+		"browser":             {"", "text/html,application/xhtml+xml,*/*;q=0.8", FormatNative},
+		"ocsf":                {"", "application/vnd.ocsf.detection-finding+json", FormatOCSF},
+		"ocsf alias":          {"", "application/ocsf+json", FormatOCSF},
+		"security finding":    {"", "application/vnd.ocsf.security-finding+json", FormatOCSFSecurityFinding},
+		"quality ordering":    {"", "application/json;q=0.5, application/ecs+json", FormatECS},
+		"zero quality skip":   {"", "application/ecs+json;q=0, application/json", FormatNative},
+		"unsupported skipped": {"", "text/csv, application/vnd.elastic.ecs+json;q=0.1", FormatECS},
+	} {
+		got, err := Negotiate(tc.param, tc.accept)
+		require.NoError(t, err, name)
+		assert.Equal(t, tc.want, got, name)
+	}
+
+	_, err := Negotiate("stix", "")
+	assert.ErrorContains(t, err, `unknown format "stix"`)
+
+	_, err = Negotiate("", "text/csv")
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+}
+
+func TestRender_AllFormatsPassBundledSchemas(t *testing.T) {
+	acknowledgedAt := time.Date(2024, 6, 1, 12, 5, 0, 0, time.UTC)
+	resolvedAt := time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC)
+
+	minimal := testAlert()
+	minimal.WholeEvent, minimal.EnrichmentType, minimal.IPAddress, minimal.Fingerprint = nil, nil, nil, ""
+
+	alerts := map[string]models.Alert{"open": testAlert(), "minimal": minimal}
+	for _, source := range []string{"siem-1", "firewall", "antivirus", "endpoint", "cloud-security", "email-gateway", "network-monitor", "vulnerability-scanner"} {
+		alert := testAlert()
+		alert.Source = source
+		alerts[source] = alert
+	}
+	for _, severity := range []string{"low", "medium", "critical"} {
+		alert := testAlert()
+		alert.Severity = severity
+		alerts[severity] = alert
+	}
+	acknowledged := testAlert()
+	acknowledged.Status, acknowledged.AcknowledgedAt = models.StatusAcknowledged, &acknowledgedAt
+	alerts["acknowledged"] = acknowledged
+	resolved := acknowledged
+	resolved.Status, resolved.ResolvedAt = models.StatusResolved, &resolvedAt
+	alerts["resolved"] = resolved
+	ipv6 := testAlert()
+	ipv6.IPAddress = stringPtr("2001:db8::1")
+	alerts["ipv6"] = ipv6
+
+	for _, format := range Formats {
+		for name, alert := range alerts {
+			doc, err := Render(format, alert)
+			require.NoError(t, err)
+			assert.NoError(t, Validate(format, doc), "%s/%s", format, name)
+		}
+	}
+}
+
+func TestValidate_RejectsInvalidDocuments(t *testing.T) {
+	finding := ToDetectionFinding(testAlert())
+	finding.ClassUID = 2001
+	assert.ErrorContains(t, Validate(FormatOCSF, finding), "does not match schema")
+
+	finding = ToDetectionFinding(testAlert())
+	finding.FindingInfo.UID = ""
+	assert.Error(t, Validate(FormatOCSF, finding))
+
+	doc := ToECS(testAlert())
+	doc.Event.Category = []string{"alerting"}
+	assert.Error(t, Validate(FormatECS, doc))
+
+	doc = ToECS(testAlert())
+	doc.Source = &ECSSource{IP: "not-an-ip"}
+	assert.Error(t, Validate(FormatECS, doc))
+
+	assert.NoError(t, Validate(FormatNative, testAlert()))
+}
+
+func TestRenderAll(t *testing.T) {
+	docs, err := RenderAll(FormatECS, []models.Alert{testAlert(), testAlert()})
+
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.IsType(t, ECSDocument{}, docs[0])
+
+	_, err = RenderAll(Format("stix"), []models.Alert{testAlert()})
+	assert.Error(t, err)
+}
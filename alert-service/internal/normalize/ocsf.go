@@ -0,0 +1,236 @@
+package normalize
+
+import (
+	"encoding/json"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+// OCSFVersion is the OCSF schema version rendered
+const OCSFVersion = "1.1.0"
+
+// logProvider names this service as the OCSF log provider
+const logProvider = "alert-service"
+
+// OCSF Findings category and classes
+const (
+	ocsfCategoryFindings      = 2
+	ocsfClassSecurityFinding  = 2001
+	ocsfClassDetectionFinding = 2004
+)
+
+// OCSF activity_id values shared by both finding classes
+const (
+	ocsfActivityCreate = 1
+	ocsfActivityUpdate = 2
+	ocsfActivityClose  = 3
+)
+
+var ocsfActivityNames = map[int]string{
+	ocsfActivityCreate: "Create",
+	ocsfActivityUpdate: "Update",
+	ocsfActivityClose:  "Close",
+}
+
+// ocsfSeverities maps alert severities to OCSF severity_id
+var ocsfSeverities = map[string]int{
+	"low":      2,
+	"medium":   3,
+	"high":     4,
+	"critical": 5,
+}
+
+var ocsfSeverityNames = map[int]string{
+	0: "Unknown",
+	2: "Low",
+	3: "Medium",
+	4: "High",
+	5: "Critical",
+}
+
+// ocsfStatuses maps alert statuses to OCSF status_id (state_id for Security Finding)
+var ocsfStatuses = map[string]int{
+	models.StatusOpen:         1,
+	models.StatusAcknowledged: 2,
+	models.StatusResolved:     4,
+}
+
+var ocsfStatusNames = map[int]string{
+	0: "Unknown",
+	1: "New",
+	2: "In Progress",
+	4: "Resolved",
+}
+
+// ocsfObservableIP is the OCSF observable type_id for an IP address
+const ocsfObservableIP = 2
+
+// OCSFProduct identifies the product that reported the finding
+type OCSFProduct struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name,omitempty"`
+}
+
+// OCSFMetadata is the OCSF metadata object
+type OCSFMetadata struct {
+	Version        string      `json:"version"`
+	Product        OCSFProduct `json:"product"`
+	LogProvider    string      `json:"log_provider"`
+	CorrelationUID string      `json:"correlation_uid,omitempty"`
+}
+
+// OCSFObservable is an indicator extracted from the finding
+type OCSFObservable struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	TypeID int    `json:"type_id"`
+	Value  string `json:"value"`
+}
+
+// OCSFFindingInfo describes the finding. It is finding_info on a Detection
+// Finding and finding on a Security Finding.
+type OCSFFindingInfo struct {
+	UID           string   `json:"uid"`
+	Title         string   `json:"title"`
+	Desc          string   `json:"desc,omitempty"`
+	Types         []string `json:"types,omitempty"`
+	CreatedTime   int64    `json:"created_time"`
+	FirstSeenTime int64    `json:"first_seen_time"`
+	ModifiedTime  int64    `json:"modified_time,omitempty"`
+}
+
+// OCSFBase holds the attributes shared by both finding classes
+type OCSFBase struct {
+	ActivityID   int              `json:"activity_id"`
+	ActivityName string           `json:"activity_name"`
+	CategoryUID  int              `json:"category_uid"`
+	CategoryName string           `json:"category_name"`
+	ClassUID     int              `json:"class_uid"`
+	ClassName    string           `json:"class_name"`
+	TypeUID      int              `json:"type_uid"`
+	TypeName     string           `json:"type_name"`
+	SeverityID   int              `json:"severity_id"`
+	Severity     string           `json:"severity"`
+	Time         int64            `json:"time"`
+	Message      string           `json:"message"`
+	Metadata     OCSFMetadata     `json:"metadata"`
+	Observables  []OCSFObservable `json:"observables,omitempty"`
+	RawData      string           `json:"raw_data,omitempty"`
+}
+
+// OCSFDetectionFinding is an OCSF 1.1 Detection Finding (class 2004)
+type OCSFDetectionFinding struct {
+	OCSFBase
+	StatusID    int             `json:"status_id"`
+	Status      string          `json:"status"`
+	FindingInfo OCSFFindingInfo `json:"finding_info"`
+}
+
+// OCSFSecurityFinding is an OCSF Security Finding (class 2001), deprecated
+// in OCSF 1.1 in favour of Detection Finding but still used by many consumers
+type OCSFSecurityFinding struct {
+	OCSFBase
+	StateID int             `json:"state_id"`
+	State   string          `json:"state"`
+	Finding OCSFFindingInfo `json:"finding"`
+}
+
+// ToDetectionFinding renders an alert as an OCSF Detection Finding
+func ToDetectionFinding(alert models.Alert) OCSFDetectionFinding {
+	statusID := ocsfStatuses[alert.Status]
+	return OCSFDetectionFinding{
+		OCSFBase:    ocsfBase(alert, ocsfClassDetectionFinding, "Detection Finding"),
+		StatusID:    statusID,
+		Status:      ocsfStatusNames[statusID],
+		FindingInfo: ocsfFindingInfo(alert),
+	}
+}
+
+// ToSecurityFinding renders an alert as an OCSF Security Finding
+func ToSecurityFinding(alert models.Alert) OCSFSecurityFinding {
+	stateID := ocsfStatuses[alert.Status]
+	return OCSFSecurityFinding{
+		OCSFBase: ocsfBase(alert, ocsfClassSecurityFinding, "Security Finding"),
+		StateID:  stateID,
+		State:    ocsfStatusNames[stateID],
+		Finding:  ocsfFindingInfo(alert),
+	}
+}
+
+// ocsfBase fills the shared attributes. The activity follows the alert's
+// lifecycle and time is when it last changed.
+func ocsfBase(alert models.Alert, classUID int, className string) OCSFBase {
+	activityID, changedAt := ocsfActivity(alert)
+	activityName := ocsfActivityNames[activityID]
+	severityID := ocsfSeverities[alert.Severity]
+
+	base := OCSFBase{
+		ActivityID:   activityID,
+		ActivityName: activityName,
+		CategoryUID:  ocsfCategoryFindings,
+		CategoryName: "Findings",
+		ClassUID:     classUID,
+		ClassName:    className,
+		TypeUID:      classUID*100 + activityID,
+		TypeName:     className + ": " + activityName,
+		SeverityID:   severityID,
+		Severity:     ocsfSeverityNames[severityID],
+		Time:         changedAt.UnixMilli(),
+		Message:      alert.Description,
+		Metadata: OCSFMetadata{
+			Version:        OCSFVersion,
+			Product:        OCSFProduct{Name: alert.Source},
+			LogProvider:    logProvider,
+			CorrelationUID: alert.Fingerprint,
+		},
+		RawData: rawEvent(alert),
+	}
+
+	if alert.IPAddress != nil && *alert.IPAddress != "" {
+		base.Observables = []OCSFObservable{{
+			Name:   "ip_address",
+			Type:   "IP Address",
+			TypeID: ocsfObservableIP,
+			Value:  *alert.IPAddress,
+		}}
+	}
+
+	return base
+}
+
+func ocsfActivity(alert models.Alert) (int, time.Time) {
+	switch {
+	case alert.ResolvedAt != nil:
+		return ocsfActivityClose, *alert.ResolvedAt
+	case alert.AcknowledgedAt != nil:
+		return ocsfActivityUpdate, *alert.AcknowledgedAt
+	default:
+		return ocsfActivityCreate, alert.CreatedAt
+	}
+}
+
+func ocsfFindingInfo(alert models.Alert) OCSFFindingInfo {
+	info := OCSFFindingInfo{
+		UID:           alert.ID,
+		Title:         alert.Description,
+		Desc:          alert.Description,
+		CreatedTime:   alert.CreatedAt.UnixMilli(),
+		FirstSeenTime: alert.CreatedAt.UnixMilli(),
+	}
+	if alert.EnrichmentType != nil && *alert.EnrichmentType != "" {
+		info.Types = []string{*alert.EnrichmentType}
+	}
+	if _, changedAt := ocsfActivity(alert); changedAt.After(alert.CreatedAt) {
+		info.ModifiedTime = changedAt.UnixMilli()
+	}
+	return info
+}
+
+// rawEvent returns the stored upstream event when it is valid JSON
+func rawEvent(alert models.Alert) string {
+	if len(alert.WholeEvent) == 0 || !json.Valid(alert.WholeEvent) {
+		return ""
+	}
+	return string(alert.WholeEvent)
+}
@@ -0,0 +1,78 @@
+package normalize
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToDetectionFinding(t *testing.T) {
+	alert := testAlert()
+
+	finding := ToDetectionFinding(alert)
+
+	assert.Equal(t, 2004, finding.ClassUID)
+	assert.Equal(t, 2, finding.CategoryUID)
+	assert.Equal(t, 1, finding.ActivityID)
+	assert.Equal(t, 200401, finding.TypeUID)
+	assert.Equal(t, "Detection Finding: Create", finding.TypeName)
+	assert.Equal(t, 4, finding.SeverityID)
+	assert.Equal(t, "High", finding.Severity)
+	assert.Equal(t, 1, finding.StatusID)
+	assert.Equal(t, "New", finding.Status)
+	assert.Equal(t, alert.CreatedAt.UnixMilli(), finding.Time)
+	assert.Equal(t, alert.ID, finding.FindingInfo.UID)
+	assert.Equal(t, "Port scan detected", finding.FindingInfo.Title)
+	assert.Equal(t, []string{"threat_intel"}, finding.FindingInfo.Types)
+	assert.Zero(t, finding.FindingInfo.ModifiedTime)
+	assert.Equal(t, OCSFMetadata{Version: OCSFVersion, Product: OCSFProduct{Name: "ids"}, LogProvider: "alert-service", CorrelationUID: "abc123"}, finding.Metadata)
+	assert.Equal(t, []OCSFObservable{{Name: "ip_address", Type: "IP Address", TypeID: 2, Value: "10.0.0.5"}}, finding.Observables)
+	assert.JSONEq(t, `{"source":"ids","severity":"high"}`, finding.RawData)
+}
+
+func TestToDetectionFinding_Lifecycle(t *testing.T) {
+	alert := testAlert()
+	acknowledgedAt := alert.CreatedAt.Add(5 * time.Minute)
+	resolvedAt := alert.CreatedAt.Add(time.Hour)
+
+	alert.Status, alert.AcknowledgedAt = models.StatusAcknowledged, &acknowledgedAt
+	finding := ToDetectionFinding(alert)
+	assert.Equal(t, 200402, finding.TypeUID)
+	assert.Equal(t, "In Progress", finding.Status)
+	assert.Equal(t, acknowledgedAt.UnixMilli(), finding.Time)
+	assert.Equal(t, acknowledgedAt.UnixMilli(), finding.FindingInfo.ModifiedTime)
+
+	alert.Status, alert.ResolvedAt = models.StatusResolved, &resolvedAt
+	finding = ToDetectionFinding(alert)
+	assert.Equal(t, 200403, finding.TypeUID)
+	assert.Equal(t, 4, finding.StatusID)
+	assert.Equal(t, resolvedAt.UnixMilli(), finding.Time)
+}
+
+func TestToSecurityFinding(t *testing.T) {
+	finding := ToSecurityFinding(testAlert())
+
+	data, err := json.Marshal(finding)
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.EqualValues(t, 2001, doc["class_uid"])
+	assert.EqualValues(t, 200101, doc["type_uid"])
+	assert.EqualValues(t, 1, doc["state_id"])
+	assert.Contains(t, doc, "finding")
+	assert.NotContains(t, doc, "finding_info")
+	assert.NotContains(t, doc, "status_id")
+}
+
+func TestToDetectionFinding_SkipsInvalidRawEvent(t *testing.T) {
+	alert := testAlert()
+	alert.WholeEvent = []byte("not json")
+
+	assert.Empty(t, ToDetectionFinding(alert).RawData)
+}
@@ -0,0 +1,102 @@
+package normalize
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaBase is the $id prefix of the bundled schemas
+const schemaBase = "https://alert-service.local/schemas/"
+
+// The bundled schemas cover the OCSF classes and ECS field sets this package
+// renders, so output can be checked without fetching anything
+//
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// schemaNames is the bundled schema each format is validated against
+var schemaNames = map[Format]string{
+	FormatOCSF:                "ocsf-1.1.0-detection_finding.json",
+	FormatOCSFSecurityFinding: "ocsf-1.1.0-security_finding.json",
+	FormatECS:                 "ecs-8.11.0.json",
+}
+
+var (
+	schemasOnce sync.Once
+	schemas     map[Format]*jsonschema.Schema
+	schemasErr  error
+)
+
+// Validate checks a rendered document against the bundled schema for its
+// format. The native format has no schema and always passes.
+func Validate(format Format, doc interface{}) error {
+	if format == FormatNative {
+		return nil
+	}
+
+	schemasOnce.Do(compileSchemas)
+	if schemasErr != nil {
+		return schemasErr
+	}
+	schema, ok := schemas[format]
+	if !ok {
+		return fmt.Errorf("normalize: unknown format %q", format)
+	}
+
+	// Round-trip through JSON so structs are validated as they are served
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("normalize: encoding %s document: %w", format, err)
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("normalize: decoding %s document: %w", format, err)
+	}
+	if err := schema.Validate(instance); err != nil {
+		return fmt.Errorf("normalize: %s document does not match schema: %w", format, err)
+	}
+	return nil
+}
+
+func compileSchemas() {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+
+	entries, err := schemaFiles.ReadDir("schemas")
+	if err != nil {
+		schemasErr = fmt.Errorf("normalize: reading bundled schemas: %w", err)
+		return
+	}
+	for _, entry := range entries {
+		data, err := schemaFiles.ReadFile(path.Join("schemas", entry.Name()))
+		if err != nil {
+			schemasErr = fmt.Errorf("normalize: reading schema %s: %w", entry.Name(), err)
+			return
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+		if err != nil {
+			schemasErr = fmt.Errorf("normalize: parsing schema %s: %w", entry.Name(), err)
+			return
+		}
+		if err := compiler.AddResource(schemaBase+entry.Name(), doc); err != nil {
+			schemasErr = fmt.Errorf("normalize: loading schema %s: %w", entry.Name(), err)
+			return
+		}
+	}
+
+	schemas = make(map[Format]*jsonschema.Schema, len(schemaNames))
+	for format, name := range schemaNames {
+		schema, err := compiler.Compile(schemaBase + name)
+		if err != nil {
+			schemasErr = fmt.Errorf("normalize: compiling schema %s: %w", name, err)
+			return
+		}
+		schemas[format] = schema
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"censys_alert_system/internal/archive"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+)
+
+// DefaultArchiveBatchSize is the number of alerts read from storage or
+// restored at a time when no batch size is set
+const DefaultArchiveBatchSize = 1000
+
+// ErrArchiveNotFound is returned when restoring an archive that does not
+// exist or was never completed
+var ErrArchiveNotFound = errors.New("archive not found")
+
+// ArchiveService copies alerts to cold storage before retention removes
+// them, and restores archived alerts on demand
+type ArchiveService struct {
+	alerts     AlertStorageInterface
+	store      *archive.Store
+	partitions *PartitionService
+	batchSize  int
+}
+
+// NewArchiveService creates an archive service writing to store
+func NewArchiveService(alerts AlertStorageInterface, store *archive.Store, batchSize int) *ArchiveService {
+	if batchSize <= 0 {
+		batchSize = DefaultArchiveBatchSize
+	}
+	return &ArchiveService{
+		alerts:    alerts,
+		store:     store,
+		batchSize: batchSize,
+	}
+}
+
+// SetPartitions makes Restore create any partitions the archived alerts
+// need, e.g. ones dropped by retention
+func (a *ArchiveService) SetPartitions(partitions *PartitionService) {
+	a.partitions = partitions
+}
+
+// ArchiveRange archives the alerts matching filter under name, oldest first.
+// It returns a nil manifest, and writes nothing, when no alert matches.
+func (a *ArchiveService) ArchiveRange(ctx context.Context, name string, filter models.AlertFilter) (*archive.Manifest, error) {
+	w := a.store.Create(name, filter.From, filter.To, filter.Severities)
+	defer w.Abort()
+
+	var afterCreatedAt time.Time
+	var afterID string
+	for {
+		alerts, err := a.alerts.ListAlertsAfter(ctx, filter, afterCreatedAt, afterID, a.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("archiving %s: %w", name, err)
+		}
+		if err := w.Write(ctx, alerts...); err != nil {
+			return nil, fmt.Errorf("archiving %s: %w", name, err)
+		}
+		if len(alerts) < a.batchSize {
+			break
+		}
+		last := alerts[len(alerts)-1]
+		afterCreatedAt, afterID = last.CreatedAt, last.ID
+	}
+
+	if w.Rows() == 0 {
+		return nil, nil
+	}
+	manifest, err := w.Close(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("archiving %s: %w", name, err)
+	}
+	metrics.AlertsArchived.Add(float64(manifest.Rows))
+	log.Printf("[ARCHIVE] Archived %d alert(s) to %s in %d file(s)", manifest.Rows, name, len(manifest.Files))
+	return manifest, nil
+}
+
+// ArchivePartition archives every alert in partition under the partition's name
+func (a *ArchiveService) ArchivePartition(ctx context.Context, partition models.AlertPartition) error {
+	_, err := a.ArchiveRange(ctx, partition.Name, models.AlertFilter{From: partition.From, To: partition.To})
+	return err
+}
+
+// ArchiveExpired archives the alerts of severity created before before
+func (a *ArchiveService) ArchiveExpired(ctx context.Context, severity string, before time.Time) error {
+	name := fmt.Sprintf("expired-%s-%s", severity, before.UTC().Format("20060102T150405Z"))
+	_, err := a.ArchiveRange(ctx, name, models.AlertFilter{Severities: []string{severity}, To: before})
+	return err
+}
+
+// Restore loads the archive name back into storage. Every data file is
+// checked against the manifest before its alerts are inserted; alerts that
+// are already stored are skipped.
+func (a *ArchiveService) Restore(ctx context.Context, name string) (restored, skipped int, err error) {
+	manifest, err := a.store.Manifest(ctx, name)
+	if errors.Is(err, archive.ErrNotFound) {
+		return 0, 0, fmt.Errorf("%w: %s", ErrArchiveNotFound, name)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if a.partitions != nil && manifest.Rows > 0 {
+		if err := a.partitions.EnsureRange(ctx, manifest.Oldest, manifest.Newest); err != nil {
+			return 0, 0, fmt.Errorf("restoring %s: %w", name, err)
+		}
+	}
+
+	err = a.store.Read(ctx, manifest, a.batchSize, func(alerts []models.Alert) error {
+		inserted, err := a.alerts.RestoreAlerts(ctx, alerts)
+		if err != nil {
+			return err
+		}
+		restored += inserted
+		skipped += len(alerts) - inserted
+		return nil
+	})
+	metrics.AlertsRestored.Add(float64(restored))
+	if err != nil {
+		return restored, skipped, fmt.Errorf("restoring %s: %w", name, err)
+	}
+	log.Printf("[ARCHIVE] Restored %d alert(s) from %s, %d already stored", restored, name, skipped)
+	return restored, skipped, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/archive"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestArchiveService(t *testing.T, batchSize int) (*ArchiveService, *mocks.AlertStorageInterface) {
+	sink, err := archive.NewDirSink(t.TempDir())
+	require.NoError(t, err)
+	store, err := archive.NewStore(sink, archive.FormatNDJSON, 0)
+	require.NoError(t, err)
+	storage := mocks.NewAlertStorageInterface(t)
+	return NewArchiveService(storage, store, batchSize), storage
+}
+
+func archivedAlerts(n int) []models.Alert {
+	alerts := make([]models.Alert, n)
+	for i := range alerts {
+		alerts[i] = models.Alert{
+			ID:          fmt.Sprintf("alert-%d", i),
+			Source:      "firewall",
+			Severity:    "low",
+			Fingerprint: fmt.Sprintf("fp-%d", i),
+			Status:      models.StatusOpen,
+			CreatedAt:   utcDay(2025, 1, 1+i),
+		}
+	}
+	return alerts
+}
+
+func TestArchiveService_ArchiveAndRestore(t *testing.T) {
+	ctx := context.Background()
+	a, storage := newTestArchiveService(t, 2)
+	alerts := archivedAlerts(3)
+	partition := models.AlertPartition{Name: "alerts_p20250101", From: utcDay(2025, 1, 1), To: utcDay(2025, 2, 1)}
+	filter := models.AlertFilter{From: partition.From, To: partition.To}
+
+	storage.On("ListAlertsAfter", ctx, filter, time.Time{}, "", 2).Return(alerts[:2], nil).Once()
+	storage.On("ListAlertsAfter", ctx, filter, alerts[1].CreatedAt, "alert-1", 2).Return(alerts[2:], nil).Once()
+	require.NoError(t, a.ArchivePartition(ctx, partition))
+
+	// The first alert survived retention, so only the other two are restored
+	storage.On("RestoreAlerts", ctx, alerts[:2]).Return(1, nil).Once()
+	storage.On("RestoreAlerts", ctx, alerts[2:]).Return(1, nil).Once()
+
+	restored, skipped, err := a.Restore(ctx, "alerts_p20250101")
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+	assert.Equal(t, 1, skipped)
+}
+
+func TestArchiveService_RestoreCreatesPartitions(t *testing.T) {
+	ctx := context.Background()
+	a, storage := newTestArchiveService(t, 10)
+	p, partitions := newTestPartitionService(t, utcDay(2025, 6, 15), PartitionPolicy{})
+	a.SetPartitions(p)
+	alerts := archivedAlerts(2)
+
+	storage.On("ListAlertsAfter", ctx, mock.Anything, time.Time{}, "", 10).Return(alerts, nil).Once()
+	require.NoError(t, a.ArchiveExpired(ctx, "low", utcDay(2025, 5, 16)))
+
+	partitions.On("ListAlertPartitions", ctx).Return(nil, nil).Once()
+	partitions.On("CreateAlertPartition", ctx, models.AlertPartition{Name: "alerts_p20250101", From: utcDay(2025, 1, 1), To: utcDay(2025, 2, 1)}).Return(nil).Once()
+	storage.On("RestoreAlerts", ctx, alerts).Return(2, nil).Once()
+
+	restored, _, err := a.Restore(ctx, "expired-low-20250516T000000Z")
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+}
+
+func TestArchiveService_ArchiveNothing(t *testing.T) {
+	ctx := context.Background()
+	a, storage := newTestArchiveService(t, 10)
+
+	storage.On("ListAlertsAfter", ctx, models.AlertFilter{Severities: []string{"low"}, To: utcDay(2025, 5, 16)}, time.Time{}, "", 10).Return(nil, nil).Once()
+
+	require.NoError(t, a.ArchiveExpired(ctx, "low", utcDay(2025, 5, 16)))
+	_, _, err := a.Restore(ctx, "expired-low-20250516T000000Z")
+	assert.True(t, errors.Is(err, ErrArchiveNotFound))
+}
+
+func TestArchiveService_ArchiveError(t *testing.T) {
+	ctx := context.Background()
+	a, storage := newTestArchiveService(t, 10)
+
+	storage.On("ListAlertsAfter", ctx, mock.Anything, time.Time{}, "", 10).Return(nil, errors.New("connection refused")).Once()
+
+	err := a.ArchivePartition(ctx, models.AlertPartition{Name: "alerts_p20250101"})
+
+	assert.ErrorContains(t, err, "archiving alerts_p20250101: connection refused")
+}
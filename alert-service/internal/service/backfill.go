@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/models"
+)
+
+// DefaultBackfillChunk is the window fetched per step when a backfill does not set one
+const DefaultBackfillChunk = time.Hour
+
+// backfillLease is how long a running job is held before another worker may
+// take it over; it is renewed at every checkpoint
+const backfillLease = 2 * time.Minute
+
+var (
+	// ErrBackfillNotFound is returned for an unknown backfill job
+	ErrBackfillNotFound = errors.New("backfill job not found")
+
+	// ErrInvalidBackfill is returned for a backfill request that cannot be run
+	ErrInvalidBackfill = errors.New("invalid backfill")
+
+	// ErrBackfillTransition is returned when a job cannot be paused, resumed or
+	// cancelled from its current status
+	ErrBackfillTransition = errors.New("backfill job cannot change status")
+
+	// errBackfillStopped ends a run whose job was paused or cancelled
+	errBackfillStopped = errors.New("backfill stopped")
+)
+
+// jobTransitions lists the statuses each manual action applies to, for
+// backfill and re-enrichment jobs alike
+var jobTransitions = map[string][]string{
+	models.JobPaused:    {models.JobPending, models.JobRunning},
+	models.JobPending:   {models.JobPaused, models.JobFailed},
+	models.JobCancelled: {models.JobPending, models.JobRunning, models.JobPaused, models.JobFailed},
+}
+
+// BackfillRequest describes a historical window to re-fetch
+type BackfillRequest struct {
+	// Connector defaults to the only connector when a single one is configured
+	Connector string
+	From      time.Time
+	To        time.Time
+	Sources   []string
+	ChunkSize time.Duration
+}
+
+// BackfillService re-fetches historical windows from upstream connectors.
+// Jobs walk their range a chunk at a time and checkpoint after every page,
+// so they resume where they stopped after a pause, failure or restart.
+// Alerts go through the regular enrichment, dedup and storage path, but
+// connector watermarks and health are left alone.
+type BackfillService struct {
+	storage    BackfillStorageInterface
+	alerts     *AlertService
+	partitions *PartitionService
+	now        func() time.Time
+}
+
+// NewBackfillService creates a backfill service for the connectors of alerts
+func NewBackfillService(storage BackfillStorageInterface, alerts *AlertService) *BackfillService {
+	return &BackfillService{
+		storage: storage,
+		alerts:  alerts,
+		now:     time.Now,
+	}
+}
+
+// SetPartitions makes jobs create the partitions of each chunk before
+// storing its alerts, so they do not pile up in the default partition
+func (b *BackfillService) SetPartitions(partitions *PartitionService) {
+	b.partitions = partitions
+}
+
+// Create validates a request and queues a job for it
+func (b *BackfillService) Create(ctx context.Context, req BackfillRequest) (*models.BackfillJob, error) {
+	c, err := b.connectorFor(req.Connector)
+	if err != nil {
+		return nil, err
+	}
+	if req.From.IsZero() || req.To.IsZero() {
+		return nil, fmt.Errorf("%w: from and to are required", ErrInvalidBackfill)
+	}
+	if !req.From.Before(req.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidBackfill)
+	}
+	if req.ChunkSize == 0 {
+		req.ChunkSize = DefaultBackfillChunk
+	}
+	if req.ChunkSize < time.Second {
+		return nil, fmt.Errorf("%w: chunk size must be at least 1s", ErrInvalidBackfill)
+	}
+
+	from := req.From.UTC()
+	job := &models.BackfillJob{
+		Connector: c.Name,
+		From:      from,
+		To:        req.To.UTC(),
+		Sources:   req.Sources,
+		ChunkSize: req.ChunkSize.Truncate(time.Second),
+		Status:    models.JobPending,
+		Position:  from,
+	}
+	if err := b.storage.CreateBackfillJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("backfill: error creating job: %w", err)
+	}
+
+	log.Printf("[BACKFILL] %s: queued job %s for %s to %s in %s chunks", job.Connector, job.ID,
+		job.From.Format(time.RFC3339), job.To.Format(time.RFC3339), job.ChunkSize)
+	return job, nil
+}
+
+// connectorFor returns the named connector, which must support ranged fetches
+func (b *BackfillService) connectorFor(name string) (*Connector, error) {
+	connectors := b.alerts.connectors
+	if name == "" {
+		if len(connectors) != 1 {
+			return nil, fmt.Errorf("%w: connector is required when several are configured", ErrInvalidBackfill)
+		}
+		name = connectors[0].Name
+	}
+
+	c := b.alerts.connectorByName(name)
+	if c == nil {
+		return nil, fmt.Errorf("%w: unknown connector %q", ErrInvalidBackfill, name)
+	}
+	if _, ok := c.Client.(RangeAPIClientInterface); !ok {
+		return nil, fmt.Errorf("%w: connector %q does not support backfills", ErrInvalidBackfill, name)
+	}
+	return c, nil
+}
+
+// Get retrieves a job by ID
+func (b *BackfillService) Get(ctx context.Context, id string) (*models.BackfillJob, error) {
+	job, err := b.storage.GetBackfillJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("backfill: %w: %v", ErrBackfillNotFound, err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("backfill: %w", ErrBackfillNotFound)
+	}
+
+	return job, nil
+}
+
+// List retrieves recent jobs, newest first, optionally only in the given statuses
+func (b *BackfillService) List(ctx context.Context, statuses []string) ([]models.BackfillJob, error) {
+	jobs, err := b.storage.ListBackfillJobs(ctx, statuses)
+	if err != nil {
+		return nil, fmt.Errorf("backfill: error listing jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Pause stops a pending or running job after its current page
+func (b *BackfillService) Pause(ctx context.Context, id string) (*models.BackfillJob, error) {
+	return b.transition(ctx, id, models.JobPaused)
+}
+
+// Resume queues a paused or failed job to continue from its last checkpoint
+func (b *BackfillService) Resume(ctx context.Context, id string) (*models.BackfillJob, error) {
+	return b.transition(ctx, id, models.JobPending)
+}
+
+// Cancel stops a job for good. Alerts already stored are kept.
+func (b *BackfillService) Cancel(ctx context.Context, id string) (*models.BackfillJob, error) {
+	return b.transition(ctx, id, models.JobCancelled)
+}
+
+func (b *BackfillService) transition(ctx context.Context, id, status string) (*models.BackfillJob, error) {
+	job, err := b.storage.TransitionBackfillJob(ctx, id, jobTransitions[status], status)
+	if err != nil {
+		return nil, fmt.Errorf("backfill: %w", err)
+	}
+	if job != nil {
+		log.Printf("[BACKFILL] %s: job %s is now %s", job.Connector, job.ID, job.Status)
+		return job, nil
+	}
+
+	// Nothing changed: tell an unknown job from one in the wrong status
+	current, err := b.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("backfill: %w: job is %s", ErrBackfillTransition, current.Status)
+}
+
+// ProcessDue runs waiting jobs one after another until none is left or ctx
+// is cancelled. Returns the number of jobs worked on.
+func (b *BackfillService) ProcessDue(ctx context.Context) (int, error) {
+	n := 0
+	for ctx.Err() == nil {
+		now := b.now()
+		job, err := b.storage.ClaimBackfillJob(ctx, now, now.Add(backfillLease))
+		if err != nil {
+			return n, fmt.Errorf("backfill: error claiming job: %w", err)
+		}
+		if job == nil {
+			return n, nil
+		}
+
+		n++
+		if !b.run(ctx, job) {
+			// Leave the job leased; it is taken up again once the lease expires
+			return n, nil
+		}
+	}
+	return n, ctx.Err()
+}
+
+// run walks a claimed job chunk by chunk from its checkpoint. It returns
+// false when the job had to be left unfinished for later, because the
+// upstream's circuit is open or ctx was cancelled.
+func (b *BackfillService) run(ctx context.Context, job *models.BackfillJob) bool {
+	c := b.alerts.connectorByName(job.Connector)
+	if c == nil {
+		b.finish(ctx, job, fmt.Errorf("unknown connector %q", job.Connector))
+		return true
+	}
+	client, ok := c.Client.(RangeAPIClientInterface)
+	if !ok {
+		b.finish(ctx, job, fmt.Errorf("connector %q does not support backfills", job.Connector))
+		return true
+	}
+
+	log.Printf("[BACKFILL] %s: running job %s from %s", c.Name, job.ID, job.Position.Format(time.RFC3339))
+	for job.Position.Before(job.To) {
+		if ctx.Err() != nil {
+			return false
+		}
+		if state := c.breaker().State(); state != CircuitClosed {
+			// The regular sync owns the trial call that closes the circuit
+			log.Printf("[BACKFILL] %s: circuit %s, pausing job %s until it closes", c.Name, state, job.ID)
+			return false
+		}
+
+		end := job.Position.Add(job.ChunkSize)
+		if end.After(job.To) {
+			end = job.To
+		}
+		if b.partitions != nil {
+			if err := b.partitions.EnsureRange(ctx, job.Position, end); err != nil {
+				b.finish(ctx, job, fmt.Errorf("creating partitions: %w", err))
+				return true
+			}
+		}
+		err := client.FetchAlertRange(ctx, job.Position, end, job.Cursor, func(page external.AlertPage) error {
+			return b.storePage(ctx, c, job, page)
+		})
+		switch {
+		case errors.Is(err, errBackfillStopped):
+			log.Printf("[BACKFILL] %s: job %s stopped at %s", c.Name, job.ID, job.Position.Format(time.RFC3339))
+			return true
+		case ctx.Err() != nil:
+			return false
+		case err != nil:
+			b.finish(ctx, job, err)
+			return true
+		}
+
+		job.Position, job.Cursor = end, ""
+		if !b.checkpoint(ctx, job) {
+			log.Printf("[BACKFILL] %s: job %s stopped at %s", c.Name, job.ID, job.Position.Format(time.RFC3339))
+			return true
+		}
+	}
+
+	b.finish(ctx, job, nil)
+	return true
+}
+
+// storePage stores the alerts of one page that match the job's sources and
+// checkpoints the cursor once a page with more to follow is complete
+func (b *BackfillService) storePage(ctx context.Context, c *Connector, job *models.BackfillJob, page external.AlertPage) error {
+	alerts := page.Alerts
+	if len(job.Sources) > 0 {
+		alerts = slices.DeleteFunc(slices.Clone(alerts), func(alert external.ExternalAlert) bool {
+			return !slices.Contains(job.Sources, alert.Source)
+		})
+	}
+
+	// A zero watermark: backfilled alerts are not late arrivals. They are
+	// historical, so nobody is paged or streamed about them.
+	run, _, err := b.alerts.storeAlerts(ctx, c, alerts, time.Time{}, true)
+	job.Fetched += len(page.Alerts)
+	job.Stored += run.Stored
+	job.Duplicates += run.Duplicates
+	if err != nil {
+		return err
+	}
+
+	if page.Partial || !page.HasMore {
+		return nil
+	}
+	job.Cursor = page.NextCursor
+	if !b.checkpoint(ctx, job) {
+		return errBackfillStopped
+	}
+	return nil
+}
+
+// checkpoint saves the job's progress and reports whether it should keep running
+func (b *BackfillService) checkpoint(ctx context.Context, job *models.BackfillJob) bool {
+	status, err := b.storage.SaveBackfillProgress(context.WithoutCancel(ctx), job, b.now().Add(backfillLease))
+	if err != nil {
+		// Progress since the last checkpoint is fetched again; dedup keeps it harmless
+		log.Printf("[BACKFILL] %s: warning: %v", job.Connector, err)
+		return true
+	}
+	job.Status = status
+	return status == models.JobRunning
+}
+
+// finish records a job as completed, or failed with cause
+func (b *BackfillService) finish(ctx context.Context, job *models.BackfillJob, cause error) {
+	if cause != nil {
+		job.Status = models.JobFailed
+		job.Error = cause.Error()
+		log.Printf("[BACKFILL] %s: job %s failed at %s: %v", job.Connector, job.ID, job.Position.Format(time.RFC3339), cause)
+	} else {
+		now := b.now()
+		job.Status = models.JobCompleted
+		job.CompletedAt = &now
+		log.Printf("[BACKFILL] %s: job %s completed: %d fetched, %d stored, %d duplicate", job.Connector, job.ID, job.Fetched, job.Stored, job.Duplicates)
+	}
+
+	if err := b.storage.FinishBackfillJob(context.WithoutCancel(ctx), job); err != nil {
+		log.Printf("[BACKFILL] %s: %v", job.Connector, err)
+	}
+}
+
+// Run works through waiting backfill jobs every interval until ctx is cancelled
+func (b *BackfillService) Run(ctx context.Context, interval time.Duration) {
+	log.Printf("[BACKFILL] Starting backfill worker every %s", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[BACKFILL] Stopping backfill worker")
+			return
+		case <-ticker.C:
+			if _, err := b.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("[BACKFILL] %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeClient is an upstream client that also fetches bounded ranges
+type rangeClient struct {
+	*mocks.APIClientInterface
+	ranges *mocks.RangeAPIClientInterface
+}
+
+func (c rangeClient) FetchAlertRange(ctx context.Context, from, to time.Time, cursor string, handle external.PageHandler) error {
+	return c.ranges.FetchAlertRange(ctx, from, to, cursor, handle)
+}
+
+func newTestBackfillService(t *testing.T, now time.Time) (*BackfillService, *mocks.BackfillStorageInterface, *mocks.AlertStorageInterface, *mocks.RangeAPIClientInterface) {
+	jobStorage := mocks.NewBackfillStorageInterface(t)
+	alertStorage := mocks.NewAlertStorageInterface(t)
+	ranges := mocks.NewRangeAPIClientInterface(t)
+
+	alerts := NewAlertService(alertStorage, nil)
+	alerts.SetConnectors([]*Connector{{Name: "siem", Client: rangeClient{mocks.NewAPIClientInterface(t), ranges}}})
+
+	b := NewBackfillService(jobStorage, alerts)
+	b.now = func() time.Time { return now }
+	return b, jobStorage, alertStorage, ranges
+}
+
+// serveRange makes the range fetch hand over pages in order
+func serveRange(pages ...external.AlertPage) func(args mock.Arguments) {
+	return func(args mock.Arguments) {
+		handle := args.Get(4).(external.PageHandler)
+		for _, page := range pages {
+			if err := handle(page); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestBackfillService_Create(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	from, to := now.Add(-6*time.Hour), now
+
+	t.Run("defaults to the only connector and hourly chunks", func(t *testing.T) {
+		b, jobStorage, _, _ := newTestBackfillService(t, now)
+
+		jobStorage.On("CreateBackfillJob", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.BackfillJob).ID = "job-1"
+		}).Return(nil)
+
+		job, err := b.Create(ctx, BackfillRequest{From: from, To: to, Sources: []string{"ids"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "job-1", job.ID)
+		assert.Equal(t, "siem", job.Connector)
+		assert.Equal(t, time.Hour, job.ChunkSize)
+		assert.Equal(t, from, job.Position)
+		assert.Equal(t, models.JobPending, job.Status)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		b, _, _, _ := newTestBackfillService(t, now)
+
+		for name, req := range map[string]BackfillRequest{
+			"empty range":       {From: to, To: from},
+			"missing bound":     {From: from},
+			"unknown connector": {Connector: "other", From: from, To: to},
+			"tiny chunks":       {From: from, To: to, ChunkSize: time.Millisecond},
+		} {
+			_, err := b.Create(ctx, req)
+			assert.ErrorIs(t, err, ErrInvalidBackfill, name)
+		}
+	})
+}
+
+func TestBackfillService_ProcessDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	from := now.Add(-2 * time.Hour)
+	newJob := func() *models.BackfillJob {
+		return &models.BackfillJob{ID: "job-1", Connector: "siem", From: from, To: now, Sources: []string{"ids"},
+			ChunkSize: time.Hour, Status: models.JobRunning, Position: from}
+	}
+
+	t.Run("walks the range in chunks and completes", func(t *testing.T) {
+		b, jobStorage, alertStorage, ranges := newTestBackfillService(t, now)
+
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(newJob(), nil).Once()
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(nil, nil).Once()
+		ranges.On("FetchAlertRange", ctx, from, from.Add(time.Hour), "", mock.Anything).Run(serveRange(
+			external.AlertPage{Alerts: []external.ExternalAlert{
+				{Source: "ids", Severity: "low", Description: "a", CreatedAt: from.Add(time.Minute)},
+				{Source: "firewall", Severity: "low", Description: "filtered", CreatedAt: from.Add(time.Minute)},
+			}, NextCursor: "c1", HasMore: true},
+			external.AlertPage{Alerts: []external.ExternalAlert{
+				{Source: "ids", Severity: "low", Description: "b", CreatedAt: from.Add(time.Minute)},
+			}},
+		)).Return(nil)
+		ranges.On("FetchAlertRange", ctx, from.Add(time.Hour), now, "", mock.Anything).Return(nil)
+		alertStorage.On("CreateAlerts", ctx, mock.MatchedBy(func(alerts []models.Alert) bool {
+			return len(alerts) == 1 && alerts[0].Source == "ids"
+		})).Run(assignIDs).Return(nil).Twice()
+
+		var cursors []string
+		jobStorage.On("SaveBackfillProgress", mock.Anything, mock.Anything, now.Add(backfillLease)).Run(func(args mock.Arguments) {
+			cursors = append(cursors, args.Get(1).(*models.BackfillJob).Cursor)
+		}).Return(models.JobRunning, nil)
+		jobStorage.On("FinishBackfillJob", mock.Anything, mock.MatchedBy(func(job *models.BackfillJob) bool {
+			return job.Status == models.JobCompleted && job.CompletedAt != nil && job.Position.Equal(now) &&
+				job.Fetched == 3 && job.Stored == 2 && job.Duplicates == 0
+		})).Return(nil)
+
+		n, err := b.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"c1", "", ""}, cursors)
+		assert.Nil(t, b.alerts.connectors[0].snapshot().Watermark, "the watermark is left alone")
+	})
+
+	t.Run("stores historical alerts without paging or publishing them", func(t *testing.T) {
+		b, jobStorage, alertStorage, ranges := newTestBackfillService(t, now)
+		mockPager := mocks.NewPagerInterface(t)
+		mockPublisher := mocks.NewEventPublisher(t)
+		b.alerts.SetPager(mockPager, PagerPolicy{})
+		b.alerts.SetPublisher(mockPublisher)
+		job := newJob()
+		job.To = from.Add(time.Hour)
+
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(job, nil).Once()
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(nil, nil).Once()
+		ranges.On("FetchAlertRange", ctx, from, from.Add(time.Hour), "", mock.Anything).Run(serveRange(
+			external.AlertPage{Alerts: []external.ExternalAlert{
+				{Source: "ids", Severity: "critical", Description: "old intrusion", CreatedAt: from.Add(time.Minute)},
+			}},
+		)).Return(nil)
+		alertStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil).Once()
+		jobStorage.On("SaveBackfillProgress", mock.Anything, mock.Anything, now.Add(backfillLease)).Return(models.JobRunning, nil)
+		jobStorage.On("FinishBackfillJob", mock.Anything, mock.MatchedBy(func(job *models.BackfillJob) bool {
+			return job.Status == models.JobCompleted && job.Stored == 1
+		})).Return(nil)
+
+		_, err := b.ProcessDue(ctx)
+		b.alerts.pages.drain(ctx)
+
+		require.NoError(t, err)
+		mockPager.AssertNotCalled(t, "Trigger", mock.Anything, mock.Anything)
+		mockPublisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("keeps an alert created exactly at from", func(t *testing.T) {
+		b, jobStorage, alertStorage, _ := newTestBackfillService(t, now)
+		job := newJob()
+		job.To = from.Add(time.Hour)
+		upstream := []external.ExternalAlert{
+			{Source: "ids", Severity: "low", Description: "before", CreatedAt: from.Add(-time.Second)},
+			{Source: "ids", Severity: "low", Description: "at from", CreatedAt: from},
+			{Source: "ids", Severity: "low", Description: "at to", CreatedAt: job.To},
+		}
+		// An upstream with the mock API's exclusive since
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			since, err := time.Parse(time.RFC3339Nano, r.URL.Query().Get("since"))
+			require.NoError(t, err)
+			var page []external.ExternalAlert
+			for _, alert := range upstream {
+				if alert.CreatedAt.After(since) {
+					page = append(page, alert)
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"alerts": page})
+		}))
+		defer server.Close()
+		b.alerts.SetConnectors([]*Connector{{Name: "siem", Client: external.NewMockAPIClient(server.URL)}})
+
+		var stored []string
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(job, nil).Once()
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(nil, nil).Once()
+		alertStorage.On("CreateAlerts", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			for _, alert := range args.Get(1).([]models.Alert) {
+				stored = append(stored, alert.Description)
+			}
+			assignIDs(args)
+		}).Return(nil)
+		jobStorage.On("SaveBackfillProgress", mock.Anything, mock.Anything, now.Add(backfillLease)).Return(models.JobRunning, nil)
+		jobStorage.On("FinishBackfillJob", mock.Anything, mock.Anything).Return(nil)
+
+		_, err := b.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"at from", "at to"}, stored)
+	})
+
+	t.Run("creates the chunk's partitions before storing it", func(t *testing.T) {
+		b, jobStorage, alertStorage, ranges := newTestBackfillService(t, now)
+		partitions, partitionStorage := newTestPartitionService(t, now, PartitionPolicy{Interval: PartitionDaily})
+		b.SetPartitions(partitions)
+		job := newJob()
+		job.To = from.Add(time.Hour)
+
+		var calls []string
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(job, nil).Once()
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(nil, nil).Once()
+		partitionStorage.On("ListAlertPartitions", ctx).Return(nil, nil)
+		partitionStorage.On("CreateAlertPartition", ctx, models.AlertPartition{Name: "alerts_p20250110", From: utcDay(2025, 1, 10), To: utcDay(2025, 1, 11)}).
+			Run(func(mock.Arguments) { calls = append(calls, "partition") }).Return(nil).Once()
+		ranges.On("FetchAlertRange", ctx, from, job.To, "", mock.Anything).Run(serveRange(
+			external.AlertPage{Alerts: []external.ExternalAlert{
+				{Source: "ids", Severity: "low", Description: "a", CreatedAt: from.Add(time.Minute)},
+			}},
+		)).Return(nil)
+		alertStorage.On("CreateAlerts", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			calls = append(calls, "store")
+			assignIDs(args)
+		}).Return(nil).Once()
+		jobStorage.On("SaveBackfillProgress", mock.Anything, mock.Anything, now.Add(backfillLease)).Return(models.JobRunning, nil)
+		jobStorage.On("FinishBackfillJob", mock.Anything, mock.MatchedBy(func(job *models.BackfillJob) bool {
+			return job.Status == models.JobCompleted
+		})).Return(nil)
+
+		_, err := b.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"partition", "store"}, calls)
+	})
+
+	t.Run("partition failure fails the job", func(t *testing.T) {
+		b, jobStorage, _, _ := newTestBackfillService(t, now)
+		partitions, partitionStorage := newTestPartitionService(t, now, PartitionPolicy{})
+		b.SetPartitions(partitions)
+
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(newJob(), nil).Once()
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(nil, nil).Once()
+		partitionStorage.On("ListAlertPartitions", ctx).Return(nil, errors.New("db down"))
+		jobStorage.On("FinishBackfillJob", mock.Anything, mock.MatchedBy(func(job *models.BackfillJob) bool {
+			return job.Status == models.JobFailed && job.Position.Equal(from)
+		})).Return(nil)
+
+		_, err := b.ProcessDue(ctx)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("stops when the job is paused", func(t *testing.T) {
+		b, jobStorage, alertStorage, ranges := newTestBackfillService(t, now)
+
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(newJob(), nil).Once()
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(nil, nil).Once()
+		ranges.On("FetchAlertRange", ctx, from, from.Add(time.Hour), "", mock.Anything).Run(serveRange(
+			external.AlertPage{Alerts: []external.ExternalAlert{
+				{Source: "ids", Severity: "low", Description: "a", CreatedAt: from.Add(time.Minute)},
+			}, NextCursor: "c1", HasMore: true},
+			external.AlertPage{Alerts: []external.ExternalAlert{
+				{Source: "ids", Severity: "low", Description: "never stored", CreatedAt: from.Add(time.Minute)},
+			}},
+		)).Return(errBackfillStopped)
+		alertStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil).Once()
+		jobStorage.On("SaveBackfillProgress", mock.Anything, mock.Anything, now.Add(backfillLease)).Return(models.JobPaused, nil).Once()
+
+		_, err := b.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		jobStorage.AssertNotCalled(t, "FinishBackfillJob", mock.Anything, mock.Anything)
+	})
+
+	t.Run("upstream failure fails the job", func(t *testing.T) {
+		b, jobStorage, _, ranges := newTestBackfillService(t, now)
+
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(newJob(), nil).Once()
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(nil, nil).Once()
+		ranges.On("FetchAlertRange", ctx, from, from.Add(time.Hour), "", mock.Anything).Return(errors.New("status 503"))
+		jobStorage.On("FinishBackfillJob", mock.Anything, mock.MatchedBy(func(job *models.BackfillJob) bool {
+			return job.Status == models.JobFailed && job.Error == "status 503" && job.Position.Equal(from)
+		})).Return(nil)
+
+		_, err := b.ProcessDue(ctx)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("open circuit leaves the job for later", func(t *testing.T) {
+		b, jobStorage, _, _ := newTestBackfillService(t, now)
+		breaker := b.alerts.connectors[0].breaker()
+		for i := 0; i < DefaultFailureThreshold; i++ {
+			breaker.Failure()
+		}
+
+		jobStorage.On("ClaimBackfillJob", ctx, now, now.Add(backfillLease)).Return(newJob(), nil).Once()
+
+		n, err := b.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+}
+
+func TestBackfillService_Transitions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("resume requeues a paused job", func(t *testing.T) {
+		b, jobStorage, _, _ := newTestBackfillService(t, now)
+
+		jobStorage.On("TransitionBackfillJob", ctx, "job-1", []string{models.JobPaused, models.JobFailed}, models.JobPending).
+			Return(&models.BackfillJob{ID: "job-1", Status: models.JobPending}, nil)
+
+		job, err := b.Resume(ctx, "job-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.JobPending, job.Status)
+	})
+
+	t.Run("finished job cannot be paused", func(t *testing.T) {
+		b, jobStorage, _, _ := newTestBackfillService(t, now)
+
+		jobStorage.On("TransitionBackfillJob", ctx, "job-1", mock.Anything, models.JobPaused).Return(nil, nil)
+		jobStorage.On("GetBackfillJob", ctx, "job-1").Return(&models.BackfillJob{ID: "job-1", Status: models.JobCompleted}, nil)
+
+		_, err := b.Pause(ctx, "job-1")
+
+		assert.ErrorIs(t, err, ErrBackfillTransition)
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		b, jobStorage, _, _ := newTestBackfillService(t, now)
+
+		jobStorage.On("TransitionBackfillJob", ctx, "missing", mock.Anything, models.JobCancelled).Return(nil, nil)
+		jobStorage.On("GetBackfillJob", ctx, "missing").Return(nil, nil)
+
+		_, err := b.Cancel(ctx, "missing")
+
+		assert.ErrorIs(t, err, ErrBackfillNotFound)
+	})
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"censys_alert_system/internal/metrics"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// Circuit breaker defaults
+const (
+	DefaultFailureThreshold = 3
+	DefaultCoolDown         = 2 * time.Minute
+)
+
+// ErrCircuitOpen is returned when a sync is skipped because the upstream's circuit is open
+var ErrCircuitOpen = errors.New("circuit open")
+
+// circuitStateValues are the values of the upstream_circuit_state gauge
+var circuitStateValues = map[string]float64{
+	CircuitClosed:   0,
+	CircuitHalfOpen: 1,
+	CircuitOpen:     2,
+}
+
+// BreakerConfig configures a circuit breaker
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before a trial call is let through
+	CoolDown time.Duration
+}
+
+// BreakerStatus is a snapshot of a circuit breaker
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	FailureThreshold    int        `json:"failure_threshold"`
+	CoolDown            string     `json:"cool_down"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+}
+
+// CircuitBreaker stops calling an upstream after repeated failures. Once
+// FailureThreshold consecutive calls fail the circuit opens and calls are
+// refused for CoolDown. The next call is then let through half-open as a
+// trial: success closes the circuit, failure opens it for another cool-down.
+type CircuitBreaker struct {
+	name string
+	cfg  BreakerConfig
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker for the named upstream
+func NewCircuitBreaker(name string, cfg BreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultFailureThreshold
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = DefaultCoolDown
+	}
+
+	b := &CircuitBreaker{name: name, cfg: cfg, now: time.Now, state: CircuitClosed}
+	metrics.UpstreamCircuitState.WithLabelValues(name).Set(circuitStateValues[CircuitClosed])
+	return b
+}
+
+// Allow reports whether a call may go ahead. An open circuit whose cool-down
+// has elapsed moves to half-open and allows the trial call.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		if b.now().Before(b.openedAt.Add(b.cfg.CoolDown)) {
+			return false
+		}
+		b.transition(CircuitHalfOpen)
+	}
+	return true
+}
+
+// Success records a successful call and closes the circuit
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.state != CircuitClosed {
+		b.transition(CircuitClosed)
+	}
+}
+
+// Failure records a failed call. It opens the circuit when the threshold is
+// reached or when the half-open trial fails.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.cfg.FailureThreshold) {
+		b.openedAt = b.now()
+		b.transition(CircuitOpen)
+	}
+}
+
+// State returns the current state. An open circuit past its cool-down is
+// still reported open until the next call is allowed.
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Status returns a snapshot of the breaker
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		FailureThreshold:    b.cfg.FailureThreshold,
+		CoolDown:            b.cfg.CoolDown.String(),
+	}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.cfg.CoolDown)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// transition moves to state and records it. Callers hold b.mu.
+func (b *CircuitBreaker) transition(state string) {
+	log.Printf("[SYNC] %s: circuit %s -> %s", b.name, b.state, state)
+	b.state = state
+	metrics.UpstreamCircuitState.WithLabelValues(b.name).Set(circuitStateValues[state])
+	metrics.UpstreamCircuitTransitions.WithLabelValues(b.name, state).Inc()
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBreaker(threshold int, coolDown time.Duration) (*CircuitBreaker, *time.Time) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker("test", BreakerConfig{FailureThreshold: threshold, CoolDown: coolDown})
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("opens after the failure threshold", func(t *testing.T) {
+		b, _ := newTestBreaker(3, time.Minute)
+
+		b.Failure()
+		b.Failure()
+		assert.Equal(t, CircuitClosed, b.State())
+		assert.True(t, b.Allow())
+
+		b.Failure()
+		assert.Equal(t, CircuitOpen, b.State())
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("success resets the failure count", func(t *testing.T) {
+		b, _ := newTestBreaker(2, time.Minute)
+
+		b.Failure()
+		b.Success()
+		b.Failure()
+
+		assert.Equal(t, CircuitClosed, b.State())
+		assert.Equal(t, 1, b.Status().ConsecutiveFailures)
+	})
+
+	t.Run("half-open trial closes the circuit on success", func(t *testing.T) {
+		b, now := newTestBreaker(1, time.Minute)
+		b.Failure()
+
+		*now = now.Add(59 * time.Second)
+		assert.False(t, b.Allow())
+
+		*now = now.Add(time.Second)
+		assert.True(t, b.Allow())
+		assert.Equal(t, CircuitHalfOpen, b.State())
+
+		b.Success()
+		assert.Equal(t, CircuitClosed, b.State())
+		assert.Nil(t, b.Status().RetryAt)
+	})
+
+	t.Run("half-open trial failure reopens for another cool-down", func(t *testing.T) {
+		b, now := newTestBreaker(3, time.Minute)
+		b.Failure()
+		b.Failure()
+		b.Failure()
+
+		*now = now.Add(time.Minute)
+		assert.True(t, b.Allow())
+		b.Failure()
+
+		status := b.Status()
+		assert.Equal(t, CircuitOpen, status.State)
+		assert.Equal(t, now.Add(time.Minute), *status.RetryAt)
+		assert.False(t, b.Allow())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		status := NewCircuitBreaker("test", BreakerConfig{}).Status()
+
+		assert.Equal(t, DefaultFailureThreshold, status.FailureThreshold)
+		assert.Equal(t, DefaultCoolDown.String(), status.CoolDown)
+	})
+}
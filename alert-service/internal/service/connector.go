@@ -0,0 +1,628 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+)
+
+// DefaultSyncParallelism bounds how many connectors sync at once
+const DefaultSyncParallelism = 4
+
+// DefaultSyncBatchSize is how many synced alerts are inserted per transaction
+const DefaultSyncBatchSize = 500
+
+// defaultConnectorName names the connector built from the client passed to NewAlertService
+const defaultConnectorName = "mock-api"
+
+// Connector is one upstream source with its own client, poll interval,
+// watermark and health. The retry policy lives in the connector's client.
+type Connector struct {
+	Name         string
+	Client       APIClientInterface
+	PollInterval time.Duration
+
+	// Breaker skips syncs while the upstream is failing. A breaker with the
+	// default thresholds is created when nil.
+	Breaker *CircuitBreaker
+
+	// InheritWatermark seeds the watermark from the newest stored alert when the
+	// connector has no saved state, so upgrading from the single-upstream sync
+	// does not refetch everything
+	InheritWatermark bool
+
+	// Lookback re-fetches this far behind the watermark on every incremental
+	// sync, so alerts that reach the upstream late with older timestamps are
+	// still picked up. Re-fetched alerts are skipped by fingerprint.
+	Lookback time.Duration
+
+	mu      sync.Mutex
+	running bool
+	loaded  bool
+	state   models.ConnectorState
+	lastRun *SyncRunStats
+}
+
+// SyncRunStats counts the alerts handled by a connector's latest sync run
+type SyncRunStats struct {
+	Fetched    int `json:"fetched"`
+	Stored     int `json:"stored"`
+	Duplicates int `json:"duplicates"`
+	// LateArrivals are stored alerts created at or before the watermark the
+	// run started from, which only the look-back overlap could find
+	LateArrivals int `json:"late_arrivals"`
+}
+
+// add accumulates the counts of one stored batch or page
+func (r *SyncRunStats) add(o SyncRunStats) {
+	r.Fetched += o.Fetched
+	r.Stored += o.Stored
+	r.Duplicates += o.Duplicates
+	r.LateArrivals += o.LateArrivals
+}
+
+// ConnectorStatus is a snapshot of a connector's health
+type ConnectorStatus struct {
+	models.ConnectorState
+	Status       string        `json:"status"`
+	PollInterval string        `json:"poll_interval"`
+	Running      bool          `json:"running"`
+	Circuit      BreakerStatus `json:"circuit"`
+	Lookback     string        `json:"lookback"`
+	// LastRun is absent until the connector has completed a fetch since startup
+	LastRun *SyncRunStats `json:"last_run,omitempty"`
+}
+
+// tryStart marks the connector as running unless a sync is already in progress
+func (c *Connector) tryStart() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running {
+		return false
+	}
+	c.running = true
+	return true
+}
+
+func (c *Connector) finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = false
+}
+
+// due reports whether the poll interval has elapsed since the last attempt
+func (c *Connector) due(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running {
+		return false
+	}
+	return c.state.LastAttemptAt == nil || !now.Before(c.state.LastAttemptAt.Add(c.PollInterval))
+}
+
+// breaker returns the connector's circuit breaker, creating a default one on first use
+func (c *Connector) breaker() *CircuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Breaker == nil {
+		c.Breaker = NewCircuitBreaker(c.Name, BreakerConfig{})
+	}
+	return c.Breaker
+}
+
+// fetchFrom returns the time to fetch after: the watermark moved back by the
+// look-back overlap. A first sync still fetches everything.
+func (c *Connector) fetchFrom(watermark time.Time) time.Time {
+	if watermark.IsZero() || c.Lookback <= 0 {
+		return watermark
+	}
+	return watermark.Add(-c.Lookback)
+}
+
+// recordRun keeps the counts of a finished run for the status endpoint and metrics
+func (c *Connector) recordRun(run SyncRunStats) {
+	c.mu.Lock()
+	c.lastRun = &run
+	c.mu.Unlock()
+
+	metrics.SyncLateArrivals.WithLabelValues(c.Name).Add(float64(run.LateArrivals))
+	metrics.SyncDuplicatesSkipped.WithLabelValues(c.Name).Add(float64(run.Duplicates))
+}
+
+func (c *Connector) snapshot() models.ConnectorState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (c *Connector) update(fn func(state *models.ConnectorState)) models.ConnectorState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fn(&c.state)
+	c.state.Name = c.Name
+	c.state.UpdatedAt = time.Now()
+	return c.state
+}
+
+// SetConnectors replaces the upstream connectors synced by PerformSync
+func (s *AlertService) SetConnectors(connectors []*Connector) {
+	s.connectors = connectors
+}
+
+// connectorByName returns the configured connector called name, or nil
+func (s *AlertService) connectorByName(name string) *Connector {
+	for _, c := range s.connectors {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// SetConnectorStorage enables persisted per-connector watermarks and health.
+// Without it the watermark is the newest stored alert, shared by all connectors.
+func (s *AlertService) SetConnectorStorage(storage ConnectorStorageInterface) {
+	s.connectorStorage = storage
+}
+
+// SetSyncParallelism bounds how many connectors sync concurrently, across
+// manual, startup and scheduled syncs. Call it before syncing starts.
+func (s *AlertService) SetSyncParallelism(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.syncSlots = make(chan struct{}, n)
+}
+
+// SetSyncBatchSize sets how many synced alerts are inserted per transaction
+func (s *AlertService) SetSyncBatchSize(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.syncBatchSize = n
+}
+
+// ConnectorStatuses returns the health of every configured connector
+func (s *AlertService) ConnectorStatuses() []ConnectorStatus {
+	statuses := make([]ConnectorStatus, 0, len(s.connectors))
+	for _, c := range s.connectors {
+		c.mu.Lock()
+		state, running, lastRun := c.state, c.running, c.lastRun
+		c.mu.Unlock()
+
+		state.Name = c.Name
+		statuses = append(statuses, ConnectorStatus{
+			ConnectorState: state,
+			Status:         state.Status(),
+			PollInterval:   c.PollInterval.String(),
+			Running:        running,
+			Circuit:        c.breaker().Status(),
+			Lookback:       c.Lookback.String(),
+			LastRun:        lastRun,
+		})
+	}
+	return statuses
+}
+
+// Syncing reports whether any connector is syncing right now
+func (s *AlertService) Syncing() bool {
+	for _, c := range s.connectors {
+		c.mu.Lock()
+		running := c.running
+		c.mu.Unlock()
+		if running {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncDue syncs the connectors whose poll interval has elapsed and returns how many ran
+func (s *AlertService) SyncDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	var due []*Connector
+	for _, c := range s.connectors {
+		if c.due(now) {
+			due = append(due, c)
+		}
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	return len(due), s.syncConnectors(ctx, due)
+}
+
+// syncConnectors runs the connectors concurrently, bounded by the shared sync
+// slots. Connectors already syncing are skipped. A failing connector does not
+// stop the others; their errors are joined.
+func (s *AlertService) syncConnectors(ctx context.Context, connectors []*Connector) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for _, c := range connectors {
+		if !c.tryStart() {
+			log.Printf("[SYNC] %s: sync already in progress, skipping", c.Name)
+			continue
+		}
+
+		wg.Add(1)
+		go func(c *Connector) {
+			defer wg.Done()
+			defer c.finish()
+
+			s.syncSlots <- struct{}{}
+			defer func() { <-s.syncSlots }()
+
+			if err := s.syncConnector(ctx, c); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.Join(errs...)
+}
+
+// syncConnector fetches alerts newer than the connector's watermark, less
+// the look-back overlap, stores the ones not already stored and advances the
+// watermark to the newest stored alert. Nothing is fetched while the
+// connector's circuit is open or its health check fails.
+func (s *AlertService) syncConnector(ctx context.Context, c *Connector) error {
+	attemptAt := time.Now()
+	c.update(func(state *models.ConnectorState) { state.LastAttemptAt = &attemptAt })
+
+	breaker := c.breaker()
+	if !breaker.Allow() {
+		status := breaker.Status()
+		log.Printf("[SYNC] %s: circuit open, skipping sync until %s", c.Name, status.RetryAt.Format(time.RFC3339))
+		metrics.UpstreamSyncsSkipped.WithLabelValues(c.Name).Inc()
+		return fmt.Errorf("connector %s: %w", c.Name, ErrCircuitOpen)
+	}
+
+	if err := c.Client.CheckHealth(ctx); err != nil {
+		err = fmt.Errorf("connector %s: health check failed: %w", c.Name, err)
+		s.recordUpstreamFailure(ctx, c, err)
+		return err
+	}
+
+	lastSync := s.connectorWatermark(ctx, c)
+	log.Printf("[SYNC] %s: last sync time: %s", c.Name, lastSync.Format(time.RFC3339))
+
+	if paged, ok := c.Client.(PagedAPIClientInterface); ok {
+		return s.syncPages(ctx, c, paged, lastSync)
+	}
+
+	var externalAlerts []external.ExternalAlert
+	var err error
+	if lastSync.IsZero() {
+		log.Printf("[SYNC] %s: fetching all alerts (first sync)", c.Name)
+		externalAlerts, err = c.Client.FetchAllAlerts(ctx)
+	} else {
+		since := c.fetchFrom(lastSync)
+		log.Printf("[SYNC] %s: fetching alerts since: %s (look-back %s)", c.Name, since.Format(time.RFC3339), c.Lookback)
+		externalAlerts, err = c.Client.FetchAlertsSince(ctx, since)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("connector %s: failed to fetch alerts: %w", c.Name, err)
+		s.recordUpstreamFailure(ctx, c, err)
+		return err
+	}
+	breaker.Success()
+
+	log.Printf("[SYNC] %s: fetched %d alerts", c.Name, len(externalAlerts))
+
+	if len(externalAlerts) == 0 {
+		log.Printf("[SYNC] %s: no new alerts to sync", c.Name)
+		c.recordRun(SyncRunStats{})
+		s.recordSyncResult(ctx, c, nil, nil)
+		return nil
+	}
+
+	run, newest, err := s.storeAlerts(ctx, c, externalAlerts, lastSync, false)
+	c.recordRun(run)
+	s.recordSyncResult(ctx, c, &newest, err)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[SYNC] %s: successfully synced %d/%d alerts (%d duplicate, %d late)", c.Name, run.Stored, run.Fetched, run.Duplicates, run.LateArrivals)
+	return nil
+}
+
+// syncPages stores alerts a page at a time. After each page the watermark
+// and the cursor of the next page are checkpointed, so a run interrupted
+// mid-stream resumes from the page it stopped at instead of starting over.
+func (s *AlertService) syncPages(ctx context.Context, c *Connector, client PagedAPIClientInterface, lastSync time.Time) error {
+	cursor := c.snapshot().Cursor
+	since := c.fetchFrom(lastSync)
+	switch {
+	case cursor != "":
+		log.Printf("[SYNC] %s: resuming interrupted sync from saved cursor", c.Name)
+	case lastSync.IsZero():
+		log.Printf("[SYNC] %s: fetching all alerts (first sync)", c.Name)
+	default:
+		log.Printf("[SYNC] %s: fetching alerts since: %s (look-back %s)", c.Name, since.Format(time.RFC3339), c.Lookback)
+	}
+
+	pages := 0
+	var run SyncRunStats
+	var newest time.Time
+	err := client.FetchAlertPages(ctx, since, cursor, func(page external.AlertPage) error {
+		pageRun, pageNewest, err := s.storeAlerts(ctx, c, page.Alerts, lastSync, false)
+		run.add(pageRun)
+		if pageNewest.After(newest) {
+			newest = pageNewest
+		}
+		if err != nil {
+			// The page is fetched again on resume
+			return err
+		}
+		if page.Partial {
+			// The cursor is only known, and safe to save, once the whole page is stored
+			return nil
+		}
+
+		pages++
+		next := ""
+		if page.HasMore {
+			next = page.NextCursor
+		}
+		s.saveCheckpoint(ctx, c, newest, next)
+		return nil
+	})
+
+	c.recordRun(run)
+	if ctx.Err() != nil {
+		log.Printf("[SYNC] %s: sync interrupted after %d page(s)", c.Name, pages)
+		s.recordSyncResult(ctx, c, &newest, ctx.Err())
+		return ctx.Err()
+	}
+	if err != nil {
+		err = fmt.Errorf("connector %s: failed to fetch alerts: %w", c.Name, err)
+		s.recordUpstreamFailure(ctx, c, err)
+		return err
+	}
+	c.breaker().Success()
+
+	if run.Fetched == 0 {
+		log.Printf("[SYNC] %s: no new alerts to sync", c.Name)
+		s.recordSyncResult(ctx, c, nil, nil)
+		return nil
+	}
+	s.recordSyncResult(ctx, c, &newest, nil)
+
+	log.Printf("[SYNC] %s: successfully synced %d/%d alerts in %d page(s) (%d duplicate, %d late)", c.Name, run.Stored, run.Fetched, pages, run.Duplicates, run.LateArrivals)
+	return nil
+}
+
+// storeAlerts enriches and stores alerts in batches, in order, and returns
+// what was stored and the newest created_at among the stored alerts. Stored
+// alerts created at or before watermark count as late arrivals. Historical
+// alerts, such as backfilled ones, are stored without notifying live
+// subscribers or the pager. It stops with ctx's error when cancelled.
+func (s *AlertService) storeAlerts(ctx context.Context, c *Connector, alerts []external.ExternalAlert, watermark time.Time, historical bool) (SyncRunStats, time.Time, error) {
+	run := SyncRunStats{Fetched: len(alerts)}
+	var newest time.Time
+	for start := 0; start < len(alerts); start += s.syncBatchSize {
+		if ctx.Err() != nil {
+			log.Printf("[SYNC] %s: sync cancelled after processing %d alerts", c.Name, start)
+			return run, newest, ctx.Err()
+		}
+
+		end := min(start+s.syncBatchSize, len(alerts))
+		stored, duplicates := s.createAlerts(ctx, c, alerts[start:end], historical)
+		run.Duplicates += duplicates
+		for _, alert := range stored {
+			if alert.CreatedAt.After(newest) {
+				newest = alert.CreatedAt
+			}
+			if !watermark.IsZero() && !alert.CreatedAt.After(watermark) {
+				run.LateArrivals++
+			}
+			run.Stored++
+		}
+	}
+	return run, newest, nil
+}
+
+// createAlerts inserts a batch in one transaction and, unless the alerts are
+// historical, notifies subscribers and the pager of each stored alert. When
+// the batch fails its alerts are stored one at a time, so one bad alert does
+// not drop the others. Alerts that cannot be enriched or stored are
+// dead-lettered. Returns the stored alerts and how many were skipped as
+// already stored.
+func (s *AlertService) createAlerts(ctx context.Context, c *Connector, batch []external.ExternalAlert, historical bool) ([]models.Alert, int) {
+	alerts := make([]models.Alert, 0, len(batch))
+	sources := make([]external.ExternalAlert, 0, len(batch))
+	for _, extAlert := range batch {
+		alert, err := newAlert(extAlert)
+		if err != nil {
+			s.deadLetter(ctx, c, models.StageEnrichment, extAlert, historical, err)
+			continue
+		}
+		alerts = append(alerts, alert)
+		sources = append(sources, extAlert)
+	}
+	if len(alerts) == 0 {
+		return nil, 0
+	}
+
+	if err := s.storage.CreateAlerts(ctx, alerts); err != nil {
+		if ctx.Err() != nil {
+			return nil, 0
+		}
+		log.Printf("[SYNC] %s: batch of %d alerts failed, storing them one at a time: %v", c.Name, len(alerts), err)
+
+		var stored []models.Alert
+		for i, alert := range alerts {
+			if err := s.storage.CreateAlert(ctx, &alert); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				s.deadLetter(ctx, c, models.StageStorage, sources[i], historical, err)
+				continue
+			}
+			stored = append(stored, alert)
+		}
+		alerts = stored
+	}
+
+	// Alerts without an ID were already stored, by an earlier run or the overlap
+	created := alerts[:0]
+	duplicates := 0
+	for _, alert := range alerts {
+		if alert.ID == "" {
+			duplicates++
+			continue
+		}
+		if !historical {
+			s.notifyCreated(ctx, alert)
+		}
+		created = append(created, alert)
+	}
+	return created, duplicates
+}
+
+// deadLetter keeps an alert that failed at stage for retry, or logs it as
+// lost when no dead-letter queue is configured
+func (s *AlertService) deadLetter(ctx context.Context, c *Connector, stage string, extAlert external.ExternalAlert, historical bool, cause error) {
+	if s.deadLetters == nil {
+		log.Printf("[SYNC] %s: error storing alert: %v", c.Name, cause)
+		return
+	}
+	if err := s.deadLetters.Record(context.WithoutCancel(ctx), c.Name, stage, extAlert, historical, cause); err != nil {
+		log.Printf("[SYNC] %s: alert lost: %v (%v)", c.Name, cause, err)
+	}
+}
+
+// saveCheckpoint records progress within a paged sync: the watermark advances
+// to newest and cursor is where the next page starts, empty after the last page
+func (s *AlertService) saveCheckpoint(ctx context.Context, c *Connector, newest time.Time, cursor string) {
+	state := c.update(func(state *models.ConnectorState) {
+		advanceWatermark(state, newest)
+		state.Cursor = cursor
+	})
+
+	if s.connectorStorage == nil {
+		return
+	}
+	if err := s.connectorStorage.SaveConnectorState(context.WithoutCancel(ctx), &state); err != nil {
+		log.Printf("[SYNC] %s: warning: failed to save checkpoint: %v", c.Name, err)
+	}
+}
+
+// recordUpstreamFailure records a failed upstream call. Cancellation is not
+// the upstream's fault and does not count against its circuit.
+func (s *AlertService) recordUpstreamFailure(ctx context.Context, c *Connector, err error) {
+	if ctx.Err() == nil {
+		c.breaker().Failure()
+	}
+	s.recordSyncResult(ctx, c, nil, err)
+}
+
+// connectorWatermark returns the time to fetch from: the saved watermark, or
+// the newest stored alert for legacy and inheriting connectors. Without
+// connector storage, the watermark the connector reached in this process is
+// used once it has one, so connectors do not share the newest stored alert.
+func (s *AlertService) connectorWatermark(ctx context.Context, c *Connector) time.Time {
+	if s.connectorStorage == nil {
+		if watermark := c.snapshot().Watermark; watermark != nil {
+			return *watermark
+		}
+		lastSync, err := s.storage.GetLastSyncTime(ctx)
+		if err != nil {
+			log.Printf("[SYNC] Warning: Could not get last sync time: %v. Fetching all alerts.", err)
+			return time.Time{}
+		}
+		return lastSync
+	}
+
+	c.mu.Lock()
+	loaded := c.loaded
+	c.mu.Unlock()
+	if !loaded {
+		saved, err := s.connectorStorage.GetConnectorState(ctx, c.Name)
+		if err != nil {
+			log.Printf("[SYNC] %s: warning: could not load connector state: %v", c.Name, err)
+		} else {
+			c.mu.Lock()
+			if saved != nil {
+				lastAttempt := c.state.LastAttemptAt
+				c.state = *saved
+				c.state.LastAttemptAt = lastAttempt
+			}
+			c.loaded = true
+			c.mu.Unlock()
+		}
+	}
+
+	if watermark := c.snapshot().Watermark; watermark != nil {
+		return *watermark
+	}
+	if !c.InheritWatermark {
+		return time.Time{}
+	}
+
+	lastSync, err := s.storage.GetLastSyncTime(ctx)
+	if err != nil {
+		log.Printf("[SYNC] Warning: Could not get last sync time: %v. Fetching all alerts.", err)
+		return time.Time{}
+	}
+	return lastSync
+}
+
+// recordSyncResult updates the connector's health and, on success, advances
+// its watermark to newest, then persists the state when storage is configured
+func (s *AlertService) recordSyncResult(ctx context.Context, c *Connector, newest *time.Time, syncErr error) {
+	state := c.update(func(state *models.ConnectorState) {
+		if newest != nil {
+			advanceWatermark(state, *newest)
+		}
+		if syncErr != nil {
+			state.ConsecutiveFailures++
+			state.LastError = syncErr.Error()
+			return
+		}
+		now := time.Now()
+		state.LastSuccessAt = &now
+		state.ConsecutiveFailures = 0
+		state.LastError = ""
+	})
+
+	if s.connectorStorage == nil {
+		// Legacy single-watermark mode
+		if syncErr == nil && newest != nil {
+			if err := s.storage.UpdateLastSyncTime(ctx, time.Now()); err != nil {
+				log.Printf("[SYNC] Warning: Failed to update last sync time: %v", err)
+			}
+		}
+		return
+	}
+
+	// Persist even when the sync was cancelled so progress is not lost
+	if err := s.connectorStorage.SaveConnectorState(context.WithoutCancel(ctx), &state); err != nil {
+		log.Printf("[SYNC] %s: warning: failed to save connector state: %v", c.Name, err)
+	}
+}
+
+// advanceWatermark moves the watermark forward to newest, never back
+func advanceWatermark(state *models.ConnectorState, newest time.Time) {
+	if !newest.IsZero() && (state.Watermark == nil || newest.After(*state.Watermark)) {
+		state.Watermark = &newest
+	}
+}
@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingClient counts concurrent fetches and holds each one until released
+type blockingClient struct {
+	active, peak *int32
+	release      chan struct{}
+}
+
+func (c *blockingClient) CheckHealth(ctx context.Context) error { return nil }
+
+func (c *blockingClient) FetchAllAlerts(ctx context.Context) ([]external.ExternalAlert, error) {
+	n := atomic.AddInt32(c.active, 1)
+	for {
+		peak := atomic.LoadInt32(c.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(c.peak, peak, n) {
+			break
+		}
+	}
+	<-c.release
+	atomic.AddInt32(c.active, -1)
+	return nil, nil
+}
+
+func (c *blockingClient) FetchAlertsSince(ctx context.Context, since time.Time) ([]external.ExternalAlert, error) {
+	return c.FetchAllAlerts(ctx)
+}
+
+func TestAlertService_PerformSyncConnectors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("failing connector does not stop the others", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockConnectorStorage := mocks.NewConnectorStorageInterface(t)
+		failing := mocks.NewAPIClientInterface(t)
+		healthy := mocks.NewAPIClientInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetConnectorStorage(mockConnectorStorage)
+		service.SetConnectors([]*Connector{
+			{Name: "failing", Client: failing},
+			{Name: "healthy", Client: healthy},
+		})
+
+		createdAt := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		failing.On("CheckHealth", ctx).Return(nil)
+		failing.On("FetchAllAlerts", ctx).Return(nil, errors.New("connection refused"))
+		healthy.On("CheckHealth", ctx).Return(nil)
+		healthy.On("FetchAllAlerts", ctx).Return([]external.ExternalAlert{
+			{Source: "ids", Severity: "low", Description: "scan", CreatedAt: createdAt},
+		}, nil)
+		mockConnectorStorage.On("GetConnectorState", ctx, mock.Anything).Return(nil, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil)
+		mockConnectorStorage.On("SaveConnectorState", mock.Anything, mock.MatchedBy(func(state *models.ConnectorState) bool {
+			return state.Name == "failing" && state.ConsecutiveFailures == 1 && state.Watermark == nil
+		})).Return(nil).Once()
+		mockConnectorStorage.On("SaveConnectorState", mock.Anything, mock.MatchedBy(func(state *models.ConnectorState) bool {
+			return state.Name == "healthy" && state.ConsecutiveFailures == 0 && state.Watermark.Equal(createdAt)
+		})).Return(nil).Once()
+
+		err := service.PerformSync(ctx)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "connector failing: failed to fetch alerts")
+		statuses := map[string]string{}
+		for _, status := range service.ConnectorStatuses() {
+			statuses[status.Name] = status.Status
+		}
+		assert.Equal(t, map[string]string{"failing": models.ConnectorFailing, "healthy": models.ConnectorHealthy}, statuses)
+	})
+
+	t.Run("incremental sync uses the saved watermark", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockConnectorStorage := mocks.NewConnectorStorageInterface(t)
+		client := mocks.NewAPIClientInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetConnectorStorage(mockConnectorStorage)
+		service.SetConnectors([]*Connector{{Name: "siem", Client: client}})
+
+		watermark := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		newer := watermark.Add(time.Hour)
+		client.On("CheckHealth", ctx).Return(nil)
+		mockConnectorStorage.On("GetConnectorState", ctx, "siem").Return(&models.ConnectorState{Name: "siem", Watermark: &watermark}, nil).Once()
+		client.On("FetchAlertsSince", ctx, watermark).Return([]external.ExternalAlert{
+			{Source: "siem-1", Severity: "high", Description: "login", CreatedAt: newer},
+		}, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil)
+		mockConnectorStorage.On("SaveConnectorState", mock.Anything, mock.MatchedBy(func(state *models.ConnectorState) bool {
+			return state.Watermark.Equal(newer)
+		})).Return(nil)
+
+		assert.NoError(t, service.PerformSync(ctx))
+
+		// The second run continues from memory without reloading state
+		client.On("FetchAlertsSince", ctx, newer).Return([]external.ExternalAlert{}, nil)
+		assert.NoError(t, service.PerformSync(ctx))
+	})
+
+	t.Run("look-back overlap stores late arrivals and skips duplicates", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockConnectorStorage := mocks.NewConnectorStorageInterface(t)
+		client := mocks.NewAPIClientInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetConnectorStorage(mockConnectorStorage)
+		service.SetConnectors([]*Connector{{Name: "siem", Client: client, Lookback: 10 * time.Minute}})
+
+		watermark := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		client.On("CheckHealth", ctx).Return(nil)
+		mockConnectorStorage.On("GetConnectorState", ctx, "siem").Return(&models.ConnectorState{Name: "siem", Watermark: &watermark}, nil)
+		client.On("FetchAlertsSince", ctx, watermark.Add(-10*time.Minute)).Return([]external.ExternalAlert{
+			{Source: "siem-1", Severity: "high", Description: "already stored", CreatedAt: watermark.Add(-5 * time.Minute)},
+			{Source: "siem-1", Severity: "high", Description: "late", CreatedAt: watermark.Add(-2 * time.Minute)},
+			{Source: "siem-1", Severity: "high", Description: "new", CreatedAt: watermark.Add(time.Minute)},
+		}, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			for i, alert := range args.Get(1).([]models.Alert) {
+				if alert.Description != "already stored" {
+					args.Get(1).([]models.Alert)[i].ID = alert.Description
+				}
+			}
+		}).Return(nil)
+		mockConnectorStorage.On("SaveConnectorState", mock.Anything, mock.MatchedBy(func(state *models.ConnectorState) bool {
+			return state.Watermark.Equal(watermark.Add(time.Minute))
+		})).Return(nil)
+
+		require.NoError(t, service.PerformSync(ctx))
+
+		status := service.ConnectorStatuses()[0]
+		assert.Equal(t, "10m0s", status.Lookback)
+		assert.Equal(t, &SyncRunStats{Fetched: 3, Stored: 2, Duplicates: 1, LateArrivals: 1}, status.LastRun)
+	})
+
+	t.Run("inheriting connector starts from newest stored alert", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockConnectorStorage := mocks.NewConnectorStorageInterface(t)
+		client := mocks.NewAPIClientInterface(t)
+		service := NewAlertService(mockStorage, client)
+		service.SetConnectorStorage(mockConnectorStorage)
+
+		lastSync := time.Now().Add(-time.Hour)
+		client.On("CheckHealth", ctx).Return(nil)
+		mockConnectorStorage.On("GetConnectorState", ctx, "mock-api").Return(nil, nil)
+		mockStorage.On("GetLastSyncTime", ctx).Return(lastSync, nil)
+		client.On("FetchAlertsSince", ctx, lastSync).Return([]external.ExternalAlert{}, nil)
+		mockConnectorStorage.On("SaveConnectorState", mock.Anything, mock.Anything).Return(nil)
+
+		assert.NoError(t, service.PerformSync(ctx))
+	})
+
+	t.Run("without connector storage each connector continues from its own watermark", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		slow := mocks.NewAPIClientInterface(t)
+		fast := mocks.NewAPIClientInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetConnectors([]*Connector{
+			{Name: "slow", Client: slow},
+			{Name: "fast", Client: fast},
+		})
+
+		lastSync := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+		slowNewest := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		fastNewest := slowNewest.Add(time.Hour)
+		slow.On("CheckHealth", ctx).Return(nil)
+		fast.On("CheckHealth", ctx).Return(nil)
+		// Only the first sync of each connector falls back to the newest stored alert
+		mockStorage.On("GetLastSyncTime", ctx).Return(lastSync, nil).Twice()
+		slow.On("FetchAlertsSince", ctx, lastSync).Return([]external.ExternalAlert{
+			{Source: "ids", Severity: "low", Description: "scan", CreatedAt: slowNewest},
+		}, nil).Once()
+		fast.On("FetchAlertsSince", ctx, lastSync).Return([]external.ExternalAlert{
+			{Source: "firewall", Severity: "low", Description: "probe", CreatedAt: fastNewest},
+		}, nil).Once()
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil)
+		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
+
+		require.NoError(t, service.PerformSync(ctx))
+
+		slow.On("FetchAlertsSince", ctx, slowNewest).Return([]external.ExternalAlert{}, nil).Once()
+		fast.On("FetchAlertsSince", ctx, fastNewest).Return([]external.ExternalAlert{}, nil).Once()
+		require.NoError(t, service.PerformSync(ctx))
+	})
+}
+
+func TestAlertService_SyncParallelism(t *testing.T) {
+	var active, peak int32
+	release := make(chan struct{})
+	service := NewAlertService(mocks.NewAlertStorageInterface(t), nil)
+	service.SetSyncParallelism(2)
+
+	var connectors []*Connector
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		connectors = append(connectors, &Connector{
+			Name:   name,
+			Client: &blockingClient{active: &active, peak: &peak, release: release},
+		})
+	}
+	service.SetConnectors(connectors)
+	service.SetConnectorStorage(noopConnectorStorage{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, service.PerformSync(context.Background()))
+	}()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&active) == 2 }, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&peak))
+}
+
+func TestAlertService_SyncDue(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewAPIClientInterface(t)
+	service := NewAlertService(mocks.NewAlertStorageInterface(t), nil)
+	service.SetConnectorStorage(noopConnectorStorage{})
+	service.SetConnectors([]*Connector{{Name: "siem", Client: client, PollInterval: time.Hour}})
+
+	client.On("CheckHealth", ctx).Return(nil)
+	client.On("FetchAllAlerts", ctx).Return([]external.ExternalAlert{}, nil).Once()
+
+	ran, err := service.SyncDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ran)
+
+	ran, err = service.SyncDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, ran, "not due again until the poll interval elapses")
+}
+
+func TestAlertService_CircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	client := mocks.NewAPIClientInterface(t)
+	service := NewAlertService(mocks.NewAlertStorageInterface(t), nil)
+	service.SetConnectorStorage(noopConnectorStorage{})
+	breaker := NewCircuitBreaker("siem", BreakerConfig{FailureThreshold: 2, CoolDown: time.Minute})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	service.SetConnectors([]*Connector{{Name: "siem", Client: client, Breaker: breaker}})
+
+	client.On("CheckHealth", ctx).Return(errors.New("connection refused")).Twice()
+
+	// Failed health checks count against the circuit and nothing is fetched
+	require.Error(t, service.PerformSync(ctx))
+	require.Error(t, service.PerformSync(ctx))
+	assert.Equal(t, CircuitOpen, service.ConnectorStatuses()[0].Circuit.State)
+
+	// While open the upstream is not called at all
+	err := service.PerformSync(ctx)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	client.AssertNumberOfCalls(t, "CheckHealth", 2)
+
+	// After the cool-down a successful trial closes the circuit
+	now = now.Add(time.Minute)
+	client.On("CheckHealth", ctx).Return(nil).Once()
+	client.On("FetchAllAlerts", ctx).Return([]external.ExternalAlert{}, nil).Once()
+
+	require.NoError(t, service.PerformSync(ctx))
+	status := service.ConnectorStatuses()[0]
+	assert.Equal(t, CircuitClosed, status.Circuit.State)
+	assert.Equal(t, models.ConnectorHealthy, status.Status)
+}
+
+// noopConnectorStorage has no saved state and discards updates
+type noopConnectorStorage struct{}
+
+func (noopConnectorStorage) GetConnectorState(ctx context.Context, name string) (*models.ConnectorState, error) {
+	return nil, nil
+}
+
+func (noopConnectorStorage) SaveConnectorState(ctx context.Context, state *models.ConnectorState) error {
+	return nil
+}
+
+// servePages makes FetchAlertPages hand the pages to the sync one by one
+func servePages(client *mocks.PagedAPIClientInterface, ctx context.Context, since time.Time, cursor string, pages ...external.AlertPage) *mock.Call {
+	return client.On("FetchAlertPages", ctx, since, cursor, mock.Anything).
+		Return(func(ctx context.Context, since time.Time, cursor string, handle external.PageHandler) error {
+			for _, page := range pages {
+				if err := handle(page); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func TestAlertService_SyncPages(t *testing.T) {
+	first := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	pages := []external.AlertPage{
+		{Alerts: []external.ExternalAlert{{Source: "ids", Severity: "low", Description: "a", CreatedAt: first}}, NextCursor: "c1", HasMore: true},
+		{Alerts: []external.ExternalAlert{{Source: "ids", Severity: "low", Description: "b", CreatedAt: second}}},
+	}
+
+	t.Run("checkpoints after every page", func(t *testing.T) {
+		ctx := context.Background()
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockConnectorStorage := mocks.NewConnectorStorageInterface(t)
+		client := mocks.NewPagedAPIClientInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetConnectorStorage(mockConnectorStorage)
+		service.SetConnectors([]*Connector{{Name: "siem", Client: client}})
+
+		client.On("CheckHealth", ctx).Return(nil)
+		servePages(client, ctx, time.Time{}, "", pages...)
+		mockConnectorStorage.On("GetConnectorState", ctx, "siem").Return(nil, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil).Twice()
+
+		var saved []models.ConnectorState
+		mockConnectorStorage.On("SaveConnectorState", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = append(saved, *args.Get(1).(*models.ConnectorState))
+		}).Return(nil)
+
+		require.NoError(t, service.PerformSync(ctx))
+
+		require.Len(t, saved, 3)
+		assert.Equal(t, "c1", saved[0].Cursor)
+		assert.True(t, saved[0].Watermark.Equal(first))
+		assert.Equal(t, "", saved[1].Cursor)
+		assert.True(t, saved[1].Watermark.Equal(second))
+		assert.NotNil(t, saved[2].LastSuccessAt)
+	})
+
+	t.Run("interrupted sync keeps the cursor of the unfinished page", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		client := mocks.NewPagedAPIClientInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetConnectorStorage(noopConnectorStorage{})
+		connector := &Connector{Name: "siem", Client: client}
+		service.SetConnectors([]*Connector{connector})
+
+		client.On("CheckHealth", ctx).Return(nil)
+		client.On("FetchAlertPages", ctx, time.Time{}, "", mock.Anything).
+			Return(func(ctx context.Context, since time.Time, cursor string, handle external.PageHandler) error {
+				if err := handle(pages[0]); err != nil {
+					return err
+				}
+				// Shut down before the second page is stored
+				cancel()
+				return handle(pages[1])
+			})
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil).Once()
+
+		err := service.PerformSync(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		state := connector.snapshot()
+		assert.Equal(t, "c1", state.Cursor)
+		assert.True(t, state.Watermark.Equal(first))
+		assert.Equal(t, 1, state.ConsecutiveFailures)
+	})
+
+	t.Run("partial pages are stored without a checkpoint", func(t *testing.T) {
+		ctx := context.Background()
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockConnectorStorage := mocks.NewConnectorStorageInterface(t)
+		client := mocks.NewPagedAPIClientInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetConnectorStorage(mockConnectorStorage)
+		service.SetConnectors([]*Connector{{Name: "siem", Client: client}})
+
+		chunk := pages[0]
+		chunk.NextCursor, chunk.HasMore, chunk.Partial = "", false, true
+
+		client.On("CheckHealth", ctx).Return(nil)
+		servePages(client, ctx, time.Time{}, "", chunk, pages[1])
+		mockConnectorStorage.On("GetConnectorState", ctx, "siem").Return(nil, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil).Twice()
+
+		var saved []models.ConnectorState
+		mockConnectorStorage.On("SaveConnectorState", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			saved = append(saved, *args.Get(1).(*models.ConnectorState))
+		}).Return(nil)
+
+		require.NoError(t, service.PerformSync(ctx))
+
+		// One checkpoint for the whole page, then the sync result
+		require.Len(t, saved, 2)
+		assert.Equal(t, "", saved[0].Cursor)
+		assert.True(t, saved[0].Watermark.Equal(second))
+	})
+
+	t.Run("resumes from the saved cursor", func(t *testing.T) {
+		ctx := context.Background()
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockConnectorStorage := mocks.NewConnectorStorageInterface(t)
+		client := mocks.NewPagedAPIClientInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetConnectorStorage(mockConnectorStorage)
+		service.SetConnectors([]*Connector{{Name: "siem", Client: client}})
+
+		watermark := first
+		mockConnectorStorage.On("GetConnectorState", ctx, "siem").Return(&models.ConnectorState{Name: "siem", Watermark: &watermark, Cursor: "c1"}, nil)
+		client.On("CheckHealth", ctx).Return(nil)
+		servePages(client, ctx, first, "c1", pages[1])
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil).Once()
+		mockConnectorStorage.On("SaveConnectorState", mock.Anything, mock.Anything).Return(nil)
+
+		require.NoError(t, service.PerformSync(ctx))
+
+		assert.Equal(t, "", service.ConnectorStatuses()[0].Cursor)
+		assert.True(t, service.ConnectorStatuses()[0].Watermark.Equal(second))
+	})
+}
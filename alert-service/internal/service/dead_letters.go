@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+)
+
+// Dead-letter retry defaults
+const (
+	DefaultDeadLetterMaxAttempts = 5
+	DefaultDeadLetterBaseDelay   = time.Minute
+	DefaultDeadLetterMaxDelay    = time.Hour
+)
+
+const (
+	// deadLetterBatchSize caps how many due dead letters are retried per tick
+	deadLetterBatchSize = 50
+
+	// deadLetterLease is how long a claimed dead letter is held before another tick may retry it
+	deadLetterLease = 2 * time.Minute
+)
+
+var (
+	// ErrDeadLetterNotFound is returned when a retry targets an unknown dead letter
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	// ErrDeadLetterResolved is returned when a retry targets a dead letter that was already stored
+	ErrDeadLetterResolved = errors.New("dead letter already resolved")
+)
+
+// DeadLetterPolicy controls automatic retries of dead-lettered alerts
+type DeadLetterPolicy struct {
+	// MaxAttempts caps the attempts per alert, including the one that failed during sync
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles with every attempt up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// deadLetterPayload is the stored form of a failed upstream alert
+type deadLetterPayload struct {
+	Source      string            `json:"source"`
+	Severity    string            `json:"severity"`
+	Description string            `json:"description"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Raw         json.RawMessage   `json:"raw,omitempty"`
+	Indicators  map[string]string `json:"indicators,omitempty"`
+	// Historical alerts, such as backfilled ones, are stored without notifications
+	Historical bool `json:"historical,omitempty"`
+}
+
+// DeadLetterService keeps upstream alerts that failed enrichment or storage
+// and retries them with exponential backoff until they are stored or run out
+// of attempts. All state lives in storage, so pending retries survive restarts.
+type DeadLetterService struct {
+	storage DeadLetterStorageInterface
+	alerts  *AlertService
+	policy  DeadLetterPolicy
+	now     func() time.Time
+}
+
+// NewDeadLetterService creates a dead-letter service that stores retried
+// alerts through alerts. Pass it to AlertService.SetDeadLetters to record failures.
+func NewDeadLetterService(storage DeadLetterStorageInterface, alerts *AlertService, policy DeadLetterPolicy) *DeadLetterService {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultDeadLetterMaxAttempts
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultDeadLetterBaseDelay
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = max(DefaultDeadLetterMaxDelay, policy.BaseDelay)
+	}
+
+	return &DeadLetterService{
+		storage: storage,
+		alerts:  alerts,
+		policy:  policy,
+		now:     time.Now,
+	}
+}
+
+// Record dead-letters an alert from connector that failed at stage. A
+// historical alert is stored without notifications when it is retried.
+func (d *DeadLetterService) Record(ctx context.Context, connector, stage string, extAlert external.ExternalAlert, historical bool, cause error) error {
+	payload, err := encodeDeadLetterPayload(extAlert, historical)
+	if err != nil {
+		return fmt.Errorf("dead letter: error encoding payload: %w", err)
+	}
+
+	dl := models.DeadLetter{
+		Connector: connector,
+		Stage:     stage,
+		Payload:   payload,
+		Error:     cause.Error(),
+		Attempts:  1,
+		Status:    models.DeadLetterPending,
+	}
+	d.schedule(&dl)
+
+	if err := d.storage.CreateDeadLetter(ctx, &dl); err != nil {
+		return fmt.Errorf("dead letter: error recording alert: %w", err)
+	}
+
+	metrics.DeadLettersRecorded.WithLabelValues(stage).Inc()
+	log.Printf("[DEADLETTER] %s: alert failed at %s, retrying at %s: %v", connector, stage, dl.NextAttemptAt.Format(time.RFC3339), cause)
+	return nil
+}
+
+// List retrieves dead letters matching the filter, newest first
+func (d *DeadLetterService) List(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error) {
+	deadLetters, err := d.storage.ListDeadLetters(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter: error listing dead letters: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// Retry reprocesses one dead letter straight away, whatever its schedule or
+// remaining attempts, and returns its new state
+func (d *DeadLetterService) Retry(ctx context.Context, id string) (*models.DeadLetter, error) {
+	dl, err := d.storage.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("dead letter: %w: %v", ErrDeadLetterNotFound, err)
+	}
+	if dl == nil {
+		return nil, fmt.Errorf("dead letter: %w", ErrDeadLetterNotFound)
+	}
+	if dl.Status == models.DeadLetterResolved {
+		return nil, fmt.Errorf("dead letter: %w", ErrDeadLetterResolved)
+	}
+
+	if err := d.reprocess(ctx, dl); err != nil {
+		return nil, err
+	}
+	return dl, nil
+}
+
+// RetryAll makes every unresolved dead letter matching the filter due now,
+// exhausted ones included. The reprocessor picks them up on its next tick.
+func (d *DeadLetterService) RetryAll(ctx context.Context, filter models.DeadLetterFilter) (int, error) {
+	n, err := d.storage.RequeueDeadLetters(ctx, filter, d.now())
+	if err != nil {
+		return 0, fmt.Errorf("dead letter: error requeueing dead letters: %w", err)
+	}
+
+	log.Printf("[DEADLETTER] Requeued %d dead letter(s)", n)
+	return n, nil
+}
+
+// ProcessDue retries every pending dead letter whose backoff has elapsed.
+// Returns the number of dead letters handled.
+func (d *DeadLetterService) ProcessDue(ctx context.Context) (int, error) {
+	now := d.now()
+	due, err := d.storage.ClaimDueDeadLetters(ctx, now, now.Add(deadLetterLease), deadLetterBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("dead letter: error claiming due dead letters: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		if err := d.reprocess(ctx, &due[i]); err != nil {
+			log.Printf("[DEADLETTER] Error retrying dead letter %s: %v", due[i].ID, err)
+		}
+	}
+
+	return len(due), nil
+}
+
+// reprocess enriches and stores a dead-lettered alert and records the outcome.
+// Only failing to save that outcome is returned as an error.
+func (d *DeadLetterService) reprocess(ctx context.Context, dl *models.DeadLetter) error {
+	dl.Attempts++
+
+	alert, stage, err := d.store(ctx, dl.Payload)
+	if err != nil {
+		dl.Stage = stage
+		dl.Error = err.Error()
+		if dl.Attempts >= d.policy.MaxAttempts {
+			dl.Status = models.DeadLetterExhausted
+			log.Printf("[DEADLETTER] %s: giving up on dead letter %s after %d attempts: %v", dl.Connector, dl.ID, dl.Attempts, err)
+		} else {
+			dl.Status = models.DeadLetterPending
+			d.schedule(dl)
+		}
+		metrics.DeadLetterRetries.WithLabelValues("failed").Inc()
+	} else {
+		dl.Status = models.DeadLetterResolved
+		dl.Error = ""
+		metrics.DeadLetterRetries.WithLabelValues("stored").Inc()
+		if alert.ID == "" {
+			// A later sync already stored the alert
+			dl.AlertID = nil
+			log.Printf("[DEADLETTER] %s: dead letter %s was already stored, resolving it", dl.Connector, dl.ID)
+		} else {
+			dl.AlertID = &alert.ID
+			log.Printf("[DEADLETTER] %s: stored dead letter %s as alert %s after %d attempts", dl.Connector, dl.ID, alert.ID, dl.Attempts)
+		}
+	}
+
+	if err := d.storage.UpdateDeadLetter(context.WithoutCancel(ctx), dl); err != nil {
+		return fmt.Errorf("dead letter: %w", err)
+	}
+	return nil
+}
+
+// store decodes, enriches and stores a payload, returning the stage that failed
+func (d *DeadLetterService) store(ctx context.Context, payload json.RawMessage) (models.Alert, string, error) {
+	var p deadLetterPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return models.Alert{}, models.StageEnrichment, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	alert, err := newAlert(external.ExternalAlert{
+		Source:      p.Source,
+		Severity:    p.Severity,
+		Description: p.Description,
+		CreatedAt:   p.CreatedAt,
+		Raw:         p.Raw,
+		Indicators:  p.Indicators,
+	})
+	if err != nil {
+		return alert, models.StageEnrichment, err
+	}
+
+	if err := d.alerts.storage.CreateAlert(ctx, &alert); err != nil {
+		return alert, models.StageStorage, err
+	}
+
+	if alert.ID != "" && !p.Historical {
+		d.alerts.notifyCreated(ctx, alert)
+	}
+	return alert, "", nil
+}
+
+// schedule sets the next attempt after the backoff for dl's attempt count
+func (d *DeadLetterService) schedule(dl *models.DeadLetter) {
+	delay := d.policy.BaseDelay
+	for i := 1; i < dl.Attempts && delay < d.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	dl.NextAttemptAt = d.now().Add(min(delay, d.policy.MaxDelay))
+}
+
+// encodeDeadLetterPayload keeps everything needed to enrich the alert again.
+// A raw record that is not valid JSON is kept as a JSON string.
+func encodeDeadLetterPayload(extAlert external.ExternalAlert, historical bool) (json.RawMessage, error) {
+	raw := extAlert.Raw
+	if len(raw) > 0 && !json.Valid(raw) {
+		quoted, err := json.Marshal(string(raw))
+		if err != nil {
+			return nil, err
+		}
+		raw = quoted
+	}
+
+	return json.Marshal(deadLetterPayload{
+		Source:      extAlert.Source,
+		Severity:    extAlert.Severity,
+		Description: extAlert.Description,
+		CreatedAt:   extAlert.CreatedAt,
+		Raw:         raw,
+		Indicators:  extAlert.Indicators,
+		Historical:  historical,
+	})
+}
+
+// Run retries due dead letters every interval until ctx is cancelled
+func (d *DeadLetterService) Run(ctx context.Context, interval time.Duration) {
+	log.Printf("[DEADLETTER] Starting dead-letter reprocessor every %s", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[DEADLETTER] Stopping dead-letter reprocessor")
+			return
+		case <-ticker.C:
+			if _, err := d.ProcessDue(ctx); err != nil {
+				log.Printf("[DEADLETTER] %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDeadLetterService(t *testing.T, now time.Time) (*DeadLetterService, *mocks.DeadLetterStorageInterface, *mocks.AlertStorageInterface) {
+	dlStorage := mocks.NewDeadLetterStorageInterface(t)
+	alertStorage := mocks.NewAlertStorageInterface(t)
+
+	d := NewDeadLetterService(dlStorage, NewAlertService(alertStorage, nil), DeadLetterPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    90 * time.Second,
+	})
+	d.now = func() time.Time { return now }
+	return d, dlStorage, alertStorage
+}
+
+func deadLetterPayloadFor(t *testing.T, extAlert external.ExternalAlert) json.RawMessage {
+	payload, err := encodeDeadLetterPayload(extAlert, false)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestDeadLetterService_Record(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("keeps the payload and schedules the first retry", func(t *testing.T) {
+		d, dlStorage, _ := newTestDeadLetterService(t, now)
+		extAlert := external.ExternalAlert{
+			Source: "firewall", Severity: "high", Description: "scan", CreatedAt: now,
+			Raw:        json.RawMessage(`{"sev":"P2"}`),
+			Indicators: map[string]string{"src_ip": "192.0.2.1"},
+		}
+
+		var recorded models.DeadLetter
+		dlStorage.On("CreateDeadLetter", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			recorded = *args.Get(1).(*models.DeadLetter)
+		}).Return(nil)
+
+		require.NoError(t, d.Record(ctx, "siem", models.StageStorage, extAlert, false, errors.New("value too long")))
+
+		assert.Equal(t, "siem", recorded.Connector)
+		assert.Equal(t, models.StageStorage, recorded.Stage)
+		assert.Equal(t, "value too long", recorded.Error)
+		assert.Equal(t, 1, recorded.Attempts)
+		assert.Equal(t, models.DeadLetterPending, recorded.Status)
+		assert.Equal(t, now.Add(time.Minute), recorded.NextAttemptAt)
+		assert.JSONEq(t, `{"source":"firewall","severity":"high","description":"scan","created_at":"2025-01-10T12:00:00Z",
+			"raw":{"sev":"P2"},"indicators":{"src_ip":"192.0.2.1"}}`, string(recorded.Payload))
+	})
+
+	t.Run("keeps an invalid raw record as a string", func(t *testing.T) {
+		payload := deadLetterPayloadFor(t, external.ExternalAlert{Source: "ids", Raw: json.RawMessage(`{broken`)})
+
+		var decoded deadLetterPayload
+		require.NoError(t, json.Unmarshal(payload, &decoded))
+		assert.Equal(t, `"{broken"`, string(decoded.Raw))
+	})
+}
+
+func TestDeadLetterService_ProcessDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	payload := func(t *testing.T) json.RawMessage {
+		return deadLetterPayloadFor(t, external.ExternalAlert{Source: "firewall", Severity: "critical", Description: "scan", CreatedAt: now})
+	}
+
+	t.Run("stores the alert and resolves the dead letter", func(t *testing.T) {
+		d, dlStorage, alertStorage := newTestDeadLetterService(t, now)
+		mockPublisher := mocks.NewEventPublisher(t)
+		d.alerts.SetPublisher(mockPublisher)
+
+		dlStorage.On("ClaimDueDeadLetters", ctx, now, now.Add(deadLetterLease), deadLetterBatchSize).
+			Return([]models.DeadLetter{{ID: "dl-1", Connector: "siem", Stage: models.StageStorage, Payload: payload(t), Attempts: 1, Status: models.DeadLetterPending}}, nil)
+		alertStorage.On("CreateAlert", ctx, mock.MatchedBy(func(alert *models.Alert) bool {
+			return alert.Description == "scan" && alert.Fingerprint == models.Fingerprint("firewall", "critical", "scan", now)
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Alert).ID = "alert-1"
+		}).Return(nil)
+		mockPublisher.On("Publish", models.EventAlertCreated, mock.Anything).Return(models.AlertEvent{ID: 1})
+		dlStorage.On("UpdateDeadLetter", mock.Anything, mock.MatchedBy(func(dl *models.DeadLetter) bool {
+			return dl.Status == models.DeadLetterResolved && dl.Attempts == 2 && *dl.AlertID == "alert-1" && dl.Error == ""
+		})).Return(nil)
+
+		n, err := d.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("stores a historical alert without publishing it", func(t *testing.T) {
+		d, dlStorage, alertStorage := newTestDeadLetterService(t, now)
+		mockPublisher := mocks.NewEventPublisher(t)
+		d.alerts.SetPublisher(mockPublisher)
+		historical, err := encodeDeadLetterPayload(external.ExternalAlert{Source: "firewall", Severity: "critical", Description: "scan", CreatedAt: now}, true)
+		require.NoError(t, err)
+
+		dlStorage.On("ClaimDueDeadLetters", ctx, now, now.Add(deadLetterLease), deadLetterBatchSize).
+			Return([]models.DeadLetter{{ID: "dl-1", Payload: historical, Attempts: 1, Status: models.DeadLetterPending}}, nil)
+		alertStorage.On("CreateAlert", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Alert).ID = "alert-1"
+		}).Return(nil)
+		dlStorage.On("UpdateDeadLetter", mock.Anything, mock.MatchedBy(func(dl *models.DeadLetter) bool {
+			return dl.Status == models.DeadLetterResolved
+		})).Return(nil)
+
+		_, err = d.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		mockPublisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed retry backs off", func(t *testing.T) {
+		d, dlStorage, alertStorage := newTestDeadLetterService(t, now)
+
+		dlStorage.On("ClaimDueDeadLetters", ctx, now, now.Add(deadLetterLease), deadLetterBatchSize).
+			Return([]models.DeadLetter{{ID: "dl-1", Payload: payload(t), Attempts: 1, Status: models.DeadLetterPending}}, nil)
+		alertStorage.On("CreateAlert", ctx, mock.Anything).Return(errors.New("connection refused"))
+		dlStorage.On("UpdateDeadLetter", mock.Anything, mock.MatchedBy(func(dl *models.DeadLetter) bool {
+			// The delay doubles to two minutes but is capped at MaxDelay
+			return dl.Status == models.DeadLetterPending && dl.Attempts == 2 &&
+				dl.Error == "connection refused" && dl.NextAttemptAt.Equal(now.Add(90*time.Second))
+		})).Return(nil)
+
+		_, err := d.ProcessDue(ctx)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		d, dlStorage, alertStorage := newTestDeadLetterService(t, now)
+
+		dlStorage.On("ClaimDueDeadLetters", ctx, now, now.Add(deadLetterLease), deadLetterBatchSize).
+			Return([]models.DeadLetter{{ID: "dl-1", Payload: payload(t), Attempts: 2, Status: models.DeadLetterPending}}, nil)
+		alertStorage.On("CreateAlert", ctx, mock.Anything).Return(errors.New("connection refused"))
+		dlStorage.On("UpdateDeadLetter", mock.Anything, mock.MatchedBy(func(dl *models.DeadLetter) bool {
+			return dl.Status == models.DeadLetterExhausted && dl.Attempts == 3
+		})).Return(nil)
+
+		_, err := d.ProcessDue(ctx)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("claim error", func(t *testing.T) {
+		d, dlStorage, _ := newTestDeadLetterService(t, now)
+
+		dlStorage.On("ClaimDueDeadLetters", ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db down"))
+
+		_, err := d.ProcessDue(ctx)
+
+		assert.ErrorContains(t, err, "db down")
+	})
+}
+
+func TestDeadLetterService_Retry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("retries an exhausted dead letter on demand", func(t *testing.T) {
+		d, dlStorage, alertStorage := newTestDeadLetterService(t, now)
+		payload := deadLetterPayloadFor(t, external.ExternalAlert{Source: "ids", Severity: "low", Description: "scan", CreatedAt: now})
+
+		dlStorage.On("GetDeadLetter", ctx, "dl-1").Return(&models.DeadLetter{ID: "dl-1", Payload: payload, Attempts: 3, Status: models.DeadLetterExhausted}, nil)
+		alertStorage.On("CreateAlert", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Alert).ID = "alert-1"
+		}).Return(nil)
+		dlStorage.On("UpdateDeadLetter", mock.Anything, mock.Anything).Return(nil)
+
+		dl, err := d.Retry(ctx, "dl-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.DeadLetterResolved, dl.Status)
+		assert.Equal(t, 4, dl.Attempts)
+	})
+
+	t.Run("unknown dead letter", func(t *testing.T) {
+		d, dlStorage, _ := newTestDeadLetterService(t, now)
+
+		dlStorage.On("GetDeadLetter", ctx, "missing").Return(nil, nil)
+
+		_, err := d.Retry(ctx, "missing")
+
+		assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+	})
+
+	t.Run("resolved dead letter", func(t *testing.T) {
+		d, dlStorage, _ := newTestDeadLetterService(t, now)
+
+		dlStorage.On("GetDeadLetter", ctx, "dl-1").Return(&models.DeadLetter{ID: "dl-1", Status: models.DeadLetterResolved}, nil)
+
+		_, err := d.Retry(ctx, "dl-1")
+
+		assert.ErrorIs(t, err, ErrDeadLetterResolved)
+	})
+
+	t.Run("bulk retry requeues matching dead letters", func(t *testing.T) {
+		d, dlStorage, _ := newTestDeadLetterService(t, now)
+		filter := models.DeadLetterFilter{Connector: "siem"}
+
+		dlStorage.On("RequeueDeadLetters", ctx, filter, now).Return(4, nil)
+
+		n, err := d.RetryAll(ctx, filter)
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, n)
+	})
+}
+
+func TestAlertService_DeadLettersFailedAlerts(t *testing.T) {
+	ctx := context.Background()
+	mockStorage := mocks.NewAlertStorageInterface(t)
+	mockClient := mocks.NewAPIClientInterface(t)
+	dlStorage := mocks.NewDeadLetterStorageInterface(t)
+	service := NewAlertService(mockStorage, mockClient)
+	service.SetDeadLetters(NewDeadLetterService(dlStorage, service, DeadLetterPolicy{}))
+
+	createdAt := time.Now()
+	externalAlerts := []external.ExternalAlert{
+		{Source: "ids", Severity: "low", Description: "unparseable", CreatedAt: createdAt, Raw: json.RawMessage(`{broken`)},
+		{Source: "ids", Severity: "low", Description: "too long", CreatedAt: createdAt},
+		{Source: "ids", Severity: "low", Description: "fine", CreatedAt: createdAt},
+	}
+
+	mockClient.On("CheckHealth", ctx).Return(nil)
+	mockStorage.On("GetLastSyncTime", ctx).Return(time.Time{}, nil)
+	mockClient.On("FetchAllAlerts", ctx).Return(externalAlerts, nil)
+	mockStorage.On("CreateAlerts", ctx, mock.Anything).Return(errors.New("value too long"))
+	mockStorage.On("CreateAlert", ctx, mock.MatchedBy(func(alert *models.Alert) bool {
+		return alert.Description == "too long"
+	})).Return(errors.New("value too long"))
+	mockStorage.On("CreateAlert", ctx, mock.MatchedBy(func(alert *models.Alert) bool {
+		return alert.Description == "fine"
+	})).Run(assignID).Return(nil)
+	mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
+
+	var stages []string
+	dlStorage.On("CreateDeadLetter", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stages = append(stages, args.Get(1).(*models.DeadLetter).Stage)
+	}).Return(nil)
+
+	require.NoError(t, service.PerformSync(ctx))
+
+	assert.Equal(t, []string{models.StageEnrichment, models.StageStorage}, stages)
+}
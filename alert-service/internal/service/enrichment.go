@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"censys_alert_system/internal/models"
+)
+
+// EnrichmentVersion is the version of the enrichment pipeline. Bump it when
+// an enricher is added or its output changes, and set that enricher's since
+// to the new version so re-enrichment can find the alerts it has not seen.
+const EnrichmentVersion = 1
+
+// enricher fills one enrichment field of an alert
+type enricher struct {
+	field string
+	// since is the pipeline version that introduced the enricher's current output
+	since  int
+	enrich func(alert *models.Alert, indicators map[string]string)
+}
+
+// enrichers is the enrichment pipeline, run in order
+var enrichers = []enricher{
+	{field: "enrichment_type", since: 1, enrich: enrichType},
+	{field: "ip_address", since: 1, enrich: enrichIPAddress},
+}
+
+// EnrichmentFields lists the fields the enrichment pipeline produces
+func EnrichmentFields() []string {
+	fields := make([]string, len(enrichers))
+	for i, e := range enrichers {
+		fields[i] = e.field
+	}
+	return fields
+}
+
+// enrich runs the enrichers for fields, or all of them when fields is empty,
+// and records EnrichmentVersion against every field it writes
+func enrich(alert *models.Alert, indicators map[string]string, fields []string) {
+	for _, e := range enrichers {
+		if len(fields) > 0 && !slices.Contains(fields, e.field) {
+			continue
+		}
+		e.enrich(alert, indicators)
+		if alert.EnrichmentVersions == nil {
+			alert.EnrichmentVersions = make(map[string]int)
+		}
+		alert.EnrichmentVersions[e.field] = EnrichmentVersion
+	}
+}
+
+// staleEnrichment returns the fields among fields (all when empty) whose
+// stored output predates their enricher's current version
+func staleEnrichment(alert models.Alert, fields []string) []string {
+	var stale []string
+	for _, e := range enrichers {
+		if len(fields) > 0 && !slices.Contains(fields, e.field) {
+			continue
+		}
+		if alert.EnrichmentVersions[e.field] < e.since {
+			stale = append(stale, e.field)
+		}
+	}
+	return stale
+}
+
+// storedIndicators recovers the upstream indicators kept in an alert's whole_event
+func storedIndicators(alert models.Alert) (map[string]string, error) {
+	var event struct {
+		Indicators map[string]string `json:"indicators"`
+	}
+	if err := json.Unmarshal(alert.WholeEvent, &event); err != nil {
+		return nil, fmt.Errorf("invalid whole_event: %w", err)
+	}
+	return event.Indicators, nil
+}
+
+func enrichType(alert *models.Alert, _ map[string]string) {
+	enrichmentType := getRandomEnrichmentType()
+	alert.EnrichmentType = &enrichmentType
+}
+
+// enrichIPAddress prefers a source address reported by the upstream over a
+// generated one
+func enrichIPAddress(alert *models.Alert, indicators map[string]string) {
+	ipAddress := indicators["src_ip"]
+	if ipAddress == "" {
+		ipAddress = generateRandomIP()
+	}
+	alert.IPAddress = &ipAddress
+}
+
+func generateRandomIP() string {
+	return fmt.Sprintf("%d.%d.%d.%d",
+		rand.Intn(256),
+		rand.Intn(256),
+		rand.Intn(256),
+		rand.Intn(256),
+	)
+}
+
+func getRandomEnrichmentType() string {
+	alertTypes := []string{
+		"geo_location",
+		"threat_intel",
+		"user_context",
+		"network_analysis",
+		"behavioral_analysis",
+	}
+
+	return alertTypes[rand.Intn(len(alertTypes))]
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+const (
+	// escalationBatchSize caps how many due escalations are handled per tick
+	escalationBatchSize = 50
+
+	// escalationLease is how long a claimed escalation is held before another tick may retry it
+	escalationLease = 2 * time.Minute
+)
+
+// EscalationPolicy controls timed re-notification of unacknowledged alerts
+type EscalationPolicy struct {
+	// Timeout is how long to wait for an acknowledgement before notifying the next tier
+	Timeout time.Duration
+	// MaxNotifications caps the total notifications per alert, including the initial page
+	MaxNotifications int
+}
+
+// EscalationService re-notifies on-call tiers until a paged alert is acknowledged.
+// All state lives in storage, so pending escalations survive restarts. An
+// escalation is recorded when its alert is stored, before the initial page is
+// queued, so a page that fails, is dropped or is lost in a restart is sent by
+// the scheduler instead.
+type EscalationService struct {
+	storage EscalationStorageInterface
+	alerts  AlertStorageInterface
+	tiers   []PagerInterface
+	policy  EscalationPolicy
+	now     func() time.Time
+}
+
+// NewEscalationService creates an escalation service.
+// tiers[0] is the pager used for the initial page; later tiers are notified in order,
+// and the last tier is repeated until MaxNotifications is reached.
+func NewEscalationService(storage EscalationStorageInterface, alerts AlertStorageInterface, tiers []PagerInterface, policy EscalationPolicy) *EscalationService {
+	return &EscalationService{
+		storage: storage,
+		alerts:  alerts,
+		tiers:   tiers,
+		policy:  policy,
+		now:     time.Now,
+	}
+}
+
+// Start records the escalation of an alert that is about to be paged. The
+// pager queue has escalationLease to send the initial page; after that the
+// scheduler sends it.
+func (e *EscalationService) Start(ctx context.Context, alert models.Alert) error {
+	if err := e.storage.CreateEscalation(ctx, alert.ID, e.now().Add(escalationLease)); err != nil {
+		return fmt.Errorf("escalation: error starting escalation: %w", err)
+	}
+
+	return nil
+}
+
+// Paged records that the pager queue sent an alert's initial page and
+// schedules the first escalation step
+func (e *EscalationService) Paged(ctx context.Context, alert models.Alert) error {
+	status := models.EscalationActive
+	if e.policy.MaxNotifications <= 1 {
+		status = models.EscalationExhausted
+	}
+
+	if _, err := e.storage.RecordInitialPage(ctx, alert.ID, status, e.now().Add(e.policy.Timeout)); err != nil {
+		return fmt.Errorf("escalation: error recording initial page: %w", err)
+	}
+
+	return nil
+}
+
+// Stop ends the escalation for an alert and mirrors the lifecycle change to
+// every tier above the first that was notified (the first tier is handled by AlertService)
+func (e *EscalationService) Stop(ctx context.Context, alert models.Alert) error {
+	esc, err := e.storage.StopEscalation(ctx, alert.ID)
+	if err != nil {
+		return fmt.Errorf("escalation: error stopping escalation: %w", err)
+	}
+	if esc == nil {
+		return nil
+	}
+
+	for tier := 1; tier <= esc.Tier && tier < len(e.tiers); tier++ {
+		var pageErr error
+		if alert.Status == models.StatusResolved {
+			pageErr = e.tiers[tier].Resolve(ctx, alert.Fingerprint)
+		} else {
+			pageErr = e.tiers[tier].Acknowledge(ctx, alert.Fingerprint)
+		}
+		if pageErr != nil {
+			log.Printf("[ESCALATION] Failed to send %s to tier %d for alert %s: %v", alert.Status, tier, alert.ID, pageErr)
+		}
+	}
+
+	log.Printf("[ESCALATION] Stopped escalation for alert %s at tier %d", alert.ID, esc.Tier)
+	return nil
+}
+
+// ProcessDue notifies the next tier for every escalation whose timeout has elapsed.
+// Returns the number of escalations handled.
+func (e *EscalationService) ProcessDue(ctx context.Context) (int, error) {
+	now := e.now()
+	due, err := e.storage.ClaimDueEscalations(ctx, now, now.Add(escalationLease), escalationBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("escalation: error claiming due escalations: %w", err)
+	}
+
+	for i := range due {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		if err := e.escalate(ctx, &due[i]); err != nil {
+			log.Printf("[ESCALATION] Error escalating alert %s: %v", due[i].AlertID, err)
+		}
+	}
+
+	return len(due), nil
+}
+
+// escalate notifies the next tier for a single escalation and schedules the
+// following step. An escalation without notifications has not been paged
+// yet, so its first tier is notified.
+func (e *EscalationService) escalate(ctx context.Context, esc *models.Escalation) error {
+	alert, err := e.alerts.GetAlertByID(ctx, esc.AlertID)
+	if err != nil {
+		return fmt.Errorf("error loading alert: %w", err)
+	}
+
+	// Acknowledged (or removed) between claim and notify; the lifecycle hook
+	// will have stopped it
+	if alert == nil || alert.Status != models.StatusOpen {
+		esc.Status = models.EscalationStopped
+		_, err := e.storage.UpdateEscalation(ctx, esc)
+		return err
+	}
+
+	tier := 0
+	if esc.Notifications > 0 {
+		tier = min(esc.Tier+1, len(e.tiers)-1)
+	}
+
+	// Record the step before notifying, so that when an acknowledgement stops
+	// the escalation first there is nothing to update and nobody is notified
+	claimed := *esc
+	esc.Tier = tier
+	esc.Notifications++
+	esc.NextNotifyAt = e.now().Add(e.policy.Timeout)
+	updated, err := e.storage.UpdateEscalation(ctx, esc)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		log.Printf("[ESCALATION] Escalation for alert %s stopped before tier %d was notified", alert.ID, tier)
+		return nil
+	}
+
+	// A failed notification puts the claimed step back; the claim lease makes it retry later
+	if err := e.tiers[tier].Trigger(ctx, *alert); err != nil {
+		if _, restoreErr := e.storage.UpdateEscalation(ctx, &claimed); restoreErr != nil {
+			err = errors.Join(err, restoreErr)
+		}
+		return fmt.Errorf("error notifying tier %d: %w", tier, err)
+	}
+
+	log.Printf("[ESCALATION] Notified tier %d for alert %s (%d/%d)", tier, alert.ID, esc.Notifications, e.policy.MaxNotifications)
+	if esc.Notifications >= e.policy.MaxNotifications {
+		esc.Status = models.EscalationExhausted
+		_, err := e.storage.UpdateEscalation(ctx, esc)
+		return err
+	}
+	return nil
+}
+
+// Run processes due escalations every interval until ctx is cancelled
+func (e *EscalationService) Run(ctx context.Context, interval time.Duration) {
+	log.Printf("[ESCALATION] Starting escalation scheduler every %s", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[ESCALATION] Stopping escalation scheduler")
+			return
+		case <-ticker.C:
+			if _, err := e.ProcessDue(ctx); err != nil {
+				log.Printf("[ESCALATION] %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestEscalationService(t *testing.T, tierCount, maxNotifications int) (*EscalationService, *mocks.EscalationStorageInterface, *mocks.AlertStorageInterface, []*mocks.PagerInterface) {
+	escStorage := mocks.NewEscalationStorageInterface(t)
+	alertStorage := mocks.NewAlertStorageInterface(t)
+
+	var pagers []*mocks.PagerInterface
+	var tiers []PagerInterface
+	for i := 0; i < tierCount; i++ {
+		p := mocks.NewPagerInterface(t)
+		pagers = append(pagers, p)
+		tiers = append(tiers, p)
+	}
+
+	e := NewEscalationService(escStorage, alertStorage, tiers, EscalationPolicy{
+		Timeout:          10 * time.Minute,
+		MaxNotifications: maxNotifications,
+	})
+	return e, escStorage, alertStorage, pagers
+}
+
+func TestEscalationService_Start(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("holds the initial page for the pager queue", func(t *testing.T) {
+		e, escStorage, _, _ := newTestEscalationService(t, 2, 3)
+		e.now = func() time.Time { return now }
+
+		escStorage.On("CreateEscalation", ctx, "alert-1", now.Add(escalationLease)).Return(nil)
+
+		assert.NoError(t, e.Start(ctx, models.Alert{ID: "alert-1"}))
+	})
+
+	t.Run("recorded even when only one notification is allowed", func(t *testing.T) {
+		e, escStorage, _, _ := newTestEscalationService(t, 2, 1)
+		e.now = func() time.Time { return now }
+
+		escStorage.On("CreateEscalation", ctx, "alert-1", now.Add(escalationLease)).Return(nil)
+
+		assert.NoError(t, e.Start(ctx, models.Alert{ID: "alert-1"}))
+	})
+}
+
+func TestEscalationService_Paged(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("schedules first step after timeout", func(t *testing.T) {
+		e, escStorage, _, _ := newTestEscalationService(t, 2, 3)
+		e.now = func() time.Time { return now }
+
+		escStorage.On("RecordInitialPage", ctx, "alert-1", models.EscalationActive, now.Add(10*time.Minute)).Return(true, nil)
+
+		assert.NoError(t, e.Paged(ctx, models.Alert{ID: "alert-1"}))
+	})
+
+	t.Run("exhausted when only one notification is allowed", func(t *testing.T) {
+		e, escStorage, _, _ := newTestEscalationService(t, 2, 1)
+		e.now = func() time.Time { return now }
+
+		escStorage.On("RecordInitialPage", ctx, "alert-1", models.EscalationExhausted, mock.Anything).Return(true, nil)
+
+		assert.NoError(t, e.Paged(ctx, models.Alert{ID: "alert-1"}))
+	})
+}
+
+func TestEscalationService_ProcessDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("notifies next tier", func(t *testing.T) {
+		e, escStorage, alertStorage, pagers := newTestEscalationService(t, 2, 3)
+		e.now = func() time.Time { return now }
+
+		alert := &models.Alert{ID: "alert-1", Status: models.StatusOpen, Fingerprint: "fp-1"}
+		escStorage.On("ClaimDueEscalations", ctx, now, now.Add(escalationLease), escalationBatchSize).
+			Return([]models.Escalation{{ID: "esc-1", AlertID: "alert-1", Tier: 0, Notifications: 1, Status: models.EscalationActive}}, nil)
+		alertStorage.On("GetAlertByID", ctx, "alert-1").Return(alert, nil)
+		escStorage.On("UpdateEscalation", ctx, mock.MatchedBy(func(esc *models.Escalation) bool {
+			return esc.Tier == 1 && esc.Notifications == 2 && esc.Status == models.EscalationActive &&
+				esc.NextNotifyAt.Equal(now.Add(10*time.Minute))
+		})).Return(true, nil)
+		pagers[1].On("Trigger", ctx, *alert).Return(nil)
+
+		count, err := e.ProcessDue(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("sends an initial page that was never sent", func(t *testing.T) {
+		e, escStorage, alertStorage, pagers := newTestEscalationService(t, 2, 3)
+		e.now = func() time.Time { return now }
+
+		alert := &models.Alert{ID: "alert-1", Status: models.StatusOpen, Fingerprint: "fp-1"}
+		escStorage.On("ClaimDueEscalations", ctx, now, now.Add(escalationLease), escalationBatchSize).
+			Return([]models.Escalation{{ID: "esc-1", AlertID: "alert-1", Tier: 0, Notifications: 0, Status: models.EscalationActive}}, nil)
+		alertStorage.On("GetAlertByID", ctx, "alert-1").Return(alert, nil)
+		escStorage.On("UpdateEscalation", ctx, mock.MatchedBy(func(esc *models.Escalation) bool {
+			return esc.Tier == 0 && esc.Notifications == 1 && esc.Status == models.EscalationActive &&
+				esc.NextNotifyAt.Equal(now.Add(10*time.Minute))
+		})).Return(true, nil)
+		pagers[0].On("Trigger", ctx, *alert).Return(nil)
+
+		_, err := e.ProcessDue(ctx)
+
+		assert.NoError(t, err)
+		pagers[1].AssertNotCalled(t, "Trigger", mock.Anything, mock.Anything)
+	})
+
+	t.Run("repeats last tier until limit", func(t *testing.T) {
+		e, escStorage, alertStorage, pagers := newTestEscalationService(t, 2, 3)
+		e.now = func() time.Time { return now }
+
+		alert := &models.Alert{ID: "alert-1", Status: models.StatusOpen}
+		escStorage.On("ClaimDueEscalations", ctx, now, mock.Anything, mock.Anything).
+			Return([]models.Escalation{{ID: "esc-1", AlertID: "alert-1", Tier: 1, Notifications: 2, Status: models.EscalationActive}}, nil)
+		alertStorage.On("GetAlertByID", ctx, "alert-1").Return(alert, nil)
+		escStorage.On("UpdateEscalation", ctx, mock.MatchedBy(func(esc *models.Escalation) bool {
+			return esc.Tier == 1 && esc.Notifications == 3 && esc.Status == models.EscalationActive
+		})).Return(true, nil).Once()
+		pagers[1].On("Trigger", ctx, *alert).Return(nil)
+		escStorage.On("UpdateEscalation", ctx, mock.MatchedBy(func(esc *models.Escalation) bool {
+			return esc.Tier == 1 && esc.Notifications == 3 && esc.Status == models.EscalationExhausted
+		})).Return(true, nil).Once()
+
+		_, err := e.ProcessDue(ctx)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("acknowledged alert stops escalation", func(t *testing.T) {
+		e, escStorage, alertStorage, _ := newTestEscalationService(t, 2, 3)
+		e.now = func() time.Time { return now }
+
+		escStorage.On("ClaimDueEscalations", ctx, now, mock.Anything, mock.Anything).
+			Return([]models.Escalation{{ID: "esc-1", AlertID: "alert-1", Status: models.EscalationActive}}, nil)
+		alertStorage.On("GetAlertByID", ctx, "alert-1").Return(&models.Alert{ID: "alert-1", Status: models.StatusAcknowledged}, nil)
+		escStorage.On("UpdateEscalation", ctx, mock.MatchedBy(func(esc *models.Escalation) bool {
+			return esc.Status == models.EscalationStopped
+		})).Return(true, nil)
+
+		_, err := e.ProcessDue(ctx)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("escalation stopped after claim is not notified", func(t *testing.T) {
+		e, escStorage, alertStorage, pagers := newTestEscalationService(t, 2, 3)
+		e.now = func() time.Time { return now }
+
+		// The alert is still open when loaded, but Stop lands before the step is recorded
+		alert := &models.Alert{ID: "alert-1", Status: models.StatusOpen}
+		escStorage.On("ClaimDueEscalations", ctx, now, mock.Anything, mock.Anything).
+			Return([]models.Escalation{{ID: "esc-1", AlertID: "alert-1", Notifications: 1, Status: models.EscalationActive}}, nil)
+		alertStorage.On("GetAlertByID", ctx, "alert-1").Return(alert, nil)
+		escStorage.On("UpdateEscalation", ctx, mock.Anything).Return(false, nil).Once()
+
+		_, err := e.ProcessDue(ctx)
+
+		assert.NoError(t, err)
+		pagers[1].AssertNotCalled(t, "Trigger", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed notification keeps current step", func(t *testing.T) {
+		e, escStorage, alertStorage, pagers := newTestEscalationService(t, 2, 3)
+		e.now = func() time.Time { return now }
+
+		lease := now.Add(escalationLease)
+		alert := &models.Alert{ID: "alert-1", Status: models.StatusOpen}
+		escStorage.On("ClaimDueEscalations", ctx, now, mock.Anything, mock.Anything).
+			Return([]models.Escalation{{ID: "esc-1", AlertID: "alert-1", Notifications: 1, Status: models.EscalationActive, NextNotifyAt: lease}}, nil)
+		alertStorage.On("GetAlertByID", ctx, "alert-1").Return(alert, nil)
+		escStorage.On("UpdateEscalation", ctx, mock.MatchedBy(func(esc *models.Escalation) bool {
+			return esc.Tier == 1 && esc.Notifications == 2
+		})).Return(true, nil).Once()
+		pagers[1].On("Trigger", ctx, *alert).Return(errors.New("pagerduty down"))
+		escStorage.On("UpdateEscalation", ctx, mock.MatchedBy(func(esc *models.Escalation) bool {
+			return esc.Tier == 0 && esc.Notifications == 1 && esc.Status == models.EscalationActive && esc.NextNotifyAt.Equal(lease)
+		})).Return(true, nil).Once()
+
+		_, err := e.ProcessDue(ctx)
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestEscalationService_Stop(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("acknowledges escalated tiers", func(t *testing.T) {
+		e, escStorage, _, pagers := newTestEscalationService(t, 3, 5)
+
+		escStorage.On("StopEscalation", ctx, "alert-1").Return(&models.Escalation{ID: "esc-1", Tier: 2}, nil)
+		pagers[1].On("Acknowledge", ctx, "fp-1").Return(nil)
+		pagers[2].On("Acknowledge", ctx, "fp-1").Return(nil)
+
+		err := e.Stop(ctx, models.Alert{ID: "alert-1", Fingerprint: "fp-1", Status: models.StatusAcknowledged})
+
+		assert.NoError(t, err)
+		pagers[0].AssertNotCalled(t, "Acknowledge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("no active escalation", func(t *testing.T) {
+		e, escStorage, _, _ := newTestEscalationService(t, 2, 3)
+
+		escStorage.On("StopEscalation", ctx, "alert-1").Return(nil, nil)
+
+		err := e.Stop(ctx, models.Alert{ID: "alert-1", Status: models.StatusResolved})
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,442 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"censys_alert_system/internal/export"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+)
+
+// DefaultExportBatchSize is the number of alerts read from storage at a time
+const DefaultExportBatchSize = 1000
+
+// exportQueueSize caps export jobs waiting for a worker
+const exportQueueSize = 100
+
+var (
+	// ErrInvalidExport is returned for an unknown format or column, or an
+	// export too large for its format
+	ErrInvalidExport = errors.New("invalid export")
+	// ErrExportNotFound is returned for an unknown or expired export job
+	ErrExportNotFound = errors.New("export job not found")
+	// ErrExportNotReady is returned when downloading a job that has not completed
+	ErrExportNotReady = errors.New("export job has not completed")
+	// ErrExportQueueFull is returned when too many export jobs are waiting
+	ErrExportQueueFull = errors.New("too many export jobs are waiting")
+)
+
+// ExportRequest selects the alerts and columns of an export. Empty Columns
+// exports export.DefaultColumns.
+type ExportRequest struct {
+	Format  string
+	Columns []string
+	Filter  models.AlertFilter
+}
+
+// ExportPolicy controls exports
+type ExportPolicy struct {
+	// Dir holds the files of export jobs and their status
+	Dir string
+	// SyncMaxRows is the largest export streamed straight to the client;
+	// larger ones run as jobs
+	SyncMaxRows int
+	// TTL is how long a finished job's file can be downloaded
+	TTL time.Duration
+	// Workers is the number of jobs run at once
+	Workers int
+}
+
+// ExportService writes filtered alerts as CSV, NDJSON or XLSX. Small exports
+// are streamed to the caller; large ones run as jobs that write a file to
+// download later. Alerts are read a batch at a time in (created_at, id)
+// order, so neither path holds more than one batch in memory.
+//
+// Jobs are kept as a JSON status file next to their export file, so they
+// survive restarts; jobs interrupted by a restart are marked failed.
+type ExportService struct {
+	alerts    AlertStorageInterface
+	policy    ExportPolicy
+	batchSize int
+	now       func() time.Time
+
+	mu    sync.Mutex
+	jobs  map[string]*models.ExportJob
+	queue chan string
+}
+
+// NewExportService creates an export service keeping its jobs in policy.Dir
+func NewExportService(alerts AlertStorageInterface, policy ExportPolicy) (*ExportService, error) {
+	if policy.Workers <= 0 {
+		policy.Workers = 1
+	}
+	if err := os.MkdirAll(policy.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+
+	e := &ExportService{
+		alerts:    alerts,
+		policy:    policy,
+		batchSize: DefaultExportBatchSize,
+		now:       time.Now,
+		jobs:      make(map[string]*models.ExportJob),
+		queue:     make(chan string, exportQueueSize),
+	}
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Prepare validates a request, filling in the default columns, and counts
+// the alerts it matches. async reports whether the export is too large to
+// stream and should run as a job.
+func (e *ExportService) Prepare(ctx context.Context, req *ExportRequest) (total int, async bool, err error) {
+	if err := export.ValidateFormat(req.Format); err != nil {
+		return 0, false, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	columns, err := export.ParseColumns(req.Columns)
+	if err != nil {
+		return 0, false, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+	}
+	req.Columns = columns
+
+	total, err = e.alerts.CountAlerts(ctx, req.Filter)
+	if err != nil {
+		return 0, false, fmt.Errorf("counting alerts to export: %w", err)
+	}
+	if req.Format == export.FormatXLSX && total > export.MaxXLSXRows {
+		return total, false, fmt.Errorf("%w: %v", ErrInvalidExport, export.ErrTooManyRows)
+	}
+	return total, total > e.policy.SyncMaxRows, nil
+}
+
+// Export writes the alerts of a prepared request to w, calling flush, when
+// set, after every batch. It returns the number of alerts written.
+func (e *ExportService) Export(ctx context.Context, w io.Writer, req ExportRequest, flush func() error) (int, error) {
+	ew, err := export.NewWriter(req.Format, w, req.Columns)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := e.write(ctx, ew, req, flush, nil)
+	if err != nil {
+		return rows, err
+	}
+	return rows, ew.Close()
+}
+
+// write pages through the matching alerts into ew, calling progress with
+// the rows written so far after every batch
+func (e *ExportService) write(ctx context.Context, ew export.Writer, req ExportRequest, flush func() error, progress func(int)) (int, error) {
+	rows := 0
+	defer func() { metrics.AlertsExported.WithLabelValues(req.Format).Add(float64(rows)) }()
+
+	var afterCreatedAt time.Time
+	var afterID string
+	for {
+		alerts, err := e.alerts.ListAlertsAfter(ctx, req.Filter, afterCreatedAt, afterID, e.batchSize)
+		if err != nil {
+			return rows, fmt.Errorf("exporting alerts: %w", err)
+		}
+		for _, alert := range alerts {
+			if err := ew.Write(alert); err != nil {
+				return rows, fmt.Errorf("exporting alert %s: %w", alert.ID, err)
+			}
+			rows++
+		}
+		if err := ew.Flush(); err != nil {
+			return rows, err
+		}
+		if flush != nil {
+			if err := flush(); err != nil {
+				return rows, err
+			}
+		}
+		if progress != nil {
+			progress(rows)
+		}
+		if len(alerts) < e.batchSize {
+			return rows, nil
+		}
+		last := alerts[len(alerts)-1]
+		afterCreatedAt, afterID = last.CreatedAt, last.ID
+	}
+}
+
+// CreateJob queues a job for a prepared request; total is the count from Prepare
+func (e *ExportService) CreateJob(req ExportRequest, total int) (*models.ExportJob, error) {
+	job := &models.ExportJob{
+		ID:        newExportID(),
+		Format:    req.Format,
+		Columns:   req.Columns,
+		Filter:    req.Filter,
+		Status:    models.JobPending,
+		Total:     total,
+		CreatedAt: e.now().UTC(),
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.save(job); err != nil {
+		return nil, err
+	}
+	select {
+	case e.queue <- job.ID:
+	default:
+		e.remove(job)
+		return nil, ErrExportQueueFull
+	}
+	e.jobs[job.ID] = job
+	log.Printf("[EXPORT] Queued %s export %s of %d alert(s)", job.Format, job.ID, total)
+	copied := *job
+	return &copied, nil
+}
+
+// Get returns an export job
+func (e *ExportService) Get(id string) (*models.ExportJob, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	job, ok := e.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrExportNotFound, id)
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// List returns the export jobs with one of statuses, or every job when
+// statuses is empty, newest first
+func (e *ExportService) List(statuses []string) []models.ExportJob {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	jobs := make([]models.ExportJob, 0, len(e.jobs))
+	for _, job := range e.jobs {
+		if len(statuses) == 0 || slices.Contains(statuses, job.Status) {
+			jobs = append(jobs, *job)
+		}
+	}
+	slices.SortFunc(jobs, func(a, b models.ExportJob) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return jobs
+}
+
+// Open opens the file of a completed export job for download
+func (e *ExportService) Open(id string) (*models.ExportJob, *os.File, error) {
+	job, err := e.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != models.JobCompleted {
+		return nil, nil, fmt.Errorf("%w: %s is %s", ErrExportNotReady, id, job.Status)
+	}
+	f, err := os.Open(e.filePath(job))
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening export %s: %w", id, err)
+	}
+	return job, f, nil
+}
+
+// process runs one queued job to completion
+func (e *ExportService) process(ctx context.Context, id string) {
+	e.mu.Lock()
+	job, ok := e.jobs[id]
+	if !ok || job.Status != models.JobPending {
+		e.mu.Unlock()
+		return
+	}
+	job.Status = models.JobRunning
+	if err := e.save(job); err != nil {
+		log.Printf("[EXPORT] %s: %v", id, err)
+	}
+	req := ExportRequest{Format: job.Format, Columns: job.Columns, Filter: job.Filter}
+	path := e.filePath(job)
+	e.mu.Unlock()
+
+	rows, size, err := e.writeFile(ctx, path, req, func(rows int) {
+		e.mu.Lock()
+		job.Rows = rows
+		e.mu.Unlock()
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now().UTC()
+	job.Rows, job.Size = rows, size
+	job.CompletedAt = &now
+	job.ExpiresAt = now.Add(e.policy.TTL)
+	if err != nil {
+		job.Status, job.Error = models.JobFailed, err.Error()
+		log.Printf("[EXPORT] %s failed after %d alert(s): %v", id, rows, err)
+	} else {
+		job.Status = models.JobCompleted
+		log.Printf("[EXPORT] %s completed: %d alert(s), %d bytes", id, rows, size)
+	}
+	if err := e.save(job); err != nil {
+		log.Printf("[EXPORT] %s: %v", id, err)
+	}
+}
+
+// writeFile exports req to path through a temporary file, so a partial
+// export is never downloadable. Returns the rows and bytes written.
+func (e *ExportService) writeFile(ctx context.Context, path string, req ExportRequest, progress func(int)) (int, int64, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	ew, err := export.NewWriter(req.Format, f, req.Columns)
+	if err != nil {
+		return 0, 0, err
+	}
+	rows, err := e.write(ctx, ew, req, nil, progress)
+	if err != nil {
+		return rows, 0, err
+	}
+	if err := ew.Close(); err != nil {
+		return rows, 0, err
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return rows, 0, err
+	}
+	if err := f.Close(); err != nil {
+		return rows, 0, err
+	}
+	return rows, size, os.Rename(f.Name(), path)
+}
+
+// RemoveExpired deletes finished jobs, and their files, past their expiry.
+// Returns the number removed.
+func (e *ExportService) RemoveExpired() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	removed := 0
+	for _, job := range e.jobs {
+		if !job.ExpiresAt.IsZero() && now.After(job.ExpiresAt) {
+			e.remove(job)
+			removed++
+		}
+	}
+	return removed
+}
+
+// remove deletes a job and its files; the caller holds mu
+func (e *ExportService) remove(job *models.ExportJob) {
+	delete(e.jobs, job.ID)
+	for _, path := range []string{e.filePath(job), e.statusPath(job.ID)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[EXPORT] Removing %s: %v", path, err)
+		}
+	}
+}
+
+// Run starts the export workers and removes expired jobs every interval
+// until ctx is cancelled
+func (e *ExportService) Run(ctx context.Context, interval time.Duration) {
+	log.Printf("[EXPORT] Starting %d export worker(s)", e.policy.Workers)
+	for range e.policy.Workers {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-e.queue:
+					e.process(ctx, id)
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[EXPORT] Stopping export workers")
+			return
+		case <-ticker.C:
+			if removed := e.RemoveExpired(); removed > 0 {
+				log.Printf("[EXPORT] Removed %d expired export(s)", removed)
+			}
+		}
+	}
+}
+
+func (e *ExportService) filePath(job *models.ExportJob) string {
+	return filepath.Join(e.policy.Dir, job.ID+"."+job.Format)
+}
+
+func (e *ExportService) statusPath(id string) string {
+	return filepath.Join(e.policy.Dir, id+".json")
+}
+
+// save writes a job's status file; the caller holds mu
+func (e *ExportService) save(job *models.ExportJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	tmp := e.statusPath(job.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("saving export %s: %w", job.ID, err)
+	}
+	if err := os.Rename(tmp, e.statusPath(job.ID)); err != nil {
+		return fmt.Errorf("saving export %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// load reads the jobs of an earlier run. Jobs that were waiting or running
+// cannot be resumed, since the export file is written in one pass.
+func (e *ExportService) load() error {
+	paths, err := filepath.Glob(filepath.Join(e.policy.Dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("export: %w", err)
+		}
+		var job models.ExportJob
+		if err := json.Unmarshal(data, &job); err != nil || job.ID != strings.TrimSuffix(filepath.Base(path), ".json") {
+			log.Printf("[EXPORT] Skipping unreadable job status %s", path)
+			continue
+		}
+		if job.Status == models.JobPending || job.Status == models.JobRunning {
+			now := e.now().UTC()
+			job.Status, job.Error = models.JobFailed, "interrupted by a restart"
+			job.CompletedAt, job.ExpiresAt = &now, now.Add(e.policy.TTL)
+			if err := e.save(&job); err != nil {
+				return err
+			}
+		}
+		e.jobs[job.ID] = &job
+	}
+	return nil
+}
+
+// newExportID returns a random job ID, safe to use in file names
+func newExportID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/export"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExportService(t *testing.T, dir string) (*ExportService, *mocks.AlertStorageInterface) {
+	storage := mocks.NewAlertStorageInterface(t)
+	e, err := NewExportService(storage, ExportPolicy{Dir: dir, SyncMaxRows: 2, TTL: time.Hour})
+	require.NoError(t, err)
+	e.batchSize = 2
+	e.now = func() time.Time { return utcDay(2025, 3, 1) }
+	return e, storage
+}
+
+func TestExportService_Prepare(t *testing.T) {
+	ctx := context.Background()
+	e, storage := newTestExportService(t, t.TempDir())
+	filter := models.AlertFilter{Severities: []string{"high"}}
+
+	storage.On("CountAlerts", ctx, filter).Return(2, nil).Once()
+	req := ExportRequest{Format: export.FormatCSV, Filter: filter}
+	total, async, err := e.Prepare(ctx, &req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.False(t, async)
+	assert.Equal(t, export.DefaultColumns, req.Columns)
+
+	storage.On("CountAlerts", ctx, filter).Return(3, nil).Once()
+	_, async, err = e.Prepare(ctx, &req)
+	require.NoError(t, err)
+	assert.True(t, async, "larger than SyncMaxRows")
+
+	storage.On("CountAlerts", ctx, filter).Return(export.MaxXLSXRows+1, nil).Once()
+	_, _, err = e.Prepare(ctx, &ExportRequest{Format: export.FormatXLSX, Filter: filter})
+	assert.ErrorIs(t, err, ErrInvalidExport)
+
+	_, _, err = e.Prepare(ctx, &ExportRequest{Format: "pdf"})
+	assert.ErrorIs(t, err, ErrInvalidExport)
+	_, _, err = e.Prepare(ctx, &ExportRequest{Format: export.FormatCSV, Columns: []string{"secret"}})
+	assert.ErrorIs(t, err, ErrInvalidExport)
+}
+
+func TestExportService_Export(t *testing.T) {
+	ctx := context.Background()
+	e, storage := newTestExportService(t, t.TempDir())
+	alerts := archivedAlerts(3)
+
+	storage.On("ListAlertsAfter", ctx, models.AlertFilter{}, time.Time{}, "", 2).Return(alerts[:2], nil).Once()
+	storage.On("ListAlertsAfter", ctx, models.AlertFilter{}, alerts[1].CreatedAt, "alert-1", 2).Return(alerts[2:], nil).Once()
+
+	var buf bytes.Buffer
+	flushes := 0
+	rows, err := e.Export(ctx, &buf, ExportRequest{Format: export.FormatCSV, Columns: []string{"id", "created_at"}}, func() error {
+		flushes++
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, rows)
+	assert.Equal(t, 2, flushes, "flushed after every batch")
+	assert.Equal(t, "id,created_at\nalert-0,2025-01-01T00:00:00Z\nalert-1,2025-01-02T00:00:00Z\nalert-2,2025-01-03T00:00:00Z\n", buf.String())
+}
+
+func TestExportService_Job(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	e, storage := newTestExportService(t, dir)
+	alerts := archivedAlerts(2)
+
+	job, err := e.CreateJob(ExportRequest{Format: export.FormatNDJSON, Columns: []string{"id"}}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobPending, job.Status)
+
+	_, _, err = e.Open(job.ID)
+	assert.ErrorIs(t, err, ErrExportNotReady)
+
+	storage.On("ListAlertsAfter", ctx, models.AlertFilter{}, time.Time{}, "", 2).Return(alerts, nil).Once()
+	storage.On("ListAlertsAfter", ctx, models.AlertFilter{}, alerts[1].CreatedAt, "alert-1", 2).Return(nil, nil).Once()
+	e.process(ctx, <-e.queue)
+
+	done, f, err := e.Open(job.ID)
+	require.NoError(t, err)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"id\":\"alert-0\"}\n{\"id\":\"alert-1\"}\n", string(data))
+	assert.Equal(t, models.JobCompleted, done.Status)
+	assert.Equal(t, 2, done.Rows)
+	assert.Equal(t, int64(len(data)), done.Size)
+	assert.Equal(t, utcDay(2025, 3, 1).Add(time.Hour), done.ExpiresAt)
+
+	// Finished jobs are still listed after a restart
+	reloaded, _ := newTestExportService(t, dir)
+	jobs := reloaded.List(nil)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, models.JobCompleted, jobs[0].Status)
+}
+
+func TestExportService_JobFailure(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	e, storage := newTestExportService(t, dir)
+
+	job, err := e.CreateJob(ExportRequest{Format: export.FormatCSV, Columns: export.DefaultColumns}, 5)
+	require.NoError(t, err)
+	storage.On("ListAlertsAfter", ctx, models.AlertFilter{}, time.Time{}, "", 2).Return(nil, assert.AnError).Once()
+	e.process(ctx, <-e.queue)
+
+	failed, err := e.Get(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobFailed, failed.Status)
+	assert.Contains(t, failed.Error, assert.AnError.Error())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.True(t, strings.HasSuffix(entry.Name(), ".json"), "no partial export left behind: %s", entry.Name())
+	}
+}
+
+func TestExportService_InterruptedJobFailsOnRestart(t *testing.T) {
+	dir := t.TempDir()
+	e, _ := newTestExportService(t, dir)
+	job, err := e.CreateJob(ExportRequest{Format: export.FormatCSV}, 10)
+	require.NoError(t, err)
+
+	reloaded, _ := newTestExportService(t, dir)
+	interrupted, err := reloaded.Get(job.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.JobFailed, interrupted.Status)
+	assert.Equal(t, "interrupted by a restart", interrupted.Error)
+}
+
+func TestExportService_RemoveExpired(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	e, storage := newTestExportService(t, dir)
+
+	job, err := e.CreateJob(ExportRequest{Format: export.FormatCSV, Columns: []string{"id"}}, 0)
+	require.NoError(t, err)
+	storage.On("ListAlertsAfter", ctx, mock.Anything, time.Time{}, "", 2).Return(nil, nil).Once()
+	e.process(ctx, <-e.queue)
+
+	assert.Equal(t, 0, e.RemoveExpired())
+	e.now = func() time.Time { return utcDay(2025, 3, 2) }
+	assert.Equal(t, 1, e.RemoveExpired())
+
+	_, err = e.Get(job.ID)
+	assert.ErrorIs(t, err, ErrExportNotFound)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
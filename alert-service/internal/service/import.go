@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/importer"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+)
+
+// DefaultImportBatchSize is the number of imported alerts stored at a time
+const DefaultImportBatchSize = 500
+
+// maxImportErrors caps the row errors listed in an import report; the
+// rejected count stays exact
+const maxImportErrors = 1000
+
+// importPreviewSize caps the alerts a dry run shows
+const importPreviewSize = 100
+
+// ErrInvalidImport is returned for an unknown format, default source or an
+// unreadable file
+var ErrInvalidImport = errors.New("invalid import")
+
+// ImportOptions controls an import
+type ImportOptions struct {
+	// Format is importer.FormatCSV or importer.FormatNDJSON
+	Format string
+	// DefaultSource is the source of rows without one
+	DefaultSource string
+	// Enrich runs imported alerts through the enrichment pipeline. Otherwise
+	// their enrichment fields are left empty for a re-enrichment job to fill.
+	Enrich bool
+	// DryRun validates and deduplicates the file without storing anything.
+	// It keeps the fingerprint of every valid row to count repeats within
+	// the file as an import does, so its memory grows with the file.
+	DryRun bool
+}
+
+// ImportError describes why a row of an import file was rejected
+type ImportError struct {
+	Line  int    `json:"line"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportResult reports what an import did, or in a dry run what it would do
+type ImportResult struct {
+	DryRun bool `json:"dry_run"`
+	// Rows counts the rows read, blank lines aside
+	Rows     int `json:"rows"`
+	Inserted int `json:"inserted"`
+	// Duplicates are rows whose fingerprint is already stored or appeared
+	// earlier in the file
+	Duplicates      int           `json:"duplicates"`
+	Rejected        int           `json:"rejected"`
+	Errors          []ImportError `json:"errors,omitempty"`
+	ErrorsTruncated bool          `json:"errors_truncated,omitempty"`
+	// Alerts are the first alerts a dry run would insert
+	Alerts []models.Alert `json:"alerts,omitempty"`
+}
+
+func (r *ImportResult) reject(line int, field, reason string) {
+	r.Rejected++
+	if len(r.Errors) == maxImportErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+	r.Errors = append(r.Errors, ImportError{Line: line, Field: field, Error: reason})
+}
+
+// ImportService loads historical alerts from CSV and NDJSON files, such as
+// exports from a previous SIEM. Rows are validated one at a time against the
+// same rules as pushed alerts, and deduplicated by the same fingerprint as
+// synced ones, so a file can be imported again, or overlap alerts already
+// synced, without storing anything twice.
+//
+// Imported alerts keep their status and lifecycle times. They are historical,
+// so they are neither published to live subscribers nor paged.
+type ImportService struct {
+	alerts     AlertStorageInterface
+	partitions *PartitionService
+	batchSize  int
+	newID      func() string
+}
+
+// NewImportService creates an import service storing into alerts
+func NewImportService(alerts AlertStorageInterface) *ImportService {
+	return &ImportService{
+		alerts:    alerts,
+		batchSize: DefaultImportBatchSize,
+		newID:     models.NewAlertID,
+	}
+}
+
+// SetPartitions makes imports create the partitions their alerts fall in
+func (s *ImportService) SetPartitions(partitions *PartitionService) {
+	s.partitions = partitions
+}
+
+// Import reads a file and stores its valid, new alerts a batch at a time.
+// Rows that fail validation are listed in the result and skipped. An error
+// means the import stopped early, e.g. on an unreadable file or a storage
+// failure; the result still reports the rows handled before it.
+func (s *ImportService) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	result := &ImportResult{DryRun: opts.DryRun}
+	if opts.DefaultSource != "" && !IsValidSource(opts.DefaultSource) {
+		return result, fmt.Errorf("%w: invalid default source %q", ErrInvalidImport, opts.DefaultSource)
+	}
+	reader, err := importer.NewReader(opts.Format, r)
+	if err != nil {
+		return result, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+	}
+
+	defer func() {
+		if !opts.DryRun {
+			metrics.AlertsImported.WithLabelValues("inserted").Add(float64(result.Inserted))
+			metrics.AlertsImported.WithLabelValues("duplicate").Add(float64(result.Duplicates))
+			metrics.AlertsImported.WithLabelValues("rejected").Add(float64(result.Rejected))
+		}
+	}()
+
+	// Repeats within the file are caught by the storage's fingerprint
+	// conflict like alerts already stored, so nothing grows with the file.
+	// A dry run stores nothing for them to conflict with, so it remembers
+	// the fingerprints it has counted instead.
+	var seen map[string]struct{}
+	if opts.DryRun {
+		seen = make(map[string]struct{})
+	}
+	batch := make([]models.Alert, 0, s.batchSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *importer.RowError
+		if errors.As(err, &rowErr) {
+			result.Rows++
+			result.reject(rowErr.Line, rowErr.Field, rowErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+		}
+		result.Rows++
+
+		alert, field, err := s.importedAlert(record, opts)
+		if err != nil {
+			result.reject(record.Line, field, err.Error())
+			continue
+		}
+		batch = append(batch, alert)
+		if len(batch) == s.batchSize {
+			if err := s.store(ctx, batch, result, seen); err != nil {
+				return result, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := s.store(ctx, batch, result, seen); err != nil {
+		return result, err
+	}
+
+	verb := "Imported"
+	if opts.DryRun {
+		verb = "Dry run would import"
+	}
+	log.Printf("[IMPORT] %s %d alert(s) of %d row(s): %d duplicate, %d rejected",
+		verb, result.Inserted, result.Rows, result.Duplicates, result.Rejected)
+	return result, nil
+}
+
+// importedAlert validates a record and builds the alert to store, returning
+// the field at fault when it is invalid
+func (s *ImportService) importedAlert(record importer.Record, opts ImportOptions) (models.Alert, string, error) {
+	extAlert := external.ExternalAlert{
+		Source:      record.Source,
+		Severity:    record.Severity,
+		Description: record.Description,
+		CreatedAt:   record.CreatedAt,
+		Raw:         record.Raw,
+		Indicators:  record.Indicators,
+	}
+	if extAlert.Source == "" {
+		extAlert.Source = opts.DefaultSource
+	}
+	switch {
+	case !IsValidSource(extAlert.Source):
+		return models.Alert{}, "source", fmt.Errorf("invalid source %q", extAlert.Source)
+	case !IsValidSeverity(extAlert.Severity):
+		return models.Alert{}, "severity", fmt.Errorf("invalid severity %q", extAlert.Severity)
+	case extAlert.Description == "":
+		return models.Alert{}, "description", errors.New("description is required")
+	case extAlert.CreatedAt.IsZero():
+		return models.Alert{}, "created_at", errors.New("created_at is required")
+	}
+
+	status := record.Status
+	if status == "" {
+		status = models.StatusOpen
+	}
+	if status != models.StatusOpen && status != models.StatusAcknowledged && status != models.StatusResolved {
+		return models.Alert{}, "status", fmt.Errorf("invalid status %q: use open, acknowledged or resolved", record.Status)
+	}
+	for _, t := range []struct {
+		field string
+		at    *time.Time
+	}{{"acknowledged_at", record.AcknowledgedAt}, {"resolved_at", record.ResolvedAt}} {
+		if t.at != nil && t.at.Before(extAlert.CreatedAt) {
+			return models.Alert{}, t.field, fmt.Errorf("%s is before created_at", t.field)
+		}
+	}
+	if field, err := checkLifecycle(status, record.AcknowledgedAt, record.ResolvedAt); err != nil {
+		return models.Alert{}, field, err
+	}
+
+	alert, err := unenrichedAlert(extAlert)
+	if err != nil {
+		return models.Alert{}, "", err
+	}
+	if opts.Enrich {
+		enrich(&alert, extAlert.Indicators, nil)
+	}
+	alert.ID = s.newID()
+	alert.Status = status
+	alert.AcknowledgedAt = record.AcknowledgedAt
+	alert.ResolvedAt = record.ResolvedAt
+	return alert, "", nil
+}
+
+// checkLifecycle checks that an imported alert's lifecycle times match its
+// status, returning the field at fault. An acknowledged alert needs
+// acknowledged_at and a resolved one resolved_at; a resolved alert may skip
+// acknowledgement, as it can when resolved through the API.
+func checkLifecycle(status string, acknowledgedAt, resolvedAt *time.Time) (string, error) {
+	switch {
+	case status == models.StatusOpen && acknowledgedAt != nil:
+		return "acknowledged_at", errors.New("acknowledged_at is set but status is open")
+	case status != models.StatusResolved && resolvedAt != nil:
+		return "resolved_at", fmt.Errorf("resolved_at is set but status is %s", status)
+	case status == models.StatusAcknowledged && acknowledgedAt == nil:
+		return "acknowledged_at", errors.New("acknowledged_at is required for status acknowledged")
+	case status == models.StatusResolved && resolvedAt == nil:
+		return "resolved_at", errors.New("resolved_at is required for status resolved")
+	case acknowledgedAt != nil && resolvedAt != nil && resolvedAt.Before(*acknowledgedAt):
+		return "resolved_at", errors.New("resolved_at is before acknowledged_at")
+	}
+	return "", nil
+}
+
+// store inserts a batch, counting the alerts already stored as duplicates.
+// A dry run, which passes the fingerprints seen so far in the file, only looks
+// them up.
+func (s *ImportService) store(ctx context.Context, batch []models.Alert, result *ImportResult, seen map[string]struct{}) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if seen != nil {
+		for _, alert := range batch {
+			if _, ok := seen[alert.Fingerprint]; ok {
+				result.Duplicates++
+				continue
+			}
+			seen[alert.Fingerprint] = struct{}{}
+			exists, err := s.alerts.AlertExists(ctx, alert.Fingerprint)
+			if err != nil {
+				return fmt.Errorf("checking for duplicates: %w", err)
+			}
+			if exists {
+				result.Duplicates++
+				continue
+			}
+			result.Inserted++
+			if len(result.Alerts) < importPreviewSize {
+				result.Alerts = append(result.Alerts, alert)
+			}
+		}
+		return nil
+	}
+
+	if s.partitions != nil {
+		oldest, newest := batch[0].CreatedAt, batch[0].CreatedAt
+		for _, alert := range batch[1:] {
+			oldest, newest = minTime(oldest, alert.CreatedAt), maxTime(newest, alert.CreatedAt)
+		}
+		if err := s.partitions.EnsureRange(ctx, oldest, newest); err != nil {
+			return fmt.Errorf("creating partitions: %w", err)
+		}
+	}
+
+	inserted, err := s.alerts.RestoreAlerts(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("storing imported alerts: %w", err)
+	}
+	result.Inserted += inserted
+	result.Duplicates += len(batch) - inserted
+	return nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/importer"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestImportService(t *testing.T, batchSize int) (*ImportService, *mocks.AlertStorageInterface) {
+	storage := mocks.NewAlertStorageInterface(t)
+	s := NewImportService(storage)
+	s.batchSize = batchSize
+	ids := 0
+	s.newID = func() string {
+		ids++
+		return fmt.Sprintf("imported-%d", ids)
+	}
+	return s, storage
+}
+
+const importCSV = `created_at,source,severity,description,status,acknowledged_at,resolved_at,src_ip
+2024-03-01T10:00:00Z,firewall,high,Blocked outbound connection,resolved,2024-03-01T10:05:00Z,2024-03-01T11:00:00Z,10.0.0.7
+2024-03-01T10:00:00Z,firewall,high,Blocked outbound connection,resolved,2024-03-01T10:05:00Z,2024-03-01T11:00:00Z,10.0.0.7
+2024-03-02T10:00:00Z,,5,Port scan,,,,
+2024-03-03T10:00:00Z,printer,low,Paper jam,,,,
+2024-03-04T10:00:00Z,ids,low,Late ack,acknowledged,2024-03-01T00:00:00Z,,
+2024-03-05T10:00:00Z,ids,urgent,Odd severity,,,,
+,ids,low,No time,,,,
+2024-03-06T10:00:00Z,ids,low,Already stored,,,,
+`
+
+func TestImportService_Import(t *testing.T) {
+	ctx := context.Background()
+	s, storage := newTestImportService(t, 2)
+
+	// Like the storage's fingerprint conflict, repeats are skipped
+	var stored []models.Alert
+	fingerprints := map[string]bool{}
+	storage.On("RestoreAlerts", ctx, mock.Anything).Return(func(_ context.Context, alerts []models.Alert) (int, error) {
+		inserted := 0
+		for _, alert := range alerts {
+			if alert.Description != "Already stored" && !fingerprints[alert.Fingerprint] {
+				fingerprints[alert.Fingerprint] = true
+				stored = append(stored, alert)
+				inserted++
+			}
+		}
+		return inserted, nil
+	}).Twice()
+
+	result, err := s.Import(ctx, strings.NewReader(importCSV), ImportOptions{Format: importer.FormatCSV, DefaultSource: "ids"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 8, result.Rows)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 2, result.Duplicates, "a repeated row and an alert already stored")
+	assert.Equal(t, 4, result.Rejected)
+	assert.Equal(t, []ImportError{
+		{Line: 5, Field: "source", Error: `invalid source "printer"`},
+		{Line: 6, Field: "acknowledged_at", Error: "acknowledged_at is before created_at"},
+		{Line: 7, Field: "severity", Error: `invalid severity "urgent"`},
+		{Line: 8, Field: "created_at", Error: "created_at is required"},
+	}, result.Errors)
+	assert.Empty(t, result.Alerts, "only a dry run previews alerts")
+
+	require.Len(t, stored, 2)
+	resolved := stored[0]
+	assert.Equal(t, "imported-1", resolved.ID)
+	assert.Equal(t, models.StatusResolved, resolved.Status)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 5, 0, 0, time.UTC), *resolved.AcknowledgedAt)
+	assert.Equal(t, time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC), *resolved.ResolvedAt)
+	assert.Equal(t, models.Fingerprint("firewall", "high", "Blocked outbound connection", resolved.CreatedAt), resolved.Fingerprint)
+	assert.Nil(t, resolved.IPAddress, "not enriched")
+	assert.Empty(t, resolved.EnrichmentVersions, "left for re-enrichment")
+
+	var event struct {
+		Indicators map[string]string `json:"indicators"`
+		Raw        map[string]string `json:"raw"`
+	}
+	require.NoError(t, json.Unmarshal(resolved.WholeEvent, &event))
+	assert.Equal(t, map[string]string{"src_ip": "10.0.0.7"}, event.Indicators)
+	assert.Equal(t, "Blocked outbound connection", event.Raw["description"])
+
+	defaulted := stored[1]
+	assert.Equal(t, "ids", defaulted.Source)
+	assert.Equal(t, "medium", defaulted.Severity)
+	assert.Equal(t, models.StatusOpen, defaulted.Status)
+	assert.Nil(t, defaulted.AcknowledgedAt)
+}
+
+func TestImportService_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestImportService(t, 10)
+	data := `created_at,source,severity,description,status,acknowledged_at,resolved_at
+2024-03-01T10:00:00Z,ids,low,Acked without time,acknowledged,,
+2024-03-01T10:00:00Z,ids,low,Resolved without time,resolved,2024-03-01T10:05:00Z,
+2024-03-01T10:00:00Z,ids,low,Acked but resolved,acknowledged,2024-03-01T10:05:00Z,2024-03-01T11:00:00Z
+2024-03-01T10:00:00Z,ids,low,Open but acked,open,2024-03-01T10:05:00Z,
+2024-03-01T10:00:00Z,ids,low,Resolved before acked,resolved,2024-03-01T11:00:00Z,2024-03-01T10:30:00Z
+`
+
+	result, err := s.Import(ctx, strings.NewReader(data), ImportOptions{Format: importer.FormatCSV})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Inserted)
+	assert.Equal(t, []ImportError{
+		{Line: 2, Field: "acknowledged_at", Error: "acknowledged_at is required for status acknowledged"},
+		{Line: 3, Field: "resolved_at", Error: "resolved_at is required for status resolved"},
+		{Line: 4, Field: "resolved_at", Error: "resolved_at is set but status is acknowledged"},
+		{Line: 5, Field: "acknowledged_at", Error: "acknowledged_at is set but status is open"},
+		{Line: 6, Field: "resolved_at", Error: "resolved_at is before acknowledged_at"},
+	}, result.Errors)
+}
+
+func TestImportService_DryRun(t *testing.T) {
+	ctx := context.Background()
+	s, storage := newTestImportService(t, 2)
+	data := `{"source":"ids","severity":"high","description":"Beacon","created_at":"2024-03-01T10:00:00Z","src_ip":"10.1.1.1"}
+{"source":"ids","severity":"high","description":"Known","created_at":"2024-03-01T10:00:00Z"}
+{"source":"ids","severity":"high"}
+`
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	storage.On("AlertExists", ctx, models.Fingerprint("ids", "high", "Beacon", created)).Return(false, nil).Once()
+	storage.On("AlertExists", ctx, models.Fingerprint("ids", "high", "Known", created)).Return(true, nil).Once()
+
+	result, err := s.Import(ctx, strings.NewReader(data), ImportOptions{Format: importer.FormatNDJSON, Enrich: true, DryRun: true})
+
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 3, result.Rows)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, 1, result.Duplicates)
+	assert.Equal(t, []ImportError{{Line: 3, Field: "description", Error: "description is required"}}, result.Errors)
+
+	require.Len(t, result.Alerts, 1)
+	preview := result.Alerts[0]
+	assert.Equal(t, "Beacon", preview.Description)
+	require.NotNil(t, preview.IPAddress)
+	assert.Equal(t, "10.1.1.1", *preview.IPAddress, "enriched from the row's src_ip")
+	assert.Equal(t, EnrichmentVersion, preview.EnrichmentVersions["ip_address"])
+	storage.AssertNotCalled(t, "RestoreAlerts", mock.Anything, mock.Anything)
+}
+
+func TestImportService_DryRunCountsRepeatsAcrossBatches(t *testing.T) {
+	ctx := context.Background()
+	s, storage := newTestImportService(t, 2)
+	data := `{"source":"ids","severity":"high","description":"One","created_at":"2024-03-01T10:00:00Z"}
+{"source":"ids","severity":"high","description":"Two","created_at":"2024-03-01T10:00:00Z"}
+{"source":"ids","severity":"high","description":"One","created_at":"2024-03-01T10:00:00Z"}
+`
+	// Each fingerprint is looked up once, though the repeat is in the next batch
+	storage.On("AlertExists", ctx, mock.Anything).Return(false, nil).Twice()
+
+	result, err := s.Import(ctx, strings.NewReader(data), ImportOptions{Format: importer.FormatNDJSON, DryRun: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Equal(t, 1, result.Duplicates, "counted as an import would")
+	assert.Len(t, result.Alerts, 2)
+}
+
+func TestImportService_InvalidImport(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestImportService(t, 2)
+
+	_, err := s.Import(ctx, strings.NewReader("{}"), ImportOptions{Format: "xml"})
+	assert.ErrorIs(t, err, ErrInvalidImport)
+
+	_, err = s.Import(ctx, strings.NewReader(importCSV), ImportOptions{Format: importer.FormatCSV, DefaultSource: "printer"})
+	assert.ErrorIs(t, err, ErrInvalidImport)
+
+	_, err = s.Import(ctx, strings.NewReader(""), ImportOptions{Format: importer.FormatCSV})
+	assert.ErrorIs(t, err, ErrInvalidImport, "a CSV file needs a header")
+}
+
+func TestImportService_StorageFailure(t *testing.T) {
+	ctx := context.Background()
+	s, storage := newTestImportService(t, 1)
+	storage.On("RestoreAlerts", ctx, mock.Anything).Return(1, nil).Once()
+	storage.On("RestoreAlerts", ctx, mock.Anything).Return(0, assert.AnError).Once()
+
+	result, err := s.Import(ctx, strings.NewReader(importCSV), ImportOptions{Format: importer.FormatCSV, DefaultSource: "ids"})
+
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, result.Inserted, "the result reports what was stored before the failure")
+}
+
+func TestImportService_ErrorsAreCapped(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestImportService(t, 2)
+	data := strings.Repeat(`{"source":"printer"}`+"\n", maxImportErrors+5)
+
+	result, err := s.Import(ctx, strings.NewReader(data), ImportOptions{Format: importer.FormatNDJSON})
+
+	require.NoError(t, err)
+	assert.Equal(t, maxImportErrors+5, result.Rejected)
+	assert.Len(t, result.Errors, maxImportErrors)
+	assert.True(t, result.ErrorsTruncated)
+}
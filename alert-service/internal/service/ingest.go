@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"censys_alert_system/external"
+)
+
+// IngestError describes why a single pushed alert was rejected
+type IngestError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// IngestResult summarises a pushed batch
+type IngestResult struct {
+	Accepted int           `json:"accepted"`
+	Rejected int           `json:"rejected"`
+	Errors   []IngestError `json:"errors,omitempty"`
+}
+
+// Reject records a rejected alert at the given batch index
+func (r *IngestResult) Reject(index int, reason string) {
+	r.Rejected++
+	r.Errors = append(r.Errors, IngestError{Index: index, Error: reason})
+}
+
+// IngestAlerts validates pushed alerts and stores the valid ones through the same
+// enrichment and storage path as PerformSync. channel names the ingestion path for logs.
+// Invalid alerts are rejected and the rest of the batch continues. A storage error
+// or cancellation stops the batch instead: the error is returned with the result
+// for the alerts before it, so the caller can retry the remainder later.
+func (s *AlertService) IngestAlerts(ctx context.Context, channel string, alerts []external.ExternalAlert) (IngestResult, error) {
+	var result IngestResult
+	for i, extAlert := range alerts {
+		if err := ctx.Err(); err != nil {
+			log.Printf("[INGEST] %s: cancelled at alert %d: accepted %d, rejected %d", channel, i, result.Accepted, result.Rejected)
+			return result, fmt.Errorf("service: ingestion cancelled at alert %d: %w", i, err)
+		}
+
+		if err := ValidateExternalAlert(extAlert); err != nil {
+			result.Reject(i, err.Error())
+			continue
+		}
+
+		if _, err := s.ingestAlert(ctx, extAlert); err != nil {
+			log.Printf("[INGEST] %s: error storing alert %d: accepted %d, rejected %d: %v", channel, i, result.Accepted, result.Rejected, err)
+			return result, fmt.Errorf("service: error storing alert %d: %w", i, err)
+		}
+
+		result.Accepted++
+	}
+
+	log.Printf("[INGEST] %s: accepted %d, rejected %d", channel, result.Accepted, result.Rejected)
+	return result, nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateExternalAlert(t *testing.T) {
+	valid := external.ExternalAlert{Source: "firewall", Severity: "high", Description: "blocked", CreatedAt: time.Now()}
+
+	assert.NoError(t, ValidateExternalAlert(valid))
+
+	invalidSource := valid
+	invalidSource.Source = "toaster"
+	assert.ErrorContains(t, ValidateExternalAlert(invalidSource), "invalid source")
+
+	invalidSeverity := valid
+	invalidSeverity.Severity = "urgent"
+	assert.ErrorContains(t, ValidateExternalAlert(invalidSeverity), "invalid severity")
+
+	missingDescription := valid
+	missingDescription.Description = " "
+	assert.ErrorContains(t, ValidateExternalAlert(missingDescription), "description is required")
+
+	missingCreatedAt := valid
+	missingCreatedAt.CreatedAt = time.Time{}
+	assert.ErrorContains(t, ValidateExternalAlert(missingCreatedAt), "created_at is required")
+}
+
+func TestAlertService_IngestAlerts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores valid alerts and reports rejects", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		createdAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		raw := json.RawMessage(`{"severity":"high","description":"blocked","vendor_id":42}`)
+		alerts := []external.ExternalAlert{
+			{Source: "firewall", Severity: "high", Description: "blocked", CreatedAt: createdAt, Raw: raw},
+			{Source: "firewall", Severity: "urgent", Description: "bad severity", CreatedAt: createdAt},
+			{Source: "firewall", Severity: "high", Description: "no created_at"},
+		}
+
+		var stored models.Alert
+		mockStorage.On("CreateAlert", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = *args.Get(1).(*models.Alert)
+		}).Return(nil).Once()
+
+		result, err := service.IngestAlerts(ctx, "webhook:firewall", alerts)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Accepted)
+		assert.Equal(t, 2, result.Rejected)
+		require.Len(t, result.Errors, 2)
+		assert.Equal(t, 1, result.Errors[0].Index)
+		assert.Equal(t, IngestError{Index: 2, Error: "created_at is required"}, result.Errors[1])
+
+		assert.Equal(t, createdAt, stored.CreatedAt)
+		var wholeEvent map[string]interface{}
+		require.NoError(t, json.Unmarshal(stored.WholeEvent, &wholeEvent))
+		assert.Equal(t, float64(42), wholeEvent["raw"].(map[string]interface{})["vendor_id"])
+	})
+
+	t.Run("indicators are kept and promote the source address", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		var stored models.Alert
+		mockStorage.On("CreateAlert", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			stored = *args.Get(1).(*models.Alert)
+		}).Return(nil)
+
+		result, err := service.IngestAlerts(ctx, "syslog:udp", []external.ExternalAlert{{
+			Source:      "ids",
+			Severity:    "high",
+			Description: "Port scan",
+			CreatedAt:   time.Now(),
+			Indicators:  map[string]string{"src_ip": "192.0.2.10", "dst_port": "22"},
+		}})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Accepted)
+		require.NotNil(t, stored.IPAddress)
+		assert.Equal(t, "192.0.2.10", *stored.IPAddress)
+		var wholeEvent map[string]interface{}
+		require.NoError(t, json.Unmarshal(stored.WholeEvent, &wholeEvent))
+		assert.Equal(t, map[string]interface{}{"src_ip": "192.0.2.10", "dst_port": "22"}, wholeEvent["indicators"])
+	})
+
+	t.Run("storage failure stops the batch", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		mockStorage.On("CreateAlert", ctx, mock.Anything).Return(nil).Once()
+		mockStorage.On("CreateAlert", ctx, mock.Anything).Return(errors.New("database error")).Once()
+
+		result, err := service.IngestAlerts(ctx, "webhook:ids", []external.ExternalAlert{
+			{Source: "ids", Severity: "low", Description: "scan", CreatedAt: time.Now()},
+			{Source: "ids", Severity: "bogus", Description: "bad severity", CreatedAt: time.Now()},
+			{Source: "ids", Severity: "low", Description: "probe", CreatedAt: time.Now()},
+			{Source: "ids", Severity: "low", Description: "never reached", CreatedAt: time.Now()},
+		})
+
+		assert.ErrorContains(t, err, "error storing alert 2")
+		assert.Equal(t, 1, result.Accepted)
+		assert.Equal(t, 1, result.Rejected, "validation rejects before the failure are kept")
+		mockStorage.AssertNumberOfCalls(t, "CreateAlert", 2)
+	})
+
+	t.Run("cancelled context stops the batch", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		result, err := service.IngestAlerts(cancelled, "webhook:ids", []external.ExternalAlert{
+			{Source: "ids", Severity: "low", Description: "scan", CreatedAt: time.Now()},
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, result.Rejected)
+	})
+
+	t.Run("already stored alert is accepted without notifying", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockPublisher := mocks.NewEventPublisher(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetPublisher(mockPublisher)
+
+		// CreateAlert leaves the ID empty when the fingerprint is already stored
+		mockStorage.On("CreateAlert", ctx, mock.Anything).Return(nil)
+
+		result, err := service.IngestAlerts(ctx, "webhook:ids", []external.ExternalAlert{
+			{Source: "ids", Severity: "low", Description: "scan", CreatedAt: time.Now()},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Accepted)
+		mockPublisher.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+}
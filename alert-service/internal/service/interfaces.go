@@ -16,7 +16,15 @@ type AlertStorageInterface interface {
 	GetAlerts(ctx context.Context) ([]models.Alert, error)
 	GetAlertByID(ctx context.Context, id string) (*models.Alert, error)
 	GetAlertsByDays(ctx context.Context, days int) ([]models.Alert, error)
-	CreateAlert(ctx context.Context, source, severity, description string, wholeEvent []byte, enrichmentType, ipAddress string, createdAt time.Time) error
+	ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error)
+	ListAlertsAfter(ctx context.Context, filter models.AlertFilter, afterCreatedAt time.Time, afterID string, limit int) ([]models.Alert, error)
+	CountAlerts(ctx context.Context, filter models.AlertFilter) (int, error)
+	AlertExists(ctx context.Context, fingerprint string) (bool, error)
+	CreateAlert(ctx context.Context, alert *models.Alert) error
+	CreateAlerts(ctx context.Context, alerts []models.Alert) error
+	UpdateAlertStatus(ctx context.Context, id string, from []string, status string) (*models.Alert, error)
+	UpdateAlertEnrichment(ctx context.Context, alert *models.Alert) error
+	RestoreAlerts(ctx context.Context, alerts []models.Alert) (int, error)
 	GetLastSyncTime(ctx context.Context) (time.Time, error)
 	UpdateLastSyncTime(ctx context.Context, t time.Time) error
 }
@@ -29,4 +37,129 @@ type APIClientInterface interface {
 	CheckHealth(ctx context.Context) error
 	FetchAllAlerts(ctx context.Context) ([]external.ExternalAlert, error)
 	FetchAlertsSince(ctx context.Context, since time.Time) ([]external.ExternalAlert, error)
-}
\ No newline at end of file
+}
+
+// PagedAPIClientInterface is implemented by clients that hand over alerts a
+// page at a time, oldest first, so a sync can checkpoint between pages.
+// Implemented by external.MockAPIClient
+//
+//go:generate mockery --name=PagedAPIClientInterface --output=./mocks --outpkg=mocks
+type PagedAPIClientInterface interface {
+	APIClientInterface
+	FetchAlertPages(ctx context.Context, since time.Time, cursor string, handle external.PageHandler) error
+}
+
+// RangeAPIClientInterface is implemented by clients that can fetch a bounded
+// time range a page at a time, for backfills.
+// Implemented by external.MockAPIClient
+//
+//go:generate mockery --name=RangeAPIClientInterface --output=./mocks --outpkg=mocks
+type RangeAPIClientInterface interface {
+	FetchAlertRange(ctx context.Context, from, to time.Time, cursor string, handle external.PageHandler) error
+}
+
+// ConnectorStorageInterface defines the contract for persisted connector watermarks and health.
+// Implemented by storage.ConnectorStorage
+//
+//go:generate mockery --name=ConnectorStorageInterface --output=./mocks --outpkg=mocks
+type ConnectorStorageInterface interface {
+	GetConnectorState(ctx context.Context, name string) (*models.ConnectorState, error)
+	SaveConnectorState(ctx context.Context, state *models.ConnectorState) error
+}
+
+// PagerInterface defines the contract for paging on-call about alerts.
+// Incidents are keyed by the alert fingerprint.
+// Implemented by external.PagerDutyClient
+//
+//go:generate mockery --name=PagerInterface --output=./mocks --outpkg=mocks
+type PagerInterface interface {
+	Trigger(ctx context.Context, alert models.Alert) error
+	Acknowledge(ctx context.Context, dedupKey string) error
+	Resolve(ctx context.Context, dedupKey string) error
+}
+
+// EscalationStorageInterface defines the contract for persisted escalation state.
+// Implemented by storage.EscalationStorage
+//
+//go:generate mockery --name=EscalationStorageInterface --output=./mocks --outpkg=mocks
+type EscalationStorageInterface interface {
+	CreateEscalation(ctx context.Context, alertID string, nextNotifyAt time.Time) error
+	RecordInitialPage(ctx context.Context, alertID, status string, nextNotifyAt time.Time) (bool, error)
+	ClaimDueEscalations(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.Escalation, error)
+	UpdateEscalation(ctx context.Context, esc *models.Escalation) (bool, error)
+	StopEscalation(ctx context.Context, alertID string) (*models.Escalation, error)
+}
+
+// DeadLetterStorageInterface defines the contract for persisted dead letters.
+// Implemented by storage.DeadLetterStorage
+//
+//go:generate mockery --name=DeadLetterStorageInterface --output=./mocks --outpkg=mocks
+type DeadLetterStorageInterface interface {
+	CreateDeadLetter(ctx context.Context, dl *models.DeadLetter) error
+	GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error)
+	ListDeadLetters(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error)
+	ClaimDueDeadLetters(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.DeadLetter, error)
+	UpdateDeadLetter(ctx context.Context, dl *models.DeadLetter) error
+	RequeueDeadLetters(ctx context.Context, filter models.DeadLetterFilter, at time.Time) (int, error)
+}
+
+// BackfillStorageInterface defines the contract for persisted backfill jobs.
+// Implemented by storage.BackfillStorage
+//
+//go:generate mockery --name=BackfillStorageInterface --output=./mocks --outpkg=mocks
+type BackfillStorageInterface interface {
+	CreateBackfillJob(ctx context.Context, job *models.BackfillJob) error
+	GetBackfillJob(ctx context.Context, id string) (*models.BackfillJob, error)
+	ListBackfillJobs(ctx context.Context, statuses []string) ([]models.BackfillJob, error)
+	ClaimBackfillJob(ctx context.Context, now, leaseUntil time.Time) (*models.BackfillJob, error)
+	SaveBackfillProgress(ctx context.Context, job *models.BackfillJob, leaseUntil time.Time) (string, error)
+	FinishBackfillJob(ctx context.Context, job *models.BackfillJob) error
+	TransitionBackfillJob(ctx context.Context, id string, from []string, status string) (*models.BackfillJob, error)
+}
+
+// ReenrichmentStorageInterface defines the contract for persisted re-enrichment jobs.
+// Implemented by storage.ReenrichmentStorage
+//
+//go:generate mockery --name=ReenrichmentStorageInterface --output=./mocks --outpkg=mocks
+type ReenrichmentStorageInterface interface {
+	CreateReenrichmentJob(ctx context.Context, job *models.ReenrichmentJob) error
+	GetReenrichmentJob(ctx context.Context, id string) (*models.ReenrichmentJob, error)
+	ListReenrichmentJobs(ctx context.Context, statuses []string) ([]models.ReenrichmentJob, error)
+	ClaimReenrichmentJob(ctx context.Context, now, leaseUntil time.Time) (*models.ReenrichmentJob, error)
+	SaveReenrichmentProgress(ctx context.Context, job *models.ReenrichmentJob, leaseUntil time.Time) (string, error)
+	FinishReenrichmentJob(ctx context.Context, job *models.ReenrichmentJob) error
+	TransitionReenrichmentJob(ctx context.Context, id string, from []string, status string) (*models.ReenrichmentJob, error)
+}
+
+// PartitionStorageInterface defines the contract for managing the alerts
+// table's partitions and expiring old alerts.
+// Implemented by storage.PartitionStorage
+//
+//go:generate mockery --name=PartitionStorageInterface --output=./mocks --outpkg=mocks
+type PartitionStorageInterface interface {
+	ListAlertPartitions(ctx context.Context) ([]models.AlertPartition, error)
+	CreateAlertPartition(ctx context.Context, partition models.AlertPartition) error
+	DropAlertPartition(ctx context.Context, name string) error
+	DetachAlertPartition(ctx context.Context, name string) error
+	DeleteExpiredAlerts(ctx context.Context, severity string, before time.Time, limit int) (int, error)
+	PurgeAlertReferences(ctx context.Context) error
+}
+
+// PartitionArchiver defines the contract for archiving alerts before
+// retention removes them.
+// Implemented by ArchiveService
+//
+//go:generate mockery --name=PartitionArchiver --output=./mocks --outpkg=mocks
+type PartitionArchiver interface {
+	ArchivePartition(ctx context.Context, partition models.AlertPartition) error
+	ArchiveExpired(ctx context.Context, severity string, before time.Time) error
+}
+
+// EventPublisher defines the contract for publishing live alert events.
+// Publish must not block on slow consumers.
+// Implemented by events.Broker
+//
+//go:generate mockery --name=EventPublisher --output=./mocks --outpkg=mocks
+type EventPublisher interface {
+	Publish(eventType string, alert models.Alert) models.AlertEvent
+}
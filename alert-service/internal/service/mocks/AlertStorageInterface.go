@@ -16,17 +16,91 @@ type AlertStorageInterface struct {
 	mock.Mock
 }
 
-// CreateAlert provides a mock function with given fields: ctx, source, severity, description, wholeEvent, enrichmentType, ipAddress, createdAt
-func (_m *AlertStorageInterface) CreateAlert(ctx context.Context, source string, severity string, description string, wholeEvent []byte, enrichmentType string, ipAddress string, createdAt time.Time) error {
-	ret := _m.Called(ctx, source, severity, description, wholeEvent, enrichmentType, ipAddress, createdAt)
+// AlertExists provides a mock function with given fields: ctx, fingerprint
+func (_m *AlertStorageInterface) AlertExists(ctx context.Context, fingerprint string) (bool, error) {
+	ret := _m.Called(ctx, fingerprint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AlertExists")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, fingerprint)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, fingerprint)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, fingerprint)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountAlerts provides a mock function with given fields: ctx, filter
+func (_m *AlertStorageInterface) CountAlerts(ctx context.Context, filter models.AlertFilter) (int, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountAlerts")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AlertFilter) (int, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.AlertFilter) int); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.AlertFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAlert provides a mock function with given fields: ctx, alert
+func (_m *AlertStorageInterface) CreateAlert(ctx context.Context, alert *models.Alert) error {
+	ret := _m.Called(ctx, alert)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateAlert")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, []byte, string, string, time.Time) error); ok {
-		r0 = rf(ctx, source, severity, description, wholeEvent, enrichmentType, ipAddress, createdAt)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Alert) error); ok {
+		r0 = rf(ctx, alert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateAlerts provides a mock function with given fields: ctx, alerts
+func (_m *AlertStorageInterface) CreateAlerts(ctx context.Context, alerts []models.Alert) error {
+	ret := _m.Called(ctx, alerts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAlerts")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.Alert) error); ok {
+		r0 = rf(ctx, alerts)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -152,6 +226,142 @@ func (_m *AlertStorageInterface) GetLastSyncTime(ctx context.Context) (time.Time
 	return r0, r1
 }
 
+// ListAlerts provides a mock function with given fields: ctx, filter
+func (_m *AlertStorageInterface) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAlerts")
+	}
+
+	var r0 []models.Alert
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AlertFilter) ([]models.Alert, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.AlertFilter) []models.Alert); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Alert)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.AlertFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAlertsAfter provides a mock function with given fields: ctx, filter, afterCreatedAt, afterID, limit
+func (_m *AlertStorageInterface) ListAlertsAfter(ctx context.Context, filter models.AlertFilter, afterCreatedAt time.Time, afterID string, limit int) ([]models.Alert, error) {
+	ret := _m.Called(ctx, filter, afterCreatedAt, afterID, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAlertsAfter")
+	}
+
+	var r0 []models.Alert
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AlertFilter, time.Time, string, int) ([]models.Alert, error)); ok {
+		return rf(ctx, filter, afterCreatedAt, afterID, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.AlertFilter, time.Time, string, int) []models.Alert); ok {
+		r0 = rf(ctx, filter, afterCreatedAt, afterID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Alert)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.AlertFilter, time.Time, string, int) error); ok {
+		r1 = rf(ctx, filter, afterCreatedAt, afterID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreAlerts provides a mock function with given fields: ctx, alerts
+func (_m *AlertStorageInterface) RestoreAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+	ret := _m.Called(ctx, alerts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreAlerts")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.Alert) (int, error)); ok {
+		return rf(ctx, alerts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []models.Alert) int); ok {
+		r0 = rf(ctx, alerts)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []models.Alert) error); ok {
+		r1 = rf(ctx, alerts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAlertEnrichment provides a mock function with given fields: ctx, alert
+func (_m *AlertStorageInterface) UpdateAlertEnrichment(ctx context.Context, alert *models.Alert) error {
+	ret := _m.Called(ctx, alert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAlertEnrichment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Alert) error); ok {
+		r0 = rf(ctx, alert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateAlertStatus provides a mock function with given fields: ctx, id, from, status
+func (_m *AlertStorageInterface) UpdateAlertStatus(ctx context.Context, id string, from []string, status string) (*models.Alert, error) {
+	ret := _m.Called(ctx, id, from, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateAlertStatus")
+	}
+
+	var r0 *models.Alert
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) (*models.Alert, error)); ok {
+		return rf(ctx, id, from, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) *models.Alert); ok {
+		r0 = rf(ctx, id, from, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Alert)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, string) error); ok {
+		r1 = rf(ctx, id, from, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateLastSyncTime provides a mock function with given fields: ctx, t
 func (_m *AlertStorageInterface) UpdateLastSyncTime(ctx context.Context, t time.Time) error {
 	ret := _m.Called(ctx, t)
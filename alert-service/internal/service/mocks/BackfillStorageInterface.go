@@ -0,0 +1,215 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "censys_alert_system/internal/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// BackfillStorageInterface is an autogenerated mock type for the BackfillStorageInterface type
+type BackfillStorageInterface struct {
+	mock.Mock
+}
+
+// ClaimBackfillJob provides a mock function with given fields: ctx, now, leaseUntil
+func (_m *BackfillStorageInterface) ClaimBackfillJob(ctx context.Context, now time.Time, leaseUntil time.Time) (*models.BackfillJob, error) {
+	ret := _m.Called(ctx, now, leaseUntil)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimBackfillJob")
+	}
+
+	var r0 *models.BackfillJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) (*models.BackfillJob, error)); ok {
+		return rf(ctx, now, leaseUntil)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) *models.BackfillJob); ok {
+		r0 = rf(ctx, now, leaseUntil)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BackfillJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, now, leaseUntil)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateBackfillJob provides a mock function with given fields: ctx, job
+func (_m *BackfillStorageInterface) CreateBackfillJob(ctx context.Context, job *models.BackfillJob) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateBackfillJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.BackfillJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FinishBackfillJob provides a mock function with given fields: ctx, job
+func (_m *BackfillStorageInterface) FinishBackfillJob(ctx context.Context, job *models.BackfillJob) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishBackfillJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.BackfillJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetBackfillJob provides a mock function with given fields: ctx, id
+func (_m *BackfillStorageInterface) GetBackfillJob(ctx context.Context, id string) (*models.BackfillJob, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBackfillJob")
+	}
+
+	var r0 *models.BackfillJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.BackfillJob, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.BackfillJob); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BackfillJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBackfillJobs provides a mock function with given fields: ctx, statuses
+func (_m *BackfillStorageInterface) ListBackfillJobs(ctx context.Context, statuses []string) ([]models.BackfillJob, error) {
+	ret := _m.Called(ctx, statuses)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBackfillJobs")
+	}
+
+	var r0 []models.BackfillJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]models.BackfillJob, error)); ok {
+		return rf(ctx, statuses)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []models.BackfillJob); ok {
+		r0 = rf(ctx, statuses)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.BackfillJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, statuses)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveBackfillProgress provides a mock function with given fields: ctx, job, leaseUntil
+func (_m *BackfillStorageInterface) SaveBackfillProgress(ctx context.Context, job *models.BackfillJob, leaseUntil time.Time) (string, error) {
+	ret := _m.Called(ctx, job, leaseUntil)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveBackfillProgress")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.BackfillJob, time.Time) (string, error)); ok {
+		return rf(ctx, job, leaseUntil)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.BackfillJob, time.Time) string); ok {
+		r0 = rf(ctx, job, leaseUntil)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.BackfillJob, time.Time) error); ok {
+		r1 = rf(ctx, job, leaseUntil)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransitionBackfillJob provides a mock function with given fields: ctx, id, from, status
+func (_m *BackfillStorageInterface) TransitionBackfillJob(ctx context.Context, id string, from []string, status string) (*models.BackfillJob, error) {
+	ret := _m.Called(ctx, id, from, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransitionBackfillJob")
+	}
+
+	var r0 *models.BackfillJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) (*models.BackfillJob, error)); ok {
+		return rf(ctx, id, from, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) *models.BackfillJob); ok {
+		r0 = rf(ctx, id, from, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.BackfillJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, string) error); ok {
+		r1 = rf(ctx, id, from, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewBackfillStorageInterface creates a new instance of BackfillStorageInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewBackfillStorageInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *BackfillStorageInterface {
+	mock := &BackfillStorageInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,77 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "censys_alert_system/internal/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ConnectorStorageInterface is an autogenerated mock type for the ConnectorStorageInterface type
+type ConnectorStorageInterface struct {
+	mock.Mock
+}
+
+// GetConnectorState provides a mock function with given fields: ctx, name
+func (_m *ConnectorStorageInterface) GetConnectorState(ctx context.Context, name string) (*models.ConnectorState, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetConnectorState")
+	}
+
+	var r0 *models.ConnectorState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.ConnectorState, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.ConnectorState); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ConnectorState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveConnectorState provides a mock function with given fields: ctx, state
+func (_m *ConnectorStorageInterface) SaveConnectorState(ctx context.Context, state *models.ConnectorState) error {
+	ret := _m.Called(ctx, state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveConnectorState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ConnectorState) error); ok {
+		r0 = rf(ctx, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewConnectorStorageInterface creates a new instance of ConnectorStorageInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewConnectorStorageInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ConnectorStorageInterface {
+	mock := &ConnectorStorageInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
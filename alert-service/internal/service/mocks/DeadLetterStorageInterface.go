@@ -0,0 +1,185 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "censys_alert_system/internal/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// DeadLetterStorageInterface is an autogenerated mock type for the DeadLetterStorageInterface type
+type DeadLetterStorageInterface struct {
+	mock.Mock
+}
+
+// ClaimDueDeadLetters provides a mock function with given fields: ctx, now, leaseUntil, limit
+func (_m *DeadLetterStorageInterface) ClaimDueDeadLetters(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]models.DeadLetter, error) {
+	ret := _m.Called(ctx, now, leaseUntil, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDueDeadLetters")
+	}
+
+	var r0 []models.DeadLetter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, int) ([]models.DeadLetter, error)); ok {
+		return rf(ctx, now, leaseUntil, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, int) []models.DeadLetter); ok {
+		r0 = rf(ctx, now, leaseUntil, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.DeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time, int) error); ok {
+		r1 = rf(ctx, now, leaseUntil, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateDeadLetter provides a mock function with given fields: ctx, dl
+func (_m *DeadLetterStorageInterface) CreateDeadLetter(ctx context.Context, dl *models.DeadLetter) error {
+	ret := _m.Called(ctx, dl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateDeadLetter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.DeadLetter) error); ok {
+		r0 = rf(ctx, dl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetDeadLetter provides a mock function with given fields: ctx, id
+func (_m *DeadLetterStorageInterface) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeadLetter")
+	}
+
+	var r0 *models.DeadLetter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.DeadLetter, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.DeadLetter); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.DeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeadLetters provides a mock function with given fields: ctx, filter
+func (_m *DeadLetterStorageInterface) ListDeadLetters(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeadLetters")
+	}
+
+	var r0 []models.DeadLetter
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.DeadLetterFilter) ([]models.DeadLetter, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.DeadLetterFilter) []models.DeadLetter); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.DeadLetter)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.DeadLetterFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RequeueDeadLetters provides a mock function with given fields: ctx, filter, at
+func (_m *DeadLetterStorageInterface) RequeueDeadLetters(ctx context.Context, filter models.DeadLetterFilter, at time.Time) (int, error) {
+	ret := _m.Called(ctx, filter, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueDeadLetters")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.DeadLetterFilter, time.Time) (int, error)); ok {
+		return rf(ctx, filter, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.DeadLetterFilter, time.Time) int); ok {
+		r0 = rf(ctx, filter, at)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.DeadLetterFilter, time.Time) error); ok {
+		r1 = rf(ctx, filter, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateDeadLetter provides a mock function with given fields: ctx, dl
+func (_m *DeadLetterStorageInterface) UpdateDeadLetter(ctx context.Context, dl *models.DeadLetter) error {
+	ret := _m.Called(ctx, dl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDeadLetter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.DeadLetter) error); ok {
+		r0 = rf(ctx, dl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewDeadLetterStorageInterface creates a new instance of DeadLetterStorageInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDeadLetterStorageInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DeadLetterStorageInterface {
+	mock := &DeadLetterStorageInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,165 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "censys_alert_system/internal/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// EscalationStorageInterface is an autogenerated mock type for the EscalationStorageInterface type
+type EscalationStorageInterface struct {
+	mock.Mock
+}
+
+// ClaimDueEscalations provides a mock function with given fields: ctx, now, leaseUntil, limit
+func (_m *EscalationStorageInterface) ClaimDueEscalations(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]models.Escalation, error) {
+	ret := _m.Called(ctx, now, leaseUntil, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDueEscalations")
+	}
+
+	var r0 []models.Escalation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, int) ([]models.Escalation, error)); ok {
+		return rf(ctx, now, leaseUntil, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, int) []models.Escalation); ok {
+		r0 = rf(ctx, now, leaseUntil, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Escalation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time, int) error); ok {
+		r1 = rf(ctx, now, leaseUntil, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateEscalation provides a mock function with given fields: ctx, alertID, nextNotifyAt
+func (_m *EscalationStorageInterface) CreateEscalation(ctx context.Context, alertID string, nextNotifyAt time.Time) error {
+	ret := _m.Called(ctx, alertID, nextNotifyAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateEscalation")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, alertID, nextNotifyAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordInitialPage provides a mock function with given fields: ctx, alertID, status, nextNotifyAt
+func (_m *EscalationStorageInterface) RecordInitialPage(ctx context.Context, alertID string, status string, nextNotifyAt time.Time) (bool, error) {
+	ret := _m.Called(ctx, alertID, status, nextNotifyAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordInitialPage")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) (bool, error)); ok {
+		return rf(ctx, alertID, status, nextNotifyAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) bool); ok {
+		r0 = rf(ctx, alertID, status, nextNotifyAt)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = rf(ctx, alertID, status, nextNotifyAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StopEscalation provides a mock function with given fields: ctx, alertID
+func (_m *EscalationStorageInterface) StopEscalation(ctx context.Context, alertID string) (*models.Escalation, error) {
+	ret := _m.Called(ctx, alertID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StopEscalation")
+	}
+
+	var r0 *models.Escalation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.Escalation, error)); ok {
+		return rf(ctx, alertID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Escalation); ok {
+		r0 = rf(ctx, alertID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Escalation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, alertID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateEscalation provides a mock function with given fields: ctx, esc
+func (_m *EscalationStorageInterface) UpdateEscalation(ctx context.Context, esc *models.Escalation) (bool, error) {
+	ret := _m.Called(ctx, esc)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateEscalation")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Escalation) (bool, error)); ok {
+		return rf(ctx, esc)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Escalation) bool); ok {
+		r0 = rf(ctx, esc)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Escalation) error); ok {
+		r1 = rf(ctx, esc)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewEscalationStorageInterface creates a new instance of EscalationStorageInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewEscalationStorageInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *EscalationStorageInterface {
+	mock := &EscalationStorageInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,83 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "censys_alert_system/internal/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PagerInterface is an autogenerated mock type for the PagerInterface type
+type PagerInterface struct {
+	mock.Mock
+}
+
+// Acknowledge provides a mock function with given fields: ctx, dedupKey
+func (_m *PagerInterface) Acknowledge(ctx context.Context, dedupKey string) error {
+	ret := _m.Called(ctx, dedupKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Acknowledge")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, dedupKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Resolve provides a mock function with given fields: ctx, dedupKey
+func (_m *PagerInterface) Resolve(ctx context.Context, dedupKey string) error {
+	ret := _m.Called(ctx, dedupKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resolve")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, dedupKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Trigger provides a mock function with given fields: ctx, alert
+func (_m *PagerInterface) Trigger(ctx context.Context, alert models.Alert) error {
+	ret := _m.Called(ctx, alert)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Trigger")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Alert) error); ok {
+		r0 = rf(ctx, alert)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewPagerInterface creates a new instance of PagerInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPagerInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PagerInterface {
+	mock := &PagerInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,67 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "censys_alert_system/internal/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// PartitionArchiver is an autogenerated mock type for the PartitionArchiver type
+type PartitionArchiver struct {
+	mock.Mock
+}
+
+// ArchiveExpired provides a mock function with given fields: ctx, severity, before
+func (_m *PartitionArchiver) ArchiveExpired(ctx context.Context, severity string, before time.Time) error {
+	ret := _m.Called(ctx, severity, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchiveExpired")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, severity, before)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ArchivePartition provides a mock function with given fields: ctx, partition
+func (_m *PartitionArchiver) ArchivePartition(ctx context.Context, partition models.AlertPartition) error {
+	ret := _m.Called(ctx, partition)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchivePartition")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AlertPartition) error); ok {
+		r0 = rf(ctx, partition)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewPartitionArchiver creates a new instance of PartitionArchiver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPartitionArchiver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PartitionArchiver {
+	mock := &PartitionArchiver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,161 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "censys_alert_system/internal/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// PartitionStorageInterface is an autogenerated mock type for the PartitionStorageInterface type
+type PartitionStorageInterface struct {
+	mock.Mock
+}
+
+// CreateAlertPartition provides a mock function with given fields: ctx, partition
+func (_m *PartitionStorageInterface) CreateAlertPartition(ctx context.Context, partition models.AlertPartition) error {
+	ret := _m.Called(ctx, partition)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAlertPartition")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.AlertPartition) error); ok {
+		r0 = rf(ctx, partition)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpiredAlerts provides a mock function with given fields: ctx, severity, before, limit
+func (_m *PartitionStorageInterface) DeleteExpiredAlerts(ctx context.Context, severity string, before time.Time, limit int) (int, error) {
+	ret := _m.Called(ctx, severity, before, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpiredAlerts")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int) (int, error)); ok {
+		return rf(ctx, severity, before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int) int); ok {
+		r0 = rf(ctx, severity, before, limit)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, int) error); ok {
+		r1 = rf(ctx, severity, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DetachAlertPartition provides a mock function with given fields: ctx, name
+func (_m *PartitionStorageInterface) DetachAlertPartition(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DetachAlertPartition")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DropAlertPartition provides a mock function with given fields: ctx, name
+func (_m *PartitionStorageInterface) DropAlertPartition(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DropAlertPartition")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListAlertPartitions provides a mock function with given fields: ctx
+func (_m *PartitionStorageInterface) ListAlertPartitions(ctx context.Context) ([]models.AlertPartition, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAlertPartitions")
+	}
+
+	var r0 []models.AlertPartition
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.AlertPartition, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.AlertPartition); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AlertPartition)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeAlertReferences provides a mock function with given fields: ctx
+func (_m *PartitionStorageInterface) PurgeAlertReferences(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeAlertReferences")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewPartitionStorageInterface creates a new instance of PartitionStorageInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPartitionStorageInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PartitionStorageInterface {
+	mock := &PartitionStorageInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,49 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	external "censys_alert_system/external"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// RangeAPIClientInterface is an autogenerated mock type for the RangeAPIClientInterface type
+type RangeAPIClientInterface struct {
+	mock.Mock
+}
+
+// FetchAlertRange provides a mock function with given fields: ctx, from, to, cursor, handle
+func (_m *RangeAPIClientInterface) FetchAlertRange(ctx context.Context, from time.Time, to time.Time, cursor string, handle external.PageHandler) error {
+	ret := _m.Called(ctx, from, to, cursor, handle)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FetchAlertRange")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time, string, external.PageHandler) error); ok {
+		r0 = rf(ctx, from, to, cursor, handle)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewRangeAPIClientInterface creates a new instance of RangeAPIClientInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRangeAPIClientInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RangeAPIClientInterface {
+	mock := &RangeAPIClientInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,215 @@
+// Code generated by mockery v2.53.5. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "censys_alert_system/internal/models"
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ReenrichmentStorageInterface is an autogenerated mock type for the ReenrichmentStorageInterface type
+type ReenrichmentStorageInterface struct {
+	mock.Mock
+}
+
+// ClaimReenrichmentJob provides a mock function with given fields: ctx, now, leaseUntil
+func (_m *ReenrichmentStorageInterface) ClaimReenrichmentJob(ctx context.Context, now time.Time, leaseUntil time.Time) (*models.ReenrichmentJob, error) {
+	ret := _m.Called(ctx, now, leaseUntil)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimReenrichmentJob")
+	}
+
+	var r0 *models.ReenrichmentJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) (*models.ReenrichmentJob, error)); ok {
+		return rf(ctx, now, leaseUntil)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) *models.ReenrichmentJob); ok {
+		r0 = rf(ctx, now, leaseUntil)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ReenrichmentJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, now, leaseUntil)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateReenrichmentJob provides a mock function with given fields: ctx, job
+func (_m *ReenrichmentStorageInterface) CreateReenrichmentJob(ctx context.Context, job *models.ReenrichmentJob) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReenrichmentJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ReenrichmentJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FinishReenrichmentJob provides a mock function with given fields: ctx, job
+func (_m *ReenrichmentStorageInterface) FinishReenrichmentJob(ctx context.Context, job *models.ReenrichmentJob) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishReenrichmentJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ReenrichmentJob) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetReenrichmentJob provides a mock function with given fields: ctx, id
+func (_m *ReenrichmentStorageInterface) GetReenrichmentJob(ctx context.Context, id string) (*models.ReenrichmentJob, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReenrichmentJob")
+	}
+
+	var r0 *models.ReenrichmentJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.ReenrichmentJob, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.ReenrichmentJob); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ReenrichmentJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListReenrichmentJobs provides a mock function with given fields: ctx, statuses
+func (_m *ReenrichmentStorageInterface) ListReenrichmentJobs(ctx context.Context, statuses []string) ([]models.ReenrichmentJob, error) {
+	ret := _m.Called(ctx, statuses)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListReenrichmentJobs")
+	}
+
+	var r0 []models.ReenrichmentJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]models.ReenrichmentJob, error)); ok {
+		return rf(ctx, statuses)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []models.ReenrichmentJob); ok {
+		r0 = rf(ctx, statuses)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ReenrichmentJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, statuses)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveReenrichmentProgress provides a mock function with given fields: ctx, job, leaseUntil
+func (_m *ReenrichmentStorageInterface) SaveReenrichmentProgress(ctx context.Context, job *models.ReenrichmentJob, leaseUntil time.Time) (string, error) {
+	ret := _m.Called(ctx, job, leaseUntil)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveReenrichmentProgress")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ReenrichmentJob, time.Time) (string, error)); ok {
+		return rf(ctx, job, leaseUntil)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.ReenrichmentJob, time.Time) string); ok {
+		r0 = rf(ctx, job, leaseUntil)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.ReenrichmentJob, time.Time) error); ok {
+		r1 = rf(ctx, job, leaseUntil)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransitionReenrichmentJob provides a mock function with given fields: ctx, id, from, status
+func (_m *ReenrichmentStorageInterface) TransitionReenrichmentJob(ctx context.Context, id string, from []string, status string) (*models.ReenrichmentJob, error) {
+	ret := _m.Called(ctx, id, from, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransitionReenrichmentJob")
+	}
+
+	var r0 *models.ReenrichmentJob
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) (*models.ReenrichmentJob, error)); ok {
+		return rf(ctx, id, from, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) *models.ReenrichmentJob); ok {
+		r0 = rf(ctx, id, from, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ReenrichmentJob)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, string) error); ok {
+		r1 = rf(ctx, id, from, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewReenrichmentStorageInterface creates a new instance of ReenrichmentStorageInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewReenrichmentStorageInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ReenrichmentStorageInterface {
+	mock := &ReenrichmentStorageInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
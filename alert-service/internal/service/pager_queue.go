@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"censys_alert_system/internal/metrics"
+)
+
+const (
+	// DefaultPagerQueueSize is the number of paging tasks that may wait to be sent
+	DefaultPagerQueueSize = 1000
+
+	// DefaultPagerTimeout bounds a single paging task, retries included
+	DefaultPagerTimeout = 30 * time.Second
+)
+
+// PagerPolicy controls how paging work is queued
+type PagerPolicy struct {
+	// QueueSize caps the tasks waiting to be sent; a task that does not fit is dropped
+	QueueSize int
+	// Timeout bounds each task, independently of the sync or request that raised it
+	Timeout time.Duration
+}
+
+// pagerTask is the paging work for one alert event, such as a trigger and
+// the start of its escalation
+type pagerTask struct {
+	alertID string
+	action  string
+	run     func(ctx context.Context)
+}
+
+// pagerQueue runs paging work in the background, one task at a time and in
+// order, so a slow or unreachable pager holds up neither ingestion nor
+// lifecycle requests
+type pagerQueue struct {
+	tasks   chan pagerTask
+	timeout time.Duration
+}
+
+func newPagerQueue(policy PagerPolicy) *pagerQueue {
+	if policy.QueueSize <= 0 {
+		policy.QueueSize = DefaultPagerQueueSize
+	}
+	if policy.Timeout <= 0 {
+		policy.Timeout = DefaultPagerTimeout
+	}
+
+	return &pagerQueue{
+		tasks:   make(chan pagerTask, policy.QueueSize),
+		timeout: policy.Timeout,
+	}
+}
+
+// enqueue queues a task without blocking, dropping it when the queue is full
+func (q *pagerQueue) enqueue(task pagerTask) {
+	select {
+	case q.tasks <- task:
+		metrics.PagerQueueDepth.Inc()
+	default:
+		metrics.PagerTasksDropped.Inc()
+		log.Printf("[PAGER] Queue full, dropped %s for alert %s", task.action, task.alertID)
+	}
+}
+
+// run sends queued tasks until ctx is cancelled. Tasks still queued then are
+// dropped.
+func (q *pagerQueue) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			if n := len(q.tasks); n > 0 {
+				log.Printf("[PAGER] Stopping with %d task(s) unsent", n)
+			}
+			return
+		case task := <-q.tasks:
+			q.send(ctx, task)
+		}
+	}
+}
+
+// drain sends the tasks queued so far and returns
+func (q *pagerQueue) drain(ctx context.Context) {
+	for {
+		select {
+		case task := <-q.tasks:
+			q.send(ctx, task)
+		default:
+			return
+		}
+	}
+}
+
+func (q *pagerQueue) send(ctx context.Context, task pagerTask) {
+	metrics.PagerQueueDepth.Dec()
+	ctx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+	task.run(ctx)
+}
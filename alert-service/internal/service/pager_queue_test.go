@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerQueue_RunsTasksInOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newPagerQueue(PagerPolicy{})
+
+	var mu sync.Mutex
+	var got []string
+	done := make(chan struct{})
+	for _, action := range []string{"trigger", "acknowledged", "resolved"} {
+		q.enqueue(pagerTask{alertID: "1", action: action, run: func(context.Context) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, action)
+			if len(got) == 3 {
+				close(done)
+			}
+		}})
+	}
+	go q.run(ctx)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("tasks were not sent")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"trigger", "acknowledged", "resolved"}, got)
+}
+
+func TestPagerQueue_BoundsEachTask(t *testing.T) {
+	q := newPagerQueue(PagerPolicy{Timeout: 10 * time.Millisecond})
+
+	var taskErr error
+	q.enqueue(pagerTask{alertID: "1", action: "trigger", run: func(ctx context.Context) {
+		// A pager that never answers
+		<-ctx.Done()
+		taskErr = ctx.Err()
+	}})
+
+	start := time.Now()
+	q.drain(context.Background())
+
+	require.ErrorIs(t, taskErr, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestPagerQueue_DropsTasksWhenFull(t *testing.T) {
+	q := newPagerQueue(PagerPolicy{QueueSize: 2})
+
+	sent := 0
+	for i := 0; i < 5; i++ {
+		q.enqueue(pagerTask{alertID: "1", action: "trigger", run: func(context.Context) { sent++ }})
+	}
+	q.drain(context.Background())
+
+	assert.Equal(t, 2, sent, "enqueue never blocks; tasks beyond the queue size are dropped")
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"alertschema"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+)
+
+// Alert partition intervals
+const (
+	PartitionDaily   = "day"
+	PartitionMonthly = "month"
+)
+
+// Retention actions for partitions whose alerts have all expired
+const (
+	RetentionDrop   = "drop"
+	RetentionDetach = "detach"
+)
+
+// DefaultPartitionPremake is the number of future partitions kept ready when none is set
+const DefaultPartitionPremake = 3
+
+// retentionDeleteBatch caps the alerts deleted per statement, so expiring a
+// severity does not hold locks on a large range at once
+const retentionDeleteBatch = 1000
+
+// PartitionPolicy controls the partitions of the alerts table and how long alerts are kept
+type PartitionPolicy struct {
+	// Interval is the span of one partition, PartitionDaily or PartitionMonthly
+	Interval string
+	// Premake is the number of partitions kept ready after the current one
+	Premake int
+	// Retention is how long alerts of each severity are kept; a severity
+	// without one is kept forever
+	Retention map[string]time.Duration
+	// Action is RetentionDrop or RetentionDetach
+	Action string
+}
+
+// PartitionService maintains the time partitions of the alerts table: it
+// creates partitions ahead of time and expires alerts past their severity's
+// retention. A partition is dropped or detached once every severity in it
+// has expired; alerts of severities with a shorter retention are deleted
+// from the partitions that remain.
+type PartitionService struct {
+	storage  PartitionStorageInterface
+	archiver PartitionArchiver
+	policy   PartitionPolicy
+	now      func() time.Time
+}
+
+// NewPartitionService creates a partition service
+func NewPartitionService(storage PartitionStorageInterface, policy PartitionPolicy) (*PartitionService, error) {
+	if policy.Interval == "" {
+		policy.Interval = PartitionMonthly
+	}
+	if policy.Interval != PartitionDaily && policy.Interval != PartitionMonthly {
+		return nil, fmt.Errorf("invalid partition interval %q: use %s or %s", policy.Interval, PartitionDaily, PartitionMonthly)
+	}
+	if policy.Action == "" {
+		policy.Action = RetentionDrop
+	}
+	if policy.Action != RetentionDrop && policy.Action != RetentionDetach {
+		return nil, fmt.Errorf("invalid retention action %q: use %s or %s", policy.Action, RetentionDrop, RetentionDetach)
+	}
+	if policy.Premake <= 0 {
+		policy.Premake = DefaultPartitionPremake
+	}
+	return &PartitionService{
+		storage: storage,
+		policy:  policy,
+		now:     time.Now,
+	}, nil
+}
+
+// SetArchiver archives alerts before retention removes them. A partition
+// or severity whose archive fails is kept until the next run.
+func (p *PartitionService) SetArchiver(archiver PartitionArchiver) {
+	p.archiver = archiver
+}
+
+// ParseRetention parses severity=days pairs, e.g. {"low": "30", "critical": "365d"}
+func ParseRetention(days map[string]string) (map[string]time.Duration, error) {
+	retention := make(map[string]time.Duration, len(days))
+	for severity, value := range days {
+		if !IsValidSeverity(severity) {
+			return nil, fmt.Errorf("invalid retention severity %q", severity)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid retention for %s: %q is not a positive number of days", severity, value)
+		}
+		retention[severity] = time.Duration(n) * 24 * time.Hour
+	}
+	return retention, nil
+}
+
+// Maintain creates the partitions for the current period and the next
+// Premake periods, then applies retention. It carries on past a failed
+// partition and returns every error.
+func (p *PartitionService) Maintain(ctx context.Context) error {
+	partitions, err := p.listPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	start := p.periodStart(p.now())
+	end := start
+	for range p.policy.Premake + 1 {
+		end = p.periodEnd(end)
+	}
+	partitions, createErr := p.ensurePartitions(ctx, partitions, start, end)
+	return errors.Join(createErr, p.applyRetention(ctx, partitions))
+}
+
+// EnsureRange creates any missing partitions for alerts created between
+// from and to, e.g. before restoring an archive
+func (p *PartitionService) EnsureRange(ctx context.Context, from, to time.Time) error {
+	partitions, err := p.listPartitions(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = p.ensurePartitions(ctx, partitions, p.periodStart(from), p.periodEnd(p.periodStart(to)))
+	return err
+}
+
+func (p *PartitionService) listPartitions(ctx context.Context) ([]models.AlertPartition, error) {
+	partitions, err := p.storage.ListAlertPartitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("partitions: %w", err)
+	}
+	slices.SortFunc(partitions, func(a, b models.AlertPartition) int { return a.From.Compare(b.From) })
+	return partitions, nil
+}
+
+// ensurePartitions creates the missing partitions for the periods from start
+// up to end and returns every partition, sorted by From. A new partition is
+// trimmed where it would overlap an existing one, e.g. after switching from
+// monthly to daily.
+func (p *PartitionService) ensurePartitions(ctx context.Context, partitions []models.AlertPartition, start, end time.Time) ([]models.AlertPartition, error) {
+	var errs []error
+	for ; start.Before(end); start = p.periodEnd(start) {
+		end := p.periodEnd(start)
+		from := start
+		for _, existing := range partitions {
+			if !existing.From.Before(end) || !existing.To.After(from) {
+				continue
+			}
+			if existing.From.After(from) {
+				end = existing.From
+				break
+			}
+			from = existing.To
+		}
+
+		if from.Before(end) {
+			partition := models.AlertPartition{Name: "alerts_p" + from.Format("20060102"), From: from, To: end}
+			if err := p.storage.CreateAlertPartition(ctx, partition); err != nil {
+				errs = append(errs, fmt.Errorf("partitions: %w", err))
+			} else {
+				log.Printf("[PARTITIONS] Created %s for [%s, %s)", partition.Name, partition.From.Format(time.DateOnly), partition.To.Format(time.DateOnly))
+				metrics.AlertPartitionsCreated.Inc()
+				partitions = append(partitions, partition)
+				slices.SortFunc(partitions, func(a, b models.AlertPartition) int { return a.From.Compare(b.From) })
+			}
+		}
+	}
+	return partitions, errors.Join(errs...)
+}
+
+// applyRetention removes the partitions past the longest retention, when
+// every severity has one, then deletes the remaining expired alerts
+func (p *PartitionService) applyRetention(ctx context.Context, partitions []models.AlertPartition) error {
+	if len(p.policy.Retention) == 0 {
+		return nil
+	}
+	now := p.now().UTC()
+	removed, expired := 0, 0
+
+	var errs []error
+	if longest, ok := p.longestRetention(); ok {
+		cutoff := now.Add(-longest)
+		for _, partition := range partitions {
+			if partition.To.After(cutoff) {
+				break
+			}
+			if p.archiver != nil {
+				if err := p.archiver.ArchivePartition(ctx, partition); err != nil {
+					errs = append(errs, fmt.Errorf("partitions: archiving %s: %w", partition.Name, err))
+					continue
+				}
+			}
+			if err := p.removePartition(ctx, partition); err != nil {
+				errs = append(errs, fmt.Errorf("partitions: %w", err))
+				continue
+			}
+			removed++
+		}
+	}
+
+	severities := make([]string, 0, len(p.policy.Retention))
+	for severity := range p.policy.Retention {
+		severities = append(severities, severity)
+	}
+	slices.Sort(severities)
+	for _, severity := range severities {
+		before := now.Add(-p.policy.Retention[severity])
+		if p.archiver != nil {
+			if err := p.archiver.ArchiveExpired(ctx, severity, before); err != nil {
+				errs = append(errs, fmt.Errorf("partitions: archiving expired %s alerts: %w", severity, err))
+				continue
+			}
+		}
+		for ctx.Err() == nil {
+			deleted, err := p.storage.DeleteExpiredAlerts(ctx, severity, before, retentionDeleteBatch)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("partitions: %w", err))
+				break
+			}
+			metrics.AlertsExpired.WithLabelValues(severity).Add(float64(deleted))
+			expired += deleted
+			if deleted < retentionDeleteBatch {
+				break
+			}
+		}
+	}
+
+	if removed > 0 || expired > 0 {
+		log.Printf("[PARTITIONS] Retention removed %d partition(s) and %d alert(s)", removed, expired)
+		if err := p.storage.PurgeAlertReferences(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("partitions: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *PartitionService) removePartition(ctx context.Context, partition models.AlertPartition) error {
+	if p.policy.Action == RetentionDetach {
+		if err := p.storage.DetachAlertPartition(ctx, partition.Name); err != nil {
+			return err
+		}
+		log.Printf("[PARTITIONS] Detached %s", partition.Name)
+	} else {
+		if err := p.storage.DropAlertPartition(ctx, partition.Name); err != nil {
+			return err
+		}
+		log.Printf("[PARTITIONS] Dropped %s", partition.Name)
+	}
+	metrics.AlertPartitionsRemoved.WithLabelValues(p.policy.Action).Inc()
+	return nil
+}
+
+// longestRetention returns the longest retention across severities. It is
+// false when some severity is kept forever, so no partition can go.
+func (p *PartitionService) longestRetention() (time.Duration, bool) {
+	var longest time.Duration
+	for severity := range alertschema.ValidSeverities {
+		retention, ok := p.policy.Retention[severity]
+		if !ok {
+			return 0, false
+		}
+		longest = max(longest, retention)
+	}
+	return longest, true
+}
+
+// periodStart returns the start of the partition period holding t, in UTC
+func (p *PartitionService) periodStart(t time.Time) time.Time {
+	t = t.UTC()
+	if p.policy.Interval == PartitionDaily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// periodEnd returns the start of the period after the one starting at start
+func (p *PartitionService) periodEnd(start time.Time) time.Time {
+	if p.policy.Interval == PartitionDaily {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+// Run maintains partitions at startup and then on every interval until ctx is cancelled
+func (p *PartitionService) Run(ctx context.Context, interval time.Duration) {
+	log.Printf("[PARTITIONS] Starting partition maintenance every %s (%s partitions, %d ahead)", interval, p.policy.Interval, p.policy.Premake)
+	if err := p.Maintain(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("[PARTITIONS] %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[PARTITIONS] Stopping partition maintenance")
+			return
+		case <-ticker.C:
+			if err := p.Maintain(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("[PARTITIONS] %v", err)
+			}
+		}
+	}
+}
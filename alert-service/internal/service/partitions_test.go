@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPartitionService(t *testing.T, now time.Time, policy PartitionPolicy) (*PartitionService, *mocks.PartitionStorageInterface) {
+	storage := mocks.NewPartitionStorageInterface(t)
+	p, err := NewPartitionService(storage, policy)
+	require.NoError(t, err)
+	p.now = func() time.Time { return now }
+	return p, storage
+}
+
+func utcDay(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRetention(t *testing.T) {
+	retention, err := ParseRetention(map[string]string{"low": "30", "critical": "365d"})
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, retention["low"])
+	assert.Equal(t, 365*24*time.Hour, retention["critical"])
+
+	_, err = ParseRetention(map[string]string{"urgent": "30"})
+	assert.ErrorContains(t, err, "invalid retention severity")
+
+	_, err = ParseRetention(map[string]string{"low": "0"})
+	assert.ErrorContains(t, err, "positive number of days")
+}
+
+func TestNewPartitionService_InvalidPolicy(t *testing.T) {
+	_, err := NewPartitionService(nil, PartitionPolicy{Interval: "week"})
+	assert.ErrorContains(t, err, "invalid partition interval")
+
+	_, err = NewPartitionService(nil, PartitionPolicy{Action: "archive"})
+	assert.ErrorContains(t, err, "invalid retention action")
+}
+
+func TestPartitionService_Maintain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates the current and upcoming monthly partitions", func(t *testing.T) {
+		p, storage := newTestPartitionService(t, time.Date(2025, 1, 15, 9, 30, 0, 0, time.UTC), PartitionPolicy{Premake: 2})
+
+		storage.On("ListAlertPartitions", ctx).Return([]models.AlertPartition{
+			{Name: "alerts_p20250101", From: utcDay(2025, 1, 1), To: utcDay(2025, 2, 1)},
+		}, nil)
+		storage.On("CreateAlertPartition", ctx, models.AlertPartition{Name: "alerts_p20250201", From: utcDay(2025, 2, 1), To: utcDay(2025, 3, 1)}).Return(nil).Once()
+		storage.On("CreateAlertPartition", ctx, models.AlertPartition{Name: "alerts_p20250301", From: utcDay(2025, 3, 1), To: utcDay(2025, 4, 1)}).Return(nil).Once()
+
+		require.NoError(t, p.Maintain(ctx))
+	})
+
+	t.Run("trims daily partitions around existing monthly ones", func(t *testing.T) {
+		p, storage := newTestPartitionService(t, utcDay(2025, 1, 30), PartitionPolicy{Interval: PartitionDaily, Premake: 3})
+
+		storage.On("ListAlertPartitions", ctx).Return([]models.AlertPartition{
+			{Name: "alerts_p20250101", From: utcDay(2025, 1, 1), To: utcDay(2025, 2, 1)},
+		}, nil)
+		storage.On("CreateAlertPartition", ctx, models.AlertPartition{Name: "alerts_p20250201", From: utcDay(2025, 2, 1), To: utcDay(2025, 2, 2)}).Return(nil).Once()
+		storage.On("CreateAlertPartition", ctx, models.AlertPartition{Name: "alerts_p20250202", From: utcDay(2025, 2, 2), To: utcDay(2025, 2, 3)}).Return(nil).Once()
+
+		require.NoError(t, p.Maintain(ctx))
+	})
+
+	t.Run("keeps going past a partition it cannot create", func(t *testing.T) {
+		p, storage := newTestPartitionService(t, utcDay(2025, 1, 15), PartitionPolicy{Premake: 1})
+
+		storage.On("ListAlertPartitions", ctx).Return(nil, nil)
+		storage.On("CreateAlertPartition", ctx, mock.MatchedBy(func(p models.AlertPartition) bool { return p.Name == "alerts_p20250101" })).
+			Return(errors.New("default partition would be violated")).Once()
+		storage.On("CreateAlertPartition", ctx, mock.MatchedBy(func(p models.AlertPartition) bool { return p.Name == "alerts_p20250201" })).
+			Return(nil).Once()
+
+		err := p.Maintain(ctx)
+
+		assert.ErrorContains(t, err, "default partition would be violated")
+	})
+
+	t.Run("drops partitions past every severity's retention", func(t *testing.T) {
+		retention, err := ParseRetention(map[string]string{"low": "30", "medium": "30", "high": "60", "critical": "90"})
+		require.NoError(t, err)
+		p, storage := newTestPartitionService(t, utcDay(2025, 6, 15), PartitionPolicy{Premake: 1, Retention: retention})
+
+		storage.On("ListAlertPartitions", ctx).Return([]models.AlertPartition{
+			{Name: "alerts_p20250301", From: utcDay(2025, 3, 1), To: utcDay(2025, 4, 1)},
+			{Name: "alerts_p20250201", From: utcDay(2025, 2, 1), To: utcDay(2025, 3, 1)},
+			{Name: "alerts_p20250401", From: utcDay(2025, 4, 1), To: utcDay(2025, 5, 1)},
+			{Name: "alerts_p20250501", From: utcDay(2025, 5, 1), To: utcDay(2025, 6, 1)},
+			{Name: "alerts_p20250601", From: utcDay(2025, 6, 1), To: utcDay(2025, 7, 1)},
+			{Name: "alerts_p20250701", From: utcDay(2025, 7, 1), To: utcDay(2025, 8, 1)},
+		}, nil)
+		// 90 days before June 15 is March 17, so only February is past retention for every severity
+		storage.On("DropAlertPartition", ctx, "alerts_p20250201").Return(nil).Once()
+		storage.On("DeleteExpiredAlerts", ctx, "critical", utcDay(2025, 3, 17), retentionDeleteBatch).Return(4, nil).Once()
+		storage.On("DeleteExpiredAlerts", ctx, "high", utcDay(2025, 4, 16), retentionDeleteBatch).Return(retentionDeleteBatch, nil).Once()
+		storage.On("DeleteExpiredAlerts", ctx, "high", utcDay(2025, 4, 16), retentionDeleteBatch).Return(10, nil).Once()
+		storage.On("DeleteExpiredAlerts", ctx, "low", utcDay(2025, 5, 16), retentionDeleteBatch).Return(0, nil).Once()
+		storage.On("DeleteExpiredAlerts", ctx, "medium", utcDay(2025, 5, 16), retentionDeleteBatch).Return(0, nil).Once()
+		storage.On("PurgeAlertReferences", ctx).Return(nil).Once()
+
+		require.NoError(t, p.Maintain(ctx))
+	})
+
+	t.Run("detaches instead of dropping when configured", func(t *testing.T) {
+		retention, err := ParseRetention(map[string]string{"low": "30", "medium": "30", "high": "30", "critical": "30"})
+		require.NoError(t, err)
+		p, storage := newTestPartitionService(t, utcDay(2025, 6, 15), PartitionPolicy{Premake: 1, Retention: retention, Action: RetentionDetach})
+
+		storage.On("ListAlertPartitions", ctx).Return([]models.AlertPartition{
+			{Name: "alerts_p20250401", From: utcDay(2025, 4, 1), To: utcDay(2025, 5, 1)},
+			{Name: "alerts_p20250501", From: utcDay(2025, 5, 1), To: utcDay(2025, 6, 1)},
+			{Name: "alerts_p20250601", From: utcDay(2025, 6, 1), To: utcDay(2025, 7, 1)},
+			{Name: "alerts_p20250701", From: utcDay(2025, 7, 1), To: utcDay(2025, 8, 1)},
+		}, nil)
+		storage.On("DetachAlertPartition", ctx, "alerts_p20250401").Return(nil).Once()
+		storage.On("DeleteExpiredAlerts", ctx, mock.Anything, utcDay(2025, 5, 16), retentionDeleteBatch).Return(0, nil).Times(4)
+		storage.On("PurgeAlertReferences", ctx).Return(nil).Once()
+
+		require.NoError(t, p.Maintain(ctx))
+	})
+
+	t.Run("keeps partitions while a severity is kept forever", func(t *testing.T) {
+		retention, err := ParseRetention(map[string]string{"low": "30"})
+		require.NoError(t, err)
+		p, storage := newTestPartitionService(t, utcDay(2025, 6, 15), PartitionPolicy{Premake: 1, Retention: retention})
+
+		storage.On("ListAlertPartitions", ctx).Return([]models.AlertPartition{
+			{Name: "alerts_p20240101", From: utcDay(2024, 1, 1), To: utcDay(2024, 2, 1)},
+			{Name: "alerts_p20250601", From: utcDay(2025, 6, 1), To: utcDay(2025, 7, 1)},
+			{Name: "alerts_p20250701", From: utcDay(2025, 7, 1), To: utcDay(2025, 8, 1)},
+		}, nil)
+		storage.On("DeleteExpiredAlerts", ctx, "low", utcDay(2025, 5, 16), retentionDeleteBatch).Return(0, nil).Once()
+
+		require.NoError(t, p.Maintain(ctx))
+	})
+}
+
+func TestPartitionService_MaintainArchivesFirst(t *testing.T) {
+	ctx := context.Background()
+	retention, err := ParseRetention(map[string]string{"low": "30", "medium": "30", "high": "30", "critical": "60"})
+	require.NoError(t, err)
+	p, storage := newTestPartitionService(t, utcDay(2025, 6, 15), PartitionPolicy{Premake: 1, Retention: retention})
+	archiver := mocks.NewPartitionArchiver(t)
+	p.SetArchiver(archiver)
+
+	march := models.AlertPartition{Name: "alerts_p20250301", From: utcDay(2025, 3, 1), To: utcDay(2025, 4, 1)}
+	storage.On("ListAlertPartitions", ctx).Return([]models.AlertPartition{
+		march,
+		{Name: "alerts_p20250601", From: utcDay(2025, 6, 1), To: utcDay(2025, 7, 1)},
+		{Name: "alerts_p20250701", From: utcDay(2025, 7, 1), To: utcDay(2025, 8, 1)},
+	}, nil)
+	archiver.On("ArchivePartition", ctx, march).Return(nil).Once()
+	storage.On("DropAlertPartition", ctx, "alerts_p20250301").Return(nil).Once()
+	archiver.On("ArchiveExpired", ctx, "critical", utcDay(2025, 4, 16)).Return(nil).Once()
+	storage.On("DeleteExpiredAlerts", ctx, "critical", utcDay(2025, 4, 16), retentionDeleteBatch).Return(0, nil).Once()
+	// A failed archive keeps that severity's alerts until the next run
+	archiver.On("ArchiveExpired", ctx, "high", utcDay(2025, 5, 16)).Return(errors.New("bucket unreachable")).Once()
+	archiver.On("ArchiveExpired", ctx, mock.Anything, utcDay(2025, 5, 16)).Return(nil).Twice()
+	storage.On("DeleteExpiredAlerts", ctx, "low", utcDay(2025, 5, 16), retentionDeleteBatch).Return(2, nil).Once()
+	storage.On("DeleteExpiredAlerts", ctx, "medium", utcDay(2025, 5, 16), retentionDeleteBatch).Return(0, nil).Once()
+	storage.On("PurgeAlertReferences", ctx).Return(nil).Once()
+
+	err = p.Maintain(ctx)
+
+	assert.ErrorContains(t, err, "archiving expired high alerts: bucket unreachable")
+}
+
+func TestPartitionService_MaintainKeepsPartitionWhenArchiveFails(t *testing.T) {
+	ctx := context.Background()
+	retention, err := ParseRetention(map[string]string{"low": "30", "medium": "30", "high": "30", "critical": "30"})
+	require.NoError(t, err)
+	p, storage := newTestPartitionService(t, utcDay(2025, 6, 15), PartitionPolicy{Premake: 1, Retention: retention})
+	archiver := mocks.NewPartitionArchiver(t)
+	p.SetArchiver(archiver)
+
+	storage.On("ListAlertPartitions", ctx).Return([]models.AlertPartition{
+		{Name: "alerts_p20250401", From: utcDay(2025, 4, 1), To: utcDay(2025, 5, 1)},
+		{Name: "alerts_p20250601", From: utcDay(2025, 6, 1), To: utcDay(2025, 7, 1)},
+		{Name: "alerts_p20250701", From: utcDay(2025, 7, 1), To: utcDay(2025, 8, 1)},
+	}, nil)
+	archiver.On("ArchivePartition", ctx, mock.Anything).Return(errors.New("bucket unreachable")).Once()
+	archiver.On("ArchiveExpired", ctx, mock.Anything, mock.Anything).Return(nil).Times(4)
+	storage.On("DeleteExpiredAlerts", ctx, mock.Anything, utcDay(2025, 5, 16), retentionDeleteBatch).Return(0, nil).Times(4)
+
+	err = p.Maintain(ctx)
+
+	assert.ErrorContains(t, err, "archiving alerts_p20250401")
+	storage.AssertNotCalled(t, "DropAlertPartition", mock.Anything, mock.Anything)
+}
+
+func TestPartitionService_EnsureRange(t *testing.T) {
+	ctx := context.Background()
+	p, storage := newTestPartitionService(t, utcDay(2025, 6, 15), PartitionPolicy{})
+
+	storage.On("ListAlertPartitions", ctx).Return([]models.AlertPartition{
+		{Name: "alerts_p20250201", From: utcDay(2025, 2, 1), To: utcDay(2025, 3, 1)},
+	}, nil)
+	storage.On("CreateAlertPartition", ctx, models.AlertPartition{Name: "alerts_p20250101", From: utcDay(2025, 1, 1), To: utcDay(2025, 2, 1)}).Return(nil).Once()
+	storage.On("CreateAlertPartition", ctx, models.AlertPartition{Name: "alerts_p20250301", From: utcDay(2025, 3, 1), To: utcDay(2025, 4, 1)}).Return(nil).Once()
+
+	require.NoError(t, p.EnsureRange(ctx, time.Date(2025, 1, 20, 8, 0, 0, 0, time.UTC), time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)))
+}
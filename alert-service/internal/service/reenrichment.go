@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+)
+
+const (
+	// DefaultReenrichmentRate caps alerts re-enriched per second when no rate is set
+	DefaultReenrichmentRate = 50
+	// DefaultReenrichmentBatchSize is the number of alerts loaded and
+	// checkpointed at a time when no batch size is set
+	DefaultReenrichmentBatchSize = 100
+)
+
+// reenrichmentLease is how long a running job is held before another worker
+// may take it over; it is renewed at every checkpoint
+const reenrichmentLease = 2 * time.Minute
+
+// reenrichmentYield is how long a job waits between checks while syncs are running
+const reenrichmentYield = time.Second
+
+var (
+	// ErrReenrichmentNotFound is returned for an unknown re-enrichment job
+	ErrReenrichmentNotFound = errors.New("re-enrichment job not found")
+
+	// ErrInvalidReenrichment is returned for a re-enrichment request that cannot be run
+	ErrInvalidReenrichment = errors.New("invalid re-enrichment")
+
+	// ErrReenrichmentTransition is returned when a job cannot be paused,
+	// resumed or cancelled from its current status
+	ErrReenrichmentTransition = errors.New("re-enrichment job cannot change status")
+)
+
+// ReenrichmentRequest selects the stored alerts to run through the current
+// enrichment pipeline
+type ReenrichmentRequest struct {
+	Filter models.AlertFilter
+	// Fields limits the job to these enrichment fields; empty runs every enricher
+	Fields []string
+	// Force re-enriches fields that are already current
+	Force bool
+}
+
+// ReenrichmentPolicy throttles re-enrichment so it does not starve live ingestion
+type ReenrichmentPolicy struct {
+	// Rate caps alerts re-enriched per second
+	Rate float64
+	// BatchSize is the number of alerts loaded and checkpointed at a time
+	BatchSize int
+}
+
+// ReenrichmentService runs stored alerts through the current enrichment
+// pipeline. Jobs walk the matching alerts oldest first and checkpoint after
+// every batch, so they resume where they stopped after a pause, failure or
+// restart. Jobs are rate limited and give way while any connector is syncing.
+type ReenrichmentService struct {
+	storage   ReenrichmentStorageInterface
+	alerts    *AlertService
+	batchSize int
+	limiter   *external.RateLimiter
+	yield     time.Duration
+	now       func() time.Time
+}
+
+// NewReenrichmentService creates a re-enrichment service for the alerts
+// stored by alerts
+func NewReenrichmentService(storage ReenrichmentStorageInterface, alerts *AlertService, policy ReenrichmentPolicy) *ReenrichmentService {
+	if policy.Rate <= 0 {
+		policy.Rate = DefaultReenrichmentRate
+	}
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = DefaultReenrichmentBatchSize
+	}
+	return &ReenrichmentService{
+		storage:   storage,
+		alerts:    alerts,
+		batchSize: policy.BatchSize,
+		limiter:   external.NewRateLimiter(policy.Rate, 0),
+		yield:     reenrichmentYield,
+		now:       time.Now,
+	}
+}
+
+// Create validates and queues a re-enrichment job. A filter on days is
+// fixed to the time of the request.
+func (r *ReenrichmentService) Create(ctx context.Context, req ReenrichmentRequest) (*models.ReenrichmentJob, error) {
+	for _, field := range req.Fields {
+		if !slices.Contains(EnrichmentFields(), field) {
+			return nil, fmt.Errorf("%w: unknown enrichment field %q", ErrInvalidReenrichment, field)
+		}
+	}
+	filter := req.Filter
+	if filter.Days < 0 {
+		return nil, fmt.Errorf("%w: days must be positive", ErrInvalidReenrichment)
+	}
+	if filter.Days > 0 {
+		from := r.now().AddDate(0, 0, -filter.Days)
+		if from.After(filter.From) {
+			filter.From = from
+		}
+		filter.Days = 0
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidReenrichment)
+	}
+
+	total, err := r.alerts.storage.CountAlerts(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("re-enrichment: %w", err)
+	}
+
+	job := &models.ReenrichmentJob{
+		Filter:  filter,
+		Fields:  req.Fields,
+		Force:   req.Force,
+		Version: EnrichmentVersion,
+		Status:  models.JobPending,
+		Total:   total,
+	}
+	if err := r.storage.CreateReenrichmentJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("re-enrichment: error creating job: %w", err)
+	}
+
+	log.Printf("[REENRICH] Queued job %s for %d alert(s) at pipeline version %d", job.ID, job.Total, job.Version)
+	return job, nil
+}
+
+// Get retrieves a job by ID
+func (r *ReenrichmentService) Get(ctx context.Context, id string) (*models.ReenrichmentJob, error) {
+	job, err := r.storage.GetReenrichmentJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("re-enrichment: %w: %v", ErrReenrichmentNotFound, err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("re-enrichment: %w", ErrReenrichmentNotFound)
+	}
+
+	return job, nil
+}
+
+// List retrieves recent jobs, optionally only those in the given statuses
+func (r *ReenrichmentService) List(ctx context.Context, statuses []string) ([]models.ReenrichmentJob, error) {
+	jobs, err := r.storage.ListReenrichmentJobs(ctx, statuses)
+	if err != nil {
+		return nil, fmt.Errorf("re-enrichment: error listing jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// Pause stops a pending or running job; a running job stops at its next checkpoint
+func (r *ReenrichmentService) Pause(ctx context.Context, id string) (*models.ReenrichmentJob, error) {
+	return r.transition(ctx, id, models.JobPaused)
+}
+
+// Resume queues a paused or failed job to continue from its last checkpoint
+func (r *ReenrichmentService) Resume(ctx context.Context, id string) (*models.ReenrichmentJob, error) {
+	return r.transition(ctx, id, models.JobPending)
+}
+
+// Cancel abandons a job that has not completed
+func (r *ReenrichmentService) Cancel(ctx context.Context, id string) (*models.ReenrichmentJob, error) {
+	return r.transition(ctx, id, models.JobCancelled)
+}
+
+func (r *ReenrichmentService) transition(ctx context.Context, id, status string) (*models.ReenrichmentJob, error) {
+	job, err := r.storage.TransitionReenrichmentJob(ctx, id, jobTransitions[status], status)
+	if err != nil {
+		return nil, fmt.Errorf("re-enrichment: %w", err)
+	}
+	if job != nil {
+		return job, nil
+	}
+
+	// Nothing changed: tell an unknown job from one in the wrong status
+	current, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("re-enrichment: %w: job is %s", ErrReenrichmentTransition, current.Status)
+}
+
+// ProcessDue runs waiting jobs one after another until none is left or ctx
+// is cancelled. Returns the number of jobs worked on.
+func (r *ReenrichmentService) ProcessDue(ctx context.Context) (int, error) {
+	n := 0
+	for ctx.Err() == nil {
+		now := r.now()
+		job, err := r.storage.ClaimReenrichmentJob(ctx, now, now.Add(reenrichmentLease))
+		if err != nil {
+			return n, fmt.Errorf("re-enrichment: error claiming job: %w", err)
+		}
+		if job == nil {
+			return n, nil
+		}
+
+		n++
+		if !r.run(ctx, job) {
+			// Leave the job leased; it is taken up again once the lease expires
+			return n, nil
+		}
+	}
+	return n, ctx.Err()
+}
+
+// run walks a claimed job batch by batch from its checkpoint. It returns
+// false when ctx was cancelled and the job was left unfinished.
+func (r *ReenrichmentService) run(ctx context.Context, job *models.ReenrichmentJob) bool {
+	log.Printf("[REENRICH] Running job %s from %d/%d alert(s)", job.ID, job.Scanned, job.Total)
+	for {
+		if !r.waitForSyncs(ctx, job) {
+			return ctx.Err() == nil
+		}
+
+		alerts, err := r.alerts.storage.ListAlertsAfter(ctx, job.Filter, job.Position, job.AfterID, r.batchSize)
+		if ctx.Err() != nil {
+			return false
+		}
+		if err != nil {
+			r.finish(ctx, job, err)
+			return true
+		}
+
+		for _, alert := range alerts {
+			if err := r.reenrich(ctx, job, alert); err != nil {
+				if ctx.Err() != nil {
+					return false
+				}
+				r.finish(ctx, job, err)
+				return true
+			}
+			job.Scanned++
+			job.Position, job.AfterID = alert.CreatedAt, alert.ID
+		}
+		if len(alerts) < r.batchSize {
+			break
+		}
+		if !r.checkpoint(ctx, job) {
+			log.Printf("[REENRICH] Job %s stopped after %d/%d alert(s)", job.ID, job.Scanned, job.Total)
+			return true
+		}
+	}
+
+	r.finish(ctx, job, nil)
+	return true
+}
+
+// waitForSyncs holds the job while any connector is syncing, keeping its
+// lease. It reports whether the job should go on.
+func (r *ReenrichmentService) waitForSyncs(ctx context.Context, job *models.ReenrichmentJob) bool {
+	for r.alerts.Syncing() {
+		timer := time.NewTimer(r.yield)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		if !r.checkpoint(ctx, job) {
+			return false
+		}
+	}
+	return true
+}
+
+// reenrich runs one alert through the pipeline and stores the result. Alerts
+// whose selected fields are current are skipped unless the job is forced.
+func (r *ReenrichmentService) reenrich(ctx context.Context, job *models.ReenrichmentJob, alert models.Alert) error {
+	fields := job.Fields
+	if !job.Force {
+		if fields = staleEnrichment(alert, job.Fields); len(fields) == 0 {
+			job.Skipped++
+			metrics.AlertsReenriched.WithLabelValues("current").Inc()
+			return nil
+		}
+	}
+
+	indicators, err := storedIndicators(alert)
+	if err != nil {
+		log.Printf("[REENRICH] Skipping alert %s: %v", alert.ID, err)
+		job.Skipped++
+		metrics.AlertsReenriched.WithLabelValues("failed").Inc()
+		return nil
+	}
+
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	enrich(&alert, indicators, fields)
+	if err := r.alerts.storage.UpdateAlertEnrichment(ctx, &alert); err != nil {
+		return err
+	}
+
+	job.Updated++
+	metrics.AlertsReenriched.WithLabelValues("updated").Inc()
+	return nil
+}
+
+// checkpoint saves the job's progress and reports whether it should keep running
+func (r *ReenrichmentService) checkpoint(ctx context.Context, job *models.ReenrichmentJob) bool {
+	status, err := r.storage.SaveReenrichmentProgress(context.WithoutCancel(ctx), job, r.now().Add(reenrichmentLease))
+	if err != nil {
+		// Alerts since the last checkpoint are handled again; re-enrichment is idempotent per version
+		log.Printf("[REENRICH] Job %s: warning: %v", job.ID, err)
+		return true
+	}
+	job.Status = status
+	return status == models.JobRunning
+}
+
+// finish records a job as completed, or failed with cause
+func (r *ReenrichmentService) finish(ctx context.Context, job *models.ReenrichmentJob, cause error) {
+	if cause != nil {
+		job.Status = models.JobFailed
+		job.Error = cause.Error()
+		log.Printf("[REENRICH] Job %s failed after %d/%d alert(s): %v", job.ID, job.Scanned, job.Total, cause)
+	} else {
+		now := r.now()
+		job.Status = models.JobCompleted
+		job.CompletedAt = &now
+		log.Printf("[REENRICH] Job %s completed: %d scanned, %d updated, %d skipped", job.ID, job.Scanned, job.Updated, job.Skipped)
+	}
+
+	if err := r.storage.FinishReenrichmentJob(context.WithoutCancel(ctx), job); err != nil {
+		log.Printf("[REENRICH] %v", err)
+	}
+}
+
+// Run works through waiting re-enrichment jobs every interval until ctx is cancelled
+func (r *ReenrichmentService) Run(ctx context.Context, interval time.Duration) {
+	log.Printf("[REENRICH] Starting re-enrichment worker every %s", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[REENRICH] Stopping re-enrichment worker")
+			return
+		case <-ticker.C:
+			if _, err := r.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("[REENRICH] %v", err)
+			}
+		}
+	}
+}
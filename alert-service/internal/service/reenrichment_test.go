@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReenrichmentService(t *testing.T, now time.Time) (*ReenrichmentService, *mocks.ReenrichmentStorageInterface, *mocks.AlertStorageInterface) {
+	jobStorage := mocks.NewReenrichmentStorageInterface(t)
+	alertStorage := mocks.NewAlertStorageInterface(t)
+
+	alerts := NewAlertService(alertStorage, nil)
+	alerts.SetConnectors([]*Connector{{Name: "siem", Client: mocks.NewAPIClientInterface(t)}})
+
+	r := NewReenrichmentService(jobStorage, alerts, ReenrichmentPolicy{Rate: 1000, BatchSize: 2})
+	r.yield = time.Millisecond
+	r.now = func() time.Time { return now }
+	return r, jobStorage, alertStorage
+}
+
+func TestEnrich(t *testing.T) {
+	t.Run("stamps the fields it writes", func(t *testing.T) {
+		alert := models.Alert{EnrichmentVersions: map[string]int{"enrichment_type": 0}}
+
+		enrich(&alert, map[string]string{"src_ip": "10.0.0.5"}, []string{"ip_address"})
+
+		assert.Equal(t, "10.0.0.5", *alert.IPAddress)
+		assert.Nil(t, alert.EnrichmentType)
+		assert.Equal(t, map[string]int{"enrichment_type": 0, "ip_address": EnrichmentVersion}, alert.EnrichmentVersions)
+	})
+
+	t.Run("stale fields", func(t *testing.T) {
+		alert := models.Alert{EnrichmentVersions: map[string]int{"enrichment_type": EnrichmentVersion}}
+
+		assert.Equal(t, []string{"ip_address"}, staleEnrichment(alert, nil))
+		assert.Empty(t, staleEnrichment(alert, []string{"enrichment_type"}))
+	})
+}
+
+func TestReenrichmentService_Create(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("fixes days to a start time and counts the matching alerts", func(t *testing.T) {
+		r, jobStorage, alertStorage := newTestReenrichmentService(t, now)
+		filter := models.AlertFilter{Sources: []string{"ids"}, From: now.AddDate(0, 0, -7)}
+
+		alertStorage.On("CountAlerts", ctx, filter).Return(42, nil)
+		jobStorage.On("CreateReenrichmentJob", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.ReenrichmentJob).ID = "job-1"
+		}).Return(nil)
+
+		job, err := r.Create(ctx, ReenrichmentRequest{Filter: models.AlertFilter{Sources: []string{"ids"}, Days: 7}, Fields: []string{"ip_address"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "job-1", job.ID)
+		assert.Equal(t, 42, job.Total)
+		assert.Equal(t, EnrichmentVersion, job.Version)
+		assert.Equal(t, models.JobPending, job.Status)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		r, _, _ := newTestReenrichmentService(t, now)
+
+		for name, req := range map[string]ReenrichmentRequest{
+			"unknown field": {Fields: []string{"threat_score"}},
+			"empty range":   {Filter: models.AlertFilter{From: now, To: now.Add(-time.Hour)}},
+			"negative days": {Filter: models.AlertFilter{Days: -1}},
+		} {
+			_, err := r.Create(ctx, req)
+			assert.ErrorIs(t, err, ErrInvalidReenrichment, name)
+		}
+	})
+}
+
+func TestReenrichmentService_ProcessDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	current := map[string]int{"enrichment_type": EnrichmentVersion, "ip_address": EnrichmentVersion}
+	newJob := func() *models.ReenrichmentJob {
+		return &models.ReenrichmentJob{ID: "job-1", Version: EnrichmentVersion, Status: models.JobRunning, Total: 3}
+	}
+	stored := func(id string, minute int, versions map[string]int) models.Alert {
+		return models.Alert{ID: id, WholeEvent: []byte(`{"indicators":{"src_ip":"10.0.0.5"}}`),
+			EnrichmentVersions: versions, CreatedAt: now.Add(time.Duration(minute) * time.Minute)}
+	}
+
+	t.Run("updates stale alerts batch by batch and completes", func(t *testing.T) {
+		r, jobStorage, alertStorage := newTestReenrichmentService(t, now)
+		first, second := stored("a-1", 1, nil), stored("a-2", 2, current)
+		third := stored("a-3", 3, map[string]int{"enrichment_type": EnrichmentVersion})
+
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(newJob(), nil).Once()
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(nil, nil).Once()
+		alertStorage.On("ListAlertsAfter", ctx, models.AlertFilter{}, time.Time{}, "", 2).Return([]models.Alert{first, second}, nil)
+		alertStorage.On("ListAlertsAfter", ctx, models.AlertFilter{}, second.CreatedAt, "a-2", 2).Return([]models.Alert{third}, nil)
+		alertStorage.On("UpdateAlertEnrichment", ctx, mock.MatchedBy(func(alert *models.Alert) bool {
+			return alert.ID == "a-1" && alert.EnrichmentType != nil && *alert.IPAddress == "10.0.0.5" &&
+				alert.EnrichmentVersions["enrichment_type"] == EnrichmentVersion
+		})).Return(nil).Once()
+		alertStorage.On("UpdateAlertEnrichment", ctx, mock.MatchedBy(func(alert *models.Alert) bool {
+			return alert.ID == "a-3" && alert.EnrichmentType == nil && *alert.IPAddress == "10.0.0.5"
+		})).Return(nil).Once()
+		jobStorage.On("SaveReenrichmentProgress", mock.Anything, mock.MatchedBy(func(job *models.ReenrichmentJob) bool {
+			return job.AfterID == "a-2" && job.Scanned == 2
+		}), now.Add(reenrichmentLease)).Return(models.JobRunning, nil).Once()
+		jobStorage.On("FinishReenrichmentJob", mock.Anything, mock.MatchedBy(func(job *models.ReenrichmentJob) bool {
+			return job.Status == models.JobCompleted && job.CompletedAt != nil && job.AfterID == "a-3" &&
+				job.Scanned == 3 && job.Updated == 2 && job.Skipped == 1
+		})).Return(nil)
+
+		n, err := r.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("forced jobs rewrite current fields", func(t *testing.T) {
+		r, jobStorage, alertStorage := newTestReenrichmentService(t, now)
+		job := newJob()
+		job.Force, job.Fields = true, []string{"ip_address"}
+
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(job, nil).Once()
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(nil, nil).Once()
+		alertStorage.On("ListAlertsAfter", ctx, models.AlertFilter{}, time.Time{}, "", 2).Return([]models.Alert{stored("a-1", 1, current)}, nil)
+		alertStorage.On("UpdateAlertEnrichment", ctx, mock.Anything).Return(nil).Once()
+		jobStorage.On("FinishReenrichmentJob", mock.Anything, mock.MatchedBy(func(job *models.ReenrichmentJob) bool {
+			return job.Updated == 1 && job.Skipped == 0
+		})).Return(nil)
+
+		_, err := r.ProcessDue(ctx)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("waits for running syncs", func(t *testing.T) {
+		r, jobStorage, alertStorage := newTestReenrichmentService(t, now)
+		c := r.alerts.connectors[0]
+		require.True(t, c.tryStart())
+
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(newJob(), nil).Once()
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(nil, nil).Once()
+		// The sync finishes while the job holds its lease
+		jobStorage.On("SaveReenrichmentProgress", mock.Anything, mock.Anything, now.Add(reenrichmentLease)).
+			Run(func(mock.Arguments) { c.finish() }).Return(models.JobRunning, nil).Once()
+		alertStorage.On("ListAlertsAfter", ctx, models.AlertFilter{}, time.Time{}, "", 2).Return(nil, nil)
+		jobStorage.On("FinishReenrichmentJob", mock.Anything, mock.Anything).Return(nil)
+
+		_, err := r.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		jobStorage.AssertNumberOfCalls(t, "SaveReenrichmentProgress", 1)
+	})
+
+	t.Run("storage failure fails the job after the last stored alert", func(t *testing.T) {
+		r, jobStorage, alertStorage := newTestReenrichmentService(t, now)
+
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(newJob(), nil).Once()
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(nil, nil).Once()
+		alertStorage.On("ListAlertsAfter", ctx, models.AlertFilter{}, time.Time{}, "", 2).
+			Return([]models.Alert{stored("a-1", 1, nil), stored("a-2", 2, nil)}, nil)
+		alertStorage.On("UpdateAlertEnrichment", ctx, mock.Anything).Return(nil).Once()
+		alertStorage.On("UpdateAlertEnrichment", ctx, mock.Anything).Return(errors.New("connection reset")).Once()
+		jobStorage.On("FinishReenrichmentJob", mock.Anything, mock.MatchedBy(func(job *models.ReenrichmentJob) bool {
+			return job.Status == models.JobFailed && job.Error == "connection reset" && job.AfterID == "a-1" && job.Scanned == 1
+		})).Return(nil)
+
+		_, err := r.ProcessDue(ctx)
+
+		require.NoError(t, err)
+	})
+
+	t.Run("stops when the job is paused", func(t *testing.T) {
+		r, jobStorage, alertStorage := newTestReenrichmentService(t, now)
+
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(newJob(), nil).Once()
+		jobStorage.On("ClaimReenrichmentJob", ctx, now, now.Add(reenrichmentLease)).Return(nil, nil).Once()
+		alertStorage.On("ListAlertsAfter", ctx, models.AlertFilter{}, time.Time{}, "", 2).
+			Return([]models.Alert{stored("a-1", 1, current), stored("a-2", 2, current)}, nil)
+		jobStorage.On("SaveReenrichmentProgress", mock.Anything, mock.Anything, now.Add(reenrichmentLease)).Return(models.JobPaused, nil).Once()
+
+		_, err := r.ProcessDue(ctx)
+
+		require.NoError(t, err)
+		jobStorage.AssertNotCalled(t, "FinishReenrichmentJob", mock.Anything, mock.Anything)
+	})
+}
+
+func TestReenrichmentService_Transitions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("cancel a paused job", func(t *testing.T) {
+		r, jobStorage, _ := newTestReenrichmentService(t, now)
+
+		jobStorage.On("TransitionReenrichmentJob", ctx, "job-1", jobTransitions[models.JobCancelled], models.JobCancelled).
+			Return(&models.ReenrichmentJob{ID: "job-1", Status: models.JobCancelled}, nil)
+
+		job, err := r.Cancel(ctx, "job-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, models.JobCancelled, job.Status)
+	})
+
+	t.Run("completed job cannot be resumed", func(t *testing.T) {
+		r, jobStorage, _ := newTestReenrichmentService(t, now)
+
+		jobStorage.On("TransitionReenrichmentJob", ctx, "job-1", mock.Anything, models.JobPending).Return(nil, nil)
+		jobStorage.On("GetReenrichmentJob", ctx, "job-1").Return(&models.ReenrichmentJob{ID: "job-1", Status: models.JobCompleted}, nil)
+
+		_, err := r.Resume(ctx, "job-1")
+
+		assert.ErrorIs(t, err, ErrReenrichmentTransition)
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		r, jobStorage, _ := newTestReenrichmentService(t, now)
+
+		jobStorage.On("TransitionReenrichmentJob", ctx, "missing", mock.Anything, models.JobPaused).Return(nil, nil)
+		jobStorage.On("GetReenrichmentJob", ctx, "missing").Return(nil, nil)
+
+		_, err := r.Pause(ctx, "missing")
+
+		assert.ErrorIs(t, err, ErrReenrichmentNotFound)
+	})
+}
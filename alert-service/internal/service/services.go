@@ -3,25 +3,95 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"time"
 
 	"censys_alert_system/external"
 	"censys_alert_system/internal/models"
 )
 
+var (
+	// ErrAlertNotFound is returned when a lifecycle change targets an unknown alert
+	ErrAlertNotFound = errors.New("alert not found")
+
+	// ErrInvalidStatusTransition is returned when a lifecycle change is not allowed from the current status
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+)
+
+// pagedSeverity is the severity that pages on-call when a pager is configured
+const pagedSeverity = "critical"
+
+// alertTransitions lists the statuses an alert may move to each lifecycle
+// status from
+var alertTransitions = map[string][]string{
+	models.StatusAcknowledged: {models.StatusOpen},
+	models.StatusResolved:     {models.StatusOpen, models.StatusAcknowledged},
+}
+
 type AlertService struct {
-	mockAPIClient APIClientInterface
-	storage       AlertStorageInterface
+	connectors       []*Connector
+	connectorStorage ConnectorStorageInterface
+	syncSlots        chan struct{}
+	syncBatchSize    int
+	storage          AlertStorageInterface
+	pager            PagerInterface
+	pages            *pagerQueue
+	escalation       *EscalationService
+	publisher        EventPublisher
+	deadLetters      *DeadLetterService
 }
 
+// NewAlertService creates the alert service. A non-nil apiClient becomes the
+// single "mock-api" connector; use SetConnectors for multiple upstreams.
 func NewAlertService(storage AlertStorageInterface, apiClient APIClientInterface) *AlertService {
-	return &AlertService{
+	s := &AlertService{
 		storage:       storage,
-		mockAPIClient: apiClient,
+		syncSlots:     make(chan struct{}, DefaultSyncParallelism),
+		syncBatchSize: DefaultSyncBatchSize,
+	}
+	if apiClient != nil {
+		s.connectors = []*Connector{{
+			Name:             defaultConnectorName,
+			Client:           apiClient,
+			InheritWatermark: true,
+		}}
 	}
+	return s
+}
+
+// SetPager enables paging for critical alerts and their lifecycle changes.
+// Paging runs in the background once RunPager is started.
+func (s *AlertService) SetPager(pager PagerInterface, policy PagerPolicy) {
+	s.pager = pager
+	s.pages = newPagerQueue(policy)
+}
+
+// RunPager sends queued paging work until ctx is cancelled. It returns at
+// once when no pager is set.
+func (s *AlertService) RunPager(ctx context.Context) {
+	if s.pages == nil {
+		return
+	}
+	log.Println("[PAGER] Starting pager queue")
+	s.pages.run(ctx)
+}
+
+// SetEscalation enables timed re-notification of paged alerts until they are acknowledged
+func (s *AlertService) SetEscalation(escalation *EscalationService) {
+	s.escalation = escalation
+}
+
+// SetPublisher enables publishing of live alert events
+func (s *AlertService) SetPublisher(publisher EventPublisher) {
+	s.publisher = publisher
+}
+
+// SetDeadLetters keeps synced alerts that fail enrichment or storage for retry
+// instead of dropping them
+func (s *AlertService) SetDeadLetters(deadLetters *DeadLetterService) {
+	s.deadLetters = deadLetters
 }
 
 // GetAlerts retrieves all alerts through the service layer
@@ -40,10 +110,23 @@ func (s *AlertService) GetAlertByID(ctx context.Context, id string) (*models.Ale
 	if err != nil {
 		return nil, fmt.Errorf("service: error getting alert: %w", err)
 	}
+	if alert == nil {
+		return nil, fmt.Errorf("service: %w: %s", ErrAlertNotFound, id)
+	}
 
 	return alert, nil
 }
 
+// AlertExists reports whether an alert with the given fingerprint is stored
+func (s *AlertService) AlertExists(ctx context.Context, fingerprint string) (bool, error) {
+	exists, err := s.storage.AlertExists(ctx, fingerprint)
+	if err != nil {
+		return false, fmt.Errorf("service: error checking alert: %w", err)
+	}
+
+	return exists, nil
+}
+
 // GetAlertsByDays retrieves alerts from the last X days through the service layer
 func (s *AlertService) GetAlertsByDays(ctx context.Context, days int) ([]models.Alert, error) {
 	if days <= 0 {
@@ -58,110 +141,194 @@ func (s *AlertService) GetAlertsByDays(ctx context.Context, days int) ([]models.
 	return alerts, nil
 }
 
-// PerformSync fetches alerts from mock API, enriches them, and stores them
-func (s *AlertService) PerformSync(ctx context.Context) error {
-	log.Println("[SYNC] Starting sync process...")
-
-	// Health check
-	if err := s.mockAPIClient.CheckHealth(ctx); err != nil {
-		log.Printf("[SYNC] Health check failed: %v, proceeding anyway (retries will handle failures)", err)
+// ListAlerts retrieves alerts matching the filter through the service layer
+func (s *AlertService) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	if filter.Days < 0 {
+		return nil, fmt.Errorf("service: days must be greater than 0")
 	}
 
-	// Get last sync time from database
-	lastSync, err := s.storage.GetLastSyncTime(ctx)
+	alerts, err := s.storage.ListAlerts(ctx, filter)
 	if err != nil {
-		log.Printf("[SYNC] Warning: Could not get last sync time: %v. Fetching all alerts.", err)
-		lastSync = time.Time{}
+		return nil, fmt.Errorf("service: error listing alerts: %w", err)
 	}
 
-	log.Printf("[SYNC] Last sync time: %s", lastSync.Format(time.RFC3339))
+	return alerts, nil
+}
 
-	// Fetch alerts from mock API
-	var externalAlerts []external.ExternalAlert
-	if lastSync.IsZero() {
-		log.Println("[SYNC] Fetching all alerts (first sync)")
-		externalAlerts, err = s.mockAPIClient.FetchAllAlerts(ctx)
-	} else {
-		log.Printf("[SYNC] Fetching alerts since: %s", lastSync.Format(time.RFC3339))
-		externalAlerts, err = s.mockAPIClient.FetchAlertsSince(ctx, lastSync)
+// PerformSync syncs every connector concurrently with bounded parallelism.
+// Each connector fetches alerts since its own watermark, enriches and stores
+// them; a failing connector does not stop the others.
+func (s *AlertService) PerformSync(ctx context.Context) error {
+	log.Printf("[SYNC] Starting sync of %d connector(s)...", len(s.connectors))
+	return s.syncConnectors(ctx, s.connectors)
+}
+
+// ingestAlert enriches and stores a single upstream alert, then notifies
+// live subscribers and the pager. An alert that is already stored is
+// returned without an ID and nobody is notified again.
+func (s *AlertService) ingestAlert(ctx context.Context, extAlert external.ExternalAlert) (models.Alert, error) {
+	alert, err := newAlert(extAlert)
+	if err != nil {
+		return alert, err
+	}
+	if err := s.storage.CreateAlert(ctx, &alert); err != nil {
+		return alert, err
+	}
+	if alert.ID == "" {
+		return alert, nil
 	}
 
+	s.notifyCreated(ctx, alert)
+	return alert, nil
+}
+
+// notifyCreated tells live subscribers and the pager about a stored alert
+func (s *AlertService) notifyCreated(ctx context.Context, alert models.Alert) {
+	s.publish(models.EventAlertCreated, alert)
+	s.pageAlert(ctx, alert)
+}
+
+// newAlert enriches an upstream alert into the alert to store. It fails
+// when the upstream record cannot be kept in whole_event.
+func newAlert(extAlert external.ExternalAlert) (models.Alert, error) {
+	alert, err := unenrichedAlert(extAlert)
 	if err != nil {
-		return fmt.Errorf("failed to fetch alerts from mock API: %w", err)
+		return alert, err
 	}
+	enrich(&alert, extAlert.Indicators, nil)
+	return alert, nil
+}
 
-	log.Printf("[SYNC] Fetched %d alerts from mock API", len(externalAlerts))
+// unenrichedAlert builds the alert to store from an upstream alert, keeping
+// the upstream record and its indicators in whole_event
+func unenrichedAlert(extAlert external.ExternalAlert) (models.Alert, error) {
+	event := map[string]interface{}{
+		"source":      extAlert.Source,
+		"severity":    extAlert.Severity,
+		"description": extAlert.Description,
+		"created_at":  extAlert.CreatedAt,
+		"synced_at":   time.Now(),
+	}
+	if len(extAlert.Raw) > 0 {
+		event["raw"] = extAlert.Raw
+	}
+	if len(extAlert.Indicators) > 0 {
+		event["indicators"] = extAlert.Indicators
+	}
 
-	if len(externalAlerts) == 0 {
-		log.Println("[SYNC] No new alerts to sync")
-		return nil
+	wholeEventJSON, err := json.Marshal(event)
+	if err != nil {
+		return models.Alert{}, fmt.Errorf("error building whole_event: %w", err)
 	}
 
-	// Process and store each alert
-	successCount := 0
-	for _, extAlert := range externalAlerts {
-		if ctx.Err() != nil {
-			log.Printf("[SYNC] Sync cancelled after processing %d alerts", successCount)
-			return ctx.Err()
-		}
+	alert := models.Alert{
+		Source:      extAlert.Source,
+		Severity:    extAlert.Severity,
+		Description: extAlert.Description,
+		WholeEvent:  wholeEventJSON,
+		Fingerprint: models.Fingerprint(extAlert.Source, extAlert.Severity, extAlert.Description, extAlert.CreatedAt),
+		CreatedAt:   extAlert.CreatedAt,
+	}
+	return alert, nil
+}
 
-		wholeEventJSON, err := json.Marshal(map[string]interface{}{
-			"source":      extAlert.Source,
-			"severity":    extAlert.Severity,
-			"description": extAlert.Description,
-			"created_at":  extAlert.CreatedAt,
-			"synced_at":   time.Now(),
-		})
-		if err != nil {
-			log.Printf("[SYNC] Warning: Failed to marshal whole_event for alert: %v", err)
-			wholeEventJSON = []byte("{}")
-		}
+// AcknowledgeAlert marks an open alert as acknowledged
+func (s *AlertService) AcknowledgeAlert(ctx context.Context, id string) (*models.Alert, error) {
+	return s.transitionAlert(ctx, id, models.StatusAcknowledged)
+}
 
-		err = s.storage.CreateAlert(
-			ctx,
-			extAlert.Source,
-			extAlert.Severity,
-			extAlert.Description,
-			wholeEventJSON,
-			getRandomEnrichmentType(),
-			generateRandomIP(),
-			extAlert.CreatedAt,
-		)
+// ResolveAlert marks an open or acknowledged alert as resolved
+func (s *AlertService) ResolveAlert(ctx context.Context, id string) (*models.Alert, error) {
+	return s.transitionAlert(ctx, id, models.StatusResolved)
+}
 
+// transitionAlert applies a lifecycle change, then mirrors it to the pager.
+// The update only applies from an allowed status, so of two racing changes
+// the later one fails instead of undoing the first.
+func (s *AlertService) transitionAlert(ctx context.Context, id, status string) (*models.Alert, error) {
+	alert, err := s.storage.UpdateAlertStatus(ctx, id, alertTransitions[status], status)
+	if err != nil {
+		return nil, fmt.Errorf("service: error updating alert status: %w", err)
+	}
+	if alert == nil {
+		// Nothing changed: tell an unknown alert from one in the wrong status
+		current, err := s.GetAlertByID(ctx, id)
 		if err != nil {
-			log.Printf("[SYNC] Error storing alert: %v", err)
-			continue
+			return nil, err
 		}
-
-		successCount++
+		return nil, fmt.Errorf("service: %w: %s -> %s", ErrInvalidStatusTransition, current.Status, status)
 	}
 
-	// Update last sync time
-	if err := s.storage.UpdateLastSyncTime(ctx, time.Now()); err != nil {
-		log.Printf("[SYNC] Warning: Failed to update last sync time: %v", err)
+	s.pageTransition(*alert)
+
+	if status == models.StatusAcknowledged {
+		s.publish(models.EventAlertAcknowledged, *alert)
+	} else {
+		s.publish(models.EventAlertResolved, *alert)
 	}
 
-	log.Printf("[SYNC] Successfully synced %d/%d alerts", successCount, len(externalAlerts))
-	return nil
+	return alert, nil
 }
 
-func generateRandomIP() string {
-	return fmt.Sprintf("%d.%d.%d.%d",
-		rand.Intn(256),
-		rand.Intn(256),
-		rand.Intn(256),
-		rand.Intn(256),
-	)
+// publish sends an alert event to live subscribers when a publisher is configured
+func (s *AlertService) publish(eventType string, alert models.Alert) {
+	if s.publisher != nil {
+		s.publisher.Publish(eventType, alert)
+	}
+}
+
+// pageAlert queues an incident for a newly stored critical alert. Its
+// escalation is recorded first, so when the page fails, is dropped or is lost
+// in a restart, the escalation scheduler sends it.
+func (s *AlertService) pageAlert(ctx context.Context, alert models.Alert) {
+	if s.pager == nil || alert.Severity != pagedSeverity {
+		return
+	}
+
+	if s.escalation != nil {
+		if err := s.escalation.Start(context.WithoutCancel(ctx), alert); err != nil {
+			log.Printf("[ESCALATION] %v", err)
+		}
+	}
+
+	s.pages.enqueue(pagerTask{alertID: alert.ID, action: "trigger", run: func(ctx context.Context) {
+		if err := s.pager.Trigger(ctx, alert); err != nil {
+			log.Printf("[PAGER] Failed to trigger incident for alert %s: %v", alert.ID, err)
+			return
+		}
+
+		if s.escalation != nil {
+			if err := s.escalation.Paged(ctx, alert); err != nil {
+				log.Printf("[ESCALATION] %v", err)
+			}
+		}
+	}})
 }
 
-func getRandomEnrichmentType() string {
-	alertTypes := []string{
-		"geo_location",
-		"threat_intel",
-		"user_context",
-		"network_analysis",
-		"behavioral_analysis",
+// pageTransition queues mirroring a lifecycle change to the pager and
+// stopping the alert's escalation
+func (s *AlertService) pageTransition(alert models.Alert) {
+	if s.pager == nil {
+		return
 	}
 
-	return alertTypes[rand.Intn(len(alertTypes))]
+	s.pages.enqueue(pagerTask{alertID: alert.ID, action: alert.Status, run: func(ctx context.Context) {
+		if alert.Severity == pagedSeverity && alert.Fingerprint != "" {
+			var pageErr error
+			if alert.Status == models.StatusAcknowledged {
+				pageErr = s.pager.Acknowledge(ctx, alert.Fingerprint)
+			} else {
+				pageErr = s.pager.Resolve(ctx, alert.Fingerprint)
+			}
+			if pageErr != nil {
+				log.Printf("[PAGER] Failed to send %s for alert %s: %v", alert.Status, alert.ID, pageErr)
+			}
+		}
+
+		if s.escalation != nil {
+			if err := s.escalation.Stop(ctx, alert); err != nil {
+				log.Printf("[ESCALATION] %v", err)
+			}
+		}
+	}})
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -12,8 +13,21 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
+// assignIDs stands in for CreateAlerts storing every alert of the batch
+func assignIDs(args mock.Arguments) {
+	for i := range args.Get(1).([]models.Alert) {
+		args.Get(1).([]models.Alert)[i].ID = fmt.Sprintf("stored-%d", i)
+	}
+}
+
+// assignID stands in for CreateAlert storing the alert
+func assignID(args mock.Arguments) {
+	args.Get(1).(*models.Alert).ID = "stored"
+}
+
 func TestAlertService_GetAlerts(t *testing.T) {
 	ctx := context.Background()
 
@@ -72,14 +86,27 @@ func TestAlertService_GetAlertByID(t *testing.T) {
 		mockStorage := mocks.NewAlertStorageInterface(t)
 		service := NewAlertService(mockStorage, nil)
 
-		mockStorage.On("GetAlertByID", ctx, "999").Return(nil, errors.New("alert not found"))
+		mockStorage.On("GetAlertByID", ctx, "999").Return(nil, nil)
 
 		alert, err := service.GetAlertByID(ctx, "999")
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrAlertNotFound)
 		assert.Nil(t, alert)
 		mockStorage.AssertExpectations(t)
 	})
+
+	t.Run("storage failure", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		mockStorage.On("GetAlertByID", ctx, "1").Return(nil, errors.New("connection refused"))
+
+		alert, err := service.GetAlertByID(ctx, "1")
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrAlertNotFound)
+		assert.Nil(t, alert)
+	})
 }
 
 func TestAlertService_GetAlertsByDays(t *testing.T) {
@@ -123,6 +150,33 @@ func TestAlertService_GetAlertsByDays(t *testing.T) {
 	})
 }
 
+func TestAlertService_ListAlerts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		filter := models.AlertFilter{Severities: []string{"critical"}}
+		mockStorage.On("ListAlerts", ctx, filter).Return([]models.Alert{{ID: "1", Severity: "critical"}}, nil)
+
+		alerts, err := service.ListAlerts(ctx, filter)
+
+		assert.NoError(t, err)
+		assert.Len(t, alerts, 1)
+	})
+
+	t.Run("invalid days", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		alerts, err := service.ListAlerts(ctx, models.AlertFilter{Days: -1})
+
+		assert.Error(t, err)
+		assert.Nil(t, alerts)
+	})
+}
+
 func TestAlertService_PerformSync(t *testing.T) {
 	ctx := context.Background()
 
@@ -138,16 +192,7 @@ func TestAlertService_PerformSync(t *testing.T) {
 		mockClient.On("CheckHealth", ctx).Return(nil)
 		mockStorage.On("GetLastSyncTime", ctx).Return(time.Time{}, nil)
 		mockClient.On("FetchAllAlerts", ctx).Return(externalAlerts, nil)
-		mockStorage.On("CreateAlert",
-			ctx,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-		).Return(nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil)
 		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
 
 		err := service.PerformSync(ctx)
@@ -170,16 +215,7 @@ func TestAlertService_PerformSync(t *testing.T) {
 		mockClient.On("CheckHealth", ctx).Return(nil)
 		mockStorage.On("GetLastSyncTime", ctx).Return(lastSync, nil)
 		mockClient.On("FetchAlertsSince", ctx, lastSync).Return(externalAlerts, nil)
-		mockStorage.On("CreateAlert",
-			ctx,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-			mock.Anything,
-		).Return(nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil)
 		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
 
 		err := service.PerformSync(ctx)
@@ -201,7 +237,7 @@ func TestAlertService_PerformSync(t *testing.T) {
 		err := service.PerformSync(ctx)
 
 		assert.NoError(t, err)
-		mockStorage.AssertNotCalled(t, "CreateAlert")
+		mockStorage.AssertNotCalled(t, "CreateAlerts")
 	})
 
 	t.Run("fetch error", func(t *testing.T) {
@@ -241,17 +277,342 @@ func TestAlertService_PerformSync(t *testing.T) {
 		assert.Equal(t, context.Canceled, err)
 	})
 
-	t.Run("health check failure continues", func(t *testing.T) {
+	t.Run("stored alerts are published", func(t *testing.T) {
 		mockStorage := mocks.NewAlertStorageInterface(t)
 		mockClient := mocks.NewAPIClientInterface(t)
+		mockPublisher := mocks.NewEventPublisher(t)
 		service := NewAlertService(mockStorage, mockClient)
+		service.SetPublisher(mockPublisher)
+
+		externalAlerts := []external.ExternalAlert{
+			{Source: "ext1", Severity: "high", Description: "desc1", CreatedAt: time.Now()},
+		}
 
-		mockClient.On("CheckHealth", ctx).Return(errors.New("health check failed"))
+		mockClient.On("CheckHealth", ctx).Return(nil)
 		mockStorage.On("GetLastSyncTime", ctx).Return(time.Time{}, nil)
-		mockClient.On("FetchAllAlerts", ctx).Return([]external.ExternalAlert{}, nil)
+		mockClient.On("FetchAllAlerts", ctx).Return(externalAlerts, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			args.Get(1).([]models.Alert)[0].ID = "new-uuid"
+		}).Return(nil)
+		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
+		mockPublisher.On("Publish", models.EventAlertCreated, mock.MatchedBy(func(alert models.Alert) bool {
+			return alert.ID == "new-uuid" && alert.Source == "ext1"
+		})).Return(models.AlertEvent{ID: 1})
 
 		err := service.PerformSync(ctx)
 
 		assert.NoError(t, err)
 	})
+
+	t.Run("alerts are inserted in batches", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockClient := mocks.NewAPIClientInterface(t)
+		service := NewAlertService(mockStorage, mockClient)
+		service.SetSyncBatchSize(2)
+
+		var externalAlerts []external.ExternalAlert
+		for i := 0; i < 5; i++ {
+			externalAlerts = append(externalAlerts, external.ExternalAlert{Source: "ext1", Severity: "low", Description: fmt.Sprintf("alert %d", i), CreatedAt: time.Now()})
+		}
+
+		var batches []int
+		mockClient.On("CheckHealth", ctx).Return(nil)
+		mockStorage.On("GetLastSyncTime", ctx).Return(time.Time{}, nil)
+		mockClient.On("FetchAllAlerts", ctx).Return(externalAlerts, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			batches = append(batches, len(args.Get(1).([]models.Alert)))
+			assignIDs(args)
+		}).Return(nil)
+		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
+
+		require.NoError(t, service.PerformSync(ctx))
+
+		assert.Equal(t, []int{2, 2, 1}, batches)
+	})
+
+	t.Run("failed batch falls back to single inserts", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockClient := mocks.NewAPIClientInterface(t)
+		mockPublisher := mocks.NewEventPublisher(t)
+		service := NewAlertService(mockStorage, mockClient)
+		service.SetPublisher(mockPublisher)
+
+		externalAlerts := []external.ExternalAlert{
+			{Source: "ext1", Severity: "high", Description: "bad", CreatedAt: time.Now()},
+			{Source: "ext1", Severity: "high", Description: "good", CreatedAt: time.Now()},
+		}
+
+		mockClient.On("CheckHealth", ctx).Return(nil)
+		mockStorage.On("GetLastSyncTime", ctx).Return(time.Time{}, nil)
+		mockClient.On("FetchAllAlerts", ctx).Return(externalAlerts, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Return(errors.New("value too long"))
+		mockStorage.On("CreateAlert", ctx, mock.MatchedBy(func(alert *models.Alert) bool {
+			return alert.Description == "bad"
+		})).Return(errors.New("value too long")).Once()
+		mockStorage.On("CreateAlert", ctx, mock.MatchedBy(func(alert *models.Alert) bool {
+			return alert.Description == "good"
+		})).Run(assignID).Return(nil).Once()
+		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
+		mockPublisher.On("Publish", models.EventAlertCreated, mock.MatchedBy(func(alert models.Alert) bool {
+			return alert.Description == "good"
+		})).Return(models.AlertEvent{ID: 1}).Once()
+
+		require.NoError(t, service.PerformSync(ctx))
+	})
+
+	t.Run("health check failure skips the fetch", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockClient := mocks.NewAPIClientInterface(t)
+		service := NewAlertService(mockStorage, mockClient)
+
+		mockClient.On("CheckHealth", ctx).Return(errors.New("connection refused"))
+
+		err := service.PerformSync(ctx)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "health check failed")
+		mockClient.AssertNotCalled(t, "FetchAllAlerts", mock.Anything)
+	})
+}
+
+func TestAlertService_Paging(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("critical alert triggers incident", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockClient := mocks.NewAPIClientInterface(t)
+		mockPager := mocks.NewPagerInterface(t)
+		service := NewAlertService(mockStorage, mockClient)
+		service.SetPager(mockPager, PagerPolicy{})
+
+		createdAt := time.Now()
+		externalAlerts := []external.ExternalAlert{
+			{Source: "firewall", Severity: "critical", Description: "intrusion", CreatedAt: createdAt},
+			{Source: "ids", Severity: "low", Description: "noise", CreatedAt: createdAt},
+		}
+
+		mockClient.On("CheckHealth", ctx).Return(nil)
+		mockStorage.On("GetLastSyncTime", ctx).Return(time.Time{}, nil)
+		mockClient.On("FetchAllAlerts", ctx).Return(externalAlerts, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil)
+		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
+		mockPager.On("Trigger", mock.Anything, mock.MatchedBy(func(alert models.Alert) bool {
+			return alert.Severity == "critical" &&
+				alert.Fingerprint == models.Fingerprint("firewall", "critical", "intrusion", createdAt)
+		})).Return(nil).Once()
+
+		err := service.PerformSync(ctx)
+		service.pages.drain(ctx)
+
+		assert.NoError(t, err)
+		mockPager.AssertExpectations(t)
+	})
+
+	t.Run("pager failure does not fail sync", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockClient := mocks.NewAPIClientInterface(t)
+		mockPager := mocks.NewPagerInterface(t)
+		service := NewAlertService(mockStorage, mockClient)
+		service.SetPager(mockPager, PagerPolicy{})
+
+		externalAlerts := []external.ExternalAlert{
+			{Source: "firewall", Severity: "critical", Description: "intrusion", CreatedAt: time.Now()},
+		}
+
+		mockClient.On("CheckHealth", ctx).Return(nil)
+		mockStorage.On("GetLastSyncTime", ctx).Return(time.Time{}, nil)
+		mockClient.On("FetchAllAlerts", ctx).Return(externalAlerts, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(assignIDs).Return(nil)
+		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
+		mockPager.On("Trigger", mock.Anything, mock.Anything).Return(errors.New("pagerduty down"))
+
+		err := service.PerformSync(ctx)
+		service.pages.drain(ctx)
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestAlertService_PagesThroughEscalation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	createdAt := now.Add(-time.Minute)
+
+	// syncCritical stores one critical alert with an escalation configured,
+	// then runs the escalation scheduler once the page's hold has expired
+	syncCritical := func(t *testing.T, policy PagerPolicy, fill bool, pagerErr error) *mocks.PagerInterface {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockClient := mocks.NewAPIClientInterface(t)
+		mockPager := mocks.NewPagerInterface(t)
+		escStorage := mocks.NewEscalationStorageInterface(t)
+		service := NewAlertService(mockStorage, mockClient)
+		service.SetPager(mockPager, policy)
+		escalation := NewEscalationService(escStorage, mockStorage, []PagerInterface{mockPager}, EscalationPolicy{Timeout: 10 * time.Minute, MaxNotifications: 3})
+		escalation.now = func() time.Time { return now }
+		service.SetEscalation(escalation)
+		if fill {
+			service.pages.enqueue(pagerTask{alertID: "other", action: "trigger", run: func(context.Context) {}})
+		}
+
+		var stored models.Alert
+		mockClient.On("CheckHealth", ctx).Return(nil)
+		mockStorage.On("GetLastSyncTime", ctx).Return(time.Time{}, nil)
+		mockClient.On("FetchAllAlerts", ctx).Return([]external.ExternalAlert{
+			{Source: "firewall", Severity: "critical", Description: "intrusion", CreatedAt: createdAt},
+		}, nil)
+		mockStorage.On("CreateAlerts", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			assignIDs(args)
+			stored = args.Get(1).([]models.Alert)[0]
+			stored.Status = models.StatusOpen
+		}).Return(nil)
+		mockStorage.On("UpdateLastSyncTime", ctx, mock.Anything).Return(nil)
+		escStorage.On("CreateEscalation", mock.Anything, "stored-0", now.Add(escalationLease)).Return(nil).Once()
+		if !fill {
+			mockPager.On("Trigger", mock.Anything, mock.Anything).Return(pagerErr).Once()
+		}
+
+		require.NoError(t, service.PerformSync(ctx))
+		service.pages.drain(ctx)
+
+		now = now.Add(escalationLease)
+		escStorage.On("ClaimDueEscalations", ctx, now, now.Add(escalationLease), escalationBatchSize).
+			Return([]models.Escalation{{ID: "esc-1", AlertID: "stored-0", Notifications: 0, Status: models.EscalationActive}}, nil).Once()
+		mockStorage.On("GetAlertByID", ctx, "stored-0").Return(&stored, nil)
+		escStorage.On("UpdateEscalation", ctx, mock.MatchedBy(func(esc *models.Escalation) bool {
+			return esc.Tier == 0 && esc.Notifications == 1
+		})).Return(true, nil).Once()
+		mockPager.On("Trigger", ctx, stored).Return(nil).Once()
+
+		n, err := escalation.ProcessDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		escStorage.AssertNotCalled(t, "RecordInitialPage", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		return mockPager
+	}
+
+	t.Run("failed page is sent by the escalation", func(t *testing.T) {
+		mockPager := syncCritical(t, PagerPolicy{}, false, errors.New("pagerduty down"))
+		mockPager.AssertNumberOfCalls(t, "Trigger", 2)
+	})
+
+	t.Run("dropped page is sent by the escalation", func(t *testing.T) {
+		mockPager := syncCritical(t, PagerPolicy{QueueSize: 1}, true, nil)
+		mockPager.AssertNumberOfCalls(t, "Trigger", 1)
+	})
+}
+
+func TestAlertService_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("acknowledge sends pager acknowledge", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockPager := mocks.NewPagerInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetPager(mockPager, PagerPolicy{})
+
+		acked := &models.Alert{ID: "1", Severity: "critical", Fingerprint: "fp-1", Status: models.StatusAcknowledged}
+		mockStorage.On("UpdateAlertStatus", ctx, "1", alertTransitions[models.StatusAcknowledged], models.StatusAcknowledged).Return(acked, nil)
+		mockPager.On("Acknowledge", mock.Anything, "fp-1").Return(nil)
+
+		alert, err := service.AcknowledgeAlert(ctx, "1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.StatusAcknowledged, alert.Status)
+		mockPager.AssertNotCalled(t, "Acknowledge", mock.Anything, mock.Anything)
+		service.pages.drain(ctx)
+		mockPager.AssertExpectations(t)
+	})
+
+	t.Run("resolve sends pager resolve", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockPager := mocks.NewPagerInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetPager(mockPager, PagerPolicy{})
+
+		resolved := &models.Alert{ID: "1", Severity: "critical", Fingerprint: "fp-1", Status: models.StatusResolved}
+		mockStorage.On("UpdateAlertStatus", ctx, "1", alertTransitions[models.StatusResolved], models.StatusResolved).Return(resolved, nil)
+		mockPager.On("Resolve", mock.Anything, "fp-1").Return(nil)
+
+		alert, err := service.ResolveAlert(ctx, "1")
+		service.pages.drain(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.StatusResolved, alert.Status)
+	})
+
+	t.Run("non-critical alert is not paged", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockPager := mocks.NewPagerInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetPager(mockPager, PagerPolicy{})
+
+		resolved := &models.Alert{ID: "1", Severity: "low", Fingerprint: "fp-1", Status: models.StatusResolved}
+		mockStorage.On("UpdateAlertStatus", ctx, "1", alertTransitions[models.StatusResolved], models.StatusResolved).Return(resolved, nil)
+
+		_, err := service.ResolveAlert(ctx, "1")
+		service.pages.drain(ctx)
+
+		assert.NoError(t, err)
+		mockPager.AssertNotCalled(t, "Resolve", mock.Anything, mock.Anything)
+	})
+
+	t.Run("lifecycle change is published", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockPublisher := mocks.NewEventPublisher(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetPublisher(mockPublisher)
+
+		acked := &models.Alert{ID: "1", Severity: "high", Status: models.StatusAcknowledged}
+		mockStorage.On("UpdateAlertStatus", ctx, "1", alertTransitions[models.StatusAcknowledged], models.StatusAcknowledged).Return(acked, nil)
+		mockPublisher.On("Publish", models.EventAlertAcknowledged, *acked).Return(models.AlertEvent{ID: 1})
+
+		_, err := service.AcknowledgeAlert(ctx, "1")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid transition", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		mockPager := mocks.NewPagerInterface(t)
+		service := NewAlertService(mockStorage, nil)
+		service.SetPager(mockPager, PagerPolicy{})
+
+		// A resolve that won a race against this acknowledge leaves nothing to update
+		resolved := &models.Alert{ID: "1", Severity: "critical", Fingerprint: "fp-1", Status: models.StatusResolved}
+		mockStorage.On("UpdateAlertStatus", ctx, "1", []string{models.StatusOpen}, models.StatusAcknowledged).Return(nil, nil)
+		mockStorage.On("GetAlertByID", ctx, "1").Return(resolved, nil)
+
+		alert, err := service.AcknowledgeAlert(ctx, "1")
+		service.pages.drain(ctx)
+
+		assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+		assert.Nil(t, alert)
+		mockPager.AssertNotCalled(t, "Acknowledge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown alert", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		mockStorage.On("UpdateAlertStatus", ctx, "999", alertTransitions[models.StatusResolved], models.StatusResolved).Return(nil, nil)
+		mockStorage.On("GetAlertByID", ctx, "999").Return(nil, nil)
+
+		alert, err := service.ResolveAlert(ctx, "999")
+
+		assert.ErrorIs(t, err, ErrAlertNotFound)
+		assert.Nil(t, alert)
+	})
+
+	t.Run("storage failure is not a missing alert", func(t *testing.T) {
+		mockStorage := mocks.NewAlertStorageInterface(t)
+		service := NewAlertService(mockStorage, nil)
+
+		mockStorage.On("UpdateAlertStatus", ctx, "1", alertTransitions[models.StatusResolved], models.StatusResolved).Return(nil, errors.New("connection refused"))
+
+		alert, err := service.ResolveAlert(ctx, "1")
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrAlertNotFound)
+		assert.Nil(t, alert)
+	})
 }
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"alertschema"
+	"censys_alert_system/external"
+)
+
+// The severity and source rules are shared with the mock API through
+// alertschema, so pushed alerts are held to the same contract as polled ones
+
+// IsValidSeverity checks if severity is valid
+func IsValidSeverity(severity string) bool {
+	return alertschema.IsValidSeverity(severity)
+}
+
+// IsValidSource checks if source is valid
+func IsValidSource(source string) bool {
+	return alertschema.IsValidSource(source)
+}
+
+// ValidateExternalAlert checks a pushed alert against the source and severity rules.
+// created_at is required: it is part of the fingerprint, so a default like the
+// time of receipt would store a retried alert twice.
+func ValidateExternalAlert(alert external.ExternalAlert) error {
+	if !IsValidSource(alert.Source) {
+		return fmt.Errorf("invalid source %q", alert.Source)
+	}
+	if !IsValidSeverity(alert.Severity) {
+		return fmt.Errorf("invalid severity %q", alert.Severity)
+	}
+	if strings.TrimSpace(alert.Description) == "" {
+		return fmt.Errorf("description is required")
+	}
+	if alert.CreatedAt.IsZero() {
+		return fmt.Errorf("created_at is required")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package spool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"censys_alert_system/internal/service"
+)
+
+// checkpoint is the durable progress of a file being processed. Records
+// before Done are settled. Records in [Done, PendingEnd) were handed to the
+// ingester but not confirmed, so after a crash they are checked against
+// storage before being ingested again.
+type checkpoint struct {
+	Done       int                   `json:"done"`
+	PendingEnd int                   `json:"pending_end"`
+	Accepted   int                   `json:"accepted"`
+	Rejected   int                   `json:"rejected"`
+	Errors     []service.IngestError `json:"errors,omitempty"`
+}
+
+// Report is the sidecar written next to each finished file
+type Report struct {
+	File     string                `json:"file"`
+	Status   string                `json:"status"`
+	Error    string                `json:"error,omitempty"`
+	Records  int                   `json:"records"`
+	Accepted int                   `json:"accepted"`
+	Rejected int                   `json:"rejected"`
+	Errors   []service.IngestError `json:"errors,omitempty"`
+}
+
+func checkpointPath(processingPath string) string {
+	return processingPath + ".checkpoint"
+}
+
+// loadCheckpoint returns the saved progress, or an empty checkpoint for a new file
+func loadCheckpoint(path string) (checkpoint, error) {
+	var cp checkpoint
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, fmt.Errorf("spool: reading checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, fmt.Errorf("spool: invalid checkpoint %s: %w", path, err)
+	}
+	return cp, nil
+}
+
+// writeJSONFile durably replaces path with the JSON encoding of value
+func writeJSONFile(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("spool: writing %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("spool: syncing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir makes renames within dir durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("spool: syncing %s: %w", dir, err)
+	}
+	return nil
+}
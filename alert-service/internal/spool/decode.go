@@ -0,0 +1,257 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"censys_alert_system/external"
+)
+
+// maxLineBytes caps a single NDJSON line
+const maxLineBytes = 1 << 20
+
+// Spool file formats, chosen by extension (optionally followed by .gz)
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+)
+
+// record is one decoded entry of a spool file
+type record struct {
+	alert external.ExternalAlert
+	err   error
+}
+
+// fileFormat returns the format of a spool file name and whether it is
+// gzip-compressed, or ok=false when the file is not a spool file
+func fileFormat(name string) (format string, compressed bool, ok bool) {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".gz") {
+		compressed = true
+		lower = strings.TrimSuffix(lower, ".gz")
+	}
+
+	switch filepath.Ext(lower) {
+	case ".json":
+		return formatJSON, compressed, true
+	case ".ndjson", ".jsonl":
+		return formatNDJSON, compressed, true
+	case ".csv":
+		return formatCSV, compressed, true
+	default:
+		return "", false, false
+	}
+}
+
+// decodeFile reads every record of a spool file. Records without created_at
+// get defaultTime, so fingerprints are stable when a file is processed again.
+// A non-nil error means the file itself could not be read.
+func decodeFile(name string, r io.Reader, defaultSource string, defaultTime time.Time) ([]record, error) {
+	format, compressed, ok := fileFormat(name)
+	if !ok {
+		return nil, fmt.Errorf("spool: unsupported file type %q", name)
+	}
+
+	if compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("spool: invalid gzip: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var records []record
+	var err error
+	switch format {
+	case formatJSON:
+		records, err = decodeJSON(r)
+	case formatNDJSON:
+		records, err = decodeNDJSON(r)
+	default:
+		records, err = decodeCSV(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		if records[i].err != nil {
+			continue
+		}
+		if records[i].alert.Source == "" {
+			records[i].alert.Source = defaultSource
+		}
+		if records[i].alert.CreatedAt.IsZero() {
+			records[i].alert.CreatedAt = defaultTime.UTC()
+		}
+	}
+	return records, nil
+}
+
+// decodeJSON reads a JSON array (streamed), an {"alerts": [...]} object or a single alert
+func decodeJSON(r io.Reader) ([]record, error) {
+	br := bufio.NewReader(r)
+	first, err := firstNonSpace(br)
+	if err != nil {
+		return nil, fmt.Errorf("spool: empty JSON file")
+	}
+
+	decoder := json.NewDecoder(br)
+	switch first {
+	case '[':
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("spool: invalid JSON array: %w", err)
+		}
+		var records []record
+		for decoder.More() {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("spool: invalid JSON array: %w", err)
+			}
+			records = append(records, decodeAlert(raw))
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("spool: invalid JSON array: %w", err)
+		}
+		return records, nil
+	case '{':
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("spool: invalid JSON object: %w", err)
+		}
+		var batch struct {
+			Alerts []json.RawMessage `json:"alerts"`
+		}
+		if err := json.Unmarshal(raw, &batch); err != nil {
+			return nil, fmt.Errorf("spool: invalid JSON object: %w", err)
+		}
+		if batch.Alerts == nil {
+			return []record{decodeAlert(raw)}, nil
+		}
+		records := make([]record, len(batch.Alerts))
+		for i, alert := range batch.Alerts {
+			records[i] = decodeAlert(alert)
+		}
+		return records, nil
+	default:
+		return nil, errors.New("spool: JSON file must hold an object or an array")
+	}
+}
+
+// decodeNDJSON reads one alert per non-empty line
+func decodeNDJSON(r io.Reader) ([]record, error) {
+	var records []record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		records = append(records, decodeAlert(append([]byte(nil), line...)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("spool: invalid NDJSON: %w", err)
+	}
+	return records, nil
+}
+
+// decodeCSV reads a CSV file with a header row. The source, severity,
+// description and created_at columns map to the alert; every column is kept
+// in the raw event.
+func decodeCSV(r io.Reader) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("spool: missing CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+
+	var records []record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				records = append(records, record{err: fmt.Errorf("invalid CSV row: %v", err)})
+				continue
+			}
+			return nil, fmt.Errorf("spool: reading CSV: %w", err)
+		}
+		if len(row) != len(header) {
+			records = append(records, record{err: fmt.Errorf("row has %d columns, header has %d", len(row), len(header))})
+			continue
+		}
+		records = append(records, csvRecord(header, row))
+	}
+}
+
+func csvRecord(header, row []string) record {
+	fields := make(map[string]string, len(header))
+	for i, column := range header {
+		fields[column] = row[i]
+	}
+
+	alert := external.ExternalAlert{
+		Source:      fields["source"],
+		Severity:    fields["severity"],
+		Description: fields["description"],
+	}
+	if value := fields["created_at"]; value != "" {
+		createdAt, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return record{err: fmt.Errorf("invalid created_at %q", value)}
+		}
+		alert.CreatedAt = createdAt
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return record{err: err}
+	}
+	alert.Raw = raw
+	return record{alert: alert}
+}
+
+func decodeAlert(raw json.RawMessage) record {
+	var alert external.ExternalAlert
+	if err := json.Unmarshal(raw, &alert); err != nil {
+		return record{err: fmt.Errorf("invalid alert: %v", err)}
+	}
+	alert.Raw = raw
+	return record{alert: alert}
+}
+
+// firstNonSpace peeks the first non-whitespace byte without consuming it
+func firstNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, r.UnreadByte()
+	}
+}
@@ -0,0 +1,116 @@
+package spool
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fileTime = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFileFormat(t *testing.T) {
+	for name, want := range map[string]struct {
+		format     string
+		compressed bool
+		ok         bool
+	}{
+		"batch.json":      {formatJSON, false, true},
+		"batch.NDJSON":    {formatNDJSON, false, true},
+		"batch.jsonl.gz":  {formatNDJSON, true, true},
+		"export.csv.gz":   {formatCSV, true, true},
+		"notes.txt":       {"", false, false},
+		"archive.tar.gz":  {"", false, false},
+		"batch.json.part": {"", false, false},
+	} {
+		format, compressed, ok := fileFormat(name)
+		assert.Equal(t, want.format, format, name)
+		assert.Equal(t, want.compressed, compressed, name)
+		assert.Equal(t, want.ok, ok, name)
+	}
+}
+
+func TestDecodeFile_JSON(t *testing.T) {
+	for name, content := range map[string]string{
+		"array":  `[{"source": "ids", "severity": "high", "description": "a"}, {"source": "ids", "severity": "low", "description": "b", "created_at": "2024-01-01T00:00:00Z"}]`,
+		"alerts": `{"alerts": [{"source": "ids", "severity": "high", "description": "a"}, {"source": "ids", "severity": "low", "description": "b", "created_at": "2024-01-01T00:00:00Z"}]}`,
+	} {
+		records, err := decodeFile("batch.json", strings.NewReader(content), "", fileTime)
+
+		require.NoError(t, err, name)
+		require.Len(t, records, 2, name)
+		assert.Equal(t, "a", records[0].alert.Description, name)
+		assert.Equal(t, fileTime, records[0].alert.CreatedAt, "missing created_at uses the file time")
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), records[1].alert.CreatedAt, name)
+		assert.JSONEq(t, `{"source": "ids", "severity": "high", "description": "a"}`, string(records[0].alert.Raw), name)
+	}
+
+	records, err := decodeFile("one.json", strings.NewReader(`{"severity": "high", "description": "a"}`), "firewall", fileTime)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "firewall", records[0].alert.Source, "missing source uses the default")
+
+	records, err = decodeFile("bad.json", strings.NewReader(`[{"source": "ids"}, "nope"]`), "", fileTime)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.NoError(t, records[0].err)
+	assert.ErrorContains(t, records[1].err, "invalid alert")
+
+	for _, content := range []string{``, `42`, `[{"source": "ids"}`, `{"alerts": 5}`} {
+		_, err := decodeFile("bad.json", strings.NewReader(content), "", fileTime)
+		assert.Error(t, err, content)
+	}
+}
+
+func TestDecodeFile_NDJSON(t *testing.T) {
+	content := "{\"source\": \"ids\", \"severity\": \"high\", \"description\": \"a\"}\n\n{broken\n"
+
+	records, err := decodeFile("batch.ndjson", strings.NewReader(content), "", fileTime)
+
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.NoError(t, records[0].err)
+	assert.Error(t, records[1].err)
+}
+
+func TestDecodeFile_CSV(t *testing.T) {
+	content := "\ufeffSource,Severity,Description,created_at,rule\n" +
+		"ids,high,\"Port scan, external\",2024-01-01T00:00:00Z,R1\n" +
+		"firewall,low,Blocked,,R2\n" +
+		"ids,high,bad time,yesterday,R3\n" +
+		"ids,high\n"
+
+	records, err := decodeFile("export.csv", strings.NewReader(content), "", fileTime)
+
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "Port scan, external", records[0].alert.Description)
+	assert.JSONEq(t, `{"source": "ids", "severity": "high", "description": "Port scan, external", "created_at": "2024-01-01T00:00:00Z", "rule": "R1"}`, string(records[0].alert.Raw))
+	assert.Equal(t, fileTime, records[1].alert.CreatedAt)
+	assert.ErrorContains(t, records[2].err, `invalid created_at "yesterday"`)
+	assert.ErrorContains(t, records[3].err, "row has 2 columns, header has 5")
+
+	_, err = decodeFile("empty.csv", strings.NewReader(""), "", fileTime)
+	assert.ErrorContains(t, err, "missing CSV header")
+}
+
+func TestDecodeFile_Gzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte("{\"source\": \"ids\", \"severity\": \"high\", \"description\": \"a\"}\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	records, err := decodeFile("batch.ndjson.gz", &buf, "", fileTime)
+
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "a", records[0].alert.Description)
+
+	_, err = decodeFile("batch.ndjson.gz", strings.NewReader("not gzip"), "", fileTime)
+	assert.ErrorContains(t, err, "invalid gzip")
+}
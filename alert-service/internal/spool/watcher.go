@@ -0,0 +1,380 @@
+// Package spool ingests alert batches dropped as files into a watched directory.
+//
+// A file is claimed by renaming it into processing/, ingested in batches with
+// a durable checkpoint after each one, and finally moved to done/ or failed/
+// next to a <name>.report.json sidecar. After a crash, files left in
+// processing/ resume from their checkpoint; records of a batch that was in
+// flight are looked up by fingerprint so they are not stored twice.
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+)
+
+// Subdirectories of the spool directory
+const (
+	processingDir = "processing"
+	doneDir       = "done"
+	failedDir     = "failed"
+)
+
+const reportSuffix = ".report.json"
+
+// Report statuses
+const (
+	StatusDone   = "done"
+	StatusFailed = "failed"
+)
+
+// Ingester stores decoded alerts. Implemented by service.AlertService
+type Ingester interface {
+	IngestAlerts(ctx context.Context, channel string, alerts []external.ExternalAlert) (service.IngestResult, error)
+	AlertExists(ctx context.Context, fingerprint string) (bool, error)
+}
+
+// Config configures the watcher
+type Config struct {
+	Dir          string
+	PollInterval time.Duration
+	// SettleTime is how long a file must be unmodified before it is claimed
+	SettleTime time.Duration
+	BatchSize  int
+	// DefaultSource is used for records without a source
+	DefaultSource string
+}
+
+// Watcher polls the spool directory and ingests the files dropped into it
+type Watcher struct {
+	cfg      Config
+	ingester Ingester
+	now      func() time.Time
+}
+
+// NewWatcher creates a watcher and its processing, done and failed directories
+func NewWatcher(cfg Config, ingester Ingester) (*Watcher, error) {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.SettleTime < 0 {
+		cfg.SettleTime = 0
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+
+	for _, dir := range []string{processingDir, doneDir, failedDir} {
+		if err := os.MkdirAll(filepath.Join(cfg.Dir, dir), 0o750); err != nil {
+			return nil, fmt.Errorf("spool: %w", err)
+		}
+	}
+
+	return &Watcher{cfg: cfg, ingester: ingester, now: time.Now}, nil
+}
+
+// Run processes files until ctx is cancelled. Files interrupted by a previous
+// run are finished before new files are claimed.
+func (w *Watcher) Run(ctx context.Context) {
+	log.Printf("[SPOOL] Watching %s every %s", w.cfg.Dir, w.cfg.PollInterval)
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[SPOOL] Stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll resumes files left in processing/ and then claims every settled file
+// in the spool directory
+func (w *Watcher) Poll(ctx context.Context) {
+	w.resume(ctx)
+
+	entries, err := os.ReadDir(w.cfg.Dir)
+	if err != nil {
+		log.Printf("[SPOOL] Error reading %s: %v", w.cfg.Dir, err)
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if entry.IsDir() || ignored(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		// Files still being written are left for a later poll
+		if w.now().Sub(info.ModTime()) < w.cfg.SettleTime {
+			continue
+		}
+
+		claimed, err := w.claim(entry.Name())
+		if err != nil {
+			log.Printf("[SPOOL] Error claiming %s: %v", entry.Name(), err)
+			continue
+		}
+		w.processFile(ctx, claimed)
+	}
+}
+
+// resume finishes files a previous run left in processing/
+func (w *Watcher) resume(ctx context.Context) {
+	dir := filepath.Join(w.cfg.Dir, processingDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("[SPOOL] Error reading %s: %v", dir, err)
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+
+		if base, ok := strings.CutSuffix(name, ".checkpoint"); ok {
+			// The file was moved on but the checkpoint was not removed yet
+			if _, err := os.Stat(filepath.Join(dir, base)); errors.Is(err, os.ErrNotExist) {
+				os.Remove(path)
+			}
+			continue
+		}
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		log.Printf("[SPOOL] Resuming %s", name)
+		w.processFile(ctx, path)
+	}
+}
+
+// claim atomically moves a file into processing/
+func (w *Watcher) claim(name string) (string, error) {
+	claimed := filepath.Join(w.cfg.Dir, processingDir, name)
+	if _, err := os.Stat(claimed); err == nil {
+		return "", fmt.Errorf("a file with the same name is still being processed")
+	}
+
+	if err := os.Rename(filepath.Join(w.cfg.Dir, name), claimed); err != nil {
+		return "", err
+	}
+	if err := syncDir(filepath.Dir(claimed)); err != nil {
+		return "", err
+	}
+	return claimed, syncDir(w.cfg.Dir)
+}
+
+// processFile ingests a claimed file from its checkpoint onwards and moves it
+// to done/ or failed/ once every record is settled. It returns early, leaving
+// the file in processing/ for a later poll, when ctx is cancelled, a batch
+// cannot be stored or a checkpoint cannot be saved.
+func (w *Watcher) processFile(ctx context.Context, path string) {
+	name := filepath.Base(path)
+	cpPath := checkpointPath(path)
+
+	cp, err := loadCheckpoint(cpPath)
+	if err != nil {
+		// Without progress the file cannot be resumed safely
+		w.finish(path, cp, 0, err)
+		return
+	}
+
+	records, err := w.readFile(path)
+	if err != nil {
+		w.finish(path, cp, 0, err)
+		return
+	}
+	if len(records) == 0 {
+		w.finish(path, cp, 0, errors.New("file holds no records"))
+		return
+	}
+
+	for cp.Done < len(records) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		end := cp.PendingEnd
+		recovering := end > cp.Done
+		if !recovering {
+			end = min(cp.Done+w.cfg.BatchSize, len(records))
+		}
+		end = min(end, len(records))
+
+		next, err := w.ingestBatch(ctx, path, records, cp, end, recovering)
+		if err != nil {
+			log.Printf("[SPOOL] %s: %v", name, err)
+			return
+		}
+		if ctx.Err() != nil {
+			// The batch may be partly stored; the next run checks it against storage
+			return
+		}
+
+		cp = next
+		if err := writeJSONFile(cpPath, cp); err != nil {
+			log.Printf("[SPOOL] %s: error saving checkpoint: %v", name, err)
+			return
+		}
+	}
+
+	w.finish(path, cp, len(records), nil)
+}
+
+// ingestBatch settles records [cp.Done, end). When recovering, records that
+// are already stored were accepted before the crash and are not ingested again.
+func (w *Watcher) ingestBatch(ctx context.Context, path string, records []record, cp checkpoint, end int, recovering bool) (checkpoint, error) {
+	// Mark the batch in flight before any of it is handed over
+	inFlight := cp
+	inFlight.PendingEnd = end
+	if err := writeJSONFile(checkpointPath(path), inFlight); err != nil {
+		return cp, fmt.Errorf("error saving checkpoint: %w", err)
+	}
+
+	var alerts []external.ExternalAlert
+	var positions []int
+	for i := cp.Done; i < end; i++ {
+		rec := records[i]
+		if rec.err != nil {
+			cp.Rejected++
+			cp.Errors = append(cp.Errors, service.IngestError{Index: i, Error: rec.err.Error()})
+			continue
+		}
+
+		if recovering {
+			fingerprint := models.Fingerprint(rec.alert.Source, rec.alert.Severity, rec.alert.Description, rec.alert.CreatedAt)
+			exists, err := w.ingester.AlertExists(ctx, fingerprint)
+			if err != nil {
+				return cp, fmt.Errorf("checking record %d: %w", i, err)
+			}
+			if exists {
+				cp.Accepted++
+				continue
+			}
+		}
+
+		alerts = append(alerts, rec.alert)
+		positions = append(positions, i)
+	}
+
+	if len(alerts) > 0 {
+		// A storage failure leaves the batch in flight, so the next poll
+		// resumes it and skips the records that were stored
+		result, err := w.ingester.IngestAlerts(ctx, "spool:"+filepath.Base(path), alerts)
+		if err != nil {
+			return cp, fmt.Errorf("ingesting records %d-%d: %w", cp.Done, end-1, err)
+		}
+		cp.Accepted += result.Accepted
+		for _, e := range result.Errors {
+			cp.Rejected++
+			cp.Errors = append(cp.Errors, service.IngestError{Index: positions[e.Index], Error: e.Error})
+		}
+	}
+
+	cp.Done = end
+	cp.PendingEnd = end
+	return cp, nil
+}
+
+// readFile decodes a claimed file. Records without created_at get the file's
+// modification time, which survives the claim rename.
+func (w *Watcher) readFile(path string) ([]record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("spool: %w", err)
+	}
+
+	return decodeFile(filepath.Base(path), f, w.cfg.DefaultSource, info.ModTime())
+}
+
+// finish writes the report and moves the file to done/ or failed/. A file
+// fails when it cannot be read or when none of its records were accepted.
+func (w *Watcher) finish(path string, cp checkpoint, records int, fileErr error) {
+	name := filepath.Base(path)
+	report := Report{
+		File:     name,
+		Status:   StatusDone,
+		Records:  records,
+		Accepted: cp.Accepted,
+		Rejected: cp.Rejected,
+		Errors:   cp.Errors,
+	}
+	if fileErr != nil {
+		report.Error = fileErr.Error()
+	}
+	if fileErr != nil || cp.Accepted == 0 {
+		report.Status = StatusFailed
+	}
+
+	destDir := filepath.Join(w.cfg.Dir, doneDir)
+	if report.Status == StatusFailed {
+		destDir = filepath.Join(w.cfg.Dir, failedDir)
+	}
+	dest := filepath.Join(destDir, name)
+	if _, err := os.Stat(dest); err == nil {
+		dest = filepath.Join(destDir, w.now().UTC().Format("20060102T150405.000000000")+"-"+name)
+	}
+
+	// The report goes first so a moved file always has one
+	if err := writeJSONFile(dest+reportSuffix, report); err != nil {
+		log.Printf("[SPOOL] %s: error writing report: %v", name, err)
+		return
+	}
+	if err := os.Rename(path, dest); err != nil {
+		log.Printf("[SPOOL] %s: error moving to %s: %v", name, destDir, err)
+		return
+	}
+	if err := syncDir(destDir); err != nil {
+		log.Printf("[SPOOL] %s: %v", name, err)
+	}
+	if err := os.Remove(checkpointPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("[SPOOL] %s: error removing checkpoint: %v", name, err)
+	}
+
+	metrics.SpoolFilesProcessed.WithLabelValues(report.Status).Inc()
+	metrics.SpoolRecords.WithLabelValues("accepted").Add(float64(report.Accepted))
+	metrics.SpoolRecords.WithLabelValues("rejected").Add(float64(report.Rejected))
+
+	if fileErr != nil {
+		log.Printf("[SPOOL] %s: failed: %v", name, fileErr)
+		return
+	}
+	log.Printf("[SPOOL] %s: %s, %d record(s), accepted %d, rejected %d", name, report.Status, records, report.Accepted, report.Rejected)
+}
+
+// ignored reports whether a directory entry is not for the watcher: hidden
+// files and in-progress uploads (*.tmp, *.part)
+func ignored(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(lower, ".tmp") || strings.HasSuffix(lower, ".part")
+}
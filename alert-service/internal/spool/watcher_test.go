@@ -0,0 +1,253 @@
+package spool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIngester stores alerts by fingerprint and applies the service's validation
+type fakeIngester struct {
+	mu       sync.Mutex
+	stored   map[string]external.ExternalAlert
+	ingested []external.ExternalAlert
+	// failOn makes storing the alert with this description fail
+	failOn string
+}
+
+func newFakeIngester() *fakeIngester {
+	return &fakeIngester{stored: make(map[string]external.ExternalAlert)}
+}
+
+func (f *fakeIngester) IngestAlerts(ctx context.Context, channel string, alerts []external.ExternalAlert) (service.IngestResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result service.IngestResult
+	for i, alert := range alerts {
+		if err := service.ValidateExternalAlert(alert); err != nil {
+			result.Reject(i, err.Error())
+			continue
+		}
+		if f.failOn != "" && alert.Description == f.failOn {
+			return result, errors.New("database unavailable")
+		}
+		f.stored[fingerprint(alert)] = alert
+		f.ingested = append(f.ingested, alert)
+		result.Accepted++
+	}
+	return result, nil
+}
+
+func (f *fakeIngester) AlertExists(ctx context.Context, fp string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.stored[fp]
+	return ok, nil
+}
+
+func fingerprint(alert external.ExternalAlert) string {
+	return models.Fingerprint(alert.Source, alert.Severity, alert.Description, alert.CreatedAt)
+}
+
+func newTestWatcher(t *testing.T, ingester Ingester, batchSize int) (*Watcher, string) {
+	t.Helper()
+	dir := t.TempDir()
+	w, err := NewWatcher(Config{Dir: dir, BatchSize: batchSize}, ingester)
+	require.NoError(t, err)
+	return w, dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func readReport(t *testing.T, path string) Report {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var report Report
+	require.NoError(t, json.Unmarshal(data, &report))
+	return report
+}
+
+const ndjsonBatch = `{"source": "ids", "severity": "high", "description": "one", "created_at": "2024-06-01T12:00:00Z"}
+{"source": "ids", "severity": "low", "description": "two", "created_at": "2024-06-01T12:01:00Z"}
+{"source": "ids", "severity": "bogus", "description": "three", "created_at": "2024-06-01T12:02:00Z"}
+{"source": "ids", "severity": "medium", "description": "four", "created_at": "2024-06-01T12:03:00Z"}
+`
+
+func TestWatcher_ProcessesFileIntoDone(t *testing.T) {
+	ingester := newFakeIngester()
+	w, dir := newTestWatcher(t, ingester, 2)
+	writeFile(t, filepath.Join(dir, "batch.ndjson"), ndjsonBatch)
+
+	w.Poll(context.Background())
+
+	assert.Len(t, ingester.ingested, 3)
+	assert.FileExists(t, filepath.Join(dir, "done", "batch.ndjson"))
+	assert.NoFileExists(t, filepath.Join(dir, "batch.ndjson"))
+	assert.NoFileExists(t, filepath.Join(dir, "processing", "batch.ndjson.checkpoint"))
+
+	report := readReport(t, filepath.Join(dir, "done", "batch.ndjson.report.json"))
+	assert.Equal(t, StatusDone, report.Status)
+	assert.Equal(t, 4, report.Records)
+	assert.Equal(t, 3, report.Accepted)
+	assert.Equal(t, 1, report.Rejected)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 2, report.Errors[0].Index)
+	assert.Contains(t, report.Errors[0].Error, "invalid severity")
+}
+
+func TestWatcher_FailedFiles(t *testing.T) {
+	ingester := newFakeIngester()
+	w, dir := newTestWatcher(t, ingester, 10)
+	writeFile(t, filepath.Join(dir, "broken.json"), `[{"source": `)
+	writeFile(t, filepath.Join(dir, "notes.txt"), "hello")
+	writeFile(t, filepath.Join(dir, "all-bad.ndjson"), `{"source": "nope", "severity": "high", "description": "x"}`)
+
+	w.Poll(context.Background())
+
+	for _, name := range []string{"broken.json", "notes.txt", "all-bad.ndjson"} {
+		assert.FileExists(t, filepath.Join(dir, "failed", name))
+		report := readReport(t, filepath.Join(dir, "failed", name+reportSuffix))
+		assert.Equal(t, StatusFailed, report.Status, name)
+	}
+	assert.Contains(t, readReport(t, filepath.Join(dir, "failed", "broken.json.report.json")).Error, "invalid JSON array")
+	assert.Contains(t, readReport(t, filepath.Join(dir, "failed", "notes.txt.report.json")).Error, "unsupported file type")
+	assert.Empty(t, ingester.ingested)
+}
+
+func TestWatcher_SkipsUnsettledAndTemporaryFiles(t *testing.T) {
+	ingester := newFakeIngester()
+	dir := t.TempDir()
+	w, err := NewWatcher(Config{Dir: dir, SettleTime: time.Hour}, ingester)
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(dir, "fresh.ndjson"), ndjsonBatch)
+	writeFile(t, filepath.Join(dir, ".hidden.ndjson"), ndjsonBatch)
+	writeFile(t, filepath.Join(dir, "upload.ndjson.part"), ndjsonBatch)
+
+	w.Poll(context.Background())
+	assert.FileExists(t, filepath.Join(dir, "fresh.ndjson"))
+
+	w.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	w.Poll(context.Background())
+
+	assert.FileExists(t, filepath.Join(dir, "done", "fresh.ndjson"))
+	assert.FileExists(t, filepath.Join(dir, ".hidden.ndjson"))
+	assert.FileExists(t, filepath.Join(dir, "upload.ndjson.part"))
+}
+
+func TestWatcher_ResumesAfterCrashWithoutDuplicates(t *testing.T) {
+	ingester := newFakeIngester()
+	w, dir := newTestWatcher(t, ingester, 2)
+
+	// A previous run stored "one" from its in-flight batch [0, 2) and crashed
+	// before confirming the batch
+	path := filepath.Join(dir, "processing", "batch.ndjson")
+	writeFile(t, path, ndjsonBatch)
+	records, err := w.readFile(path)
+	require.NoError(t, err)
+	ingester.stored[fingerprint(records[0].alert)] = records[0].alert
+	require.NoError(t, writeJSONFile(checkpointPath(path), checkpoint{Done: 0, PendingEnd: 2}))
+
+	w.Poll(context.Background())
+
+	var descriptions []string
+	for _, alert := range ingester.ingested {
+		descriptions = append(descriptions, alert.Description)
+	}
+	assert.Equal(t, []string{"two", "four"}, descriptions)
+
+	report := readReport(t, filepath.Join(dir, "done", "batch.ndjson.report.json"))
+	assert.Equal(t, 3, report.Accepted)
+	assert.Equal(t, 1, report.Rejected)
+}
+
+func TestWatcher_StorageFailureRetriesOnNextPoll(t *testing.T) {
+	ingester := newFakeIngester()
+	ingester.failOn = "four"
+	w, dir := newTestWatcher(t, ingester, 10)
+	writeFile(t, filepath.Join(dir, "batch.ndjson"), ndjsonBatch)
+
+	w.Poll(context.Background())
+
+	path := filepath.Join(dir, "processing", "batch.ndjson")
+	assert.FileExists(t, path, "the file stays in processing/ until it can be stored")
+	assert.NoFileExists(t, filepath.Join(dir, "done", "batch.ndjson"))
+	assert.NoFileExists(t, filepath.Join(dir, "failed", "batch.ndjson"))
+	cp, err := loadCheckpoint(checkpointPath(path))
+	require.NoError(t, err)
+	assert.Equal(t, checkpoint{Done: 0, PendingEnd: 4}, cp)
+
+	ingester.failOn = ""
+	w.Poll(context.Background())
+
+	var descriptions []string
+	for _, alert := range ingester.ingested {
+		descriptions = append(descriptions, alert.Description)
+	}
+	assert.Equal(t, []string{"one", "two", "four"}, descriptions, "records stored before the failure are not stored again")
+
+	report := readReport(t, filepath.Join(dir, "done", "batch.ndjson.report.json"))
+	assert.Equal(t, 3, report.Accepted)
+	assert.Equal(t, 1, report.Rejected)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 2, report.Errors[0].Index)
+}
+
+func TestWatcher_ResumesFromCheckpoint(t *testing.T) {
+	ingester := newFakeIngester()
+	w, dir := newTestWatcher(t, ingester, 2)
+
+	path := filepath.Join(dir, "processing", "batch.ndjson")
+	writeFile(t, path, ndjsonBatch)
+	require.NoError(t, writeJSONFile(checkpointPath(path), checkpoint{Done: 2, PendingEnd: 2, Accepted: 2}))
+
+	w.Poll(context.Background())
+
+	require.Len(t, ingester.ingested, 1)
+	assert.Equal(t, "four", ingester.ingested[0].Description)
+	assert.Equal(t, 3, readReport(t, filepath.Join(dir, "done", "batch.ndjson.report.json")).Accepted)
+}
+
+func TestWatcher_CancelledRunLeavesFileInProcessing(t *testing.T) {
+	ingester := newFakeIngester()
+	w, dir := newTestWatcher(t, ingester, 2)
+	path := filepath.Join(dir, "processing", "batch.ndjson")
+	writeFile(t, path, ndjsonBatch)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w.Poll(ctx)
+
+	assert.FileExists(t, path)
+	assert.Empty(t, ingester.ingested)
+}
+
+func TestWatcher_NameCollisionInDone(t *testing.T) {
+	ingester := newFakeIngester()
+	w, dir := newTestWatcher(t, ingester, 10)
+
+	writeFile(t, filepath.Join(dir, "batch.ndjson"), ndjsonBatch)
+	w.Poll(context.Background())
+	writeFile(t, filepath.Join(dir, "batch.ndjson"), ndjsonBatch)
+	w.Poll(context.Background())
+
+	entries, err := os.ReadDir(filepath.Join(dir, "done"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 4, "two files and two reports")
+}
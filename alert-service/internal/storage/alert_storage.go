@@ -3,12 +3,18 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"censys_alert_system/internal/models"
 )
 
+// alertColumns lists the columns selected for every alert query, in scan order
+const alertColumns = `id, source, severity, description, whole_event, enrichment_type, ip_address,
+		enrichment_versions, fingerprint, status, acknowledged_at, resolved_at, created_at`
+
 type AlertStorage struct {
 	db *sql.DB
 }
@@ -17,14 +23,97 @@ func NewAlertStorage(db *sql.DB) *AlertStorage {
 	return &AlertStorage{db: db}
 }
 
-// CreateAlert inserts a new alert into the database with enrichment
-func (s *AlertStorage) CreateAlert(ctx context.Context, source, severity, description string, wholeEvent []byte, enrichmentType, ipAddress string, createdAt time.Time) error {
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAlert scans a single row selected with alertColumns
+func scanAlert(row rowScanner) (*models.Alert, error) {
+	var alert models.Alert
+	var fingerprint sql.NullString
+	var versions []byte
+	err := row.Scan(
+		&alert.ID,
+		&alert.Source,
+		&alert.Severity,
+		&alert.Description,
+		&alert.WholeEvent,
+		&alert.EnrichmentType,
+		&alert.IPAddress,
+		&versions,
+		&fingerprint,
+		&alert.Status,
+		&alert.AcknowledgedAt,
+		&alert.ResolvedAt,
+		&alert.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	alert.Fingerprint = fingerprint.String
+	if len(versions) > 0 {
+		if err := json.Unmarshal(versions, &alert.EnrichmentVersions); err != nil {
+			return nil, fmt.Errorf("invalid enrichment versions: %w", err)
+		}
+	}
+
+	return &alert, nil
+}
+
+// encodeEnrichmentVersions encodes the enrichment_versions column, which is
+// never NULL
+func encodeEnrichmentVersions(versions map[string]int) []byte {
+	if len(versions) == 0 {
+		return []byte("{}")
+	}
+	data, _ := json.Marshal(versions)
+	return data
+}
+
+// scanAlerts drains rows into a slice of alerts
+func scanAlerts(rows *sql.Rows) ([]models.Alert, error) {
+	var alerts []models.Alert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning alert: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// CreateAlert inserts a new alert into the database with enrichment.
+// The generated ID and initial status are written back into alert.
+func (s *AlertStorage) CreateAlert(ctx context.Context, alert *models.Alert) error {
 	query := `
-		INSERT INTO alerts (source, severity, description, whole_event, enrichment_type, ip_address, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO alerts (source, severity, description, whole_event, enrichment_type, ip_address, enrichment_versions, fingerprint, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (fingerprint, created_at) DO NOTHING
+		RETURNING id, status
 	`
 
-	_, err := s.db.ExecContext(ctx, query, source, severity, description, wholeEvent, enrichmentType, ipAddress, createdAt)
+	err := s.db.QueryRowContext(ctx, query,
+		alert.Source,
+		alert.Severity,
+		alert.Description,
+		alert.WholeEvent,
+		alert.EnrichmentType,
+		alert.IPAddress,
+		encodeEnrichmentVersions(alert.EnrichmentVersions),
+		alert.Fingerprint,
+		alert.CreatedAt,
+	).Scan(&alert.ID, &alert.Status)
+	if err == sql.ErrNoRows {
+		// An alert with the same fingerprint is already stored
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("error creating alert: %w", err)
 	}
@@ -32,115 +121,386 @@ func (s *AlertStorage) CreateAlert(ctx context.Context, source, severity, descri
 	return nil
 }
 
+// insertBatchRows caps the rows of one multi-row INSERT, well under
+// Postgres' limit of 65535 bind parameters per statement
+const insertBatchRows = 1000
+
+// insertAlertColumns are the columns written by CreateAlerts, in argument order
+var insertAlertColumns = []string{"id", "source", "severity", "description", "whole_event", "enrichment_type", "ip_address", "enrichment_versions", "fingerprint", "created_at"}
+
+// CreateAlerts inserts alerts with multi-row INSERTs inside one transaction,
+// so either every alert is stored or none is. IDs are generated up front,
+// since the order of RETURNING rows is not guaranteed; the IDs and initial
+// status are written back into alerts after the commit. Alerts whose
+// fingerprint is already stored are skipped and keep an empty ID.
+func (s *AlertStorage) CreateAlerts(ctx context.Context, alerts []models.Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(alerts))
+	for i := range ids {
+		ids[i] = newAlertID()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting alert batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted := make(map[string]bool, len(alerts))
+	for start := 0; start < len(alerts); start += insertBatchRows {
+		end := min(start+insertBatchRows, len(alerts))
+		query, args := insertAlertsQuery(alerts[start:end], ids[start:end])
+		if err := insertAlertRows(ctx, tx, query, args, inserted); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing alert batch: %w", err)
+	}
+
+	for i := range alerts {
+		if inserted[ids[i]] {
+			alerts[i].ID = ids[i]
+			alerts[i].Status = models.StatusOpen
+		}
+	}
+	return nil
+}
+
+// insertAlertRows runs one multi-row INSERT and records the IDs it stored
+func insertAlertRows(ctx context.Context, tx *sql.Tx, query string, args []interface{}, inserted map[string]bool) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error creating alerts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("error scanning created alert: %w", err)
+		}
+		inserted[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error creating alerts: %w", err)
+	}
+	return nil
+}
+
+// insertAlertsQuery builds one multi-row INSERT and its arguments
+func insertAlertsQuery(alerts []models.Alert, ids []string) (string, []interface{}) {
+	var query strings.Builder
+	query.WriteString("INSERT INTO alerts (" + strings.Join(insertAlertColumns, ", ") + ") VALUES ")
+
+	args := make([]interface{}, 0, len(alerts)*len(insertAlertColumns))
+	for i, alert := range alerts {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j := range insertAlertColumns {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", len(args)+j+1)
+		}
+		query.WriteString(")")
+
+		args = append(args,
+			ids[i],
+			alert.Source,
+			alert.Severity,
+			alert.Description,
+			alert.WholeEvent,
+			alert.EnrichmentType,
+			alert.IPAddress,
+			encodeEnrichmentVersions(alert.EnrichmentVersions),
+			alert.Fingerprint,
+			alert.CreatedAt,
+		)
+	}
+	query.WriteString(" ON CONFLICT (fingerprint, created_at) DO NOTHING RETURNING id")
+	return query.String(), args
+}
+
+// restoreAlertColumns are the columns written by RestoreAlerts, in argument order
+var restoreAlertColumns = []string{"id", "source", "severity", "description", "whole_event", "enrichment_type", "ip_address", "enrichment_versions", "fingerprint", "status", "acknowledged_at", "resolved_at", "created_at"}
+
+// RestoreAlerts inserts archived alerts as they were stored, keeping their
+// IDs and lifecycle, inside one transaction. Alerts that are already stored
+// are skipped. Returns the number inserted.
+func (s *AlertStorage) RestoreAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+	if len(alerts) == 0 {
+		return 0, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting restore: %w", err)
+	}
+	defer tx.Rollback()
+
+	inserted := make(map[string]bool, len(alerts))
+	for start := 0; start < len(alerts); start += insertBatchRows {
+		end := min(start+insertBatchRows, len(alerts))
+		query, args := restoreAlertsQuery(alerts[start:end])
+		if err := insertAlertRows(ctx, tx, query, args, inserted); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing restore: %w", err)
+	}
+
+	return len(inserted), nil
+}
+
+// restoreAlertsQuery builds one multi-row INSERT of archived alerts and its
+// arguments. Any unique conflict, on the key or the fingerprint, skips the row.
+func restoreAlertsQuery(alerts []models.Alert) (string, []interface{}) {
+	var query strings.Builder
+	query.WriteString("INSERT INTO alerts (" + strings.Join(restoreAlertColumns, ", ") + ") VALUES ")
+
+	args := make([]interface{}, 0, len(alerts)*len(restoreAlertColumns))
+	for i, alert := range alerts {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j := range restoreAlertColumns {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", len(args)+j+1)
+		}
+		query.WriteString(")")
+
+		args = append(args,
+			alert.ID,
+			alert.Source,
+			alert.Severity,
+			alert.Description,
+			alert.WholeEvent,
+			alert.EnrichmentType,
+			alert.IPAddress,
+			encodeEnrichmentVersions(alert.EnrichmentVersions),
+			sql.NullString{String: alert.Fingerprint, Valid: alert.Fingerprint != ""},
+			alert.Status,
+			alert.AcknowledgedAt,
+			alert.ResolvedAt,
+			alert.CreatedAt,
+		)
+	}
+	query.WriteString(" ON CONFLICT DO NOTHING RETURNING id")
+	return query.String(), args
+}
+
+// newAlertID generates the IDs of batch-inserted alerts; tests replace it
+var newAlertID = models.NewAlertID
+
 // GetAlerts retrieves all alerts from the database
 func (s *AlertStorage) GetAlerts(ctx context.Context) ([]models.Alert, error) {
+	return s.ListAlerts(ctx, models.AlertFilter{})
+}
+
+// ListAlerts retrieves alerts matching the filter, newest first
+func (s *AlertStorage) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	where, args := filterClause(filter)
 	query := `
-		SELECT id, source, severity, description, whole_event, enrichment_type, ip_address, created_at
-		FROM alerts
-		ORDER BY created_at DESC
+		SELECT ` + alertColumns + `
+		FROM alerts` + where + `
+		ORDER BY created_at DESC, id DESC
 	`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error querying alerts: %w", err)
 	}
 	defer rows.Close()
 
-	var alerts []models.Alert
-	for rows.Next() {
-		var alert models.Alert
-		err := rows.Scan(
-			&alert.ID,
-			&alert.Source,
-			&alert.Severity,
-			&alert.Description,
-			&alert.WholeEvent,
-			&alert.EnrichmentType,
-			&alert.IPAddress,
-			&alert.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning alert: %w", err)
+	return scanAlerts(rows)
+}
+
+// filterClause builds a WHERE clause and its positional arguments for a filter
+func filterClause(filter models.AlertFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Days > 0 {
+		args = append(args, filter.Days)
+		conditions = append(conditions, fmt.Sprintf("created_at >= NOW() - INTERVAL '1 day' * $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			args = append(args, v)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
 		}
-		alerts = append(alerts, alert)
+		conditions = append(conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
 	}
+	in("source", filter.Sources)
+	in("severity", filter.Severities)
+	in("status", filter.Statuses)
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	if len(conditions) == 0 {
+		return "", nil
 	}
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
 
-	return alerts, nil
+// ListAlertsAfter retrieves up to limit alerts matching the filter, oldest
+// first, that come after the alert with the given created_at and ID. An
+// empty afterID starts from the oldest alert.
+func (s *AlertStorage) ListAlertsAfter(ctx context.Context, filter models.AlertFilter, afterCreatedAt time.Time, afterID string, limit int) ([]models.Alert, error) {
+	where, args := filterClause(filter)
+	if afterID != "" {
+		args = append(args, afterCreatedAt, afterID)
+		condition := fmt.Sprintf("(created_at, id) > ($%d, $%d)", len(args)-1, len(args))
+		if where == "" {
+			where = "\n\t\tWHERE " + condition
+		} else {
+			where += " AND " + condition
+		}
+	}
+	args = append(args, limit)
+
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts` + where + `
+		ORDER BY created_at, id
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying alerts: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAlerts(rows)
 }
 
-// GetAlertByID retrieves a single alert by ID
+// CountAlerts counts the alerts matching the filter
+func (s *AlertStorage) CountAlerts(ctx context.Context, filter models.AlertFilter) (int, error) {
+	where, args := filterClause(filter)
+	query := `
+		SELECT COUNT(*)
+		FROM alerts` + where
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting alerts: %w", err)
+	}
+
+	return count, nil
+}
+
+// UpdateAlertEnrichment writes an alert's enrichment fields and their versions
+func (s *AlertStorage) UpdateAlertEnrichment(ctx context.Context, alert *models.Alert) error {
+	query := `
+		UPDATE alerts
+		SET enrichment_type = $2, ip_address = $3, enrichment_versions = $4
+		WHERE id = $1
+	`
+
+	result, err := s.db.ExecContext(ctx, query, alert.ID, alert.EnrichmentType, alert.IPAddress, encodeEnrichmentVersions(alert.EnrichmentVersions))
+	if err != nil {
+		return fmt.Errorf("error updating alert enrichment: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("alert not found")
+	}
+
+	return nil
+}
+
+// GetAlertByID retrieves a single alert by ID.
+// Returns nil without error when there is none.
 func (s *AlertStorage) GetAlertByID(ctx context.Context, id string) (*models.Alert, error) {
 	query := `
-		SELECT id, source, severity, description, whole_event, enrichment_type, ip_address, created_at
+		SELECT ` + alertColumns + `
 		FROM alerts
 		WHERE id = $1
 	`
 
-	var alert models.Alert
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&alert.ID,
-		&alert.Source,
-		&alert.Severity,
-		&alert.Description,
-		&alert.WholeEvent,
-		&alert.EnrichmentType,
-		&alert.IPAddress,
-		&alert.CreatedAt,
-	)
-
+	alert, err := scanAlert(s.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("alert not found")
+		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error querying alert: %w", err)
 	}
 
-	return &alert, nil
+	return alert, nil
 }
 
-// GetAlertsByDays retrieves alerts from the last X days
-func (s *AlertStorage) GetAlertsByDays(ctx context.Context, days int) ([]models.Alert, error) {
+// AlertExists reports whether an alert with the given fingerprint is stored
+func (s *AlertStorage) AlertExists(ctx context.Context, fingerprint string) (bool, error) {
 	query := `
-		SELECT id, source, severity, description, whole_event, enrichment_type, ip_address, created_at
-		FROM alerts
-		WHERE created_at >= NOW() - INTERVAL '1 day' * $1
-		ORDER BY created_at DESC
+		SELECT EXISTS(SELECT 1 FROM alerts WHERE fingerprint = $1)
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, days)
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, query, fingerprint).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error checking alert fingerprint: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetAlertsByDays retrieves alerts from the last X days
+func (s *AlertStorage) GetAlertsByDays(ctx context.Context, days int) ([]models.Alert, error) {
+	alerts, err := s.ListAlerts(ctx, models.AlertFilter{Days: days})
 	if err != nil {
 		return nil, fmt.Errorf("error querying alerts by days: %w", err)
 	}
-	defer rows.Close()
 
-	var alerts []models.Alert
-	for rows.Next() {
-		var alert models.Alert
-		err := rows.Scan(
-			&alert.ID,
-			&alert.Source,
-			&alert.Severity,
-			&alert.Description,
-			&alert.WholeEvent,
-			&alert.EnrichmentType,
-			&alert.IPAddress,
-			&alert.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning alert: %w", err)
-		}
-		alerts = append(alerts, alert)
+	return alerts, nil
+}
+
+// UpdateAlertStatus moves an alert in one of the from statuses to status and
+// stamps the matching acknowledged_at/resolved_at column. Returns nil without
+// error when the alert does not exist or is in another status.
+func (s *AlertStorage) UpdateAlertStatus(ctx context.Context, id string, from []string, status string) (*models.Alert, error) {
+	condition, args := statusCondition(from, 3)
+	if condition == "" {
+		return nil, nil
 	}
+	query := `
+		UPDATE alerts
+		SET status = $2,
+		    acknowledged_at = CASE WHEN $2 = 'acknowledged' THEN NOW() ELSE acknowledged_at END,
+		    resolved_at = CASE WHEN $2 = 'resolved' THEN NOW() ELSE resolved_at END
+		WHERE id = $1 AND ` + condition + `
+		RETURNING ` + alertColumns
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	args = append([]interface{}{id, status}, args...)
+	alert, err := scanAlert(s.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating alert status: %w", err)
 	}
 
-	return alerts, nil
+	return alert, nil
 }
 
 // GetLastSyncTime retrieves the last sync timestamp
@@ -3,9 +3,12 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"testing"
 	"time"
 
+	"censys_alert_system/internal/models"
+
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,6 +25,12 @@ func setupMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, func()) {
 	return db, mock, cleanup
 }
 
+var alertRowColumns = []string{"id", "source", "severity", "description", "whole_event", "enrichment_type", "ip_address", "enrichment_versions", "fingerprint", "status", "acknowledged_at", "resolved_at", "created_at"}
+
+func strPtr(s string) *string {
+	return &s
+}
+
 func TestAlertStorage_CreateAlert(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -30,22 +39,41 @@ func TestAlertStorage_CreateAlert(t *testing.T) {
 	ctx := context.Background()
 	createdAt := time.Now()
 
-	mock.ExpectExec("INSERT INTO alerts").
-		WithArgs("test-source", "high", "test description", []byte(`{"key": "value"}`), "geo_location", "192.168.1.1", createdAt).
-		WillReturnResult(sqlmock.NewResult(1, 1))
-
-	err := storage.CreateAlert(
-		ctx,
-		"test-source",
-		"high",
-		"test description",
-		[]byte(`{"key": "value"}`),
-		"geo_location",
-		"192.168.1.1",
-		createdAt,
-	)
+	mock.ExpectQuery("INSERT INTO alerts (.+) ON CONFLICT \\(fingerprint, created_at\\) DO NOTHING RETURNING id, status").
+		WithArgs("test-source", "high", "test description", []byte(`{"key": "value"}`), "geo_location", "192.168.1.1", []byte(`{"enrichment_type":1,"ip_address":1}`), "fp-1", createdAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("some-uuid", "open"))
+
+	alert := &models.Alert{
+		Source:             "test-source",
+		Severity:           "high",
+		Description:        "test description",
+		WholeEvent:         []byte(`{"key": "value"}`),
+		EnrichmentType:     strPtr("geo_location"),
+		IPAddress:          strPtr("192.168.1.1"),
+		EnrichmentVersions: map[string]int{"enrichment_type": 1, "ip_address": 1},
+		Fingerprint:        "fp-1",
+		CreatedAt:          createdAt,
+	}
+	err := storage.CreateAlert(ctx, alert)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some-uuid", alert.ID)
+	assert.Equal(t, models.StatusOpen, alert.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAlertStorage_CreateAlert_Duplicate(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("INSERT INTO alerts (.+) ON CONFLICT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}))
+
+	alert := &models.Alert{Source: "test-source", Severity: "high", Description: "test description", WholeEvent: []byte(`{}`), Fingerprint: "fp-1", CreatedAt: time.Now()}
+	err := NewAlertStorage(db).CreateAlert(context.Background(), alert)
 
 	assert.NoError(t, err)
+	assert.Empty(t, alert.ID)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -56,25 +84,157 @@ func TestAlertStorage_CreateAlert_Error(t *testing.T) {
 	storage := NewAlertStorage(db)
 	ctx := context.Background()
 
-	mock.ExpectExec("INSERT INTO alerts").
+	mock.ExpectQuery("INSERT INTO alerts").
 		WillReturnError(sql.ErrConnDone)
 
-	err := storage.CreateAlert(
-		ctx,
-		"test-source",
-		"high",
-		"test description",
-		[]byte(`{}`),
-		"geo_location",
-		"192.168.1.1",
-		time.Now(),
-	)
+	err := storage.CreateAlert(ctx, &models.Alert{
+		Source:      "test-source",
+		Severity:    "high",
+		Description: "test description",
+		WholeEvent:  []byte(`{}`),
+		CreatedAt:   time.Now(),
+	})
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error creating alert")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAlertStorage_CreateAlerts(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Now()
+	newAlerts := func(n int) []models.Alert {
+		alerts := make([]models.Alert, n)
+		for i := range alerts {
+			alerts[i] = models.Alert{Source: "firewall", Severity: "low", Description: "scan", WholeEvent: []byte(`{}`), Fingerprint: fmt.Sprintf("fp-%d", i), CreatedAt: createdAt}
+		}
+		return alerts
+	}
+
+	// IDs are predictable so the mocked RETURNING rows can echo them
+	restore := newAlertID
+	defer func() { newAlertID = restore }()
+	next := 0
+	newAlertID = func() string {
+		next++
+		return fmt.Sprintf("id-%d", next)
+	}
+	returning := func(ids ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id"})
+		for _, id := range ids {
+			rows.AddRow(id)
+		}
+		return rows
+	}
+
+	t.Run("inserts every row in one transaction", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		next = 0
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO alerts \(id, source, severity, description, whole_event, enrichment_type, ip_address, enrichment_versions, fingerprint, created_at\) VALUES \(\$1, (.+), \$10\), \(\$11, (.+), \$20\) ON CONFLICT \(fingerprint, created_at\) DO NOTHING RETURNING id`).
+			WithArgs("id-1", "firewall", "low", "scan", []byte(`{}`), nil, nil, []byte(`{}`), "fp-0", createdAt,
+				"id-2", "firewall", "low", "scan", []byte(`{}`), nil, nil, []byte(`{}`), "fp-1", createdAt).
+			WillReturnRows(returning("id-2", "id-1"))
+		mock.ExpectCommit()
+
+		alerts := newAlerts(2)
+		err := NewAlertStorage(db).CreateAlerts(ctx, alerts)
+
+		require.NoError(t, err)
+		assert.Equal(t, "id-1", alerts[0].ID)
+		assert.Equal(t, "id-2", alerts[1].ID)
+		assert.Equal(t, models.StatusOpen, alerts[1].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("skips alerts whose fingerprint is already stored", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		next = 0
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO alerts").WillReturnRows(returning("id-1"))
+		mock.ExpectCommit()
+
+		alerts := newAlerts(2)
+		err := NewAlertStorage(db).CreateAlerts(ctx, alerts)
+
+		require.NoError(t, err)
+		assert.Equal(t, "id-1", alerts[0].ID)
+		assert.Empty(t, alerts[1].ID)
+		assert.Empty(t, alerts[1].Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("splits large batches into several statements", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+		next = 0
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO alerts").WillReturnRows(returning())
+		mock.ExpectQuery("INSERT INTO alerts").WillReturnRows(returning(fmt.Sprintf("id-%d", insertBatchRows+1)))
+		mock.ExpectCommit()
+
+		alerts := newAlerts(insertBatchRows + 1)
+		require.NoError(t, NewAlertStorage(db).CreateAlerts(ctx, alerts))
+		assert.NotEmpty(t, alerts[insertBatchRows].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back when a statement fails", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO alerts").WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		alerts := newAlerts(2)
+		err := NewAlertStorage(db).CreateAlerts(ctx, alerts)
+
+		assert.ErrorContains(t, err, "error creating alerts")
+		assert.Empty(t, alerts[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty batch does nothing", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		require.NoError(t, NewAlertStorage(db).CreateAlerts(ctx, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAlertStorage_RestoreAlerts(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+	ctx := context.Background()
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	resolvedAt := createdAt.Add(time.Hour)
+
+	alerts := []models.Alert{
+		{ID: "a-1", Source: "firewall", Severity: "low", Description: "scan", WholeEvent: []byte(`{}`), Fingerprint: "fp-1", Status: models.StatusResolved, ResolvedAt: &resolvedAt, CreatedAt: createdAt},
+		{ID: "a-2", Source: "firewall", Severity: "low", Description: "scan", WholeEvent: []byte(`{}`), Status: models.StatusOpen, CreatedAt: createdAt},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO alerts \(id, (.+), status, acknowledged_at, resolved_at, created_at\) VALUES \(\$1, (.+), \$13\), \(\$14, (.+), \$26\) ON CONFLICT DO NOTHING RETURNING id`).
+		WithArgs("a-1", "firewall", "low", "scan", []byte(`{}`), nil, nil, []byte(`{}`), "fp-1", models.StatusResolved, nil, &resolvedAt, createdAt,
+			"a-2", "firewall", "low", "scan", []byte(`{}`), nil, nil, []byte(`{}`), nil, models.StatusOpen, nil, nil, createdAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("a-2"))
+	mock.ExpectCommit()
+
+	restored, err := NewAlertStorage(db).RestoreAlerts(ctx, alerts)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAlertStorage_GetAlerts(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -83,11 +243,11 @@ func TestAlertStorage_GetAlerts(t *testing.T) {
 	ctx := context.Background()
 	createdAt := time.Now()
 
-	rows := sqlmock.NewRows([]string{"id", "source", "severity", "description", "whole_event", "enrichment_type", "ip_address", "created_at"}).
-		AddRow(1, "source1", "high", "desc1", []byte(`{}`), "geo_location", "10.0.0.1", createdAt).
-		AddRow(2, "source2", "low", "desc2", []byte(`{}`), "threat_intel", "10.0.0.2", createdAt)
+	rows := sqlmock.NewRows(alertRowColumns).
+		AddRow(1, "source1", "high", "desc1", []byte(`{}`), "geo_location", "10.0.0.1", []byte(`{"enrichment_type":1,"ip_address":2}`), "fp-1", "open", nil, nil, createdAt).
+		AddRow(2, "source2", "low", "desc2", []byte(`{}`), "threat_intel", "10.0.0.2", []byte(`{}`), "fp-2", "open", nil, nil, createdAt)
 
-	mock.ExpectQuery("SELECT (.+) FROM alerts ORDER BY created_at DESC").
+	mock.ExpectQuery("SELECT (.+) FROM alerts ORDER BY created_at DESC, id DESC").
 		WillReturnRows(rows)
 
 	alerts, err := storage.GetAlerts(ctx)
@@ -95,6 +255,7 @@ func TestAlertStorage_GetAlerts(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, alerts, 2)
 	assert.Equal(t, "source1", alerts[0].Source)
+	assert.Equal(t, map[string]int{"enrichment_type": 1, "ip_address": 2}, alerts[0].EnrichmentVersions)
 	assert.Equal(t, "source2", alerts[1].Source)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -106,9 +267,9 @@ func TestAlertStorage_GetAlerts_Empty(t *testing.T) {
 	storage := NewAlertStorage(db)
 	ctx := context.Background()
 
-	rows := sqlmock.NewRows([]string{"id", "source", "severity", "description", "whole_event", "enrichment_type", "ip_address", "created_at"})
+	rows := sqlmock.NewRows(alertRowColumns)
 
-	mock.ExpectQuery("SELECT (.+) FROM alerts ORDER BY created_at DESC").
+	mock.ExpectQuery("SELECT (.+) FROM alerts ORDER BY created_at DESC, id DESC").
 		WillReturnRows(rows)
 
 	alerts, err := storage.GetAlerts(ctx)
@@ -127,8 +288,8 @@ func TestAlertStorage_GetAlertByID(t *testing.T) {
 	createdAt := time.Now()
 
 	t.Run("existing alert", func(t *testing.T) {
-		row := sqlmock.NewRows([]string{"id", "source", "severity", "description", "whole_event", "enrichment_type", "ip_address", "created_at"}).
-			AddRow(1, "test-source", "critical", "critical alert", []byte(`{}`), "network_analysis", "172.16.0.1", createdAt)
+		row := sqlmock.NewRows(alertRowColumns).
+			AddRow(1, "test-source", "critical", "critical alert", []byte(`{}`), "network_analysis", "172.16.0.1", []byte(`{}`), "fp-1", "open", nil, nil, createdAt)
 
 		mock.ExpectQuery("SELECT (.+) FROM alerts WHERE id = \\$1").
 			WithArgs("1").
@@ -150,13 +311,41 @@ func TestAlertStorage_GetAlertByID(t *testing.T) {
 
 		alert, err := storage.GetAlertByID(ctx, "999")
 
-		assert.Error(t, err)
+		assert.NoError(t, err)
+		assert.Nil(t, alert)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		mock.ExpectQuery("SELECT (.+) FROM alerts WHERE id = \\$1").
+			WithArgs("1").
+			WillReturnError(sql.ErrConnDone)
+
+		alert, err := storage.GetAlertByID(ctx, "1")
+
+		assert.ErrorIs(t, err, sql.ErrConnDone)
 		assert.Nil(t, alert)
-		assert.Contains(t, err.Error(), "alert not found")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
+func TestAlertStorage_AlertExists(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewAlertStorage(db)
+
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM alerts WHERE fingerprint = \\$1\\)").
+		WithArgs("fp-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	exists, err := storage.AlertExists(context.Background(), "fp-1")
+
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAlertStorage_GetAlertsByDays(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -165,8 +354,8 @@ func TestAlertStorage_GetAlertsByDays(t *testing.T) {
 	ctx := context.Background()
 	createdAt := time.Now()
 
-	rows := sqlmock.NewRows([]string{"id", "source", "severity", "description", "whole_event", "enrichment_type", "ip_address", "created_at"}).
-		AddRow(1, "recent-source", "low", "recent alert", []byte(`{}`), "user_context", "8.8.8.8", createdAt)
+	rows := sqlmock.NewRows(alertRowColumns).
+		AddRow(1, "recent-source", "low", "recent alert", []byte(`{}`), "user_context", "8.8.8.8", []byte(`{}`), "fp-1", "open", nil, nil, createdAt)
 
 	mock.ExpectQuery("SELECT (.+) FROM alerts WHERE created_at >= NOW\\(\\) - INTERVAL").
 		WithArgs(3).
@@ -180,6 +369,156 @@ func TestAlertStorage_GetAlertsByDays(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAlertStorage_ListAlerts(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewAlertStorage(db)
+	ctx := context.Background()
+	createdAt := time.Now()
+
+	rows := sqlmock.NewRows(alertRowColumns).
+		AddRow(1, "firewall", "critical", "critical alert", []byte(`{}`), "geo_location", "10.0.0.1", []byte(`{}`), "fp-1", "open", nil, nil, createdAt)
+
+	mock.ExpectQuery("SELECT (.+) FROM alerts WHERE created_at >= NOW\\(\\) - INTERVAL '1 day' \\* \\$1 AND source IN \\(\\$2, \\$3\\) AND severity IN \\(\\$4\\) ORDER BY created_at DESC, id DESC").
+		WithArgs(7, "firewall", "ids", "critical").
+		WillReturnRows(rows)
+
+	alerts, err := storage.ListAlerts(ctx, models.AlertFilter{
+		Sources:    []string{"firewall", "ids"},
+		Severities: []string{"critical"},
+		Days:       7,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAlertStorage_ListAlertsAfter(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Now()
+	from := createdAt.Add(-time.Hour)
+
+	t.Run("starts from the oldest alert", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery("SELECT (.+) FROM alerts WHERE created_at >= \\$1 AND source IN \\(\\$2\\) ORDER BY created_at, id LIMIT \\$3").
+			WithArgs(from, "ids", 50).
+			WillReturnRows(sqlmock.NewRows(alertRowColumns).
+				AddRow("a-1", "ids", "low", "scan", []byte(`{}`), nil, nil, []byte(`{}`), "fp-1", "open", nil, nil, createdAt))
+
+		alerts, err := NewAlertStorage(db).ListAlertsAfter(ctx, models.AlertFilter{Sources: []string{"ids"}, From: from}, time.Time{}, "", 50)
+
+		require.NoError(t, err)
+		assert.Len(t, alerts, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("continues after the cursor", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery("SELECT (.+) FROM alerts WHERE \\(created_at, id\\) > \\(\\$1, \\$2\\) ORDER BY created_at, id LIMIT \\$3").
+			WithArgs(createdAt, "a-1", 50).
+			WillReturnRows(sqlmock.NewRows(alertRowColumns))
+
+		alerts, err := NewAlertStorage(db).ListAlertsAfter(ctx, models.AlertFilter{}, createdAt, "a-1", 50)
+
+		require.NoError(t, err)
+		assert.Empty(t, alerts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAlertStorage_CountAlerts(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	to := time.Now()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM alerts WHERE created_at < \\$1 AND severity IN \\(\\$2\\)").
+		WithArgs(to, "critical").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+
+	count, err := NewAlertStorage(db).CountAlerts(context.Background(), models.AlertFilter{Severities: []string{"critical"}, To: to})
+
+	require.NoError(t, err)
+	assert.Equal(t, 12, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAlertStorage_UpdateAlertEnrichment(t *testing.T) {
+	alert := &models.Alert{
+		ID:                 "a-1",
+		EnrichmentType:     strPtr("threat_intel"),
+		IPAddress:          strPtr("10.0.0.5"),
+		EnrichmentVersions: map[string]int{"enrichment_type": 1, "ip_address": 2},
+	}
+
+	t.Run("updated", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectExec("UPDATE alerts SET enrichment_type = \\$2, ip_address = \\$3, enrichment_versions = \\$4 WHERE id = \\$1").
+			WithArgs("a-1", "threat_intel", "10.0.0.5", []byte(`{"enrichment_type":1,"ip_address":2}`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, NewAlertStorage(db).UpdateAlertEnrichment(context.Background(), alert))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectExec("UPDATE alerts").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := NewAlertStorage(db).UpdateAlertEnrichment(context.Background(), alert)
+
+		assert.ErrorContains(t, err, "alert not found")
+	})
+}
+
+func TestAlertStorage_UpdateAlertStatus(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewAlertStorage(db)
+	ctx := context.Background()
+	createdAt := time.Now()
+
+	t.Run("existing alert", func(t *testing.T) {
+		row := sqlmock.NewRows(alertRowColumns).
+			AddRow(1, "test-source", "critical", "critical alert", []byte(`{}`), "network_analysis", "172.16.0.1", []byte(`{}`), "fp-1", "acknowledged", createdAt, nil, createdAt)
+
+		mock.ExpectQuery("UPDATE alerts SET status = \\$2(.+)WHERE id = \\$1 AND status IN \\(\\$3\\)").
+			WithArgs("1", models.StatusAcknowledged, models.StatusOpen).
+			WillReturnRows(row)
+
+		alert, err := storage.UpdateAlertStatus(ctx, "1", []string{models.StatusOpen}, models.StatusAcknowledged)
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.StatusAcknowledged, alert.Status)
+		assert.NotNil(t, alert.AcknowledgedAt)
+		assert.Nil(t, alert.ResolvedAt)
+		assert.Equal(t, "fp-1", alert.Fingerprint)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("non-existing alert or disallowed status", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE alerts").
+			WithArgs("999", models.StatusResolved, models.StatusOpen, models.StatusAcknowledged).
+			WillReturnError(sql.ErrNoRows)
+
+		alert, err := storage.UpdateAlertStatus(ctx, "999", []string{models.StatusOpen, models.StatusAcknowledged}, models.StatusResolved)
+
+		assert.NoError(t, err)
+		assert.Nil(t, alert)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestAlertStorage_GetLastSyncTime(t *testing.T) {
 	db, mock, cleanup := setupMockDB(t)
 	defer cleanup()
@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+const backfillColumns = `id, connector, range_from, range_to, sources, chunk_seconds, status, position, page_cursor, fetched, stored, duplicates, error, created_at, updated_at, completed_at`
+
+// defaultBackfillLimit caps job listings
+const defaultBackfillLimit = 100
+
+type BackfillStorage struct {
+	db *sql.DB
+}
+
+func NewBackfillStorage(db *sql.DB) *BackfillStorage {
+	return &BackfillStorage{db: db}
+}
+
+func scanBackfillJob(row rowScanner) (*models.BackfillJob, error) {
+	var job models.BackfillJob
+	var sources []byte
+	var chunkSeconds int64
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&job.ID,
+		&job.Connector,
+		&job.From,
+		&job.To,
+		&sources,
+		&chunkSeconds,
+		&job.Status,
+		&job.Position,
+		&job.Cursor,
+		&job.Fetched,
+		&job.Stored,
+		&job.Duplicates,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(sources, &job.Sources); err != nil {
+		return nil, fmt.Errorf("invalid sources: %w", err)
+	}
+	job.ChunkSize = time.Duration(chunkSeconds) * time.Second
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	return &job, nil
+}
+
+// CreateBackfillJob records a new job. The generated ID and timestamps are
+// written back into job.
+func (s *BackfillStorage) CreateBackfillJob(ctx context.Context, job *models.BackfillJob) error {
+	sources, err := json.Marshal(job.Sources)
+	if err != nil {
+		return fmt.Errorf("error encoding sources: %w", err)
+	}
+	if job.Sources == nil {
+		sources = []byte("[]")
+	}
+
+	query := `
+		INSERT INTO backfill_jobs (connector, range_from, range_to, sources, chunk_seconds, status, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = s.db.QueryRowContext(ctx, query,
+		job.Connector,
+		job.From,
+		job.To,
+		sources,
+		int64(job.ChunkSize/time.Second),
+		job.Status,
+		job.Position,
+	).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating backfill job: %w", err)
+	}
+
+	return nil
+}
+
+// GetBackfillJob retrieves a job by ID.
+// Returns nil without error when there is none.
+func (s *BackfillStorage) GetBackfillJob(ctx context.Context, id string) (*models.BackfillJob, error) {
+	query := `
+		SELECT ` + backfillColumns + `
+		FROM backfill_jobs
+		WHERE id = $1
+	`
+
+	job, err := scanBackfillJob(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying backfill job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ListBackfillJobs retrieves the most recent jobs, newest first, optionally
+// only those in the given statuses
+func (s *BackfillStorage) ListBackfillJobs(ctx context.Context, statuses []string) ([]models.BackfillJob, error) {
+	where := ""
+	condition, args := statusCondition(statuses, 1)
+	if condition != "" {
+		where = "\n\t\tWHERE " + condition
+	}
+	args = append(args, defaultBackfillLimit)
+
+	query := `
+		SELECT ` + backfillColumns + `
+		FROM backfill_jobs` + where + `
+		ORDER BY created_at DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying backfill jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.BackfillJob
+	for rows.Next() {
+		job, err := scanBackfillJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning backfill job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating backfill jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ClaimBackfillJob marks the oldest pending job, or a running job whose
+// worker let its lease expire, as running and leased until leaseUntil.
+// Returns nil without error when no job is waiting.
+func (s *BackfillStorage) ClaimBackfillJob(ctx context.Context, now, leaseUntil time.Time) (*models.BackfillJob, error) {
+	query := `
+		UPDATE backfill_jobs
+		SET status = 'running', lease_until = $2, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM backfill_jobs
+			WHERE status = 'pending' OR (status = 'running' AND (lease_until IS NULL OR lease_until <= $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + backfillColumns
+
+	job, err := scanBackfillJob(s.db.QueryRowContext(ctx, query, now, leaseUntil))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error claiming backfill job: %w", err)
+	}
+
+	return job, nil
+}
+
+// SaveBackfillProgress checkpoints a running job's position, cursor and
+// counts and extends its lease. Returns the job's current status, which is
+// no longer running once it has been paused or cancelled.
+func (s *BackfillStorage) SaveBackfillProgress(ctx context.Context, job *models.BackfillJob, leaseUntil time.Time) (string, error) {
+	query := `
+		UPDATE backfill_jobs
+		SET position = $2, page_cursor = $3, fetched = $4, stored = $5, duplicates = $6,
+			lease_until = CASE WHEN status = 'running' THEN $7::TIMESTAMP END, updated_at = NOW()
+		WHERE id = $1
+		RETURNING status
+	`
+
+	var status string
+	err := s.db.QueryRowContext(ctx, query, job.ID, job.Position, job.Cursor, job.Fetched, job.Stored, job.Duplicates, leaseUntil).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("error saving backfill progress: %w", err)
+	}
+
+	return status, nil
+}
+
+// FinishBackfillJob records the final status, error, counts and completion
+// time of a running job. A job paused or cancelled in the meantime keeps
+// that status.
+func (s *BackfillStorage) FinishBackfillJob(ctx context.Context, job *models.BackfillJob) error {
+	query := `
+		UPDATE backfill_jobs
+		SET status = $2, error = $3, completed_at = $4, fetched = $5, stored = $6, duplicates = $7,
+			lease_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+
+	_, err := s.db.ExecContext(ctx, query, job.ID, job.Status, job.Error, job.CompletedAt, job.Fetched, job.Stored, job.Duplicates)
+	if err != nil {
+		return fmt.Errorf("error finishing backfill job: %w", err)
+	}
+
+	return nil
+}
+
+// TransitionBackfillJob moves a job in one of the from statuses to status,
+// releasing any lease; resuming also clears the last error. Returns nil
+// without error when the job does not exist or is in another status.
+func (s *BackfillStorage) TransitionBackfillJob(ctx context.Context, id string, from []string, status string) (*models.BackfillJob, error) {
+	condition, args := statusCondition(from, 4)
+	if condition == "" {
+		return nil, nil
+	}
+	query := `
+		UPDATE backfill_jobs
+		SET status = $2, lease_until = NULL, error = CASE WHEN $3 THEN '' ELSE error END, updated_at = NOW()
+		WHERE id = $1 AND ` + condition + `
+		RETURNING ` + backfillColumns
+
+	args = append([]interface{}{id, status, status == models.JobPending}, args...)
+	job, err := scanBackfillJob(s.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating backfill job: %w", err)
+	}
+
+	return job, nil
+}
+
+// statusCondition builds a condition matching any of statuses, numbering its
+// placeholders from first. It is empty when there are no statuses.
+func statusCondition(statuses []string, first int) (string, []interface{}) {
+	if len(statuses) == 0 {
+		return "", nil
+	}
+
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = fmt.Sprintf("$%d", first+i)
+		args[i] = status
+	}
+	return "status IN (" + strings.Join(placeholders, ", ") + ")", args
+}
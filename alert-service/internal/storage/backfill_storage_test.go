@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var backfillRowColumns = []string{"id", "connector", "range_from", "range_to", "sources", "chunk_seconds", "status", "position", "page_cursor", "fetched", "stored", "duplicates", "error", "created_at", "updated_at", "completed_at"}
+
+func TestBackfillStorage_CreateBackfillJob(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	from := now.Add(-24 * time.Hour)
+
+	mock.ExpectQuery("INSERT INTO backfill_jobs (.+) RETURNING id, created_at, updated_at").
+		WithArgs("siem", from, now, []byte(`[]`), int64(3600), "pending", from).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("job-1", now, now))
+
+	job := &models.BackfillJob{
+		Connector: "siem",
+		From:      from,
+		To:        now,
+		ChunkSize: time.Hour,
+		Status:    models.JobPending,
+		Position:  from,
+	}
+	err := NewBackfillStorage(db).CreateBackfillJob(context.Background(), job)
+
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", job.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackfillStorage_GetBackfillJob(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		now := time.Now()
+		mock.ExpectQuery("SELECT (.+) FROM backfill_jobs WHERE id = \\$1").
+			WithArgs("job-1").
+			WillReturnRows(sqlmock.NewRows(backfillRowColumns).
+				AddRow("job-1", "siem", now, now, []byte(`["ids"]`), int64(900), "completed", now, "", 10, 8, 2, "", now, now, now))
+
+		job, err := NewBackfillStorage(db).GetBackfillJob(context.Background(), "job-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ids"}, job.Sources)
+		assert.Equal(t, 15*time.Minute, job.ChunkSize)
+		assert.Equal(t, 2, job.Duplicates)
+		assert.NotNil(t, job.CompletedAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery("SELECT (.+) FROM backfill_jobs").WillReturnError(sql.ErrNoRows)
+
+		job, err := NewBackfillStorage(db).GetBackfillJob(context.Background(), "missing")
+
+		assert.NoError(t, err)
+		assert.Nil(t, job)
+	})
+}
+
+func TestBackfillStorage_ListBackfillJobs(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM backfill_jobs\\s+WHERE status IN \\(\\$1, \\$2\\)\\s+ORDER BY created_at DESC\\s+LIMIT \\$3").
+		WithArgs("pending", "running", defaultBackfillLimit).
+		WillReturnRows(sqlmock.NewRows(backfillRowColumns).
+			AddRow("job-1", "siem", now, now, []byte(`[]`), int64(3600), "running", now, "c1", 5, 5, 0, "", now, now, nil))
+
+	jobs, err := NewBackfillStorage(db).ListBackfillJobs(context.Background(), []string{"pending", "running"})
+
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "c1", jobs[0].Cursor)
+	assert.Nil(t, jobs[0].CompletedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackfillStorage_ClaimBackfillJob(t *testing.T) {
+	t.Run("claims a waiting job", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		now := time.Now()
+		lease := now.Add(2 * time.Minute)
+		mock.ExpectQuery("UPDATE backfill_jobs SET status = 'running'(.+)FOR UPDATE SKIP LOCKED").
+			WithArgs(now, lease).
+			WillReturnRows(sqlmock.NewRows(backfillRowColumns).
+				AddRow("job-1", "siem", now, now, []byte(`[]`), int64(3600), "running", now, "", 0, 0, 0, "", now, now, nil))
+
+		job, err := NewBackfillStorage(db).ClaimBackfillJob(context.Background(), now, lease)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.JobRunning, job.Status)
+	})
+
+	t.Run("nothing waiting", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery("UPDATE backfill_jobs").WillReturnError(sql.ErrNoRows)
+
+		job, err := NewBackfillStorage(db).ClaimBackfillJob(context.Background(), time.Now(), time.Now())
+
+		assert.NoError(t, err)
+		assert.Nil(t, job)
+	})
+}
+
+func TestBackfillStorage_SaveBackfillProgress(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	lease := now.Add(2 * time.Minute)
+	mock.ExpectQuery("UPDATE backfill_jobs SET position = \\$2(.+)RETURNING status").
+		WithArgs("job-1", now, "c1", 10, 9, 1, lease).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("paused"))
+
+	job := &models.BackfillJob{ID: "job-1", Position: now, Cursor: "c1", Fetched: 10, Stored: 9, Duplicates: 1}
+	status, err := NewBackfillStorage(db).SaveBackfillProgress(context.Background(), job, lease)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.JobPaused, status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackfillStorage_TransitionBackfillJob(t *testing.T) {
+	t.Run("resume clears the error", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		now := time.Now()
+		mock.ExpectQuery("UPDATE backfill_jobs SET status = \\$2(.+)WHERE id = \\$1 AND status IN \\(\\$4, \\$5\\)").
+			WithArgs("job-1", "pending", true, "paused", "failed").
+			WillReturnRows(sqlmock.NewRows(backfillRowColumns).
+				AddRow("job-1", "siem", now, now, []byte(`[]`), int64(3600), "pending", now, "", 0, 0, 0, "", now, now, nil))
+
+		job, err := NewBackfillStorage(db).TransitionBackfillJob(context.Background(), "job-1",
+			[]string{models.JobPaused, models.JobFailed}, models.JobPending)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.JobPending, job.Status)
+	})
+
+	t.Run("job in another status", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery("UPDATE backfill_jobs").
+			WithArgs("job-1", "paused", false, "pending", "running").
+			WillReturnError(sql.ErrNoRows)
+
+		job, err := NewBackfillStorage(db).TransitionBackfillJob(context.Background(), "job-1",
+			[]string{models.JobPending, models.JobRunning}, models.JobPaused)
+
+		assert.NoError(t, err)
+		assert.Nil(t, job)
+	})
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+const connectorStateColumns = `name, watermark, page_cursor, last_attempt_at, last_success_at, last_error, consecutive_failures, updated_at`
+
+type ConnectorStorage struct {
+	db *sql.DB
+}
+
+func NewConnectorStorage(db *sql.DB) *ConnectorStorage {
+	return &ConnectorStorage{db: db}
+}
+
+func scanConnectorState(row rowScanner) (*models.ConnectorState, error) {
+	var state models.ConnectorState
+	var watermark, lastAttempt, lastSuccess sql.NullTime
+	err := row.Scan(
+		&state.Name,
+		&watermark,
+		&state.Cursor,
+		&lastAttempt,
+		&lastSuccess,
+		&state.LastError,
+		&state.ConsecutiveFailures,
+		&state.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	state.Watermark = nullTimePtr(watermark)
+	state.LastAttemptAt = nullTimePtr(lastAttempt)
+	state.LastSuccessAt = nullTimePtr(lastSuccess)
+	return &state, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// GetConnectorState returns the saved state of a connector.
+// Returns nil without error when the connector has never run.
+func (s *ConnectorStorage) GetConnectorState(ctx context.Context, name string) (*models.ConnectorState, error) {
+	query := `SELECT ` + connectorStateColumns + ` FROM connector_state WHERE name = $1`
+
+	state, err := scanConnectorState(s.db.QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting connector state: %w", err)
+	}
+
+	return state, nil
+}
+
+// SaveConnectorState inserts or replaces the state of a connector
+func (s *ConnectorStorage) SaveConnectorState(ctx context.Context, state *models.ConnectorState) error {
+	query := `
+		INSERT INTO connector_state (name, watermark, page_cursor, last_attempt_at, last_success_at, last_error, consecutive_failures, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			watermark = EXCLUDED.watermark,
+			page_cursor = EXCLUDED.page_cursor,
+			last_attempt_at = EXCLUDED.last_attempt_at,
+			last_success_at = EXCLUDED.last_success_at,
+			last_error = EXCLUDED.last_error,
+			consecutive_failures = EXCLUDED.consecutive_failures,
+			updated_at = NOW()
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		state.Name,
+		state.Watermark,
+		state.Cursor,
+		state.LastAttemptAt,
+		state.LastSuccessAt,
+		state.LastError,
+		state.ConsecutiveFailures,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving connector state: %w", err)
+	}
+
+	return nil
+}
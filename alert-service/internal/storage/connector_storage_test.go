@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var connectorStateRowColumns = []string{"name", "watermark", "page_cursor", "last_attempt_at", "last_success_at", "last_error", "consecutive_failures", "updated_at"}
+
+func TestConnectorStorage_GetConnectorState(t *testing.T) {
+	t.Run("saved state", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		storage := NewConnectorStorage(db)
+		watermark := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+		now := time.Now()
+
+		rows := sqlmock.NewRows(connectorStateRowColumns).
+			AddRow("siem", watermark, "c2", now, nil, "timeout", 2, now)
+		mock.ExpectQuery("SELECT (.+) FROM connector_state WHERE name = \\$1").
+			WithArgs("siem").
+			WillReturnRows(rows)
+
+		state, err := storage.GetConnectorState(context.Background(), "siem")
+
+		require.NoError(t, err)
+		require.NotNil(t, state.Watermark)
+		assert.Equal(t, watermark, *state.Watermark)
+		assert.Equal(t, "c2", state.Cursor)
+		assert.Nil(t, state.LastSuccessAt)
+		assert.Equal(t, 2, state.ConsecutiveFailures)
+		assert.Equal(t, models.ConnectorFailing, state.Status())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("never run", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		storage := NewConnectorStorage(db)
+		mock.ExpectQuery("SELECT (.+) FROM connector_state WHERE name = \\$1").
+			WithArgs("new").
+			WillReturnError(sql.ErrNoRows)
+
+		state, err := storage.GetConnectorState(context.Background(), "new")
+
+		assert.NoError(t, err)
+		assert.Nil(t, state)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestConnectorStorage_SaveConnectorState(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewConnectorStorage(db)
+	watermark := time.Now().Add(-time.Hour)
+	attempt := time.Now()
+	state := &models.ConnectorState{Name: "siem", Watermark: &watermark, Cursor: "c1", LastAttemptAt: &attempt, LastSuccessAt: &attempt}
+
+	mock.ExpectExec("INSERT INTO connector_state (.+) ON CONFLICT \\(name\\) DO UPDATE").
+		WithArgs("siem", &watermark, "c1", &attempt, &attempt, "", 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := storage.SaveConnectorState(context.Background(), state)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+const deadLetterColumns = `id, connector, stage, payload, error, attempts, status, next_attempt_at, alert_id, created_at, updated_at`
+
+// defaultDeadLetterLimit caps listings that do not set a limit
+const defaultDeadLetterLimit = 100
+
+type DeadLetterStorage struct {
+	db *sql.DB
+}
+
+func NewDeadLetterStorage(db *sql.DB) *DeadLetterStorage {
+	return &DeadLetterStorage{db: db}
+}
+
+func scanDeadLetter(row rowScanner) (*models.DeadLetter, error) {
+	var dl models.DeadLetter
+	var alertID sql.NullString
+	err := row.Scan(
+		&dl.ID,
+		&dl.Connector,
+		&dl.Stage,
+		&dl.Payload,
+		&dl.Error,
+		&dl.Attempts,
+		&dl.Status,
+		&dl.NextAttemptAt,
+		&alertID,
+		&dl.CreatedAt,
+		&dl.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if alertID.Valid {
+		dl.AlertID = &alertID.String
+	}
+
+	return &dl, nil
+}
+
+func scanDeadLetters(rows *sql.Rows) ([]models.DeadLetter, error) {
+	var deadLetters []models.DeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning dead letter: %w", err)
+		}
+		deadLetters = append(deadLetters, *dl)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dead letters: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// CreateDeadLetter records a failed alert. The generated ID and timestamps
+// are written back into dl.
+func (s *DeadLetterStorage) CreateDeadLetter(ctx context.Context, dl *models.DeadLetter) error {
+	query := `
+		INSERT INTO dead_letters (connector, stage, payload, error, attempts, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := s.db.QueryRowContext(ctx, query,
+		dl.Connector,
+		dl.Stage,
+		[]byte(dl.Payload),
+		dl.Error,
+		dl.Attempts,
+		dl.Status,
+		dl.NextAttemptAt,
+	).Scan(&dl.ID, &dl.CreatedAt, &dl.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeadLetter retrieves a dead letter by ID.
+// Returns nil without error when there is none.
+func (s *DeadLetterStorage) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM dead_letters
+		WHERE id = $1
+	`
+
+	dl, err := scanDeadLetter(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying dead letter: %w", err)
+	}
+
+	return dl, nil
+}
+
+// ListDeadLetters retrieves dead letters matching the filter, newest first
+func (s *DeadLetterStorage) ListDeadLetters(ctx context.Context, filter models.DeadLetterFilter) ([]models.DeadLetter, error) {
+	where, args := deadLetterFilterClause(filter)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+	args = append(args, limit)
+
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM dead_letters` + where + `
+		ORDER BY created_at DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDeadLetters(rows)
+}
+
+// ClaimDueDeadLetters returns pending dead letters due at or before now and
+// pushes their next_attempt_at to leaseUntil, so a crashed or concurrent
+// reprocessor does not retry the same alert twice before the lease expires
+func (s *DeadLetterStorage) ClaimDueDeadLetters(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.DeadLetter, error) {
+	query := `
+		UPDATE dead_letters
+		SET next_attempt_at = $2, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM dead_letters
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deadLetterColumns
+
+	rows, err := s.db.QueryContext(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming due dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDeadLetters(rows)
+}
+
+// UpdateDeadLetter persists the outcome of a retry
+func (s *DeadLetterStorage) UpdateDeadLetter(ctx context.Context, dl *models.DeadLetter) error {
+	query := `
+		UPDATE dead_letters
+		SET stage = $2, error = $3, attempts = $4, status = $5, next_attempt_at = $6, alert_id = $7, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	_, err := s.db.ExecContext(ctx, query, dl.ID, dl.Stage, dl.Error, dl.Attempts, dl.Status, dl.NextAttemptAt, dl.AlertID)
+	if err != nil {
+		return fmt.Errorf("error updating dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// RequeueDeadLetters makes every unresolved dead letter matching the filter
+// pending and due at at. Returns how many were requeued.
+func (s *DeadLetterStorage) RequeueDeadLetters(ctx context.Context, filter models.DeadLetterFilter, at time.Time) (int, error) {
+	where, args := deadLetterFilterClause(filter)
+	if where == "" {
+		where = "\n\t\tWHERE status <> 'resolved'"
+	} else {
+		where += " AND status <> 'resolved'"
+	}
+	args = append(args, at)
+
+	query := `
+		UPDATE dead_letters
+		SET status = 'pending', next_attempt_at = $` + fmt.Sprint(len(args)) + `, updated_at = NOW()` + where
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error requeueing dead letters: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error requeueing dead letters: %w", err)
+	}
+	return int(n), nil
+}
+
+// deadLetterFilterClause builds a WHERE clause and its positional arguments for a filter
+func deadLetterFilterClause(filter models.DeadLetterFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			args = append(args, status)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if filter.Connector != "" {
+		args = append(args, filter.Connector)
+		conditions = append(conditions, fmt.Sprintf("connector = $%d", len(args)))
+	}
+	if filter.Stage != "" {
+		args = append(args, filter.Stage)
+		conditions = append(conditions, fmt.Sprintf("stage = $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var deadLetterRowColumns = []string{"id", "connector", "stage", "payload", "error", "attempts", "status", "next_attempt_at", "alert_id", "created_at", "updated_at"}
+
+func TestDeadLetterStorage_CreateDeadLetter(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewDeadLetterStorage(db)
+	now := time.Now()
+
+	mock.ExpectQuery("INSERT INTO dead_letters (.+) RETURNING id, created_at, updated_at").
+		WithArgs("siem", "storage", []byte(`{"source":"ids"}`), "value too long", 1, "pending", now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("dl-1", now, now))
+
+	dl := &models.DeadLetter{
+		Connector:     "siem",
+		Stage:         models.StageStorage,
+		Payload:       []byte(`{"source":"ids"}`),
+		Error:         "value too long",
+		Attempts:      1,
+		Status:        models.DeadLetterPending,
+		NextAttemptAt: now,
+	}
+	err := storage.CreateDeadLetter(context.Background(), dl)
+
+	require.NoError(t, err)
+	assert.Equal(t, "dl-1", dl.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeadLetterStorage_GetDeadLetter(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		now := time.Now()
+		mock.ExpectQuery("SELECT (.+) FROM dead_letters WHERE id = \\$1").
+			WithArgs("dl-1").
+			WillReturnRows(sqlmock.NewRows(deadLetterRowColumns).
+				AddRow("dl-1", "siem", "storage", []byte(`{}`), "", 2, "resolved", now, "alert-1", now, now))
+
+		dl, err := NewDeadLetterStorage(db).GetDeadLetter(context.Background(), "dl-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, "alert-1", *dl.AlertID)
+		assert.Equal(t, 2, dl.Attempts)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery("SELECT (.+) FROM dead_letters").WillReturnError(sql.ErrNoRows)
+
+		dl, err := NewDeadLetterStorage(db).GetDeadLetter(context.Background(), "missing")
+
+		assert.NoError(t, err)
+		assert.Nil(t, dl)
+	})
+}
+
+func TestDeadLetterStorage_ListDeadLetters(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM dead_letters WHERE status IN \\(\\$1, \\$2\\) AND connector = \\$3 ORDER BY created_at DESC LIMIT \\$4").
+		WithArgs("pending", "exhausted", "siem", defaultDeadLetterLimit).
+		WillReturnRows(sqlmock.NewRows(deadLetterRowColumns).
+			AddRow("dl-1", "siem", "enrichment", []byte(`{}`), "bad raw", 1, "pending", now, nil, now, now))
+
+	deadLetters, err := NewDeadLetterStorage(db).ListDeadLetters(context.Background(), models.DeadLetterFilter{
+		Statuses:  []string{"pending", "exhausted"},
+		Connector: "siem",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Nil(t, deadLetters[0].AlertID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeadLetterStorage_ClaimDueDeadLetters(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	lease := now.Add(time.Minute)
+	mock.ExpectQuery("UPDATE dead_letters SET next_attempt_at = \\$2(.+)FOR UPDATE SKIP LOCKED").
+		WithArgs(now, lease, 10).
+		WillReturnRows(sqlmock.NewRows(deadLetterRowColumns).
+			AddRow("dl-1", "siem", "storage", []byte(`{}`), "", 1, "pending", lease, nil, now, now))
+
+	deadLetters, err := NewDeadLetterStorage(db).ClaimDueDeadLetters(context.Background(), now, lease, 10)
+
+	require.NoError(t, err)
+	assert.Len(t, deadLetters, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeadLetterStorage_UpdateDeadLetter(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	alertID := "alert-1"
+	mock.ExpectExec("UPDATE dead_letters SET stage = \\$2(.+)WHERE id = \\$1").
+		WithArgs("dl-1", "storage", "", 2, "resolved", now, &alertID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := NewDeadLetterStorage(db).UpdateDeadLetter(context.Background(), &models.DeadLetter{
+		ID: "dl-1", Stage: models.StageStorage, Attempts: 2, Status: models.DeadLetterResolved, NextAttemptAt: now, AlertID: &alertID,
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeadLetterStorage_RequeueDeadLetters(t *testing.T) {
+	t.Run("every unresolved dead letter", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		now := time.Now()
+		mock.ExpectExec("UPDATE dead_letters SET status = 'pending', next_attempt_at = \\$1, updated_at = NOW\\(\\) WHERE status <> 'resolved'").
+			WithArgs(now).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		n, err := NewDeadLetterStorage(db).RequeueDeadLetters(context.Background(), models.DeadLetterFilter{}, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		now := time.Now()
+		mock.ExpectExec("UPDATE dead_letters SET (.+) WHERE stage = \\$1 AND status <> 'resolved'").
+			WithArgs("storage", now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		n, err := NewDeadLetterStorage(db).RequeueDeadLetters(context.Background(), models.DeadLetterFilter{Stage: "storage"}, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
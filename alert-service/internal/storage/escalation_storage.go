@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+const escalationColumns = `id, alert_id, tier, notifications, status, next_notify_at, created_at, updated_at`
+
+type EscalationStorage struct {
+	db *sql.DB
+}
+
+func NewEscalationStorage(db *sql.DB) *EscalationStorage {
+	return &EscalationStorage{db: db}
+}
+
+func scanEscalation(row rowScanner) (*models.Escalation, error) {
+	var esc models.Escalation
+	err := row.Scan(
+		&esc.ID,
+		&esc.AlertID,
+		&esc.Tier,
+		&esc.Notifications,
+		&esc.Status,
+		&esc.NextNotifyAt,
+		&esc.CreatedAt,
+		&esc.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &esc, nil
+}
+
+// CreateEscalation records the escalation of an alert that is about to be
+// paged. Its notifications stay at 0 until RecordInitialPage, so the
+// scheduler sends the initial page itself once nextNotifyAt passes.
+// Starting an escalation for an alert that already has one is a no-op.
+func (s *EscalationStorage) CreateEscalation(ctx context.Context, alertID string, nextNotifyAt time.Time) error {
+	query := `
+		INSERT INTO escalations (alert_id, notifications, next_notify_at)
+		VALUES ($1, 0, $2)
+		ON CONFLICT (alert_id) DO NOTHING
+	`
+
+	_, err := s.db.ExecContext(ctx, query, alertID, nextNotifyAt)
+	if err != nil {
+		return fmt.Errorf("error creating escalation: %w", err)
+	}
+
+	return nil
+}
+
+// RecordInitialPage records that the initial page of an alert was sent and
+// schedules the next step. Returns false when the page was already recorded
+// or the escalation is no longer active.
+func (s *EscalationStorage) RecordInitialPage(ctx context.Context, alertID, status string, nextNotifyAt time.Time) (bool, error) {
+	query := `
+		UPDATE escalations
+		SET notifications = 1, status = $2, next_notify_at = $3, updated_at = NOW()
+		WHERE alert_id = $1 AND status = 'active' AND notifications = 0
+	`
+
+	result, err := s.db.ExecContext(ctx, query, alertID, status, nextNotifyAt)
+	if err != nil {
+		return false, fmt.Errorf("error recording initial page: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error recording initial page: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// ClaimDueEscalations returns active escalations due at or before now and
+// pushes their next_notify_at to leaseUntil, so a crashed or concurrent
+// scheduler does not notify the same tier twice before the lease expires
+func (s *EscalationStorage) ClaimDueEscalations(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.Escalation, error) {
+	query := `
+		UPDATE escalations
+		SET next_notify_at = $2, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM escalations
+			WHERE status = 'active' AND next_notify_at <= $1
+			ORDER BY next_notify_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + escalationColumns
+
+	rows, err := s.db.QueryContext(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming due escalations: %w", err)
+	}
+	defer rows.Close()
+
+	var escalations []models.Escalation
+	for rows.Next() {
+		esc, err := scanEscalation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning escalation: %w", err)
+		}
+		escalations = append(escalations, *esc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating escalations: %w", err)
+	}
+
+	return escalations, nil
+}
+
+// UpdateEscalation persists the tier, notification count, status and next
+// due time of an active escalation. Returns false when the escalation is no
+// longer active, e.g. because an acknowledgement stopped it; it is then left
+// as it is.
+func (s *EscalationStorage) UpdateEscalation(ctx context.Context, esc *models.Escalation) (bool, error) {
+	query := `
+		UPDATE escalations
+		SET tier = $2, notifications = $3, status = $4, next_notify_at = $5, updated_at = NOW()
+		WHERE id = $1 AND status = 'active'
+	`
+
+	result, err := s.db.ExecContext(ctx, query, esc.ID, esc.Tier, esc.Notifications, esc.Status, esc.NextNotifyAt)
+	if err != nil {
+		return false, fmt.Errorf("error updating escalation: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error updating escalation: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// StopEscalation stops the active escalation for an alert.
+// Returns nil without error when the alert has no active escalation.
+func (s *EscalationStorage) StopEscalation(ctx context.Context, alertID string) (*models.Escalation, error) {
+	query := `
+		UPDATE escalations
+		SET status = 'stopped', updated_at = NOW()
+		WHERE alert_id = $1 AND status = 'active'
+		RETURNING ` + escalationColumns
+
+	esc, err := scanEscalation(s.db.QueryRowContext(ctx, query, alertID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error stopping escalation: %w", err)
+	}
+
+	return esc, nil
+}
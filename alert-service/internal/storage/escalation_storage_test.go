@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var escalationRowColumns = []string{"id", "alert_id", "tier", "notifications", "status", "next_notify_at", "created_at", "updated_at"}
+
+func TestEscalationStorage_CreateEscalation(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewEscalationStorage(db)
+	nextNotifyAt := time.Now()
+
+	mock.ExpectExec("INSERT INTO escalations (.+) ON CONFLICT \\(alert_id\\) DO NOTHING").
+		WithArgs("alert-1", nextNotifyAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := storage.CreateEscalation(context.Background(), "alert-1", nextNotifyAt)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEscalationStorage_RecordInitialPage(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewEscalationStorage(db)
+	next := time.Now()
+
+	t.Run("unpaged escalation", func(t *testing.T) {
+		mock.ExpectExec("UPDATE escalations SET notifications = 1(.+)WHERE alert_id = \\$1 AND status = 'active' AND notifications = 0").
+			WithArgs("alert-1", models.EscalationActive, next).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		recorded, err := storage.RecordInitialPage(context.Background(), "alert-1", models.EscalationActive, next)
+
+		assert.NoError(t, err)
+		assert.True(t, recorded)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("escalation already paged by the scheduler", func(t *testing.T) {
+		mock.ExpectExec("UPDATE escalations SET notifications = 1").
+			WithArgs("alert-1", models.EscalationActive, next).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		recorded, err := storage.RecordInitialPage(context.Background(), "alert-1", models.EscalationActive, next)
+
+		assert.NoError(t, err)
+		assert.False(t, recorded)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEscalationStorage_ClaimDueEscalations(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewEscalationStorage(db)
+	now := time.Now()
+	lease := now.Add(time.Minute)
+
+	rows := sqlmock.NewRows(escalationRowColumns).
+		AddRow("esc-1", "alert-1", 0, 1, "active", lease, now, now)
+
+	mock.ExpectQuery("UPDATE escalations SET next_notify_at = \\$2(.+)FOR UPDATE SKIP LOCKED").
+		WithArgs(now, lease, 10).
+		WillReturnRows(rows)
+
+	escalations, err := storage.ClaimDueEscalations(context.Background(), now, lease, 10)
+
+	assert.NoError(t, err)
+	assert.Len(t, escalations, 1)
+	assert.Equal(t, "alert-1", escalations[0].AlertID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEscalationStorage_UpdateEscalation(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewEscalationStorage(db)
+	next := time.Now()
+
+	esc := &models.Escalation{ID: "esc-1", Tier: 1, Notifications: 2, Status: models.EscalationActive, NextNotifyAt: next}
+
+	t.Run("active escalation", func(t *testing.T) {
+		mock.ExpectExec("UPDATE escalations SET tier = \\$2(.+)WHERE id = \\$1 AND status = 'active'").
+			WithArgs("esc-1", 1, 2, models.EscalationActive, next).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		updated, err := storage.UpdateEscalation(context.Background(), esc)
+
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stopped escalation is left as it is", func(t *testing.T) {
+		mock.ExpectExec("UPDATE escalations SET tier = \\$2").
+			WithArgs("esc-1", 1, 2, models.EscalationActive, next).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		updated, err := storage.UpdateEscalation(context.Background(), esc)
+
+		assert.NoError(t, err)
+		assert.False(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEscalationStorage_StopEscalation(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	storage := NewEscalationStorage(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("active escalation", func(t *testing.T) {
+		rows := sqlmock.NewRows(escalationRowColumns).
+			AddRow("esc-1", "alert-1", 2, 3, "stopped", now, now, now)
+
+		mock.ExpectQuery("UPDATE escalations SET status = 'stopped'").
+			WithArgs("alert-1").
+			WillReturnRows(rows)
+
+		esc, err := storage.StopEscalation(ctx, "alert-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, esc.Tier)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no active escalation", func(t *testing.T) {
+		mock.ExpectQuery("UPDATE escalations SET status = 'stopped'").
+			WithArgs("alert-2").
+			WillReturnError(sql.ErrNoRows)
+
+		esc, err := storage.StopEscalation(ctx, "alert-2")
+
+		assert.NoError(t, err)
+		assert.Nil(t, esc)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
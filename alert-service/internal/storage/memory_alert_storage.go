@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+// MemoryAlertStorage keeps alerts in memory, for local development and
+// tests without a database. It follows the semantics of AlertStorage,
+// including fingerprint deduplication, and loses every alert on restart.
+type MemoryAlertStorage struct {
+	mu     sync.RWMutex
+	alerts map[string]*models.Alert
+	// byFingerprint indexes stored alerts by fingerprint and created_at,
+	// the unique key of the alerts table
+	byFingerprint map[fingerprintKey]string
+}
+
+type fingerprintKey struct {
+	fingerprint string
+	createdAt   time.Time
+}
+
+func NewMemoryAlertStorage() *MemoryAlertStorage {
+	return &MemoryAlertStorage{
+		alerts:        make(map[string]*models.Alert),
+		byFingerprint: make(map[fingerprintKey]string),
+	}
+}
+
+// storedTime drops the location and any precision below a microsecond, as a
+// Postgres TIMESTAMP column does
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+func storedTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	stored := storedTime(*t)
+	return &stored
+}
+
+// copyAlert returns a copy that shares no pointers or maps with alert
+func copyAlert(alert *models.Alert) models.Alert {
+	c := *alert
+	c.WholeEvent = slices.Clone(alert.WholeEvent)
+	if alert.EnrichmentType != nil {
+		v := *alert.EnrichmentType
+		c.EnrichmentType = &v
+	}
+	if alert.IPAddress != nil {
+		v := *alert.IPAddress
+		c.IPAddress = &v
+	}
+	c.EnrichmentVersions = maps.Clone(alert.EnrichmentVersions)
+	if c.EnrichmentVersions == nil {
+		c.EnrichmentVersions = map[string]int{}
+	}
+	c.AcknowledgedAt = storedTimePtr(alert.AcknowledgedAt)
+	c.ResolvedAt = storedTimePtr(alert.ResolvedAt)
+	c.CreatedAt = storedTime(alert.CreatedAt)
+	return c
+}
+
+// insert stores a copy of alert unless its ID or fingerprint is taken; the
+// caller holds the write lock
+func (s *MemoryAlertStorage) insert(alert models.Alert) bool {
+	stored := copyAlert(&alert)
+	if _, ok := s.alerts[stored.ID]; ok {
+		return false
+	}
+	key := fingerprintKey{stored.Fingerprint, stored.CreatedAt}
+	if stored.Fingerprint != "" {
+		if _, ok := s.byFingerprint[key]; ok {
+			return false
+		}
+		s.byFingerprint[key] = stored.ID
+	}
+	s.alerts[stored.ID] = &stored
+	return true
+}
+
+// CreateAlert stores a new alert. The generated ID and initial status are
+// written back into alert; an alert whose fingerprint is already stored is
+// skipped and keeps an empty ID.
+func (s *MemoryAlertStorage) CreateAlert(ctx context.Context, alert *models.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *alert
+	stored.ID = newAlertID()
+	stored.Status = models.StatusOpen
+	stored.AcknowledgedAt, stored.ResolvedAt = nil, nil
+	if s.insert(stored) {
+		alert.ID, alert.Status = stored.ID, stored.Status
+	}
+	return nil
+}
+
+// CreateAlerts stores alerts as CreateAlert does, all at once
+func (s *MemoryAlertStorage) CreateAlerts(ctx context.Context, alerts []models.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range alerts {
+		stored := alerts[i]
+		stored.ID = newAlertID()
+		stored.Status = models.StatusOpen
+		stored.AcknowledgedAt, stored.ResolvedAt = nil, nil
+		if s.insert(stored) {
+			alerts[i].ID, alerts[i].Status = stored.ID, stored.Status
+		}
+	}
+	return nil
+}
+
+// RestoreAlerts stores archived alerts as they were, skipping alerts that
+// are already stored. Returns the number inserted.
+func (s *MemoryAlertStorage) RestoreAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inserted := 0
+	for _, alert := range alerts {
+		if s.insert(alert) {
+			inserted++
+		}
+	}
+	return inserted, nil
+}
+
+// GetAlerts retrieves all alerts, newest first
+func (s *MemoryAlertStorage) GetAlerts(ctx context.Context) ([]models.Alert, error) {
+	return s.ListAlerts(ctx, models.AlertFilter{})
+}
+
+// matching returns copies of the alerts matching the filter, oldest first;
+// the caller holds the read lock
+func (s *MemoryAlertStorage) matching(filter models.AlertFilter) []models.Alert {
+	var alerts []models.Alert
+	for _, alert := range s.alerts {
+		if filter.Matches(*alert) {
+			alerts = append(alerts, copyAlert(alert))
+		}
+	}
+	slices.SortFunc(alerts, func(a, b models.Alert) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return alerts
+}
+
+// ListAlerts retrieves alerts matching the filter, newest first
+func (s *MemoryAlertStorage) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alerts := s.matching(filter)
+	slices.Reverse(alerts)
+	return alerts, nil
+}
+
+// ListAlertsAfter retrieves up to limit alerts matching the filter, oldest
+// first, that come after the alert with the given created_at and ID. An
+// empty afterID starts from the oldest alert.
+func (s *MemoryAlertStorage) ListAlertsAfter(ctx context.Context, filter models.AlertFilter, afterCreatedAt time.Time, afterID string, limit int) ([]models.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alerts := s.matching(filter)
+	if afterID != "" {
+		after := storedTime(afterCreatedAt)
+		start, _ := slices.BinarySearchFunc(alerts, after, func(a models.Alert, t time.Time) int {
+			return a.CreatedAt.Compare(t)
+		})
+		for start < len(alerts) && alerts[start].CreatedAt.Equal(after) && alerts[start].ID <= afterID {
+			start++
+		}
+		alerts = alerts[start:]
+	}
+	if len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+	return alerts, nil
+}
+
+// CountAlerts counts the alerts matching the filter
+func (s *MemoryAlertStorage) CountAlerts(ctx context.Context, filter models.AlertFilter) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, alert := range s.alerts {
+		if filter.Matches(*alert) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// UpdateAlertEnrichment writes an alert's enrichment fields and their versions
+func (s *MemoryAlertStorage) UpdateAlertEnrichment(ctx context.Context, alert *models.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.alerts[alert.ID]
+	if !ok {
+		return fmt.Errorf("alert not found")
+	}
+	update := copyAlert(alert)
+	stored.EnrichmentType = update.EnrichmentType
+	stored.IPAddress = update.IPAddress
+	stored.EnrichmentVersions = update.EnrichmentVersions
+	return nil
+}
+
+// GetAlertByID retrieves a single alert by ID.
+// Returns nil without error when there is none.
+func (s *MemoryAlertStorage) GetAlertByID(ctx context.Context, id string) (*models.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.alerts[id]
+	if !ok {
+		return nil, nil
+	}
+	alert := copyAlert(stored)
+	return &alert, nil
+}
+
+// AlertExists reports whether an alert with the given fingerprint is stored
+func (s *MemoryAlertStorage) AlertExists(ctx context.Context, fingerprint string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for key := range s.byFingerprint {
+		if key.fingerprint == fingerprint {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetAlertsByDays retrieves alerts from the last X days
+func (s *MemoryAlertStorage) GetAlertsByDays(ctx context.Context, days int) ([]models.Alert, error) {
+	return s.ListAlerts(ctx, models.AlertFilter{Days: days})
+}
+
+// UpdateAlertStatus moves an alert in one of the from statuses to status and
+// stamps the matching acknowledged_at/resolved_at time. Returns nil without
+// error when the alert does not exist or is in another status.
+func (s *MemoryAlertStorage) UpdateAlertStatus(ctx context.Context, id string, from []string, status string) (*models.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.alerts[id]
+	if !ok || !slices.Contains(from, stored.Status) {
+		return nil, nil
+	}
+	now := storedTime(time.Now())
+	stored.Status = status
+	switch status {
+	case models.StatusAcknowledged:
+		stored.AcknowledgedAt = &now
+	case models.StatusResolved:
+		stored.ResolvedAt = &now
+	}
+	alert := copyAlert(stored)
+	return &alert, nil
+}
+
+// GetLastSyncTime returns the created_at of the newest alert
+func (s *MemoryAlertStorage) GetLastSyncTime(ctx context.Context) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var last time.Time
+	for _, alert := range s.alerts {
+		if alert.CreatedAt.After(last) {
+			last = alert.CreatedAt
+		}
+	}
+	return last, nil
+}
+
+// UpdateLastSyncTime is a no-op; as with AlertStorage, the sync time is the newest alert
+func (s *MemoryAlertStorage) UpdateLastSyncTime(ctx context.Context, t time.Time) error {
+	return nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+// MemoryConnectorStorage keeps connector watermarks and health in memory,
+// alongside MemoryAlertStorage. Like the alerts, the state is lost on restart.
+type MemoryConnectorStorage struct {
+	mu     sync.Mutex
+	states map[string]models.ConnectorState
+}
+
+func NewMemoryConnectorStorage() *MemoryConnectorStorage {
+	return &MemoryConnectorStorage{states: make(map[string]models.ConnectorState)}
+}
+
+// GetConnectorState returns the saved state of a connector.
+// Returns nil without error when the connector has never run.
+func (s *MemoryConnectorStorage) GetConnectorState(ctx context.Context, name string) (*models.ConnectorState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[name]
+	if !ok {
+		return nil, nil
+	}
+	return cloneConnectorState(state), nil
+}
+
+// SaveConnectorState inserts or replaces the state of a connector
+func (s *MemoryConnectorStorage) SaveConnectorState(ctx context.Context, state *models.ConnectorState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := cloneConnectorState(*state)
+	saved.UpdatedAt = time.Now().UTC()
+	s.states[state.Name] = *saved
+	return nil
+}
+
+// cloneConnectorState copies a state so the caller cannot change a stored one
+// through its time pointers
+func cloneConnectorState(state models.ConnectorState) *models.ConnectorState {
+	for _, t := range []**time.Time{&state.Watermark, &state.LastAttemptAt, &state.LastSuccessAt} {
+		if *t != nil {
+			copied := **t
+			*t = &copied
+		}
+	}
+	return &state
+}
@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// partitionBoundLayout is how Postgres renders TIMESTAMP partition bounds
+const partitionBoundLayout = "2006-01-02 15:04:05"
+
+// partitionBounds matches a range partition's bound expression, e.g.
+// FOR VALUES FROM ('2025-01-01 00:00:00') TO ('2025-02-01 00:00:00')
+var partitionBounds = regexp.MustCompile(`FROM \('([^']+)'\) TO \('([^']+)'\)`)
+
+// PartitionStorage manages the range partitions of the alerts table
+type PartitionStorage struct {
+	db *sql.DB
+}
+
+func NewPartitionStorage(db *sql.DB) *PartitionStorage {
+	return &PartitionStorage{db: db}
+}
+
+// ListAlertPartitions retrieves the range partitions attached to alerts,
+// oldest first. The default partition is not included.
+func (s *PartitionStorage) ListAlertPartitions(ctx context.Context) ([]models.AlertPartition, error) {
+	query := `
+		SELECT c.relname, pg_get_expr(c.relpartbound, c.oid)
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'alerts'::regclass
+		ORDER BY c.relname
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error querying alert partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []models.AlertPartition
+	for rows.Next() {
+		var name, bound string
+		if err := rows.Scan(&name, &bound); err != nil {
+			return nil, fmt.Errorf("error scanning alert partition: %w", err)
+		}
+		m := partitionBounds.FindStringSubmatch(bound)
+		if m == nil {
+			continue
+		}
+		from, err := time.Parse(partitionBoundLayout, m[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bound on partition %s: %w", name, err)
+		}
+		to, err := time.Parse(partitionBoundLayout, m[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bound on partition %s: %w", name, err)
+		}
+		partitions = append(partitions, models.AlertPartition{Name: name, From: from, To: to})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alert partitions: %w", err)
+	}
+
+	return partitions, nil
+}
+
+// CreateAlertPartition attaches a new partition for the alerts created in
+// [partition.From, partition.To). Postgres refuses the partition while
+// alerts_default holds rows in that range, so those rows are moved into it
+// in the same transaction: the default partition is detached, the partition
+// created and filled, and the default partition reattached. That locks
+// alerts until the transaction commits, so it is only done when needed.
+func (s *PartitionStorage) CreateAlertPartition(ctx context.Context, partition models.AlertPartition) error {
+	from, to := partition.From.UTC(), partition.To.UTC()
+	name := pq.QuoteIdentifier(partition.Name)
+	// DDL takes no bind parameters, so the name and bounds are quoted instead
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF alerts FOR VALUES FROM (%s) TO (%s)",
+		name,
+		pq.QuoteLiteral(from.Format(partitionBoundLayout)),
+		pq.QuoteLiteral(to.Format(partitionBoundLayout)),
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var stranded bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM alerts_default WHERE created_at >= $1 AND created_at < $2)
+	`, from, to).Scan(&stranded)
+	if err != nil {
+		return fmt.Errorf("error checking default alert partition: %w", err)
+	}
+
+	if !stranded {
+		if _, err := tx.ExecContext(ctx, create); err != nil {
+			return fmt.Errorf("error creating alert partition %s: %w", partition.Name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("error committing transaction: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "ALTER TABLE alerts DETACH PARTITION alerts_default"); err != nil {
+		return fmt.Errorf("error detaching default alert partition: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("error creating alert partition %s: %w", partition.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO "+name+" SELECT * FROM alerts_default WHERE created_at >= $1 AND created_at < $2", from, to); err != nil {
+		return fmt.Errorf("error moving alerts into partition %s: %w", partition.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM alerts_default WHERE created_at >= $1 AND created_at < $2", from, to); err != nil {
+		return fmt.Errorf("error moving alerts into partition %s: %w", partition.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, "ALTER TABLE alerts ATTACH PARTITION alerts_default DEFAULT"); err != nil {
+		return fmt.Errorf("error reattaching default alert partition: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DropAlertPartition deletes a partition and the alerts in it
+func (s *PartitionStorage) DropAlertPartition(ctx context.Context, name string) error {
+	if _, err := s.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+pq.QuoteIdentifier(name)); err != nil {
+		return fmt.Errorf("error dropping alert partition %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// DetachAlertPartition removes a partition from alerts, keeping it as a
+// standalone table for archiving
+func (s *PartitionStorage) DetachAlertPartition(ctx context.Context, name string) error {
+	if _, err := s.db.ExecContext(ctx, "ALTER TABLE alerts DETACH PARTITION "+pq.QuoteIdentifier(name)); err != nil {
+		return fmt.Errorf("error detaching alert partition %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredAlerts deletes up to limit alerts of a severity created before
+// before. Returns the number deleted.
+func (s *PartitionStorage) DeleteExpiredAlerts(ctx context.Context, severity string, before time.Time, limit int) (int, error) {
+	query := `
+		DELETE FROM alerts
+		WHERE (id, created_at) IN (
+			SELECT id, created_at FROM alerts
+			WHERE severity = $1 AND created_at < $2
+			LIMIT $3
+		)
+	`
+
+	result, err := s.db.ExecContext(ctx, query, severity, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired alerts: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired alerts: %w", err)
+	}
+
+	return int(deleted), nil
+}
+
+// PurgeAlertReferences removes the escalations of alerts that no longer
+// exist and unlinks their dead letters, which foreign keys cannot do for a
+// partitioned alerts table
+func (s *PartitionStorage) PurgeAlertReferences(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM escalations e
+		WHERE NOT EXISTS (SELECT 1 FROM alerts a WHERE a.id = e.alert_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("error purging escalations: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE dead_letters d SET alert_id = NULL
+		WHERE alert_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM alerts a WHERE a.id = d.alert_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("error unlinking dead letters: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionStorage_ListAlertPartitions(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT c.relname, pg_get_expr(.+) FROM pg_inherits").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "bound"}).
+			AddRow("alerts_default", "DEFAULT").
+			AddRow("alerts_p20250101", "FOR VALUES FROM ('2025-01-01 00:00:00') TO ('2025-02-01 00:00:00')"))
+
+	partitions, err := NewPartitionStorage(db).ListAlertPartitions(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.AlertPartition{{
+		Name: "alerts_p20250101",
+		From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+	}}, partitions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPartitionStorage_CreateAlertPartition(t *testing.T) {
+	from, to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)
+	partition := models.AlertPartition{Name: "alerts_p20250201", From: from, To: to}
+	create := `CREATE TABLE IF NOT EXISTS "alerts_p20250201" PARTITION OF alerts FOR VALUES FROM \('2025-02-01 00:00:00'\) TO \('2025-02-02 00:00:00'\)`
+
+	t.Run("empty default partition", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM alerts_default WHERE created_at >= \$1 AND created_at < \$2\)`).
+			WithArgs(from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(create).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		require.NoError(t, NewPartitionStorage(db).CreateAlertPartition(context.Background(), partition))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("moves alerts out of the default partition", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM alerts_default`).
+			WithArgs(from, to).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectExec(`ALTER TABLE alerts DETACH PARTITION alerts_default`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(create).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "alerts_p20250201" SELECT \* FROM alerts_default WHERE created_at >= \$1 AND created_at < \$2`).
+			WithArgs(from, to).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`DELETE FROM alerts_default WHERE created_at >= \$1 AND created_at < \$2`).
+			WithArgs(from, to).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`ALTER TABLE alerts ATTACH PARTITION alerts_default DEFAULT`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		require.NoError(t, NewPartitionStorage(db).CreateAlertPartition(context.Background(), partition))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back when the move fails", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM alerts_default`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectExec(`ALTER TABLE alerts DETACH PARTITION alerts_default`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(create).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO "alerts_p20250201"`).WillReturnError(errors.New("disk full"))
+		mock.ExpectRollback()
+
+		err := NewPartitionStorage(db).CreateAlertPartition(context.Background(), partition)
+
+		assert.ErrorContains(t, err, "disk full")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPartitionStorage_RemoveAlertPartition(t *testing.T) {
+	t.Run("drop", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectExec(`DROP TABLE IF EXISTS "alerts_p20250101"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.NoError(t, NewPartitionStorage(db).DropAlertPartition(context.Background(), "alerts_p20250101"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("detach", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectExec(`ALTER TABLE alerts DETACH PARTITION "alerts_p20250101"`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.NoError(t, NewPartitionStorage(db).DetachAlertPartition(context.Background(), "alerts_p20250101"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPartitionStorage_DeleteExpiredAlerts(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	before := time.Now().AddDate(0, 0, -30)
+	mock.ExpectExec("DELETE FROM alerts WHERE \\(id, created_at\\) IN \\(\\s*SELECT id, created_at FROM alerts WHERE severity = \\$1 AND created_at < \\$2 LIMIT \\$3\\s*\\)").
+		WithArgs("low", before, 500).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	deleted, err := NewPartitionStorage(db).DeleteExpiredAlerts(context.Background(), "low", before, 500)
+
+	require.NoError(t, err)
+	assert.Equal(t, 42, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPartitionStorage_PurgeAlertReferences(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM escalations e WHERE NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE dead_letters d SET alert_id = NULL").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, NewPartitionStorage(db).PurgeAlertReferences(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
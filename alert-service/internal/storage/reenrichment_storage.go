@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+const reenrichmentColumns = `id, filter, fields, force, version, status, total, position, after_id, scanned, updated, skipped, error, created_at, updated_at, completed_at`
+
+// defaultReenrichmentLimit caps job listings
+const defaultReenrichmentLimit = 100
+
+type ReenrichmentStorage struct {
+	db *sql.DB
+}
+
+func NewReenrichmentStorage(db *sql.DB) *ReenrichmentStorage {
+	return &ReenrichmentStorage{db: db}
+}
+
+func scanReenrichmentJob(row rowScanner) (*models.ReenrichmentJob, error) {
+	var job models.ReenrichmentJob
+	var filter, fields []byte
+	var position, completedAt sql.NullTime
+	var afterID sql.NullString
+	err := row.Scan(
+		&job.ID,
+		&filter,
+		&fields,
+		&job.Force,
+		&job.Version,
+		&job.Status,
+		&job.Total,
+		&position,
+		&afterID,
+		&job.Scanned,
+		&job.Updated,
+		&job.Skipped,
+		&job.Error,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(filter, &job.Filter); err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	if err := json.Unmarshal(fields, &job.Fields); err != nil {
+		return nil, fmt.Errorf("invalid fields: %w", err)
+	}
+	job.Position = position.Time
+	job.AfterID = afterID.String
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	return &job, nil
+}
+
+// CreateReenrichmentJob records a new job. The generated ID and timestamps
+// are written back into job.
+func (s *ReenrichmentStorage) CreateReenrichmentJob(ctx context.Context, job *models.ReenrichmentJob) error {
+	filter, err := json.Marshal(job.Filter)
+	if err != nil {
+		return fmt.Errorf("error encoding filter: %w", err)
+	}
+	fields := []byte("[]")
+	if len(job.Fields) > 0 {
+		if fields, err = json.Marshal(job.Fields); err != nil {
+			return fmt.Errorf("error encoding fields: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO reenrichment_jobs (filter, fields, force, version, status, total)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = s.db.QueryRowContext(ctx, query,
+		filter,
+		fields,
+		job.Force,
+		job.Version,
+		job.Status,
+		job.Total,
+	).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating re-enrichment job: %w", err)
+	}
+
+	return nil
+}
+
+// GetReenrichmentJob retrieves a job by ID.
+// Returns nil without error when there is none.
+func (s *ReenrichmentStorage) GetReenrichmentJob(ctx context.Context, id string) (*models.ReenrichmentJob, error) {
+	query := `
+		SELECT ` + reenrichmentColumns + `
+		FROM reenrichment_jobs
+		WHERE id = $1
+	`
+
+	job, err := scanReenrichmentJob(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying re-enrichment job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ListReenrichmentJobs retrieves the most recent jobs, newest first,
+// optionally only those in the given statuses
+func (s *ReenrichmentStorage) ListReenrichmentJobs(ctx context.Context, statuses []string) ([]models.ReenrichmentJob, error) {
+	where := ""
+	condition, args := statusCondition(statuses, 1)
+	if condition != "" {
+		where = "\n\t\tWHERE " + condition
+	}
+	args = append(args, defaultReenrichmentLimit)
+
+	query := `
+		SELECT ` + reenrichmentColumns + `
+		FROM reenrichment_jobs` + where + `
+		ORDER BY created_at DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying re-enrichment jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.ReenrichmentJob
+	for rows.Next() {
+		job, err := scanReenrichmentJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning re-enrichment job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating re-enrichment jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// ClaimReenrichmentJob marks the oldest pending job, or a running job whose
+// worker let its lease expire, as running and leased until leaseUntil.
+// Returns nil without error when no job is waiting.
+func (s *ReenrichmentStorage) ClaimReenrichmentJob(ctx context.Context, now, leaseUntil time.Time) (*models.ReenrichmentJob, error) {
+	query := `
+		UPDATE reenrichment_jobs
+		SET status = 'running', lease_until = $2, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM reenrichment_jobs
+			WHERE status = 'pending' OR (status = 'running' AND (lease_until IS NULL OR lease_until <= $1))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + reenrichmentColumns
+
+	job, err := scanReenrichmentJob(s.db.QueryRowContext(ctx, query, now, leaseUntil))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error claiming re-enrichment job: %w", err)
+	}
+
+	return job, nil
+}
+
+// SaveReenrichmentProgress checkpoints a running job's position and counts
+// and extends its lease. Returns the job's current status, which is no
+// longer running once it has been paused or cancelled.
+func (s *ReenrichmentStorage) SaveReenrichmentProgress(ctx context.Context, job *models.ReenrichmentJob, leaseUntil time.Time) (string, error) {
+	query := `
+		UPDATE reenrichment_jobs
+		SET position = $2, after_id = $3, scanned = $4, updated = $5, skipped = $6,
+			lease_until = CASE WHEN status = 'running' THEN $7::TIMESTAMP END, updated_at = NOW()
+		WHERE id = $1
+		RETURNING status
+	`
+
+	position, afterID := reenrichmentCursor(job)
+	var status string
+	err := s.db.QueryRowContext(ctx, query, job.ID, position, afterID, job.Scanned, job.Updated, job.Skipped, leaseUntil).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("error saving re-enrichment progress: %w", err)
+	}
+
+	return status, nil
+}
+
+// FinishReenrichmentJob records the final status, error, position, counts
+// and completion time of a running job, so a failed job resumes after the
+// last alert it handled. A job paused or cancelled in the meantime keeps
+// that status.
+func (s *ReenrichmentStorage) FinishReenrichmentJob(ctx context.Context, job *models.ReenrichmentJob) error {
+	query := `
+		UPDATE reenrichment_jobs
+		SET status = $2, error = $3, completed_at = $4, position = $5, after_id = $6,
+			scanned = $7, updated = $8, skipped = $9, lease_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+
+	position, afterID := reenrichmentCursor(job)
+	_, err := s.db.ExecContext(ctx, query, job.ID, job.Status, job.Error, job.CompletedAt, position, afterID, job.Scanned, job.Updated, job.Skipped)
+	if err != nil {
+		return fmt.Errorf("error finishing re-enrichment job: %w", err)
+	}
+
+	return nil
+}
+
+// reenrichmentCursor returns the position and after_id columns, which are
+// NULL until the job has handled an alert
+func reenrichmentCursor(job *models.ReenrichmentJob) (sql.NullTime, sql.NullString) {
+	if job.AfterID == "" {
+		return sql.NullTime{}, sql.NullString{}
+	}
+	return sql.NullTime{Time: job.Position, Valid: true}, sql.NullString{String: job.AfterID, Valid: true}
+}
+
+// TransitionReenrichmentJob moves a job in one of the from statuses to
+// status, releasing any lease; resuming also clears the last error. Returns
+// nil without error when the job does not exist or is in another status.
+func (s *ReenrichmentStorage) TransitionReenrichmentJob(ctx context.Context, id string, from []string, status string) (*models.ReenrichmentJob, error) {
+	condition, args := statusCondition(from, 4)
+	if condition == "" {
+		return nil, nil
+	}
+	query := `
+		UPDATE reenrichment_jobs
+		SET status = $2, lease_until = NULL, error = CASE WHEN $3 THEN '' ELSE error END, updated_at = NOW()
+		WHERE id = $1 AND ` + condition + `
+		RETURNING ` + reenrichmentColumns
+
+	args = append([]interface{}{id, status, status == models.JobPending}, args...)
+	job, err := scanReenrichmentJob(s.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating re-enrichment job: %w", err)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var reenrichmentRowColumns = []string{"id", "filter", "fields", "force", "version", "status", "total", "position", "after_id", "scanned", "updated", "skipped", "error", "created_at", "updated_at", "completed_at"}
+
+func TestReenrichmentStorage_CreateReenrichmentJob(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO reenrichment_jobs (.+) RETURNING id, created_at, updated_at").
+		WithArgs([]byte(`{"sources":["ids"],"from":"2025-01-10T12:00:00Z"}`), []byte(`[]`), false, 1, "pending", 42).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("job-1", now, now))
+
+	job := &models.ReenrichmentJob{
+		Filter:  models.AlertFilter{Sources: []string{"ids"}, From: now},
+		Version: 1,
+		Status:  models.JobPending,
+		Total:   42,
+	}
+	err := NewReenrichmentStorage(db).CreateReenrichmentJob(context.Background(), job)
+
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", job.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReenrichmentStorage_GetReenrichmentJob(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		now := time.Now()
+		mock.ExpectQuery("SELECT (.+) FROM reenrichment_jobs WHERE id = \\$1").
+			WithArgs("job-1").
+			WillReturnRows(sqlmock.NewRows(reenrichmentRowColumns).
+				AddRow("job-1", []byte(`{"severities":["critical"]}`), []byte(`["ip_address"]`), true, 2, "running", 10, now, "a-4", 4, 3, 1, "", now, now, nil))
+
+		job, err := NewReenrichmentStorage(db).GetReenrichmentJob(context.Background(), "job-1")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"critical"}, job.Filter.Severities)
+		assert.Equal(t, []string{"ip_address"}, job.Fields)
+		assert.True(t, job.Force)
+		assert.Equal(t, "a-4", job.AfterID)
+		assert.Nil(t, job.CompletedAt)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock, cleanup := setupMockDB(t)
+		defer cleanup()
+
+		mock.ExpectQuery("SELECT (.+) FROM reenrichment_jobs").WillReturnError(sql.ErrNoRows)
+
+		job, err := NewReenrichmentStorage(db).GetReenrichmentJob(context.Background(), "missing")
+
+		assert.NoError(t, err)
+		assert.Nil(t, job)
+	})
+}
+
+func TestReenrichmentStorage_ClaimReenrichmentJob(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	lease := now.Add(2 * time.Minute)
+	mock.ExpectQuery("UPDATE reenrichment_jobs SET status = 'running'(.+)FOR UPDATE SKIP LOCKED").
+		WithArgs(now, lease).
+		WillReturnRows(sqlmock.NewRows(reenrichmentRowColumns).
+			AddRow("job-1", []byte(`{}`), []byte(`[]`), false, 1, "running", 10, nil, nil, 0, 0, 0, "", now, now, nil))
+
+	job, err := NewReenrichmentStorage(db).ClaimReenrichmentJob(context.Background(), now, lease)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.JobRunning, job.Status)
+	assert.True(t, job.Position.IsZero())
+	assert.Empty(t, job.AfterID)
+}
+
+func TestReenrichmentStorage_SaveReenrichmentProgress(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	lease := now.Add(2 * time.Minute)
+	mock.ExpectQuery("UPDATE reenrichment_jobs SET position = \\$2, after_id = \\$3(.+)RETURNING status").
+		WithArgs("job-1", now, "a-2", 2, 1, 1, lease).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("cancelled"))
+
+	job := &models.ReenrichmentJob{ID: "job-1", Position: now, AfterID: "a-2", Scanned: 2, Updated: 1, Skipped: 1}
+	status, err := NewReenrichmentStorage(db).SaveReenrichmentProgress(context.Background(), job, lease)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.JobCancelled, status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReenrichmentStorage_FinishReenrichmentJob(t *testing.T) {
+	db, mock, cleanup := setupMockDB(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE reenrichment_jobs SET status = \\$2(.+)WHERE id = \\$1 AND status = 'running'").
+		WithArgs("job-1", "failed", "connection reset", nil, nil, nil, 0, 0, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	job := &models.ReenrichmentJob{ID: "job-1", Status: models.JobFailed, Error: "connection reset"}
+	err := NewReenrichmentStorage(db).FinishReenrichmentJob(context.Background(), job)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
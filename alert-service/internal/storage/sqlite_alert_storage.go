@@ -0,0 +1,507 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"censys_alert_system/internal/models"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the alerts and connector_state tables of a SQLite
+// database. Times are stored as Unix microseconds in UTC, the precision of a
+// Postgres TIMESTAMP.
+const sqliteSchema = `
+	CREATE TABLE IF NOT EXISTS alerts (
+		id TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		severity TEXT NOT NULL,
+		description TEXT NOT NULL,
+		whole_event BLOB,
+		enrichment_type TEXT,
+		ip_address TEXT,
+		enrichment_versions TEXT NOT NULL DEFAULT '{}',
+		fingerprint TEXT,
+		status TEXT NOT NULL DEFAULT 'open',
+		acknowledged_at INTEGER,
+		resolved_at INTEGER,
+		created_at INTEGER NOT NULL
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS alerts_fingerprint_created_at ON alerts (fingerprint, created_at);
+	CREATE INDEX IF NOT EXISTS alerts_created_at_id ON alerts (created_at, id);
+	CREATE TABLE IF NOT EXISTS connector_state (
+		name TEXT PRIMARY KEY,
+		watermark INTEGER,
+		page_cursor TEXT NOT NULL DEFAULT '',
+		last_attempt_at INTEGER,
+		last_success_at INTEGER,
+		last_error TEXT NOT NULL DEFAULT '',
+		consecutive_failures INTEGER NOT NULL DEFAULT 0,
+		updated_at INTEGER NOT NULL
+	);
+`
+
+// SQLiteAlertStorage stores alerts in a SQLite file, for single-node
+// deployments without Postgres. The schema is created on open.
+type SQLiteAlertStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteAlertStorage opens, and creates if needed, the SQLite database at
+// path. ":memory:" keeps the database in memory.
+func NewSQLiteAlertStorage(path string) (*SQLiteAlertStorage, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)"
+	if path != ":memory:" {
+		dsn += "&_pragma=journal_mode(WAL)"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database: %w", err)
+	}
+	// SQLite allows a single writer; one connection also keeps an in-memory
+	// database alive and shared
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating sqlite schema: %w", err)
+	}
+	return &SQLiteAlertStorage{db: db}, nil
+}
+
+// Close closes the database
+func (s *SQLiteAlertStorage) Close() error {
+	return s.db.Close()
+}
+
+func toMicros(t time.Time) int64 {
+	return t.UnixMicro()
+}
+
+func toMicrosPtr(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixMicro(), Valid: true}
+}
+
+func fromMicrosPtr(v sql.NullInt64) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.UnixMicro(v.Int64).UTC()
+	return &t
+}
+
+// nullFingerprint stores an empty fingerprint as NULL, which never conflicts
+func nullFingerprint(fingerprint string) sql.NullString {
+	return sql.NullString{String: fingerprint, Valid: fingerprint != ""}
+}
+
+// scanSQLiteAlert scans a single row selected with alertColumns
+func scanSQLiteAlert(row rowScanner) (*models.Alert, error) {
+	var alert models.Alert
+	var fingerprint sql.NullString
+	var versions string
+	var acknowledgedAt, resolvedAt sql.NullInt64
+	var createdAt int64
+	err := row.Scan(
+		&alert.ID,
+		&alert.Source,
+		&alert.Severity,
+		&alert.Description,
+		&alert.WholeEvent,
+		&alert.EnrichmentType,
+		&alert.IPAddress,
+		&versions,
+		&fingerprint,
+		&alert.Status,
+		&acknowledgedAt,
+		&resolvedAt,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	alert.Fingerprint = fingerprint.String
+	alert.AcknowledgedAt = fromMicrosPtr(acknowledgedAt)
+	alert.ResolvedAt = fromMicrosPtr(resolvedAt)
+	alert.CreatedAt = time.UnixMicro(createdAt).UTC()
+	if versions != "" {
+		if err := json.Unmarshal([]byte(versions), &alert.EnrichmentVersions); err != nil {
+			return nil, fmt.Errorf("invalid enrichment versions: %w", err)
+		}
+	}
+
+	return &alert, nil
+}
+
+// scanSQLiteAlerts drains rows into a slice of alerts
+func scanSQLiteAlerts(rows *sql.Rows) ([]models.Alert, error) {
+	var alerts []models.Alert
+	for rows.Next() {
+		alert, err := scanSQLiteAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning alert: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// sqliteInsertAlert inserts one alert with every column, skipping it on any
+// unique conflict
+var sqliteInsertAlert = "INSERT INTO alerts (" + strings.Join(restoreAlertColumns, ", ") + ") VALUES (" +
+	strings.TrimSuffix(strings.Repeat("?, ", len(restoreAlertColumns)), ", ") + ") ON CONFLICT DO NOTHING"
+
+// insertSQLiteAlert runs sqliteInsertAlert and reports whether the alert was stored
+func insertSQLiteAlert(ctx context.Context, stmt *sql.Stmt, alert *models.Alert) (bool, error) {
+	result, err := stmt.ExecContext(ctx,
+		alert.ID,
+		alert.Source,
+		alert.Severity,
+		alert.Description,
+		alert.WholeEvent,
+		alert.EnrichmentType,
+		alert.IPAddress,
+		encodeEnrichmentVersions(alert.EnrichmentVersions),
+		nullFingerprint(alert.Fingerprint),
+		alert.Status,
+		toMicrosPtr(alert.AcknowledgedAt),
+		toMicrosPtr(alert.ResolvedAt),
+		toMicros(alert.CreatedAt),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// insertAlerts inserts alerts inside one transaction and returns which were stored
+func (s *SQLiteAlertStorage) insertAlerts(ctx context.Context, alerts []models.Alert) ([]bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting alert batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, sqliteInsertAlert)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing alert insert: %w", err)
+	}
+	defer stmt.Close()
+
+	inserted := make([]bool, len(alerts))
+	for i := range alerts {
+		if inserted[i], err = insertSQLiteAlert(ctx, stmt, &alerts[i]); err != nil {
+			return nil, fmt.Errorf("error creating alerts: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing alert batch: %w", err)
+	}
+	return inserted, nil
+}
+
+// CreateAlert inserts a new alert. The generated ID and initial status are
+// written back into alert; an alert whose fingerprint is already stored is
+// skipped and keeps an empty ID.
+func (s *SQLiteAlertStorage) CreateAlert(ctx context.Context, alert *models.Alert) error {
+	alerts := []models.Alert{*alert}
+	if err := s.CreateAlerts(ctx, alerts); err != nil {
+		return err
+	}
+	alert.ID, alert.Status = alerts[0].ID, alerts[0].Status
+	return nil
+}
+
+// CreateAlerts inserts alerts inside one transaction, so either every alert
+// is stored or none is. The IDs and initial status are written back into
+// alerts after the commit; alerts whose fingerprint is already stored are
+// skipped and keep an empty ID.
+func (s *SQLiteAlertStorage) CreateAlerts(ctx context.Context, alerts []models.Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	rows := make([]models.Alert, len(alerts))
+	for i, alert := range alerts {
+		rows[i] = alert
+		rows[i].ID = newAlertID()
+		rows[i].Status = models.StatusOpen
+		rows[i].AcknowledgedAt, rows[i].ResolvedAt = nil, nil
+	}
+
+	inserted, err := s.insertAlerts(ctx, rows)
+	if err != nil {
+		return err
+	}
+
+	for i := range alerts {
+		if inserted[i] {
+			alerts[i].ID = rows[i].ID
+			alerts[i].Status = models.StatusOpen
+		}
+	}
+	return nil
+}
+
+// RestoreAlerts inserts archived alerts as they were stored, keeping their
+// IDs and lifecycle, inside one transaction. Alerts that are already stored
+// are skipped. Returns the number inserted.
+func (s *SQLiteAlertStorage) RestoreAlerts(ctx context.Context, alerts []models.Alert) (int, error) {
+	if len(alerts) == 0 {
+		return 0, nil
+	}
+
+	inserted, err := s.insertAlerts(ctx, alerts)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, ok := range inserted {
+		if ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetAlerts retrieves all alerts, newest first
+func (s *SQLiteAlertStorage) GetAlerts(ctx context.Context) ([]models.Alert, error) {
+	return s.ListAlerts(ctx, models.AlertFilter{})
+}
+
+// sqliteFilterClause builds a WHERE clause and its arguments for a filter
+func sqliteFilterClause(filter models.AlertFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Days > 0 {
+		args = append(args, toMicros(time.Now().AddDate(0, 0, -filter.Days)))
+		conditions = append(conditions, "created_at >= ?")
+	}
+	if !filter.From.IsZero() {
+		args = append(args, toMicros(filter.From))
+		conditions = append(conditions, "created_at >= ?")
+	}
+	if !filter.To.IsZero() {
+		args = append(args, toMicros(filter.To))
+		conditions = append(conditions, "created_at < ?")
+	}
+
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		for _, v := range values {
+			args = append(args, v)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		conditions = append(conditions, fmt.Sprintf("%s IN (%s)", column, placeholders))
+	}
+	in("source", filter.Sources)
+	in("severity", filter.Severities)
+	in("status", filter.Statuses)
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "\n\t\tWHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ListAlerts retrieves alerts matching the filter, newest first
+func (s *SQLiteAlertStorage) ListAlerts(ctx context.Context, filter models.AlertFilter) ([]models.Alert, error) {
+	where, args := sqliteFilterClause(filter)
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts` + where + `
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying alerts: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSQLiteAlerts(rows)
+}
+
+// ListAlertsAfter retrieves up to limit alerts matching the filter, oldest
+// first, that come after the alert with the given created_at and ID. An
+// empty afterID starts from the oldest alert.
+func (s *SQLiteAlertStorage) ListAlertsAfter(ctx context.Context, filter models.AlertFilter, afterCreatedAt time.Time, afterID string, limit int) ([]models.Alert, error) {
+	where, args := sqliteFilterClause(filter)
+	if afterID != "" {
+		args = append(args, toMicros(afterCreatedAt), afterID)
+		condition := "(created_at, id) > (?, ?)"
+		if where == "" {
+			where = "\n\t\tWHERE " + condition
+		} else {
+			where += " AND " + condition
+		}
+	}
+	args = append(args, limit)
+
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts` + where + `
+		ORDER BY created_at, id
+		LIMIT ?`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying alerts: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSQLiteAlerts(rows)
+}
+
+// CountAlerts counts the alerts matching the filter
+func (s *SQLiteAlertStorage) CountAlerts(ctx context.Context, filter models.AlertFilter) (int, error) {
+	where, args := sqliteFilterClause(filter)
+	query := `
+		SELECT COUNT(*)
+		FROM alerts` + where
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting alerts: %w", err)
+	}
+
+	return count, nil
+}
+
+// UpdateAlertEnrichment writes an alert's enrichment fields and their versions
+func (s *SQLiteAlertStorage) UpdateAlertEnrichment(ctx context.Context, alert *models.Alert) error {
+	query := `
+		UPDATE alerts
+		SET enrichment_type = ?, ip_address = ?, enrichment_versions = ?
+		WHERE id = ?
+	`
+
+	result, err := s.db.ExecContext(ctx, query, alert.EnrichmentType, alert.IPAddress, encodeEnrichmentVersions(alert.EnrichmentVersions), alert.ID)
+	if err != nil {
+		return fmt.Errorf("error updating alert enrichment: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("alert not found")
+	}
+
+	return nil
+}
+
+// GetAlertByID retrieves a single alert by ID.
+// Returns nil without error when there is none.
+func (s *SQLiteAlertStorage) GetAlertByID(ctx context.Context, id string) (*models.Alert, error) {
+	query := `
+		SELECT ` + alertColumns + `
+		FROM alerts
+		WHERE id = ?
+	`
+
+	alert, err := scanSQLiteAlert(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying alert: %w", err)
+	}
+
+	return alert, nil
+}
+
+// AlertExists reports whether an alert with the given fingerprint is stored
+func (s *SQLiteAlertStorage) AlertExists(ctx context.Context, fingerprint string) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM alerts WHERE fingerprint = ?)
+	`
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, query, fingerprint).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error checking alert fingerprint: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetAlertsByDays retrieves alerts from the last X days
+func (s *SQLiteAlertStorage) GetAlertsByDays(ctx context.Context, days int) ([]models.Alert, error) {
+	alerts, err := s.ListAlerts(ctx, models.AlertFilter{Days: days})
+	if err != nil {
+		return nil, fmt.Errorf("error querying alerts by days: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// UpdateAlertStatus moves an alert in one of the from statuses to status and
+// stamps the matching acknowledged_at/resolved_at column. Returns nil without
+// error when the alert does not exist or is in another status.
+func (s *SQLiteAlertStorage) UpdateAlertStatus(ctx context.Context, id string, from []string, status string) (*models.Alert, error) {
+	if len(from) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{status, toMicros(time.Now()), id}
+	placeholders := make([]string, len(from))
+	for i, f := range from {
+		args = append(args, f)
+		placeholders[i] = fmt.Sprintf("?%d", len(args))
+	}
+	query := `
+		UPDATE alerts
+		SET status = ?1,
+		    acknowledged_at = CASE WHEN ?1 = 'acknowledged' THEN ?2 ELSE acknowledged_at END,
+		    resolved_at = CASE WHEN ?1 = 'resolved' THEN ?2 ELSE resolved_at END
+		WHERE id = ?3 AND status IN (` + strings.Join(placeholders, ", ") + `)
+		RETURNING ` + alertColumns
+
+	alert, err := scanSQLiteAlert(s.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating alert status: %w", err)
+	}
+
+	return alert, nil
+}
+
+// GetLastSyncTime returns the created_at of the newest alert
+func (s *SQLiteAlertStorage) GetLastSyncTime(ctx context.Context) (time.Time, error) {
+	query := `
+		SELECT MAX(created_at) FROM alerts
+	`
+
+	var lastSync sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, query).Scan(&lastSync); err != nil {
+		return time.Time{}, fmt.Errorf("error querying last sync time: %w", err)
+	}
+
+	if !lastSync.Valid {
+		return time.Time{}, nil
+	}
+
+	return time.UnixMicro(lastSync.Int64).UTC(), nil
+}
+
+// UpdateLastSyncTime is a no-op; as with AlertStorage, the sync time is the newest alert
+func (s *SQLiteAlertStorage) UpdateLastSyncTime(ctx context.Context, t time.Time) error {
+	return nil
+}
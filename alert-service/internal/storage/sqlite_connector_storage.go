@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"censys_alert_system/internal/models"
+)
+
+// SQLiteConnectorStorage keeps connector watermarks and health in the
+// connector_state table of a SQLiteAlertStorage database
+type SQLiteConnectorStorage struct {
+	db *sql.DB
+}
+
+// ConnectorStorage returns the connector state storage that shares this database
+func (s *SQLiteAlertStorage) ConnectorStorage() *SQLiteConnectorStorage {
+	return &SQLiteConnectorStorage{db: s.db}
+}
+
+// GetConnectorState returns the saved state of a connector.
+// Returns nil without error when the connector has never run.
+func (s *SQLiteConnectorStorage) GetConnectorState(ctx context.Context, name string) (*models.ConnectorState, error) {
+	query := `SELECT ` + connectorStateColumns + ` FROM connector_state WHERE name = ?`
+
+	var state models.ConnectorState
+	var watermark, lastAttempt, lastSuccess sql.NullInt64
+	var updatedAt int64
+	err := s.db.QueryRowContext(ctx, query, name).Scan(
+		&state.Name,
+		&watermark,
+		&state.Cursor,
+		&lastAttempt,
+		&lastSuccess,
+		&state.LastError,
+		&state.ConsecutiveFailures,
+		&updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting connector state: %w", err)
+	}
+
+	state.Watermark = fromMicrosPtr(watermark)
+	state.LastAttemptAt = fromMicrosPtr(lastAttempt)
+	state.LastSuccessAt = fromMicrosPtr(lastSuccess)
+	state.UpdatedAt = time.UnixMicro(updatedAt).UTC()
+	return &state, nil
+}
+
+// SaveConnectorState inserts or replaces the state of a connector
+func (s *SQLiteConnectorStorage) SaveConnectorState(ctx context.Context, state *models.ConnectorState) error {
+	query := `
+		INSERT INTO connector_state (name, watermark, page_cursor, last_attempt_at, last_success_at, last_error, consecutive_failures, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			watermark = excluded.watermark,
+			page_cursor = excluded.page_cursor,
+			last_attempt_at = excluded.last_attempt_at,
+			last_success_at = excluded.last_success_at,
+			last_error = excluded.last_error,
+			consecutive_failures = excluded.consecutive_failures,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		state.Name,
+		toMicrosPtr(state.Watermark),
+		state.Cursor,
+		toMicrosPtr(state.LastAttemptAt),
+		toMicrosPtr(state.LastSuccessAt),
+		state.LastError,
+		state.ConsecutiveFailures,
+		toMicros(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving connector state: %w", err)
+	}
+
+	return nil
+}
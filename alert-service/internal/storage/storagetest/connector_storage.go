@@ -0,0 +1,100 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewConnectorStorage returns an empty connector storage for one test
+type NewConnectorStorage func(t *testing.T) service.ConnectorStorageInterface
+
+// RunConnectorStorageTests runs the conformance suite for connector state
+// against the storage returned by newStorage
+func RunConnectorStorageTests(t *testing.T, newStorage NewConnectorStorage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s service.ConnectorStorageInterface)
+	}{
+		{"GetConnectorStateNeverRun", testGetConnectorStateNeverRun},
+		{"SaveConnectorState", testSaveConnectorState},
+		{"SaveConnectorStateReplaces", testSaveConnectorStateReplaces},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+func testGetConnectorStateNeverRun(t *testing.T, s service.ConnectorStorageInterface) {
+	state, err := s.GetConnectorState(context.Background(), "siem")
+
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func testSaveConnectorState(t *testing.T, s service.ConnectorStorageInterface) {
+	ctx := context.Background()
+	watermark := base.Add(123456 * time.Microsecond)
+	attempt := base.Add(time.Minute)
+
+	require.NoError(t, s.SaveConnectorState(ctx, &models.ConnectorState{
+		Name:                "siem",
+		Watermark:           &watermark,
+		Cursor:              "c2",
+		LastAttemptAt:       &attempt,
+		LastError:           "timeout",
+		ConsecutiveFailures: 2,
+	}))
+	require.NoError(t, s.SaveConnectorState(ctx, &models.ConnectorState{Name: "edr"}))
+
+	state, err := s.GetConnectorState(ctx, "siem")
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, "siem", state.Name)
+	require.NotNil(t, state.Watermark)
+	assert.True(t, watermark.Equal(*state.Watermark), "watermark %s, want %s", state.Watermark, watermark)
+	assert.Equal(t, "c2", state.Cursor)
+	require.NotNil(t, state.LastAttemptAt)
+	assert.True(t, attempt.Equal(*state.LastAttemptAt))
+	assert.Nil(t, state.LastSuccessAt)
+	assert.Equal(t, "timeout", state.LastError)
+	assert.Equal(t, 2, state.ConsecutiveFailures)
+	assert.False(t, state.UpdatedAt.IsZero())
+
+	other, err := s.GetConnectorState(ctx, "edr")
+	require.NoError(t, err)
+	require.NotNil(t, other)
+	assert.Nil(t, other.Watermark, "each connector keeps its own watermark")
+}
+
+func testSaveConnectorStateReplaces(t *testing.T, s service.ConnectorStorageInterface) {
+	ctx := context.Background()
+	first := base
+	second := base.Add(time.Hour)
+	success := base.Add(time.Hour)
+
+	state := &models.ConnectorState{Name: "siem", Watermark: &first, Cursor: "c2", LastError: "timeout", ConsecutiveFailures: 1}
+	require.NoError(t, s.SaveConnectorState(ctx, state))
+	require.NoError(t, s.SaveConnectorState(ctx, &models.ConnectorState{Name: "siem", Watermark: &second, LastSuccessAt: &success}))
+
+	// Changing the saved value afterwards does not reach the stored state
+	first = base.Add(-time.Hour)
+
+	got, err := s.GetConnectorState(ctx, "siem")
+	require.NoError(t, err)
+	require.NotNil(t, got.Watermark)
+	assert.True(t, second.Equal(*got.Watermark))
+	assert.Empty(t, got.Cursor)
+	assert.Empty(t, got.LastError)
+	assert.Zero(t, got.ConsecutiveFailures)
+	require.NotNil(t, got.LastSuccessAt)
+	assert.True(t, success.Equal(*got.LastSuccessAt))
+}
@@ -0,0 +1,398 @@
+// Package storagetest is the conformance suite for implementations of
+// service.AlertStorageInterface and service.ConnectorStorageInterface. Every
+// backend runs the same tests, so the service behaves the same whichever one
+// is configured.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"censys_alert_system/internal/models"
+	"censys_alert_system/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewStorage returns an empty storage for one test
+type NewStorage func(t *testing.T) service.AlertStorageInterface
+
+// RunAlertStorageTests runs the conformance suite against the storage
+// returned by newStorage
+func RunAlertStorageTests(t *testing.T, newStorage NewStorage) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s service.AlertStorageInterface)
+	}{
+		{"CreateAlert", testCreateAlert},
+		{"CreateAlertSkipsDuplicate", testCreateAlertSkipsDuplicate},
+		{"CreateAlerts", testCreateAlerts},
+		{"RestoreAlerts", testRestoreAlerts},
+		{"ListAlerts", testListAlerts},
+		{"ListAlertsTies", testListAlertsTies},
+		{"ListAlertsAfter", testListAlertsAfter},
+		{"CountAlerts", testCountAlerts},
+		{"UpdateAlertEnrichment", testUpdateAlertEnrichment},
+		{"GetAlertByIDNotFound", testGetAlertByIDNotFound},
+		{"AlertExists", testAlertExists},
+		{"GetAlertsByDays", testGetAlertsByDays},
+		{"UpdateAlertStatus", testUpdateAlertStatus},
+		{"GetLastSyncTime", testGetLastSyncTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+// base is the created_at of test alerts; recent, so filters by days include it
+var base = time.Now().UTC().Truncate(time.Second).Add(-time.Hour)
+
+func newAlert(source, severity string, createdAt time.Time) models.Alert {
+	description := fmt.Sprintf("%s %s alert at %s", source, severity, createdAt.Format(time.RFC3339Nano))
+	return models.Alert{
+		Source:      source,
+		Severity:    severity,
+		Description: description,
+		WholeEvent:  []byte(`{"source": "` + source + `"}`),
+		Fingerprint: models.Fingerprint(source, severity, description, createdAt),
+		CreatedAt:   createdAt,
+	}
+}
+
+func create(t *testing.T, s service.AlertStorageInterface, alerts ...models.Alert) []models.Alert {
+	require.NoError(t, s.CreateAlerts(context.Background(), alerts))
+	for _, alert := range alerts {
+		require.NotEmpty(t, alert.ID)
+	}
+	return alerts
+}
+
+func ids(alerts []models.Alert) []string {
+	result := make([]string, len(alerts))
+	for i, alert := range alerts {
+		result[i] = alert.ID
+	}
+	return result
+}
+
+func testCreateAlert(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	enrichment, ip := "threat_intel", "10.0.0.1"
+	alert := newAlert("siem", "high", base)
+	alert.EnrichmentType, alert.IPAddress = &enrichment, &ip
+	alert.EnrichmentVersions = map[string]int{"enrichment_type": 2}
+
+	require.NoError(t, s.CreateAlert(ctx, &alert))
+	require.NotEmpty(t, alert.ID)
+	assert.Equal(t, models.StatusOpen, alert.Status)
+
+	got, err := s.GetAlertByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alert.ID, got.ID)
+	assert.Equal(t, "siem", got.Source)
+	assert.Equal(t, "high", got.Severity)
+	assert.Equal(t, alert.Description, got.Description)
+	assert.JSONEq(t, string(alert.WholeEvent), string(got.WholeEvent))
+	assert.Equal(t, &enrichment, got.EnrichmentType)
+	assert.Equal(t, &ip, got.IPAddress)
+	assert.Equal(t, map[string]int{"enrichment_type": 2}, got.EnrichmentVersions)
+	assert.Equal(t, alert.Fingerprint, got.Fingerprint)
+	assert.Equal(t, models.StatusOpen, got.Status)
+	assert.Nil(t, got.AcknowledgedAt)
+	assert.Nil(t, got.ResolvedAt)
+	assert.True(t, base.Equal(got.CreatedAt), "created_at %s, want %s", got.CreatedAt, base)
+}
+
+func testCreateAlertSkipsDuplicate(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	first := newAlert("siem", "high", base)
+	duplicate := first
+
+	require.NoError(t, s.CreateAlert(ctx, &first))
+	require.NoError(t, s.CreateAlert(ctx, &duplicate))
+
+	assert.NotEmpty(t, first.ID)
+	assert.Empty(t, duplicate.ID)
+	count, err := s.CountAlerts(ctx, models.AlertFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func testCreateAlerts(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	stored := newAlert("siem", "high", base)
+	require.NoError(t, s.CreateAlert(ctx, &stored))
+
+	batch := []models.Alert{
+		newAlert("siem", "low", base),
+		newAlert("siem", "high", base), // already stored
+		newAlert("edr", "medium", base.Add(time.Minute)),
+	}
+	require.NoError(t, s.CreateAlerts(ctx, batch))
+
+	assert.NotEmpty(t, batch[0].ID)
+	assert.Equal(t, models.StatusOpen, batch[0].Status)
+	assert.Empty(t, batch[1].ID)
+	assert.NotEmpty(t, batch[2].ID)
+	assert.NotEqual(t, batch[0].ID, batch[2].ID)
+
+	count, err := s.CountAlerts(ctx, models.AlertFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	assert.NoError(t, s.CreateAlerts(ctx, nil))
+}
+
+func testRestoreAlerts(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	resolvedAt := base.Add(time.Hour)
+	archived := newAlert("siem", "high", base)
+	archived.ID = models.NewAlertID()
+	archived.Status = models.StatusResolved
+	archived.ResolvedAt = &resolvedAt
+
+	inserted, err := s.RestoreAlerts(ctx, []models.Alert{archived})
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserted)
+
+	got, err := s.GetAlertByID(ctx, archived.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusResolved, got.Status)
+	require.NotNil(t, got.ResolvedAt)
+	assert.True(t, resolvedAt.Equal(*got.ResolvedAt))
+
+	again := newAlert("edr", "low", base)
+	again.ID, again.Status = models.NewAlertID(), models.StatusOpen
+	inserted, err = s.RestoreAlerts(ctx, []models.Alert{archived, again})
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserted, "an alert already stored is skipped")
+
+	repeated := newAlert("ids", "medium", base)
+	repeated.ID, repeated.Status = models.NewAlertID(), models.StatusOpen
+	repeat := repeated
+	repeat.ID = models.NewAlertID()
+	inserted, err = s.RestoreAlerts(ctx, []models.Alert{repeated, repeat})
+	require.NoError(t, err)
+	assert.Equal(t, 1, inserted, "a fingerprint repeated within the call is stored once")
+}
+
+func testListAlerts(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	alerts := create(t, s,
+		newAlert("siem", "high", base),
+		newAlert("edr", "low", base.Add(time.Minute)),
+		newAlert("siem", "low", base.Add(2*time.Minute)),
+	)
+
+	all, err := s.ListAlerts(ctx, models.AlertFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{alerts[2].ID, alerts[1].ID, alerts[0].ID}, ids(all), "newest first")
+
+	siemLow, err := s.ListAlerts(ctx, models.AlertFilter{Sources: []string{"siem"}, Severities: []string{"low"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{alerts[2].ID}, ids(siemLow))
+
+	window, err := s.ListAlerts(ctx, models.AlertFilter{From: base.Add(time.Minute), To: base.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{alerts[1].ID}, ids(window), "from is inclusive, to exclusive")
+
+	_, err = s.UpdateAlertStatus(ctx, alerts[0].ID, []string{models.StatusOpen}, models.StatusResolved)
+	require.NoError(t, err)
+	resolved, err := s.ListAlerts(ctx, models.AlertFilter{Statuses: []string{models.StatusResolved}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{alerts[0].ID}, ids(resolved))
+
+	all, err = s.GetAlerts(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func testListAlertsTies(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	alerts := create(t, s,
+		newAlert("siem", "high", base),
+		newAlert("edr", "high", base),
+		newAlert("ids", "high", base),
+	)
+	want := ids(alerts)
+	slices.Sort(want)
+	slices.Reverse(want)
+
+	all, err := s.ListAlerts(ctx, models.AlertFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, want, ids(all), "alerts sharing a created_at are ordered by descending ID")
+}
+
+func testListAlertsAfter(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	// Alerts sharing a created_at are ordered by ID
+	create(t, s,
+		newAlert("siem", "high", base),
+		newAlert("siem", "low", base),
+		newAlert("edr", "high", base),
+		newAlert("edr", "low", base.Add(time.Second)),
+		newAlert("siem", "medium", base.Add(2*time.Second)),
+	)
+	want, err := s.ListAlerts(ctx, models.AlertFilter{})
+	require.NoError(t, err)
+
+	var pages [][]string
+	var seen []models.Alert
+	var after time.Time
+	var afterID string
+	for {
+		page, err := s.ListAlertsAfter(ctx, models.AlertFilter{}, after, afterID, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, ids(page))
+		seen = append(seen, page...)
+		last := page[len(page)-1]
+		after, afterID = last.CreatedAt, last.ID
+	}
+
+	assert.Len(t, pages, 3)
+	require.Len(t, seen, 5)
+	for i := 1; i < len(seen); i++ {
+		prev, cur := seen[i-1], seen[i]
+		assert.True(t, prev.CreatedAt.Before(cur.CreatedAt) || prev.CreatedAt.Equal(cur.CreatedAt) && prev.ID < cur.ID,
+			"alert %d is not after alert %d", i, i-1)
+	}
+	assert.ElementsMatch(t, ids(want), ids(seen))
+
+	edr, err := s.ListAlertsAfter(ctx, models.AlertFilter{Sources: []string{"edr"}}, time.Time{}, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, edr, 2)
+}
+
+func testCountAlerts(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	create(t, s,
+		newAlert("siem", "high", base),
+		newAlert("edr", "high", base),
+		newAlert("siem", "low", base.AddDate(0, 0, -10)),
+	)
+
+	count, err := s.CountAlerts(ctx, models.AlertFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	count, err = s.CountAlerts(ctx, models.AlertFilter{Severities: []string{"high"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = s.CountAlerts(ctx, models.AlertFilter{Sources: []string{"siem"}, Days: 7})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func testUpdateAlertEnrichment(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	alert := create(t, s, newAlert("siem", "high", base))[0]
+
+	enrichment, ip := "geo", "192.168.1.1"
+	alert.EnrichmentType, alert.IPAddress = &enrichment, &ip
+	alert.EnrichmentVersions = map[string]int{"enrichment_type": 3, "ip_address": 1}
+	require.NoError(t, s.UpdateAlertEnrichment(ctx, &alert))
+
+	got, err := s.GetAlertByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &enrichment, got.EnrichmentType)
+	assert.Equal(t, &ip, got.IPAddress)
+	assert.Equal(t, alert.EnrichmentVersions, got.EnrichmentVersions)
+
+	missing := models.Alert{ID: models.NewAlertID()}
+	assert.ErrorContains(t, s.UpdateAlertEnrichment(ctx, &missing), "alert not found")
+}
+
+func testGetAlertByIDNotFound(t *testing.T, s service.AlertStorageInterface) {
+	alert, err := s.GetAlertByID(context.Background(), models.NewAlertID())
+	require.NoError(t, err)
+	assert.Nil(t, alert)
+}
+
+func testAlertExists(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	alert := create(t, s, newAlert("siem", "high", base))[0]
+
+	exists, err := s.AlertExists(ctx, alert.Fingerprint)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = s.AlertExists(ctx, "unknown")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testGetAlertsByDays(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	alerts := create(t, s,
+		newAlert("siem", "high", base),
+		newAlert("siem", "low", base.AddDate(0, 0, -10)),
+	)
+
+	recent, err := s.GetAlertsByDays(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, []string{alerts[0].ID}, ids(recent))
+}
+
+func testUpdateAlertStatus(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+	alert := create(t, s, newAlert("siem", "high", base))[0]
+	before := time.Now().Add(-time.Minute)
+
+	acknowledged, err := s.UpdateAlertStatus(ctx, alert.ID, []string{models.StatusOpen}, models.StatusAcknowledged)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusAcknowledged, acknowledged.Status)
+	require.NotNil(t, acknowledged.AcknowledgedAt)
+	assert.True(t, acknowledged.AcknowledgedAt.After(before))
+	assert.Nil(t, acknowledged.ResolvedAt)
+
+	resolved, err := s.UpdateAlertStatus(ctx, alert.ID, []string{models.StatusOpen, models.StatusAcknowledged}, models.StatusResolved)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusResolved, resolved.Status)
+	require.NotNil(t, resolved.ResolvedAt)
+	require.NotNil(t, resolved.AcknowledgedAt, "acknowledged_at is kept")
+	assert.True(t, acknowledged.AcknowledgedAt.Equal(*resolved.AcknowledgedAt))
+
+	got, err := s.GetAlertByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusResolved, got.Status)
+
+	again, err := s.UpdateAlertStatus(ctx, alert.ID, []string{models.StatusOpen}, models.StatusAcknowledged)
+	require.NoError(t, err)
+	assert.Nil(t, again, "a resolved alert is not in an allowed status")
+	got, err = s.GetAlertByID(ctx, alert.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusResolved, got.Status, "a refused change leaves the alert as it was")
+	assert.True(t, acknowledged.AcknowledgedAt.Equal(*got.AcknowledgedAt))
+
+	missing, err := s.UpdateAlertStatus(ctx, models.NewAlertID(), []string{models.StatusOpen}, models.StatusResolved)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func testGetLastSyncTime(t *testing.T, s service.AlertStorageInterface) {
+	ctx := context.Background()
+
+	last, err := s.GetLastSyncTime(ctx)
+	require.NoError(t, err)
+	assert.True(t, last.IsZero())
+
+	create(t, s,
+		newAlert("siem", "high", base),
+		newAlert("siem", "low", base.Add(time.Minute)),
+	)
+	require.NoError(t, s.UpdateLastSyncTime(ctx, time.Now()))
+
+	last, err = s.GetLastSyncTime(ctx)
+	require.NoError(t, err)
+	assert.True(t, base.Add(time.Minute).Equal(last), "last sync %s, want %s", last, base.Add(time.Minute))
+}
@@ -0,0 +1,85 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxMessageSize caps a single framed message
+const MaxMessageSize = 64 * 1024
+
+// ErrMessageTooLarge is returned for frames larger than MaxMessageSize
+var ErrMessageTooLarge = errors.New("syslog: message too large")
+
+// ReadFrame reads one message from a TCP stream. Per RFC 6587, a frame that
+// starts with a digit uses octet counting ("LEN SP MSG"); anything else is
+// delimited by a trailing newline.
+func ReadFrame(r *bufio.Reader) ([]byte, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] >= '0' && first[0] <= '9' {
+		return readOctetCounted(r)
+	}
+	return readNewlineDelimited(r)
+}
+
+func readOctetCounted(r *bufio.Reader) ([]byte, error) {
+	length := 0
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if c == ' ' {
+			break
+		}
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("syslog: invalid octet count")
+		}
+		length = length*10 + int(c-'0')
+		if length > MaxMessageSize {
+			return nil, ErrMessageTooLarge
+		}
+	}
+
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return frame, nil
+}
+
+func readNewlineDelimited(r *bufio.Reader) ([]byte, error) {
+	var frame []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		frame = append(frame, chunk...)
+		if len(frame) > MaxMessageSize {
+			return nil, ErrMessageTooLarge
+		}
+		if err == nil {
+			return bytes.TrimRight(frame, "\r\n"), nil
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		// A final message without a trailing newline is still delivered
+		if errors.Is(err, io.EOF) && len(frame) > 0 {
+			return bytes.TrimRight(frame, "\r"), nil
+		}
+		return nil, err
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
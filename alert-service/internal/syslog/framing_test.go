@@ -0,0 +1,61 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, input string) []string {
+	t.Helper()
+
+	reader := bufio.NewReader(strings.NewReader(input))
+	var frames []string
+	for {
+		frame, err := ReadFrame(reader)
+		if err == io.EOF {
+			return frames
+		}
+		require.NoError(t, err)
+		frames = append(frames, string(frame))
+	}
+}
+
+func TestReadFrame_OctetCounting(t *testing.T) {
+	frames := readAll(t, "11 <13>hello\nx5 <13>a")
+
+	assert.Equal(t, []string{"<13>hello\nx", "<13>a"}, frames)
+}
+
+func TestReadFrame_NewlineDelimited(t *testing.T) {
+	frames := readAll(t, "<13>first\r\n<13>second\n<13>last")
+
+	assert.Equal(t, []string{"<13>first", "<13>second", "<13>last"}, frames)
+}
+
+func TestReadFrame_MixedFraming(t *testing.T) {
+	frames := readAll(t, "<13>newline\n9 <13>octet<13>again\n")
+
+	assert.Equal(t, []string{"<13>newline", "<13>octet", "<13>again"}, frames)
+}
+
+func TestReadFrame_Errors(t *testing.T) {
+	t.Run("truncated octet-counted frame", func(t *testing.T) {
+		_, err := ReadFrame(bufio.NewReader(strings.NewReader("20 <13>short")))
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("oversized frame", func(t *testing.T) {
+		_, err := ReadFrame(bufio.NewReader(strings.NewReader("99999999 <13>x")))
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+	})
+
+	t.Run("oversized line", func(t *testing.T) {
+		_, err := ReadFrame(bufio.NewReader(strings.NewReader("<13>" + strings.Repeat("a", MaxMessageSize+1) + "\n")))
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+	})
+}
@@ -0,0 +1,144 @@
+package syslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// facilityNames are the RFC 5424 facility keywords accepted in rules
+var facilityNames = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"ntp": 12, "security": 13, "console": 14, "solaris-cron": 15,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// severityNames are the RFC 5424 severity keywords accepted in rules
+var severityNames = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3,
+	"warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// Rule maps a facility and a range of syslog severities onto an alert severity
+type Rule struct {
+	Facility    int // -1 matches any facility
+	MinSeverity int
+	MaxSeverity int
+	Level       string
+}
+
+// Rules is an ordered rule list; the first matching rule wins
+type Rules []Rule
+
+// ParseRules parses comma-separated "facility:severity=level" rules, where
+// facility is a keyword, a number or "*", and severity is a keyword, a number,
+// a "from-to" range or "*". Example: "auth:emerg-err=critical,*:warning=medium".
+func ParseRules(spec string, validLevel func(string) bool) (Rules, error) {
+	var rules Rules
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		selector, level, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("syslog: rule %q: missing level", item)
+		}
+		level = strings.TrimSpace(level)
+		if validLevel != nil && !validLevel(level) {
+			return nil, fmt.Errorf("syslog: rule %q: invalid level %q", item, level)
+		}
+
+		facilityPart, severityPart, ok := strings.Cut(selector, ":")
+		if !ok {
+			facilityPart, severityPart = "*", selector
+		}
+
+		facility, err := parseFacility(strings.TrimSpace(facilityPart))
+		if err != nil {
+			return nil, fmt.Errorf("syslog: rule %q: %w", item, err)
+		}
+		minSeverity, maxSeverity, err := parseSeverityRange(strings.TrimSpace(severityPart))
+		if err != nil {
+			return nil, fmt.Errorf("syslog: rule %q: %w", item, err)
+		}
+
+		rules = append(rules, Rule{
+			Facility:    facility,
+			MinSeverity: minSeverity,
+			MaxSeverity: maxSeverity,
+			Level:       level,
+		})
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("syslog: no mapping rules")
+	}
+	return rules, nil
+}
+
+// Map returns the alert severity for a message, or false if no rule matches
+func (r Rules) Map(facility, severity int) (string, bool) {
+	for _, rule := range r {
+		if rule.Facility >= 0 && rule.Facility != facility {
+			continue
+		}
+		if severity >= rule.MinSeverity && severity <= rule.MaxSeverity {
+			return rule.Level, true
+		}
+	}
+	return "", false
+}
+
+func parseFacility(s string) (int, error) {
+	if s == "*" || s == "" {
+		return -1, nil
+	}
+	if f, ok := facilityNames[strings.ToLower(s)]; ok {
+		return f, nil
+	}
+	f, err := strconv.Atoi(s)
+	if err != nil || f < 0 || f > 23 {
+		return 0, fmt.Errorf("invalid facility %q", s)
+	}
+	return f, nil
+}
+
+func parseSeverityRange(s string) (int, int, error) {
+	if s == "*" || s == "" {
+		return 0, 7, nil
+	}
+
+	from, to, isRange := strings.Cut(s, "-")
+	minSeverity, err := parseSeverity(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return minSeverity, minSeverity, nil
+	}
+
+	maxSeverity, err := parseSeverity(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if maxSeverity < minSeverity {
+		return 0, 0, fmt.Errorf("invalid severity range %q", s)
+	}
+	return minSeverity, maxSeverity, nil
+}
+
+func parseSeverity(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if v, ok := severityNames[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 || v > 7 {
+		return 0, fmt.Errorf("invalid severity %q", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,248 @@
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"censys_alert_system/internal/formats"
+)
+
+// Message formats
+const (
+	FormatRFC5424 = "rfc5424"
+	FormatRFC3164 = "rfc3164"
+)
+
+// nilValue is the RFC 5424 NILVALUE
+const nilValue = "-"
+
+// Message is a parsed syslog message in either format.
+// Fields absent from the wire format are left empty.
+type Message struct {
+	Format         string                       `json:"format"`
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Version        int                          `json:"version,omitempty"`
+	Timestamp      time.Time                    `json:"timestamp"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"app_name,omitempty"`
+	ProcID         string                       `json:"proc_id,omitempty"`
+	MsgID          string                       `json:"msg_id,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+	Message        string                       `json:"message"`
+}
+
+// Parse parses an RFC 5424 or RFC 3164 message. Messages whose header is
+// malformed are rejected; the legacy format is parsed leniently because
+// real-world RFC 3164 senders rarely follow it exactly.
+func Parse(data []byte) (*Message, error) {
+	data = bytes.TrimRight(data, "\r\n\x00")
+
+	pri, rest, err := parsePriority(data)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &Message{Facility: pri / 8, Severity: pri % 8}
+	if len(rest) >= 2 && rest[0] >= '1' && rest[0] <= '9' && (rest[1] == ' ' || (rest[1] >= '0' && rest[1] <= '9')) {
+		if err := parseRFC5424(msg, string(rest)); err == nil {
+			return msg, nil
+		}
+	}
+
+	parseRFC3164(msg, string(rest), time.Now())
+	return msg, nil
+}
+
+// parsePriority reads "<PRI>" and returns the value and the remaining bytes
+func parsePriority(data []byte) (int, []byte, error) {
+	if len(data) < 3 || data[0] != '<' {
+		return 0, nil, errors.New("syslog: missing priority")
+	}
+
+	end := bytes.IndexByte(data[:min(len(data), 5)], '>')
+	if end < 2 {
+		return 0, nil, errors.New("syslog: malformed priority")
+	}
+
+	pri, err := strconv.Atoi(string(data[1:end]))
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, nil, fmt.Errorf("syslog: invalid priority %q", data[1:end])
+	}
+
+	return pri, data[end+1:], nil
+}
+
+// parseRFC5424 parses "VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD [MSG]"
+func parseRFC5424(msg *Message, rest string) error {
+	fields := strings.SplitN(rest, " ", 7)
+	if len(fields) < 7 {
+		return errors.New("syslog: truncated RFC 5424 header")
+	}
+
+	version, err := strconv.Atoi(fields[0])
+	if err != nil || version < 1 {
+		return fmt.Errorf("syslog: invalid version %q", fields[0])
+	}
+
+	var timestamp time.Time
+	if fields[1] != nilValue {
+		timestamp, err = time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return fmt.Errorf("syslog: invalid timestamp %q", fields[1])
+		}
+	}
+
+	sd, text, err := parseStructuredData(fields[6])
+	if err != nil {
+		return err
+	}
+
+	msg.Format = FormatRFC5424
+	msg.Version = version
+	msg.Timestamp = timestamp
+	msg.Hostname = nilToEmpty(fields[2])
+	msg.AppName = nilToEmpty(fields[3])
+	msg.ProcID = nilToEmpty(fields[4])
+	msg.MsgID = nilToEmpty(fields[5])
+	msg.StructuredData = sd
+	msg.Message = strings.TrimPrefix(text, "\ufeff")
+	return nil
+}
+
+// parseStructuredData parses "-" or one or more [SD-ID PARAM="VALUE" ...] elements
+// and returns them together with the message text that follows
+func parseStructuredData(s string) (map[string]map[string]string, string, error) {
+	if s == nilValue || strings.HasPrefix(s, nilValue+" ") {
+		return nil, strings.TrimPrefix(strings.TrimPrefix(s, nilValue), " "), nil
+	}
+	if !strings.HasPrefix(s, "[") {
+		return nil, "", errors.New("syslog: invalid structured data")
+	}
+
+	sd := make(map[string]map[string]string)
+	i := 0
+	for i < len(s) && s[i] == '[' {
+		i++
+		idEnd := strings.IndexAny(s[i:], " ]")
+		if idEnd <= 0 {
+			return nil, "", errors.New("syslog: invalid structured data id")
+		}
+		id := s[i : i+idEnd]
+		params := make(map[string]string)
+		i += idEnd
+
+		for i < len(s) && s[i] == ' ' {
+			i++
+			eq := strings.IndexByte(s[i:], '=')
+			if eq <= 0 || i+eq+1 >= len(s) || s[i+eq+1] != '"' {
+				return nil, "", errors.New("syslog: invalid structured data param")
+			}
+			name := s[i : i+eq]
+			i += eq + 2
+
+			var value strings.Builder
+			for {
+				if i >= len(s) {
+					return nil, "", errors.New("syslog: unterminated structured data value")
+				}
+				c := s[i]
+				if c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\' || s[i+1] == ']') {
+					value.WriteByte(s[i+1])
+					i += 2
+					continue
+				}
+				i++
+				if c == '"' {
+					break
+				}
+				value.WriteByte(c)
+			}
+			params[name] = value.String()
+		}
+
+		if i >= len(s) || s[i] != ']' {
+			return nil, "", errors.New("syslog: unterminated structured data element")
+		}
+		i++
+		sd[id] = params
+	}
+
+	return sd, strings.TrimPrefix(s[i:], " "), nil
+}
+
+// parseRFC3164 parses "TIMESTAMP HOSTNAME TAG: MSG", falling back to treating
+// everything after an unparseable timestamp as the message
+func parseRFC3164(msg *Message, rest string, now time.Time) {
+	msg.Format = FormatRFC3164
+	msg.Timestamp = now
+
+	if ts, remaining, ok := parse3164Timestamp(rest, now); ok {
+		msg.Timestamp = ts
+		rest = remaining
+
+		// HOSTNAME is present when the next token is followed by a TAG
+		if sp := strings.IndexByte(rest, ' '); sp > 0 && !strings.ContainsAny(rest[:sp], ":[") {
+			msg.Hostname = rest[:sp]
+			rest = rest[sp+1:]
+		}
+	}
+
+	// TAG is up to 32 alphanumeric characters, optionally followed by [PID], then ":"
+	// CEF and LEEF records sent without a TAG are not mistaken for one
+	tagEnd := strings.IndexAny(rest, "[: ")
+	if tagEnd > 0 && tagEnd <= 32 && !formats.IsSecurityEvent(rest) {
+		tag := rest[:tagEnd]
+		remaining := rest[tagEnd:]
+		if strings.HasPrefix(remaining, "[") {
+			if pidEnd := strings.IndexByte(remaining, ']'); pidEnd > 0 {
+				msg.ProcID = remaining[1:pidEnd]
+				remaining = remaining[pidEnd+1:]
+			}
+		}
+		if strings.HasPrefix(remaining, ":") {
+			msg.AppName = tag
+			rest = strings.TrimPrefix(remaining[1:], " ")
+		}
+	}
+
+	msg.Message = rest
+}
+
+// parse3164Timestamp reads the "Mmm dd hh:mm:ss" header, or an RFC 3339
+// timestamp as sent by many modern daemons in the legacy format
+func parse3164Timestamp(s string, now time.Time) (time.Time, string, bool) {
+	if sp := strings.IndexByte(s, ' '); sp > 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, s[:sp]); err == nil {
+			return ts, s[sp+1:], true
+		}
+	}
+
+	const stampLen = len(time.Stamp)
+	if len(s) < stampLen {
+		return time.Time{}, s, false
+	}
+	ts, err := time.ParseInLocation(time.Stamp, s[:stampLen], now.Location())
+	if err != nil {
+		return time.Time{}, s, false
+	}
+
+	// The legacy format has no year; a date in the future belongs to last year
+	ts = ts.AddDate(now.Year(), 0, 0)
+	if ts.After(now.Add(24 * time.Hour)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+
+	return ts, strings.TrimPrefix(s[stampLen:], " "), true
+}
+
+func nilToEmpty(s string) string {
+	if s == nilValue {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_RFC5424(t *testing.T) {
+	t.Run("full message with structured data", func(t *testing.T) {
+		data := `<165>1 2024-01-15T10:30:00.123Z fw01.example.com firewall 1234 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication" eventID="1011"][meta seq="7"] Connection denied`
+
+		msg, err := Parse([]byte(data))
+		require.NoError(t, err)
+
+		assert.Equal(t, FormatRFC5424, msg.Format)
+		assert.Equal(t, 20, msg.Facility)
+		assert.Equal(t, 5, msg.Severity)
+		assert.Equal(t, 1, msg.Version)
+		assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 123000000, time.UTC), msg.Timestamp.UTC())
+		assert.Equal(t, "fw01.example.com", msg.Hostname)
+		assert.Equal(t, "firewall", msg.AppName)
+		assert.Equal(t, "1234", msg.ProcID)
+		assert.Equal(t, "ID47", msg.MsgID)
+		assert.Equal(t, `App"lication`, msg.StructuredData["exampleSDID@32473"]["eventSource"])
+		assert.Equal(t, "7", msg.StructuredData["meta"]["seq"])
+		assert.Equal(t, "Connection denied", msg.Message)
+	})
+
+	t.Run("nil values and BOM", func(t *testing.T) {
+		msg, err := Parse([]byte("<34>1 - - - - - - \ufeffsu root failed\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, FormatRFC5424, msg.Format)
+		assert.True(t, msg.Timestamp.IsZero())
+		assert.Empty(t, msg.Hostname)
+		assert.Nil(t, msg.StructuredData)
+		assert.Equal(t, "su root failed", msg.Message)
+	})
+}
+
+func TestParse_RFC3164(t *testing.T) {
+	t.Run("classic BSD header", func(t *testing.T) {
+		msg, err := Parse([]byte("<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8"))
+		require.NoError(t, err)
+
+		assert.Equal(t, FormatRFC3164, msg.Format)
+		assert.Equal(t, 4, msg.Facility)
+		assert.Equal(t, 2, msg.Severity)
+		assert.Equal(t, time.October, msg.Timestamp.Month())
+		assert.Equal(t, 11, msg.Timestamp.Day())
+		assert.Equal(t, "mymachine", msg.Hostname)
+		assert.Equal(t, "su", msg.AppName)
+		assert.Equal(t, "230", msg.ProcID)
+		assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", msg.Message)
+	})
+
+	t.Run("RFC 3339 timestamp", func(t *testing.T) {
+		msg, err := Parse([]byte("<13>2024-01-15T10:30:00Z host ids: Port scan detected"))
+		require.NoError(t, err)
+
+		assert.Equal(t, FormatRFC3164, msg.Format)
+		assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), msg.Timestamp.UTC())
+		assert.Equal(t, "host", msg.Hostname)
+		assert.Equal(t, "ids", msg.AppName)
+		assert.Equal(t, "Port scan detected", msg.Message)
+	})
+
+	t.Run("no header keeps whole text", func(t *testing.T) {
+		msg, err := Parse([]byte("<13>something happened here"))
+		require.NoError(t, err)
+
+		assert.Equal(t, FormatRFC3164, msg.Format)
+		assert.Empty(t, msg.AppName)
+		assert.Equal(t, "something happened here", msg.Message)
+	})
+}
+
+func TestParse_RejectsBadPriority(t *testing.T) {
+	for _, data := range []string{"", "no priority", "<>1 - - - - - -", "<192>hello", "<abc>hello"} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestParse3164Timestamp_RollsBackYear(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)
+
+	ts, rest, ok := parse3164Timestamp("Dec 31 23:59:00 host", now)
+
+	require.True(t, ok)
+	assert.Equal(t, 2023, ts.Year())
+	assert.Equal(t, "host", rest)
+}
+
+func TestRules(t *testing.T) {
+	isLevel := func(level string) bool {
+		return level == "low" || level == "medium" || level == "high" || level == "critical"
+	}
+
+	t.Run("first matching rule wins", func(t *testing.T) {
+		rules, err := ParseRules("auth:emerg-err=critical,*:emerg-crit=critical,*:err=high,*:warning=medium,*:*=low", isLevel)
+		require.NoError(t, err)
+
+		level, ok := rules.Map(4, 3)
+		assert.True(t, ok)
+		assert.Equal(t, "critical", level)
+
+		level, _ = rules.Map(1, 3)
+		assert.Equal(t, "high", level)
+
+		level, _ = rules.Map(16, 6)
+		assert.Equal(t, "low", level)
+	})
+
+	t.Run("numeric selectors", func(t *testing.T) {
+		rules, err := ParseRules("16:0-4=high", isLevel)
+		require.NoError(t, err)
+
+		level, ok := rules.Map(16, 4)
+		assert.True(t, ok)
+		assert.Equal(t, "high", level)
+
+		_, ok = rules.Map(16, 5)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, spec := range []string{"", "*:err", "*:err=urgent", "nope:err=high", "*:debug-emerg=low", "*:9=low"} {
+			_, err := ParseRules(spec, isLevel)
+			assert.Error(t, err, spec)
+		}
+	})
+}
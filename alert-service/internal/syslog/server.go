@@ -0,0 +1,351 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/formats"
+	"censys_alert_system/internal/metrics"
+	"censys_alert_system/internal/service"
+)
+
+// Transports
+const (
+	TransportUDP = "udp"
+	TransportTCP = "tcp"
+	TransportTLS = "tls"
+)
+
+const (
+	queueSize     = 4096
+	batchSize     = 100
+	flushInterval = time.Second
+	idleTimeout   = 5 * time.Minute
+)
+
+// Ingester is the part of AlertService the receiver feeds
+type Ingester interface {
+	IngestAlerts(ctx context.Context, channel string, alerts []external.ExternalAlert) (service.IngestResult, error)
+}
+
+// Config configures the receiver. Empty addresses disable that transport.
+type Config struct {
+	UDPAddr   string
+	TCPAddr   string
+	TLSAddr   string
+	TLSConfig *tls.Config
+
+	// DefaultSource is used when the message's APP-NAME is not a known source
+	DefaultSource string
+	Rules         Rules
+}
+
+type queuedAlert struct {
+	transport string
+	alert     external.ExternalAlert
+}
+
+// Server receives syslog over UDP, TCP and TLS and feeds parsed messages into
+// the ingestion pipeline. Messages are queued and ingested in batches so a
+// burst of syslog traffic never blocks the network readers.
+type Server struct {
+	cfg      Config
+	ingester Ingester
+	queue    chan queuedAlert
+
+	mu        sync.Mutex
+	listeners []io.Closer
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+}
+
+// NewServer creates a syslog receiver
+func NewServer(cfg Config, ingester Ingester) *Server {
+	return &Server{
+		cfg:      cfg,
+		ingester: ingester,
+		queue:    make(chan queuedAlert, queueSize),
+		conns:    make(map[net.Conn]struct{}),
+	}
+}
+
+// Run starts the configured listeners and blocks until ctx is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	if s.cfg.UDPAddr != "" {
+		conn, err := net.ListenPacket("udp", s.cfg.UDPAddr)
+		if err != nil {
+			return fmt.Errorf("syslog: listen udp %s: %w", s.cfg.UDPAddr, err)
+		}
+		s.track(conn)
+		log.Printf("[SYSLOG] Listening on udp %s", conn.LocalAddr())
+		s.wg.Add(1)
+		go s.serveUDP(conn)
+	}
+
+	if s.cfg.TCPAddr != "" {
+		ln, err := net.Listen("tcp", s.cfg.TCPAddr)
+		if err != nil {
+			s.close()
+			return fmt.Errorf("syslog: listen tcp %s: %w", s.cfg.TCPAddr, err)
+		}
+		s.track(ln)
+		log.Printf("[SYSLOG] Listening on tcp %s", ln.Addr())
+		s.wg.Add(1)
+		go s.serveStream(ln, TransportTCP)
+	}
+
+	if s.cfg.TLSAddr != "" {
+		if s.cfg.TLSConfig == nil {
+			s.close()
+			return errors.New("syslog: tls listener requires a certificate")
+		}
+		ln, err := tls.Listen("tcp", s.cfg.TLSAddr, s.cfg.TLSConfig)
+		if err != nil {
+			s.close()
+			return fmt.Errorf("syslog: listen tls %s: %w", s.cfg.TLSAddr, err)
+		}
+		s.track(ln)
+		log.Printf("[SYSLOG] Listening on tls %s", ln.Addr())
+		s.wg.Add(1)
+		go s.serveStream(ln, TransportTLS)
+	}
+
+	ingestDone := make(chan struct{})
+	go func() {
+		defer close(ingestDone)
+		s.ingestLoop(ctx)
+	}()
+
+	<-ctx.Done()
+	log.Println("[SYSLOG] Stopping receiver")
+	s.close()
+	s.wg.Wait()
+	<-ingestDone
+	return nil
+}
+
+func (s *Server) track(c io.Closer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, c)
+}
+
+// close shuts down listeners and open stream connections
+func (s *Server) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *Server) serveUDP(conn net.PacketConn) {
+	defer s.wg.Done()
+
+	buf := make([]byte, MaxMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("[SYSLOG] udp read error: %v", err)
+			}
+			return
+		}
+		s.handle(TransportUDP, buf[:n])
+	}
+}
+
+func (s *Server) serveStream(ln net.Listener, transport string) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("[SYSLOG] %s accept error: %v", transport, err)
+			}
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn, transport)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn, transport string) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+		frame, err := ReadFrame(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("[SYSLOG] %s %s: closing connection: %v", transport, conn.RemoteAddr(), err)
+			}
+			return
+		}
+		if len(frame) > 0 {
+			s.handle(transport, frame)
+		}
+	}
+}
+
+// handle parses and maps one message and queues it for ingestion
+func (s *Server) handle(transport string, data []byte) {
+	metrics.SyslogMessagesReceived.WithLabelValues(transport).Inc()
+
+	alert, err := s.toAlert(data)
+	if err != nil {
+		metrics.SyslogMessagesInvalid.WithLabelValues(transport).Inc()
+		log.Printf("[SYSLOG] %s: dropping message: %v", transport, err)
+		return
+	}
+
+	select {
+	case s.queue <- queuedAlert{transport: transport, alert: alert}:
+	default:
+		metrics.SyslogMessagesDropped.Inc()
+	}
+}
+
+// toAlert converts a raw syslog message into an ExternalAlert. The parsed
+// message is kept as the raw payload so the header fields land in whole_event.
+func (s *Server) toAlert(data []byte) (external.ExternalAlert, error) {
+	msg, err := Parse(data)
+	if err != nil {
+		return external.ExternalAlert{}, err
+	}
+
+	severity, ok := s.cfg.Rules.Map(msg.Facility, msg.Severity)
+	if !ok && !formats.IsSecurityEvent(msg.Message) {
+		return external.ExternalAlert{}, fmt.Errorf("no rule for facility %d severity %d", msg.Facility, msg.Severity)
+	}
+
+	source := s.cfg.DefaultSource
+	if service.IsValidSource(msg.AppName) {
+		source = msg.AppName
+	}
+
+	createdAt := msg.Timestamp.UTC()
+	if msg.Timestamp.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	if formats.IsSecurityEvent(msg.Message) {
+		return s.securityEventToAlert(msg, source, severity, createdAt)
+	}
+
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return external.ExternalAlert{}, err
+	}
+
+	return external.ExternalAlert{
+		Source:      source,
+		Severity:    severity,
+		Description: strings.TrimSpace(msg.Message),
+		CreatedAt:   createdAt,
+		Raw:         raw,
+	}, nil
+}
+
+// securityEventToAlert converts a CEF or LEEF payload carried in a syslog
+// message. The event's own severity and timestamp win over the syslog header;
+// the raw payload keeps both the syslog header and the parsed event.
+func (s *Server) securityEventToAlert(msg *Message, source, severity string, createdAt time.Time) (external.ExternalAlert, error) {
+	event, err := formats.Parse(msg.Message)
+	if err != nil {
+		return external.ExternalAlert{}, err
+	}
+
+	alert, err := event.ToExternalAlert(source, severity)
+	if err != nil {
+		return external.ExternalAlert{}, err
+	}
+	if alert.CreatedAt.IsZero() {
+		alert.CreatedAt = createdAt
+	}
+
+	header := *msg
+	header.Message = ""
+	alert.Raw, err = json.Marshal(map[string]interface{}{
+		"syslog": header,
+		"event":  json.RawMessage(alert.Raw),
+	})
+	if err != nil {
+		return external.ExternalAlert{}, err
+	}
+	return alert, nil
+}
+
+// ingestLoop batches queued alerts per transport and hands them to the ingester
+func (s *Server) ingestLoop(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batches := make(map[string][]external.ExternalAlert)
+	flush := func(transport string) {
+		if len(batches[transport]) == 0 {
+			return
+		}
+		// Ingestion outlives ctx so queued messages are stored during shutdown
+		if _, err := s.ingester.IngestAlerts(context.WithoutCancel(ctx), "syslog:"+transport, batches[transport]); err != nil {
+			log.Printf("[SYSLOG] %s: dropped the rest of a batch of %d: %v", transport, len(batches[transport]), err)
+		}
+		batches[transport] = nil
+	}
+	flushAll := func() {
+		for transport := range batches {
+			flush(transport)
+		}
+	}
+
+	for {
+		select {
+		case item := <-s.queue:
+			batches[item.transport] = append(batches[item.transport], item.alert)
+			if len(batches[item.transport]) >= batchSize {
+				flush(item.transport)
+			}
+		case <-ticker.C:
+			flushAll()
+		case <-ctx.Done():
+			// Drain what the listeners queued before they were closed
+			s.wg.Wait()
+			for {
+				select {
+				case item := <-s.queue:
+					batches[item.transport] = append(batches[item.transport], item.alert)
+				default:
+					flushAll()
+					return
+				}
+			}
+		}
+	}
+}
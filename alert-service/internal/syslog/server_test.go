@@ -0,0 +1,179 @@
+package syslog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"censys_alert_system/external"
+	"censys_alert_system/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingIngester struct {
+	mu       sync.Mutex
+	channels []string
+	alerts   []external.ExternalAlert
+}
+
+func (r *recordingIngester) IngestAlerts(ctx context.Context, channel string, alerts []external.ExternalAlert) (service.IngestResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.channels = append(r.channels, channel)
+	r.alerts = append(r.alerts, alerts...)
+	return service.IngestResult{Accepted: len(alerts)}, nil
+}
+
+func (r *recordingIngester) received() []external.ExternalAlert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]external.ExternalAlert(nil), r.alerts...)
+}
+
+func testRules(t *testing.T) Rules {
+	rules, err := ParseRules("*:emerg-crit=critical,*:err=high,*:warning=medium,*:notice-debug=low", service.IsValidSeverity)
+	require.NoError(t, err)
+	return rules
+}
+
+func TestServer_ToAlert(t *testing.T) {
+	server := NewServer(Config{DefaultSource: "network-monitor", Rules: testRules(t)}, &recordingIngester{})
+
+	t.Run("known app name becomes the source", func(t *testing.T) {
+		alert, err := server.toAlert([]byte("<10>1 2024-01-15T10:30:00Z fw01 firewall - - - Blocked inbound SSH"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "firewall", alert.Source)
+		assert.Equal(t, "critical", alert.Severity)
+		assert.Equal(t, "Blocked inbound SSH", alert.Description)
+		assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), alert.CreatedAt)
+
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(alert.Raw, &raw))
+		assert.Equal(t, "fw01", raw["hostname"])
+		assert.Equal(t, float64(1), raw["facility"])
+	})
+
+	t.Run("unknown app name falls back to default source", func(t *testing.T) {
+		alert, err := server.toAlert([]byte("<28>Oct 11 22:14:15 host sshd[1]: Disk usage high"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "network-monitor", alert.Source)
+		assert.Equal(t, "medium", alert.Severity)
+	})
+
+	t.Run("CEF payload uses the event severity and indicators", func(t *testing.T) {
+		alert, err := server.toAlert([]byte("<134>Jan 15 10:30:00 fw01 CEF:0|Vendor|NGFW|1.0|100|Blocked connection|9|src=192.0.2.10 dst=198.51.100.7 dpt=443"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "network-monitor", alert.Source)
+		assert.Equal(t, "critical", alert.Severity)
+		assert.Equal(t, "Blocked connection", alert.Description)
+		assert.Equal(t, time.January, alert.CreatedAt.Month())
+		assert.Equal(t, "192.0.2.10", alert.Indicators["src_ip"])
+		assert.Equal(t, "198.51.100.7", alert.Indicators["dst_ip"])
+
+		var raw struct {
+			Syslog Message `json:"syslog"`
+			Event  struct {
+				Extensions map[string]string `json:"extensions"`
+			} `json:"event"`
+		}
+		require.NoError(t, json.Unmarshal(alert.Raw, &raw))
+		assert.Equal(t, "fw01", raw.Syslog.Hostname)
+		assert.Equal(t, "443", raw.Event.Extensions["dpt"])
+	})
+
+	t.Run("LEEF payload without severity falls back to the rules", func(t *testing.T) {
+		alert, err := server.toAlert([]byte("<12>1 - host ids - - - LEEF:1.0|Vendor|IDS|1.0|77|src=10.0.0.1\tcat=scan"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "ids", alert.Source)
+		assert.Equal(t, "medium", alert.Severity)
+	})
+
+	t.Run("unmapped severity is rejected", func(t *testing.T) {
+		server := NewServer(Config{DefaultSource: "ids", Rules: Rules{{Facility: -1, MinSeverity: 0, MaxSeverity: 2, Level: "critical"}}}, &recordingIngester{})
+
+		_, err := server.toAlert([]byte("<14>hello"))
+		assert.Error(t, err)
+	})
+}
+
+func TestServer_ReceivesOverUDPAndTCP(t *testing.T) {
+	udpAddr := freeAddr(t, "udp")
+	tcpAddr := freeAddr(t, "tcp")
+	ingester := &recordingIngester{}
+	server := NewServer(Config{
+		UDPAddr:       udpAddr,
+		TCPAddr:       tcpAddr,
+		DefaultSource: "ids",
+		Rules:         testRules(t),
+	}, ingester)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx) }()
+
+	// The UDP listener is bound before TCP, so a TCP connection means both are up
+	tcpConn := dialWhenReady(t, "tcp", tcpAddr)
+	msg := "<12>Jan  2 03:04:05 host ids: octet message"
+	_, err := fmt.Fprintf(tcpConn, "<13>tcp newline message\n%d %s", len(msg), msg)
+	require.NoError(t, err)
+	tcpConn.Close()
+
+	udpConn, err := net.Dial("udp", udpAddr)
+	require.NoError(t, err)
+	_, err = udpConn.Write([]byte("<11>1 - - - - - - udp message"))
+	require.NoError(t, err)
+	udpConn.Close()
+
+	require.Eventually(t, func() bool { return len(ingester.received()) == 3 }, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	descriptions := map[string]string{}
+	for _, alert := range ingester.received() {
+		descriptions[alert.Description] = alert.Severity
+	}
+	assert.Equal(t, map[string]string{
+		"udp message":         "high",
+		"tcp newline message": "low",
+		"octet message":       "medium",
+	}, descriptions)
+}
+
+func freeAddr(t *testing.T, network string) string {
+	t.Helper()
+
+	if network == "udp" {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.LocalAddr().String()
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func dialWhenReady(t *testing.T, network, addr string) net.Conn {
+	t.Helper()
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial(network, addr)
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	return conn
+}
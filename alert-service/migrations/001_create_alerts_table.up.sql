@@ -0,0 +1,23 @@
+-- Enable UUID extension
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+-- Create alerts table
+CREATE TABLE IF NOT EXISTS alerts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    source VARCHAR(255) NOT NULL,
+    severity VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL,
+    whole_event BYTEA NOT NULL,
+    enrichment_type VARCHAR(100),
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- Create index on severity for faster queries
+CREATE INDEX IF NOT EXISTS idx_alerts_severity ON alerts(severity);
+
+-- Create index on created_at for faster sorting
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at ON alerts(created_at DESC);
+
+-- Create index on source
+CREATE INDEX IF NOT EXISTS idx_alerts_source ON alerts(source);
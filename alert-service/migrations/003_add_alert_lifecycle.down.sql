@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_alerts_status;
+DROP INDEX IF EXISTS idx_alerts_fingerprint;
+ALTER TABLE alerts DROP COLUMN IF EXISTS resolved_at;
+ALTER TABLE alerts DROP COLUMN IF EXISTS acknowledged_at;
+ALTER TABLE alerts DROP COLUMN IF EXISTS status;
+ALTER TABLE alerts DROP COLUMN IF EXISTS fingerprint;
@@ -0,0 +1,11 @@
+-- Add fingerprint and lifecycle tracking to alerts
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS fingerprint VARCHAR(64);
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'open';
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMP;
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;
+
+-- Create index on fingerprint for correlation with external systems
+CREATE INDEX IF NOT EXISTS idx_alerts_fingerprint ON alerts(fingerprint);
+
+-- Create index on status for lifecycle queries
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON alerts(status);
@@ -0,0 +1,14 @@
+-- Create escalations table; one row per paged alert drives timed re-notification
+CREATE TABLE IF NOT EXISTS escalations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    alert_id UUID NOT NULL UNIQUE REFERENCES alerts(id) ON DELETE CASCADE,
+    tier INTEGER NOT NULL DEFAULT 0,
+    notifications INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    next_notify_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- Create partial index so the scheduler only scans active escalations
+CREATE INDEX IF NOT EXISTS idx_escalations_due ON escalations(next_notify_at) WHERE status = 'active';
@@ -0,0 +1,10 @@
+-- Create connector_state table; one row per upstream connector holds its sync watermark and health
+CREATE TABLE IF NOT EXISTS connector_state (
+    name VARCHAR(100) PRIMARY KEY,
+    watermark TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    last_success_at TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
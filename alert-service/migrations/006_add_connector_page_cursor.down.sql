@@ -0,0 +1 @@
+ALTER TABLE connector_state DROP COLUMN IF EXISTS page_cursor;
@@ -0,0 +1,2 @@
+-- Keep the upstream's next page cursor while a paged sync is in progress so an interrupted run resumes mid-stream
+ALTER TABLE connector_state ADD COLUMN IF NOT EXISTS page_cursor TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+-- Create dead_letters table; one row per upstream alert that could not be enriched or stored
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    connector VARCHAR(100) NOT NULL,
+    stage VARCHAR(20) NOT NULL,
+    payload JSONB NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    next_attempt_at TIMESTAMP NOT NULL,
+    alert_id UUID REFERENCES alerts(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+-- Create partial index so the reprocessor only scans pending dead letters
+CREATE INDEX IF NOT EXISTS idx_dead_letters_due ON dead_letters(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,3 @@
+-- Duplicates removed by the up migration are not restored
+DROP INDEX IF EXISTS idx_alerts_fingerprint;
+CREATE INDEX IF NOT EXISTS idx_alerts_fingerprint ON alerts(fingerprint);
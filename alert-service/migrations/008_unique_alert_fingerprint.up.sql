@@ -0,0 +1,18 @@
+-- Make the fingerprint unique so re-fetched alerts (look-back overlap, resumed pages) are skipped instead of duplicated.
+-- Existing duplicates are removed first, keeping the oldest copy; rows without a fingerprint are left alone.
+-- Skipped once alerts is partitioned: 012 keeps the fingerprint unique together with created_at.
+DO $$
+BEGIN
+    IF (SELECT relkind FROM pg_class WHERE oid = 'alerts'::regclass) = 'p' THEN
+        RETURN;
+    END IF;
+
+    DELETE FROM alerts a
+    USING alerts b
+    WHERE a.fingerprint IS NOT NULL
+      AND a.fingerprint = b.fingerprint
+      AND (a.created_at, a.ctid) > (b.created_at, b.ctid);
+
+    DROP INDEX IF EXISTS idx_alerts_fingerprint;
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_fingerprint ON alerts(fingerprint);
+END $$;
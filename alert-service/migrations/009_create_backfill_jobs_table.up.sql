@@ -0,0 +1,23 @@
+-- Create backfill_jobs table; one row per re-pull of a historical window from an upstream connector
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    connector VARCHAR(100) NOT NULL,
+    range_from TIMESTAMP NOT NULL,
+    range_to TIMESTAMP NOT NULL,
+    sources JSONB NOT NULL DEFAULT '[]',
+    chunk_seconds BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    position TIMESTAMP NOT NULL,
+    page_cursor TEXT NOT NULL DEFAULT '',
+    fetched INTEGER NOT NULL DEFAULT 0,
+    stored INTEGER NOT NULL DEFAULT 0,
+    duplicates INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    lease_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+    );
+
+-- Create partial index so workers only scan jobs that still have work to do
+CREATE INDEX IF NOT EXISTS idx_backfill_jobs_active ON backfill_jobs(created_at) WHERE status IN ('pending', 'running');
@@ -0,0 +1,10 @@
+-- Record which enrichment pipeline version produced each enrichment field
+ALTER TABLE alerts ADD COLUMN IF NOT EXISTS enrichment_versions JSONB NOT NULL DEFAULT '{}';
+
+-- Alerts stored so far were enriched by version 1 of the pipeline
+UPDATE alerts
+SET enrichment_versions = jsonb_strip_nulls(jsonb_build_object(
+    'enrichment_type', CASE WHEN enrichment_type IS NOT NULL THEN 1 END,
+    'ip_address', CASE WHEN ip_address IS NOT NULL THEN 1 END
+))
+WHERE enrichment_versions = '{}';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_alerts_created_at_id;
+DROP TABLE IF EXISTS reenrichment_jobs;
@@ -0,0 +1,26 @@
+-- Create reenrichment_jobs table; one row per run of stored alerts through the current enrichment pipeline
+CREATE TABLE IF NOT EXISTS reenrichment_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    filter JSONB NOT NULL DEFAULT '{}',
+    fields JSONB NOT NULL DEFAULT '[]',
+    force BOOLEAN NOT NULL DEFAULT FALSE,
+    version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    total INTEGER NOT NULL DEFAULT 0,
+    position TIMESTAMP,
+    after_id UUID,
+    scanned INTEGER NOT NULL DEFAULT 0,
+    updated INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    lease_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+    );
+
+-- Create partial index so workers only scan jobs that still have work to do
+CREATE INDEX IF NOT EXISTS idx_reenrichment_jobs_active ON reenrichment_jobs(created_at) WHERE status IN ('pending', 'running');
+
+-- Create index for walking alerts oldest first with a (created_at, id) cursor
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at_id ON alerts(created_at, id);
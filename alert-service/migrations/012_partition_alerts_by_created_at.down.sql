@@ -0,0 +1,52 @@
+-- Convert alerts back into a single table keyed on id, restoring the foreign keys from escalations and dead_letters.
+-- Every attached partition, including alerts_default, is copied back; detached partitions are left as they are.
+DO $$
+BEGIN
+    IF (SELECT relkind FROM pg_class WHERE oid = 'alerts'::regclass) <> 'p' THEN
+        RETURN;
+    END IF;
+
+    ALTER TABLE alerts RENAME TO alerts_partitioned;
+    ALTER TABLE alerts_partitioned RENAME CONSTRAINT alerts_pkey TO alerts_partitioned_pkey;
+
+    CREATE TABLE alerts (
+        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+        source VARCHAR(255) NOT NULL,
+        severity VARCHAR(50) NOT NULL,
+        description TEXT NOT NULL,
+        whole_event BYTEA NOT NULL,
+        enrichment_type VARCHAR(100),
+        ip_address VARCHAR(45),
+        enrichment_versions JSONB NOT NULL DEFAULT '{}',
+        fingerprint VARCHAR(64),
+        status VARCHAR(20) NOT NULL DEFAULT 'open',
+        acknowledged_at TIMESTAMP,
+        resolved_at TIMESTAMP,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+    INSERT INTO alerts (id, source, severity, description, whole_event, enrichment_type, ip_address,
+                        enrichment_versions, fingerprint, status, acknowledged_at, resolved_at, created_at)
+    SELECT id, source, severity, description, whole_event, enrichment_type, ip_address,
+           enrichment_versions, fingerprint, status, acknowledged_at, resolved_at, created_at
+    FROM alerts_partitioned;
+
+    -- Dropping the parent drops its partitions
+    DROP TABLE alerts_partitioned;
+
+    -- References to alerts removed by retention cannot be restored
+    DELETE FROM escalations e WHERE NOT EXISTS (SELECT 1 FROM alerts a WHERE a.id = e.alert_id);
+    UPDATE dead_letters d SET alert_id = NULL
+    WHERE alert_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM alerts a WHERE a.id = d.alert_id);
+    ALTER TABLE escalations ADD CONSTRAINT escalations_alert_id_fkey
+        FOREIGN KEY (alert_id) REFERENCES alerts(id) ON DELETE CASCADE;
+    ALTER TABLE dead_letters ADD CONSTRAINT dead_letters_alert_id_fkey
+        FOREIGN KEY (alert_id) REFERENCES alerts(id) ON DELETE SET NULL;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_alerts_severity ON alerts(severity);
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at ON alerts(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at_id ON alerts(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_alerts_source ON alerts(source);
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON alerts(status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_fingerprint ON alerts(fingerprint);
@@ -0,0 +1,66 @@
+-- Convert alerts into a table range-partitioned on created_at so old alerts can be dropped a partition at a time.
+-- Existing rows are copied into monthly partitions; the service pre-creates later partitions at the configured
+-- interval (ALERT_PARTITION_INTERVAL) and applies retention. Safe to re-run: it does nothing once alerts is partitioned.
+DO $$
+DECLARE
+    period_start TIMESTAMP;
+    last_start TIMESTAMP;
+BEGIN
+    IF (SELECT relkind FROM pg_class WHERE oid = 'alerts'::regclass) = 'p' THEN
+        RETURN;
+    END IF;
+
+    -- A partitioned table's unique keys must include created_at, so alerts(id) can no longer be referenced.
+    -- The service clears escalations and dead-letter links of expired alerts instead.
+    ALTER TABLE escalations DROP CONSTRAINT IF EXISTS escalations_alert_id_fkey;
+    ALTER TABLE dead_letters DROP CONSTRAINT IF EXISTS dead_letters_alert_id_fkey;
+
+    ALTER TABLE alerts RENAME TO alerts_unpartitioned;
+    ALTER TABLE alerts_unpartitioned RENAME CONSTRAINT alerts_pkey TO alerts_unpartitioned_pkey;
+
+    CREATE TABLE alerts (
+        id UUID NOT NULL DEFAULT uuid_generate_v4(),
+        source VARCHAR(255) NOT NULL,
+        severity VARCHAR(50) NOT NULL,
+        description TEXT NOT NULL,
+        whole_event BYTEA NOT NULL,
+        enrichment_type VARCHAR(100),
+        ip_address VARCHAR(45),
+        enrichment_versions JSONB NOT NULL DEFAULT '{}',
+        fingerprint VARCHAR(64),
+        status VARCHAR(20) NOT NULL DEFAULT 'open',
+        acknowledged_at TIMESTAMP,
+        resolved_at TIMESTAMP,
+        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (id, created_at)
+    ) PARTITION BY RANGE (created_at);
+
+    -- One monthly partition per month of existing data through next month
+    SELECT date_trunc('month', COALESCE(MIN(created_at), NOW())) INTO period_start FROM alerts_unpartitioned;
+    last_start := date_trunc('month', GREATEST(NOW(), (SELECT MAX(created_at) FROM alerts_unpartitioned))) + INTERVAL '1 month';
+    WHILE period_start <= last_start LOOP
+        EXECUTE format('CREATE TABLE %I PARTITION OF alerts FOR VALUES FROM (%L) TO (%L)',
+            'alerts_p' || to_char(period_start, 'YYYYMMDD'), period_start, period_start + INTERVAL '1 month');
+        period_start := period_start + INTERVAL '1 month';
+    END LOOP;
+
+    -- Catches alerts outside every partition, e.g. backfills older than the first one
+    CREATE TABLE alerts_default PARTITION OF alerts DEFAULT;
+
+    INSERT INTO alerts (id, source, severity, description, whole_event, enrichment_type, ip_address,
+                        enrichment_versions, fingerprint, status, acknowledged_at, resolved_at, created_at)
+    SELECT id, source, severity, description, whole_event, enrichment_type, ip_address,
+           enrichment_versions, fingerprint, status, acknowledged_at, resolved_at, created_at
+    FROM alerts_unpartitioned;
+
+    DROP TABLE alerts_unpartitioned;
+END $$;
+
+-- Recreate the indexes on the partitioned table; each partition gets its own copy.
+-- The fingerprint covers created_at, so it stays unique per alert with created_at in the key.
+CREATE INDEX IF NOT EXISTS idx_alerts_severity ON alerts(severity);
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at ON alerts(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at_id ON alerts(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_alerts_source ON alerts(source);
+CREATE INDEX IF NOT EXISTS idx_alerts_status ON alerts(status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_fingerprint ON alerts(fingerprint, created_at);
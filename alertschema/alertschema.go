@@ -0,0 +1,38 @@
+// Package alertschema holds the alert contract shared by the mock API, which
+// serves alerts, and the alert service, which polls and accepts them, so both
+// hold alerts to the same sources and severities.
+package alertschema
+
+var (
+	// ValidSeverities defines allowed severity levels
+	ValidSeverities = map[string]bool{
+		"low":      true,
+		"medium":   true,
+		"high":     true,
+		"critical": true,
+	}
+
+	// ValidSources defines the 10 allowed alert sources
+	ValidSources = map[string]bool{
+		"siem-1":                true,
+		"siem-2":                true,
+		"firewall":              true,
+		"ids":                   true,
+		"antivirus":             true,
+		"endpoint":              true,
+		"cloud-security":        true,
+		"email-gateway":         true,
+		"network-monitor":       true,
+		"vulnerability-scanner": true,
+	}
+)
+
+// IsValidSeverity checks if severity is valid
+func IsValidSeverity(severity string) bool {
+	return ValidSeverities[severity]
+}
+
+// IsValidSource checks if source is valid
+func IsValidSource(source string) bool {
+	return ValidSources[source]
+}
@@ -0,0 +1,3 @@
+module alertschema
+
+go 1.25
@@ -0,0 +1,91 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Start applies pending migrations when up is set and loads the seed data
+// when seed is set, as each service does at startup
+func (m *Migrator) Start(ctx context.Context, up, seed bool) error {
+	if up {
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range applied {
+			log.Printf("Applied migration %03d_%s", migration.Version, migration.Name)
+		}
+	}
+	if seed {
+		if err := m.Seed(ctx); err != nil {
+			return err
+		}
+		log.Println("Loaded sample data")
+	}
+	return nil
+}
+
+// RunCommand runs the migrate subcommand of a service, which manages the
+// schema by hand:
+//
+//	<service> migrate [up]          apply pending migrations
+//	<service> migrate down [steps]  roll back the last migration, or the last steps
+//	<service> migrate status        list migrations and whether they are applied
+//	<service> migrate seed          load the sample data
+func (m *Migrator) RunCommand(ctx context.Context, args []string) error {
+	action := "up"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			log.Printf("Applied %03d_%s", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Println("Schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid number of steps %q", args[0])
+			}
+			steps = n
+		}
+		rolledBack, err := m.Down(ctx, steps)
+		for _, migration := range rolledBack {
+			log.Printf("Rolled back %03d_%s", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			appliedAt := ""
+			if !s.AppliedAt.IsZero() {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%03d  %-40s  %-8s  %s\n", s.Version, s.Name, s.State, appliedAt)
+		}
+		return nil
+	case "seed":
+		if err := m.Seed(ctx); err != nil {
+			return err
+		}
+		log.Println("Loaded sample data")
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate action %q: use up, down, status or seed", action)
+	}
+}
@@ -0,0 +1,346 @@
+// Package migrate applies the versioned SQL migrations embedded in the binary.
+//
+// Migrations are files named NNN_description.up.sql, each with an optional
+// NNN_description.down.sql to roll it back. Every migration runs in its own
+// transaction together with its row in the migrations table, which records
+// the SHA-256 of the up script. An applied migration whose script has since
+// changed, or that this build does not know, stops the runner. A Postgres
+// advisory lock keeps instances starting together from migrating at once.
+//
+// The alert service and the mock API both use this runner, each with its own
+// migrations table in the shared database.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// seedFile is the optional sample data script alongside the migrations
+const seedFile = "seed.sql"
+
+// Migration states reported by Status
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified"
+	StateUnknown  = "unknown"
+)
+
+// ErrNoSeed is returned by Seed when there is no seed.sql
+var ErrNoSeed = errors.New("migrate: no seed data")
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	// Down rolls the migration back; empty when it cannot be rolled back
+	Down     string
+	Checksum string
+}
+
+// Status is the state of one migration in the database
+type Status struct {
+	Version int
+	Name    string
+	// State is StateApplied, StatePending, StateModified (applied, but the
+	// script has changed since) or StateUnknown (applied, but not in this build)
+	State     string
+	AppliedAt time.Time
+}
+
+// applied is a row of the migrations table
+type applied struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	table      string
+	lockID     int64
+	migrations []Migration
+	seed       string
+}
+
+// New creates a migrator for the migrations in fsys, tracked in table.
+// Services sharing a database use different tables.
+func New(db *sql.DB, table string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := fs.ReadFile(fsys, seedFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("migrate: reading %s: %w", seedFile, err)
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte("migrate:" + table))
+	return &Migrator{
+		db:         db,
+		table:      pq.QuoteIdentifier(table),
+		lockID:     int64(h.Sum64()),
+		migrations: migrations,
+		seed:       string(seed),
+	}, nil
+}
+
+// Load reads the migrations in the top directory of fsys, ordered by
+// version. Files that are not migrations are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: reading %s: %w", entry.Name(), err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrate: version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(script)
+			sum := sha256.Sum256(script)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migrate: %03d_%s has a down script but no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in version order and returns the ones applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, rows []applied) error {
+		if err := m.verify(rows); err != nil {
+			return err
+		}
+		isApplied := make(map[int]bool, len(rows))
+		for _, row := range rows {
+			isApplied[row.version] = true
+		}
+
+		for _, migration := range m.migrations {
+			if isApplied[migration.Version] {
+				continue
+			}
+			err := m.inTx(ctx, conn, migration.Up,
+				`INSERT INTO `+m.table+` (version, name, checksum) VALUES ($1, $2, $3)`,
+				migration.Version, migration.Name, migration.Checksum)
+			if err != nil {
+				return fmt.Errorf("migrate: applying %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, newest first, and
+// returns the ones rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, rows []applied) error {
+		if err := m.verify(rows); err != nil {
+			return err
+		}
+
+		for i := len(rows) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.find(rows[i].version)
+			if migration.Down == "" {
+				return fmt.Errorf("migrate: %03d_%s cannot be rolled back: it has no down script", migration.Version, migration.Name)
+			}
+			err := m.inTx(ctx, conn, migration.Down,
+				`DELETE FROM `+m.table+` WHERE version = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("migrate: rolling back %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, *migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status reports every known and applied migration, in version order
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sql.Conn, rows []applied) error {
+		byVersion := make(map[int]applied, len(rows))
+		for _, row := range rows {
+			byVersion[row.version] = row
+			if m.find(row.version) == nil {
+				statuses = append(statuses, Status{Version: row.version, Name: row.name, State: StateUnknown, AppliedAt: row.appliedAt})
+			}
+		}
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name, State: StatePending}
+			if row, ok := byVersion[migration.Version]; ok {
+				status.State, status.AppliedAt = StateApplied, row.appliedAt
+				if row.checksum != migration.Checksum {
+					status.State = StateModified
+				}
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	slices.SortFunc(statuses, func(a, b Status) int { return a.Version - b.Version })
+	return statuses, err
+}
+
+// Seed loads the sample data in seed.sql. The script is expected to skip
+// data that is already there, so seeding twice is harmless.
+func (m *Migrator) Seed(ctx context.Context) error {
+	if m.seed == "" {
+		return ErrNoSeed
+	}
+	return m.withLock(ctx, func(conn *sql.Conn, _ []applied) error {
+		if err := m.inTx(ctx, conn, m.seed, ""); err != nil {
+			return fmt.Errorf("migrate: seeding: %w", err)
+		}
+		return nil
+	})
+}
+
+// withLock holds the advisory lock on one connection, creating the
+// migrations table if needed, and calls fn with the applied migrations
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, rows []applied) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, m.lockID); err != nil {
+		return fmt.Errorf("migrate: taking lock: %w", err)
+	}
+	// Unlocked even when ctx is done, so the lock is not held until the connection is recycled
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, m.lockID)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+m.table+` (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("migrate: creating %s: %w", m.table, err)
+	}
+
+	rows, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, rows)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) ([]applied, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM `+m.table+` ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: reading %s: %w", m.table, err)
+	}
+	defer rows.Close()
+
+	var result []applied
+	for rows.Next() {
+		var row applied
+		if err := rows.Scan(&row.version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("migrate: reading %s: %w", m.table, err)
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("migrate: reading %s: %w", m.table, err)
+	}
+	return result, nil
+}
+
+// verify checks that every applied migration is known and unchanged
+func (m *Migrator) verify(rows []applied) error {
+	for _, row := range rows {
+		migration := m.find(row.version)
+		if migration == nil {
+			return fmt.Errorf("migrate: %03d_%s is applied but not part of this build; the database is newer than the service", row.version, row.name)
+		}
+		if migration.Checksum != row.checksum {
+			return fmt.Errorf("migrate: %03d_%s has changed since it was applied: checksum %s, applied %s",
+				migration.Version, migration.Name, migration.Checksum, row.checksum)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// inTx runs script and then record, when set, in one transaction
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if record != "" {
+		if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
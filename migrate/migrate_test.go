@@ -0,0 +1,201 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = fstest.MapFS{
+	"001_create_alerts.up.sql":   {Data: []byte("CREATE TABLE alerts (id UUID)")},
+	"001_create_alerts.down.sql": {Data: []byte("DROP TABLE alerts")},
+	"002_add_status.up.sql":      {Data: []byte("ALTER TABLE alerts ADD COLUMN status TEXT")},
+	"seed.sql":                   {Data: []byte("INSERT INTO alerts VALUES (gen_random_uuid())")},
+	"migrations.go":              {Data: []byte("package migrations")},
+}
+
+func newTestMigrator(t *testing.T, fsys fstest.MapFS) (*Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, "schema_migrations", fsys)
+	require.NoError(t, err)
+	return m, mock
+}
+
+func checksum(t *testing.T, version int) string {
+	migrations, err := Load(testMigrations)
+	require.NoError(t, err)
+	return migrations[version-1].Checksum
+}
+
+// expectLock expects the lock, the migrations table and the applied rows
+func expectLock(mock sqlmock.Sqlmock, m *Migrator, rows *sqlmock.Rows) {
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(m.lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "schema_migrations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT version, name, checksum, applied_at FROM "schema_migrations" ORDER BY version`).WillReturnRows(rows)
+}
+
+func expectUnlock(mock sqlmock.Sqlmock, m *Migrator) {
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(m.lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func appliedRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"})
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testMigrations)
+
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "create_alerts", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE alerts", migrations[0].Down)
+	assert.Len(t, migrations[0].Checksum, 64)
+	assert.Empty(t, migrations[1].Down)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"001_create_alerts.up.sql": {Data: []byte("")},
+		"001_create_events.up.sql": {Data: []byte("")},
+	})
+	assert.ErrorContains(t, err, "version 1 is used by both")
+
+	_, err = Load(fstest.MapFS{"003_drop_alerts.down.sql": {Data: []byte("")}})
+	assert.ErrorContains(t, err, "has a down script but no up script")
+}
+
+func TestMigrator_Up(t *testing.T) {
+	m, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock, m, appliedRows().AddRow(1, "create_alerts", checksum(t, 1), time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`ALTER TABLE alerts ADD COLUMN status TEXT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "schema_migrations" \(version, name, checksum\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs(2, "add_status", checksum(t, 2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock, m)
+
+	done, err := m.Up(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.Equal(t, 2, done[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Up_RollsBackFailedMigration(t *testing.T) {
+	m, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock, m, appliedRows())
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE alerts`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "schema_migrations"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`ALTER TABLE alerts`).WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	expectUnlock(mock, m)
+
+	done, err := m.Up(context.Background())
+
+	assert.ErrorContains(t, err, "applying 002_add_status")
+	assert.Len(t, done, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Up_RefusesChangedMigration(t *testing.T) {
+	m, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock, m, appliedRows().AddRow(1, "create_alerts", "edited", time.Now()))
+	expectUnlock(mock, m)
+
+	_, err := m.Up(context.Background())
+
+	assert.ErrorContains(t, err, "001_create_alerts has changed since it was applied")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Up_RefusesNewerDatabase(t *testing.T) {
+	m, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock, m, appliedRows().
+		AddRow(1, "create_alerts", checksum(t, 1), time.Now()).
+		AddRow(3, "add_owner", "abc", time.Now()))
+	expectUnlock(mock, m)
+
+	_, err := m.Up(context.Background())
+
+	assert.ErrorContains(t, err, "003_add_owner is applied but not part of this build")
+}
+
+func TestMigrator_Down(t *testing.T) {
+	m, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock, m, appliedRows().AddRow(1, "create_alerts", checksum(t, 1), time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DROP TABLE alerts`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "schema_migrations" WHERE version = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock, m)
+
+	done, err := m.Down(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.Equal(t, "create_alerts", done[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Down_WithoutDownScript(t *testing.T) {
+	m, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock, m, appliedRows().
+		AddRow(1, "create_alerts", checksum(t, 1), time.Now()).
+		AddRow(2, "add_status", checksum(t, 2), time.Now()))
+	expectUnlock(mock, m)
+
+	_, err := m.Down(context.Background(), 1)
+
+	assert.ErrorContains(t, err, "002_add_status cannot be rolled back")
+}
+
+func TestMigrator_Status(t *testing.T) {
+	m, mock := newTestMigrator(t, testMigrations)
+	appliedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expectLock(mock, m, appliedRows().AddRow(1, "create_alerts", "edited", appliedAt))
+	expectUnlock(mock, m)
+
+	statuses, err := m.Status(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []Status{
+		{Version: 1, Name: "create_alerts", State: StateModified, AppliedAt: appliedAt},
+		{Version: 2, Name: "add_status", State: StatePending},
+	}, statuses)
+}
+
+func TestMigrator_Seed(t *testing.T) {
+	m, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock, m, appliedRows())
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO alerts`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock, m)
+
+	require.NoError(t, m.Seed(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	withoutSeed, _ := newTestMigrator(t, fstest.MapFS{"001_create_alerts.up.sql": {Data: []byte("")}})
+	assert.ErrorIs(t, withoutSeed.Seed(context.Background()), ErrNoSeed)
+}
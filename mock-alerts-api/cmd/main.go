@@ -21,7 +21,9 @@ func main() {
 	log.Printf("Mock Alerts API Configuration:")
 	log.Printf("  Port: %s", cfg.Port)
 	log.Printf("  Failure Rate: %.0f%%", cfg.FailureRate*100)
+	log.Printf("  Rate Limit: %d per %s", cfg.RateLimit, cfg.RateWindow)
 	log.Printf("  Database: %s@%s:%s/%s", cfg.DBUser, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	log.Printf("  Migrate On Start: %t", cfg.MigrateOnStart)
 
 	// Initialize database connection
 	db, err := config.NewDB(cfg.GetDBConnectionString())
@@ -32,13 +34,32 @@ func main() {
 
 	log.Println("Successfully connected to database")
 
+	// Apply the schema, or manage it by hand with the migrate subcommand
+	migrator, err := newMigrator(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(os.Args) > 1 {
+		if os.Args[1] != "migrate" {
+			log.Fatalf("Unknown command %q: use migrate", os.Args[1])
+		}
+		if err := migrator.RunCommand(context.Background(), os.Args[2:]); err != nil {
+			log.Fatalf("migrate failed: %v", err)
+		}
+		return
+	}
+	if err := migrator.Start(context.Background(), cfg.MigrateOnStart, cfg.SeedSampleData); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
 	// Initialize layers
 	alertGen := service.NewAlertGenerator(db)
 	alertsHandler := handler.NewAlertsHandler(alertGen, cfg.FailureRate)
+	rateLimiter := handler.NewRateLimiter(cfg.RateLimit, cfg.RateWindow, cfg.RetryAfterFormat)
 
 	// Setup routes
 	mux := http.NewServeMux()
-	mux.HandleFunc("/alerts", alertsHandler.GetAlerts)
+	mux.HandleFunc("/alerts", rateLimiter.Middleware(alertsHandler.GetAlerts))
 	mux.HandleFunc("/health", alertsHandler.HealthHandler)
 
 	// Create server with timeouts
@@ -54,7 +75,7 @@ func main() {
 	go func() {
 		log.Printf("Mock Alerts API starting on http://localhost%s", server.Addr)
 		log.Printf("Endpoints:")
-		log.Printf("  GET  /alerts  - Fetch alerts (optional ?since=<ISO8601>)")
+		log.Printf("  GET  /alerts  - Fetch a page of alerts (optional ?since=<ISO8601>, ?until=<ISO8601>, ?cursor=, ?limit=)")
 		log.Printf("  GET  /health  - Health check")
 		log.Printf("Simulating %.0f%% random failures on /alerts endpoint", cfg.FailureRate*100)
 
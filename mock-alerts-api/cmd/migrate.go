@@ -0,0 +1,16 @@
+package main
+
+import (
+	"database/sql"
+
+	"migrate"
+	"mock-alerts-api/migrations"
+)
+
+// migrationsTable tracks the mock API's migrations, apart from the alert
+// service's schema_migrations in the same database
+const migrationsTable = "mock_api_schema_migrations"
+
+func newMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrationsTable, migrations.FS)
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -18,6 +19,16 @@ type Config struct {
 	DBName      string
 	Port        string
 	FailureRate float64
+
+	// RateLimit is the number of /alerts requests allowed per RateWindow; 0 disables it
+	RateLimit        int
+	RateWindow       time.Duration
+	RetryAfterFormat string
+
+	// MigrateOnStart applies pending schema migrations at startup
+	MigrateOnStart bool
+	// SeedSampleData loads the sample alerts into an empty external_alerts table at startup
+	SeedSampleData bool
 }
 
 func LoadConfig() *Config {
@@ -36,6 +47,18 @@ func LoadConfig() *Config {
 		failureRate = 1
 	}
 
+	rateLimit, err := strconv.Atoi(getEnv("MOCK_RATE_LIMIT", "0"))
+	if err != nil || rateLimit < 0 {
+		log.Printf("Invalid MOCK_RATE_LIMIT, rate limiting disabled")
+		rateLimit = 0
+	}
+
+	rateWindow, err := time.ParseDuration(getEnv("MOCK_RATE_WINDOW", "1m"))
+	if err != nil || rateWindow <= 0 {
+		log.Printf("Invalid MOCK_RATE_WINDOW, using default 1m")
+		rateWindow = time.Minute
+	}
+
 	return &Config{
 		DBHost:      getEnv("DB_HOST", "localhost"),
 		DBPort:      getEnv("DB_PORT", "5432"),
@@ -44,6 +67,13 @@ func LoadConfig() *Config {
 		DBName:      getEnv("DB_NAME", "alerts_db"),
 		Port:        getEnv("PORT", "8081"),
 		FailureRate: failureRate,
+
+		RateLimit:        rateLimit,
+		RateWindow:       rateWindow,
+		RetryAfterFormat: getEnv("MOCK_RETRY_AFTER_FORMAT", "seconds"),
+
+		MigrateOnStart: parseBool("MIGRATE_ON_START", true),
+		SeedSampleData: parseBool("SEED_SAMPLE_DATA", false),
 	}
 }
 
@@ -54,6 +84,20 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// parseBool reads a boolean variable, logging and falling back to defaultValue when it is invalid
+func parseBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid %s, using default %t", key, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
 func (c *Config) GetDBConnectionString() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
@@ -2,4 +2,21 @@ module mock-alerts-api
 
 go 1.25
 
-require github.com/lib/pq v1.10.9
+require (
+	alertschema v0.0.0
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/lib/pq v1.10.9
+	github.com/stretchr/testify v1.11.1
+	migrate v0.0.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	alertschema => ../alertschema
+	migrate => ../migrate
+)
@@ -1,11 +1,14 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"math/rand/v2"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"mock-alerts-api/internal/service"
@@ -17,7 +20,9 @@ type AlertsHandler struct {
 }
 
 type AlertsResponse struct {
-	Alerts []service.ExternalAlert `json:"alerts"`
+	Alerts     []service.ExternalAlert `json:"alerts"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	HasMore    bool                    `json:"has_more"`
 }
 
 type ErrorResponse struct {
@@ -31,7 +36,15 @@ func NewAlertsHandler(gen *service.AlertGenerator, failureRate float64) *AlertsH
 	}
 }
 
-// GetAlerts handles GET /alerts with optional ?since= parameter
+// ndjsonContentType is served to clients that accept it or ask for ?format=ndjson
+const ndjsonContentType = "application/x-ndjson"
+
+// GetAlerts handles GET /alerts with optional ?since=, ?until=, ?cursor= and
+// ?limit= parameters. Alerts are returned oldest first, a page at a time; pass
+// next_cursor back as ?cursor= while has_more is true. The cursor does not
+// carry until, so it is sent with every page of a bounded range. NDJSON
+// responses carry one alert per line and the pagination fields in
+// X-Next-Cursor and X-Has-More.
 func (h *AlertsHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed. Use GET.")
@@ -45,44 +58,73 @@ func (h *AlertsHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
-	sinceParam := r.URL.Query().Get("since")
+	query := r.URL.Query()
 
-	var alerts []service.ExternalAlert
-	var err error
+	var since time.Time
+	if sinceParam := query.Get("since"); sinceParam != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, sinceParam)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "Invalid since parameter. Use RFC3339.")
+			return
+		}
+	}
 
-	if sinceParam != "" {
-		alerts, err = h.getAlertsSince(ctx, sinceParam)
-	} else {
-		alerts, err = h.getAllAlerts(ctx)
+	var until time.Time
+	if untilParam := query.Get("until"); untilParam != "" {
+		var err error
+		until, err = time.Parse(time.RFC3339, untilParam)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "Invalid until parameter. Use RFC3339.")
+			return
+		}
 	}
 
+	limit := service.DefaultPageSize
+	if limitParam := query.Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > service.MaxPageSize {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit parameter. Use 1-%d.", service.MaxPageSize))
+			return
+		}
+	}
+
+	cursor := query.Get("cursor")
+	log.Printf("[MOCK API] Fetching alerts (since: %s, until: %s, cursor: %q, limit: %d)", since.Format(time.RFC3339), until.Format(time.RFC3339), cursor, limit)
+
+	page, err := h.generator.GetAlertsPage(r.Context(), since, until, cursor, limit)
+	if errors.Is(err, service.ErrInvalidCursor) {
+		h.writeError(w, http.StatusBadRequest, "Invalid cursor parameter")
+		return
+	}
 	if err != nil {
 		log.Printf("[MOCK API] Error fetching alerts: %v", err)
 		h.writeError(w, http.StatusInternalServerError, "Failed to fetch alerts")
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, AlertsResponse{Alerts: alerts})
-	log.Printf("[MOCK API] Successfully returned %d alerts", len(alerts))
-}
-
-func (h *AlertsHandler) getAlertsSince(ctx context.Context, sinceParam string) ([]service.ExternalAlert, error) {
-	since, err := time.Parse(time.RFC3339, sinceParam)
-	if err != nil {
-		return nil, err
+	alerts := page.Alerts
+	if alerts == nil {
+		alerts = []service.ExternalAlert{}
 	}
+	if wantsNDJSON(r) {
+		writeNDJSON(w, alerts, page.NextCursor, page.HasMore)
+	} else {
+		h.writeJSON(w, http.StatusOK, AlertsResponse{Alerts: alerts, NextCursor: page.NextCursor, HasMore: page.HasMore})
+	}
+	log.Printf("[MOCK API] Successfully returned %d alerts (has_more: %t)", len(alerts), page.HasMore)
+}
 
-	log.Printf("[MOCK API] Fetching alerts since: %s", since.Format(time.RFC3339))
-	return h.generator.GetAlertsSince(ctx, since)
+func (h *AlertsHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, status, data)
 }
 
-func (h *AlertsHandler) getAllAlerts(ctx context.Context) ([]service.ExternalAlert, error) {
-	log.Printf("[MOCK API] Fetching all alerts")
-	return h.generator.GetAllAlerts(ctx)
+func (h *AlertsHandler) writeError(w http.ResponseWriter, status int, message string) {
+	h.writeJSON(w, status, ErrorResponse{Error: message})
 }
 
-func (h *AlertsHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
@@ -90,8 +132,30 @@ func (h *AlertsHandler) writeJSON(w http.ResponseWriter, status int, data interf
 	}
 }
 
-func (h *AlertsHandler) writeError(w http.ResponseWriter, status int, message string) {
-	h.writeJSON(w, status, ErrorResponse{Error: message})
+// wantsNDJSON reports whether the client asked for NDJSON
+func wantsNDJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "ndjson"
+	}
+	return strings.Contains(r.Header.Get("Accept"), ndjsonContentType)
+}
+
+// writeNDJSON writes one alert per line, with the page's pagination in headers
+func writeNDJSON(w http.ResponseWriter, alerts []service.ExternalAlert, nextCursor string, hasMore bool) {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	if nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+	w.Header().Set("X-Has-More", strconv.FormatBool(hasMore))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	for _, alert := range alerts {
+		if err := encoder.Encode(alert); err != nil {
+			log.Printf("[MOCK API] Error encoding NDJSON response: %v", err)
+			return
+		}
+	}
 }
 
 func (h *AlertsHandler) shouldFail() bool {
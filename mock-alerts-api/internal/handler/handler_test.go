@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mock-alerts-api/internal/service"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var alertRowColumns = []string{"id", "source", "severity", "description", "created_at"}
+
+func newTestHandler(t *testing.T) (*AlertsHandler, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewAlertsHandler(service.NewAlertGenerator(db), 0), mock
+}
+
+func getAlerts(h *AlertsHandler, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.GetAlerts(rec, req)
+	return rec
+}
+
+func TestAlertsHandler_GetAlertsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		error  string
+	}{
+		{"since not RFC3339", "/alerts?since=2024-01-15", "Invalid since parameter. Use RFC3339."},
+		{"until not RFC3339", "/alerts?until=yesterday", "Invalid until parameter. Use RFC3339."},
+		{"limit not a number", "/alerts?limit=ten", "Invalid limit parameter. Use 1-1000."},
+		{"limit zero", "/alerts?limit=0", "Invalid limit parameter. Use 1-1000."},
+		{"limit negative", "/alerts?limit=-5", "Invalid limit parameter. Use 1-1000."},
+		{"limit above the maximum", "/alerts?limit=1001", "Invalid limit parameter. Use 1-1000."},
+		{"tampered cursor", "/alerts?cursor=MjAyNC0wMS0xNVQxMDowMDowMFo", "Invalid cursor parameter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestHandler(t)
+
+			rec := getAlerts(h, tt.target, nil)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.error, resp.Error)
+			assert.NoError(t, mock.ExpectationsWereMet(), "no query is run")
+		})
+	}
+
+	t.Run("method not allowed", func(t *testing.T) {
+		h, _ := newTestHandler(t)
+		rec := httptest.NewRecorder()
+		h.GetAlerts(rec, httptest.NewRequest(http.MethodPost, "/alerts", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestAlertsHandler_GetAlerts(t *testing.T) {
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	since := base.Add(-time.Hour)
+	until := base.Add(time.Hour)
+	const target = "/alerts?since=2024-01-15T09:00:00Z&until=2024-01-15T11:00:00Z&limit=1"
+
+	expectPage := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery("SELECT (.+) FROM external_alerts").
+			WithArgs(since, time.Time{}, 0, 2, until).
+			WillReturnRows(sqlmock.NewRows(alertRowColumns).
+				AddRow(1, "firewall", "high", "one", base).
+				AddRow(2, "ids", "low", "two", base.Add(time.Minute)))
+	}
+
+	t.Run("JSON page", func(t *testing.T) {
+		h, mock := newTestHandler(t)
+		expectPage(mock)
+
+		rec := getAlerts(h, target, nil)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var resp AlertsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Alerts, 1)
+		assert.Equal(t, "one", resp.Alerts[0].Description)
+		assert.True(t, resp.HasMore)
+		assert.NotEmpty(t, resp.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty page is an empty array", func(t *testing.T) {
+		h, mock := newTestHandler(t)
+		mock.ExpectQuery("SELECT (.+) FROM external_alerts").WillReturnRows(sqlmock.NewRows(alertRowColumns))
+
+		rec := getAlerts(h, "/alerts", nil)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"alerts": [], "has_more": false}`, rec.Body.String())
+	})
+
+	ndjson := []struct {
+		name   string
+		target string
+		accept string
+		want   bool
+	}{
+		{name: "Accept header", target: target, accept: "application/x-ndjson", want: true},
+		{name: "Accept list", target: target, accept: "application/json;q=0.5, application/x-ndjson", want: true},
+		{name: "format parameter", target: target + "&format=ndjson", want: true},
+		{name: "format parameter overrides Accept", target: target + "&format=json", accept: "application/x-ndjson"},
+		{name: "default", target: target, accept: "*/*"},
+	}
+	for _, tt := range ndjson {
+		t.Run("NDJSON via "+tt.name, func(t *testing.T) {
+			h, mock := newTestHandler(t)
+			expectPage(mock)
+
+			rec := getAlerts(h, tt.target, http.Header{"Accept": {tt.accept}})
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			if !tt.want {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+				return
+			}
+			assert.Equal(t, ndjsonContentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, "true", rec.Header().Get("X-Has-More"))
+			assert.NotEmpty(t, rec.Header().Get("X-Next-Cursor"))
+
+			var lines []service.ExternalAlert
+			scanner := bufio.NewScanner(strings.NewReader(rec.Body.String()))
+			for scanner.Scan() {
+				var alert service.ExternalAlert
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &alert))
+				lines = append(lines, alert)
+			}
+			require.Len(t, lines, 1)
+			assert.Equal(t, "one", lines[0].Description)
+		})
+	}
+
+	t.Run("NDJSON last page", func(t *testing.T) {
+		h, mock := newTestHandler(t)
+		mock.ExpectQuery("SELECT (.+) FROM external_alerts").WillReturnRows(sqlmock.NewRows(alertRowColumns))
+
+		rec := getAlerts(h, "/alerts?format=ndjson", nil)
+
+		assert.Equal(t, "false", rec.Header().Get("X-Has-More"))
+		assert.Empty(t, rec.Header().Values("X-Next-Cursor"))
+		assert.Empty(t, rec.Body.String())
+	})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Retry-After formats
+const (
+	RetryAfterSeconds  = "seconds"
+	RetryAfterHTTPDate = "http-date"
+)
+
+// RateLimiter simulates an upstream quota: at most limit requests per fixed
+// window. Responses carry X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (Unix time); rejected requests get a 429 with Retry-After.
+type RateLimiter struct {
+	limit            int
+	window           time.Duration
+	retryAfterFormat string
+	now              func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	count       int
+}
+
+func NewRateLimiter(limit int, window time.Duration, retryAfterFormat string) *RateLimiter {
+	if window <= 0 {
+		window = time.Minute
+	}
+	return &RateLimiter{
+		limit:            limit,
+		window:           window,
+		retryAfterFormat: retryAfterFormat,
+		now:              time.Now,
+	}
+}
+
+// Middleware applies the quota to next. A limit of 0 disables it.
+func (l *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	if l.limit <= 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		remaining, reset := l.take()
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(remaining, 0)))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+		if remaining < 0 {
+			wait := reset.Sub(l.now())
+			if l.retryAfterFormat == RetryAfterHTTPDate {
+				w.Header().Set("Retry-After", reset.UTC().Format(http.TimeFormat))
+			} else {
+				w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+0.999)))
+			}
+			log.Printf("[MOCK API] Rate limit of %d per %s exceeded, retry in %s", l.limit, l.window, wait.Round(time.Second))
+			writeJSON(w, http.StatusTooManyRequests, ErrorResponse{Error: "Rate limit exceeded"})
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// take counts a request and returns the requests left in the window, negative
+// when the quota is exhausted, and when the window resets
+func (l *RateLimiter) take() (int, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.windowStart) >= l.window {
+		l.windowStart = now
+		l.count = 0
+	}
+	l.count++
+	return l.limit - l.count, l.windowStart.Add(l.window)
+}
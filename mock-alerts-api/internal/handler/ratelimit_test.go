@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLimiter returns a limiter on a fake clock and a func to advance it
+func newTestLimiter(limit int, window time.Duration, retryAfterFormat string) (*RateLimiter, func(time.Duration)) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(limit, window, retryAfterFormat)
+	l.now = func() time.Time { return now }
+	return l, func(d time.Duration) { now = now.Add(d) }
+}
+
+func serveLimited(l *RateLimiter) *httptest.ResponseRecorder {
+	handler := l.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/alerts", nil))
+	return rec
+}
+
+func TestRateLimiter_FixedWindow(t *testing.T) {
+	l, advance := newTestLimiter(2, time.Minute, RetryAfterSeconds)
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	reset := strconv.FormatInt(start.Add(time.Minute).Unix(), 10)
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := serveLimited(l)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, remaining, rec.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, reset, rec.Header().Get("X-RateLimit-Reset"))
+		assert.Empty(t, rec.Header().Get("Retry-After"))
+	}
+
+	rec := serveLimited(l)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, reset, rec.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error": "Rate limit exceeded"}`, rec.Body.String())
+
+	// Retry-After rounds the remaining wait up to whole seconds
+	advance(30*time.Second + 200*time.Millisecond)
+	rec = serveLimited(l)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	// The quota is restored in full once the window has passed
+	advance(29*time.Second + 800*time.Millisecond)
+	rec = serveLimited(l)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, strconv.FormatInt(start.Add(2*time.Minute).Unix(), 10), rec.Header().Get("X-RateLimit-Reset"))
+}
+
+func TestRateLimiter_RetryAfterHTTPDate(t *testing.T) {
+	l, advance := newTestLimiter(1, 10*time.Second, RetryAfterHTTPDate)
+
+	assert.Equal(t, http.StatusOK, serveLimited(l).Code)
+	advance(4 * time.Second)
+	rec := serveLimited(l)
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "Mon, 15 Jan 2024 10:00:10 GMT", rec.Header().Get("Retry-After"))
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	l, _ := newTestLimiter(0, time.Minute, RetryAfterSeconds)
+
+	for range 5 {
+		rec := serveLimited(l)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestNewRateLimiter_DefaultWindow(t *testing.T) {
+	assert.Equal(t, time.Minute, NewRateLimiter(10, 0, RetryAfterSeconds).window)
+}
@@ -3,32 +3,14 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
-)
-
-var (
-	// ValidSeverities defines allowed severity levels
-	ValidSeverities = map[string]bool{
-		"low":      true,
-		"medium":   true,
-		"high":     true,
-		"critical": true,
-	}
 
-	// ValidSources defines the 10 allowed alert sources
-	ValidSources = map[string]bool{
-		"siem-1":                true,
-		"siem-2":                true,
-		"firewall":              true,
-		"ids":                   true,
-		"antivirus":             true,
-		"endpoint":              true,
-		"cloud-security":        true,
-		"email-gateway":         true,
-		"network-monitor":       true,
-		"vulnerability-scanner": true,
-	}
+	"alertschema"
 )
 
 // ExternalAlert represents an alert from the third-party system
@@ -49,84 +31,139 @@ func NewAlertGenerator(db *sql.DB) *AlertGenerator {
 	return &AlertGenerator{db: db}
 }
 
-// GetAlertsSince fetches alerts created after the given timestamp
-func (g *AlertGenerator) GetAlertsSince(ctx context.Context, since time.Time) ([]ExternalAlert, error) {
-	query := `
-		SELECT source, severity, description, created_at
-		FROM external_alerts
-		WHERE created_at > $1
-		ORDER BY created_at ASC
-	`
+// Page sizes for GetAlertsPage
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
 
-	rows, err := g.db.QueryContext(ctx, query, since)
-	if err != nil {
-		return nil, fmt.Errorf("error querying external alerts: %w", err)
-	}
-	defer rows.Close()
+// ErrInvalidCursor is returned for a cursor that was not issued by this API
+var ErrInvalidCursor = errors.New("invalid cursor")
 
-	return g.scanAndFilterAlerts(rows)
+// AlertPage is one page of alerts in created_at order. NextCursor continues
+// after the last row of the page and is empty when HasMore is false.
+type AlertPage struct {
+	Alerts     []ExternalAlert
+	NextCursor string
+	HasMore    bool
 }
 
-// GetAllAlerts fetches all alerts (limited to 100)
-func (g *AlertGenerator) GetAllAlerts(ctx context.Context) ([]ExternalAlert, error) {
+// GetAlertsPage fetches up to limit alerts in created_at order, after the
+// cursor when one is given, otherwise created after since (when non-zero).
+// A non-zero until leaves out alerts created after it. Pages are keyed on
+// (created_at, id) so rows sharing a timestamp are neither skipped nor
+// repeated across pages.
+func (g *AlertGenerator) GetAlertsPage(ctx context.Context, since, until time.Time, cursor string, limit int) (AlertPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	untilClause := ""
+	if !until.IsZero() {
+		untilClause = " AND created_at <= $5"
+	}
 	query := `
-		SELECT source, severity, description, created_at
+		SELECT id, source, severity, description, created_at
 		FROM external_alerts
-		ORDER BY created_at DESC
-		LIMIT 100
+		WHERE created_at > $1 AND (created_at, id) > ($2, $3)` + untilClause + `
+		ORDER BY created_at ASC, id ASC
+		LIMIT $4
 	`
 
-	rows, err := g.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("error querying all external alerts: %w", err)
+	// Without a cursor the keyset condition starts before every row
+	afterTime, afterID := time.Time{}, 0
+	if cursor != "" {
+		var err error
+		afterTime, afterID, err = decodeCursor(cursor)
+		if err != nil {
+			return AlertPage{}, err
+		}
 	}
-	defer rows.Close()
 
-	return g.scanAndFilterAlerts(rows)
-}
+	args := []interface{}{since, afterTime, afterID, limit + 1}
+	if !until.IsZero() {
+		args = append(args, until)
+	}
 
-func (g *AlertGenerator) scanAndFilterAlerts(rows *sql.Rows) ([]ExternalAlert, error) {
-	var alerts []ExternalAlert
+	rows, err := g.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return AlertPage{}, fmt.Errorf("error querying external alerts: %w", err)
+	}
+	defer rows.Close()
 
+	var page AlertPage
+	var lastTime time.Time
+	var lastID, scanned int
 	for rows.Next() {
+		scanned++
+		if scanned > limit {
+			page.HasMore = true
+			break
+		}
+
+		var id int
 		var alert ExternalAlert
-		err := rows.Scan(
-			&alert.Source,
-			&alert.Severity,
-			&alert.Description,
-			&alert.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("error scanning alert: %w", err)
+		if err := rows.Scan(&id, &alert.Source, &alert.Severity, &alert.Description, &alert.CreatedAt); err != nil {
+			return AlertPage{}, fmt.Errorf("error scanning alert: %w", err)
 		}
+		lastTime, lastID = alert.CreatedAt, id
 
 		// Only include alerts with valid source and severity
 		if IsValidSource(alert.Source) && IsValidSeverity(alert.Severity) {
-			alerts = append(alerts, alert)
+			page.Alerts = append(page.Alerts, alert)
 		}
 	}
-
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating alerts: %w", err)
+		return AlertPage{}, fmt.Errorf("error iterating alerts: %w", err)
 	}
 
-	return alerts, nil
+	if page.HasMore {
+		page.NextCursor = encodeCursor(lastTime, lastID)
+	}
+	return page, nil
+}
+
+// encodeCursor makes an opaque cursor for the row (createdAt, id)
+func encodeCursor(createdAt time.Time, id int) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.Itoa(id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	ts, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return createdAt, id, nil
 }
 
 // IsValidSeverity checks if severity is valid
 func IsValidSeverity(severity string) bool {
-	return ValidSeverities[severity]
+	return alertschema.IsValidSeverity(severity)
 }
 
 // IsValidSource checks if source is valid
 func IsValidSource(source string) bool {
-	return ValidSources[source]
+	return alertschema.IsValidSource(source)
 }
 
 // GetValidSources returns list of valid sources
 func GetValidSources() []string {
-	sources := make([]string, 0, len(ValidSources))
-	for s := range ValidSources {
+	sources := make([]string, 0, len(alertschema.ValidSources))
+	for s := range alertschema.ValidSources {
 		sources = append(sources, s)
 	}
 	return sources
@@ -134,8 +171,8 @@ func GetValidSources() []string {
 
 // GetValidSeverities returns list of valid severities
 func GetValidSeverities() []string {
-	severities := make([]string, 0, len(ValidSeverities))
-	for s := range ValidSeverities {
+	severities := make([]string, 0, len(alertschema.ValidSeverities))
+	for s := range alertschema.ValidSeverities {
 		severities = append(severities, s)
 	}
 	return severities
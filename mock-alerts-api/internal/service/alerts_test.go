@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var alertRowColumns = []string{"id", "source", "severity", "description", "created_at"}
+
+func setupMockDB(t *testing.T) (*AlertGenerator, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewAlertGenerator(db), mock
+}
+
+func TestCursor(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		createdAt := time.Date(2024, 1, 15, 10, 0, 0, 123456000, time.FixedZone("EST", -5*3600))
+
+		gotTime, gotID, err := decodeCursor(encodeCursor(createdAt, 42))
+
+		require.NoError(t, err)
+		assert.True(t, createdAt.Equal(gotTime))
+		assert.Equal(t, time.UTC, gotTime.Location())
+		assert.Equal(t, 42, gotID)
+	})
+
+	t.Run("is opaque and URL safe", func(t *testing.T) {
+		cursor := encodeCursor(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), 7)
+
+		assert.NotContains(t, cursor, "|")
+		assert.NotContains(t, cursor, "=")
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		require.NoError(t, err)
+		assert.Equal(t, "2024-01-15T10:00:00Z|7", string(raw))
+	})
+
+	t.Run("tampered cursors are rejected", func(t *testing.T) {
+		encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+		for name, cursor := range map[string]string{
+			"not base64":     "not base64!",
+			"padded base64":  base64.URLEncoding.EncodeToString([]byte("2024-01-15T10:00:00Z|7x")),
+			"no separator":   encode("2024-01-15T10:00:00Z"),
+			"bad timestamp":  encode("yesterday|7"),
+			"bad id":         encode("2024-01-15T10:00:00Z|seven"),
+			"empty id":       encode("2024-01-15T10:00:00Z|"),
+			"truncated time": encode("2024-01-15|7"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, _, err := decodeCursor(cursor)
+				assert.ErrorIs(t, err, ErrInvalidCursor)
+			})
+		}
+	})
+}
+
+func TestAlertGenerator_GetAlertsPage(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	t.Run("first page without bounds", func(t *testing.T) {
+		gen, mock := setupMockDB(t)
+
+		rows := sqlmock.NewRows(alertRowColumns).
+			AddRow(1, "firewall", "high", "one", base).
+			AddRow(2, "ids", "low", "two", base.Add(time.Minute))
+		mock.ExpectQuery("WHERE created_at > \\$1 AND \\(created_at, id\\) > \\(\\$2, \\$3\\)\\s+ORDER BY created_at ASC, id ASC").
+			WithArgs(time.Time{}, time.Time{}, 0, DefaultPageSize+1).
+			WillReturnRows(rows)
+
+		page, err := gen.GetAlertsPage(ctx, time.Time{}, time.Time{}, "", 0)
+
+		require.NoError(t, err)
+		assert.Len(t, page.Alerts, 2)
+		assert.False(t, page.HasMore)
+		assert.Empty(t, page.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cursor continues after the last row, breaking created_at ties by id", func(t *testing.T) {
+		gen, mock := setupMockDB(t)
+
+		// Rows 3 and 4 share a created_at; the page ends between them
+		mock.ExpectQuery("SELECT (.+) FROM external_alerts").
+			WithArgs(time.Time{}, time.Time{}, 0, 3).
+			WillReturnRows(sqlmock.NewRows(alertRowColumns).
+				AddRow(1, "firewall", "high", "one", base).
+				AddRow(3, "firewall", "high", "three", base.Add(time.Minute)).
+				AddRow(4, "firewall", "high", "four", base.Add(time.Minute)))
+
+		first, err := gen.GetAlertsPage(ctx, time.Time{}, time.Time{}, "", 2)
+		require.NoError(t, err)
+		assert.True(t, first.HasMore)
+		require.Len(t, first.Alerts, 2)
+		assert.Equal(t, "three", first.Alerts[1].Description)
+
+		afterTime, afterID, err := decodeCursor(first.NextCursor)
+		require.NoError(t, err)
+		assert.True(t, base.Add(time.Minute).Equal(afterTime))
+		assert.Equal(t, 3, afterID)
+
+		mock.ExpectQuery("SELECT (.+) FROM external_alerts").
+			WithArgs(time.Time{}, afterTime, 3, 3).
+			WillReturnRows(sqlmock.NewRows(alertRowColumns).
+				AddRow(4, "firewall", "high", "four", base.Add(time.Minute)))
+
+		second, err := gen.GetAlertsPage(ctx, time.Time{}, time.Time{}, first.NextCursor, 2)
+		require.NoError(t, err)
+		assert.False(t, second.HasMore)
+		require.Len(t, second.Alerts, 1)
+		assert.Equal(t, "four", second.Alerts[0].Description)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid rows are skipped but still advance the cursor", func(t *testing.T) {
+		gen, mock := setupMockDB(t)
+
+		mock.ExpectQuery("SELECT (.+) FROM external_alerts").
+			WithArgs(time.Time{}, time.Time{}, 0, 3).
+			WillReturnRows(sqlmock.NewRows(alertRowColumns).
+				AddRow(1, "firewall", "high", "one", base).
+				AddRow(2, "toaster", "urgent", "invalid", base.Add(time.Minute)).
+				AddRow(3, "firewall", "high", "three", base.Add(2*time.Minute)))
+
+		page, err := gen.GetAlertsPage(ctx, time.Time{}, time.Time{}, "", 2)
+
+		require.NoError(t, err)
+		assert.Len(t, page.Alerts, 1)
+		_, afterID, err := decodeCursor(page.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, 2, afterID)
+	})
+
+	t.Run("since and until bound the range", func(t *testing.T) {
+		gen, mock := setupMockDB(t)
+		until := base.Add(time.Hour)
+
+		mock.ExpectQuery("WHERE created_at > \\$1 AND \\(created_at, id\\) > \\(\\$2, \\$3\\) AND created_at <= \\$5").
+			WithArgs(base, time.Time{}, 0, 11, until).
+			WillReturnRows(sqlmock.NewRows(alertRowColumns))
+
+		page, err := gen.GetAlertsPage(ctx, base, until, "", 10)
+
+		require.NoError(t, err)
+		assert.Empty(t, page.Alerts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("limit above the maximum is clamped", func(t *testing.T) {
+		gen, mock := setupMockDB(t)
+
+		mock.ExpectQuery("SELECT (.+) FROM external_alerts").
+			WithArgs(time.Time{}, time.Time{}, 0, MaxPageSize+1).
+			WillReturnRows(sqlmock.NewRows(alertRowColumns))
+
+		_, err := gen.GetAlertsPage(ctx, time.Time{}, time.Time{}, "", 5000)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("tampered cursor", func(t *testing.T) {
+		gen, mock := setupMockDB(t)
+
+		_, err := gen.GetAlertsPage(ctx, time.Time{}, time.Time{}, "bogus!", 10)
+
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		assert.NoError(t, mock.ExpectationsWereMet(), "no query is run")
+	})
+}
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS external_alerts (
+                                               id SERIAL PRIMARY KEY,
+                                               source VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_severity CHECK (severity IN ('low', 'medium', 'high', 'critical')),
+    CONSTRAINT chk_source CHECK (source IN ('siem-1', 'siem-2', 'firewall', 'ids', 'antivirus', 'endpoint', 'cloud-security', 'email-gateway', 'network-monitor', 'vulnerability-scanner'))
+    );
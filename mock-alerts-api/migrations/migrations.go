@@ -0,0 +1,9 @@
+// Package migrations embeds the mock API's schema migrations and sample data
+package migrations
+
+import "embed"
+
+// FS holds the NNN_name.up.sql and NNN_name.down.sql migrations and seed.sql
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,17 @@
+-- Sample upstream alerts served by GET /alerts; applied by "migrate seed" or SEED_SAMPLE_DATA=true.
+-- Only inserted into an empty external_alerts table, so seeding again does nothing.
+INSERT INTO external_alerts (source, severity, description, created_at)
+SELECT source, severity, description, created_at
+FROM (VALUES
+    ('siem-1', 'high', 'Suspicious login attempt detected', NOW() - INTERVAL '1 hour'),
+    ('firewall', 'critical', 'Multiple blocked intrusion attempts', NOW() - INTERVAL '2 hours'),
+    ('ids', 'medium', 'Unusual network traffic pattern', NOW() - INTERVAL '3 hours'),
+    ('antivirus', 'low', 'Potentially unwanted program detected', NOW() - INTERVAL '4 hours'),
+    ('endpoint', 'high', 'Unauthorized software installation', NOW() - INTERVAL '5 hours'),
+    ('cloud-security', 'critical', 'Exposed S3 bucket detected', NOW() - INTERVAL '6 hours'),
+    ('email-gateway', 'medium', 'Phishing email blocked', NOW() - INTERVAL '7 hours'),
+    ('network-monitor', 'low', 'High bandwidth usage detected', NOW() - INTERVAL '8 hours'),
+    ('vulnerability-scanner', 'high', 'Critical CVE found in production', NOW() - INTERVAL '9 hours'),
+    ('siem-2', 'medium', 'Failed authentication attempts', NOW() - INTERVAL '10 hours')
+) AS sample (source, severity, description, created_at)
+WHERE NOT EXISTS (SELECT 1 FROM external_alerts);